@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/ONSdigital/dis-search-test-bed/shared/audit"
+	"github.com/ONSdigital/dis-search-test-bed/shared/comparison"
+	"github.com/ONSdigital/dis-search-test-bed/shared/paths"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+var auditWith string
+
+var auditCmd = &cobra.Command{
+	Use:   "audit <run_folder>",
+	Short: "Check a run folder's reports for internal consistency",
+	Long: `Audit recomputes all statistics from the raw results in a run folder and
+checks them against the CSV, manifest and comparison reports. It flags count
+mismatches, rank gaps and duplicate URIs, and exits non-zero if any
+inconsistencies are found.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAudit,
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+
+	auditCmd.Flags().StringVar(&auditWith, "with", "",
+		"Results file the historical comparison was made against (defaults to the one compare recorded, else the baseline, else the preceding run)")
+}
+
+func runAudit(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	matcher, err := comparison.NewMatcher(cfg.Comparison.Matcher)
+	if err != nil {
+		return fmt.Errorf("invalid comparison matcher: %w", err)
+	}
+
+	printer := ui.NewPrinter(verbose)
+	runFolder := args[0]
+
+	printer.Info("Auditing run folder: %s", runFolder)
+
+	auditor := audit.NewAuditor(runFolder, auditWith)
+	auditor.SetMatcher(matcher)
+	auditor.SetLabels(comparisonLabels(cfg.Comparison.Labels))
+	auditor.SetLayout(runLayout(cfg))
+	if baseline, err := paths.ResolvePointer(cfg.Output.BaseDir, paths.BaselinePointer); err == nil {
+		auditor.SetBaseline(filepath.Join(baseline, "results.json"))
+	}
+
+	report, err := auditor.Run()
+	if err != nil {
+		return fmt.Errorf("failed to audit run: %w", err)
+	}
+
+	printer.Section("Audit Results")
+	printer.Info("Queries: %d | Results: %d", report.QueriesCount, report.ResultsCount)
+	for _, file := range report.FilesChecked {
+		printer.Debug("Checked %s", file)
+	}
+	if report.PreviousPath != "" {
+		printer.Info("Historical stats recomputed against: %s", report.PreviousPath)
+	}
+	for _, skipped := range report.SkippedChecks {
+		printer.Info("Skipped: %s", skipped)
+	}
+
+	errorCount := 0
+	for _, issue := range report.Issues {
+		if issue.Severity == audit.SeverityError {
+			errorCount++
+			printer.Error("[%s] %s", issue.Source, issue.Message)
+		} else {
+			printer.Warning("[%s] %s", issue.Source, issue.Message)
+		}
+	}
+
+	if report.HasErrors() {
+		return fmt.Errorf("audit found %d inconsistencies", errorCount)
+	}
+
+	if len(report.Issues) > 0 {
+		printer.Success("No inconsistencies found (%d warnings)", len(report.Issues))
+	} else {
+		printer.Success("No inconsistencies found")
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ONSdigital/dis-search-test-bed/shared/analytics"
+	"github.com/ONSdigital/dis-search-test-bed/shared/metrics"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	baselineRun string
+	baselineK   int
+)
+
+var baselineCmd = &cobra.Command{
+	Use:   "baseline <clicks.csv>",
+	Short: "Measure agreement with a click-popularity baseline",
+	Long: `Baseline builds an "oracle" ranking for each query from a click log, ordering
+pages purely by how often they were clicked, and measures how closely each
+algorithm's results agree with it: the share of the oracle's top K found in the
+algorithm's top K, and rank-biased overlap (RBO), which weights agreement near
+the top more heavily. Only queries that appear in the click log are scored.
+
+This is a cheap sanity check before a full judgment effort, not a relevance
+measure: clicks favour whatever already ranks well.
+
+The set and show subcommands pin and print the baseline run compare
+measures against.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBaseline,
+}
+
+func init() {
+	rootCmd.AddCommand(baselineCmd)
+
+	baselineCmd.Flags().StringVar(&baselineRun, "run", "",
+		"Run to score (folder, folder name or results file; defaults to latest)")
+	baselineCmd.Flags().IntVarP(&baselineK, "k", "k", metrics.DefaultK,
+		"Rank cut-off for agreement measures")
+}
+
+func runBaseline(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	printer := ui.NewPrinter(verbose)
+
+	resultsPath, err := runLayout(cfg).ResolveResults(cfg.Output.BaseDir, baselineRun)
+	if err != nil {
+		return fmt.Errorf("failed to find results: %w", err)
+	}
+	results, err := output.LoadResults(resultsPath)
+	if err != nil {
+		return fmt.Errorf("failed to load results: %w", err)
+	}
+
+	logPath := args[0]
+	f, err := os.Open(logPath)
+	if err != nil {
+		return fmt.Errorf("failed to open click log: %w", err)
+	}
+	defer f.Close()
+
+	clicks, err := analytics.NewImporter(analytics.Options{Source: filepath.Base(logPath)}).ImportClicks(f)
+	if err != nil {
+		return fmt.Errorf("failed to import %s: %w", logPath, err)
+	}
+
+	oracle := analytics.NewOracle(clicks)
+	agreements := oracle.Compare(results, baselineK)
+	if len(agreements) == 0 {
+		return fmt.Errorf("none of the queries in %s appear in the click log", resultsPath)
+	}
+
+	printer.Info("Results: %s", resultsPath)
+	printer.Info("Click baseline: %d queries from %s", len(oracle), logPath)
+	fmt.Println()
+
+	table := ui.NewTable("ALGORITHM", "QUERIES", fmt.Sprintf("OVERLAP@%d", baselineK), "RBO")
+	for _, a := range agreements {
+		table.AddRow(
+			a.Algorithm,
+			strconv.Itoa(a.Queries),
+			fmt.Sprintf("%.2f", a.Overlap),
+			fmt.Sprintf("%.3f", a.RBO),
+		)
+	}
+	if err := table.Print(); err != nil {
+		return fmt.Errorf("failed to print agreement: %w", err)
+	}
+
+	runLock, err := openRunFolder(cfg, filepath.Dir(resultsPath), printer)
+	if err != nil {
+		return err
+	}
+	defer releaseRunFolder(runLock)
+	if noWrite {
+		return nil
+	}
+
+	path := filepath.Join(filepath.Dir(resultsPath), output.BaselineFileName)
+	if err := output.WriteJSONFile(path, agreements); err != nil {
+		return fmt.Errorf("failed to save baseline agreement: %w", err)
+	}
+
+	fmt.Println()
+	printer.Info("Location: %s", path)
+	return nil
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/shared/paths"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+var baselineSetCmd = &cobra.Command{
+	Use:   "set <run>",
+	Short: "Pin a run as the baseline compare measures against",
+	Long: `Set points the baseline pointer in output.base_dir at a run: a run folder,
+folder name, results file, latest, previous or name:<label>. From then on
+compare measures the current run against it rather than the run before,
+so every experiment is judged against the same agreed reference.
+
+Pass --with previous to compare to measure against the run before instead.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireWritable(); err != nil {
+			return err
+		}
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		return setBaseline(cfg, args[0], ui.NewPrinter(verbose))
+	},
+}
+
+var baselineShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the pinned baseline run",
+	Args:  cobra.NoArgs,
+	RunE:  runBaselineShow,
+}
+
+func init() {
+	baselineCmd.AddCommand(baselineSetCmd)
+	baselineCmd.AddCommand(baselineShowCmd)
+}
+
+func runBaselineShow(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	printer := ui.NewPrinter(verbose)
+	layout := runLayout(cfg)
+
+	runFolder, err := paths.ResolvePointer(cfg.Output.BaseDir, paths.BaselinePointer)
+	if errors.Is(err, paths.ErrNoPointer) {
+		printer.Info("No baseline is pinned; compare uses the run before the current one")
+		printer.Info("Pin one with: search-testbed baseline set <run>")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find baseline: %w", err)
+	}
+
+	results, err := output.LoadResults(filepath.Join(runFolder, "results.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load baseline results: %w", err)
+	}
+	return showRunOverview(cfg, layout, runFolder, results, printer)
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/user"
+	"path/filepath"
+
+	"github.com/ONSdigital/dis-search-test-bed/config"
+	"github.com/ONSdigital/dis-search-test-bed/shared/clock"
+	"github.com/ONSdigital/dis-search-test-bed/shared/paths"
+	"github.com/ONSdigital/dis-search-test-bed/shared/remote"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+var baselineRemote string
+
+var baselinePushCmd = &cobra.Command{
+	Use:   "push [run]",
+	Short: "Publish a run as the team's baseline",
+	Long: `Push uploads a run folder to the shared baseline remote and makes it the
+published baseline, so every machine and CI job can pull the same approved
+run. The run defaults to the locally pinned baseline; any run compare
+accepts can be given instead.
+
+The remote is baseline.remote in the config, TESTBED_BASELINE_REMOTE or
+--remote: a directory (e.g. a network share), a file:// URL, an http(s)://
+URL taking PUT and GET such as an Artifactory generic repository, or an
+s3://bucket/prefix URL, which is written with the aws CLI and its usual
+credentials. An http(s) remote is sent the bearer token named by
+baseline.token_env or baseline.token_file.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runBaselinePush,
+}
+
+var baselinePullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Fetch the team's published baseline and pin it",
+	Long: `Pull downloads the published baseline run from the shared baseline remote
+into output.base_dir, checks it against the hash recorded when it was
+pushed, and pins it as the local baseline that compare measures against.
+A run already present locally is not downloaded again.`,
+	Args: cobra.NoArgs,
+	RunE: runBaselinePull,
+}
+
+func init() {
+	baselineCmd.AddCommand(baselinePushCmd)
+	baselineCmd.AddCommand(baselinePullCmd)
+
+	for _, cmd := range []*cobra.Command{baselinePushCmd, baselinePullCmd} {
+		cmd.Flags().StringVar(&baselineRemote, "remote", "",
+			"Shared baseline location (defaults to baseline.remote in the config)")
+	}
+}
+
+func runBaselinePush(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	store, err := openBaselineRemote(cfg)
+	if err != nil {
+		return err
+	}
+
+	printer := ui.NewPrinter(verbose)
+	run := paths.BaselinePointer
+	if len(args) > 0 {
+		run = args[0]
+	}
+	resultsPath, err := runLayout(cfg).ResolveResults(cfg.Output.BaseDir, run)
+	if errors.Is(err, paths.ErrNoPointer) {
+		return errors.New("no baseline is pinned; give the run to push or pin one with: search-testbed baseline set <run>")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find run: %w", err)
+	}
+	runFolder := filepath.Dir(resultsPath)
+
+	spinner := ui.NewSpinner(fmt.Sprintf("Pushing %s to %s...", paths.RunID(runFolder), store))
+	spinner.Start()
+	pointer, pushed, err := remote.Push(context.Background(), store, runFolder, currentUser(), clock.Real{}.Now())
+	spinner.Stop()
+	if err != nil {
+		return fmt.Errorf("failed to push baseline: %w", err)
+	}
+
+	if !pushed {
+		printer.Info("%s is already the published baseline at %s", pointer.RunID, store)
+		return nil
+	}
+	printer.Success("Published %s as the baseline at %s", pointer.RunID, store)
+	return nil
+}
+
+func runBaselinePull(cmd *cobra.Command, args []string) error {
+	if err := requireWritable(); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	store, err := openBaselineRemote(cfg)
+	if err != nil {
+		return err
+	}
+
+	printer := ui.NewPrinter(verbose)
+	spinner := ui.NewSpinner(fmt.Sprintf("Pulling the baseline from %s...", store))
+	spinner.Start()
+	pointer, runFolder, downloaded, err := remote.Pull(context.Background(), store, cfg.Output.BaseDir)
+	spinner.Stop()
+	if errors.Is(err, remote.ErrNotFound) {
+		return fmt.Errorf("no baseline has been pushed to %s", store)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to pull baseline: %w", err)
+	}
+
+	if downloaded {
+		printer.Success("Downloaded %s", pointer.RunID)
+	} else {
+		printer.Info("%s is already in %s", pointer.RunID, cfg.Output.BaseDir)
+	}
+	if pointer.PushedBy != "" {
+		printer.Info("Pushed by %s at %s", pointer.PushedBy, pointer.PushedAt.Format("2006-01-02 15:04:05 MST"))
+	} else {
+		printer.Info("Pushed at %s", pointer.PushedAt.Format("2006-01-02 15:04:05 MST"))
+	}
+	return setBaseline(cfg, runFolder, printer)
+}
+
+// openBaselineRemote opens the shared baseline location from --remote or
+// the config
+func openBaselineRemote(cfg *config.Config) (remote.Store, error) {
+	location := cfg.Baseline.Remote
+	if baselineRemote != "" {
+		location = baselineRemote
+	}
+	if location == "" {
+		return nil, errors.New("no baseline remote is configured; set baseline.remote or pass --remote")
+	}
+
+	token, err := cfg.Baseline.Token()
+	if err != nil {
+		return nil, err
+	}
+	store, err := remote.Open(location, remote.Options{Token: token})
+	if err != nil {
+		return nil, fmt.Errorf("invalid baseline remote: %w", err)
+	}
+	return store, nil
+}
+
+// currentUser names who pushed a baseline, or "" when it can't be told
+func currentUser() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	return u.Username
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+)
+
+// maxPrintedFailures caps the rejected documents listed after a bulk load
+const maxPrintedFailures = 10
+
+// withBulkProgress returns a context whose bulk loads advance the spinner
+// message and the bulk progress events after each chunk
+func withBulkProgress(ctx context.Context, spinner *ui.Spinner, message string, total int) (context.Context, *ui.Progress) {
+	progress := ui.NewProgress(phaseBulk, total)
+	return elasticsearch.WithBulkProgress(ctx, func(indexed, total int) {
+		spinner.UpdateMessage(fmt.Sprintf("%s %d/%d", message, indexed, total))
+		progress.Set(indexed)
+	}), progress
+}
+
+// reportBulkFailures lists the documents a bulk load rejected, when err
+// is a *elasticsearch.BulkError
+func reportBulkFailures(err error, printer *ui.Printer) {
+	var bulkErr *elasticsearch.BulkError
+	if !errors.As(err, &bulkErr) {
+		return
+	}
+	printer.Error("%d of %d documents were rejected:", len(bulkErr.Failures), bulkErr.Total)
+	for _, f := range bulkErr.Failures[:min(len(bulkErr.Failures), maxPrintedFailures)] {
+		printer.Error("  %s (%d)", f, f.Status)
+	}
+	if len(bulkErr.Failures) > maxPrintedFailures {
+		printer.Error("  and %d more", len(bulkErr.Failures)-maxPrintedFailures)
+	}
+}
@@ -1,38 +1,122 @@
 package cmd
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/ONSdigital/dis-search-test-bed/config"
 	"github.com/ONSdigital/dis-search-test-bed/models"
 	"github.com/ONSdigital/dis-search-test-bed/shared/comparison"
+	"github.com/ONSdigital/dis-search-test-bed/shared/golden"
+	"github.com/ONSdigital/dis-search-test-bed/shared/indexgen"
 	"github.com/ONSdigital/dis-search-test-bed/shared/output"
 	"github.com/ONSdigital/dis-search-test-bed/shared/paths"
+	"github.com/ONSdigital/dis-search-test-bed/shared/preview"
 	"github.com/ONSdigital/dis-search-test-bed/ui"
 	"github.com/spf13/cobra"
 )
 
+// stdio stands for stdin or stdout in place of a file path
+const stdio = "-"
+
 var (
-	compareWith string
-	compareMode string
+	compareCurrent   string
+	compareWith      string
+	compareFrom      string
+	compareTo        string
+	compareOut       string
+	compareMode      string
+	comparePreviews  bool
+	compareFormats   []string
+	compareJudgments string
+	compareStopAfter int
+	compareFailOn    string
+	compareGolden    string
+	updateGoldenFile bool
+
+	allowMissingBaseline bool
 )
 
+// errGateFailed is returned when the comparison breaks the regression gate
+var errGateFailed = errors.New("regression gate failed")
+
 var compareCmd = &cobra.Command{
 	Use:   "compare",
 	Short: "Compare query results",
 	Long: `Compare query results between different runs or between queries 
-within the same run.`,
+within the same run.
+
+Results are read from the latest run and compared with the baseline pinned
+by baseline set (or promote), or the run before the latest when no baseline
+is pinned, unless --current or --with name a results file. Reports are saved
+in the current run's folder unless --out names another directory. Pass - to
+any of them to read results from stdin or write reports to stdout, so
+compare can sit in a shell pipeline; status messages then go to stderr.
+Results read from stdin are compared with the baseline, or the latest run
+when none is pinned. --current and --with also accept latest and baseline,
+the runs those pointers in output.base_dir point at, previous, and
+name:<label> for the newest run given that --label.
+
+To compare any two runs, older ones included, name both sides with --from
+(the earlier run) and --to (the later one). Each takes a run folder name
+under output.base_dir, a path to a run folder or results file, latest,
+previous, baseline or name:<label>. --to alone compares a run with the
+baseline, or the one before it. Reports are saved in the --to run's folder.
+
+--fail-on makes compare a CI quality gate: it exits non-zero, after writing
+the reports, when the historical comparison breaks any of the given rules.
+Rules are comma-separated metric>limit (or >=) pairs over the whole suite,
+e.g. "removed>5,worsened>10,ndcg-drop>0.05". Metrics: removed, worsened and
+new results, regressions (queries beyond the per-query thresholds),
+ndcg-drop (fall in mean NDCG, needs judgments), watchlist-drops, flagged
+(top results carrying a warn flag) and missing (queries in the previous run
+only). With nothing to compare against the gate can't be checked, so
+compare fails unless --allow-missing-baseline lets a first run pass.
+
+--golden compares with a golden results file checked into version control
+instead of an earlier run, and exits non-zero unless every query returns
+the same results in the same order. --update-golden writes the current
+results to that file instead, to accept a change in search behaviour. The
+file keeps only ranks, documents and rounded scores, so it diffs cleanly.`,
 	RunE: runCompare,
 }
 
 func init() {
 	rootCmd.AddCommand(compareCmd)
 
+	compareCmd.Flags().StringVar(&compareCurrent, "current", "",
+		"Results file to compare, latest, previous, baseline or name:<label>, or - for stdin (defaults to latest run)")
 	compareCmd.Flags().StringVar(&compareWith, "with", "",
-		"Previous results file to compare against (defaults to previous run)")
+		"Previous results file to compare against, latest, previous, baseline or name:<label>, or - for stdin (defaults to the baseline, else the previous run)")
+	compareCmd.Flags().StringVar(&compareFrom, "from", "",
+		"Earlier run to compare from: run folder name or path, results file, latest, previous, baseline or name:<label>")
+	compareCmd.Flags().StringVar(&compareTo, "to", "",
+		"Later run to compare to: run folder name or path, results file, latest, previous, baseline or name:<label>")
+	compareCmd.Flags().StringVar(&compareOut, "out", "",
+		"Directory to save reports in, or - for stdout (defaults to the current run folder)")
 	compareCmd.Flags().StringVar(&compareMode, "mode", "both",
-		"Comparison mode: historical, cross-query, or both")
+		"Comparison mode: historical, cross-query, both or score-drift")
+	compareCmd.Flags().BoolVar(&comparePreviews, "previews", false,
+		"Include body previews and query-term hits from the run's index.json")
+	compareCmd.Flags().StringSliceVar(&compareFormats, "format", nil,
+		"Report formats to write: text, markdown, json, junit, html (defaults to output.report_formats)")
+	addParquetFlag(compareCmd)
+	compareCmd.Flags().StringVar(&compareJudgments, "judgments", "",
+		"Judgments file (JSON or TREC qrels) to score NDCG in the historical report (defaults to comparison.judgments_file)")
+	compareCmd.Flags().IntVar(&compareStopAfter, "stop-after", -1,
+		"Drop per-query report detail after this many regressions, 0 for never (defaults to comparison.thresholds.stop_after)")
+	compareCmd.Flags().StringVar(&compareFailOn, "fail-on", "",
+		`Exit non-zero when the comparison breaks any rule, e.g. "removed>5,ndcg-drop>0.05" (defaults to comparison.fail_on)`)
+	compareCmd.Flags().BoolVar(&allowMissingBaseline, "allow-missing-baseline", false,
+		"Pass the --fail-on gate when there is no earlier run to compare with, e.g. on the first run")
+	compareCmd.Flags().StringVar(&compareGolden, "golden", "",
+		"Golden results file to compare against; exits non-zero if any query's results differ")
+	compareCmd.Flags().BoolVar(&updateGoldenFile, "update-golden", false,
+		"Write the current results to the --golden file instead of comparing")
 }
 
 func runCompare(cmd *cobra.Command, args []string) error {
@@ -41,113 +125,694 @@ func runCompare(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if err := checkCompareFlags(); err != nil {
+		return err
+	}
+	mode := parseComparisonMode(compareMode)
+	gate, err := applyCompareFlags(cmd, cfg, mode)
+	if err != nil {
+		return err
+	}
+	if compareCurrent == stdio || compareOut == stdio {
+		ui.SetOutput(os.Stderr)
+	}
+
 	printer := ui.NewPrinter(verbose)
 
-	// Load current results
-	currentPath, err := paths.FindLatestResults(cfg.Output.BaseDir)
+	if err := resolveCompareRuns(cfg, printer); err != nil {
+		return err
+	}
+
+	currentPath, err := findCurrentResults(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to find current results: %w", err)
 	}
+	printer.Info("Current results: %s", describeResultsPath(currentPath))
 
-	printer.Info("Current results: %s", currentPath)
-
-	current, err := output.LoadResults(currentPath)
+	current, err := readResults(currentPath)
 	if err != nil {
 		return fmt.Errorf("failed to load current results: %w", err)
 	}
 
-	var previous []models.QueryResults
-	mode := parseComparisonMode(compareMode)
-	runFolder := filepath.Dir(currentPath)
-
-	// Load previous results if needed
-	if mode == comparison.ModeHistorical || mode == comparison.ModeBoth {
-		if compareWith == "" {
-			prevPath, err := paths.FindPreviousResults(cfg.Output.BaseDir, currentPath)
-			if err != nil {
-				printer.Warning("No previous results found, skipping historical comparison")
-				if mode == comparison.ModeHistorical {
-					return fmt.Errorf("historical comparison requested but no previous results found")
-				}
-				mode = comparison.ModeCrossQuery
-			} else {
-				compareWith = prevPath
-			}
+	if updateGoldenFile {
+		return updateGolden(compareGolden, current, printer)
+	}
+	goldenResults, err := loadGolden()
+	if err != nil {
+		return err
+	}
+
+	runFolder, reportDir := reportDestination(cfg, currentPath)
+	if runFolder != "" {
+		runLock, err := openRunFolder(cfg, runFolder, printer)
+		if err != nil {
+			return err
 		}
+		defer releaseRunFolder(runLock)
+	}
 
-		if compareWith != "" {
-			printer.Info("Comparing with: %s", compareWith)
-			previous, err = output.LoadResults(compareWith)
-			if err != nil {
-				return fmt.Errorf("failed to load previous results: %w", err)
-			}
+	previous, mode, err := loadPreviousResults(cfg, mode, currentPath, runFolder, goldenResults, len(gate) > 0, printer)
+	if err != nil {
+		return err
+	}
+
+	endPhase := phases.Start(phaseReports)
+	err = generateComparisons(mode, current, previous, runFolder, reportDir, cfg, printer)
+	endPhase()
+	if err != nil && !errors.Is(err, errGateFailed) {
+		return err
+	}
+	if recordErr := recordComparedWith(mode, runFolder, previous); recordErr != nil {
+		return recordErr
+	}
+	if timingErr := reportTimings("compare", runFolder, printer); timingErr != nil {
+		return timingErr
+	}
+	if compareGolden != "" {
+		if goldenErr := checkGolden(cfg, compareGolden, goldenResults, current, printer); goldenErr != nil {
+			return errors.Join(err, goldenErr)
+		}
+	}
+	return err
+}
+
+// checkCompareFlags rejects flags that name the same results twice or
+// can't be used together
+func checkCompareFlags() error {
+	if compareFrom != "" && compareWith != "" {
+		return fmt.Errorf("--from and --with both name the earlier run; use one")
+	}
+	if compareTo != "" && compareCurrent != "" {
+		return fmt.Errorf("--to and --current both name the later run; use one")
+	}
+	if compareGolden != "" && (compareWith != "" || compareFrom != "") {
+		return fmt.Errorf("--golden names the results to compare with; don't combine it with --with or --from")
+	}
+	if updateGoldenFile && compareGolden == "" {
+		return fmt.Errorf("--update-golden needs --golden to name the file to write")
+	}
+	if compareCurrent == stdio && compareWith == stdio {
+		return fmt.Errorf("only one of --current and --with can read from stdin")
+	}
+	return nil
+}
+
+// applyCompareFlags overrides the config with the flags given and parses
+// the regression gate, which needs a mode with a historical comparison
+func applyCompareFlags(cmd *cobra.Command, cfg *config.Config, mode comparison.Mode) (comparison.Gate, error) {
+	if len(compareFormats) > 0 {
+		cfg.Output.ReportFormats = compareFormats
+	}
+	if err := comparison.ValidateFormats(cfg.Output.ReportFormats); err != nil {
+		return nil, fmt.Errorf("invalid report formats: %w", err)
+	}
+	if cmd.Flags().Changed("parquet") {
+		cfg.Output.Parquet = exportParquet
+	}
+	if comparePreviews {
+		cfg.Comparison.ShowPreviews = true
+	}
+	if compareStopAfter >= 0 {
+		cfg.Comparison.Thresholds.StopAfter = compareStopAfter
+	}
+	if compareJudgments != "" {
+		cfg.Comparison.JudgmentsFile = compareJudgments
+	}
+
+	if compareFailOn != "" {
+		cfg.Comparison.FailOn = compareFailOn
+	}
+	gate, err := comparison.ParseGate(cfg.Comparison.FailOn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --fail-on rules: %w", err)
+	}
+	if len(gate) > 0 && mode != comparison.ModeHistorical && mode != comparison.ModeBoth {
+		return nil, fmt.Errorf("--fail-on needs a historical comparison (--mode historical or both)")
+	}
+	return gate, nil
+}
+
+// findCurrentResults returns the results file to compare: --current, or
+// the latest run's results
+func findCurrentResults(cfg *config.Config) (string, error) {
+	switch {
+	case compareCurrent == "":
+		return runLayout(cfg).FindLatestResults(cfg.Output.BaseDir)
+	case paths.IsReference(compareCurrent):
+		return runLayout(cfg).ResolveResults(cfg.Output.BaseDir, compareCurrent)
+	default:
+		return compareCurrent, nil
+	}
+}
+
+// loadGolden loads the --golden results to compare with, if any
+func loadGolden() ([]models.QueryResults, error) {
+	if compareGolden == "" {
+		return nil, nil
+	}
+	f, err := golden.Load(compareGolden)
+	if err != nil {
+		return nil, err
+	}
+	return f.Results(), nil
+}
+
+// reportDestination returns the run folder of the current results and the
+// directory reports are saved in. Results from stdin have no run folder, so
+// only the reports are written, to stdout unless --out names a directory.
+func reportDestination(cfg *config.Config, currentPath string) (runFolder, reportDir string) {
+	if currentPath != stdio {
+		runFolder = filepath.Dir(currentPath)
+		reportDir = runLayout(cfg).ReportsFolder(runFolder)
+	}
+	switch {
+	case compareOut == stdio:
+		reportsToStdout = true
+	case compareOut != "":
+		reportDir = compareOut
+	case runFolder == "":
+		reportsToStdout = true
+	}
+	return runFolder, reportDir
+}
+
+// loadPreviousResults loads the results to compare with: the golden file,
+// --with, the pinned baseline or the run before the current one. With none
+// of those, only the cross-query comparison is made, which returns its mode.
+func loadPreviousResults(cfg *config.Config, mode comparison.Mode, currentPath, runFolder string,
+	goldenResults []models.QueryResults, gated bool, printer *ui.Printer) ([]models.QueryResults, comparison.Mode, error) {
+	if mode == comparison.ModeCrossQuery {
+		return nil, mode, nil
+	}
+	if compareGolden != "" {
+		printer.Info("Comparing with golden results: %s", compareGolden)
+		return goldenResults, mode, nil
+	}
+
+	if compareWith == "" {
+		compareWith = pinnedBaseline(cfg, currentPath, printer)
+	}
+	if compareWith == "" {
+		previousPath, err := findPreviousResults(cfg, currentPath)
+		if err != nil {
+			printer.Warning("No previous results found, skipping historical comparison")
+			return nil, comparison.ModeCrossQuery, noPreviousResults(mode, gated, printer)
 		}
+		compareWith = previousPath
+	}
+
+	if paths.IsReference(compareWith) {
+		var err error
+		if compareWith, err = runLayout(cfg).ResolveResults(cfg.Output.BaseDir, compareWith); err != nil {
+			return nil, mode, fmt.Errorf("failed to find previous results: %w", err)
+		}
+	}
+
+	printer.Info("Comparing with: %s", describeResultsPath(compareWith))
+	previous, err := readResults(compareWith)
+	if err != nil {
+		return nil, mode, fmt.Errorf("failed to load previous results: %w", err)
+	}
+	if runFolder != "" && compareWith != stdio {
+		checkClusterChanges(runFolder, filepath.Dir(compareWith), printer)
+		checkInputChanges(runFolder, filepath.Dir(compareWith), printer)
 	}
+	return previous, mode, nil
+}
 
-	// Create comparison and generate reports
+// findPreviousResults returns the run before the current one, or the
+// latest run for results read from stdin
+func findPreviousResults(cfg *config.Config, currentPath string) (string, error) {
+	if currentPath == stdio {
+		return runLayout(cfg).FindLatestResults(cfg.Output.BaseDir)
+	}
+	return runLayout(cfg).FindPreviousResults(cfg.Output.BaseDir, currentPath)
+}
+
+// noPreviousResults reports why a comparison with nothing to compare with
+// can't go ahead: the mode needs one, or the gate can't be checked
+func noPreviousResults(mode comparison.Mode, gated bool, printer *ui.Printer) error {
+	if err := missingBaseline(gated, printer); err != nil {
+		return err
+	}
 	switch mode {
 	case comparison.ModeHistorical:
-		return generateHistoricalComparison(current, previous, runFolder, printer)
+		return fmt.Errorf("historical comparison requested but no previous results found")
+	case comparison.ModeScoreDrift:
+		return fmt.Errorf("score drift comparison requested but no previous results found")
+	}
+	return nil
+}
+
+// recordComparedWith notes in the run's manifest which results file the
+// historical report was made against, so audit recomputes it against the
+// same run. Golden files and results read from stdin aren't recorded.
+func recordComparedWith(mode comparison.Mode, runFolder string, previous []models.QueryResults) error {
+	historical := mode == comparison.ModeHistorical || mode == comparison.ModeBoth
+	if noWrite || runFolder == "" || !historical || len(previous) == 0 ||
+		compareGolden != "" || compareWith == "" || compareWith == stdio {
+		return nil
+	}
+	path, err := filepath.Abs(compareWith)
+	if err != nil {
+		return fmt.Errorf("failed to resolve previous results path: %w", err)
+	}
+	if err := output.RecordComparison(runFolder, path); err != nil {
+		return fmt.Errorf("failed to update manifest: %w", err)
+	}
+	return nil
+}
+
+// generateComparisons creates and saves the reports for the mode
+func generateComparisons(mode comparison.Mode, current, previous []models.QueryResults, runFolder, reportDir string,
+	cfg *config.Config, printer *ui.Printer) error {
+	switch mode {
+	case comparison.ModeHistorical:
+		return generateHistoricalComparison(current, previous, runFolder, reportDir, cfg, printer)
 	case comparison.ModeCrossQuery:
-		return generateCrossQueryComparison(current, runFolder, printer)
+		return generateCrossQueryComparison(current, reportDir, cfg, printer)
 	case comparison.ModeBoth:
-		if err := generateHistoricalComparison(current, previous, runFolder, printer); err != nil {
+		// A failed gate still leaves the cross-query report to write
+		gateErr := generateHistoricalComparison(current, previous, runFolder, reportDir, cfg, printer)
+		if gateErr != nil && !errors.Is(gateErr, errGateFailed) {
+			return gateErr
+		}
+		if err := generateCrossQueryComparison(current, reportDir, cfg, printer); err != nil {
 			return err
 		}
-		return generateCrossQueryComparison(current, runFolder, printer)
+		return gateErr
+	case comparison.ModeScoreDrift:
+		return generateScoreDriftComparison(current, previous, runFolder, reportDir, cfg, printer)
 	default:
 		return fmt.Errorf("unknown comparison mode: %s", compareMode)
 	}
 }
 
-func generateHistoricalComparison(current, previous []models.QueryResults, runFolder string, printer *ui.Printer) error {
+// generateHistoricalComparison compares the current results with the
+// previous ones, saves the reports and per-query data, prints a summary and
+// checks the regression gate
+func generateHistoricalComparison(current, previous []models.QueryResults, runFolder, reportDir string,
+	cfg *config.Config, printer *ui.Printer) error {
 	if len(previous) == 0 {
 		printer.Warning("No previous results to compare against")
-		return nil
+		return missingBaseline(strings.TrimSpace(cfg.Comparison.FailOn) != "", printer)
 	}
 
 	printer.Info("Generating historical comparison...")
 
-	opts := comparison.Options{
-		ShowUnchanged:  true,
-		HighlightNew:   true,
-		ShowScores:     true,
-		MaxRankDisplay: 20,
+	opts, err := historicalOptions(cfg, current, previous, runFolder, printer)
+	if err != nil {
+		return err
 	}
-
 	comp := comparison.NewComparison(current, previous, opts, comparison.ModeHistorical)
 
 	spinner := ui.NewSpinner("Generating historical comparison report...")
 	spinner.Start()
 
-	report, err := comp.Generate()
+	// Save historical comparison in each configured format
+	historicalPaths, err := writeReports(comp, reportDir, runLayout(cfg).ReportNames().Historical, cfg.Output.ReportFormats)
+	spinner.Stop()
 	if err != nil {
-		spinner.Stop()
-		return fmt.Errorf("failed to generate historical comparison: %w", err)
+		return fmt.Errorf("failed to write historical comparison: %w", err)
 	}
 
-	spinner.Stop()
-
-	// Save historical comparison
-	historicalPath := filepath.Join(runFolder, "comparison_historical.txt")
-	if err := output.WriteText(historicalPath, report); err != nil {
-		return fmt.Errorf("failed to write historical comparison: %w", err)
+	for _, path := range historicalPaths {
+		printer.Success("Historical comparison saved to: %s", path)
 	}
 
-	printer.Success("Historical comparison saved to: %s", historicalPath)
+	if !noWrite && runFolder != "" {
+		if err := writeHistoricalData(comp, cfg, runFolder, printer); err != nil {
+			return err
+		}
+	}
 
-	// Print summary
 	summary := comp.GetSummary()
+	printHistoricalSummary(comp, summary, opts, cfg, printer)
+
+	return checkGate(cfg.Comparison.FailOn, summary, printer)
+}
+
+// historicalOptions builds the historical comparison options from the
+// config, loading the judgments, watchlist and editorial flags it names.
+// Flags are annotated onto both sets of results.
+func historicalOptions(cfg *config.Config, current, previous []models.QueryResults, runFolder string,
+	printer *ui.Printer) (comparison.Options, error) {
+	matcher, err := comparison.NewMatcher(cfg.Comparison.Matcher)
+	if err != nil {
+		return comparison.Options{}, fmt.Errorf("invalid comparison matcher: %w", err)
+	}
+
+	opts := comparison.Options{
+		ShowUnchanged:      true,
+		HighlightNew:       true,
+		ShowScores:         true,
+		MaxRankDisplay:     20,
+		Matcher:            matcher,
+		Labels:             comparisonLabels(cfg.Comparison.Labels),
+		RBOPersistence:     cfg.Comparison.RBOPersistence,
+		DiversityK:         cfg.Comparison.DiversityK,
+		VisibilityK:        cfg.Comparison.VisibilityK,
+		MarkdownRows:       cfg.Comparison.MarkdownRows,
+		BootstrapResamples: cfg.Comparison.BootstrapResamples,
+		Thresholds: comparison.Thresholds{
+			MaxRemovedResults:   cfg.Comparison.Thresholds.MaxRemovedResults,
+			MaxWorsenedRankings: cfg.Comparison.Thresholds.MaxWorsenedRankings,
+			MaxAvgRankChange:    cfg.Comparison.Thresholds.MaxAvgRankChange,
+			MinOverlap:          cfg.Comparison.Thresholds.MinOverlap,
+			MinRBO:              cfg.Comparison.Thresholds.MinRBO,
+			MinKendallTau:       cfg.Comparison.Thresholds.MinKendallTau,
+			MinNDCG:             cfg.Comparison.Thresholds.MinNDCG,
+			MaxNewTop:           cfg.Comparison.Thresholds.MaxNewTop,
+			MaxNewImpact:        cfg.Comparison.Thresholds.MaxNewImpact,
+			StopAfter:           cfg.Comparison.Thresholds.StopAfter,
+		},
+	}
+
+	for _, b := range cfg.Comparison.PositionBands {
+		opts.PositionBands = append(opts.PositionBands, comparison.PositionBand{MaxRank: b.MaxRank, Weight: b.Weight})
+	}
+	if err := comparison.ValidatePositionBands(opts.PositionBands); err != nil {
+		return opts, fmt.Errorf("invalid comparison.position_bands: %w", err)
+	}
+
+	if cfg.Comparison.ShowPreviews && runFolder != "" {
+		opts.Previewer = loadPreviewer(runFolder, cfg.Comparison.PreviewLength, printer)
+	}
+	if cfg.Comparison.JudgmentsFile != "" {
+		judgments, err := models.LoadJudgments(cfg.Comparison.JudgmentsFile)
+		if err != nil {
+			return opts, fmt.Errorf("failed to load judgments: %w", err)
+		}
+		opts.Judgments = judgments
+		opts.RelevanceK = cfg.Comparison.NDCGK
+	}
+	if cfg.Comparison.WatchlistFile != "" {
+		watchlist, err := models.LoadWatchlist(cfg.Comparison.WatchlistFile)
+		if err != nil {
+			return opts, fmt.Errorf("failed to load watchlist: %w", err)
+		}
+		opts.Watchlist = watchlist
+		opts.WatchlistK = cfg.Comparison.WatchlistK
+	}
+	if cfg.Comparison.FlagsFile != "" {
+		flags, err := models.LoadEditorialFlags(cfg.Comparison.FlagsFile)
+		if err != nil {
+			return opts, fmt.Errorf("failed to load editorial flags: %w", err)
+		}
+		comparison.AnnotateFlags(current, flags)
+		comparison.AnnotateFlags(previous, flags)
+		opts.Flags = flags
+		opts.WarnFlags = cfg.Comparison.WarnFlags
+		opts.FlagsK = cfg.Comparison.FlagsK
+	}
+
+	return opts, nil
+}
+
+// writeHistoricalData saves the per-query breakdowns, result movements and
+// theme visibility alongside the historical report
+func writeHistoricalData(comp *comparison.Comparison, cfg *config.Config, runFolder string, printer *ui.Printer) error {
+	layout := runLayout(cfg)
+	queryComparisons := comp.QueryComparisons()
+	comparisonsDir := layout.ComparisonsFolder(runFolder)
+	for _, qc := range queryComparisons {
+		path := filepath.Join(comparisonsDir, qc.Slug+".json")
+		if err := output.WriteJSONFile(path, qc); err != nil {
+			return fmt.Errorf("failed to write query comparison for %q: %w", qc.Query, err)
+		}
+	}
+	if len(queryComparisons) > 0 {
+		printer.Success("Per-query comparisons saved to: %s", comparisonsDir)
+	}
+	if cfg.Output.Parquet {
+		path := filepath.Join(layout.ReportsFolder(runFolder), output.MovementsParquetFileName)
+		if err := writeMovementsParquet(path, queryComparisons); err != nil {
+			return err
+		}
+		printer.Success("Result movements saved to: %s", path)
+	}
+
+	// Save theme visibility shares for the whole suite
+	visibility := comp.Visibility()
+	visibilityPath := filepath.Join(layout.ReportsFolder(runFolder), "visibility.json")
+	if err := output.WriteJSONFile(visibilityPath, visibility); err != nil {
+		return fmt.Errorf("failed to write visibility report: %w", err)
+	}
+	printer.Success("Theme visibility saved to: %s", visibilityPath)
+
+	return nil
+}
+
+// printHistoricalSummary prints the verdict and suite-wide measures of a
+// historical comparison
+func printHistoricalSummary(comp *comparison.Comparison, summary comparison.Summary, opts comparison.Options,
+	cfg *config.Config, printer *ui.Printer) {
 	printer.Section("Historical Comparison Summary")
+	switch {
+	case !summary.Verdict.Checked:
+		printer.Info("%s", summary.Verdict.Headline())
+	case summary.Verdict.Passed():
+		printer.Success("%s", summary.Verdict.Headline())
+	default:
+		printer.Warning("%s", summary.Verdict.Headline())
+	}
+	if summary.Verdict.Truncated(cfg.Comparison.Thresholds.StopAfter) {
+		printer.Warning("Report detail stopped after %d regressions; per-query stats cover every query",
+			cfg.Comparison.Thresholds.StopAfter)
+	}
+	if summary.Verdict.Volatile > 0 {
+		printer.Info("Volatile queries not checked: %d", summary.Verdict.Volatile)
+	}
+	printer.Info("Queries compared: %d", summary.QueriesCompared)
+	if !summary.Coverage.IsComplete() {
+		printer.Warning("Query sets differ: %d only in current run, %d only in previous run (removed or failed)",
+			summary.Coverage.CurrentOnly, summary.Coverage.PreviousOnly)
+	}
 	printer.Info("New results: %d", summary.NewResults)
 	printer.Info("Removed results: %d", summary.RemovedResults)
 	printer.Info("Improved rankings: %d", summary.ImprovedRankings)
 	printer.Info("Worsened rankings: %d", summary.WorsenedRankings)
+	if i := summary.Intervals; i != nil {
+		printer.Info("Avg rank change: %.2f (%.0f%% CI %.2f to %.2f)",
+			i.AvgRankChange.Mean, i.Level*100, i.AvgRankChange.Lower, i.AvgRankChange.Upper)
+		printer.Info("Overlap with previous results: %.0f%% (%.0f%% CI %.0f%% to %.0f%%)",
+			i.Overlap.Mean*100, i.Level*100, i.Overlap.Lower*100, i.Overlap.Upper*100)
+	}
+	if c := summary.Correlation; c.Lists > 0 {
+		printer.Info("Mean rank correlation over %d queries: Kendall τ %.2f | Spearman ρ %.2f",
+			c.Lists, c.AvgKendallTau, c.AvgSpearman)
+	}
+	if r := summary.Relevance; r.Queries > 0 {
+		printer.Info("Mean NDCG@%d over %d judged queries: %.3f → %.3f (%d improved, %d worsened)",
+			r.K, r.Queries, r.PrevAvgNDCG, r.AvgNDCG, r.Improved, r.Worsened)
+		if i := summary.Intervals; i != nil && i.NDCG != nil {
+			printer.Info("NDCG@%d %.0f%% CI: %.3f to %.3f", r.K, i.Level*100, i.NDCG.Lower, i.NDCG.Upper)
+		}
+		if s := r.Significance; s != nil {
+			printer.Info("NDCG change significance: %s", s)
+		}
+	} else if cfg.Comparison.JudgmentsFile != "" {
+		printer.Warning("None of the compared queries have relevant judgments in %s", cfg.Comparison.JudgmentsFile)
+	}
+	if len(opts.Watchlist) > 0 {
+		reportWatchlistDrops(comp.WatchlistDrops(), cfg.Comparison.WatchlistK, printer)
+	}
+	if len(opts.Flags) > 0 && len(opts.WarnFlags) > 0 {
+		reportFlaggedResults(comp.FlaggedResults(), cfg.Comparison.FlagsK, printer)
+	}
+	if d := summary.Diversity; d.Queries > 0 {
+		printer.Info("Avg distinct topics in top %d: %.2f → %.2f", d.K, d.PrevAvgTopics, d.AvgTopics)
+		printer.Info("Avg intra-list similarity in top %d: %.2f → %.2f", d.K, d.PrevAvgSimilarity, d.AvgSimilarity)
+		if s := d.SimilaritySignificance; s != nil {
+			printer.Info("Similarity change significance: %s", s)
+		}
+	}
+}
 
+// missingBaseline handles a comparison with nothing to compare against. A
+// regression gate can't be checked then, which fails compare unless
+// --allow-missing-baseline lets a first run pass.
+func missingBaseline(gated bool, printer *ui.Printer) error {
+	if !gated {
+		return nil
+	}
+	if !allowMissingBaseline {
+		return fmt.Errorf("no previous results to check the --fail-on rules against; " +
+			"pass --allow-missing-baseline to let a run without one pass")
+	}
+	printer.Warning("Regression gate not checked: nothing to compare with")
 	return nil
 }
 
-func generateCrossQueryComparison(current []models.QueryResults, runFolder string, printer *ui.Printer) error {
+// writeMovementsParquet saves every result movement as a Parquet table
+func writeMovementsParquet(path string, queries []comparison.QueryComparison) error {
+	var buf bytes.Buffer
+	if err := comparison.WriteMovementsParquet(&buf, queries); err != nil {
+		return fmt.Errorf("failed to build result movements: %w", err)
+	}
+	if err := output.WriteText(path, buf.String()); err != nil {
+		return fmt.Errorf("failed to write result movements: %w", err)
+	}
+	return nil
+}
+
+// checkGate checks the summary against the regression gate rules, returning
+// errGateFailed if any is broken
+func checkGate(rules string, summary comparison.Summary, printer *ui.Printer) error {
+	gate, err := comparison.ParseGate(rules)
+	if err != nil {
+		return fmt.Errorf("invalid regression gate rules: %w", err)
+	}
+	if len(gate) == 0 {
+		return nil
+	}
+
+	printer.Section("Regression Gate")
+	if gate.Uses(comparison.GateNDCGDrop) && summary.Relevance.Queries == 0 {
+		printer.Warning("ndcg-drop not checked: no compared query has judgments")
+	}
+	failures := gate.Check(summary)
+	if len(failures) == 0 {
+		printer.Success("Passed: %s", rules)
+		return nil
+	}
+	for _, f := range failures {
+		printer.Error("%s", f)
+	}
+	return fmt.Errorf("%w: %s", errGateFailed, strings.Join(failures, "; "))
+}
+
+// pinnedBaseline returns the results of the baseline run, the default run
+// to compare with, or "" when none is pinned or the current run is the
+// baseline itself
+func pinnedBaseline(cfg *config.Config, currentPath string, printer *ui.Printer) string {
+	runFolder, err := paths.ResolvePointer(cfg.Output.BaseDir, paths.BaselinePointer)
+	if errors.Is(err, paths.ErrNoPointer) {
+		return ""
+	}
+	if err != nil {
+		printer.Warning("Ignoring the baseline: %v", err)
+		return ""
+	}
+	if currentPath != stdio && paths.SameFolder(runFolder, filepath.Dir(currentPath)) {
+		printer.Info("The current run is the baseline; comparing with the run before it")
+		return ""
+	}
+	printer.Info("Comparing with the pinned baseline %s (--with previous for the run before)", paths.RunID(runFolder))
+	return filepath.Join(runFolder, "results.json")
+}
+
+// resolveCompareRuns turns --from and --to into the results files to
+// compare, warning when --from is the later run
+func resolveCompareRuns(cfg *config.Config, printer *ui.Printer) error {
+	layout := runLayout(cfg)
+	var err error
+	if compareTo != "" {
+		if compareCurrent, err = layout.ResolveResults(cfg.Output.BaseDir, compareTo); err != nil {
+			return fmt.Errorf("failed to find --to run: %w", err)
+		}
+	}
+	if compareFrom != "" {
+		if compareWith, err = layout.ResolveResults(cfg.Output.BaseDir, compareFrom); err != nil {
+			return fmt.Errorf("failed to find --from run: %w", err)
+		}
+	}
+	if compareFrom == "" || compareTo == "" {
+		return nil
+	}
+
+	from, fromErr := layout.Timestamp(filepath.Dir(compareWith))
+	to, toErr := layout.Timestamp(filepath.Dir(compareCurrent))
+	if fromErr == nil && toErr == nil && from.After(to) {
+		printer.Warning("--from %s is later than --to %s; changes are reported from --from to --to",
+			paths.RunID(filepath.Dir(compareWith)), paths.RunID(filepath.Dir(compareCurrent)))
+	}
+	return nil
+}
+
+// checkClusterChanges warns when two runs were made against differently
+// configured clusters, which can explain otherwise surprising differences
+func checkClusterChanges(runFolder, previousFolder string, printer *ui.Printer) {
+	current, err := output.LoadClusterSnapshot(runFolder)
+	if err != nil {
+		printer.Debug("No cluster snapshot for current run: %v", err)
+		return
+	}
+	previous, err := output.LoadClusterSnapshot(previousFolder)
+	if err != nil {
+		printer.Debug("No cluster snapshot for previous run: %v", err)
+		return
+	}
+
+	diffs := current.Differences(*previous)
+	if len(diffs) == 0 {
+		printer.Debug("Both runs used the same cluster configuration")
+		return
+	}
+
+	printer.Warning("Runs were made against differently configured clusters:")
+	for _, d := range diffs {
+		printer.Warning("  %s", d)
+	}
+}
+
+// checkInputChanges reports when the queries, config, index or CLI version
+// recorded in two runs' manifests differ, since any of them can explain the
+// differences the comparison finds
+func checkInputChanges(runFolder, previousFolder string, printer *ui.Printer) {
+	current, err := output.LoadManifest(runFolder)
+	if err != nil {
+		printer.Debug("No manifest for current run: %v", err)
+		return
+	}
+	previous, err := output.LoadManifest(previousFolder)
+	if err != nil {
+		printer.Debug("No manifest for previous run: %v", err)
+		return
+	}
+
+	diffs := current.Differences(*previous)
+	if len(diffs) == 0 {
+		printer.Debug("Both runs used the same queries, config and index")
+		return
+	}
+
+	printer.Info("Run inputs changed since the previous run:")
+	for _, d := range diffs {
+		printer.Info("  %s", d)
+	}
+}
+
+// comparisonLabels maps configured report terminology onto comparison labels
+func comparisonLabels(l config.LabelsConfig) comparison.Labels {
+	return comparison.Labels{
+		New:           l.New,
+		Removed:       l.Removed,
+		Improved:      l.Improved,
+		Worsened:      l.Worsened,
+		Unchanged:     l.Unchanged,
+		TotalNew:      l.TotalNew,
+		TotalRemoved:  l.TotalRemoved,
+		TotalImproved: l.TotalImproved,
+		TotalWorsened: l.TotalWorsened,
+	}
+}
+
+// loadPreviewer reads the run's stored index for result previews, returning
+// nil if it can't be loaded so the report is generated without them
+func loadPreviewer(runFolder string, length int, printer *ui.Printer) *preview.Previewer {
+	indexPath := filepath.Join(runFolder, "index.json")
+	index, err := indexgen.NewLoader().Load(indexPath)
+	if err != nil {
+		printer.Warning("Previews disabled, could not load %s: %v", indexPath, err)
+		return nil
+	}
+
+	printer.Debug("Loaded %d documents for previews", len(index.Documents))
+	return preview.NewPreviewer(index, length)
+}
+
+func generateCrossQueryComparison(current []models.QueryResults, reportDir string, cfg *config.Config, printer *ui.Printer) error {
 	if len(current) < 2 {
 		printer.Warning("Need at least 2 queries to perform cross-query comparison")
 		return nil
@@ -155,11 +820,18 @@ func generateCrossQueryComparison(current []models.QueryResults, runFolder strin
 
 	printer.Info("Generating cross-query comparison...")
 
+	matcher, err := comparison.NewMatcher(cfg.Comparison.Matcher)
+	if err != nil {
+		return fmt.Errorf("invalid comparison matcher: %w", err)
+	}
+
 	opts := comparison.Options{
 		ShowUnchanged:  false,
 		HighlightNew:   true,
 		ShowScores:     true,
 		MaxRankDisplay: 20,
+		Matcher:        matcher,
+		RBOPersistence: cfg.Comparison.RBOPersistence,
 	}
 
 	comp := comparison.NewComparison(current, nil, opts, comparison.ModeCrossQuery)
@@ -167,29 +839,119 @@ func generateCrossQueryComparison(current []models.QueryResults, runFolder strin
 	spinner := ui.NewSpinner("Generating cross-query comparison report...")
 	spinner.Start()
 
-	report, err := comp.Generate()
-	if err != nil {
-		spinner.Stop()
-		return fmt.Errorf("failed to generate cross-query comparison: %w", err)
-	}
-
+	// Save cross-query comparison in each configured format
+	crossQueryPaths, err := writeReports(comp, reportDir, runLayout(cfg).ReportNames().CrossQuery, cfg.Output.ReportFormats)
 	spinner.Stop()
-
-	// Save cross-query comparison
-	crossQueryPath := filepath.Join(runFolder, "comparison_cross_query.txt")
-	if err := output.WriteText(crossQueryPath, report); err != nil {
+	if err != nil {
 		return fmt.Errorf("failed to write cross-query comparison: %w", err)
 	}
 
-	printer.Success("Cross-query comparison saved to: %s", crossQueryPath)
+	for _, path := range crossQueryPaths {
+		printer.Success("Cross-query comparison saved to: %s", path)
+	}
 
 	printer.Section("Cross-Query Comparison Summary")
 	printer.Info("Total queries analyzed: %d", len(current))
 	printer.Info("Comparison pairs: %d", (len(current)*(len(current)-1))/2)
+	if c := comp.GetSummary().Correlation; c.Lists > 0 {
+		printer.Info("Mean rank correlation over %d pairs: Kendall τ %.2f | Spearman ρ %.2f",
+			c.Lists, c.AvgKendallTau, c.AvgSpearman)
+	}
+
+	return nil
+}
+
+// generateScoreDriftComparison reports how scores moved for queries whose
+// ranking is unchanged, writing the reports and score_drift.json
+func generateScoreDriftComparison(current, previous []models.QueryResults, runFolder, reportDir string,
+	cfg *config.Config, printer *ui.Printer) error {
+	printer.Info("Generating score drift comparison...")
+
+	matcher, err := comparison.NewMatcher(cfg.Comparison.Matcher)
+	if err != nil {
+		return fmt.Errorf("invalid comparison matcher: %w", err)
+	}
+
+	comp := comparison.NewComparison(current, previous, comparison.Options{Matcher: matcher}, comparison.ModeScoreDrift)
+
+	driftPaths, err := writeReports(comp, reportDir, runLayout(cfg).ReportNames().ScoreDrift, cfg.Output.ReportFormats)
+	if err != nil {
+		return fmt.Errorf("failed to write score drift comparison: %w", err)
+	}
+	for _, path := range driftPaths {
+		printer.Success("Score drift comparison saved to: %s", path)
+	}
+
+	drift := comp.ScoreDrift()
+	if !noWrite && runFolder != "" {
+		driftPath := filepath.Join(runLayout(cfg).ReportsFolder(runFolder), "score_drift.json")
+		if err := output.WriteJSONFile(driftPath, drift); err != nil {
+			return fmt.Errorf("failed to write score drift: %w", err)
+		}
+		printer.Success("Score drift data saved to: %s", driftPath)
+	}
+
+	printer.Section("Score Drift Summary")
+	printer.Info("Identical rankings: %d of %d queries", drift.Identical, drift.Compared)
+	if drift.Identical > 0 {
+		printer.Info("Scores rising: %d | falling: %d", drift.Rising, drift.Falling)
+		printer.Info("Mean |Δ| per result: %.4f | Largest |Δ|: %.4f", drift.MeanAbsDelta, drift.MaxAbsDelta)
+	}
 
 	return nil
 }
 
+// reportsToStdout prints compare's reports instead of saving them, set by
+// --out - or when results are read from stdin
+var reportsToStdout bool
+
+// writeReports renders the comparison in each format and saves it in dir,
+// returning the paths written. With --no-write, or when reports go to
+// stdout, the reports are printed instead and no paths are returned.
+func writeReports(comp *comparison.Comparison, dir, baseName string, formats []string) ([]string, error) {
+	paths := make([]string, 0, len(formats))
+	for _, format := range formats {
+		report, err := comp.Render(format)
+		if err != nil {
+			return nil, fmt.Errorf("render %s report: %w", format, err)
+		}
+
+		if reportsToStdout {
+			if _, err := os.Stdout.Write(report); err != nil {
+				return nil, fmt.Errorf("write %s report: %w", format, err)
+			}
+			continue
+		}
+		if noWrite {
+			fmt.Printf("\n%s\n", report)
+			continue
+		}
+
+		path := filepath.Join(dir, comparison.ReportFileName(baseName, format))
+		if err := output.WriteText(path, string(report)); err != nil {
+			return nil, fmt.Errorf("write %s report: %w", format, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// readResults loads a results file, or reads results from stdin for -
+func readResults(path string) ([]models.QueryResults, error) {
+	if path == stdio {
+		return output.ReadResults(os.Stdin)
+	}
+	return output.LoadResults(path)
+}
+
+// describeResultsPath names a results path in status messages
+func describeResultsPath(path string) string {
+	if path == stdio {
+		return "stdin"
+	}
+	return path
+}
+
 func parseComparisonMode(mode string) comparison.Mode {
 	switch strings.ToLower(strings.TrimSpace(mode)) {
 	case "historical":
@@ -198,6 +960,8 @@ func parseComparisonMode(mode string) comparison.Mode {
 		return comparison.ModeCrossQuery
 	case "both":
 		return comparison.ModeBoth
+	case "score-drift", "scoredrift":
+		return comparison.ModeScoreDrift
 	default:
 		return comparison.ModeBoth
 	}
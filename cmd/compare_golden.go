@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ONSdigital/dis-search-test-bed/config"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/comparison"
+	"github.com/ONSdigital/dis-search-test-bed/shared/golden"
+	"github.com/ONSdigital/dis-search-test-bed/shared/suitediff"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+)
+
+var errGoldenMismatch = errors.New("results differ from the golden results")
+
+// goldenRows caps the differing queries listed against the golden results
+const goldenRows = 20
+
+// goldenStatus names a query's status from the golden file's side
+var goldenStatus = map[suitediff.Status]string{
+	suitediff.StatusReordered: "reordered",
+	suitediff.StatusChanged:   "changed",
+	suitediff.StatusOnlyA:     "missing",
+	suitediff.StatusOnlyB:     "new",
+}
+
+// updateGolden replaces the golden file with the current results
+func updateGolden(path string, current []models.QueryResults, printer *ui.Printer) error {
+	if err := requireWritable(); err != nil {
+		return err
+	}
+	if err := golden.Write(path, current); err != nil {
+		return fmt.Errorf("failed to update golden results: %w", err)
+	}
+	printer.Success("Updated golden results %s (%d queries)", path, len(current))
+	return nil
+}
+
+// checkGolden compares the current results with the golden results query
+// by query and fails unless every query returned the same results in the
+// same order. Volatile queries are left out.
+func checkGolden(cfg *config.Config, path string, expected, current []models.QueryResults, printer *ui.Printer) error {
+	matcher, err := comparison.NewMatcher(cfg.Comparison.Matcher)
+	if err != nil {
+		return fmt.Errorf("invalid comparison matcher: %w", err)
+	}
+	expected, current, volatile := golden.WithoutVolatile(expected, current)
+	report := suitediff.Compare(expected, current, matcher)
+
+	printer.Section("Golden Results")
+	if volatile > 0 {
+		printer.Info("Volatile queries not checked: %d", volatile)
+	}
+	printer.Info("Identical: %d | Reordered: %d | Changed: %d | Missing: %d | New: %d",
+		report.Counts[suitediff.StatusIdentical],
+		report.Counts[suitediff.StatusReordered],
+		report.Counts[suitediff.StatusChanged],
+		report.Counts[suitediff.StatusOnlyA],
+		report.Counts[suitediff.StatusOnlyB])
+	if report.Preserved {
+		printer.Success("All %d queries match %s", len(report.Queries), path)
+		return nil
+	}
+
+	table := ui.NewTable("STATUS", "ALGORITHM", "QUERY", "FIRST DIFF", "ADDED", "REMOVED")
+	for i, q := range report.Queries {
+		if q.Status == suitediff.StatusIdentical || i == goldenRows {
+			break
+		}
+		firstDiff := "-"
+		if q.FirstDifference > 0 {
+			firstDiff = strconv.Itoa(q.FirstDifference)
+		}
+		table.AddRow(
+			goldenStatus[q.Status],
+			q.Algorithm,
+			ui.Truncate(q.Query, showTitleWidth),
+			firstDiff,
+			strconv.Itoa(len(q.Added)),
+			strconv.Itoa(len(q.Removed)),
+		)
+	}
+	if err := table.Print(); err != nil {
+		return fmt.Errorf("failed to print golden comparison: %w", err)
+	}
+	printer.Info("Run compare --golden %s --update-golden to accept these results", path)
+
+	return fmt.Errorf("%w: %d of %d queries differ from %s", errGoldenMismatch,
+		len(report.Queries)-report.Counts[suitediff.StatusIdentical], len(report.Queries), path)
+}
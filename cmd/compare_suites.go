@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ONSdigital/dis-search-test-bed/config"
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/comparison"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/shared/paths"
+	"github.com/ONSdigital/dis-search-test-bed/shared/queryexec"
+	"github.com/ONSdigital/dis-search-test-bed/shared/suitediff"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	suitesIndexPath string
+	suitesRows      int
+)
+
+// errSuitesDiffer is returned when two query suites return different results
+var errSuitesDiffer = errors.New("query suites differ")
+
+var compareSuitesCmd = &cobra.Command{
+	Use:   "compare-suites <suite-a.json> <suite-b.json>",
+	Short: "Check two query suites return the same results",
+	Long: `Compare-suites loads a stored index (the latest by default) into
+Elasticsearch once, runs two query files against it, e.g. queries.json before
+and after a refactor, and compares them query by query. Queries are paired by
+algorithm and query ID; a renamed query is paired through the aliases in
+suite B.
+
+Each query is identical (same results in the same order), reordered, changed
+(results added or removed), or only in one suite. The command exits non-zero
+unless every query is identical, so it can guard a query refactor in CI. The
+full report is saved as suite_diff.json where the index's run keeps its
+comparison reports.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runCompareSuites,
+}
+
+func init() {
+	rootCmd.AddCommand(compareSuitesCmd)
+
+	compareSuitesCmd.Flags().StringVarP(&suitesIndexPath, "index", "i", "",
+		"Path to stored index (defaults to latest)")
+	compareSuitesCmd.Flags().IntVar(&suitesRows, "rows", 20,
+		"Differing queries to list")
+	addForceReloadFlag(compareSuitesCmd)
+	addMappingProfileFlag(compareSuitesCmd)
+}
+
+func runCompareSuites(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	matcher, err := comparison.NewMatcher(cfg.Comparison.Matcher)
+	if err != nil {
+		return fmt.Errorf("invalid comparison matcher: %w", err)
+	}
+
+	printer := ui.NewPrinter(verbose)
+
+	suiteA, err := models.LoadAlgorithms(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to load suite A: %w", err)
+	}
+	suiteB, err := models.LoadAlgorithms(args[1])
+	if err != nil {
+		return fmt.Errorf("failed to load suite B: %w", err)
+	}
+
+	if suitesIndexPath == "" {
+		suitesIndexPath, err = runLayout(cfg).FindLatestIndex(cfg.Output.BaseDir)
+		if err != nil {
+			return fmt.Errorf("failed to find latest index: %w", err)
+		}
+	}
+	runFolder := filepath.Dir(suitesIndexPath)
+	runLock, err := openRunFolder(cfg, runFolder, printer)
+	if err != nil {
+		return err
+	}
+	defer releaseRunFolder(runLock)
+
+	ctx := context.Background()
+	client, err := loadSnapshot(ctx, cfg, suitesIndexPath, printer)
+	if err != nil {
+		return err
+	}
+
+	executor := queryexec.NewExecutor(client, cfg.Elasticsearch.Index, verbose)
+	executor.SetRunID(paths.RunID(runFolder))
+	runner := queryexec.NewRunner(executor, printer)
+	if cfg.Execution.BatchSize > 1 {
+		runner.SetBatchSize(cfg.Execution.BatchSize)
+	}
+
+	endPhase := phases.Start(phaseQueries)
+	printer.Info("Running suite A: %s", args[0])
+	resultsA, err := runner.RunAlgorithms(ctx, suiteA)
+	if err != nil {
+		endPhase()
+		return fmt.Errorf("failed to run suite A: %w", err)
+	}
+	printer.Info("Running suite B: %s", args[1])
+	resultsB, err := runner.RunAlgorithms(ctx, suiteB)
+	endPhase()
+	if err != nil {
+		return fmt.Errorf("failed to run suite B: %w", err)
+	}
+
+	report := suitediff.Compare(resultsA, resultsB, matcher)
+	if err := printSuiteDiff(report, printer); err != nil {
+		return err
+	}
+
+	if !noWrite {
+		path := filepath.Join(runLayout(cfg).ReportsFolder(runFolder), output.SuiteDiffFileName)
+		if err := output.WriteJSONFile(path, report); err != nil {
+			return fmt.Errorf("failed to save suite comparison: %w", err)
+		}
+		printer.Info("Location: %s", path)
+	}
+
+	if err := reportTimings("compare-suites", runFolder, printer); err != nil {
+		return err
+	}
+
+	if !report.Preserved {
+		return fmt.Errorf("%w: %d of %d queries are not identical", errSuitesDiffer,
+			len(report.Queries)-report.Counts[suitediff.StatusIdentical], len(report.Queries))
+	}
+	printer.Success("Both suites return identical results for all %d queries", len(report.Queries))
+	return nil
+}
+
+// loadSnapshot loads a stored index into the configured Elasticsearch index
+// and returns the connected client
+func loadSnapshot(ctx context.Context, cfg *config.Config, indexPath string, printer *ui.Printer) (*elasticsearch.Client, error) {
+	endPhase := phases.Start(phaseLoadIndex)
+	loader, err := newIndexLoader(cfg)
+	if err != nil {
+		return nil, err
+	}
+	storedIndex, err := loader.Load(indexPath)
+	endPhase()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+	printer.Success("Loaded index with %d documents", len(storedIndex.Documents))
+
+	endPhase = phases.Start(phaseConnect)
+	client, err := newESClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ES client: %w", err)
+	}
+	if err := client.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to Elasticsearch: %w", err)
+	}
+	endPhase()
+
+	endPhase = phases.Start(phaseBulk)
+	err = loader.LoadIntoElasticsearch(ctx, client, cfg.Elasticsearch.Index, storedIndex)
+	endPhase()
+	if err != nil {
+		reportBulkFailures(err, printer)
+		return nil, fmt.Errorf("failed to load index: %w", err)
+	}
+	reportIndexLoad(loader, cfg.Elasticsearch.Index, printer)
+	return client, nil
+}
+
+// printSuiteDiff prints the status counts and the least alike queries
+func printSuiteDiff(report suitediff.Report, printer *ui.Printer) error {
+	printer.Section("Suite Comparison")
+	printer.Info("Identical: %d | Reordered: %d | Changed: %d | Only in A: %d | Only in B: %d",
+		report.Counts[suitediff.StatusIdentical],
+		report.Counts[suitediff.StatusReordered],
+		report.Counts[suitediff.StatusChanged],
+		report.Counts[suitediff.StatusOnlyA],
+		report.Counts[suitediff.StatusOnlyB])
+
+	table := ui.NewTable("STATUS", "ALGORITHM", "QUERY", "FIRST DIFF", "OVERLAP", "ADDED", "REMOVED")
+	rows := 0
+	for _, q := range report.Queries {
+		if q.Status == suitediff.StatusIdentical || rows == suitesRows {
+			break
+		}
+		firstDiff := "-"
+		if q.FirstDifference > 0 {
+			firstDiff = strconv.Itoa(q.FirstDifference)
+		}
+		table.AddRow(
+			string(q.Status),
+			q.Algorithm,
+			ui.Truncate(q.Query, showTitleWidth),
+			firstDiff,
+			fmt.Sprintf("%.2f", q.Overlap),
+			strconv.Itoa(len(q.Added)),
+			strconv.Itoa(len(q.Removed)),
+		)
+		rows++
+	}
+	if rows == 0 {
+		return nil
+	}
+	return table.Print()
+}
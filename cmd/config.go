@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Print the effective configuration, with secrets redacted",
+	Long: `Config prints the configuration commands run with, after environment
+overrides and defaults, as YAML on stdout. A password in the Elasticsearch URL
+is redacted, so the output is safe to paste into an issue or save alongside a
+run. Elasticsearch credentials are checked as well: an unset password, API
+key or bearer token variable, or an unreadable secret file, is reported as
+an error.`,
+	RunE: runConfig,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfig(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	ui.SetOutput(os.Stderr)
+	printer := ui.NewPrinter(verbose)
+	printer.Info("Configuration loaded from: %s", cfgFile)
+
+	data, err := yaml.Marshal(cfg.Redact())
+	if err != nil {
+		return fmt.Errorf("failed to format config: %w", err)
+	}
+	if _, err := os.Stdout.Write(data); err != nil {
+		return err
+	}
+
+	es := cfg.Elasticsearch
+	password, err := es.Password()
+	if err != nil {
+		return err
+	}
+	apiKey, err := es.APIKey()
+	if err != nil {
+		return err
+	}
+	token, err := es.BearerToken()
+	if err != nil {
+		return err
+	}
+	switch {
+	case token != "":
+		printer.Success("Elasticsearch bearer token found")
+	case apiKey != "":
+		printer.Success("Elasticsearch API key found")
+	case password != "":
+		printer.Success("Elasticsearch password found for user %q", es.Username)
+	default:
+		printer.Info("No Elasticsearch credentials configured")
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/corpus"
+	"github.com/ONSdigital/dis-search-test-bed/shared/metrics"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scalingRun        string
+	scalingThresholds = corpus.ScalingThresholds{
+		MaxLatencyExponent: corpus.DefaultMaxLatencyExponent,
+		MinStability:       corpus.DefaultMinStability,
+	}
+	scalingK int
+)
+
+var corpusScalingCmd = &cobra.Command{
+	Use:   "corpus-scaling",
+	Short: "Report how latency and result stability change with corpus size",
+	Long: `Corpus-scaling reads a run made with 'run' across several corpora and, for each
+algorithm, reports per-query latency (mean and p95 took) and result stability
+as the corpus grows. Stability is the share of a query's top K on one corpus
+still in its top K on the next larger corpus.
+
+An algorithm is flagged as degrading when its p95 latency grows faster than
+corpus size to the power --max-latency-exponent, or when its stability drops
+below --min-stability, catching algorithms that behave on 50 documents but
+not on 500,000. The report is saved to corpus_scaling.json in the run folder.`,
+	RunE: runCorpusScaling,
+}
+
+func init() {
+	rootCmd.AddCommand(corpusScalingCmd)
+
+	corpusScalingCmd.Flags().StringVar(&scalingRun, "run", "",
+		"Run to report on (folder, folder name or results file; defaults to latest)")
+	corpusScalingCmd.Flags().IntVarP(&scalingK, "k", "k", metrics.DefaultK,
+		"Rank cut-off for result stability")
+	corpusScalingCmd.Flags().Float64Var(&scalingThresholds.MaxLatencyExponent, "max-latency-exponent",
+		corpus.DefaultMaxLatencyExponent, "Flag p95 latency growing faster than corpus size to this power (0 disables)")
+	corpusScalingCmd.Flags().Float64Var(&scalingThresholds.MinStability, "min-stability",
+		corpus.DefaultMinStability, "Flag stability below this share of top results kept (0 disables)")
+}
+
+func runCorpusScaling(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	printer := ui.NewPrinter(verbose)
+
+	resultsPath, err := runLayout(cfg).ResolveResults(cfg.Output.BaseDir, scalingRun)
+	if err != nil {
+		return fmt.Errorf("failed to find results: %w", err)
+	}
+	results, err := output.LoadResults(resultsPath)
+	if err != nil {
+		return fmt.Errorf("failed to load results: %w", err)
+	}
+
+	runFolder := filepath.Dir(resultsPath)
+	sizes, err := output.LoadCorpusSizes(runFolder)
+	if err != nil {
+		return fmt.Errorf("failed to load corpora: %w", err)
+	}
+	if len(sizes) < 2 {
+		return fmt.Errorf("%s has %d corpora; run the suite across at least two with 'run'", runFolder, len(sizes))
+	}
+	specs, err := corpusSpecs(cfg, nil)
+	if err != nil {
+		return err
+	}
+	chain := scalingChain(specs, sizes, printer)
+	if len(chain) < 2 {
+		return fmt.Errorf("%s has %d corpora of one unfiltered source; scaling needs at least two", runFolder, len(chain))
+	}
+
+	runLock, err := openRunFolder(cfg, runFolder, printer)
+	if err != nil {
+		return err
+	}
+	defer releaseRunFolder(runLock)
+
+	return reportScaling(results, chain, runFolder, printer)
+}
+
+// scalingChain keeps the corpora a scaling report can compare, saying which
+// were left out. Corpora missing from the config are left out too, as there
+// is no telling how they were built.
+func scalingChain(specs []corpus.Spec, sizes map[string]int, printer *ui.Printer) map[string]int {
+	chain, excluded := corpus.ScalingChain(specs, sizes)
+
+	known := make(map[string]bool, len(specs))
+	for _, s := range specs {
+		known[s.Name] = true
+	}
+	var unknown []string
+	for name := range sizes {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+
+	if len(excluded) > 0 {
+		printer.Info("Left out of scaling (filtered or another source): %s", strings.Join(excluded, ", "))
+	}
+	if len(unknown) > 0 {
+		printer.Info("Left out of scaling (not in config): %s", strings.Join(unknown, ", "))
+	}
+	return chain
+}
+
+// reportScaling prints the corpus scaling report and saves it to the run
+// folder
+func reportScaling(results []models.QueryResults, sizes map[string]int, runFolder string, printer *ui.Printer) error {
+	report := corpus.Scaling(results, sizes, scalingK, scalingThresholds)
+
+	for _, alg := range report.Algorithms {
+		printer.Section(fmt.Sprintf("%s (p95 latency ~ size^%.2f)", alg.Algorithm, alg.LatencyExponent))
+		table := ui.NewTable("CORPUS", "DOCS", "QUERIES", "ZERO", "MEAN TOOK", "P95 TOOK",
+			fmt.Sprintf("STABILITY@%d", report.K))
+		for _, p := range alg.Points {
+			stability := "-"
+			if p.Stability != nil {
+				stability = fmt.Sprintf("%.2f", *p.Stability)
+			}
+			table.AddRow(
+				p.Corpus,
+				strconv.Itoa(p.Documents),
+				strconv.Itoa(p.Queries),
+				strconv.Itoa(p.ZeroResults),
+				fmt.Sprintf("%.1fms", p.MeanTookMs),
+				fmt.Sprintf("%dms", p.P95TookMs),
+				stability,
+			)
+		}
+		if err := table.Print(); err != nil {
+			return fmt.Errorf("failed to print scaling: %w", err)
+		}
+		if alg.Degrades() {
+			printer.Warning("%s degrades with scale: %s", alg.Algorithm, strings.Join(alg.Reasons, "; "))
+		}
+	}
+
+	fmt.Println()
+	if report.Degraded == 0 {
+		printer.Success("No algorithm degrades across %d corpora", len(report.Corpora))
+	} else {
+		printer.Warning("%d of %d algorithms degrade as the corpus grows", report.Degraded, len(report.Algorithms))
+	}
+
+	if noWrite {
+		return nil
+	}
+	path := filepath.Join(runFolder, output.CorpusScalingFileName)
+	if err := output.WriteJSONFile(path, report); err != nil {
+		return fmt.Errorf("failed to save scaling report: %w", err)
+	}
+	printer.Info("Location: %s", path)
+	return nil
+}
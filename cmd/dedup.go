@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/config"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/querydedup"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+)
+
+// checkDuplicateQueries warns about queries the suite runs more than once,
+// and drops an algorithm's repeats when execution.merge_duplicates is set
+func checkDuplicateQueries(cfg *config.Config, algorithms []models.AlgorithmConfig, printer *ui.Printer) []models.AlgorithmConfig {
+	groups := querydedup.Find(algorithms)
+	if len(groups) == 0 {
+		return algorithms
+	}
+
+	printer.Warning("%d groups of duplicate queries in the suite:", len(groups))
+	for _, g := range groups {
+		entries := make([]string, len(g.Entries))
+		for i, e := range g.Entries {
+			if g.Kind == querydedup.KindIdentical {
+				entries[i] = e.Algorithm
+			} else {
+				entries[i] = e.QueryID
+			}
+		}
+		switch g.Kind {
+		case querydedup.KindRepeated:
+			printer.Warning("  %s repeats %q as %s", g.Entries[0].Algorithm, g.Normalised, strings.Join(entries, ", "))
+		case querydedup.KindIdentical:
+			printer.Warning("  %s send the same request for %q", strings.Join(entries, ", "), g.Entries[0].Query)
+		}
+	}
+
+	if !cfg.Execution.MergeDuplicates {
+		printer.Info("Set execution.merge_duplicates to run each repeated query once")
+		return algorithms
+	}
+	merged, dropped := querydedup.Merge(algorithms)
+	if dropped > 0 {
+		printer.Info("Merged %d repeated queries; their texts and IDs are kept as aliases", dropped)
+	}
+	return merged
+}
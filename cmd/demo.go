@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ONSdigital/dis-search-test-bed/config"
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch/memory"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/clock"
+	"github.com/ONSdigital/dis-search-test-bed/shared/comparison"
+	"github.com/ONSdigital/dis-search-test-bed/shared/demo"
+	"github.com/ONSdigital/dis-search-test-bed/shared/indexgen"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/shared/paths"
+	"github.com/ONSdigital/dis-search-test-bed/shared/queryexec"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+)
+
+var (
+	demoMode bool
+	demoDir  string
+)
+
+// runDemo runs the built-in suite twice against the built-in corpus, as a
+// previous and a current run, and compares them in every report format.
+// Searches go to the in-memory Elasticsearch fake, so no cluster is needed
+// and the output is the same on every run apart from timestamps.
+func runDemo() error {
+	printer := ui.NewPrinter(verbose)
+
+	dir := demoDir
+	if dir == "" {
+		var err error
+		dir, err = os.MkdirTemp("", "search-testbed-demo-")
+		if err != nil {
+			return fmt.Errorf("failed to create demo directory: %w", err)
+		}
+	}
+
+	cfg := config.Default()
+	cfg.Output.BaseDir = dir
+	cfg.Output.ReportFormats = comparison.SupportedFormats()
+	cfg.Comparison.ShowPreviews = true
+
+	printer.Section("Demo")
+	printer.Info("Corpus: %d sample documents, %d algorithms", len(demo.Corpus()), len(demo.Suite()))
+	printer.Info("Output: %s", dir)
+
+	ctx := context.Background()
+	client := memory.NewClient()
+	now := time.Now().Truncate(time.Second)
+
+	printer.Section("Previous Run")
+	previous, _, err := runDemoSuite(ctx, client, demo.PreviousSuite(), now.Add(-24*time.Hour), dir, printer)
+	if err != nil {
+		return err
+	}
+
+	printer.Section("Current Run")
+	current, runFolder, err := runDemoSuite(ctx, client, demo.Suite(), now, dir, printer)
+	if err != nil {
+		return err
+	}
+
+	if err := generateHistoricalComparison(current, previous, runFolder, runFolder, cfg, printer); err != nil {
+		return err
+	}
+	if err := generateCrossQueryComparison(current, runFolder, cfg, printer); err != nil {
+		return err
+	}
+
+	printer.Celebrate("Demo complete! Reports are in %s", runFolder)
+	return nil
+}
+
+// runDemoSuite loads the corpus and runs the algorithms through the normal
+// loader and query runner, saving the results and corpus in a run folder
+// stamped with at
+func runDemoSuite(ctx context.Context, client *memory.Client, algorithms []models.AlgorithmConfig,
+	at time.Time, baseDir string, printer *ui.Printer) ([]models.QueryResults, string, error) {
+	storedIndex := demo.Index(at)
+	if err := indexgen.NewLoader().LoadIntoElasticsearch(ctx, client, demo.IndexName, storedIndex); err != nil {
+		return nil, "", fmt.Errorf("failed to load demo index: %w", err)
+	}
+
+	executor := queryexec.NewExecutor(client, demo.IndexName, verbose)
+	executor.SetClock(clock.Fixed(at))
+	runner := queryexec.NewRunner(executor, printer)
+
+	results, err := runner.RunAlgorithms(ctx, algorithms)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to run demo queries: %w", err)
+	}
+
+	runFolder, err := paths.DefaultLayout.CreateRunFolder(baseDir, clock.Fixed(at))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create demo run folder: %w", err)
+	}
+	if err := output.NewWriter(runFolder).WriteAll(results, storedIndex); err != nil {
+		return nil, "", fmt.Errorf("failed to write demo results: %w", err)
+	}
+
+	return results, runFolder, nil
+}
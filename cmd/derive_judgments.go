@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ONSdigital/dis-search-test-bed/shared/analytics"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	deriveOut          string
+	deriveMaxGrade     int
+	deriveMinClicks    int64
+	derivePositionBias float64
+	deriveQueryCol     string
+	deriveURICol       string
+	derivePositionCol  string
+	deriveClicksCol    string
+)
+
+var deriveJudgmentsCmd = &cobra.Command{
+	Use:   "derive-judgments <clicks.csv>",
+	Short: "Derive implicit relevance judgments from a click log",
+	Long: `Derive-judgments reads a CSV click log of query, clicked page and position
+(plus a clicks count if the log is aggregated) and grades each clicked page by
+its share of the query's clicks. The most clicked page for a query gets the
+top grade. With --position-bias, clicks lower down the results count for more,
+correcting for users rarely looking past the first few results.
+
+The output uses the judgments file format, so it can be passed to
+'query --judgments'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDeriveJudgments,
+}
+
+func init() {
+	rootCmd.AddCommand(deriveJudgmentsCmd)
+
+	deriveJudgmentsCmd.Flags().StringVarP(&deriveOut, "out", "o", filepath.Join("config", "judgments_implicit.json"),
+		"Judgments file to write")
+	deriveJudgmentsCmd.Flags().IntVar(&deriveMaxGrade, "max-grade", analytics.DefaultMaxGrade,
+		"Grade given to each query's most clicked page")
+	deriveJudgmentsCmd.Flags().Int64Var(&deriveMinClicks, "min-clicks", 1,
+		"Leave pages with fewer clicks unjudged")
+	deriveJudgmentsCmd.Flags().Float64Var(&derivePositionBias, "position-bias", 0,
+		"Position bias exponent η: a click at position p counts p^η times (0 disables, 1 is typical)")
+	deriveJudgmentsCmd.Flags().StringVar(&deriveQueryCol, "query-column", "",
+		"Header of the search query column")
+	deriveJudgmentsCmd.Flags().StringVar(&deriveURICol, "uri-column", "",
+		"Header of the clicked page column")
+	deriveJudgmentsCmd.Flags().StringVar(&derivePositionCol, "position-column", "",
+		"Header of the clicked position column")
+	deriveJudgmentsCmd.Flags().StringVar(&deriveClicksCol, "clicks-column", "",
+		"Header of the clicks column (aggregated logs)")
+}
+
+func runDeriveJudgments(cmd *cobra.Command, args []string) error {
+	printer := ui.NewPrinter(verbose)
+
+	logPath := args[0]
+	f, err := os.Open(logPath)
+	if err != nil {
+		return fmt.Errorf("failed to open click log: %w", err)
+	}
+	defer f.Close()
+
+	importer := analytics.NewImporter(analytics.Options{
+		Source:         filepath.Base(logPath),
+		QueryColumn:    deriveQueryCol,
+		URIColumn:      deriveURICol,
+		PositionColumn: derivePositionCol,
+		ClicksColumn:   deriveClicksCol,
+	})
+
+	clicks, err := importer.ImportClicks(f)
+	if err != nil {
+		return fmt.Errorf("failed to import %s: %w", logPath, err)
+	}
+	printer.Success("Read %d click rows", len(clicks))
+
+	judgments := analytics.DeriveJudgments(clicks, analytics.JudgmentOptions{
+		MaxGrade:     deriveMaxGrade,
+		MinClicks:    deriveMinClicks,
+		PositionBias: derivePositionBias,
+	})
+
+	judged := 0
+	for _, grades := range judgments {
+		judged += len(grades)
+	}
+
+	if err := output.WriteJSONFile(deriveOut, judgments); err != nil {
+		return fmt.Errorf("failed to write judgments: %w", err)
+	}
+
+	printer.Success("Derived %d judgments for %d queries", judged, len(judgments))
+	if derivePositionBias > 0 {
+		printer.Info("Position bias correction: η = %.2f", derivePositionBias)
+	}
+	printer.Info("Location: %s", deriveOut)
+	printer.Info("Use with: search-testbed query --judgments %s", deriveOut)
+	return nil
+}
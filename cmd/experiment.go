@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/experiment"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	experimentQueries string
+	experimentOut     string
+)
+
+var experimentCmd = &cobra.Command{
+	Use:   "experiment",
+	Short: "Work with production A/B experiment definitions",
+}
+
+var experimentGenerateCmd = &cobra.Command{
+	Use:   "generate <experiment.json>",
+	Short: "Generate per-bucket query definitions from an A/B experiment",
+	Long: `Generate reads an A/B experiment definition and writes a queries file with
+one algorithm per bucket, using either the bucket's production query template
+or an algorithm from the existing queries file. Run the generated file with
+'query --queries' and compare buckets with 'compare --mode cross-query'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExperimentGenerate,
+}
+
+func init() {
+	rootCmd.AddCommand(experimentCmd)
+	experimentCmd.AddCommand(experimentGenerateCmd)
+
+	experimentGenerateCmd.Flags().StringVarP(&experimentQueries, "queries", "q", "",
+		"Query configuration file for buckets that reference an algorithm (defaults to config/queries.json)")
+	experimentGenerateCmd.Flags().StringVarP(&experimentOut, "out", "o", "",
+		"Output queries file (defaults to config/experiment_<name>.json)")
+}
+
+func runExperimentGenerate(cmd *cobra.Command, args []string) error {
+	printer := ui.NewPrinter(verbose)
+
+	exp, err := experiment.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to load experiment: %w", err)
+	}
+
+	printer.Info("Experiment: %s (%d buckets)", exp.Name, len(exp.Buckets))
+
+	var existing []models.AlgorithmConfig
+	for _, b := range exp.Buckets {
+		if b.Template != nil {
+			continue
+		}
+		if experimentQueries == "" {
+			experimentQueries = filepath.Join("config", "queries.json")
+		}
+		existing, err = models.LoadAlgorithms(experimentQueries)
+		if err != nil {
+			return fmt.Errorf("failed to load queries: %w", err)
+		}
+		break
+	}
+
+	algorithms, err := exp.Generate(existing)
+	if err != nil {
+		return fmt.Errorf("failed to generate bucket queries: %w", err)
+	}
+
+	if experimentOut == "" {
+		experimentOut = filepath.Join("config", "experiment_"+models.Slugify(exp.Name)+".json")
+	}
+
+	if err := output.WriteJSONFile(experimentOut, algorithms); err != nil {
+		return fmt.Errorf("failed to write queries: %w", err)
+	}
+
+	printer.Section("Bucket Queries Generated")
+	for _, alg := range algorithms {
+		printer.Info("%s: %d queries", alg.Name, len(alg.Queries))
+	}
+	printer.Info("Location: %s", experimentOut)
+	printer.Info("Run with: search-testbed query --queries %s", experimentOut)
+
+	printer.Celebrate("Experiment generation complete!")
+	return nil
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/metrics"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/shared/shardcheck"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	shardsQueriesPath string
+	shardsSample      int
+	shardsK           int
+	shardsOutput      string
+)
+
+var explainShardsCmd = &cobra.Command{
+	Use:   "explain-shards",
+	Short: "Check whether shard-local IDF changes scores or rankings",
+	Long: `Explain-shards reruns a sample of queries against the configured index three
+ways: with the default query_then_fetch, where each shard scores with its own
+term statistics; with dfs_query_then_fetch, where every shard uses index-wide
+statistics; and against shard 0 alone.
+
+For each query it reports whether index-wide statistics change the ranking or
+only the scores, and how far shard-local scores stray from index-wide ones.
+It also lists the document count of every shard copy, since an uneven spread
+makes shard-local IDF worse. Rank flips that appear between environments with
+different shard counts but vanish here under dfs_query_then_fetch are caused
+by shard-local IDF rather than by the query.`,
+	RunE: runExplainShards,
+}
+
+func init() {
+	rootCmd.AddCommand(explainShardsCmd)
+
+	explainShardsCmd.Flags().StringVarP(&shardsQueriesPath, "queries", "q", "",
+		"Query configuration file (defaults to config/queries.json)")
+	explainShardsCmd.Flags().IntVar(&shardsSample, "sample", shardcheck.DefaultSample,
+		"Number of queries to check, spread through the suite (0 for all)")
+	explainShardsCmd.Flags().IntVarP(&shardsK, "k", "k", metrics.DefaultK,
+		"Rank cut-off for ranking agreement")
+	explainShardsCmd.Flags().StringVarP(&shardsOutput, "output", "o", "",
+		"Also save the report as JSON to this file")
+}
+
+func runExplainShards(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	printer := ui.NewPrinter(verbose)
+
+	if shardsQueriesPath == "" {
+		shardsQueriesPath = filepath.Join("config", "queries.json")
+	}
+	algorithms, err := models.LoadAlgorithms(shardsQueriesPath)
+	if err != nil {
+		return fmt.Errorf("failed to load queries: %w", err)
+	}
+
+	client, err := newESClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create ES client: %w", err)
+	}
+
+	ctx := context.Background()
+	if err := client.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to connect to Elasticsearch: %w", err)
+	}
+	if err := uploadScripts(ctx, client, algorithms, "", printer); err != nil {
+		return err
+	}
+
+	index := cfg.Elasticsearch.Index
+	shards, err := client.Shards(ctx, index)
+	if err != nil {
+		return fmt.Errorf("failed to list shards: %w", err)
+	}
+
+	spinner := ui.NewSpinner("Rerunning queries with dfs_query_then_fetch...")
+	spinner.Start()
+	report, err := shardcheck.NewChecker(client, index, shardsK).Run(ctx, algorithms, shardsSample)
+	spinner.Stop()
+	if err != nil {
+		return fmt.Errorf("failed to check queries: %w", err)
+	}
+	report.Shards = shards
+	report.DocSkew = shardcheck.DocSkew(shards)
+
+	printer.Section("Shards")
+	shardTable := ui.NewTable("SHARD", "COPY", "STATE", "DOCS", "NODE")
+	for _, s := range shards {
+		copyType := "replica"
+		if s.Primary {
+			copyType = "primary"
+		}
+		shardTable.AddRow(strconv.Itoa(s.Shard), copyType, s.State, strconv.Itoa(s.Docs), s.Node)
+	}
+	if err := shardTable.Print(); err != nil {
+		return fmt.Errorf("failed to print shards: %w", err)
+	}
+	printer.Info("Primary document skew: %.1f%% of the mean", report.DocSkew*100)
+
+	printer.Section(fmt.Sprintf("Default vs dfs_query_then_fetch (top %d)", report.K))
+	table := ui.NewTable("QUERY", "ALGORITHM", "EFFECT", "OVERLAP", "RBO", "MOVED", "MAX SCORE Δ", "SHARD 0 Δ")
+	for _, q := range report.Queries {
+		table.AddRow(
+			ui.Truncate(q.Query, showTitleWidth),
+			q.Algorithm,
+			q.Effect,
+			fmt.Sprintf("%.2f", q.Overlap),
+			fmt.Sprintf("%.3f", q.RBO),
+			strconv.Itoa(q.Moved),
+			fmt.Sprintf("%.2f%%", q.MaxScoreChange*100),
+			fmt.Sprintf("%.2f%%", q.ShardDistortion*100),
+		)
+	}
+	if err := table.Print(); err != nil {
+		return fmt.Errorf("failed to print checks: %w", err)
+	}
+
+	fmt.Println()
+	switch {
+	case report.Reordered > 0:
+		printer.Warning("Shard-local IDF reorders %d of %d queries; compare environments with dfs_query_then_fetch or a single shard",
+			report.Reordered, len(report.Queries))
+	case report.Rescored > 0:
+		printer.Info("Shard-local IDF changes scores for %d of %d queries but no rankings yet",
+			report.Rescored, len(report.Queries))
+	default:
+		printer.Success("Shard-local IDF has no effect on the %d queries checked", len(report.Queries))
+	}
+
+	if shardsOutput != "" {
+		if err := output.WriteJSONFile(shardsOutput, report); err != nil {
+			return fmt.Errorf("failed to save report: %w", err)
+		}
+		printer.Info("Location: %s", shardsOutput)
+	}
+
+	return nil
+}
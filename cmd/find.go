@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ONSdigital/dis-search-test-bed/shared/find"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	findURI       string
+	findTitle     string
+	findQuery     string
+	findAlgorithm string
+	findRun       string
+	findTop       int
+)
+
+var findCmd = &cobra.Command{
+	Use:   "find",
+	Short: "Find which runs and queries returned matching results",
+	Long: `Find searches the results stored in every run folder (or one run with
+--run) and lists the run, query, algorithm and rank of each result whose URI or
+title contains the given text. Matching ignores case.`,
+	RunE: runFind,
+}
+
+func init() {
+	rootCmd.AddCommand(findCmd)
+
+	findCmd.Flags().StringVar(&findURI, "uri", "",
+		"Match results whose URI contains this text")
+	findCmd.Flags().StringVar(&findTitle, "title", "",
+		"Match results whose title contains this text")
+	findCmd.Flags().StringVarP(&findQuery, "query", "q", "",
+		"Only search this query (text, ID or alias)")
+	findCmd.Flags().StringVarP(&findAlgorithm, "algorithm", "a", "",
+		"Only search results from this algorithm")
+	findCmd.Flags().StringVar(&findRun, "run", "",
+		"Only search this run (folder, folder name or results file)")
+	findCmd.Flags().IntVar(&findTop, "top", 0,
+		"Only match results ranked in the top N (0 for any rank)")
+}
+
+func runFind(cmd *cobra.Command, args []string) error {
+	criteria := find.Criteria{
+		URI:       findURI,
+		Title:     findTitle,
+		Query:     findQuery,
+		Algorithm: findAlgorithm,
+		MaxRank:   findTop,
+	}
+	if criteria.IsEmpty() {
+		return fmt.Errorf("specify --uri or --title to search for")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	printer := ui.NewPrinter(verbose)
+
+	var resultsPaths []string
+	if findRun != "" {
+		path, err := runLayout(cfg).ResolveResults(cfg.Output.BaseDir, findRun)
+		if err != nil {
+			return fmt.Errorf("failed to find results: %w", err)
+		}
+		resultsPaths = append(resultsPaths, path)
+	} else {
+		folders, err := runLayout(cfg).ListRunFolders(cfg.Output.BaseDir)
+		if err != nil {
+			return fmt.Errorf("failed to list runs: %w", err)
+		}
+		for _, folder := range folders {
+			path := filepath.Join(folder, "results.json")
+			if _, err := os.Stat(path); err == nil {
+				resultsPaths = append(resultsPaths, path)
+			}
+		}
+	}
+
+	if len(resultsPaths) == 0 {
+		return fmt.Errorf("no results files found in %s", cfg.Output.BaseDir)
+	}
+
+	var matches []find.Match
+	runsMatched := 0
+	for _, path := range resultsPaths {
+		results, err := output.LoadResults(path)
+		if err != nil {
+			printer.Warning("Skipping %s: %v", path, err)
+			continue
+		}
+
+		found := find.Search(filepath.Base(filepath.Dir(path)), results, criteria)
+		printer.Debug("%s: %d matches", path, len(found))
+		if len(found) > 0 {
+			runsMatched++
+		}
+		matches = append(matches, found...)
+	}
+
+	if len(matches) == 0 {
+		printer.Info("No matching results in %d runs", len(resultsPaths))
+		return nil
+	}
+
+	table := ui.NewTable("RUN", "ALGORITHM", "QUERY", "RANK", "TITLE", "URI")
+	for _, m := range matches {
+		table.AddRow(
+			m.Run,
+			m.Algorithm,
+			m.Query,
+			strconv.Itoa(m.Rank),
+			ui.Truncate(m.Title, showTitleWidth),
+			m.URI,
+		)
+	}
+	if err := table.Print(); err != nil {
+		return fmt.Errorf("failed to print matches: %w", err)
+	}
+
+	fmt.Println()
+	printer.Success("%d matches in %d of %d runs", len(matches), runsMatched, len(resultsPaths))
+	return nil
+}
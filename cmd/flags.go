@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"github.com/ONSdigital/dis-search-test-bed/shared/comparison"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+)
+
+// reportFlaggedResults prints a warning for each top K result carrying a
+// warn flag, such as a withdrawn page
+func reportFlaggedResults(flagged []comparison.FlaggedResult, k int, printer *ui.Printer) {
+	if len(flagged) == 0 {
+		printer.Success("No flagged content in the top %d", k)
+		return
+	}
+	printer.Warning("%d top %d results carry editorial flags:", len(flagged), k)
+	for _, r := range flagged {
+		printer.Warning("  %s", r.Describe())
+	}
+}
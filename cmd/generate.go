@@ -4,9 +4,8 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
+	"github.com/ONSdigital/dis-search-test-bed/shared/clock"
 	"github.com/ONSdigital/dis-search-test-bed/shared/indexgen"
-	"github.com/ONSdigital/dis-search-test-bed/shared/paths"
 	"github.com/ONSdigital/dis-search-test-bed/ui"
 	"github.com/spf13/cobra"
 )
@@ -22,6 +21,8 @@ dataset.`,
 
 func init() {
 	rootCmd.AddCommand(generateCmd)
+
+	addLabelFlag(generateCmd)
 }
 
 func runGenerate(cmd *cobra.Command, args []string) error {
@@ -29,19 +30,26 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if err := requireWritable(); err != nil {
+		return err
+	}
+	if err := validateLabels(); err != nil {
+		return err
+	}
 
 	printer := ui.NewPrinter(verbose)
 	printer.Info("Configuration loaded from: %s", cfgFile)
 
 	if verbose {
-		printer.Debug("Elasticsearch URL: %s", cfg.Elasticsearch.URL)
+		printer.Debug("Elasticsearch: %s", cfg.Elasticsearch.Endpoint())
 		printer.Debug("Target document count: %d", cfg.Generation.DocumentCount)
 	}
 
 	spinner := ui.NewSpinner("Connecting to Elasticsearch...")
 	spinner.Start()
+	endPhase := phases.Start(phaseConnect)
 
-	client, err := elasticsearch.NewClient(cfg.Elasticsearch.URL)
+	client, err := newESClient(cfg)
 	if err != nil {
 		spinner.Stop()
 		return fmt.Errorf("failed to create ES client: %w", err)
@@ -53,6 +61,7 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to connect to Elasticsearch: %w", err)
 	}
 
+	endPhase()
 	spinner.Stop()
 	printer.Success("Connected to Elasticsearch")
 
@@ -70,6 +79,8 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 	spinner = ui.NewSpinner(fmt.Sprintf("Fetching %d documents...",
 		cfg.Generation.DocumentCount))
 	spinner.Start()
+	endPhase = phases.Start(phaseFetch)
+	progress := ui.NewProgress(phaseFetch, cfg.Generation.DocumentCount)
 
 	storedIndex, err := generator.Generate(ctx, sourceIndex,
 		cfg.Generation.DocumentCount)
@@ -78,31 +89,47 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to generate index: %w", err)
 	}
 
+	progress.Done()
+	endPhase()
 	spinner.Stop()
 	printer.Success("Fetched %d documents", len(storedIndex.Documents))
 
 	// Save index
-	runFolder, err := paths.CreateRunFolder(cfg.Output.BaseDir)
+	runFolder, err := runLayout(cfg).CreateRunFolder(cfg.Output.BaseDir, clock.Real{})
 	if err != nil {
 		return fmt.Errorf("failed to create run folder: %w", err)
 	}
+	profileTo(runFolder)
 
 	spinner = ui.NewSpinner("Saving index...")
 	spinner.Start()
+	endPhase = phases.Start(phaseSave)
 
 	if err := generator.Save(storedIndex, runFolder); err != nil {
 		spinner.Stop()
 		return fmt.Errorf("failed to save index: %w", err)
 	}
 
+	endPhase()
 	spinner.Stop()
 
+	if err := saveLabels(runFolder, printer); err != nil {
+		return err
+	}
+	if err := recordInvocation(runFolder, "", ""); err != nil {
+		return err
+	}
+
 	printer.Section("Index Generated")
 	printer.Info("Location: %s", runFolder)
 	printer.Info("Documents: %d", len(storedIndex.Documents))
 	printer.Info("Source: %s", sourceIndex)
 	printer.Info("Version: %s", storedIndex.Version)
 
+	if err := reportTimings("generate", runFolder, printer); err != nil {
+		return err
+	}
+
 	printer.Celebrate("Index generation complete!")
 	return nil
 }
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ONSdigital/dis-search-test-bed/shared/analytics"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	analyticsRun       string
+	analyticsURICol    string
+	analyticsViewsCol  string
+	analyticsClicksCol string
+)
+
+var importAnalyticsCmd = &cobra.Command{
+	Use:   "import-analytics <export.csv>",
+	Short: "Import page views and clicks from an analytics export",
+	Long: `Import-analytics reads a CSV analytics export mapping pages to page views
+(and optionally clicks) and stores it as analytics.json next to the run's
+index.json. Page, views and clicks columns are detected from common header
+names unless given explicitly.
+
+The imported traffic can be used as document weights for popularity boosts
+(test_data.analytics_weights) and for traffic-weighted metrics.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImportAnalytics,
+}
+
+func init() {
+	rootCmd.AddCommand(importAnalyticsCmd)
+
+	importAnalyticsCmd.Flags().StringVar(&analyticsRun, "run", "",
+		"Run folder to store the analytics in (defaults to the latest index's run)")
+	importAnalyticsCmd.Flags().StringVar(&analyticsURICol, "uri-column", "",
+		"Header of the page column")
+	importAnalyticsCmd.Flags().StringVar(&analyticsViewsCol, "views-column", "",
+		"Header of the page views column")
+	importAnalyticsCmd.Flags().StringVar(&analyticsClicksCol, "clicks-column", "",
+		"Header of the clicks column")
+}
+
+func runImportAnalytics(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	printer := ui.NewPrinter(verbose)
+
+	runFolder := analyticsRun
+	if runFolder == "" {
+		latest, err := runLayout(cfg).FindLatestIndex(cfg.Output.BaseDir)
+		if err != nil {
+			return fmt.Errorf("failed to find latest index: %w", err)
+		}
+		runFolder = filepath.Dir(latest)
+	} else if _, err := os.Stat(filepath.Join(runFolder, "index.json")); err != nil {
+		runFolder = filepath.Join(cfg.Output.BaseDir, runFolder)
+	}
+
+	runLock, err := lockRunFolder(cfg, runFolder)
+	if err != nil {
+		return err
+	}
+	defer releaseRunFolder(runLock)
+
+	exportPath := args[0]
+	f, err := os.Open(exportPath)
+	if err != nil {
+		return fmt.Errorf("failed to open export: %w", err)
+	}
+	defer f.Close()
+
+	importer := analytics.NewImporter(analytics.Options{
+		Source:       filepath.Base(exportPath),
+		URIColumn:    analyticsURICol,
+		ViewsColumn:  analyticsViewsCol,
+		ClicksColumn: analyticsClicksCol,
+	})
+
+	traffic, err := importer.Import(f)
+	if err != nil {
+		return fmt.Errorf("failed to import %s: %w", exportPath, err)
+	}
+
+	path := filepath.Join(runFolder, output.AnalyticsFileName)
+	if err := output.WriteJSONFile(path, traffic); err != nil {
+		return fmt.Errorf("failed to save analytics: %w", err)
+	}
+
+	printer.Success("Imported traffic for %d pages", len(traffic.Pages))
+
+	uris := make([]string, 0, len(traffic.Pages))
+	for uri := range traffic.Pages {
+		uris = append(uris, uri)
+	}
+	sort.Slice(uris, func(i, j int) bool {
+		return traffic.Pages[uris[i]].PageViews > traffic.Pages[uris[j]].PageViews
+	})
+	if len(uris) > 5 {
+		uris = uris[:5]
+	}
+	for _, uri := range uris {
+		printer.Info("%s: %d page views", uri, traffic.Pages[uri].PageViews)
+	}
+
+	printer.Info("Location: %s", path)
+	return nil
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ONSdigital/dis-search-test-bed/config"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/indexgen"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/shared/preview"
+	"github.com/ONSdigital/dis-search-test-bed/shared/review"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	judgeRun  string
+	judgeOut  string
+	judgeTopN int
+)
+
+var judgeCmd = &cobra.Command{
+	Use:   "judge",
+	Short: "Grade a run's results interactively to build relevance judgments",
+	Long: `Judge walks through a run's results query by query, showing each result's
+title, URI and a snippet of the document, and asks for a relevance grade from
+0 (not relevant) to 3 (perfect match). The top results of every algorithm are
+pooled, so a page returned by several is graded once.
+
+Grades are saved after each query to judgments.json in the run folder, or to
+--out, in the JSON format read by 'metrics', 'compare' and 'query
+--judgments'. Results already in that file are skipped, so a session can be
+left with q and picked up later.`,
+	RunE: runJudge,
+}
+
+func init() {
+	rootCmd.AddCommand(judgeCmd)
+
+	judgeCmd.Flags().StringVar(&judgeRun, "run", "",
+		"Run to judge (folder, folder name or results file; defaults to latest)")
+	judgeCmd.Flags().StringVarP(&judgeOut, "out", "o", "",
+		"Judgments file to write and resume from (defaults to judgments.json in the run folder)")
+	judgeCmd.Flags().IntVar(&judgeTopN, "top", review.DefaultJudgeTopN,
+		"Results per algorithm to judge for each query")
+}
+
+func runJudge(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if err := requireWritable(); err != nil {
+		return err
+	}
+
+	printer := ui.NewPrinter(verbose)
+
+	resultsPath, err := runLayout(cfg).ResolveResults(cfg.Output.BaseDir, judgeRun)
+	if err != nil {
+		return fmt.Errorf("failed to find results: %w", err)
+	}
+	results, err := output.LoadResults(resultsPath)
+	if err != nil {
+		return fmt.Errorf("failed to load results: %w", err)
+	}
+	runFolder := filepath.Dir(resultsPath)
+
+	inRunFolder := judgeOut == ""
+	if inRunFolder {
+		judgeOut = filepath.Join(runFolder, output.JudgmentsFileName)
+	}
+
+	judgments := make(models.Judgments)
+	if _, err := os.Stat(judgeOut); err == nil {
+		if judgments, err = models.LoadJudgments(judgeOut); err != nil {
+			return fmt.Errorf("failed to load judgments: %w", err)
+		}
+		printer.Info("Resuming from %s (%d queries judged)", judgeOut, len(judgments))
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read judgments: %w", err)
+	}
+
+	printer.Info("Results: %s", resultsPath)
+
+	judged, err := review.Judge(os.Stdin, os.Stdout, results, judgments, review.JudgeOptions{
+		TopN:    judgeTopN,
+		Snippet: snippetFunc(runFolder, cfg, printer),
+		Save: func(judgments models.Judgments) error {
+			return saveJudgments(cfg, runFolder, inRunFolder, judgments)
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	printer.Success("Recorded %d grades", judged)
+	if judged > 0 {
+		printer.Info("Location: %s", judgeOut)
+	}
+	return nil
+}
+
+// saveJudgments writes the judgments to judgeOut, holding the run folder
+// lock only while writing so other commands can use the run mid-session
+func saveJudgments(cfg *config.Config, runFolder string, inRunFolder bool, judgments models.Judgments) error {
+	if inRunFolder {
+		runLock, err := lockRunFolder(cfg, runFolder)
+		if err != nil {
+			return err
+		}
+		defer releaseRunFolder(runLock)
+	}
+	return output.WriteJSONFile(judgeOut, judgments)
+}
+
+// snippetFunc returns document snippets from the run's stored index, or nil
+// if it can't be loaded
+func snippetFunc(runFolder string, cfg *config.Config, printer *ui.Printer) func(uri, query string) string {
+	indexPath := filepath.Join(runFolder, "index.json")
+	index, err := indexgen.NewLoader().Load(indexPath)
+	if err != nil {
+		printer.Debug("Snippets disabled, could not load %s: %v", indexPath, err)
+		return nil
+	}
+
+	previewer := preview.NewPreviewer(index, cfg.Comparison.PreviewLength)
+	return func(uri, query string) string {
+		p, _ := previewer.Preview(uri, query)
+		return p.Snippet
+	}
+}
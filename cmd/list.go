@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/shared/paths"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+var listLast int
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List run folders with what each holds",
+	Long: `List prints a table of the run folders under output.base_dir, newest first:
+when each was created, its labels, how many documents its index held, how
+many queries it ran, the algorithms used, and whether compare has saved
+reports for it. Only folders that fit output.layout are listed. List only
+reads runs, so it is safe on read-only data.`,
+	RunE: runList,
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+
+	listCmd.Flags().IntVar(&listLast, "last", 0,
+		"Only list the most recent N runs (0 for all)")
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	printer := ui.NewPrinter(verbose)
+	layout := runLayout(cfg)
+
+	folders, err := layout.ListRunFolders(cfg.Output.BaseDir)
+	if err != nil {
+		return fmt.Errorf("failed to list runs: %w", err)
+	}
+	if len(folders) == 0 {
+		printer.Info("No runs found in %s", cfg.Output.BaseDir)
+		return nil
+	}
+	if listLast > 0 && len(folders) > listLast {
+		folders = folders[:listLast]
+	}
+
+	table := ui.NewTable("RUN", "CREATED", "LABELS", "DOCUMENTS", "QUERIES", "ALGORITHMS", "COMPARED")
+	for _, folder := range folders {
+		created := "-"
+		if at, err := layout.Timestamp(folder); err == nil {
+			created = at.Format("2006-01-02 15:04:05")
+		}
+
+		summary, err := output.SummariseRun(folder, layout)
+		if err != nil {
+			printer.Warning("Skipping %s: %v", paths.RunID(folder), err)
+			continue
+		}
+
+		documents := "-"
+		if summary.Documents >= 0 {
+			documents = strconv.Itoa(summary.Documents)
+		}
+		queries, algorithms := "-", "-"
+		if summary.HasResults {
+			queries = strconv.Itoa(summary.Queries)
+			algorithms = strings.Join(summary.Algorithms, ", ")
+		}
+		labels := "-"
+		if len(summary.Labels) > 0 {
+			labels = strings.Join(summary.Labels, ", ")
+		}
+		compared := "no"
+		if summary.Compared {
+			compared = "yes"
+		}
+
+		table.AddRow(paths.RunID(folder), created, labels, documents, queries, algorithms, compared)
+	}
+
+	return table.Print()
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/indexgen"
+	"github.com/ONSdigital/dis-search-test-bed/shared/metrics"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	metricsRun       string
+	metricsJudgments string
+	metricsK         int
+)
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Score a run's results with Precision@K, Recall@K, MRR and NDCG@K",
+	Long: `Metrics scores a stored run against graded relevance judgments, printing
+Precision@K, Recall@K, NDCG@K and the reciprocal rank of the first relevant
+result for every judged query, and each algorithm's means (MRR for reciprocal
+rank).
+Results with a grade above 0 count as relevant; unjudged results don't.
+
+Judgments use the JSON format of 'query --judgments' or TREC qrels lines, and
+default to comparison.judgments_file. Queries with nothing judged relevant are
+skipped. Metrics only reads the run, so it is safe on read-only data.`,
+	RunE: runMetrics,
+}
+
+func init() {
+	rootCmd.AddCommand(metricsCmd)
+
+	metricsCmd.Flags().StringVar(&metricsRun, "run", "",
+		"Run to score (folder, folder name or results file; defaults to latest)")
+	metricsCmd.Flags().StringVar(&metricsJudgments, "judgments", "",
+		"Judgments file, JSON or TREC qrels (defaults to comparison.judgments_file)")
+	metricsCmd.Flags().IntVarP(&metricsK, "k", "k", metrics.DefaultK,
+		"Rank cut-off for precision and recall")
+}
+
+func runMetrics(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	printer := ui.NewPrinter(verbose)
+
+	if metricsJudgments == "" {
+		metricsJudgments = cfg.Comparison.JudgmentsFile
+	}
+	if metricsJudgments == "" {
+		return errors.New("no judgments file: pass --judgments or set comparison.judgments_file")
+	}
+	judgments, err := models.LoadJudgments(metricsJudgments)
+	if err != nil {
+		return fmt.Errorf("failed to load judgments: %w", err)
+	}
+
+	resultsPath, err := runLayout(cfg).ResolveResults(cfg.Output.BaseDir, metricsRun)
+	if err != nil {
+		return fmt.Errorf("failed to find results: %w", err)
+	}
+	results, err := output.LoadResults(resultsPath)
+	if err != nil {
+		return fmt.Errorf("failed to load results: %w", err)
+	}
+
+	qualities := metrics.ScoreQuality(results, judgments, metricsK)
+	if len(qualities) == 0 {
+		return fmt.Errorf("none of the queries in %s have relevant judgments in %s", resultsPath, metricsJudgments)
+	}
+
+	printer.Info("Results: %s", resultsPath)
+	printer.Info("Judgments: %s (%d queries)", metricsJudgments, len(judgments))
+	checkJudgedURIs(judgments, filepath.Dir(resultsPath), printer)
+
+	precision, recall := fmt.Sprintf("P@%d", metricsK), fmt.Sprintf("R@%d", metricsK)
+	ndcg := fmt.Sprintf("NDCG@%d", metricsK)
+
+	printer.Section("Per Query")
+	table := ui.NewTable("QUERY", "ALGORITHM", precision, recall, ndcg, "RR")
+	for _, q := range qualities {
+		for _, pq := range q.PerQuery {
+			table.AddRow(
+				ui.Truncate(pq.Query, showTitleWidth),
+				q.Algorithm,
+				fmt.Sprintf("%.2f", pq.Precision),
+				fmt.Sprintf("%.2f", pq.Recall),
+				fmt.Sprintf("%.3f", pq.NDCG),
+				fmt.Sprintf("%.3f", pq.ReciprocalRank),
+			)
+		}
+	}
+	if err := table.Print(); err != nil {
+		return fmt.Errorf("failed to print metrics: %w", err)
+	}
+
+	printer.Section("Per Algorithm")
+	table = ui.NewTable("ALGORITHM", "QUERIES", precision, recall, ndcg, "MRR")
+	for _, q := range qualities {
+		table.AddRow(
+			q.Algorithm,
+			strconv.Itoa(q.Queries),
+			fmt.Sprintf("%.2f", q.Precision),
+			fmt.Sprintf("%.2f", q.Recall),
+			fmt.Sprintf("%.3f", q.NDCG),
+			fmt.Sprintf("%.3f", q.MRR),
+		)
+	}
+	if err := table.Print(); err != nil {
+		return fmt.Errorf("failed to print metrics: %w", err)
+	}
+	return nil
+}
+
+// checkJudgedURIs warns about judged URIs that are not in a run's stored
+// index. They can never be returned, so they only ever count as misses.
+func checkJudgedURIs(judgments models.Judgments, runFolder string, printer *ui.Printer) {
+	indexPath := filepath.Join(runFolder, "index.json")
+	index, err := indexgen.NewLoader().Load(indexPath)
+	if err != nil {
+		printer.Debug("Judged URIs not checked, could not load %s: %v", indexPath, err)
+		return
+	}
+	warnUnknownURIs(judgments, index, printer)
+}
+
+// warnUnknownURIs warns about judged URIs missing from a stored index,
+// listing them when verbose
+func warnUnknownURIs(judgments models.Judgments, index *models.StoredIndex, printer *ui.Printer) {
+	unknown := judgments.UnknownURIs(index)
+	if len(unknown) == 0 {
+		return
+	}
+
+	queries := make([]string, 0, len(unknown))
+	total := 0
+	for query, uris := range unknown {
+		queries = append(queries, query)
+		total += len(uris)
+	}
+	sort.Strings(queries)
+
+	printer.Warning("%d judged URIs in %d queries are not in the index; check them for typos or moved pages",
+		total, len(queries))
+	for _, query := range queries {
+		printer.Debug("  %s: %s", query, strings.Join(unknown[query], ", "))
+	}
+}
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/metrics"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	paretoRun       string
+	paretoJudgments string
+	paretoK         int
+	paretoRelevance string
+	paretoLatency   string
+)
+
+// paretoReport is what the pareto command saves to the run folder
+type paretoReport struct {
+	K         int                `json:"k"`
+	Relevance string             `json:"relevance"`
+	Latency   string             `json:"latency"`
+	Tradeoffs []metrics.Tradeoff `json:"tradeoffs"`
+}
+
+var paretoCmd = &cobra.Command{
+	Use:   "pareto",
+	Short: "Plot each algorithm's relevance against its latency",
+	Long: `Pareto places every algorithm in a run by relevance, scored against graded
+judgments as in 'metrics', and by the time its queries took in Elasticsearch.
+Algorithms that no other algorithm beats on both are on the Pareto frontier:
+each is a reasonable choice depending on how much latency a gain in relevance
+is worth. The rest are dominated, and the report names an algorithm that is
+both at least as relevant and at least as fast.
+
+The report is printed as a table and a text scatter plot, and saved as
+pareto.json in the run folder.`,
+	RunE: runPareto,
+}
+
+func init() {
+	rootCmd.AddCommand(paretoCmd)
+
+	paretoCmd.Flags().StringVar(&paretoRun, "run", "",
+		"Run to report on (folder, folder name or results file; defaults to latest)")
+	paretoCmd.Flags().StringVar(&paretoJudgments, "judgments", "",
+		"Judgments file, JSON or TREC qrels (defaults to comparison.judgments_file)")
+	paretoCmd.Flags().IntVarP(&paretoK, "k", "k", metrics.DefaultK,
+		"Rank cut-off for relevance")
+	paretoCmd.Flags().StringVar(&paretoRelevance, "relevance", "ndcg",
+		"Relevance metric: ndcg, mrr, precision or recall")
+	paretoCmd.Flags().StringVar(&paretoLatency, "latency", "p95",
+		"Latency statistic: mean or p95")
+}
+
+func runPareto(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	relevanceOf, err := relevanceMetric(paretoRelevance)
+	if err != nil {
+		return err
+	}
+	if paretoLatency != "mean" && paretoLatency != "p95" {
+		return fmt.Errorf("unknown latency statistic %q: use mean or p95", paretoLatency)
+	}
+
+	printer := ui.NewPrinter(verbose)
+
+	if paretoJudgments == "" {
+		paretoJudgments = cfg.Comparison.JudgmentsFile
+	}
+	if paretoJudgments == "" {
+		return errors.New("no judgments file: pass --judgments or set comparison.judgments_file")
+	}
+	judgments, err := models.LoadJudgments(paretoJudgments)
+	if err != nil {
+		return fmt.Errorf("failed to load judgments: %w", err)
+	}
+
+	resultsPath, err := runLayout(cfg).ResolveResults(cfg.Output.BaseDir, paretoRun)
+	if err != nil {
+		return fmt.Errorf("failed to find results: %w", err)
+	}
+	results, err := output.LoadResults(resultsPath)
+	if err != nil {
+		return fmt.Errorf("failed to load results: %w", err)
+	}
+
+	qualities := metrics.ScoreQuality(results, judgments, paretoK)
+	if len(qualities) == 0 {
+		return fmt.Errorf("none of the queries in %s have relevant judgments in %s", resultsPath, paretoJudgments)
+	}
+
+	latencies := make(map[string]metrics.Latency)
+	recorded := false
+	for _, l := range metrics.MeasureLatency(results) {
+		latencies[l.Algorithm] = l
+		recorded = recorded || l.MeanMs > 0
+	}
+	if !recorded {
+		return fmt.Errorf("no latency recorded in %s", resultsPath)
+	}
+
+	tradeoffs := make([]metrics.Tradeoff, 0, len(qualities))
+	for _, q := range qualities {
+		latency := latencies[q.Algorithm].MeanMs
+		if paretoLatency == "p95" {
+			latency = float64(latencies[q.Algorithm].P95Ms)
+		}
+		tradeoffs = append(tradeoffs, metrics.Tradeoff{
+			Algorithm: q.Algorithm,
+			Relevance: relevanceOf(q),
+			LatencyMs: latency,
+		})
+	}
+	report := paretoReport{
+		K:         paretoK,
+		Relevance: paretoRelevance,
+		Latency:   paretoLatency,
+		Tradeoffs: metrics.ParetoFrontier(tradeoffs),
+	}
+
+	printer.Info("Results: %s", resultsPath)
+	printer.Info("Judgments: %s (%d queries)", paretoJudgments, len(judgments))
+	checkJudgedURIs(judgments, filepath.Dir(resultsPath), printer)
+
+	if err := printPareto(report, printer); err != nil {
+		return err
+	}
+
+	runFolder := filepath.Dir(resultsPath)
+	runLock, err := openRunFolder(cfg, runFolder, printer)
+	if err != nil {
+		return err
+	}
+	defer releaseRunFolder(runLock)
+
+	if noWrite {
+		return nil
+	}
+	path := filepath.Join(runFolder, output.ParetoFileName)
+	if err := output.WriteJSONFile(path, report); err != nil {
+		return fmt.Errorf("failed to save pareto report: %w", err)
+	}
+	printer.Info("Location: %s", path)
+	return nil
+}
+
+// relevanceMetric returns the function picking the named metric from an
+// algorithm's quality scores
+func relevanceMetric(name string) (func(metrics.Quality) float64, error) {
+	switch name {
+	case "ndcg":
+		return func(q metrics.Quality) float64 { return q.NDCG }, nil
+	case "mrr":
+		return func(q metrics.Quality) float64 { return q.MRR }, nil
+	case "precision":
+		return func(q metrics.Quality) float64 { return q.Precision }, nil
+	case "recall":
+		return func(q metrics.Quality) float64 { return q.Recall }, nil
+	default:
+		return nil, fmt.Errorf("unknown relevance metric %q: use ndcg, mrr, precision or recall", name)
+	}
+}
+
+// printPareto prints the tradeoffs as a table and a scatter plot, each
+// algorithm marked with a letter
+func printPareto(report paretoReport, printer *ui.Printer) error {
+	relevance := fmt.Sprintf("%s@%d", report.Relevance, report.K)
+	if report.Relevance == "mrr" {
+		relevance = "mrr"
+	}
+	latency := report.Latency + " latency (ms)"
+
+	printer.Section("Relevance vs Latency")
+	table := ui.NewTable("MARK", "ALGORITHM", relevance, latency, "FRONTIER")
+	plot := ui.NewScatter(latency, relevance, 50, 12)
+	frontier := 0
+	for i, t := range report.Tradeoffs {
+		mark := rune('A' + i%26)
+		status := "yes"
+		if !t.Frontier {
+			status = "dominated by " + t.DominatedBy
+		} else {
+			frontier++
+		}
+		table.AddRow(
+			string(mark),
+			t.Algorithm,
+			fmt.Sprintf("%.3f", t.Relevance),
+			strconv.FormatFloat(t.LatencyMs, 'f', 1, 64),
+			status,
+		)
+		plot.Add(t.LatencyMs, t.Relevance, mark)
+	}
+	if err := table.Print(); err != nil {
+		return fmt.Errorf("failed to print pareto report: %w", err)
+	}
+	fmt.Println()
+	if err := plot.Print(); err != nil {
+		return fmt.Errorf("failed to print pareto report: %w", err)
+	}
+
+	fmt.Println()
+	printer.Success("%d of %d algorithms on the Pareto frontier (higher and further left is better)",
+		frontier, len(report.Tradeoffs))
+	return nil
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/shared/preflight"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	preflightQueriesPath string
+	preflightOffline     bool
+	preflightStrict      bool
+	preflightOutput      string
+)
+
+var preflightCmd = &cobra.Command{
+	Use:   "preflight",
+	Short: "Warn about expensive queries before running them",
+	Long: `Preflight checks every query in the suite before it is run on a shared cluster.
+
+Each query is checked for constructs that are costly on a large index: leading
+wildcards, wildcard, regexp, fuzzy and short prefix queries, bool queries with
+many clauses, terms queries with many values, scripts scoring every matching
+document, and large or deep result windows. Queries are also sent to
+Elasticsearch's _validate/query API with explain and rewrite, which catches
+queries the cluster would reject and shows how many term clauses multi-term
+queries expand to. Use --offline to run the heuristics without a cluster.
+
+Exits with an error if any query would be rejected, or with --strict if any
+query has a warning. 'query --preflight' runs the same checks first.`,
+	RunE: runPreflight,
+}
+
+func init() {
+	rootCmd.AddCommand(preflightCmd)
+
+	preflightCmd.Flags().StringVarP(&preflightQueriesPath, "queries", "q", "",
+		"Query configuration file (defaults to config/queries.json)")
+	preflightCmd.Flags().BoolVar(&preflightOffline, "offline", false,
+		"Only run the heuristics, without validating queries in Elasticsearch")
+	preflightCmd.Flags().BoolVar(&preflightStrict, "strict", false,
+		"Fail if any query has a warning")
+	preflightCmd.Flags().StringVarP(&preflightOutput, "output", "o", "",
+		"Also save the report as JSON to this file")
+}
+
+func runPreflight(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	printer := ui.NewPrinter(verbose)
+
+	if preflightQueriesPath == "" {
+		preflightQueriesPath = filepath.Join("config", "queries.json")
+	}
+	algorithms, err := models.LoadAlgorithms(preflightQueriesPath)
+	if err != nil {
+		return fmt.Errorf("failed to load queries: %w", err)
+	}
+
+	var validator preflight.Validator
+	ctx := context.Background()
+	if !preflightOffline {
+		client, err := newESClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create ES client: %w", err)
+		}
+		if err := client.Ping(ctx); err != nil {
+			return fmt.Errorf("failed to connect to Elasticsearch (use --offline to skip validation): %w", err)
+		}
+		validator = client
+	}
+
+	report, err := checkQueries(ctx, validator, cfg.Elasticsearch.Index, algorithms, printer)
+	if err != nil {
+		return err
+	}
+
+	if preflightOutput != "" {
+		if err := output.WriteJSONFile(preflightOutput, report); err != nil {
+			return fmt.Errorf("failed to save report: %w", err)
+		}
+		printer.Info("Location: %s", preflightOutput)
+	}
+
+	switch {
+	case report.Errors > 0:
+		return fmt.Errorf("%d queries would be rejected by Elasticsearch", report.Errors)
+	case preflightStrict && report.Warnings > 0:
+		return fmt.Errorf("%d queries may be expensive", report.Warnings)
+	}
+	return nil
+}
+
+// checkQueries runs the pre-flight checks and prints every query with an
+// issue. A nil validator runs the heuristics only.
+func checkQueries(ctx context.Context, validator preflight.Validator, index string,
+	algorithms []models.AlgorithmConfig, printer *ui.Printer) (preflight.Report, error) {
+	spinner := ui.NewSpinner("Checking queries...")
+	spinner.Start()
+	report, err := preflight.NewChecker(validator, index, preflight.DefaultLimits()).Run(ctx, algorithms)
+	spinner.Stop()
+	if err != nil {
+		return report, fmt.Errorf("failed to check queries: %w", err)
+	}
+
+	printer.Section("Pre-flight")
+	table := ui.NewTable("QUERY", "ALGORITHM", "SEVERITY", "RULE", "DETAIL")
+	flagged := 0
+	for _, q := range report.Queries {
+		for _, issue := range q.Issues {
+			table.AddRow(ui.Truncate(q.Query, showTitleWidth), q.Algorithm, issue.Severity, issue.Rule, issue.Message)
+			flagged++
+		}
+	}
+	if flagged > 0 {
+		if err := table.Print(); err != nil {
+			return report, fmt.Errorf("failed to print checks: %w", err)
+		}
+		fmt.Println()
+	}
+
+	checked := strconv.Itoa(len(report.Queries)) + " queries"
+	if !report.Validated {
+		checked += " (heuristics only)"
+	}
+	switch {
+	case report.Errors > 0:
+		printer.Error("%s checked: %d would be rejected, %d may be expensive", checked, report.Errors, report.Warnings)
+	case report.Warnings > 0:
+		printer.Warning("%s checked: %d may be expensive", checked, report.Warnings)
+	default:
+		printer.Success("%s checked: no issues found", checked)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+
+	"github.com/ONSdigital/dis-search-test-bed/shared/clock"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+)
+
+// Hidden profiling flags
+var (
+	cpuProfile bool
+	memProfile bool
+	execTrace  bool
+)
+
+// profiles collects the running command's Go profiles in a temporary
+// directory until the command knows its run folder
+var profiles struct {
+	command string
+	dir     string
+	folder  string // Run folder the profiles are saved to; empty keeps them in dir
+	cpu     *os.File
+	trace   *os.File
+	stopped bool
+	err     error // From stopping, returned again by later calls
+}
+
+func init() {
+	flags := rootCmd.PersistentFlags()
+	flags.BoolVar(&cpuProfile, "cpuprofile", false, "Write a CPU profile to the run folder")
+	flags.BoolVar(&memProfile, "memprofile", false, "Write a heap profile to the run folder")
+	flags.BoolVar(&execTrace, "trace", false, "Write an execution trace to the run folder")
+	for _, name := range []string{"cpuprofile", "memprofile", "trace"} {
+		_ = flags.MarkHidden(name)
+	}
+}
+
+// startProfiling starts the profiles asked for by the hidden flags
+func startProfiling(command string) error {
+	if !cpuProfile && !memProfile && !execTrace {
+		return nil
+	}
+
+	dir, err := os.MkdirTemp("", "search-testbed-profiles-")
+	if err != nil {
+		return fmt.Errorf("failed to create profile directory: %w", err)
+	}
+	profiles.command = command
+	profiles.dir = dir
+
+	if cpuProfile {
+		f, err := os.Create(filepath.Join(dir, "cpu.pprof"))
+		if err != nil {
+			return fmt.Errorf("failed to create CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+		profiles.cpu = f
+	}
+	if execTrace {
+		f, err := os.Create(filepath.Join(dir, "trace.out"))
+		if err != nil {
+			return fmt.Errorf("failed to create execution trace: %w", err)
+		}
+		if err := trace.Start(f); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("failed to start execution trace: %w", err)
+		}
+		profiles.trace = f
+	}
+	return nil
+}
+
+// profileTo sets the run folder the profiles are saved to. Commands call it
+// as soon as they have a run folder, so profiles are kept even when the
+// command fails later on.
+func profileTo(runFolder string) {
+	profiles.folder = runFolder
+}
+
+// stopProfiling stops the profiles and saves them. Commands with a locked
+// run folder stop them before releasing the lock; Execute stops them for
+// every other command. Only the first call does anything.
+func stopProfiling() error {
+	if profiles.dir == "" || profiles.stopped {
+		return profiles.err
+	}
+	profiles.stopped = true
+	profiles.err = saveProfiles()
+	return profiles.err
+}
+
+// saveProfiles stops the profiles, writes the heap profile and moves them
+// into the run folder, prefixed with the time and command so profiles of
+// several commands can sit side by side. Without a run folder, or with
+// --no-write, they are left in the temporary directory.
+func saveProfiles() error {
+
+	var errs []error
+	if profiles.cpu != nil {
+		pprof.StopCPUProfile()
+		errs = append(errs, profiles.cpu.Close())
+	}
+	if profiles.trace != nil {
+		trace.Stop()
+		errs = append(errs, profiles.trace.Close())
+	}
+	if memProfile {
+		errs = append(errs, writeHeapProfile(filepath.Join(profiles.dir, "mem.pprof")))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to write profiles: %w", err)
+	}
+
+	printer := ui.NewPrinter(verbose)
+	if profiles.folder == "" || noWrite {
+		printer.Info("Profiles: %s", profiles.dir)
+		return nil
+	}
+
+	dest := filepath.Join(profiles.folder, output.ProfilesDirName)
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		return fmt.Errorf("failed to create profiles directory: %w", err)
+	}
+	entries, err := os.ReadDir(profiles.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read profiles: %w", err)
+	}
+	prefix := clock.Real{}.Now().Format("2006-01-02_15-04-05") + "-" + profiles.command + "-"
+	for _, e := range entries {
+		if err := moveFile(filepath.Join(profiles.dir, e.Name()), filepath.Join(dest, prefix+e.Name())); err != nil {
+			return fmt.Errorf("failed to save profile %s: %w", e.Name(), err)
+		}
+	}
+	if err := os.Remove(profiles.dir); err != nil {
+		return fmt.Errorf("failed to remove temporary profile directory: %w", err)
+	}
+	printer.Info("Profiles: %s", dest)
+	return nil
+}
+
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// moveFile renames a file, copying it when the rename crosses filesystems
+func moveFile(from, to string) error {
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(from)
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/ONSdigital/dis-search-test-bed/config"
+	"github.com/ONSdigital/dis-search-test-bed/shared/paths"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote [run]",
+	Short: "Make a run the baseline",
+	Long: `Promote points the baseline pointer in output.base_dir at a run (a run
+folder, folder name, results file or latest; the latest run by default).
+compare then compares against it by default, and scripts can read its
+artefacts from data/baseline/ without searching for the right run.
+
+The pointer is a symlink, or a file naming the run folder where symlinks
+aren't available. query and run keep the latest pointer up to date the same
+way. baseline set does the same for a named run.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runPromote,
+}
+
+func init() {
+	rootCmd.AddCommand(promoteCmd)
+}
+
+func runPromote(cmd *cobra.Command, args []string) error {
+	if err := requireWritable(); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	var run string
+	if len(args) == 1 {
+		run = args[0]
+	}
+	return setBaseline(cfg, run, ui.NewPrinter(verbose))
+}
+
+// setBaseline points the baseline pointer at a run, the latest by default
+func setBaseline(cfg *config.Config, run string, printer *ui.Printer) error {
+	resultsPath, err := runLayout(cfg).ResolveResults(cfg.Output.BaseDir, run)
+	if err != nil {
+		return fmt.Errorf("failed to find run: %w", err)
+	}
+	runFolder := filepath.Dir(resultsPath)
+
+	if err := paths.SetPointer(cfg.Output.BaseDir, paths.BaselinePointer, runFolder, cfg.Output.LockTimeout); err != nil {
+		return fmt.Errorf("failed to set baseline: %w", err)
+	}
+
+	printer.Success("Baseline is now %s", paths.RunID(runFolder))
+	return nil
+}
@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/shared/querydiff"
+	"github.com/ONSdigital/dis-search-test-bed/shared/queryfeatures"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	queriesDiffOut      string
+	queriesDiffExitCode bool
+)
+
+// errQueriesDiffer is returned by queries diff --exit-code when the files
+// differ
+var errQueriesDiffer = errors.New("query files differ")
+
+var queriesCmd = &cobra.Command{
+	Use:   "queries",
+	Short: "Work with query configuration files",
+}
+
+var queriesDiffCmd = &cobra.Command{
+	Use:   "diff <old.json> <new.json>",
+	Short: "Show what changed between two query files",
+	Long: `Diff compares two query files semantically rather than line by line. It lists
+the algorithms and queries that were added, removed or modified, and for each
+modified query the fields that changed, including a structural diff of the
+es_query body: every changed value is shown by its path, e.g.
+query.multi_match.fields[0], so reformatting and reordering keys don't show up.
+
+Algorithms are paired by name and queries by ID; a renamed query pairs through
+its aliases in the new file. Bodies are compared as they are sent, with any
+algorithm boost applied. --out writes the report as JSON (--out - writes it
+to stdout), and --exit-code exits non-zero when the files differ.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runQueriesDiff,
+}
+
+var queriesFeaturesCmd = &cobra.Command{
+	Use:   "features [queries.json]",
+	Short: "Summarise the Elasticsearch features each algorithm uses",
+	Long: `Features reads a query file (config/queries.json by default) and summarises
+each algorithm's queries in a table: how many clauses they have and how deeply
+they nest, the multi_match types used, and how many queries are boosted (a
+boost, weight or field^boost), use function_score or script_score, filter
+(filter, must_not or post_filter) or are fuzzy. A second table lists the clause
+types and field weightings of each algorithm, so two algorithms can be
+compared at a glance. Bodies are read as they are sent, with any algorithm
+boost applied.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runQueriesFeatures,
+}
+
+var queriesCoverageCmd = &cobra.Command{
+	Use:   "coverage [queries.json]",
+	Short: "Show which production search features the query suite exercises",
+	Long: `Coverage reads a query file (config/queries.json by default) and reports which
+features of the production search service its queries exercise: filters
+(filter, must_not or post_filter), sorting other than by score, typeahead
+(prefix matching or a completion suggester), spelling (a term or phrase
+suggester, or fuzzy matching), pagination (from beyond the first page, or
+search_after) and topic pages (a clause on a topic field).
+
+Each feature is listed with how many queries exercise it, from which
+algorithms, and a few example queries. Features no query exercises are
+warned about, as the places where new queries would add the most.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runQueriesCoverage,
+}
+
+func init() {
+	rootCmd.AddCommand(queriesCmd)
+	queriesCmd.AddCommand(queriesDiffCmd)
+	queriesCmd.AddCommand(queriesFeaturesCmd)
+	queriesCmd.AddCommand(queriesCoverageCmd)
+
+	queriesDiffCmd.Flags().StringVarP(&queriesDiffOut, "out", "o", "",
+		"File to write the JSON report to, or - for stdout")
+	queriesDiffCmd.Flags().BoolVar(&queriesDiffExitCode, "exit-code", false,
+		"Exit non-zero when the files differ")
+}
+
+func runQueriesDiff(cmd *cobra.Command, args []string) error {
+	var w io.Writer = os.Stdout
+	if queriesDiffOut == stdio {
+		ui.SetOutput(os.Stderr)
+		w = os.Stderr
+	}
+	printer := ui.NewPrinter(verbose)
+
+	old, err := models.LoadAlgorithms(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", args[0], err)
+	}
+	new, err := models.LoadAlgorithms(args[1])
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", args[1], err)
+	}
+
+	report := querydiff.Diff(old, new)
+	if report.IsEmpty() {
+		printer.Success("No semantic changes between %s and %s", args[0], args[1])
+	} else {
+		writeQueryDiff(w, report)
+	}
+
+	switch queriesDiffOut {
+	case "":
+	case stdio:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	default:
+		if err := output.WriteJSONFile(queriesDiffOut, report); err != nil {
+			return fmt.Errorf("failed to save query diff: %w", err)
+		}
+		printer.Info("Location: %s", queriesDiffOut)
+	}
+
+	if queriesDiffExitCode && !report.IsEmpty() {
+		return errQueriesDiffer
+	}
+	return nil
+}
+
+// writeQueryDiff writes the report as an indented list, one line per
+// algorithm, query and changed value
+func writeQueryDiff(w io.Writer, report querydiff.Report) {
+	marks := map[querydiff.Kind]string{querydiff.Added: "+", querydiff.Removed: "-", querydiff.Modified: "~"}
+	for _, alg := range report.Algorithms {
+		fmt.Fprintf(w, "%s algorithm %s (%s)\n", marks[alg.Kind], alg.Name, alg.Kind)
+		for _, c := range alg.Settings {
+			fmt.Fprintf(w, "    %s\n", c)
+		}
+		for _, q := range alg.Queries {
+			name := q.ID
+			if q.RenamedTo != "" {
+				name += " → " + q.RenamedTo
+			}
+			fmt.Fprintf(w, "  %s %s %q\n", marks[q.Kind], name, ui.Truncate(q.Query, showTitleWidth))
+			for _, c := range slices.Concat(q.Fields, q.ESQuery) {
+				fmt.Fprintf(w, "      %s\n", c)
+			}
+		}
+	}
+}
+
+func runQueriesFeatures(cmd *cobra.Command, args []string) error {
+	path := filepath.Join("config", "queries.json")
+	if len(args) == 1 {
+		path = args[0]
+	}
+
+	algorithms, err := models.LoadAlgorithms(path)
+	if err != nil {
+		return fmt.Errorf("failed to load queries: %w", err)
+	}
+	report := queryfeatures.Analyse(algorithms)
+
+	table := ui.NewTable("ALGORITHM", "QUERIES", "AVG CLAUSES", "DEPTH", "MULTI_MATCH", "BOOSTED",
+		"FUNCTION_SCORE", "FILTERED", "FUZZY", "SCRIPT")
+	for _, f := range report {
+		share := func(n int) string {
+			return strconv.Itoa(n) + "/" + strconv.Itoa(f.Queries)
+		}
+		multiMatch := "-"
+		if len(f.MultiMatchTypes) > 0 {
+			types := make([]string, 0, len(f.MultiMatchTypes))
+			for t, n := range f.MultiMatchTypes {
+				types = append(types, t+" "+share(n))
+			}
+			slices.Sort(types)
+			multiMatch = strings.Join(types, ", ")
+		}
+		script := "no"
+		if f.Script {
+			script = "yes"
+		}
+		table.AddRow(f.Algorithm, strconv.Itoa(f.Queries), fmt.Sprintf("%.1f", f.AvgClauses),
+			strconv.Itoa(f.MaxDepth), multiMatch, share(f.Boosted), share(f.FunctionScore),
+			share(f.Filtered), share(f.Fuzzy), script)
+	}
+	if err := table.Print(); err != nil {
+		return err
+	}
+	fmt.Println()
+
+	table = ui.NewTable("ALGORITHM", "CLAUSE TYPES", "FIELD BOOSTS")
+	for _, f := range report {
+		types := make([]string, 0, len(f.Clauses))
+		for _, t := range f.ClauseTypes() {
+			types = append(types, t+" "+strconv.Itoa(f.Clauses[t]))
+		}
+		boosts := "-"
+		if len(f.FieldBoosts) > 0 {
+			boosts = strings.Join(f.FieldBoosts, ", ")
+		}
+		table.AddRow(f.Algorithm, strings.Join(types, ", "), boosts)
+	}
+	return table.Print()
+}
+
+func runQueriesCoverage(cmd *cobra.Command, args []string) error {
+	path := filepath.Join("config", "queries.json")
+	if len(args) == 1 {
+		path = args[0]
+	}
+
+	algorithms, err := models.LoadAlgorithms(path)
+	if err != nil {
+		return fmt.Errorf("failed to load queries: %w", err)
+	}
+	report := queryfeatures.Coverage(algorithms)
+
+	total := 0
+	for _, alg := range algorithms {
+		total += len(alg.Queries)
+	}
+
+	table := ui.NewTable("FEATURE", "QUERIES", "ALGORITHMS", "EXAMPLES")
+	for _, c := range report {
+		algs, examples := "-", "-"
+		if c.Covered() {
+			algs = strings.Join(c.Algorithms, ", ")
+			examples = strings.Join(c.Examples, ", ")
+		}
+		table.AddRow(c.Feature, strconv.Itoa(c.Queries)+"/"+strconv.Itoa(total), algs, examples)
+	}
+	if err := table.Print(); err != nil {
+		return err
+	}
+
+	uncovered := queryfeatures.Uncovered(report)
+	if len(uncovered) == 0 {
+		return nil
+	}
+	printer := ui.NewPrinter(verbose)
+	fmt.Println()
+	printer.Warning("%d of %d features have no queries:", len(uncovered), len(report))
+	for _, c := range uncovered {
+		printer.Warning("  %s (%s)", c.Feature, c.Description)
+	}
+	return nil
+}
@@ -3,29 +3,48 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 
+	"github.com/ONSdigital/dis-search-test-bed/config"
 	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
 	"github.com/ONSdigital/dis-search-test-bed/models"
 	"github.com/ONSdigital/dis-search-test-bed/shared/indexgen"
 	"github.com/ONSdigital/dis-search-test-bed/shared/output"
 	"github.com/ONSdigital/dis-search-test-bed/shared/paths"
 	"github.com/ONSdigital/dis-search-test-bed/shared/queryexec"
+	"github.com/ONSdigital/dis-search-test-bed/shared/rankeval"
 	"github.com/ONSdigital/dis-search-test-bed/ui"
 	"github.com/spf13/cobra"
 )
 
 var (
-	indexPath   string
-	queriesPath string
-	loadResults string
+	indexPath    string
+	queriesPath  string
+	loadResults  string
+	batchSize    int
+	weightsPath  string
+	preflightRun bool
+	cacheMode    string
+
+	judgmentsPath  string
+	rankEvalMetric string
+	rankEvalK      int
+	exportSort     string
+	exportSplitCSV bool
+	exportParquet  bool
 )
 
 var queryCmd = &cobra.Command{
 	Use:   "query",
 	Short: "Run queries against stored index",
 	Long: `Query loads a stored index into Elasticsearch, runs configured queries,
-and generates results.`,
+and generates results.
+
+Loading is skipped when Elasticsearch already holds the same snapshot: the
+loader keeps a marker per index in the search-testbed-markers index with a
+fingerprint of the documents and mapping, and reuses the index when the
+fingerprint and document count still match. --force-reload always reloads.`,
 	RunE: runQuery,
 }
 
@@ -38,6 +57,66 @@ func init() {
 		"Query configuration file (defaults to config/queries.json)")
 	queryCmd.Flags().StringVar(&loadResults, "load-results", "",
 		"Load results from file instead of running queries")
+	queryCmd.Flags().IntVar(&batchSize, "batch-size", -1,
+		"Queries per _msearch request, 0 for one request per query (defaults to execution.batch_size)")
+	queryCmd.Flags().StringVar(&weightsPath, "weights", "",
+		"Per-document weights file (JSON or CSV) merged in at load time (defaults to test_data.weights_file)")
+	queryCmd.Flags().BoolVar(&preflightRun, "preflight", false,
+		"Check queries for expensive constructs first, stopping if Elasticsearch would reject any")
+	queryCmd.Flags().StringVar(&cacheMode, "cache", "",
+		"Cache state before measured queries: as-is, clear or warm (defaults to execution.cache_mode)")
+	queryCmd.Flags().StringVar(&judgmentsPath, "judgments", "",
+		"Judgments file; when set, queries are also scored with the Elasticsearch _rank_eval API")
+	queryCmd.Flags().StringVar(&rankEvalMetric, "rank-eval-metric", rankeval.MetricDCG,
+		"Metric for _rank_eval: dcg, err, precision, recall or mrr")
+	queryCmd.Flags().IntVar(&rankEvalK, "rank-eval-k", 10,
+		"Rank cut-off for _rank_eval metrics")
+	addExportFlags(queryCmd)
+	addLabelFlag(queryCmd)
+	addForceReloadFlag(queryCmd)
+	addMappingProfileFlag(queryCmd)
+}
+
+// addExportFlags adds the flags controlling how results are exported
+func addExportFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&exportSort, "sort", "",
+		"Sort exported results by run, query, algorithm or rank (defaults to output.sort)")
+	cmd.Flags().BoolVar(&exportSplitCSV, "split-csv", false,
+		"Also write one results CSV per algorithm (defaults to output.split_csv)")
+	addParquetFlag(cmd)
+}
+
+// addParquetFlag adds the flag for Parquet exports
+func addParquetFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&exportParquet, "parquet", false,
+		"Also export as Parquet tables (defaults to output.parquet)")
+}
+
+// applyExportFlags overrides the output config with the export flags given
+func applyExportFlags(cmd *cobra.Command, cfg *config.Config) error {
+	if exportSort != "" {
+		cfg.Output.Sort = exportSort
+	}
+	if cmd.Flags().Changed("split-csv") {
+		cfg.Output.SplitCSV = exportSplitCSV
+	}
+	if cmd.Flags().Changed("parquet") {
+		cfg.Output.Parquet = exportParquet
+	}
+	if err := output.ValidateSort(cfg.Output.Sort); err != nil {
+		return fmt.Errorf("invalid results sort: %w", err)
+	}
+	return nil
+}
+
+// newResultsWriter creates a writer for a run folder's results with the
+// configured export settings
+func newResultsWriter(cfg *config.Config, runFolder string) *output.Writer {
+	writer := output.NewWriter(runFolder)
+	writer.SetSort(cfg.Output.Sort)
+	writer.SetSplitCSV(cfg.Output.SplitCSV)
+	writer.SetParquet(cfg.Output.Parquet)
+	return writer
 }
 
 func runQuery(cmd *cobra.Command, args []string) error {
@@ -46,119 +125,295 @@ func runQuery(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if err := applyExportFlags(cmd, cfg); err != nil {
+		return err
+	}
+	if err := validateLabels(); err != nil {
+		return err
+	}
+
 	printer := ui.NewPrinter(verbose)
 
+	if cacheMode == "" {
+		cacheMode = cfg.Execution.CacheMode
+	}
+	if err := queryexec.ValidateCacheMode(cacheMode); err != nil {
+		return err
+	}
+
 	// Handle queries path
 	if queriesPath == "" {
 		queriesPath = filepath.Join("config", "queries.json")
 	}
 
+	runFolder, err := queryRunFolder(cfg)
+	if err != nil {
+		return err
+	}
+	runLock, err := lockRunFolder(cfg, runFolder)
+	if err != nil {
+		return err
+	}
+	defer releaseRunFolder(runLock)
+
 	// Load or run queries
 	var allResults []models.QueryResults
-	var runFolder string
-	var storedIndex *models.StoredIndex
+	if loadResults != "" {
+		allResults, err = loadSavedResults(printer)
+	} else {
+		allResults, err = executeQueries(cfg, runFolder, printer)
+	}
+	if err != nil {
+		return err
+	}
 
+	return saveQueryResults(cfg, runFolder, allResults, printer)
+}
+
+// queryRunFolder returns the existing run folder query works in: the one
+// holding --load-results, else the one holding the index, the latest by
+// default. Query never creates a run folder.
+func queryRunFolder(cfg *config.Config) (string, error) {
 	if loadResults != "" {
-		printer.Info("Loading results from %s", loadResults)
-		results, err := output.LoadResults(loadResults)
+		return filepath.Dir(loadResults), nil
+	}
+	if indexPath == "" {
+		latest, err := runLayout(cfg).FindLatestIndex(cfg.Output.BaseDir)
 		if err != nil {
-			return fmt.Errorf("failed to load results: %w", err)
-		}
-		allResults = results
-		runFolder = filepath.Dir(loadResults)
-		printer.Success("Loaded %d query results", len(allResults))
-	} else {
-		// Determine index path
-		if indexPath == "" {
-			latest, err := paths.FindLatestIndex(cfg.Output.BaseDir)
-			if err != nil {
-				return fmt.Errorf("failed to find latest index: %w", err)
-			}
-			indexPath = latest
+			return "", fmt.Errorf("failed to find latest index: %w", err)
 		}
+		indexPath = latest
+	}
+	return filepath.Dir(indexPath), nil
+}
 
-		// Use the run folder from the index (KEY CHANGE)
-		runFolder = filepath.Dir(indexPath)
-		printer.Info("Using run folder: %s", runFolder)
-		printer.Info("Using index: %s", indexPath)
+// loadSavedResults loads the --load-results file instead of running the
+// queries
+func loadSavedResults(printer *ui.Printer) ([]models.QueryResults, error) {
+	printer.Info("Loading results from %s", loadResults)
+	results, err := output.LoadResults(loadResults)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load results: %w", err)
+	}
+	printer.Success("Loaded %d query results", len(results))
+	return results, nil
+}
 
-		// Load stored index
-		spinner := ui.NewSpinner("Loading stored index...")
-		spinner.Start()
+// executeQueries loads the stored index into Elasticsearch, runs the query
+// suite against it and, given judgments, scores the suite with _rank_eval
+func executeQueries(cfg *config.Config, runFolder string, printer *ui.Printer) ([]models.QueryResults, error) {
+	printer.Info("Using run folder: %s", runFolder)
+	printer.Info("Using index: %s", indexPath)
 
-		loader := indexgen.NewLoader()
-		var err error
-		storedIndex, err = loader.Load(indexPath)
-		if err != nil {
-			spinner.Stop()
-			return fmt.Errorf("failed to load index: %w", err)
+	loader, storedIndex, err := loadStoredIndex(cfg, runFolder, printer)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	client, err := connectElasticsearch(ctx, cfg, printer)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadIndexIntoElasticsearch(ctx, cfg, client, loader, storedIndex, runFolder, printer); err != nil {
+		return nil, err
+	}
+
+	algorithms, err := loadQueryAlgorithms(ctx, cfg, client, runFolder, printer)
+	if err != nil {
+		return nil, err
+	}
+	results, err := runQuerySuite(ctx, cfg, client, algorithms, runFolder, printer)
+	if err != nil {
+		return nil, err
+	}
+
+	printer.Success("All queries complete")
+
+	captureClusterSnapshot(ctx, client, cfg.Elasticsearch.Index, runFolder, printer)
+
+	if judgmentsPath != "" {
+		if err := runRankEval(ctx, client, cfg.Elasticsearch.Index, algorithms,
+			storedIndex, runFolder, printer); err != nil {
+			return nil, err
 		}
+	}
+	return results, nil
+}
 
+// loadStoredIndex reads the stored index and merges in any document
+// weights, from a weights file or the run's analytics
+func loadStoredIndex(cfg *config.Config, runFolder string, printer *ui.Printer) (*indexgen.Loader, *models.StoredIndex, error) {
+	spinner := ui.NewSpinner("Loading stored index...")
+	spinner.Start()
+	endPhase := phases.Start(phaseLoadIndex)
+
+	loader, err := newIndexLoader(cfg)
+	if err != nil {
 		spinner.Stop()
-		printer.Success("Loaded index with %d documents", len(storedIndex.Documents))
+		return nil, nil, err
+	}
+	storedIndex, err := loader.Load(indexPath)
+	if err != nil {
+		spinner.Stop()
+		return nil, nil, fmt.Errorf("failed to load index: %w", err)
+	}
 
-		// Connect to Elasticsearch
-		spinner = ui.NewSpinner("Connecting to Elasticsearch...")
-		spinner.Start()
+	endPhase()
+	spinner.Stop()
+	printer.Success("Loaded index with %d documents", len(storedIndex.Documents))
 
-		client, err := elasticsearch.NewClient(cfg.Elasticsearch.URL)
+	if weightsPath == "" {
+		weightsPath = cfg.TestData.WeightsFile
+	}
+	if weightsPath != "" || cfg.TestData.AnalyticsWeights != "" {
+		weights, source, err := loadWeights(weightsPath, cfg.TestData.AnalyticsWeights, runFolder)
 		if err != nil {
-			spinner.Stop()
-			return fmt.Errorf("failed to create ES client: %w", err)
+			return nil, nil, err
 		}
-
-		ctx := context.Background()
-		if err := client.Ping(ctx); err != nil {
-			spinner.Stop()
-			return fmt.Errorf("failed to connect to Elasticsearch: %w", err)
+		if err := mergeWeights(weights, source, storedIndex, runFolder, printer); err != nil {
+			return nil, nil, err
 		}
+	}
+	return loader, storedIndex, nil
+}
 
+// connectElasticsearch creates the Elasticsearch client and checks the
+// cluster can be reached
+func connectElasticsearch(ctx context.Context, cfg *config.Config, printer *ui.Printer) (*elasticsearch.Client, error) {
+	spinner := ui.NewSpinner("Connecting to Elasticsearch...")
+	spinner.Start()
+	endPhase := phases.Start(phaseConnect)
+
+	client, err := newESClient(cfg)
+	if err != nil {
 		spinner.Stop()
-		printer.Success("Connected to Elasticsearch")
+		return nil, fmt.Errorf("failed to create ES client: %w", err)
+	}
+	if err := client.Ping(ctx); err != nil {
+		spinner.Stop()
+		return nil, fmt.Errorf("failed to connect to Elasticsearch: %w", err)
+	}
 
-		// Load index into Elasticsearch
-		spinner = ui.NewSpinner("Loading index into Elasticsearch...")
-		spinner.Start()
+	endPhase()
+	spinner.Stop()
+	printer.Success("Connected to Elasticsearch")
+	return client, nil
+}
 
-		if err := loader.LoadIntoElasticsearch(ctx, client,
-			cfg.Elasticsearch.Index, storedIndex); err != nil {
-			spinner.Stop()
-			return fmt.Errorf("failed to load index: %w", err)
-		}
+// loadIndexIntoElasticsearch bulk loads the stored index into the test
+// index and saves the mapping it was created with
+func loadIndexIntoElasticsearch(ctx context.Context, cfg *config.Config, client *elasticsearch.Client,
+	loader *indexgen.Loader, storedIndex *models.StoredIndex, runFolder string, printer *ui.Printer) error {
+	spinner := ui.NewSpinner("Loading index into Elasticsearch...")
+	spinner.Start()
+	endPhase := phases.Start(phaseBulk)
+	bulkCtx, progress := withBulkProgress(ctx, spinner, "Loading index into Elasticsearch...", len(storedIndex.Documents))
 
+	if err := loader.LoadIntoElasticsearch(bulkCtx, client,
+		cfg.Elasticsearch.Index, storedIndex); err != nil {
 		spinner.Stop()
-		printer.Success("Index loaded")
+		reportBulkFailures(err, printer)
+		return fmt.Errorf("failed to load index: %w", err)
+	}
 
-		// Load and run queries
-		algorithms, err := models.LoadAlgorithms(queriesPath)
-		if err != nil {
-			return fmt.Errorf("failed to load queries: %w", err)
+	progress.Done()
+	endPhase()
+	spinner.Stop()
+	reportIndexLoad(loader, cfg.Elasticsearch.Index, printer)
+	return saveMapping(runFolder, loader.Mapping())
+}
+
+// loadQueryAlgorithms loads the query suite, checks it can be run as asked
+// (pre-flight and rank eval) and uploads the stored scripts it uses
+func loadQueryAlgorithms(ctx context.Context, cfg *config.Config, client *elasticsearch.Client,
+	runFolder string, printer *ui.Printer) ([]models.AlgorithmConfig, error) {
+	algorithms, err := models.LoadAlgorithms(queriesPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load queries: %w", err)
+	}
+	algorithms = checkDuplicateQueries(cfg, algorithms, printer)
+	if judgmentsPath != "" {
+		if err := rankeval.CheckAlgorithms(algorithms); err != nil {
+			return nil, fmt.Errorf("--judgments: %w", err)
 		}
+	}
 
-		totalQueries := 0
-		for _, alg := range algorithms {
-			totalQueries += len(alg.Queries)
+	if preflightRun {
+		report, err := checkQueries(ctx, client, cfg.Elasticsearch.Index, algorithms, printer)
+		if err != nil {
+			return nil, err
+		}
+		if report.Errors > 0 {
+			return nil, fmt.Errorf("pre-flight: %d queries would be rejected by Elasticsearch", report.Errors)
 		}
+	}
 
-		printer.Info("Running %d queries across %d algorithms",
-			totalQueries, len(algorithms))
+	if err := uploadScripts(ctx, client, algorithms, runFolder, printer); err != nil {
+		return nil, err
+	}
+	return algorithms, nil
+}
 
-		executor := queryexec.NewExecutor(client, cfg.Elasticsearch.Index, verbose)
-		runner := queryexec.NewRunner(executor, printer)
+// runQuerySuite runs every algorithm's queries, tracing each search to the
+// run folder
+func runQuerySuite(ctx context.Context, cfg *config.Config, client *elasticsearch.Client,
+	algorithms []models.AlgorithmConfig, runFolder string, printer *ui.Printer) ([]models.QueryResults, error) {
+	totalQueries := 0
+	for _, alg := range algorithms {
+		totalQueries += len(alg.Queries)
+	}
 
-		allResults, err = runner.RunAlgorithms(ctx, algorithms)
-		if err != nil {
-			return fmt.Errorf("failed to run queries: %w", err)
-		}
+	printer.Info("Running %d queries across %d algorithms",
+		totalQueries, len(algorithms))
 
-		printer.Success("All queries complete")
+	trace, closeTrace, err := openTrace(runFolder)
+	if err != nil {
+		return nil, err
 	}
+	defer closeTrace()
 
-	// Write results to the existing run folder (NOT creating a new one)
-	writer := output.NewWriter(runFolder)
+	executor := queryexec.NewExecutor(client, cfg.Elasticsearch.Index, verbose)
+	executor.SetRunID(paths.RunID(runFolder))
+	executor.SetTrace(trace)
+	runner := queryexec.NewRunner(executor, printer)
+
+	if batchSize < 0 {
+		batchSize = cfg.Execution.BatchSize
+	}
+	if batchSize > 1 {
+		printer.Info("Batching queries into _msearch requests of %d", batchSize)
+		runner.SetBatchSize(batchSize)
+	}
+	runner.SetCacheMode(cacheMode, client)
+
+	endPhase := phases.Start(phaseQueries)
+	results, err := runner.RunAlgorithms(ctx, algorithms)
+	endPhase()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run queries: %w", err)
+	}
+	if err := trace.Err(); err != nil {
+		printer.Warning("Trace log incomplete: %v", err)
+	}
+	return results, nil
+}
+
+// saveQueryResults writes the results to the run folder (never a new one),
+// points latest at them and checks the watchlist
+func saveQueryResults(cfg *config.Config, runFolder string, allResults []models.QueryResults, printer *ui.Printer) error {
+	if err := saveLabels(runFolder, printer); err != nil {
+		return err
+	}
+
+	writer := newResultsWriter(cfg, runFolder)
+	if loadResults == "" {
+		writer.SetCacheMode(cacheMode)
+	}
 
 	spinner := ui.NewSpinner("Saving results...")
 	spinner.Start()
+	endPhase := phases.Start(phaseSave)
 
 	// Pass nil for index since it's already in the folder
 	if err := writer.WriteAll(allResults, nil); err != nil {
@@ -166,12 +421,190 @@ func runQuery(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to write results: %w", err)
 	}
 
+	endPhase()
 	spinner.Stop()
 
+	usedQueries, profile := queriesPath, mappingProfile(cfg)
+	if loadResults != "" {
+		usedQueries, profile = "", ""
+	}
+	if err := recordInvocation(runFolder, usedQueries, profile); err != nil {
+		return err
+	}
+
+	// The results are saved, so point latest at them before a watchlist
+	// error can stop the command
+	if loadResults == "" {
+		updateLatest(cfg, runFolder, printer)
+	}
+	if err := checkWatchlist(cfg, runFolder, allResults, printer); err != nil {
+		return err
+	}
+
 	printer.Section("Results Saved")
 	printer.Info("Location: %s", runFolder)
-	printer.Info("Files: results.csv, results.json, metadata.txt")
+	printer.Info("Files: results.csv, results.json, manifest.json, metadata.txt")
+
+	if err := reportTimings("query", runFolder, printer); err != nil {
+		return err
+	}
 
 	printer.Celebrate("Query execution complete!")
 	return nil
 }
+
+// loadWeights loads document weights from a weights file, or failing that
+// from one metric of the analytics imported into the run folder
+func loadWeights(path, analyticsMetric, runFolder string) (models.Weights, string, error) {
+	if path != "" {
+		weights, err := models.LoadWeights(path)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to load weights: %w", err)
+		}
+		return weights, path, nil
+	}
+
+	traffic, err := output.LoadAnalytics(runFolder)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load analytics (run import-analytics first): %w", err)
+	}
+	weights, err := traffic.Weights(analyticsMetric)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load analytics weights: %w", err)
+	}
+	return weights, fmt.Sprintf("%s (%s)", traffic.Source, analyticsMetric), nil
+}
+
+// mergeWeights sets document weights before the index is loaded into
+// Elasticsearch, and saves the weights used to weights.json in the run folder
+func mergeWeights(weights models.Weights, source string, storedIndex *models.StoredIndex,
+	runFolder string, printer *ui.Printer) error {
+	matched := weights.Apply(storedIndex.Documents)
+	if matched == 0 {
+		printer.Warning("No documents matched the weights in %s", source)
+	} else {
+		printer.Success("Merged weights from %s into %d of %d documents",
+			source, matched, len(storedIndex.Documents))
+	}
+
+	if err := output.WriteJSONFile(filepath.Join(runFolder, output.WeightsFileName), weights); err != nil {
+		return fmt.Errorf("failed to save weights: %w", err)
+	}
+
+	return nil
+}
+
+// uploadScripts stores each algorithm's scoring script in Elasticsearch,
+// failing before any query runs if a script doesn't compile, wraps the
+// algorithm's queries to use it and records the script content in
+// scripts.json in the run folder, if one is given
+func uploadScripts(ctx context.Context, client *elasticsearch.Client,
+	algorithms []models.AlgorithmConfig, runFolder string, printer *ui.Printer) error {
+	var records []models.ScriptRecord
+	for i := range algorithms {
+		alg := &algorithms[i]
+		if alg.Script == nil {
+			continue
+		}
+		if err := client.PutScript(ctx, alg.Script.ID, alg.Script.Source); err != nil {
+			return fmt.Errorf("algorithm %s: %w", alg.Name, err)
+		}
+		alg.ApplyScript()
+		records = append(records, alg.Script.Record(alg.Name))
+	}
+
+	if len(records) == 0 {
+		return nil
+	}
+	printer.Success("Uploaded %d scoring scripts", len(records))
+	if runFolder == "" {
+		return nil
+	}
+
+	path := filepath.Join(runFolder, output.ScriptsFileName)
+	if err := output.WriteJSONFile(path, records); err != nil {
+		return fmt.Errorf("failed to save scripts: %w", err)
+	}
+	return nil
+}
+
+// captureClusterSnapshot saves the cluster and index configuration the run
+// was made against. Failures are reported but don't fail the run.
+func captureClusterSnapshot(ctx context.Context, client *elasticsearch.Client, index, runFolder string, printer *ui.Printer) {
+	snapshot, err := client.ClusterSnapshot(ctx, index)
+	if err != nil {
+		printer.Warning("Could not capture cluster state: %v", err)
+		return
+	}
+
+	path := filepath.Join(runFolder, output.ClusterFileName)
+	if err := output.WriteJSONFile(path, snapshot); err != nil {
+		printer.Warning("Could not save cluster state: %v", err)
+		return
+	}
+
+	engine := elasticsearch.ServerInfo{Distribution: snapshot.Distribution, Version: snapshot.Version}
+	printer.Info("%s, %d nodes, index %s: %s shards, %s replicas, refresh %s",
+		engine, len(snapshot.Nodes), snapshot.Index.Name,
+		snapshot.Index.NumberOfShards, snapshot.Index.NumberOfReplicas, snapshot.Index.RefreshInterval)
+}
+
+// runRankEval scores the query suite with Elasticsearch's _rank_eval API
+// and saves the output to rank_eval.json in the run folder
+func runRankEval(ctx context.Context, client *elasticsearch.Client, index string,
+	algorithms []models.AlgorithmConfig, storedIndex *models.StoredIndex,
+	runFolder string, printer *ui.Printer) error {
+	judgments, err := models.LoadJudgments(judgmentsPath)
+	if err != nil {
+		return fmt.Errorf("failed to load judgments: %w", err)
+	}
+	warnUnknownURIs(judgments, storedIndex, printer)
+
+	evaluator, err := rankeval.NewEvaluator(client, index, rankEvalMetric, rankEvalK)
+	if err != nil {
+		return err
+	}
+
+	uriToID := make(map[string]string, len(storedIndex.Documents))
+	for _, doc := range storedIndex.Documents {
+		uriToID[doc.URI] = doc.ID
+	}
+
+	spinner := ui.NewSpinner("Running _rank_eval...")
+	spinner.Start()
+
+	results, err := evaluator.Evaluate(ctx, algorithms, judgments, uriToID)
+	if err != nil {
+		spinner.Stop()
+		return fmt.Errorf("failed to run rank eval: %w", err)
+	}
+
+	spinner.Stop()
+
+	rankEvalPath := filepath.Join(runFolder, "rank_eval.json")
+	if err := output.WriteJSONFile(rankEvalPath, results); err != nil {
+		return fmt.Errorf("failed to write rank eval results: %w", err)
+	}
+
+	printer.Section("Elasticsearch Rank Evaluation")
+	for _, r := range results {
+		printer.Info("%s: %s@%d = %.4f (%d queries judged, %d skipped)",
+			r.Algorithm, r.Metric, r.K, r.Score, len(r.Queries), len(r.Skipped))
+		for _, failure := range r.Failures {
+			printer.Warning("  %s: rank eval failed for query %s", r.Algorithm, failure)
+		}
+	}
+	printer.Success("Rank eval results saved to: %s", rankEvalPath)
+
+	return nil
+}
+
+// openTrace opens the run folder's trace log for appending, so queries
+// re-run into the same folder add to it
+func openTrace(runFolder string) (*queryexec.Trace, func(), error) {
+	f, err := os.OpenFile(filepath.Join(runFolder, output.TraceFileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open trace log: %w", err)
+	}
+	return queryexec.NewTrace(f), func() { _ = f.Close() }, nil
+}
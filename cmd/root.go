@@ -1,17 +1,33 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/ONSdigital/dis-search-test-bed/config"
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
+	"github.com/ONSdigital/dis-search-test-bed/shared/clock"
+	"github.com/ONSdigital/dis-search-test-bed/shared/indexgen"
+	"github.com/ONSdigital/dis-search-test-bed/shared/lock"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/shared/paths"
+	"github.com/ONSdigital/dis-search-test-bed/shared/timing"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
 	"github.com/spf13/cobra"
 )
 
 var (
 	cfgFile     string
 	verbose     bool
+	noWrite     bool
+	progressFmt string
+	runLabels   []string
+	forceReload bool
+	profileName string
 	versionInfo struct {
 		version string
 		commit  string
@@ -23,13 +39,34 @@ var rootCmd = &cobra.Command{
 	Use:   "search-testbed",
 	Short: "Search relevance testing tool",
 	Long: `A comprehensive tool for testing and comparing search algorithm 
-relevance across different configurations and datasets.`,
+relevance across different configurations and datasets.
+
+Run with --demo to try the whole pipeline on a small built-in corpus without
+an Elasticsearch cluster.`,
 	SilenceUsage: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		phases = timing.NewTimer(clock.Real{})
+		if err := ui.ValidateProgressFormat(progressFmt); err != nil {
+			return fmt.Errorf("invalid --progress: %w", err)
+		}
+		ui.SetProgress(progressFmt, os.Stderr)
+		return startProfiling(cmd.Name())
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if demoMode {
+			return runDemo()
+		}
+		return cmd.Help()
+	},
 }
 
 // Execute runs the root command
 func Execute() error {
-	return rootCmd.Execute()
+	err := rootCmd.Execute()
+	if perr := stopProfiling(); perr != nil && err == nil {
+		err = perr
+	}
+	return err
 }
 
 // SetVersionInfo sets version information for the binary
@@ -46,6 +83,15 @@ func init() {
 		"config file (default: $HOME/.search-testbed/config.yaml or ./config/config.yaml)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false,
 		"verbose output")
+	rootCmd.PersistentFlags().BoolVar(&noWrite, "no-write", false,
+		"Never write to the data directory: reports print to stdout, and commands that create runs refuse to start")
+	rootCmd.PersistentFlags().StringVar(&progressFmt, "progress", ui.ProgressText,
+		"Progress reporting: text, or json for JSON Lines events on stderr in place of spinners")
+
+	rootCmd.Flags().BoolVar(&demoMode, "demo", false,
+		"Run the full pipeline on a built-in sample corpus, without Elasticsearch")
+	rootCmd.Flags().StringVar(&demoDir, "demo-dir", "",
+		"Output directory for --demo (defaults to a new temporary directory)")
 
 	rootCmd.AddCommand(versionCmd)
 }
@@ -91,5 +137,306 @@ func loadConfig() (*config.Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config from %s: %w", cfgFile, err)
 	}
+	if err := runLayout(cfg).Validate(); err != nil {
+		return nil, fmt.Errorf("invalid output.layout: %w", err)
+	}
+	if err := elasticsearch.ValidateBackend(cfg.Elasticsearch.Backend); err != nil {
+		return nil, fmt.Errorf("invalid elasticsearch.backend: %w", err)
+	}
+	if err := elasticsearch.ValidateHealth(cfg.Elasticsearch.Readiness.Health); err != nil {
+		return nil, fmt.Errorf("invalid elasticsearch.readiness.health: %w", err)
+	}
 	return cfg, nil
 }
+
+// runLayout returns how run folders are named and arranged
+func runLayout(cfg *config.Config) paths.Layout {
+	return paths.Layout{
+		Folder:     cfg.Output.Layout.Folder,
+		Tag:        cfg.Output.Layout.Tag,
+		Experiment: cfg.Output.Layout.Experiment,
+		ReportsDir: cfg.Output.Layout.ReportsDir,
+		Reports: paths.ReportNames{
+			Historical: cfg.Output.Layout.ReportNames.Historical,
+			CrossQuery: cfg.Output.Layout.ReportNames.CrossQuery,
+			ScoreDrift: cfg.Output.Layout.ReportNames.ScoreDrift,
+		},
+	}
+}
+
+// lockRunFolder takes the advisory lock on a run folder before writing to it,
+// so concurrent commands updating the same run fail clearly instead of
+// overwriting each other's files
+func lockRunFolder(cfg *config.Config, runFolder string) (*lock.Lock, error) {
+	if err := requireWritable(); err != nil {
+		return nil, err
+	}
+	l, err := lock.AcquireDir(runFolder, cfg.Output.LockTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock run folder: %w", err)
+	}
+	profileTo(runFolder)
+	return l, nil
+}
+
+// openRunFolder locks a run folder for a command that only adds reports to
+// existing artefacts. With --no-write, or when the folder turns out to be
+// read-only (e.g. a mounted CI artefact share), no lock is taken and
+// noWrite is set, so the command prints its reports instead of saving them.
+func openRunFolder(cfg *config.Config, runFolder string, printer *ui.Printer) (*lock.Lock, error) {
+	if noWrite {
+		return nil, nil
+	}
+	l, err := lock.AcquireDir(runFolder, cfg.Output.LockTimeout)
+	if errors.Is(err, lock.ErrReadOnly) {
+		printer.Warning("%s is read-only; printing reports instead of saving them", runFolder)
+		noWrite = true
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock run folder: %w", err)
+	}
+	profileTo(runFolder)
+	return l, nil
+}
+
+// releaseRunFolder saves the profiles into a run folder while it is still
+// locked, then releases the lock. Errors saving profiles are returned by
+// Execute.
+func releaseRunFolder(l *lock.Lock) {
+	_ = stopProfiling()
+	_ = l.Release()
+}
+
+// updateLatest points the latest pointer at a run whose results were just
+// written. Failing to is only a warning, as the results are already saved.
+func updateLatest(cfg *config.Config, runFolder string, printer *ui.Printer) {
+	if err := paths.SetPointer(cfg.Output.BaseDir, paths.LatestPointer, runFolder, cfg.Output.LockTimeout); err != nil {
+		printer.Warning("Could not update the latest run pointer: %v", err)
+	}
+}
+
+// recordInvocation adds the running command to a run folder's manifest,
+// with hashes of the config file and, when one was used, the queries file.
+// profile is the mapping profile the index was created with, if any.
+func recordInvocation(runFolder, queriesFile, profile string) error {
+	inv := output.Invocation{
+		Command:    strings.Join(os.Args[1:], " "),
+		At:         clock.Real{}.Now(),
+		Version:    versionInfo.version,
+		Commit:     versionInfo.commit,
+		ConfigFile: cfgFile,
+	}
+	inv.MappingProfile = profile
+	var err error
+	if inv.ConfigHash, err = output.HashFile(cfgFile); err != nil {
+		return fmt.Errorf("failed to hash config: %w", err)
+	}
+	if queriesFile != "" {
+		inv.QueriesFile = queriesFile
+		if inv.QueriesHash, err = output.HashFile(queriesFile); err != nil {
+			return fmt.Errorf("failed to hash queries: %w", err)
+		}
+	}
+	if err := output.RecordInvocation(runFolder, inv); err != nil {
+		return fmt.Errorf("failed to update manifest: %w", err)
+	}
+	return nil
+}
+
+// addForceReloadFlag adds the flag for reloading a snapshot Elasticsearch
+// already holds
+func addForceReloadFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&forceReload, "force-reload", false,
+		"Reload the index even if Elasticsearch already holds this snapshot")
+}
+
+// newIndexLoader creates a loader that skips reloading unchanged snapshots
+// unless --force-reload was given, and waits for the loaded index to be
+// ready to search
+func newIndexLoader(cfg *config.Config) (*indexgen.Loader, error) {
+	mapping, err := indexMapping(cfg)
+	if err != nil {
+		return nil, err
+	}
+	loader := indexgen.NewLoader()
+	loader.SetForceReload(forceReload)
+	loader.SetReadiness(cfg.Elasticsearch.Readiness.Health, cfg.Elasticsearch.Readiness.Timeout)
+	loader.SetMapping(mapping)
+	return loader, nil
+}
+
+// addMappingProfileFlag adds the flag for choosing the settings and mappings
+// profile the index is created with
+func addMappingProfileFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&profileName, "mapping-profile", "",
+		"Create the index with this elasticsearch.mapping_profiles entry (defaults to elasticsearch.mapping_profile)")
+}
+
+// mappingProfile returns the name of the mapping profile to create the index
+// with, or "" for none
+func mappingProfile(cfg *config.Config) string {
+	if profileName != "" {
+		return profileName
+	}
+	return cfg.Elasticsearch.MappingProfile
+}
+
+// indexMapping returns the create index body from elasticsearch.mapping_file,
+// or the built-in mapping when none is set, with the chosen mapping profile
+// merged over it
+func indexMapping(cfg *config.Config) (map[string]interface{}, error) {
+	file := cfg.Elasticsearch.Mapping
+	var profile config.MappingProfileConfig
+	if name := mappingProfile(cfg); name != "" {
+		var ok bool
+		if profile, ok = cfg.Elasticsearch.MappingProfiles[name]; !ok {
+			return nil, fmt.Errorf("unknown mapping profile %q (have: %s)", name, mappingProfileNames(cfg))
+		}
+		if profile.MappingFile != "" {
+			file = profile.MappingFile
+		}
+	}
+
+	mapping := elasticsearch.DefaultMapping()
+	if file != "" {
+		var err error
+		if mapping, err = elasticsearch.LoadMapping(file); err != nil {
+			return nil, fmt.Errorf("failed to load index mapping: %w", err)
+		}
+	}
+	if profile.Settings != nil {
+		mapping = elasticsearch.MergeMapping(mapping, map[string]interface{}{"settings": profile.Settings})
+	}
+	if profile.Mappings != nil {
+		mapping = elasticsearch.MergeMapping(mapping, map[string]interface{}{"mappings": profile.Mappings})
+	}
+	return mapping, nil
+}
+
+// mappingProfileNames lists the configured mapping profiles for error messages
+func mappingProfileNames(cfg *config.Config) string {
+	if len(cfg.Elasticsearch.MappingProfiles) == 0 {
+		return "none configured"
+	}
+	names := make([]string, 0, len(cfg.Elasticsearch.MappingProfiles))
+	for name := range cfg.Elasticsearch.MappingProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// saveMapping records the index settings and mappings a run's index was
+// loaded with in the run folder
+func saveMapping(runFolder string, mapping map[string]interface{}) error {
+	path := filepath.Join(runFolder, output.MappingFileName)
+	if err := output.WriteJSONFile(path, mapping); err != nil {
+		return fmt.Errorf("failed to save index mapping: %w", err)
+	}
+	return nil
+}
+
+// reportIndexLoad says whether the loader reloaded the index or found the
+// snapshot already there
+func reportIndexLoad(loader *indexgen.Loader, index string, printer *ui.Printer) {
+	if loader.Skipped() {
+		printer.Success("Index %s already holds this snapshot; skipped reloading (--force-reload to reload)", index)
+		return
+	}
+	printer.Success("Index loaded")
+}
+
+// addLabelFlag adds the flag for labelling the run a command writes to
+func addLabelFlag(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&runLabels, "label", nil,
+		"Label the run, e.g. synonym-boost-experiment, so it can be given as name:<label> (repeatable)")
+}
+
+// validateLabels checks the --label values before any work is done
+func validateLabels() error {
+	for _, label := range runLabels {
+		if err := paths.ValidateLabel(label); err != nil {
+			return fmt.Errorf("invalid --label: %w", err)
+		}
+	}
+	return nil
+}
+
+// saveLabels stores the --label values in a run folder
+func saveLabels(runFolder string, printer *ui.Printer) error {
+	if len(runLabels) == 0 {
+		return nil
+	}
+	if err := paths.AddLabels(runFolder, runLabels); err != nil {
+		return fmt.Errorf("failed to label run: %w", err)
+	}
+	printer.Info("Labels: %s", strings.Join(runLabels, ", "))
+	return nil
+}
+
+// requireWritable fails commands that create or update runs when --no-write
+// is set
+func requireWritable() error {
+	if noWrite {
+		return errors.New("this command writes to the data directory and can't run with --no-write")
+	}
+	return nil
+}
+
+// newESClient creates an Elasticsearch client from the loaded configuration
+func newESClient(cfg *config.Config) (*elasticsearch.Client, error) {
+	password, err := cfg.Elasticsearch.Password()
+	if err != nil {
+		return nil, err
+	}
+	apiKey, err := cfg.Elasticsearch.APIKey()
+	if err != nil {
+		return nil, err
+	}
+	token, err := cfg.Elasticsearch.BearerToken()
+	if err != nil {
+		return nil, err
+	}
+
+	t := cfg.Elasticsearch.Transport
+	r := cfg.Elasticsearch.Retry
+	return elasticsearch.NewClient(elasticsearch.Config{
+		Addresses:   cfg.Elasticsearch.Nodes(),
+		CloudID:     cfg.Elasticsearch.CloudID,
+		Backend:     cfg.Elasticsearch.Backend,
+		Username:    cfg.Elasticsearch.Username,
+		Password:    password,
+		APIKey:      apiKey,
+		BearerToken: token,
+		Transport: elasticsearch.TransportConfig{
+			CompressRequests:           t.CompressRequests,
+			DisableResponseCompression: t.DisableResponseCompression,
+			MaxIdleConns:               t.MaxIdleConns,
+			MaxIdleConnsPerHost:        t.MaxIdleConnsPerHost,
+			IdleConnTimeout:            t.IdleConnTimeout,
+			DisableKeepAlives:          t.DisableKeepAlives,
+			RequestTimeout:             t.RequestTimeout,
+			DialTimeout:                t.DialTimeout,
+		},
+		Retry: elasticsearch.RetryConfig{
+			Disable:        r.Disable,
+			MaxRetries:     r.MaxRetries,
+			InitialBackoff: r.InitialBackoff,
+			MaxBackoff:     r.MaxBackoff,
+			OnStatus:       r.OnStatus,
+		},
+		Bulk: elasticsearch.BulkConfig{
+			ChunkDocs:  cfg.Elasticsearch.Bulk.ChunkDocs,
+			ChunkBytes: cfg.Elasticsearch.Bulk.ChunkBytes,
+			Workers:    cfg.Elasticsearch.Bulk.Workers,
+		},
+		Proxy: cfg.Elasticsearch.Proxy,
+		TLS: elasticsearch.TLSConfig{
+			InsecureSkipVerify: cfg.Elasticsearch.TLS.InsecureSkipVerify,
+			CAFile:             cfg.Elasticsearch.TLS.CAFile,
+			CertFile:           cfg.Elasticsearch.TLS.CertFile,
+			KeyFile:            cfg.Elasticsearch.TLS.KeyFile,
+		},
+	})
+}
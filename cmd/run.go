@@ -0,0 +1,415 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ONSdigital/dis-search-test-bed/config"
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/clock"
+	"github.com/ONSdigital/dis-search-test-bed/shared/corpus"
+	"github.com/ONSdigital/dis-search-test-bed/shared/metrics"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/shared/paths"
+	"github.com/ONSdigital/dis-search-test-bed/shared/queryexec"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	runQueriesPath string
+	runCorpora     []string
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the query suite across each configured corpus",
+	Long: `Run builds each corpus defined under 'corpora' in the config, loads it into
+its own Elasticsearch index (<index>_<corpus>) and runs the query suite
+against it. Results from every corpus are saved to one new run folder, with
+each result tagged by corpus, so algorithm behaviour can be compared as the
+corpus scales or narrows to a topic.
+
+Tagged results show as "algorithm@corpus" in reports and are matched against
+the same algorithm and corpus in earlier runs. The documents of each corpus
+are saved under corpora/ in the run folder.
+
+Without corpora in the config, the suite runs once against the test_data
+corpus and results are left untagged.`,
+	RunE: runRun,
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+
+	runCmd.Flags().StringVarP(&runQueriesPath, "queries", "q", "",
+		"Query configuration file (defaults to config/queries.json)")
+	runCmd.Flags().StringSliceVar(&runCorpora, "corpus", nil,
+		"Only run these corpora (repeatable or comma-separated)")
+	addExportFlags(runCmd)
+	addLabelFlag(runCmd)
+	addForceReloadFlag(runCmd)
+	addMappingProfileFlag(runCmd)
+}
+
+func runRun(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if err := requireWritable(); err != nil {
+		return err
+	}
+	if err := applyExportFlags(cmd, cfg); err != nil {
+		return err
+	}
+	if err := validateLabels(); err != nil {
+		return err
+	}
+
+	printer := ui.NewPrinter(verbose)
+
+	specs, algorithms, err := loadRunSuite(cfg, printer)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := connectRunCluster(ctx, cfg, printer)
+	if err != nil {
+		return err
+	}
+
+	runFolder, err := runLayout(cfg).CreateRunFolder(cfg.Output.BaseDir, clock.Real{})
+	if err != nil {
+		return fmt.Errorf("failed to create run folder: %w", err)
+	}
+	runLock, err := lockRunFolder(cfg, runFolder)
+	if err != nil {
+		return err
+	}
+	defer releaseRunFolder(runLock)
+	printer.Info("Using run folder: %s", runFolder)
+
+	if err := prepareRunFolder(ctx, cfg, client, algorithms, runFolder, printer); err != nil {
+		return err
+	}
+
+	allResults, documents, err := runAllCorpora(ctx, cfg, client, specs, algorithms, runFolder, printer)
+	if err != nil {
+		return err
+	}
+	if err := saveRunResults(cfg, runFolder, allResults, printer); err != nil {
+		return err
+	}
+	if err := reportCorpora(specs, allResults, documents, runFolder, printer); err != nil {
+		return err
+	}
+
+	// The results are saved, so point latest at them before a watchlist
+	// error can stop the run
+	updateLatest(cfg, runFolder, printer)
+	if err := checkWatchlist(cfg, runFolder, allResults, printer); err != nil {
+		return err
+	}
+
+	printer.Section("Results Saved")
+	printer.Info("Location: %s", runFolder)
+
+	if err := reportTimings("run", runFolder, printer); err != nil {
+		return err
+	}
+	printer.Celebrate("Run complete!")
+	return nil
+}
+
+// loadRunSuite returns the corpora to run and the query suite to run
+// against each of them
+func loadRunSuite(cfg *config.Config, printer *ui.Printer) ([]corpus.Spec, []models.AlgorithmConfig, error) {
+	specs, err := corpusSpecs(cfg, runCorpora)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := queryexec.ValidateCacheMode(cfg.Execution.CacheMode); err != nil {
+		return nil, nil, err
+	}
+
+	if runQueriesPath == "" {
+		runQueriesPath = filepath.Join("config", "queries.json")
+	}
+	algorithms, err := models.LoadAlgorithms(runQueriesPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load queries: %w", err)
+	}
+	return specs, checkDuplicateQueries(cfg, algorithms, printer), nil
+}
+
+// connectRunCluster creates the Elasticsearch client and checks the cluster
+// can be reached
+func connectRunCluster(ctx context.Context, cfg *config.Config, printer *ui.Printer) (*elasticsearch.Client, error) {
+	endPhase := phases.Start(phaseConnect)
+	client, err := newESClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ES client: %w", err)
+	}
+	if err := client.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to Elasticsearch: %w", err)
+	}
+	endPhase()
+	printer.Success("Connected to Elasticsearch")
+	return client, nil
+}
+
+// prepareRunFolder saves the mapping every corpus index is created with and
+// uploads the stored scripts the suite uses
+func prepareRunFolder(ctx context.Context, cfg *config.Config, client *elasticsearch.Client,
+	algorithms []models.AlgorithmConfig, runFolder string, printer *ui.Printer) error {
+	mapping, err := indexMapping(cfg)
+	if err != nil {
+		return err
+	}
+	if err := saveMapping(runFolder, mapping); err != nil {
+		return err
+	}
+	return uploadScripts(ctx, client, algorithms, runFolder, printer)
+}
+
+// runAllCorpora runs the suite against each corpus in turn, tracing every
+// search to the run folder. It returns the results of all corpora and the
+// document count of each.
+func runAllCorpora(ctx context.Context, cfg *config.Config, client *elasticsearch.Client, specs []corpus.Spec,
+	algorithms []models.AlgorithmConfig, runFolder string, printer *ui.Printer) ([]models.QueryResults, map[string]int, error) {
+	trace, closeTrace, err := openTrace(runFolder)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeTrace()
+
+	var allResults []models.QueryResults
+	documents := make(map[string]int, len(specs))
+	for _, spec := range specs {
+		results, docCount, err := runCorpus(ctx, client, cfg, spec, algorithms, runFolder, trace, printer)
+		if err != nil {
+			return nil, nil, err
+		}
+		allResults = append(allResults, results...)
+		documents[spec.Name] = docCount
+	}
+	if err := trace.Err(); err != nil {
+		printer.Warning("Trace log incomplete: %v", err)
+	}
+	return allResults, documents, nil
+}
+
+// saveRunResults writes the results and labels to the run folder and records
+// how they were produced
+func saveRunResults(cfg *config.Config, runFolder string, allResults []models.QueryResults, printer *ui.Printer) error {
+	if err := saveLabels(runFolder, printer); err != nil {
+		return err
+	}
+
+	spinner := ui.NewSpinner("Saving results...")
+	spinner.Start()
+	endPhase := phases.Start(phaseSave)
+	writer := newResultsWriter(cfg, runFolder)
+	writer.SetCacheMode(cfg.Execution.CacheMode)
+	err := writer.WriteAll(allResults, nil)
+	endPhase()
+	spinner.Stop()
+	if err != nil {
+		return fmt.Errorf("failed to write results: %w", err)
+	}
+	return recordInvocation(runFolder, runQueriesPath, mappingProfile(cfg))
+}
+
+// reportCorpora prints the per-corpus summary of a run over named corpora
+// and, given several, how the results scale with corpus size
+func reportCorpora(specs []corpus.Spec, allResults []models.QueryResults, documents map[string]int,
+	runFolder string, printer *ui.Printer) error {
+	if len(specs) == 1 && specs[0].Name == "" {
+		return nil
+	}
+
+	names := make([]string, len(specs))
+	for i, spec := range specs {
+		names[i] = spec.Name
+	}
+
+	printer.Section(fmt.Sprintf("Results by Corpus (overlap@%d with the largest)", metrics.DefaultK))
+	table := ui.NewTable("CORPUS", "DOCS", "ALGORITHM", "QUERIES", "ZERO", "AVG RESULTS", "AVG TOP SCORE", "OVERLAP")
+	for _, row := range corpus.Summarise(allResults, names, documents, metrics.DefaultK) {
+		table.AddRow(
+			row.Corpus,
+			strconv.Itoa(row.Documents),
+			row.Algorithm,
+			strconv.Itoa(row.Queries),
+			strconv.Itoa(row.ZeroResults),
+			fmt.Sprintf("%.1f", row.AvgResults),
+			fmt.Sprintf("%.3f", row.AvgTopScore),
+			fmt.Sprintf("%.2f", row.Overlap),
+		)
+	}
+	if err := table.Print(); err != nil {
+		return fmt.Errorf("failed to print summary: %w", err)
+	}
+
+	if len(specs) > 1 {
+		if chain := scalingChain(specs, documents, printer); len(chain) > 1 {
+			return reportScaling(allResults, chain, runFolder, printer)
+		}
+	}
+	return nil
+}
+
+// runCorpus builds one corpus, loads it into its own index, runs the suite
+// against it and saves its documents to the run folder. Results are tagged
+// with the corpus name.
+func runCorpus(ctx context.Context, client *elasticsearch.Client, cfg *config.Config, spec corpus.Spec,
+	algorithms []models.AlgorithmConfig, runFolder string, trace *queryexec.Trace, printer *ui.Printer) ([]models.QueryResults, int, error) {
+	label, index := spec.Name, cfg.Elasticsearch.Index
+	if spec.Name != "" {
+		index += "_" + spec.Name
+	} else {
+		label = "test_data"
+	}
+
+	docs, err := corpus.Build(spec)
+	if err != nil {
+		return nil, 0, err
+	}
+	printer.Section(fmt.Sprintf("Corpus %s (%d documents)", label, len(docs)))
+
+	stored := &models.StoredIndex{
+		GeneratedAt: clock.Real{}.Now(),
+		Version:     "corpus",
+		SourceIndex: label,
+		Documents:   docs,
+	}
+	if err := saveCorpus(runFolder, spec, stored); err != nil {
+		return nil, 0, err
+	}
+
+	spinner := ui.NewSpinner(fmt.Sprintf("Loading %s into %s...", label, index))
+	spinner.Start()
+	endPhase := phases.Start(phaseBulk)
+	bulkCtx, progress := withBulkProgress(ctx, spinner, fmt.Sprintf("Loading %s into %s...", label, index), len(docs))
+	loader, err := newIndexLoader(cfg)
+	if err != nil {
+		spinner.Stop()
+		return nil, 0, err
+	}
+	err = loader.LoadIntoElasticsearch(bulkCtx, client, index, stored)
+	if err == nil {
+		progress.Done()
+	}
+	endPhase()
+	spinner.Stop()
+	if err != nil {
+		reportBulkFailures(err, printer)
+		return nil, 0, fmt.Errorf("corpus %s: failed to load index: %w", label, err)
+	}
+	if loader.Skipped() {
+		printer.Info("%s already holds %s; skipped reloading", index, label)
+	}
+
+	executor := queryexec.NewExecutor(client, index, verbose)
+	executor.SetRunID(paths.RunID(runFolder))
+	executor.SetTrace(trace)
+	runner := queryexec.NewRunner(executor, printer)
+	if cfg.Execution.BatchSize > 1 {
+		runner.SetBatchSize(cfg.Execution.BatchSize)
+	}
+	runner.SetCacheMode(cfg.Execution.CacheMode, client)
+	endPhase = phases.Start(phaseQueries)
+	results, err := runner.RunAlgorithms(ctx, algorithms)
+	endPhase()
+	if err != nil {
+		return nil, 0, fmt.Errorf("corpus %s: failed to run queries: %w", label, err)
+	}
+
+	for i := range results {
+		results[i].Corpus = spec.Name
+	}
+	return results, len(docs), nil
+}
+
+// saveCorpus records the documents a corpus was built from. An untagged
+// run keeps them as the run's index.json, as query does.
+func saveCorpus(runFolder string, spec corpus.Spec, stored *models.StoredIndex) error {
+	path := filepath.Join(runFolder, "index.json")
+	if spec.Name != "" {
+		path = filepath.Join(runFolder, output.CorporaDirName, spec.Name+".json")
+	}
+	if err := output.WriteJSONFile(path, stored); err != nil {
+		return fmt.Errorf("failed to save corpus %s: %w", spec.Name, err)
+	}
+	return nil
+}
+
+// corpusSpecs returns the configured corpora, narrowed to the named ones if
+// any are given. With none configured, the test_data settings form a single
+// unnamed corpus.
+func corpusSpecs(cfg *config.Config, only []string) ([]corpus.Spec, error) {
+	if len(cfg.Corpora) == 0 {
+		if len(only) > 0 {
+			return nil, fmt.Errorf("no corpora are configured")
+		}
+		td := cfg.TestData
+		return []corpus.Spec{{
+			Description:   td.Description,
+			Mode:          td.Mode,
+			SourceFile:    td.SourceFile,
+			Seed:          td.Seed,
+			DocumentCount: fileCount(td.Mode, td.DocumentCount),
+			IDStrategy:    td.IDStrategy,
+		}}, nil
+	}
+
+	var specs []corpus.Spec
+	for _, c := range cfg.Corpora {
+		specs = append(specs, corpus.Spec{
+			Name:          c.Name,
+			Description:   c.Description,
+			Mode:          c.Mode,
+			SourceFile:    c.SourceFile,
+			Seed:          c.Seed,
+			DocumentCount: c.DocumentCount,
+			ContentTypes:  c.ContentTypes,
+			URIPrefixes:   c.URIPrefixes,
+			IDStrategy:    c.IDStrategy,
+		})
+	}
+	if err := corpus.Validate(specs); err != nil {
+		return nil, fmt.Errorf("invalid corpora: %w", err)
+	}
+
+	if len(only) == 0 {
+		return specs, nil
+	}
+	byName := make(map[string]corpus.Spec, len(specs))
+	for _, s := range specs {
+		byName[s.Name] = s
+	}
+	var selected []corpus.Spec
+	for _, name := range only {
+		s, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown corpus %q", name)
+		}
+		selected = append(selected, s)
+	}
+	return selected, nil
+}
+
+// fileCount keeps the whole file for test_data in file mode, where
+// document_count only applies to random generation
+func fileCount(mode string, count int) int {
+	if mode == corpus.ModeFile {
+		return 0
+	}
+	return count
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/config"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/clock"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/shared/review"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sampleRun  string
+	sampleWith string
+	sampleOut  string
+	sampleOpts review.Options
+)
+
+var sampleCmd = &cobra.Command{
+	Use:   "sample",
+	Short: "Sample queries and their top results into a sheet for manual review",
+	Long: `Sample picks queries from a run, with their top results, into a CSV review
+sheet for periodic human spot-checks. The sheet has one row per result, with
+empty "relevant" and "notes" columns for reviewers, and opens in any
+spreadsheet tool.
+
+The sample is stratified by category (the algorithm a query ran under) and by
+how much its top results changed since the previous run: new, unchanged,
+reordered or changed. Each stratum takes a turn until the sample is full, so
+the few queries that changed are always reviewed alongside the many that
+didn't. The seed used is printed; pass it back with --seed to redraw the same
+sample.
+
+The sheet is saved as qa_sample.csv in the run folder unless --out is given;
+--out - writes it to stdout. An --out file ending in .xlsx is written as an
+Excel workbook instead of CSV.`,
+	RunE: runSample,
+}
+
+func init() {
+	rootCmd.AddCommand(sampleCmd)
+
+	sampleCmd.Flags().StringVar(&sampleRun, "run", "",
+		"Run to sample (folder, folder name or results file; defaults to latest)")
+	sampleCmd.Flags().StringVar(&sampleWith, "with", "",
+		"Results to measure change against (defaults to the run before)")
+	sampleCmd.Flags().StringVarP(&sampleOut, "out", "o", "",
+		"File to write the sheet to (.csv or .xlsx), or - for stdout (defaults to qa_sample.csv in the run folder)")
+	sampleCmd.Flags().IntVarP(&sampleOpts.Size, "n", "n", review.DefaultSize,
+		"Number of queries to sample")
+	sampleCmd.Flags().IntVar(&sampleOpts.TopN, "top", review.DefaultTopN,
+		"Results per query")
+	sampleCmd.Flags().Int64Var(&sampleOpts.Seed, "seed", 0,
+		"Random seed (0 picks one)")
+}
+
+func runSample(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if sampleOut == stdio || (noWrite && sampleOut == "") {
+		ui.SetOutput(os.Stderr)
+	}
+	printer := ui.NewPrinter(verbose)
+
+	resultsPath, err := runLayout(cfg).ResolveResults(cfg.Output.BaseDir, sampleRun)
+	if err != nil {
+		return fmt.Errorf("failed to find results: %w", err)
+	}
+	current, err := output.LoadResults(resultsPath)
+	if err != nil {
+		return fmt.Errorf("failed to load results: %w", err)
+	}
+	printer.Info("Results: %s", resultsPath)
+
+	previous, err := loadSamplePrevious(cfg, resultsPath, printer)
+	if err != nil {
+		return err
+	}
+
+	if sampleOpts.Seed == 0 {
+		sampleOpts.Seed = clock.Real{}.Now().UnixNano()
+	}
+	sample := review.Sample(current, previous, sampleOpts)
+	if len(sample) == 0 {
+		return fmt.Errorf("no queries with results in %s", resultsPath)
+	}
+
+	write := review.WriteCSV
+	if strings.EqualFold(filepath.Ext(sampleOut), ".xlsx") {
+		write = review.WriteXLSX
+	}
+	var buf bytes.Buffer
+	if err := write(&buf, sample); err != nil {
+		return fmt.Errorf("failed to write sample: %w", err)
+	}
+
+	changes := make(map[string]int)
+	for _, item := range sample {
+		changes[item.Change]++
+	}
+	printer.Success("Sampled %d queries (seed %d): %d new, %d unchanged, %d reordered, %d changed",
+		len(sample), sampleOpts.Seed, changes[review.ChangeNew], changes[review.ChangeUnchanged],
+		changes[review.ChangeReordered], changes[review.ChangeChanged])
+
+	if sampleOut == "" {
+		runFolder := filepath.Dir(resultsPath)
+		runLock, err := openRunFolder(cfg, runFolder, printer)
+		if err != nil {
+			return err
+		}
+		defer releaseRunFolder(runLock)
+		if noWrite {
+			sampleOut = stdio
+		} else {
+			sampleOut = filepath.Join(runFolder, output.QASampleFileName)
+		}
+	}
+
+	if sampleOut == stdio {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := output.WriteText(sampleOut, buf.String()); err != nil {
+		return fmt.Errorf("failed to save sample: %w", err)
+	}
+	printer.Info("Location: %s", sampleOut)
+	return nil
+}
+
+// loadSamplePrevious loads the results each query's change is measured
+// against: --with, else the run before the sampled one. With neither, every
+// query counts as new.
+func loadSamplePrevious(cfg *config.Config, resultsPath string, printer *ui.Printer) ([]models.QueryResults, error) {
+	if sampleWith == "" {
+		prevPath, err := runLayout(cfg).FindPreviousResults(cfg.Output.BaseDir, resultsPath)
+		if err != nil {
+			printer.Warning("No previous results found, every query counts as new")
+			return nil, nil
+		}
+		sampleWith = prevPath
+	}
+
+	printer.Info("Change measured against: %s", sampleWith)
+	previous, err := output.LoadResults(sampleWith)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load previous results: %w", err)
+	}
+	return previous, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/ONSdigital/dis-search-test-bed/config"
 	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
 	"github.com/ONSdigital/dis-search-test-bed/models"
 	"github.com/ONSdigital/dis-search-test-bed/testdata"
@@ -21,12 +22,16 @@ Configure via config file:
   - mode: "random" or "file"
   - source_file: path to JSON file (if mode is "file")
   - seed: random seed for reproducibility (if mode is "random")
-  - document_count: number of documents to generate (if mode is "random")`,
+  - document_count: number of documents to generate (if mode is "random")
+  - id_strategy: "sequential" keeps the generated or file IDs; "uri_hash"
+    derives each ID from the document's URI, so IDs don't change when the
+    document count does`,
 	RunE: runSeed,
 }
 
 func init() {
 	rootCmd.AddCommand(seedCmd)
+	addMappingProfileFlag(seedCmd)
 }
 
 func runSeed(cmd *cobra.Command, args []string) error {
@@ -34,12 +39,19 @@ func runSeed(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if err := testdata.ValidateIDStrategy(cfg.TestData.IDStrategy); err != nil {
+		return fmt.Errorf("invalid test_data.id_strategy: %w", err)
+	}
+	mapping, err := indexMapping(cfg)
+	if err != nil {
+		return err
+	}
 
 	printer := ui.NewPrinter(verbose)
 	spinner := ui.NewSpinner("Connecting to Elasticsearch...")
 	spinner.Start()
 
-	client, err := elasticsearch.NewClient(cfg.Elasticsearch.URL)
+	client, err := newESClient(cfg)
 	if err != nil {
 		spinner.Stop()
 		return fmt.Errorf("failed to create ES client: %w", err)
@@ -53,7 +65,7 @@ func runSeed(cmd *cobra.Command, args []string) error {
 	}
 
 	spinner.Stop()
-	printer.Success("Connected to Elasticsearch at %s", cfg.Elasticsearch.URL)
+	printer.Success("Connected to Elasticsearch at %s", cfg.Elasticsearch.Endpoint())
 
 	// Check if index exists
 	indexName := cfg.Elasticsearch.Index
@@ -77,11 +89,16 @@ func runSeed(cmd *cobra.Command, args []string) error {
 		printer.Success("Index deleted")
 	}
 
+	// The seeded documents aren't a stored snapshot, so a later query must
+	// reload its index rather than trust an earlier load marker
+	if err := client.DeleteLoadMarker(ctx, indexName); err != nil {
+		return fmt.Errorf("failed to clear load marker: %w", err)
+	}
+
 	// Create index
 	spinner = ui.NewSpinner("Creating index...")
 	spinner.Start()
 
-	mapping := elasticsearch.DefaultMapping()
 	if err := client.CreateIndex(ctx, indexName, mapping); err != nil {
 		spinner.Stop()
 		return fmt.Errorf("failed to create index: %w", err)
@@ -89,8 +106,25 @@ func runSeed(cmd *cobra.Command, args []string) error {
 
 	spinner.Stop()
 	printer.Success("Index '%s' created", indexName)
+	if profile := mappingProfile(cfg); profile != "" {
+		printer.Info("Mapping profile: %s", profile)
+	}
 
-	// Load or generate documents based on config
+	docs, err := loadSeedDocuments(cfg, printer)
+	if err != nil {
+		return err
+	}
+	if err := indexSeedDocuments(ctx, client, indexName, docs, printer); err != nil {
+		return err
+	}
+
+	printer.Celebrate("Sample data seeding complete!")
+	return nil
+}
+
+// loadSeedDocuments loads the documents from the source file or generates
+// them, as test_data.mode says, and assigns their IDs
+func loadSeedDocuments(cfg *config.Config, printer *ui.Printer) ([]models.Document, error) {
 	var docs []models.Document
 	mode := cfg.TestData.Mode
 
@@ -98,17 +132,17 @@ func runSeed(cmd *cobra.Command, args []string) error {
 
 	if mode == "file" {
 		if cfg.TestData.SourceFile == "" {
-			return fmt.Errorf("test_data.mode is 'file' but source_file is not specified")
+			return nil, fmt.Errorf("test_data.mode is 'file' but source_file is not specified")
 		}
 
 		printer.Info("Loading documents from: %s", cfg.TestData.SourceFile)
-		spinner = ui.NewSpinner("Loading documents from file...")
+		spinner := ui.NewSpinner("Loading documents from file...")
 		spinner.Start()
 
 		loadedDocs, err := testdata.LoadDocumentsFromFile(cfg.TestData.SourceFile)
 		if err != nil {
 			spinner.Stop()
-			return fmt.Errorf("failed to load documents: %w", err)
+			return nil, fmt.Errorf("failed to load documents: %w", err)
 		}
 
 		spinner.Stop()
@@ -122,7 +156,7 @@ func runSeed(cmd *cobra.Command, args []string) error {
 		}
 
 		printer.Info("Generating %d random documents (seed: %d)", docCount, cfg.TestData.Seed)
-		spinner = ui.NewSpinner(fmt.Sprintf("Generating %d documents...", docCount))
+		spinner := ui.NewSpinner(fmt.Sprintf("Generating %d documents...", docCount))
 		spinner.Start()
 
 		docs = testdata.GetSampleDocumentsWithSeed(cfg.TestData.Seed, docCount)
@@ -130,15 +164,30 @@ func runSeed(cmd *cobra.Command, args []string) error {
 		printer.Success("Generated %d documents", docCount)
 	}
 
-	// Index documents
-	spinner = ui.NewSpinner(fmt.Sprintf("Indexing %d documents...", len(docs)))
+	if err := testdata.AssignIDs(docs, cfg.TestData.IDStrategy); err != nil {
+		return nil, fmt.Errorf("failed to assign document IDs: %w", err)
+	}
+	if cfg.TestData.IDStrategy == testdata.IDURIHash {
+		printer.Info("Document IDs derived from URIs")
+	}
+	return docs, nil
+}
+
+// indexSeedDocuments bulk indexes the documents, then refreshes the index
+// and checks they were all indexed
+func indexSeedDocuments(ctx context.Context, client *elasticsearch.Client, indexName string,
+	docs []models.Document, printer *ui.Printer) error {
+	spinner := ui.NewSpinner(fmt.Sprintf("Indexing %d documents...", len(docs)))
 	spinner.Start()
+	bulkCtx, progress := withBulkProgress(ctx, spinner, "Indexing documents...", len(docs))
 
-	if err := client.BulkIndex(ctx, indexName, docs); err != nil {
+	if err := client.BulkIndex(bulkCtx, indexName, docs); err != nil {
 		spinner.Stop()
+		reportBulkFailures(err, printer)
 		return fmt.Errorf("failed to index documents: %w", err)
 	}
 
+	progress.Done()
 	spinner.Stop()
 	printer.Success("Documents indexed successfully")
 
@@ -165,7 +214,5 @@ func runSeed(cmd *cobra.Command, args []string) error {
 	} else {
 		printer.Warning("Expected %d documents, but got %d", len(docs), count)
 	}
-
-	printer.Celebrate("Sample data seeding complete!")
 	return nil
 }
@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/config"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/comparison"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/shared/paths"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+const showTitleWidth = 60
+
+var (
+	showQuery     string
+	showRun       string
+	showAlgorithm string
+)
+
+var showCmd = &cobra.Command{
+	Use:   "show [run]",
+	Short: "Print a run's overview or a query's ranked results",
+	Long: `Show prints an overview of a stored run: when it was created, its document,
+query and algorithm counts, each query's result count, and the summary of
+any comparison saved for it. The run can be a run folder, folder name or
+results file, or latest, previous or baseline; without one the latest run
+is used.
+
+With --query, show prints the ranked results of that query instead. The
+query can be given by its text, ID or an alias; without --algorithm every
+algorithm that ran the query is shown.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runShow,
+}
+
+func init() {
+	rootCmd.AddCommand(showCmd)
+
+	showCmd.Flags().StringVarP(&showQuery, "query", "q", "",
+		"Query text, ID or alias to show results for")
+	showCmd.Flags().StringVar(&showRun, "run", "",
+		"Run folder, run folder name or results file (defaults to latest run)")
+	showCmd.Flags().StringVarP(&showAlgorithm, "algorithm", "a", "",
+		"Only show results from this algorithm")
+}
+
+func runShow(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		if showRun != "" {
+			return fmt.Errorf("give the run as an argument or with --run, not both")
+		}
+		showRun = args[0]
+	}
+
+	printer := ui.NewPrinter(verbose)
+	layout := runLayout(cfg)
+
+	resultsPath, err := layout.ResolveResults(cfg.Output.BaseDir, showRun)
+	if err != nil {
+		return fmt.Errorf("failed to find results: %w", err)
+	}
+
+	results, err := output.LoadResults(resultsPath)
+	if err != nil {
+		return fmt.Errorf("failed to load results: %w", err)
+	}
+
+	if showQuery == "" {
+		return showRunOverview(cfg, layout, filepath.Dir(resultsPath), results, printer)
+	}
+	return showQueryResults(results, resultsPath, printer)
+}
+
+// showQueryResults prints the ranked results of the --query query
+func showQueryResults(results []models.QueryResults, resultsPath string, printer *ui.Printer) error {
+	var matched []models.QueryResults
+	for _, qr := range results {
+		if !qr.Matches(showQuery) {
+			continue
+		}
+		if showAlgorithm != "" && qr.Algorithm != showAlgorithm {
+			continue
+		}
+		matched = append(matched, qr)
+	}
+
+	if len(matched) == 0 {
+		return fmt.Errorf("no results for query %q in %s", showQuery, resultsPath)
+	}
+
+	printer.Info("Results: %s", resultsPath)
+
+	for _, qr := range matched {
+		printer.Section(fmt.Sprintf("%s (%s)", qr.Query, qr.AlgorithmLabel()))
+		if qr.Description != "" {
+			printer.Info("%s", qr.Description)
+		}
+		printer.Info("Run at: %s | Results: %d", qr.RunAt.Format("2006-01-02 15:04:05"), len(qr.Results))
+		fmt.Println()
+
+		table := ui.NewTable("RANK", "SCORE", "TITLE", "TYPE", "DATE", "URI")
+		for _, r := range qr.Results {
+			table.AddRow(
+				strconv.Itoa(r.Rank),
+				fmt.Sprintf("%.4f", r.Score),
+				ui.Truncate(r.Title, showTitleWidth),
+				r.ContentType,
+				r.Date,
+				r.URI,
+			)
+		}
+		if err := table.Print(); err != nil {
+			return fmt.Errorf("failed to print results: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// showRunOverview prints what a run holds and how it compared with the run
+// before
+func showRunOverview(cfg *config.Config, layout paths.Layout, runFolder string,
+	results []models.QueryResults, printer *ui.Printer) error {
+	summary, err := output.SummariseRun(runFolder, layout)
+	if err != nil {
+		return fmt.Errorf("failed to read run: %w", err)
+	}
+	comparisons, err := loadQueryComparisons(layout, runFolder)
+	if err != nil {
+		return err
+	}
+
+	printer.Section(paths.RunID(runFolder))
+	printer.Info("Folder: %s", runFolder)
+	if at, err := layout.Timestamp(runFolder); err == nil {
+		printer.Info("Created: %s", at.Format("2006-01-02 15:04:05"))
+	}
+	var pointers []string
+	for _, name := range []string{paths.LatestPointer, paths.BaselinePointer} {
+		if target, err := paths.ResolvePointer(cfg.Output.BaseDir, name); err == nil && paths.SameFolder(target, runFolder) {
+			pointers = append(pointers, name)
+		}
+	}
+	if len(pointers) > 0 {
+		printer.Info("Marked as: %s", strings.Join(pointers, ", "))
+	}
+	if len(summary.Labels) > 0 {
+		printer.Info("Labels: %s", strings.Join(summary.Labels, ", "))
+	}
+	documents := "unknown"
+	if summary.Documents >= 0 {
+		documents = strconv.Itoa(summary.Documents)
+	}
+	printer.Info("Documents: %s | Queries: %d | Algorithms: %s",
+		documents, summary.Queries, strings.Join(summary.Algorithms, ", "))
+
+	outcomes := make(map[string]string, len(comparisons))
+	for _, qc := range comparisons {
+		outcomes[qc.Key()] = qc.Outcome
+	}
+
+	printer.Section("Queries")
+	table := ui.NewTable("QUERY", "ALGORITHM", "RESULTS", "TOOK", "OUTCOME")
+	for _, qr := range results {
+		outcome := outcomes[qr.Key()]
+		if outcome == "" {
+			outcome = "-"
+		}
+		took := "-"
+		if qr.TookMs > 0 {
+			took = formatMs(float64(qr.TookMs))
+		}
+		table.AddRow(
+			ui.Truncate(qr.Query, showTitleWidth),
+			qr.AlgorithmLabel(),
+			strconv.Itoa(len(qr.Results)),
+			took,
+			outcome,
+		)
+	}
+	if err := table.Print(); err != nil {
+		return fmt.Errorf("failed to print queries: %w", err)
+	}
+
+	printer.Section("Comparison")
+	if len(comparisons) == 0 {
+		printer.Info("Not compared yet; run 'compare' to compare it with the run before")
+		return nil
+	}
+	var stats models.ComparisonStats
+	failed := 0
+	for _, qc := range comparisons {
+		stats.NewResults += qc.Stats.NewResults
+		stats.RemovedCount += qc.Stats.RemovedCount
+		stats.ImprovedCount += qc.Stats.ImprovedCount
+		stats.WorsedCount += qc.Stats.WorsedCount
+		if qc.Outcome == comparison.QueryFailed {
+			failed++
+		}
+	}
+	printer.Info("Queries compared: %d | Failing thresholds: %d", len(comparisons), failed)
+	printer.Info("New: %d | Removed: %d | Improved: %d | Worsened: %d",
+		stats.NewResults, stats.RemovedCount, stats.ImprovedCount, stats.WorsedCount)
+
+	reports, err := layout.ReportFiles(runFolder)
+	if err != nil {
+		return fmt.Errorf("failed to list reports: %w", err)
+	}
+	for _, report := range reports {
+		printer.Info("Report: %s", report)
+	}
+	return nil
+}
+
+// loadQueryComparisons loads the per-query comparisons compare saved in a
+// run folder, if any
+func loadQueryComparisons(layout paths.Layout, runFolder string) ([]comparison.QueryComparison, error) {
+	files, err := filepath.Glob(filepath.Join(layout.ComparisonsFolder(runFolder), "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comparisons: %w", err)
+	}
+
+	comparisons := make([]comparison.QueryComparison, 0, len(files))
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read comparison: %w", err)
+		}
+		var qc comparison.QueryComparison
+		if err := json.Unmarshal(data, &qc); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+		}
+		comparisons = append(comparisons, qc)
+	}
+	return comparisons, nil
+}
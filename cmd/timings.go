@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ONSdigital/dis-search-test-bed/shared/clock"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/shared/timing"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+)
+
+// Phases commands time
+const (
+	phaseConnect   = "connect"
+	phaseFetch     = "fetch"
+	phaseLoadIndex = "load index"
+	phaseBulk      = "bulk"
+	phaseQueries   = "queries"
+	phaseSave      = "save"
+	phaseReports   = "reports"
+)
+
+// phases times the running command; it is restarted before each command
+var phases = timing.NewTimer(clock.Real{})
+
+// reportTimings prints where the command's time went and, when it has a run
+// folder, adds the breakdown to the folder's timings file
+func reportTimings(command, runFolder string, printer *ui.Printer) error {
+	report := phases.Report(command)
+	if len(report.Phases) == 0 {
+		return nil
+	}
+
+	printer.Section("Timings")
+	table := ui.NewTable("PHASE", "TIME", "SHARE")
+	for _, p := range report.Phases {
+		table.AddRow(p.Name, formatMs(p.Ms), fmt.Sprintf("%.0f%%", p.Share*100))
+	}
+	table.AddRow("total", formatMs(report.TotalMs), "100%")
+	if err := table.Print(); err != nil {
+		return fmt.Errorf("failed to print timings: %w", err)
+	}
+
+	if runFolder == "" || noWrite {
+		return nil
+	}
+	path := filepath.Join(runFolder, output.TimingsFileName)
+	var reports []timing.Report
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &reports); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read timings: %w", err)
+	}
+	if err := output.WriteJSONFile(path, append(reports, report)); err != nil {
+		return fmt.Errorf("failed to save timings: %w", err)
+	}
+	return nil
+}
+
+// formatMs rounds a time in milliseconds for display
+func formatMs(ms float64) string {
+	d := time.Duration(ms * float64(time.Millisecond))
+	if d < time.Second {
+		return d.Round(10 * time.Microsecond).String()
+	}
+	return d.Round(time.Millisecond).String()
+}
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/metrics"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/shared/paths"
+	"github.com/ONSdigital/dis-search-test-bed/shared/trends"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	trendsLast      int
+	trendsJudgments string
+	trendsK         int
+	trendsRows      int
+	trendsOut       string
+)
+
+var trendsCmd = &cobra.Command{
+	Use:   "trends",
+	Short: "Show how results and metrics evolved across runs",
+	Long: `Trends reads every run under output.base_dir, oldest first, and prints a
+time series of each algorithm's metrics per run: queries, queries with no
+results, average result count, mean took time, and the overlap and
+rank-biased overlap of the top K with the run before. With judgments (the
+--judgments flag or comparison.judgments_file), mean NDCG@K is added.
+
+Below it, the least stable queries are listed: those whose top K overlapped
+least with the run before, on average, and whose top result changed most
+often. --out writes the full report, with every query's metrics in every
+run, as JSON (--out - writes it to stdout). Trends only reads runs, so it is
+safe on read-only data.`,
+	RunE: runTrends,
+}
+
+func init() {
+	rootCmd.AddCommand(trendsCmd)
+
+	trendsCmd.Flags().IntVar(&trendsLast, "last", 0,
+		"Only include the most recent N runs (0 for all)")
+	trendsCmd.Flags().StringVar(&trendsJudgments, "judgments", "",
+		"Judgments file, JSON or TREC qrels, for NDCG (defaults to comparison.judgments_file)")
+	trendsCmd.Flags().IntVarP(&trendsK, "k", "k", metrics.DefaultK,
+		"Rank cut-off for overlap, RBO and NDCG")
+	trendsCmd.Flags().IntVar(&trendsRows, "rows", 10,
+		"Least stable queries to list")
+	trendsCmd.Flags().StringVarP(&trendsOut, "out", "o", "",
+		"File to write the JSON report to, or - for stdout")
+}
+
+func runTrends(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if trendsOut == stdio {
+		ui.SetOutput(os.Stderr)
+	}
+	printer := ui.NewPrinter(verbose)
+
+	opts := trends.Options{K: trendsK, RBOPersistence: cfg.Comparison.RBOPersistence}
+	if trendsJudgments == "" {
+		trendsJudgments = cfg.Comparison.JudgmentsFile
+	}
+	if trendsJudgments != "" {
+		opts.Judgments, err = models.LoadJudgments(trendsJudgments)
+		if err != nil {
+			return fmt.Errorf("failed to load judgments: %w", err)
+		}
+	}
+
+	runs, err := loadTrendRuns(runLayout(cfg), cfg.Output.BaseDir, trendsLast, printer)
+	if err != nil {
+		return err
+	}
+	if len(runs) < 2 {
+		return fmt.Errorf("need at least 2 runs with results in %s, found %d", cfg.Output.BaseDir, len(runs))
+	}
+	printer.Info("Runs: %d, from %s to %s", len(runs), runs[0].ID, runs[len(runs)-1].ID)
+
+	report := trends.Analyse(runs, opts)
+	if err := printTrends(report, opts.Judgments != nil, printer); err != nil {
+		return err
+	}
+
+	switch trendsOut {
+	case "":
+		return nil
+	case stdio:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	default:
+		if err := output.WriteJSONFile(trendsOut, report); err != nil {
+			return fmt.Errorf("failed to save trends: %w", err)
+		}
+		printer.Info("Location: %s", trendsOut)
+		return nil
+	}
+}
+
+// loadTrendRuns loads the results of every run folder, or the most recent
+// last of them, oldest first. Folders without results are skipped.
+func loadTrendRuns(layout paths.Layout, baseDir string, last int, printer *ui.Printer) ([]trends.Run, error) {
+	folders, err := layout.ListRunFolders(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runs: %w", err)
+	}
+
+	var runs []trends.Run
+	for _, folder := range folders {
+		if last > 0 && len(runs) == last {
+			break
+		}
+		path := filepath.Join(folder, "results.json")
+		if _, err := os.Stat(path); err != nil {
+			printer.Debug("Skipping %s: no results", folder)
+			continue
+		}
+		results, err := output.LoadResults(path)
+		if err != nil {
+			printer.Warning("Skipping %s: %v", folder, err)
+			continue
+		}
+		run := trends.Run{ID: paths.RunID(folder), Results: results}
+		if at, err := layout.Timestamp(folder); err == nil {
+			run.At = at
+		}
+		runs = append(runs, run)
+	}
+
+	// Folders are listed newest first
+	for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+		runs[i], runs[j] = runs[j], runs[i]
+	}
+	return runs, nil
+}
+
+// printTrends prints the per-run series and the least stable queries
+func printTrends(report trends.Report, judged bool, printer *ui.Printer) error {
+	overlap, rbo, ndcg := fmt.Sprintf("OVERLAP@%d", report.K), fmt.Sprintf("RBO@%d", report.K), fmt.Sprintf("NDCG@%d", report.K)
+
+	printer.Section("Metrics by Run")
+	headers := []string{"RUN", "ALGORITHM", "QUERIES", "ZERO", "AVG RESULTS", "MEAN MS", overlap, rbo}
+	if judged {
+		headers = append(headers, ndcg)
+	}
+	table := ui.NewTable(headers...)
+	for _, p := range report.Overall {
+		row := []string{
+			p.RunID,
+			p.Algorithm,
+			strconv.Itoa(p.Queries),
+			strconv.Itoa(p.ZeroResults),
+			fmt.Sprintf("%.1f", p.AvgResults),
+			fmt.Sprintf("%.1f", p.MeanTookMs),
+			formatOptional(p.Overlap, "%.2f"),
+			formatOptional(p.RBO, "%.3f"),
+		}
+		if judged {
+			row = append(row, formatOptional(p.NDCG, "%.3f"))
+		}
+		table.AddRow(row...)
+	}
+	if err := table.Print(); err != nil {
+		return fmt.Errorf("failed to print trends: %w", err)
+	}
+
+	printer.Section("Least Stable Queries")
+	table = ui.NewTable("QUERY", "ALGORITHM", "RUNS", "MEAN "+overlap, "TOP RESULT CHANGES")
+	for i, q := range report.Queries {
+		if i == trendsRows || q.MeanOverlap == nil {
+			break
+		}
+		table.AddRow(
+			ui.Truncate(q.Query, showTitleWidth),
+			q.Algorithm,
+			strconv.Itoa(len(q.Points)),
+			formatOptional(q.MeanOverlap, "%.2f"),
+			strconv.Itoa(q.TopChanges),
+		)
+	}
+	if err := table.Print(); err != nil {
+		return fmt.Errorf("failed to print least stable queries: %w", err)
+	}
+	return nil
+}
+
+// formatOptional formats a value that may be unset, showing "-" when it is
+func formatOptional(v *float64, format string) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf(format, *v)
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/ONSdigital/dis-search-test-bed/config"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/comparison"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+)
+
+// checkWatchlist compares a finished run with the one before it and reports
+// every watchlist document that left a query's top K, whatever the general
+// comparison thresholds say. The drops are saved to watchlist.json in the
+// run folder. Nothing is checked without a watchlist or a previous run.
+func checkWatchlist(cfg *config.Config, runFolder string, results []models.QueryResults, printer *ui.Printer) error {
+	if cfg.Comparison.WatchlistFile == "" {
+		return nil
+	}
+	watchlist, err := models.LoadWatchlist(cfg.Comparison.WatchlistFile)
+	if err != nil {
+		return fmt.Errorf("failed to load watchlist: %w", err)
+	}
+
+	prevPath, err := runLayout(cfg).FindPreviousResults(cfg.Output.BaseDir, filepath.Join(runFolder, "results.json"))
+	if err != nil {
+		printer.Debug("No previous run to check the watchlist against")
+		return nil
+	}
+	previous, err := output.LoadResults(prevPath)
+	if err != nil {
+		return fmt.Errorf("failed to load previous results: %w", err)
+	}
+
+	drops := comparison.FindWatchlistDrops(results, previous, watchlist, cfg.Comparison.WatchlistK)
+	if err := output.WriteJSONFile(filepath.Join(runFolder, output.WatchlistFileName), drops); err != nil {
+		return fmt.Errorf("failed to save watchlist drops: %w", err)
+	}
+
+	printer.Section("Watchlist")
+	printer.Info("Checked %d documents against %s", len(watchlist), prevPath)
+	reportWatchlistDrops(drops, cfg.Comparison.WatchlistK, printer)
+	return nil
+}
+
+// reportWatchlistDrops prints a warning for each watchlist document that
+// left the top K
+func reportWatchlistDrops(drops []comparison.WatchlistDrop, k int, printer *ui.Printer) {
+	if len(drops) == 0 {
+		printer.Success("No watchlist documents left the top %d", k)
+		return
+	}
+	printer.Warning("%d watchlist documents left the top %d:", len(drops), k)
+	for _, d := range drops {
+		printer.Warning("  %s", d.Describe())
+	}
+}
@@ -3,7 +3,10 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/ONSdigital/dis-search-test-bed/shared/redact"
 	"gopkg.in/yaml.v3"
 )
 
@@ -14,12 +17,95 @@ type Config struct {
 	Output        OutputConfig        `yaml:"output"`
 	Comparison    ComparisonConfig    `yaml:"comparison"`
 	TestData      TestDataConfig      `yaml:"test_data"`
+	Corpora       []CorpusConfig      `yaml:"corpora"`
+	Execution     ExecutionConfig     `yaml:"execution"`
+	Baseline      BaselineConfig      `yaml:"baseline"`
 }
 
 // ElasticsearchConfig holds Elasticsearch connection settings
 type ElasticsearchConfig struct {
-	URL   string `yaml:"url" env:"ES_URL"`
-	Index string `yaml:"index" env:"ES_INDEX"`
+	URL       string          `yaml:"url" env:"ES_URL"`           // ES_URL may list several nodes, comma separated
+	URLs      []string        `yaml:"urls"`                       // More nodes to spread requests across
+	CloudID   string          `yaml:"cloud_id" env:"ES_CLOUD_ID"` // Elastic Cloud deployment; replaces url and urls
+	Index     string          `yaml:"index" env:"ES_INDEX"`
+	Backend   string          `yaml:"backend" env:"ES_BACKEND"` // elasticsearch7, elasticsearch8 or opensearch
+	Transport TransportConfig `yaml:"transport"`
+	Retry     RetryConfig     `yaml:"retry"`
+	Bulk      BulkConfig      `yaml:"bulk"`
+	Mapping   string          `yaml:"mapping_file" env:"ES_MAPPING_FILE"` // Index settings and mappings (JSON); the built-in mapping when empty
+	Proxy     string          `yaml:"proxy"`                              // HTTP proxy URL; HTTP(S)_PROXY and NO_PROXY apply when empty
+	TLS       TLSConfig       `yaml:"tls"`
+	Readiness ReadinessConfig `yaml:"readiness"`
+
+	// Named settings and mappings indexes can be created with instead, so
+	// analyzer changes can be compared run against run
+	MappingProfile  string                          `yaml:"mapping_profile"` // Profile used unless --mapping-profile is given; none when empty
+	MappingProfiles map[string]MappingProfileConfig `yaml:"mapping_profiles"`
+
+	// Credentials are read from an environment variable or a file, never
+	// from the config itself, so configs can be committed safely
+	Username        string `yaml:"username"`
+	PasswordEnv     string `yaml:"password_env"`  // e.g. ES_PASSWORD
+	PasswordFile    string `yaml:"password_file"` // Used when PasswordEnv is unset
+	APIKeyEnv       string `yaml:"api_key_env"`   // Base64 "id:key" API key; used instead of a password
+	APIKeyFile      string `yaml:"api_key_file"`
+	BearerTokenEnv  string `yaml:"bearer_token_env"` // OAuth or service account token; used instead of both
+	BearerTokenFile string `yaml:"bearer_token_file"`
+}
+
+// ReadinessConfig controls the wait after loading an index, so queries only
+// start once every document is searchable
+type ReadinessConfig struct {
+	Health  string        `yaml:"health"`  // Cluster health to wait for: green (default), yellow, or none to skip
+	Timeout time.Duration `yaml:"timeout"` // How long to wait for health and the sentinel search, e.g. "30s"
+}
+
+// TLSConfig holds TLS options for HTTPS clusters
+type TLSConfig struct {
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"` // Development only
+	CAFile             string `yaml:"ca_file"`              // Extra CAs to trust, as a PEM bundle
+	CertFile           string `yaml:"cert_file"`            // Client certificate (PEM)
+	KeyFile            string `yaml:"key_file"`             // Client certificate key (PEM)
+}
+
+// TransportConfig holds HTTP transport tuning for the Elasticsearch client
+type TransportConfig struct {
+	CompressRequests           bool          `yaml:"compress_requests"`            // Gzip request bodies
+	DisableResponseCompression bool          `yaml:"disable_response_compression"` // Don't ask for gzipped responses
+	MaxIdleConns               int           `yaml:"max_idle_conns"`
+	MaxIdleConnsPerHost        int           `yaml:"max_idle_conns_per_host"`
+	IdleConnTimeout            time.Duration `yaml:"idle_conn_timeout"` // e.g. "90s"
+	DisableKeepAlives          bool          `yaml:"disable_keep_alives"`
+	RequestTimeout             time.Duration `yaml:"request_timeout"` // Per attempt, e.g. "2m" for heavy aggregations; 0 waits indefinitely
+	DialTimeout                time.Duration `yaml:"dial_timeout"`    // Opening a connection, e.g. "5s"
+}
+
+// RetryConfig controls how requests that fail transiently, such as during
+// a node restart, are retried
+type RetryConfig struct {
+	Disable        bool          `yaml:"disable"`         // Send every request once
+	MaxRetries     int           `yaml:"max_retries"`     // Retries after the first attempt
+	InitialBackoff time.Duration `yaml:"initial_backoff"` // Wait before the first retry, doubling after each
+	MaxBackoff     time.Duration `yaml:"max_backoff"`     // Longest wait between attempts
+	OnStatus       []int         `yaml:"on_status"`       // Response statuses to retry, as well as network errors
+}
+
+// MappingProfileConfig is a named variant of the index settings and
+// mappings, e.g. one trying a stemmer or synonym filter. Settings and
+// mappings are merged over the profile's mapping file, or
+// elasticsearch.mapping_file, or the built-in mapping.
+type MappingProfileConfig struct {
+	MappingFile string                 `yaml:"mapping_file"` // Replaces elasticsearch.mapping_file for this profile
+	Settings    map[string]interface{} `yaml:"settings"`     // e.g. analysis.analyzer and analysis.filter
+	Mappings    map[string]interface{} `yaml:"mappings"`     // e.g. properties.title.analyzer
+}
+
+// BulkConfig controls how documents are split into bulk requests, so large
+// corpora are indexed without one huge request body
+type BulkConfig struct {
+	ChunkDocs  int `yaml:"chunk_docs"`  // Most documents per request
+	ChunkBytes int `yaml:"chunk_bytes"` // Most request body bytes; a larger document is sent alone
+	Workers    int `yaml:"workers"`     // Chunks sent at once
 }
 
 // GenerationConfig holds index generation settings
@@ -30,24 +116,141 @@ type GenerationConfig struct {
 
 // OutputConfig holds output directory configuration
 type OutputConfig struct {
-	BaseDir string `yaml:"base_dir"`
+	BaseDir       string        `yaml:"base_dir"`
+	ReportFormats []string      `yaml:"report_formats"` // Formats compare writes each report in
+	LockTimeout   time.Duration `yaml:"lock_timeout"`   // How long to wait for a run folder or pointer another process is writing to, e.g. "30s"
+	Sort          string        `yaml:"sort"`           // Order of exported results: run, query, algorithm or rank
+	SplitCSV      bool          `yaml:"split_csv"`      // Also write one results CSV per algorithm
+	Parquet       bool          `yaml:"parquet"`        // Also export results and comparison movements as Parquet
+	Layout        LayoutConfig  `yaml:"layout"`
+}
+
+// BaselineConfig holds where the team's approved baseline is published
+type BaselineConfig struct {
+	Remote    string `yaml:"remote" env:"TESTBED_BASELINE_REMOTE"` // Directory, file://, http(s):// or s3:// location
+	TokenEnv  string `yaml:"token_env"`                            // Bearer token for an http(s) remote, e.g. an Artifactory access token
+	TokenFile string `yaml:"token_file"`                           // Used when TokenEnv is unset
+}
+
+// LayoutConfig holds how run folders are named and arranged
+type LayoutConfig struct {
+	Folder     string `yaml:"folder"`      // Run folder name template with {time}, {tag} and {experiment}
+	Tag        string `yaml:"tag"`         // Value of {tag}
+	Experiment string `yaml:"experiment"`  // Value of {experiment}
+	ReportsDir string `yaml:"reports_dir"` // Run folder subdirectory for comparison reports, e.g. "comparison"
+
+	ReportNames ReportNamesConfig `yaml:"report_names"`
+}
+
+// ReportNamesConfig holds the base names compare saves its reports under,
+// before the format's extension
+type ReportNamesConfig struct {
+	Historical string `yaml:"historical"`  // Default "comparison_historical"
+	CrossQuery string `yaml:"cross_query"` // Default "comparison_cross_query"
+	ScoreDrift string `yaml:"score_drift"` // Default "comparison_score_drift"
 }
 
 // ComparisonConfig holds comparison output settings
 type ComparisonConfig struct {
-	ShowUnchanged  bool `yaml:"show_unchanged"`
-	HighlightNew   bool `yaml:"highlight_new"`
-	ShowScores     bool `yaml:"show_scores"`
-	MaxRankDisplay int  `yaml:"max_rank_display"`
+	ShowUnchanged      bool             `yaml:"show_unchanged"`
+	HighlightNew       bool             `yaml:"highlight_new"`
+	ShowScores         bool             `yaml:"show_scores"`
+	MaxRankDisplay     int              `yaml:"max_rank_display"`
+	DiversityK         int              `yaml:"diversity_k"`  // Rank cut-off for diversity measures
+	VisibilityK        int              `yaml:"visibility_k"` // Rank cut-off for theme visibility shares
+	Thresholds         ThresholdsConfig `yaml:"thresholds"`
+	ShowPreviews       bool             `yaml:"show_previews"`  // Add body previews and term hits from index.json
+	PreviewLength      int              `yaml:"preview_length"` // Preview length in characters
+	Matcher            string           `yaml:"matcher"`        // How results are paired: uri, id or normalised_uri
+	Labels             LabelsConfig     `yaml:"labels"`
+	JudgmentsFile      string           `yaml:"judgments_file"`      // Judgments (JSON or qrels) for NDCG in historical reports
+	NDCGK              int              `yaml:"ndcg_k"`              // Rank cut-off for NDCG
+	RBOPersistence     float64          `yaml:"rbo_persistence"`     // Rank-biased overlap weighting, between 0 and 1
+	WatchlistFile      string           `yaml:"watchlist_file"`      // Must-have URIs, one per line, reported when they leave the top K
+	WatchlistK         int              `yaml:"watchlist_k"`         // Rank cut-off for the watchlist
+	FlagsFile          string           `yaml:"flags_file"`          // Editorial flags by URI (JSON or CSV), merged into results in reports
+	WarnFlags          []string         `yaml:"warn_flags"`          // Flags warned about when a result carrying one is in the top K
+	FlagsK             int              `yaml:"flags_k"`             // Rank cut-off for flag warnings
+	MarkdownRows       int              `yaml:"markdown_rows"`       // Rows per table and items per list in Markdown reports
+	FailOn             string           `yaml:"fail_on"`             // Regression gate rules, e.g. "removed>5,ndcg-drop>0.05"
+	BootstrapResamples int              `yaml:"bootstrap_resamples"` // Resamples for summary confidence intervals
+
+	// Bands of ranks new results are counted in; the comparison defaults
+	// when empty
+	PositionBands []PositionBandConfig `yaml:"position_bands"`
+}
+
+// PositionBandConfig is a band of ranks new results are counted in
+type PositionBandConfig struct {
+	MaxRank int     `yaml:"max_rank"` // Last rank of the band; unset for a final band with no end
+	Weight  float64 `yaml:"weight"`   // What a new result in the band counts for
+}
+
+// LabelsConfig overrides the terms used in historical reports. Unset
+// labels keep their English defaults.
+type LabelsConfig struct {
+	New           string `yaml:"new"`
+	Removed       string `yaml:"removed"`
+	Improved      string `yaml:"improved"`
+	Worsened      string `yaml:"worsened"`
+	Unchanged     string `yaml:"unchanged"`
+	TotalNew      string `yaml:"total_new"`
+	TotalRemoved  string `yaml:"total_removed"`
+	TotalImproved string `yaml:"total_improved"`
+	TotalWorsened string `yaml:"total_worsened"`
+}
+
+// ThresholdsConfig holds the per-query limits used for the report verdict.
+// A zero value disables that rule; max_new_top is disabled by leaving it
+// out, as 0 allows no new results in the top band.
+type ThresholdsConfig struct {
+	MaxRemovedResults   int     `yaml:"max_removed_results"`
+	MaxWorsenedRankings int     `yaml:"max_worsened_rankings"`
+	MaxAvgRankChange    float64 `yaml:"max_avg_rank_change"`
+	MinOverlap          float64 `yaml:"min_overlap"`     // Share of the previous results still returned, 0-1
+	MinRBO              float64 `yaml:"min_rbo"`         // Rank-biased overlap with the previous results
+	MinKendallTau       float64 `yaml:"min_kendall_tau"` // Rank correlation over shared results, -1 to 1
+	MinNDCG             float64 `yaml:"min_ndcg"`        // NDCG of judged queries (needs judgments_file)
+	MaxNewTop           *int    `yaml:"max_new_top"`     // New results in the first position band
+	MaxNewImpact        float64 `yaml:"max_new_impact"`  // New results weighted by position band
+	StopAfter           int     `yaml:"stop_after"`      // Regressions after which the report drops per-query detail
 }
 
 // TestDataConfig holds test data generation settings
 type TestDataConfig struct {
-	Mode          string `yaml:"mode"`           // "random" or "file"
-	SourceFile    string `yaml:"source_file"`    // Path to JSON file if mode is "file"
-	Seed          int64  `yaml:"seed"`           // Random seed for reproducibility
-	DocumentCount int    `yaml:"document_count"` // Number of documents to generate (if random)
-	Description   string `yaml:"description"`    // Description for this dataset
+	Mode             string `yaml:"mode"`              // "random" or "file"
+	SourceFile       string `yaml:"source_file"`       // Path to JSON file if mode is "file"
+	Seed             int64  `yaml:"seed"`              // Random seed for reproducibility
+	DocumentCount    int    `yaml:"document_count"`    // Number of documents to generate (if random)
+	Description      string `yaml:"description"`       // Description for this dataset
+	WeightsFile      string `yaml:"weights_file"`      // Per-document weights merged in when the index is loaded
+	AnalyticsWeights string `yaml:"analytics_weights"` // Use the run's analytics.json as weights: page_views or clicks
+	IDStrategy       string `yaml:"id_strategy"`       // Document IDs: sequential, or uri_hash to derive them from URIs
+}
+
+// CorpusConfig defines a named corpus the run command executes the suite
+// against. Unset mode, source file, seed and ID strategy fall back to
+// test_data.
+type CorpusConfig struct {
+	Name          string   `yaml:"name"`
+	Description   string   `yaml:"description"`
+	Mode          string   `yaml:"mode"`           // "random" or "file"
+	SourceFile    string   `yaml:"source_file"`    // Path to JSON file if mode is "file"
+	Seed          int64    `yaml:"seed"`           // Seed for generation or for sampling the file
+	DocumentCount int      `yaml:"document_count"` // Documents to generate, or to sample from the file (0 keeps all)
+	ContentTypes  []string `yaml:"content_types"`  // Keep only these content types
+	URIPrefixes   []string `yaml:"uri_prefixes"`   // Keep only URIs under these prefixes
+	IDStrategy    string   `yaml:"id_strategy"`    // Document IDs: sequential or uri_hash
+}
+
+// ExecutionConfig holds query execution settings
+type ExecutionConfig struct {
+	BatchSize int    `yaml:"batch_size"` // Queries per _msearch request; 0 runs queries one at a time
+	CacheMode string `yaml:"cache_mode"` // Cache state before measured queries: as-is, clear or warm
+
+	// MergeDuplicates drops queries an algorithm repeats with trivially
+	// different text, keeping the first; they are only warned about otherwise
+	MergeDuplicates bool `yaml:"merge_duplicates"`
 }
 
 // Load reads and parses the configuration file from the specified path.
@@ -65,11 +268,38 @@ func Load(path string) (*Config, error) {
 
 	// Apply environment variable overrides
 	if url := os.Getenv("ES_URL"); url != "" {
-		cfg.Elasticsearch.URL = url
+		nodes := splitList(url)
+		cfg.Elasticsearch.URL, cfg.Elasticsearch.URLs = nodes[0], nodes[1:]
+	}
+	if cloudID := os.Getenv("ES_CLOUD_ID"); cloudID != "" {
+		cfg.Elasticsearch.CloudID = cloudID
 	}
 	if index := os.Getenv("ES_INDEX"); index != "" {
 		cfg.Elasticsearch.Index = index
 	}
+	if mapping := os.Getenv("ES_MAPPING_FILE"); mapping != "" {
+		cfg.Elasticsearch.Mapping = mapping
+	}
+	if backend := os.Getenv("ES_BACKEND"); backend != "" {
+		cfg.Elasticsearch.Backend = backend
+	}
+	if username := os.Getenv("ES_USERNAME"); username != "" {
+		cfg.Elasticsearch.Username = username
+	}
+	// Secrets set in these variables are read from them in place of
+	// whatever the config names
+	if os.Getenv(PasswordEnvVar) != "" {
+		cfg.Elasticsearch.PasswordEnv = PasswordEnvVar
+	}
+	if os.Getenv(APIKeyEnvVar) != "" {
+		cfg.Elasticsearch.APIKeyEnv = APIKeyEnvVar
+	}
+	if os.Getenv(BearerTokenEnvVar) != "" {
+		cfg.Elasticsearch.BearerTokenEnv = BearerTokenEnvVar
+	}
+	if remote := os.Getenv("TESTBED_BASELINE_REMOTE"); remote != "" {
+		cfg.Baseline.Remote = remote
+	}
 	if seed := os.Getenv("TESTBED_SEED"); seed != "" {
 		var s int64
 		if _, err := fmt.Sscanf(seed, "%d", &s); err == nil {
@@ -86,30 +316,198 @@ func Load(path string) (*Config, error) {
 	return &cfg, nil
 }
 
+// Default returns the configuration used when no file is loaded
+func Default() *Config {
+	var cfg Config
+	cfg.applyDefaults()
+	return &cfg
+}
+
 // applyDefaults sets sensible default values for unset configuration options
 func (c *Config) applyDefaults() {
-	if c.Elasticsearch.URL == "" {
-		c.Elasticsearch.URL = "http://localhost:9200"
+	c.Elasticsearch.applyDefaults()
+	c.Generation.applyDefaults()
+	c.Output.applyDefaults()
+	c.Comparison.applyDefaults()
+	c.Execution.applyDefaults()
+	c.TestData.applyDefaults()
+	for i := range c.Corpora {
+		c.Corpora[i].applyDefaults(c.TestData)
+	}
+}
+
+// applyDefaults sets the connection, retry, bulk and readiness defaults
+func (c *ElasticsearchConfig) applyDefaults() {
+	if c.URL == "" && len(c.URLs) == 0 && c.CloudID == "" {
+		c.URL = "http://localhost:9200"
+	}
+	if c.Backend == "" {
+		c.Backend = "elasticsearch7"
+	}
+	if c.Index == "" {
+		c.Index = "search_test"
+	}
+	if c.Retry.MaxRetries == 0 {
+		c.Retry.MaxRetries = 3
 	}
-	if c.Elasticsearch.Index == "" {
-		c.Elasticsearch.Index = "search_test"
+	if c.Retry.InitialBackoff == 0 {
+		c.Retry.InitialBackoff = 200 * time.Millisecond
 	}
-	if c.Generation.DocumentCount == 0 {
-		c.Generation.DocumentCount = 50
+	if c.Retry.MaxBackoff == 0 {
+		c.Retry.MaxBackoff = 5 * time.Second
 	}
-	if c.Output.BaseDir == "" {
-		c.Output.BaseDir = "data"
+	if len(c.Retry.OnStatus) == 0 {
+		c.Retry.OnStatus = []int{429, 502, 503, 504}
 	}
-	if c.Comparison.MaxRankDisplay == 0 {
-		c.Comparison.MaxRankDisplay = 20
+	if c.Bulk.ChunkDocs == 0 {
+		c.Bulk.ChunkDocs = 500
 	}
-	if c.TestData.Mode == "" {
-		c.TestData.Mode = "random"
+	if c.Bulk.ChunkBytes == 0 {
+		c.Bulk.ChunkBytes = 5 << 20
 	}
-	if c.TestData.DocumentCount == 0 {
-		c.TestData.DocumentCount = 50
+	if c.Bulk.Workers == 0 {
+		c.Bulk.Workers = 1
+	}
+	if c.Readiness.Health == "" {
+		c.Readiness.Health = "green"
+	}
+	if c.Readiness.Timeout == 0 {
+		c.Readiness.Timeout = 30 * time.Second
+	}
+}
+
+// applyDefaults sets the generated document count
+func (c *GenerationConfig) applyDefaults() {
+	if c.DocumentCount == 0 {
+		c.DocumentCount = 50
+	}
+}
+
+// applyDefaults sets where and how results are written
+func (c *OutputConfig) applyDefaults() {
+	if c.BaseDir == "" {
+		c.BaseDir = "data"
+	}
+	if len(c.ReportFormats) == 0 {
+		c.ReportFormats = []string{"text"}
+	}
+	if c.LockTimeout == 0 {
+		c.LockTimeout = 30 * time.Second
+	}
+}
+
+// applyDefaults sets the rank cut-offs and display limits of the reports
+func (c *ComparisonConfig) applyDefaults() {
+	if c.MaxRankDisplay == 0 {
+		c.MaxRankDisplay = 20
+	}
+	if c.DiversityK == 0 {
+		c.DiversityK = 10
+	}
+	if c.VisibilityK == 0 {
+		c.VisibilityK = 3
+	}
+	if c.NDCGK == 0 {
+		c.NDCGK = 10
+	}
+	if c.RBOPersistence == 0 {
+		c.RBOPersistence = 0.9
+	}
+	if c.WatchlistK == 0 {
+		c.WatchlistK = 10
+	}
+	if c.WarnFlags == nil {
+		c.WarnFlags = []string{"withdrawn", "superseded"}
+	}
+	if c.FlagsK == 0 {
+		c.FlagsK = 10
+	}
+	if c.MarkdownRows == 0 {
+		c.MarkdownRows = 20
+	}
+	if c.PreviewLength == 0 {
+		c.PreviewLength = 200
+	}
+}
+
+// applyDefaults sets the query cache mode
+func (c *ExecutionConfig) applyDefaults() {
+	if c.CacheMode == "" {
+		c.CacheMode = "as-is"
+	}
+}
+
+// applyDefaults sets the test data source and ID strategy
+func (c *TestDataConfig) applyDefaults() {
+	if c.Mode == "" {
+		c.Mode = "random"
+	}
+	if c.DocumentCount == 0 {
+		c.DocumentCount = 50
+	}
+	if c.Seed == 0 {
+		c.Seed = 42
+	}
+	if c.IDStrategy == "" {
+		c.IDStrategy = "sequential"
+	}
+}
+
+// applyDefaults fills the corpus settings left unset from test_data
+func (c *CorpusConfig) applyDefaults(td TestDataConfig) {
+	if c.Mode == "" {
+		c.Mode = td.Mode
+	}
+	if c.SourceFile == "" {
+		c.SourceFile = td.SourceFile
+	}
+	if c.Seed == 0 {
+		c.Seed = td.Seed
+	}
+	if c.IDStrategy == "" {
+		c.IDStrategy = td.IDStrategy
+	}
+}
+
+// Nodes lists the node URLs to connect to, none when connecting by cloud ID
+func (c ElasticsearchConfig) Nodes() []string {
+	if c.CloudID != "" {
+		return nil
+	}
+	var nodes []string
+	seen := make(map[string]bool)
+	for _, u := range append([]string{c.URL}, c.URLs...) {
+		if u != "" && !seen[u] {
+			seen[u] = true
+			nodes = append(nodes, u)
+		}
+	}
+	return nodes
+}
+
+// Endpoint describes where the cluster is, with passwords redacted
+func (c ElasticsearchConfig) Endpoint() string {
+	if c.CloudID != "" {
+		name, _, _ := strings.Cut(c.CloudID, ":")
+		return fmt.Sprintf("Elastic Cloud deployment %s", name)
+	}
+	nodes := c.Nodes()
+	for i, u := range nodes {
+		nodes[i] = redact.URL(u)
+	}
+	return strings.Join(nodes, ", ")
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
-	if c.TestData.Seed == 0 {
-		c.TestData.Seed = 42
+	if len(items) == 0 {
+		return []string{""}
 	}
+	return items
 }
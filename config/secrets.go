@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/shared/redact"
+)
+
+// Environment variables that, when set, supply the Elasticsearch secrets
+// whatever the config says
+const (
+	PasswordEnvVar    = "ES_PASSWORD"
+	APIKeyEnvVar      = "ES_API_KEY"
+	BearerTokenEnvVar = "ES_BEARER_TOKEN"
+)
+
+// Password returns the Elasticsearch password from the environment
+// variable or file the config names, or "" when it names neither
+func (e ElasticsearchConfig) Password() (string, error) {
+	password, err := readSecret(e.PasswordEnv, e.PasswordFile)
+	if err != nil {
+		return "", fmt.Errorf("elasticsearch password: %w", err)
+	}
+	return password, nil
+}
+
+// APIKey returns the Elasticsearch API key from the environment variable or
+// file the config names, or "" when it names neither
+func (e ElasticsearchConfig) APIKey() (string, error) {
+	key, err := readSecret(e.APIKeyEnv, e.APIKeyFile)
+	if err != nil {
+		return "", fmt.Errorf("elasticsearch API key: %w", err)
+	}
+	return key, nil
+}
+
+// BearerToken returns the Elasticsearch bearer token from the environment
+// variable or file the config names, or "" when it names neither
+func (e ElasticsearchConfig) BearerToken() (string, error) {
+	token, err := readSecret(e.BearerTokenEnv, e.BearerTokenFile)
+	if err != nil {
+		return "", fmt.Errorf("elasticsearch bearer token: %w", err)
+	}
+	return token, nil
+}
+
+// Token returns the baseline remote's bearer token from the environment
+// variable or file the config names, or "" when it names neither
+func (b BaselineConfig) Token() (string, error) {
+	token, err := readSecret(b.TokenEnv, b.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("baseline remote token: %w", err)
+	}
+	return token, nil
+}
+
+// readSecret reads a secret from the named environment variable, or failing
+// that from a file. Surrounding whitespace, such as a trailing newline, is
+// trimmed.
+func readSecret(envName, file string) (string, error) {
+	if envName != "" {
+		if value, ok := os.LookupEnv(envName); ok && value != "" {
+			return strings.TrimSpace(value), nil
+		}
+		if file == "" {
+			return "", fmt.Errorf("environment variable %s is not set", envName)
+		}
+	}
+	if file == "" {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("read secret file: %w", err)
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("secret file %s is empty", file)
+	}
+	return secret, nil
+}
+
+// Redact returns a copy of the config that is safe to print or save. Secrets
+// themselves never live in the config, only where to find them, but a URL can
+// still carry a password.
+func (c Config) Redact() Config {
+	c.Elasticsearch.URL = redact.URL(c.Elasticsearch.URL)
+	urls := make([]string, len(c.Elasticsearch.URLs))
+	for i, u := range c.Elasticsearch.URLs {
+		urls[i] = redact.URL(u)
+	}
+	if len(urls) > 0 {
+		c.Elasticsearch.URLs = urls
+	}
+	c.Elasticsearch.Proxy = redact.URL(c.Elasticsearch.Proxy)
+	c.Baseline.Remote = redact.URL(c.Baseline.Remote)
+	return c
+}
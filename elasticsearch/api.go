@@ -0,0 +1,43 @@
+package elasticsearch
+
+import (
+	"context"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// Searcher runs searches against an index
+type Searcher interface {
+	Search(ctx context.Context, index string, query map[string]interface{}) (*SearchResponse, error)
+	MultiSearch(ctx context.Context, index string, queries []map[string]interface{}) ([]MultiSearchItem, error)
+}
+
+// Indexer creates, fills and removes indexes
+type Indexer interface {
+	IndexExists(ctx context.Context, index string) (bool, error)
+	CreateIndex(ctx context.Context, index string, mapping map[string]interface{}) error
+	DeleteIndex(ctx context.Context, index string) error
+	BulkIndex(ctx context.Context, index string, docs []models.Document) error
+	RefreshIndex(ctx context.Context, index string) error
+	CountDocuments(ctx context.Context, index string) (int, error)
+}
+
+// CacheClearer empties an index's query, request and fielddata caches
+type CacheClearer interface {
+	ClearCache(ctx context.Context, index string) error
+}
+
+// API is the part of the client used to load, fetch and search test
+// indexes. Client talks to a real cluster; memory.Client is a deterministic
+// in-memory fake for tests and offline development.
+type API interface {
+	Searcher
+	Indexer
+	CacheClearer
+	Markers
+	HealthWaiter
+	Ping(ctx context.Context) error
+	Fetch(ctx context.Context, index string, size int) ([]models.Document, error)
+}
+
+var _ API = (*Client)(nil)
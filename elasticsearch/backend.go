@@ -0,0 +1,199 @@
+package elasticsearch
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Search engines the client can talk to
+const (
+	BackendElasticsearch7 = "elasticsearch7"
+	BackendElasticsearch8 = "elasticsearch8"
+	BackendOpenSearch     = "opensearch"
+)
+
+// Distributions a cluster reports at its root endpoint
+const (
+	DistributionElasticsearch = "elasticsearch"
+	DistributionOpenSearch    = "opensearch"
+)
+
+// SearchBackend adapts the client to the engine and major version a cluster
+// runs. Every backend sends requests shaped by the 7.x REST API, which all
+// three engines accept; a backend adds what its cluster needs on top and
+// checks the cluster is the engine it was configured for.
+type SearchBackend interface {
+	// Name is the backend's config value, e.g. "opensearch"
+	Name() string
+	// Transport wraps the HTTP transport requests are sent through
+	Transport(next http.RoundTripper) http.RoundTripper
+	// Check returns an error if the cluster runs a different engine or
+	// major version than the backend serves
+	Check(info ServerInfo) error
+	// PointInTime describes the cluster's point in time API, which Fetch
+	// pages through
+	PointInTime() PointInTimeAPI
+}
+
+// PointInTimeAPI is how a cluster opens and closes a point in time, a
+// frozen view of an index that search_after can page through consistently
+type PointInTimeAPI struct {
+	Path      string // Appended to the index to open one, and used alone to close it
+	IDField   string // Field of the open response and the close body holding the ID
+	CloseList bool   // The close body takes a list of IDs
+	Sort      string // Sort that pages through the point in time cheaply, e.g. "_shard_doc"
+}
+
+// elasticsearchPIT is the point in time API of Elasticsearch 7.10 and later.
+// 7.x sorts by _doc, which 7.12 and later tie-break across shards
+// themselves.
+var elasticsearchPIT = PointInTimeAPI{Path: "/_pit", IDField: "id", Sort: "_doc"}
+
+// ServerInfo is what a cluster reports about itself at its root endpoint
+type ServerInfo struct {
+	Distribution string // "elasticsearch" or "opensearch"
+	Version      string // e.g. "8.11.1"
+}
+
+// Major returns the major version, or 0 if the version can't be read
+func (i ServerInfo) Major() int {
+	major, _, _ := strings.Cut(i.Version, ".")
+	n, err := strconv.Atoi(major)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// String returns the distribution name and version, e.g. OpenSearch 2.11.0
+func (i ServerInfo) String() string {
+	name := "Elasticsearch"
+	if i.Distribution == DistributionOpenSearch {
+		name = "OpenSearch"
+	}
+	return name + " " + i.Version
+}
+
+// NewBackend returns the backend with the given name. Empty means
+// elasticsearch7.
+func NewBackend(name string) (SearchBackend, error) {
+	switch name {
+	case "", BackendElasticsearch7:
+		return elasticsearch7{}, nil
+	case BackendElasticsearch8:
+		return elasticsearch8{}, nil
+	case BackendOpenSearch:
+		return openSearch{}, nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q (use %s, %s or %s)",
+			name, BackendElasticsearch7, BackendElasticsearch8, BackendOpenSearch)
+	}
+}
+
+// ValidateBackend checks a backend name is known
+func ValidateBackend(name string) error {
+	_, err := NewBackend(name)
+	return err
+}
+
+// elasticsearch7 talks to Elasticsearch 7.x natively
+type elasticsearch7 struct{}
+
+func (elasticsearch7) Name() string { return BackendElasticsearch7 }
+
+func (elasticsearch7) Transport(next http.RoundTripper) http.RoundTripper { return next }
+
+func (b elasticsearch7) Check(info ServerInfo) error {
+	return checkElasticsearch(b, info, 7)
+}
+
+func (elasticsearch7) PointInTime() PointInTimeAPI { return elasticsearchPIT }
+
+// elasticsearch8 talks to Elasticsearch 8.x through its REST API
+// compatibility mode, which accepts and answers 7.x-shaped requests
+type elasticsearch8 struct{}
+
+func (elasticsearch8) Name() string { return BackendElasticsearch8 }
+
+func (elasticsearch8) Transport(next http.RoundTripper) http.RoundTripper {
+	return &compatTransport{next: next, version: 7}
+}
+
+func (b elasticsearch8) Check(info ServerInfo) error {
+	return checkElasticsearch(b, info, 8)
+}
+
+func (elasticsearch8) PointInTime() PointInTimeAPI {
+	pit := elasticsearchPIT
+	pit.Sort = "_shard_doc"
+	return pit
+}
+
+// openSearch talks to OpenSearch, which kept the 7.10 REST API it was
+// forked from
+type openSearch struct{}
+
+func (openSearch) Name() string { return BackendOpenSearch }
+
+func (openSearch) Transport(next http.RoundTripper) http.RoundTripper { return next }
+
+func (b openSearch) Check(info ServerInfo) error {
+	if info.Distribution != DistributionOpenSearch {
+		return mismatch(b, info)
+	}
+	return nil
+}
+
+// PointInTime is OpenSearch's own point in time API, added in 2.4
+func (openSearch) PointInTime() PointInTimeAPI {
+	return PointInTimeAPI{Path: "/_search/point_in_time", IDField: "pit_id", CloseList: true, Sort: "_doc"}
+}
+
+// checkElasticsearch checks the cluster is Elasticsearch of one major
+// version
+func checkElasticsearch(b SearchBackend, info ServerInfo, major int) error {
+	if info.Distribution == DistributionOpenSearch || info.Major() != major {
+		return mismatch(b, info)
+	}
+	return nil
+}
+
+func mismatch(b SearchBackend, info ServerInfo) error {
+	return fmt.Errorf("cluster runs %s but elasticsearch.backend is %s", info, b.Name())
+}
+
+// compatTransport asks Elasticsearch to treat requests as coming from an
+// older client, by stating the compatible version in the media types
+type compatTransport struct {
+	next    http.RoundTripper
+	version int
+}
+
+// RoundTrip sets the Accept and Content-Type headers to their versioned
+// forms. The 7.10 client labels NDJSON bodies as plain JSON, so bulk and
+// multi-search bodies are recognised by their endpoint.
+func (t *compatTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.Header.Set("Accept", t.mediaType("json"))
+	if out.Header.Get("Content-Type") != "" {
+		subtype := "json"
+		if isNDJSONEndpoint(out.URL.Path) {
+			subtype = "x-ndjson"
+		}
+		out.Header.Set("Content-Type", t.mediaType(subtype))
+	}
+	return t.next.RoundTrip(out)
+}
+
+func (t *compatTransport) mediaType(subtype string) string {
+	return fmt.Sprintf("application/vnd.elasticsearch+%s;compatible-with=%d", subtype, t.version)
+}
+
+// isNDJSONEndpoint reports whether an endpoint takes newline-delimited JSON
+func isNDJSONEndpoint(path string) bool {
+	return strings.HasSuffix(path, "/_bulk") ||
+		strings.HasSuffix(path, "/_msearch") ||
+		strings.HasSuffix(path, "/_msearch/template")
+}
@@ -0,0 +1,93 @@
+package elasticsearch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestSearchBackend_Check(t *testing.T) {
+	es7 := ServerInfo{Distribution: DistributionElasticsearch, Version: "7.17.9"}
+	es8 := ServerInfo{Distribution: DistributionElasticsearch, Version: "8.11.1"}
+	os2 := ServerInfo{Distribution: DistributionOpenSearch, Version: "2.11.0"}
+
+	tests := []struct {
+		backend string
+		info    ServerInfo
+		wantErr bool
+	}{
+		{backend: "", info: es7},
+		{backend: BackendElasticsearch7, info: es8, wantErr: true},
+		{backend: BackendElasticsearch7, info: ServerInfo{Distribution: DistributionOpenSearch, Version: "7.10.2"}, wantErr: true},
+		{backend: BackendElasticsearch8, info: es8},
+		{backend: BackendElasticsearch8, info: es7, wantErr: true},
+		{backend: BackendOpenSearch, info: os2},
+		{backend: BackendOpenSearch, info: es7, wantErr: true},
+	}
+	for _, tt := range tests {
+		backend, err := NewBackend(tt.backend)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := backend.Check(tt.info); (err != nil) != tt.wantErr {
+			t.Errorf("%s.Check(%s) error = %v, wantErr %v", backend.Name(), tt.info, err, tt.wantErr)
+		}
+	}
+
+	if err := ValidateBackend("solr"); err == nil {
+		t.Error("ValidateBackend(solr) expected an error")
+	}
+}
+
+func TestNewClient_Backend(t *testing.T) {
+	root := `{"version":{"number":"8.11.1"}}`
+	headers := map[string]http.Header{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers[r.URL.Path] = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/":
+			_, _ = w.Write([]byte(root))
+		case "/search_test/_bulk":
+			_, _ = w.Write([]byte(`{"errors":false,"items":[]}`))
+		default:
+			_, _ = w.Write([]byte(`{"hits":{"total":{"value":0},"hits":[]}}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{URL: server.URL, Backend: BackendElasticsearch8})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	ctx := context.Background()
+	if err := client.Ping(ctx); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if _, err := client.Search(ctx, "search_test", map[string]interface{}{"size": 1}); err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if err := client.BulkIndex(ctx, "search_test", []models.Document{{ID: "1", URI: "/a"}}); err != nil {
+		t.Fatalf("BulkIndex() error = %v", err)
+	}
+
+	want := map[string][2]string{
+		"/":                    {"application/vnd.elasticsearch+json;compatible-with=7", ""},
+		"/search_test/_search": {"application/vnd.elasticsearch+json;compatible-with=7", "application/vnd.elasticsearch+json;compatible-with=7"},
+		"/search_test/_bulk":   {"application/vnd.elasticsearch+json;compatible-with=7", "application/vnd.elasticsearch+x-ndjson;compatible-with=7"},
+	}
+	for path, w := range want {
+		h := headers[path]
+		if h.Get("Accept") != w[0] || h.Get("Content-Type") != w[1] {
+			t.Errorf("%s sent Accept %q, Content-Type %q, want %q, %q", path, h.Get("Accept"), h.Get("Content-Type"), w[0], w[1])
+		}
+	}
+
+	root = `{"version":{"distribution":"opensearch","number":"2.11.0"}}`
+	if err := client.Ping(ctx); err == nil {
+		t.Error("Ping() of an OpenSearch cluster with the elasticsearch8 backend expected an error")
+	}
+}
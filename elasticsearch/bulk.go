@@ -5,19 +5,197 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/ONSdigital/dis-search-test-bed/models"
 )
 
-// BulkIndex indexes multiple documents at once
+// Bulk chunk defaults, used for the zero value of each BulkConfig field
+const (
+	DefaultBulkChunkDocs  = 500
+	DefaultBulkChunkBytes = 5 << 20
+)
+
+// maxReportedFailures caps the failures a BulkError describes in its message
+const maxReportedFailures = 3
+
+// BulkConfig controls how BulkIndex splits documents into requests
+type BulkConfig struct {
+	ChunkDocs  int // Most documents per request
+	ChunkBytes int // Most body bytes per request; a larger document is sent alone
+	Workers    int // Requests sent at once; 1 sends chunks one after another
+}
+
+func (c BulkConfig) withDefaults() BulkConfig {
+	if c.ChunkDocs <= 0 {
+		c.ChunkDocs = DefaultBulkChunkDocs
+	}
+	if c.ChunkBytes <= 0 {
+		c.ChunkBytes = DefaultBulkChunkBytes
+	}
+	if c.Workers <= 0 {
+		c.Workers = 1
+	}
+	return c
+}
+
+// BulkFailure is a document Elasticsearch rejected
+type BulkFailure struct {
+	ID     string `json:"id"`
+	Status int    `json:"status"`
+	Type   string `json:"type"`   // e.g. mapper_parsing_exception
+	Reason string `json:"reason"` // Elasticsearch's explanation
+}
+
+// String formats the failure as id: type: reason
+func (f BulkFailure) String() string {
+	return fmt.Sprintf("%s: %s: %s", f.ID, f.Type, f.Reason)
+}
+
+// BulkError reports the documents a bulk load rejected. The other
+// documents were indexed.
+type BulkError struct {
+	Total    int
+	Failures []BulkFailure
+}
+
+// Error implements the error interface and lists the first few failures
+func (e *BulkError) Error() string {
+	shown := make([]string, 0, maxReportedFailures)
+	for _, f := range e.Failures[:min(len(e.Failures), maxReportedFailures)] {
+		shown = append(shown, f.String())
+	}
+	msg := fmt.Sprintf("bulk indexing failed for %d of %d documents: %s",
+		len(e.Failures), e.Total, strings.Join(shown, "; "))
+	if len(e.Failures) > maxReportedFailures {
+		msg += fmt.Sprintf("; and %d more", len(e.Failures)-maxReportedFailures)
+	}
+	return msg
+}
+
+// BulkProgressFunc is told how many documents have been sent so far
+type BulkProgressFunc func(indexed, total int)
+
+type bulkProgressKey struct{}
+
+// WithBulkProgress returns a context whose bulk loads call fn after each
+// chunk
+func WithBulkProgress(ctx context.Context, fn BulkProgressFunc) context.Context {
+	return context.WithValue(ctx, bulkProgressKey{}, fn)
+}
+
+// BulkProgress returns the progress function set on ctx, or nil
+func BulkProgress(ctx context.Context) BulkProgressFunc {
+	fn, _ := ctx.Value(bulkProgressKey{}).(BulkProgressFunc)
+	return fn
+}
+
+// BulkIndex indexes documents in chunks of at most ChunkDocs documents and
+// ChunkBytes bytes, so large corpora needn't be held in one request body.
+// Workers chunks are sent at once. Documents Elasticsearch rejects don't
+// stop the load; they are returned together, in document order, as a
+// *BulkError once every chunk has been sent. A failed request stops the
+// load and is returned instead.
 func (c *Client) BulkIndex(ctx context.Context, index string, docs []models.Document) error {
 	if len(docs) == 0 {
 		return nil
 	}
 
+	progress := BulkProgress(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		indexed int
+		failed  = make(map[int][]BulkFailure)
+		sendErr error
+	)
+	chunks := make(chan bulkChunk)
+	for range c.bulk.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range chunks {
+				failures, err := c.sendBulk(ctx, index, chunk.body)
+
+				mu.Lock()
+				switch {
+				case err != nil:
+					// Later errors are mostly the cancellation of the first
+					if sendErr == nil {
+						sendErr = err
+						cancel()
+					}
+				default:
+					failed[chunk.seq] = failures
+					indexed += chunk.docs
+					if progress != nil {
+						progress(indexed, len(docs))
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	splitErr := splitBulk(ctx, index, docs, c.bulk, chunks)
+	close(chunks)
+	wg.Wait()
+
+	if sendErr != nil {
+		return sendErr
+	}
+	if splitErr != nil {
+		return splitErr
+	}
+
+	var failures []BulkFailure
+	for seq := 0; seq < len(failed); seq++ {
+		failures = append(failures, failed[seq]...)
+	}
+	if len(failures) > 0 {
+		return &BulkError{Total: len(docs), Failures: failures}
+	}
+	return nil
+}
+
+// bulkChunk is one bulk request body
+type bulkChunk struct {
+	seq  int // Position among the load's chunks
+	body []byte
+	docs int
+}
+
+// splitBulk encodes documents into chunks and passes each to out, until the
+// documents run out or the context ends
+func splitBulk(ctx context.Context, index string, docs []models.Document, cfg BulkConfig, out chan<- bulkChunk) error {
 	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	chunk := bulkChunk{}
+
+	flush := func() error {
+		if chunk.docs == 0 {
+			return nil
+		}
+		chunk.body = bytes.Clone(buf.Bytes())
+		select {
+		case out <- chunk:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		buf.Reset()
+		chunk = bulkChunk{seq: chunk.seq + 1}
+		return nil
+	}
 
 	for _, doc := range docs {
+		start := buf.Len()
+
 		// Action line
 		action := map[string]interface{}{
 			"index": map[string]interface{}{
@@ -25,23 +203,126 @@ func (c *Client) BulkIndex(ctx context.Context, index string, docs []models.Docu
 				"_id":    doc.ID,
 			},
 		}
-		if err := json.NewEncoder(&buf).Encode(action); err != nil {
+		if err := enc.Encode(action); err != nil {
 			return fmt.Errorf("encode action: %w", err)
 		}
 
 		// Document line
-		if err := json.NewEncoder(&buf).Encode(doc); err != nil {
+		if err := enc.Encode(doc); err != nil {
 			return fmt.Errorf("encode document: %w", err)
 		}
+
+		// Send what came before if this document would overfill the chunk
+		if chunk.docs > 0 && buf.Len() > cfg.ChunkBytes {
+			line := bytes.Clone(buf.Bytes()[start:])
+			buf.Truncate(start)
+			if err := flush(); err != nil {
+				return err
+			}
+			buf.Write(line)
+		}
+
+		chunk.docs++
+		if chunk.docs >= cfg.ChunkDocs {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+// sendBulk sends one chunk and returns the documents it rejected.
+// Documents rejected with a 429, because the cluster's write queue was
+// full, are sent again in a smaller request after the usual backoff, and
+// only count as rejected once the retries run out.
+func (c *Client) sendBulk(ctx context.Context, index string, body []byte) ([]BulkFailure, error) {
+	retries := c.retry.MaxRetries
+	if c.retry.Disable {
+		retries = 0
+	}
+
+	// positions maps each document in the body to its place in the chunk,
+	// so failures from later attempts are still reported in order
+	lines := bytes.SplitAfter(body, []byte("\n"))
+	positions := make([]int, len(lines)/2)
+	for i := range positions {
+		positions[i] = i
+	}
+	failed := make(map[int]BulkFailure)
+
+	for attempt := 0; ; attempt++ {
+		items, err := c.postBulk(ctx, index, body)
+		if err != nil {
+			return nil, err
+		}
+
+		var again []int
+		for i, item := range items {
+			if item.Error == nil || i >= len(positions) {
+				continue
+			}
+			if item.Status == http.StatusTooManyRequests && attempt < retries {
+				again = append(again, positions[i])
+				continue
+			}
+			failed[positions[i]] = BulkFailure{
+				ID:     item.ID,
+				Status: item.Status,
+				Type:   item.Error.Type,
+				Reason: item.Error.Reason,
+			}
+		}
+		if len(again) == 0 {
+			break
+		}
+
+		if err := sleep(ctx, c.retry.backoff(attempt+1)); err != nil {
+			return nil, err
+		}
+		var retryBody []byte
+		for _, pos := range again {
+			retryBody = append(retryBody, lines[2*pos]...)
+			retryBody = append(retryBody, lines[2*pos+1]...)
+		}
+		body, positions = retryBody, again
 	}
 
+	if len(failed) == 0 {
+		return nil, nil
+	}
+	order := make([]int, 0, len(failed))
+	for pos := range failed {
+		order = append(order, pos)
+	}
+	sort.Ints(order)
+	failures := make([]BulkFailure, 0, len(order))
+	for _, pos := range order {
+		failures = append(failures, failed[pos])
+	}
+	return failures, nil
+}
+
+// bulkItem is Elasticsearch's outcome for one document of a bulk request
+type bulkItem struct {
+	ID     string `json:"_id"`
+	Status int    `json:"status"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+// postBulk sends one bulk request and returns each document's outcome, in
+// the order of the body
+func (c *Client) postBulk(ctx context.Context, index string, body []byte) ([]bulkItem, error) {
 	res, err := c.es.Bulk(
-		bytes.NewReader(buf.Bytes()),
+		bytes.NewReader(body),
 		c.es.Bulk.WithContext(ctx),
 		c.es.Bulk.WithIndex(index),
 	)
 	if err != nil {
-		return &Error{
+		return nil, &Error{
 			Type:    ErrorTypeIndex,
 			Message: "failed to bulk index",
 			Err:     err,
@@ -50,69 +331,29 @@ func (c *Client) BulkIndex(ctx context.Context, index string, docs []models.Docu
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return &Error{
+		return nil, &Error{
 			Type:    ErrorTypeIndex,
 			Message: fmt.Sprintf("bulk index error: %s", res.Status()),
 		}
 	}
 
 	var bulkResp struct {
-		Errors bool                     `json:"errors"`
-		Items  []map[string]interface{} `json:"items"`
+		Errors bool                  `json:"errors"`
+		Items  []map[string]bulkItem `json:"items"`
 	}
 
 	if err := json.NewDecoder(res.Body).Decode(&bulkResp); err != nil {
-		return fmt.Errorf("decode bulk response: %w", err)
+		return nil, fmt.Errorf("decode bulk response: %w", err)
 	}
-
-	if bulkResp.Errors {
-		// Count failed items
-		failedCount := 0
-		for _, item := range bulkResp.Items {
-			for _, v := range item {
-				if m, ok := v.(map[string]interface{}); ok {
-					if m["error"] != nil {
-						failedCount++
-					}
-				}
-			}
-		}
-		return fmt.Errorf("bulk indexing failed for %d documents", failedCount)
+	if !bulkResp.Errors {
+		return nil, nil
 	}
 
-	return nil
-}
-
-// DefaultMapping returns the default index mapping
-func DefaultMapping() map[string]interface{} {
-	return map[string]interface{}{
-		"settings": map[string]interface{}{
-			"number_of_shards":   1,
-			"number_of_replicas": 0,
-		},
-		"mappings": map[string]interface{}{
-			"properties": map[string]interface{}{
-				"title": map[string]interface{}{
-					"type": "text",
-					"fields": map[string]interface{}{
-						"keyword": map[string]interface{}{
-							"type": "keyword",
-						},
-					},
-				},
-				"uri": map[string]interface{}{
-					"type": "keyword",
-				},
-				"body": map[string]interface{}{
-					"type": "text",
-				},
-				"content_type": map[string]interface{}{
-					"type": "keyword",
-				},
-				"date": map[string]interface{}{
-					"type": "date",
-				},
-			},
-		},
+	items := make([]bulkItem, 0, len(bulkResp.Items))
+	for _, item := range bulkResp.Items {
+		for _, result := range item {
+			items = append(items, result)
+		}
 	}
+	return items, nil
 }
@@ -0,0 +1,334 @@
+package elasticsearch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestClient_BulkIndexChunks(t *testing.T) {
+	var chunks []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		type item struct {
+			ID     string                 `json:"_id"`
+			Status int                    `json:"status"`
+			Error  map[string]interface{} `json:"error,omitempty"`
+		}
+		var items []map[string]item
+		failed := false
+
+		scanner := bufio.NewScanner(r.Body)
+		scanner.Buffer(nil, 1<<20)
+		for line := 0; scanner.Scan(); line++ {
+			if line%2 == 1 {
+				continue
+			}
+			var action struct {
+				Index struct {
+					ID string `json:"_id"`
+				} `json:"index"`
+			}
+			if err := json.Unmarshal(scanner.Bytes(), &action); err != nil {
+				t.Errorf("decode action: %v", err)
+			}
+			it := item{ID: action.Index.ID, Status: http.StatusCreated}
+			if strings.HasSuffix(it.ID, "-bad") {
+				failed = true
+				it.Status = http.StatusBadRequest
+				it.Error = map[string]interface{}{"type": "mapper_parsing_exception", "reason": "failed to parse field [date]"}
+			}
+			items = append(items, map[string]item{"index": it})
+		}
+		chunks = append(chunks, len(items))
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": failed, "items": items})
+	}))
+	defer server.Close()
+
+	docs := func(n int, bad ...int) []models.Document {
+		out := make([]models.Document, n)
+		for i := range out {
+			out[i] = models.Document{ID: fmt.Sprintf("doc-%d", i), Title: "Inflation"}
+		}
+		for _, i := range bad {
+			out[i].ID += "-bad"
+		}
+		return out
+	}
+
+	tests := []struct {
+		name         string
+		bulk         BulkConfig
+		docs         []models.Document
+		wantChunks   []int
+		wantProgress []int
+		wantFailures []string
+	}{
+		{
+			name:         "by document count",
+			bulk:         BulkConfig{ChunkDocs: 4},
+			docs:         docs(10),
+			wantChunks:   []int{4, 4, 2},
+			wantProgress: []int{4, 8, 10},
+		},
+		{
+			name:         "by body size",
+			bulk:         BulkConfig{ChunkDocs: 100, ChunkBytes: 300},
+			docs:         docs(5),
+			wantChunks:   []int{2, 2, 1},
+			wantProgress: []int{2, 4, 5},
+		},
+		{
+			name:         "document larger than a chunk",
+			bulk:         BulkConfig{ChunkBytes: 10},
+			docs:         docs(3),
+			wantChunks:   []int{1, 1, 1},
+			wantProgress: []int{1, 2, 3},
+		},
+		{
+			name:         "failures don't stop later chunks",
+			bulk:         BulkConfig{ChunkDocs: 3},
+			docs:         docs(7, 1, 5),
+			wantChunks:   []int{3, 3, 1},
+			wantProgress: []int{3, 6, 7},
+			wantFailures: []string{"doc-1-bad", "doc-5-bad"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(Config{URL: server.URL, Bulk: tt.bulk})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			chunks = nil
+			var progress []int
+			ctx := WithBulkProgress(context.Background(), func(indexed, total int) {
+				if total != len(tt.docs) {
+					t.Errorf("progress total = %d, want %d", total, len(tt.docs))
+				}
+				progress = append(progress, indexed)
+			})
+
+			err = client.BulkIndex(ctx, "test", tt.docs)
+			if !reflect.DeepEqual(chunks, tt.wantChunks) {
+				t.Errorf("chunks = %v, want %v", chunks, tt.wantChunks)
+			}
+			if !reflect.DeepEqual(progress, tt.wantProgress) {
+				t.Errorf("progress = %v, want %v", progress, tt.wantProgress)
+			}
+
+			if tt.wantFailures == nil {
+				if err != nil {
+					t.Fatalf("BulkIndex() error = %v", err)
+				}
+				return
+			}
+			var bulkErr *BulkError
+			if !errors.As(err, &bulkErr) {
+				t.Fatalf("BulkIndex() error = %v, want a *BulkError", err)
+			}
+			var ids []string
+			for _, f := range bulkErr.Failures {
+				ids = append(ids, f.ID)
+				if f.Status != http.StatusBadRequest || f.Type != "mapper_parsing_exception" || f.Reason == "" {
+					t.Errorf("failure = %+v, want a 400 mapper_parsing_exception with a reason", f)
+				}
+			}
+			if !reflect.DeepEqual(ids, tt.wantFailures) {
+				t.Errorf("failed IDs = %v, want %v", ids, tt.wantFailures)
+			}
+			if bulkErr.Total != len(tt.docs) {
+				t.Errorf("Total = %d, want %d", bulkErr.Total, len(tt.docs))
+			}
+			if !strings.Contains(err.Error(), "2 of 7 documents") {
+				t.Errorf("Error() = %q, want the failure count", err.Error())
+			}
+		})
+	}
+}
+
+func TestClient_BulkIndexWorkers(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		inFlight int
+		peak     int
+		received = make(map[string]bool)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		var ids []string
+		scanner := bufio.NewScanner(r.Body)
+		for line := 0; scanner.Scan(); line++ {
+			var action struct {
+				Index struct {
+					ID string `json:"_id"`
+				} `json:"index"`
+			}
+			if line%2 == 0 && json.Unmarshal(scanner.Bytes(), &action) == nil {
+				ids = append(ids, action.Index.ID)
+			}
+		}
+		if slices.Contains(ids, "doc-13") {
+			http.Error(w, `{"error":"cluster_block_exception"}`, http.StatusForbidden)
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		var items []map[string]interface{}
+		failed := false
+		mu.Lock()
+		for _, id := range ids {
+			received[id] = true
+			it := map[string]interface{}{"_id": id, "status": http.StatusCreated}
+			if strings.HasSuffix(id, "0") {
+				failed = true
+				it["status"] = http.StatusBadRequest
+				it["error"] = map[string]interface{}{"type": "mapper_parsing_exception", "reason": "bad"}
+			}
+			items = append(items, map[string]interface{}{"index": it})
+		}
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": failed, "items": items})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{URL: server.URL, Bulk: BulkConfig{ChunkDocs: 2, Workers: 4}})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	docs := make([]models.Document, 12)
+	for i := range docs {
+		docs[i] = models.Document{ID: fmt.Sprintf("doc-%d", i)}
+	}
+	var progress []int
+	ctx := WithBulkProgress(context.Background(), func(indexed, _ int) {
+		progress = append(progress, indexed)
+	})
+
+	err = client.BulkIndex(ctx, "test", docs)
+	var bulkErr *BulkError
+	if !errors.As(err, &bulkErr) {
+		t.Fatalf("BulkIndex() error = %v, want a *BulkError", err)
+	}
+	var ids []string
+	for _, f := range bulkErr.Failures {
+		ids = append(ids, f.ID)
+	}
+	if want := []string{"doc-0", "doc-10"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("failed IDs = %v, want %v in document order", ids, want)
+	}
+	if len(received) != len(docs) {
+		t.Errorf("server received %d documents, want %d", len(received), len(docs))
+	}
+	if peak < 2 {
+		t.Errorf("at most %d requests were in flight, want several", peak)
+	}
+	if want := []int{2, 4, 6, 8, 10, 12}; !reflect.DeepEqual(progress, want) {
+		t.Errorf("progress = %v, want %v", progress, want)
+	}
+
+	// A failed request stops the load
+	docs = append(docs, models.Document{ID: "doc-12"}, models.Document{ID: "doc-13"})
+	err = client.BulkIndex(context.Background(), "test", docs)
+	var esErr *Error
+	if !errors.As(err, &esErr) {
+		t.Errorf("BulkIndex() error = %v, want an *Error for the failed request", err)
+	}
+}
+
+func TestClient_BulkIndexRetriesRejectedItems(t *testing.T) {
+	var (
+		requests [][]string
+		attempts = make(map[string]int)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ids []string
+		scanner := bufio.NewScanner(r.Body)
+		for line := 0; scanner.Scan(); line++ {
+			var action struct {
+				Index struct {
+					ID string `json:"_id"`
+				} `json:"index"`
+			}
+			if line%2 == 0 && json.Unmarshal(scanner.Bytes(), &action) == nil {
+				ids = append(ids, action.Index.ID)
+			}
+		}
+		requests = append(requests, ids)
+
+		var items []map[string]interface{}
+		for _, id := range ids {
+			attempts[id]++
+			it := map[string]interface{}{"_id": id, "status": http.StatusCreated}
+			switch {
+			case id == "doc-1":
+				it["status"] = http.StatusBadRequest
+				it["error"] = map[string]interface{}{"type": "mapper_parsing_exception", "reason": "bad"}
+			case id == "doc-0" || (id == "doc-2" && attempts[id] < 3):
+				it["status"] = http.StatusTooManyRequests
+				it["error"] = map[string]interface{}{"type": "es_rejected_execution_exception", "reason": "queue full"}
+			}
+			items = append(items, map[string]interface{}{"index": it})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": true, "items": items})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		URL:   server.URL,
+		Retry: RetryConfig{MaxRetries: 2, InitialBackoff: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	docs := make([]models.Document, 4)
+	for i := range docs {
+		docs[i] = models.Document{ID: fmt.Sprintf("doc-%d", i)}
+	}
+	err = client.BulkIndex(context.Background(), "test", docs)
+
+	want := [][]string{{"doc-0", "doc-1", "doc-2", "doc-3"}, {"doc-0", "doc-2"}, {"doc-0", "doc-2"}}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+	var bulkErr *BulkError
+	if !errors.As(err, &bulkErr) {
+		t.Fatalf("BulkIndex() error = %v, want a *BulkError", err)
+	}
+	var failed []string
+	for _, f := range bulkErr.Failures {
+		failed = append(failed, fmt.Sprintf("%s/%d", f.ID, f.Status))
+	}
+	if want := []string{"doc-0/429", "doc-1/400"}; !reflect.DeepEqual(failed, want) {
+		t.Errorf("failures = %v, want %v in document order", failed, want)
+	}
+}
@@ -4,37 +4,141 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 
 	"github.com/ONSdigital/dis-search-test-bed/models"
 	"github.com/elastic/go-elasticsearch/v7"
+	"github.com/elastic/go-elasticsearch/v7/esapi"
 )
 
 // Client wraps Elasticsearch client with convenience methods
 type Client struct {
-	es *elasticsearch.Client
+	es      *elasticsearch.Client
+	backend SearchBackend
+	bulk    BulkConfig
+	retry   RetryConfig // Also paces resending documents a bulk request rejected
+}
+
+// Config holds the settings used to create a Client
+type Config struct {
+	// URL and Addresses list the nodes requests are spread across. CloudID
+	// connects to an Elastic Cloud deployment instead.
+	URL       string
+	Addresses []string
+	CloudID   string
+
+	Backend   string // elasticsearch7 (default), elasticsearch8 or opensearch
+	Transport TransportConfig
+	Retry     RetryConfig
+	Bulk      BulkConfig
+
+	// Proxy is the HTTP proxy URL to reach the cluster through. When empty
+	// the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables apply.
+	Proxy string
+	TLS   TLSConfig
+
+	// Basic authentication, an API key (base64 "id:key") or a bearer token.
+	// When more than one is set, the bearer token is used first, then the
+	// API key.
+	Username    string
+	Password    string
+	APIKey      string
+	BearerToken string
+
+	// RoundTripper replaces the default HTTP transport when set
+	RoundTripper http.RoundTripper
 }
 
 // NewClient creates a new Elasticsearch client
-func NewClient(url string) (*Client, error) {
-	cfg := elasticsearch.Config{
-		Addresses: []string{url},
+func NewClient(cfg Config) (*Client, error) {
+	backend, err := NewBackend(cfg.Backend)
+	if err != nil {
+		return nil, &Error{
+			Type:    ErrorTypeConnection,
+			Message: "failed to configure backend",
+			Err:     err,
+		}
 	}
 
-	es, err := elasticsearch.NewClient(cfg)
+	transport, err := newTransport(cfg)
 	if err != nil {
+		return nil, &Error{
+			Type:    ErrorTypeConnection,
+			Message: "failed to configure transport",
+			Err:     err,
+		}
+	}
+
+	addresses := cfg.addresses()
+	if cfg.CloudID != "" && len(addresses) > 0 {
 		return nil, &Error{
 			Type:    ErrorTypeConnection,
 			Message: "failed to create client",
+			Err:     errors.New("set a cloud ID or node URLs, not both"),
+		}
+	}
+
+	retry, err := newRetryTransport(backend.Transport(transport), cfg.Retry, addresses)
+	if err != nil {
+		return nil, &Error{
+			Type:    ErrorTypeConnection,
+			Message: "failed to configure transport",
 			Err:     err,
 		}
 	}
 
-	return &Client{es: es}, nil
+	esCfg := elasticsearch.Config{
+		Addresses: addresses,
+		CloudID:   cfg.CloudID,
+		Username:  cfg.Username,
+		Password:  cfg.Password,
+		APIKey:    cfg.APIKey,
+		Transport: retry,
+		// Retries are left to the retry transport, which backs off and
+		// fails over to the next node
+		DisableRetry: true,
+	}
+	if cfg.BearerToken != "" {
+		// The client leaves an Authorization header it is given alone
+		esCfg.Header = http.Header{"Authorization": []string{"Bearer " + cfg.BearerToken}}
+	}
+
+	es, err := elasticsearch.NewClient(esCfg)
+	if err != nil {
+		return nil, &Error{
+			Type:    ErrorTypeConnection,
+			Message: "failed to create client",
+			Err:     err,
+		}
+	}
+
+	return &Client{es: es, backend: backend, bulk: cfg.Bulk.withDefaults(), retry: cfg.Retry.withDefaults()}, nil
+}
+
+// addresses lists the configured nodes once each, URL first
+func (cfg Config) addresses() []string {
+	var addresses []string
+	seen := make(map[string]bool)
+	for _, a := range append([]string{cfg.URL}, cfg.Addresses...) {
+		if a != "" && !seen[a] {
+			seen[a] = true
+			addresses = append(addresses, a)
+		}
+	}
+	return addresses
+}
+
+// Backend returns the backend the client was configured for
+func (c *Client) Backend() SearchBackend {
+	return c.backend
 }
 
-// Ping tests the connection to Elasticsearch
+// Ping tests the connection to Elasticsearch and checks the cluster runs
+// the engine the backend serves. A cluster that doesn't report its version,
+// such as one behind a gateway hiding the root endpoint, isn't checked.
 func (c *Client) Ping(ctx context.Context) error {
 	res, err := c.es.Info(c.es.Info.WithContext(ctx))
 	if err != nil {
@@ -53,9 +157,46 @@ func (c *Client) Ping(ctx context.Context) error {
 		}
 	}
 
+	var root rootInfo
+	if err := json.NewDecoder(res.Body).Decode(&root); err != nil && err != io.EOF {
+		return &Error{
+			Type:    ErrorTypeConnection,
+			Message: "failed to decode cluster info",
+			Err:     err,
+		}
+	}
+	if info := root.serverInfo(); info.Version != "" {
+		if err := c.backend.Check(info); err != nil {
+			return &Error{
+				Type:    ErrorTypeConnection,
+				Message: "wrong backend",
+				Err:     err,
+			}
+		}
+	}
+
 	return nil
 }
 
+// rootInfo is the body of the cluster's root endpoint
+type rootInfo struct {
+	ClusterName string `json:"cluster_name"`
+	ClusterUUID string `json:"cluster_uuid"`
+	Version     struct {
+		Number        string `json:"number"`
+		Distribution  string `json:"distribution"` // Only OpenSearch reports one
+		LuceneVersion string `json:"lucene_version"`
+	} `json:"version"`
+}
+
+func (r rootInfo) serverInfo() ServerInfo {
+	info := ServerInfo{Distribution: r.Version.Distribution, Version: r.Version.Number}
+	if info.Distribution == "" {
+		info.Distribution = DistributionElasticsearch
+	}
+	return info
+}
+
 // IndexExists checks if an index exists
 func (c *Client) IndexExists(ctx context.Context, index string) (bool, error) {
 	res, err := c.es.Indices.Exists(
@@ -150,6 +291,31 @@ func (c *Client) RefreshIndex(ctx context.Context, index string) error {
 	return nil
 }
 
+// ClearCache clears an index's query, request and fielddata caches
+func (c *Client) ClearCache(ctx context.Context, index string) error {
+	res, err := c.es.Indices.ClearCache(
+		c.es.Indices.ClearCache.WithContext(ctx),
+		c.es.Indices.ClearCache.WithIndex(index),
+	)
+	if err != nil {
+		return &Error{
+			Type:    ErrorTypeIndex,
+			Message: "failed to clear cache",
+			Err:     err,
+		}
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return &Error{
+			Type:    ErrorTypeIndex,
+			Message: fmt.Sprintf("clear cache error: %s", res.Status()),
+		}
+	}
+
+	return nil
+}
+
 // CountDocuments returns the number of documents in an index
 func (c *Client) CountDocuments(ctx context.Context, index string) (int, error) {
 	res, err := c.es.Count(
@@ -184,16 +350,37 @@ func (c *Client) CountDocuments(ctx context.Context, index string) (int, error)
 
 // Search executes a search query
 func (c *Client) Search(ctx context.Context, index string, query map[string]interface{}) (*SearchResponse, error) {
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+	return c.SearchWith(ctx, index, query, SearchOptions{})
+}
+
+// SearchWith executes a search query with the given search type and shard
+// preference
+func (c *Client) SearchWith(ctx context.Context, index string, query map[string]interface{}, opts SearchOptions) (*SearchResponse, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := json.NewEncoder(buf).Encode(query); err != nil {
 		return nil, fmt.Errorf("encode query: %w", err)
 	}
 
-	res, err := c.es.Search(
+	options := []func(*esapi.SearchRequest){
 		c.es.Search.WithContext(ctx),
-		c.es.Search.WithIndex(index),
-		c.es.Search.WithBody(&buf),
-	)
+		c.es.Search.WithBody(buf),
+	}
+	if index != "" {
+		options = append(options, c.es.Search.WithIndex(index))
+	}
+	if opts.SearchType != "" {
+		options = append(options, c.es.Search.WithSearchType(opts.SearchType))
+	}
+	if opts.Preference != "" {
+		options = append(options, c.es.Search.WithPreference(opts.Preference))
+	}
+	if id := OpaqueID(ctx); id != "" {
+		options = append(options, c.es.Search.WithOpaqueID(id))
+	}
+
+	res, err := c.es.Search(options...)
 	if err != nil {
 		return nil, &Error{
 			Type:    ErrorTypeQuery,
@@ -219,34 +406,68 @@ func (c *Client) Search(ctx context.Context, index string, query map[string]inte
 	return &result, nil
 }
 
-// Fetch retrieves documents from an index
-func (c *Client) Fetch(ctx context.Context, index string, size int) ([]models.Document, error) {
-	query := map[string]interface{}{
-		"query": map[string]interface{}{
-			"match_all": map[string]interface{}{},
-		},
-		"size": size,
-		"sort": []interface{}{
-			map[string]interface{}{"_id": "asc"},
-		},
-	}
+// FetchPageSize is how many documents each search of a Fetch returns
+const FetchPageSize = 1000
 
-	response, err := c.Search(ctx, index, query)
+// Fetch retrieves up to size documents from an index, in index order. It
+// pages through a point in time with search_after, so it isn't limited by
+// the index's max_result_window and pages don't shift as the index is
+// refreshed.
+func (c *Client) Fetch(ctx context.Context, index string, size int) (docs []models.Document, err error) {
+	pit, err := c.openPointInTime(ctx, index)
 	if err != nil {
 		return nil, err
 	}
+	defer func() {
+		if closeErr := c.closePointInTime(context.WithoutCancel(ctx), pit); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	docs = make([]models.Document, 0, min(size, FetchPageSize))
+	var after []interface{}
+
+	for len(docs) < size {
+		query := c.pointInTimeQuery(map[string]interface{}{
+			"query": map[string]interface{}{
+				"match_all": map[string]interface{}{},
+			},
+			"size":             min(size-len(docs), FetchPageSize),
+			"track_total_hits": false,
+		}, pit)
+		if after != nil {
+			query["search_after"] = after
+		}
 
-	docs := make([]models.Document, 0, len(response.Hits.Hits))
-	for _, hit := range response.Hits.Hits {
-		doc := models.Document{
-			ID:          hit.ID,
-			Title:       getStringField(hit.Source, "title"),
-			URI:         getStringField(hit.Source, "uri"),
-			Body:        getStringField(hit.Source, "body"),
-			ContentType: getStringField(hit.Source, "content_type"),
-			Date:        getStringField(hit.Source, "date"),
+		// A point in time search names no index
+		response, err := c.Search(ctx, "", query)
+		if err != nil {
+			return nil, fmt.Errorf("fetch page after %d documents: %w", len(docs), err)
+		}
+		if response.PitID != "" {
+			pit = response.PitID
+		}
+
+		hits := response.Hits.Hits
+		for _, hit := range hits {
+			docs = append(docs, models.Document{
+				ID:          hit.ID,
+				Title:       hit.Source.Title,
+				URI:         hit.Source.URI,
+				Body:        hit.Source.Body,
+				ContentType: hit.Source.ContentType,
+				Date:        hit.Source.Date,
+			})
+		}
+
+		// A short page is the end of the index
+		if len(hits) == 0 || len(hits) < query["size"].(int) {
+			break
+		}
+		after = hits[len(hits)-1].Sort
+		if after == nil {
+			return nil, fmt.Errorf("fetch page after %d documents: hits carry no sort values", len(docs))
 		}
-		docs = append(docs, doc)
 	}
 
 	return docs, nil
@@ -254,6 +475,7 @@ func (c *Client) Fetch(ctx context.Context, index string, size int) ([]models.Do
 
 // SearchResponse represents an Elasticsearch search response
 type SearchResponse struct {
+	Took int `json:"took"`
 	Hits struct {
 		Total struct {
 			Value    int    `json:"value"`
@@ -261,19 +483,33 @@ type SearchResponse struct {
 		} `json:"total"`
 		Hits []Hit `json:"hits"`
 	} `json:"hits"`
+
+	// PitID is the point in time to search next, when one was searched
+	PitID string `json:"pit_id,omitempty"`
 }
 
 // Hit represents a single search result
 type Hit struct {
-	Index  string                 `json:"_index"`
-	ID     string                 `json:"_id"`
-	Score  float64                `json:"_score"`
-	Source map[string]interface{} `json:"_source"`
+	Index  string    `json:"_index"`
+	ID     string    `json:"_id"`
+	Score  float64   `json:"_score"`
+	Source HitSource `json:"_source"`
+
+	// Sort holds the hit's sort values, for search_after
+	Sort []interface{} `json:"sort,omitempty"`
+
+	// Fields holds requested and collapse key values, InnerHits the
+	// documents of a collapsed group
+	Fields    map[string][]interface{} `json:"fields,omitempty"`
+	InnerHits map[string]InnerHits     `json:"inner_hits,omitempty"`
 }
 
-func getStringField(m map[string]interface{}, key string) string {
-	if v, ok := m[key].(string); ok {
-		return v
-	}
-	return ""
+// InnerHits are the hits returned within a hit, such as a collapsed group
+type InnerHits struct {
+	Hits struct {
+		Total struct {
+			Value int `json:"value"`
+		} `json:"total"`
+		Hits []Hit `json:"hits"`
+	} `json:"hits"`
 }
@@ -0,0 +1,130 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_FetchPages(t *testing.T) {
+	ids := make([]string, 2500)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("doc-%05d", i)
+	}
+
+	backends := []struct {
+		backend  string
+		pitPath  string
+		idField  string
+		wantSort string
+	}{
+		{backend: BackendElasticsearch7, pitPath: "/_pit", idField: "id", wantSort: "_doc"},
+		{backend: BackendElasticsearch8, pitPath: "/_pit", idField: "id", wantSort: "_shard_doc"},
+		{backend: BackendOpenSearch, pitPath: "/_search/point_in_time", idField: "pit_id", wantSort: "_doc"},
+	}
+	for _, b := range backends {
+		t.Run(b.backend, func(t *testing.T) {
+			var sizes []int
+			open := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case r.Method == http.MethodPost && r.URL.Path == "/source"+b.pitPath:
+					open++
+					_ = json.NewEncoder(w).Encode(map[string]interface{}{b.idField: "pit-1"})
+					return
+				case r.Method == http.MethodDelete && r.URL.Path == b.pitPath:
+					body, _ := io.ReadAll(r.Body)
+					if !strings.Contains(string(body), "pit-1") {
+						t.Errorf("close body = %s, want the point in time ID", body)
+					}
+					open--
+					_, _ = w.Write([]byte(`{"succeeded":true}`))
+					return
+				case r.URL.Path != "/_search":
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+					return
+				}
+
+				var body struct {
+					Size        int                 `json:"size"`
+					SearchAfter []interface{}       `json:"search_after"`
+					Sort        []map[string]string `json:"sort"`
+					PIT         struct {
+						ID string `json:"id"`
+					} `json:"pit"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("decode search body: %v", err)
+				}
+				if body.PIT.ID != "pit-1" {
+					t.Errorf("search pit = %q, want pit-1", body.PIT.ID)
+				}
+				if len(body.Sort) != 1 || body.Sort[0][b.wantSort] != "asc" {
+					t.Errorf("sort = %v, want %s", body.Sort, b.wantSort)
+				}
+				sizes = append(sizes, body.Size)
+
+				start := 0
+				if len(body.SearchAfter) == 1 {
+					after, _ := body.SearchAfter[0].(float64)
+					start = int(after) + 1
+				}
+				end := min(start+body.Size, len(ids))
+
+				hits := make([]map[string]interface{}, 0, end-start)
+				for i := start; i < end; i++ {
+					hits = append(hits, map[string]interface{}{
+						"_id":     ids[i],
+						"_source": map[string]interface{}{"title": ids[i], "uri": "/" + ids[i]},
+						"sort":    []interface{}{i},
+					})
+				}
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"pit_id": "pit-1", "hits": map[string]interface{}{"hits": hits}})
+			}))
+			defer server.Close()
+
+			client, err := NewClient(Config{URL: server.URL, Backend: b.backend})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			tests := []struct {
+				size      int
+				wantDocs  int
+				wantSizes []int
+			}{
+				{size: 10, wantDocs: 10, wantSizes: []int{10}},
+				{size: 2100, wantDocs: 2100, wantSizes: []int{1000, 1000, 100}},
+				{size: 5000, wantDocs: 2500, wantSizes: []int{1000, 1000, 1000}},
+			}
+			for _, tt := range tests {
+				sizes = nil
+				docs, err := client.Fetch(context.Background(), "source", tt.size)
+				if err != nil {
+					t.Fatalf("Fetch(%d) error = %v", tt.size, err)
+				}
+				if len(docs) != tt.wantDocs {
+					t.Errorf("Fetch(%d) returned %d documents, want %d", tt.size, len(docs), tt.wantDocs)
+				}
+				for i, doc := range docs {
+					if doc.ID != ids[i] {
+						t.Errorf("Fetch(%d) document %d = %s, want %s", tt.size, i, doc.ID, ids[i])
+						break
+					}
+				}
+				if fmt.Sprint(sizes) != fmt.Sprint(tt.wantSizes) {
+					t.Errorf("Fetch(%d) requested pages of %v, want %v", tt.size, sizes, tt.wantSizes)
+				}
+				if open != 0 {
+					t.Errorf("Fetch(%d) left %d points in time open", tt.size, open)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+)
+
+// ClusterSnapshot captures the cluster version, nodes and the settings of
+// index, so runs made against differently configured clusters can be told apart
+func (c *Client) ClusterSnapshot(ctx context.Context, index string) (*models.ClusterSnapshot, error) {
+	snapshot := &models.ClusterSnapshot{CapturedAt: time.Now()}
+
+	if err := c.captureInfo(ctx, snapshot); err != nil {
+		return nil, err
+	}
+	if err := c.captureNodes(ctx, snapshot); err != nil {
+		return nil, err
+	}
+	if err := c.captureIndex(ctx, index, snapshot); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+func (c *Client) captureInfo(ctx context.Context, snapshot *models.ClusterSnapshot) error {
+	res, err := c.es.Info(c.es.Info.WithContext(ctx))
+	var info rootInfo
+	if err := decodeClusterResponse(res, err, "cluster info", &info); err != nil {
+		return err
+	}
+
+	snapshot.ClusterName = info.ClusterName
+	snapshot.Distribution = info.serverInfo().Distribution
+	snapshot.ClusterUUID = info.ClusterUUID
+	snapshot.Version = info.Version.Number
+	snapshot.LuceneVersion = info.Version.LuceneVersion
+	return nil
+}
+
+func (c *Client) captureNodes(ctx context.Context, snapshot *models.ClusterSnapshot) error {
+	res, err := c.es.Nodes.Info(c.es.Nodes.Info.WithContext(ctx))
+	var nodes struct {
+		Nodes map[string]struct {
+			Name    string   `json:"name"`
+			Version string   `json:"version"`
+			Roles   []string `json:"roles"`
+		} `json:"nodes"`
+	}
+	if err := decodeClusterResponse(res, err, "node info", &nodes); err != nil {
+		return err
+	}
+
+	for _, n := range nodes.Nodes {
+		snapshot.Nodes = append(snapshot.Nodes, models.NodeSnapshot{
+			Name:    n.Name,
+			Version: n.Version,
+			Roles:   n.Roles,
+		})
+	}
+	sort.Slice(snapshot.Nodes, func(i, j int) bool {
+		return snapshot.Nodes[i].Name < snapshot.Nodes[j].Name
+	})
+	return nil
+}
+
+func (c *Client) captureIndex(ctx context.Context, index string, snapshot *models.ClusterSnapshot) error {
+	res, err := c.es.Indices.GetSettings(
+		c.es.Indices.GetSettings.WithContext(ctx),
+		c.es.Indices.GetSettings.WithIndex(index),
+		c.es.Indices.GetSettings.WithIncludeDefaults(true),
+	)
+	type indexSettings struct {
+		UUID             string `json:"uuid"`
+		NumberOfShards   string `json:"number_of_shards"`
+		NumberOfReplicas string `json:"number_of_replicas"`
+		RefreshInterval  string `json:"refresh_interval"`
+	}
+	var settings map[string]struct {
+		Settings struct {
+			Index indexSettings `json:"index"`
+		} `json:"settings"`
+		Defaults struct {
+			Index indexSettings `json:"index"`
+		} `json:"defaults"`
+	}
+	if err := decodeClusterResponse(res, err, "index settings", &settings); err != nil {
+		return err
+	}
+
+	// The index name may be an alias, so take the concrete index it resolves to
+	for name, s := range settings {
+		snapshot.Index = models.IndexSnapshot{
+			Name:             name,
+			UUID:             s.Settings.Index.UUID,
+			NumberOfShards:   s.Settings.Index.NumberOfShards,
+			NumberOfReplicas: s.Settings.Index.NumberOfReplicas,
+			RefreshInterval:  s.Settings.Index.RefreshInterval,
+		}
+		if snapshot.Index.RefreshInterval == "" {
+			snapshot.Index.RefreshInterval = s.Defaults.Index.RefreshInterval
+		}
+		break
+	}
+
+	res, err = c.es.Cat.Indices(
+		c.es.Cat.Indices.WithContext(ctx),
+		c.es.Cat.Indices.WithIndex(index),
+		c.es.Cat.Indices.WithFormat("json"),
+	)
+	var stats []struct {
+		Health    string `json:"health"`
+		DocsCount string `json:"docs.count"`
+	}
+	if err := decodeClusterResponse(res, err, "index stats", &stats); err != nil {
+		return err
+	}
+	if len(stats) > 0 {
+		snapshot.Index.Health = stats[0].Health
+		snapshot.Index.DocsCount = stats[0].DocsCount
+	}
+
+	return nil
+}
+
+// decodeClusterResponse checks a cluster API response and decodes its body into v
+func decodeClusterResponse(res *esapi.Response, err error, what string, v interface{}) error {
+	if err != nil {
+		return &Error{
+			Type:    ErrorTypeConnection,
+			Message: fmt.Sprintf("failed to get %s", what),
+			Err:     err,
+		}
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return &Error{
+			Type:    ErrorTypeConnection,
+			Message: fmt.Sprintf("%s error: %s", what, string(body)),
+		}
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode %s response: %w", what, err)
+	}
+	return nil
+}
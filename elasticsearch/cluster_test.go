@@ -0,0 +1,58 @@
+package elasticsearch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_ClusterSnapshot(t *testing.T) {
+	mux := http.NewServeMux()
+	respond := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(body))
+		}
+	}
+	mux.HandleFunc("/", respond(`{"cluster_name":"test","cluster_uuid":"abc","version":{"number":"7.10.2","lucene_version":"8.7.0"}}`))
+	mux.HandleFunc("/_nodes", respond(`{"nodes":{
+		"n2":{"name":"node-2","version":"7.10.2","roles":["data"]},
+		"n1":{"name":"node-1","version":"7.10.2","roles":["master","data"]}
+	}}`))
+	mux.HandleFunc("/search_test/_settings", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("include_defaults") != "true" {
+			t.Errorf("expected include_defaults, got %s", r.URL.RawQuery)
+		}
+		respond(`{"search_test_v2":{
+			"settings":{"index":{"uuid":"u1","number_of_shards":"3","number_of_replicas":"1"}},
+			"defaults":{"index":{"refresh_interval":"1s"}}
+		}}`)(w, r)
+	})
+	mux.HandleFunc("/_cat/indices/search_test", respond(`[{"health":"green","docs.count":"50"}]`))
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient(Config{URL: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	snapshot, err := client.ClusterSnapshot(context.Background(), "search_test")
+	if err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+
+	if snapshot.ClusterName != "test" || snapshot.Version != "7.10.2" || snapshot.LuceneVersion != "8.7.0" {
+		t.Errorf("unexpected cluster info: %+v", snapshot)
+	}
+	if len(snapshot.Nodes) != 2 || snapshot.Nodes[0].Name != "node-1" {
+		t.Errorf("expected nodes sorted by name, got %+v", snapshot.Nodes)
+	}
+	idx := snapshot.Index
+	if idx.Name != "search_test_v2" || idx.NumberOfShards != "3" || idx.NumberOfReplicas != "1" ||
+		idx.RefreshInterval != "1s" || idx.Health != "green" || idx.DocsCount != "50" {
+		t.Errorf("unexpected index snapshot: %+v", idx)
+	}
+}
@@ -0,0 +1,75 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Cluster health statuses that can be waited for. HealthNone skips the wait.
+const (
+	HealthGreen  = "green"
+	HealthYellow = "yellow"
+	HealthRed    = "red"
+	HealthNone   = "none"
+)
+
+// ValidateHealth checks a configured health status
+func ValidateHealth(status string) error {
+	switch status {
+	case HealthGreen, HealthYellow, HealthRed, HealthNone:
+		return nil
+	default:
+		return fmt.Errorf("unknown health %q: use %s, %s, %s or %s", status, HealthGreen, HealthYellow, HealthRed, HealthNone)
+	}
+}
+
+// HealthWaiter waits for an index to reach a cluster health status
+type HealthWaiter interface {
+	// WaitForHealth blocks until index is at least status ("green",
+	// "yellow" or "red"), failing once timeout passes
+	WaitForHealth(ctx context.Context, index, status string, timeout time.Duration) error
+}
+
+var _ HealthWaiter = (*Client)(nil)
+
+// WaitForHealth blocks until index is at least the given health status,
+// failing once timeout passes
+func (c *Client) WaitForHealth(ctx context.Context, index, status string, timeout time.Duration) error {
+	res, err := c.es.Cluster.Health(
+		c.es.Cluster.Health.WithContext(ctx),
+		c.es.Cluster.Health.WithIndex(index),
+		c.es.Cluster.Health.WithWaitForStatus(status),
+		c.es.Cluster.Health.WithTimeout(timeout),
+	)
+	if err != nil {
+		return &Error{
+			Type:    ErrorTypeConnection,
+			Message: "failed to check cluster health",
+			Err:     err,
+		}
+	}
+	defer res.Body.Close()
+
+	// A timed out wait is reported with 408 and the current status
+	var health struct {
+		Status   string `json:"status"`
+		TimedOut bool   `json:"timed_out"`
+	}
+	body, _ := io.ReadAll(res.Body)
+	if jerr := json.Unmarshal(body, &health); jerr != nil || (res.IsError() && health.Status == "") {
+		return &Error{
+			Type:    ErrorTypeIndex,
+			Message: fmt.Sprintf("cluster health error: %s", string(body)),
+		}
+	}
+	if health.TimedOut {
+		return &Error{
+			Type:    ErrorTypeIndex,
+			Message: fmt.Sprintf("index %s is %s after %s, want %s", index, health.Status, timeout, status),
+		}
+	}
+	return nil
+}
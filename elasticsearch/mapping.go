@@ -0,0 +1,148 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// LoadMapping reads an index mapping from a JSON file, so indexes can be
+// created with the production index's settings and mappings. The file may
+// hold a create index body ({"settings": ..., "mappings": ...}), a bare
+// mapping ({"properties": ...}), or the response of GET <index>/_mapping or
+// GET <index>, keyed by the index name.
+func LoadMapping(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read mapping file: %w", err)
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("parse mapping file: %w", err)
+	}
+
+	mapping, err := normaliseMapping(body)
+	if err != nil {
+		return nil, fmt.Errorf("mapping file %s: %w", path, err)
+	}
+	return mapping, nil
+}
+
+// normaliseMapping turns the accepted mapping file shapes into a create
+// index body
+func normaliseMapping(body map[string]interface{}) (map[string]interface{}, error) {
+	if _, ok := body["properties"]; ok {
+		return map[string]interface{}{"mappings": body}, nil
+	}
+
+	// An index's own GET response is keyed by its name
+	if len(body) == 1 {
+		for _, v := range body {
+			if inner, ok := v.(map[string]interface{}); ok {
+				if _, ok := inner["mappings"]; ok {
+					body = inner
+				}
+			}
+		}
+	}
+
+	if _, ok := body["mappings"].(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("no mappings object found")
+	}
+	out := map[string]interface{}{"mappings": body["mappings"]}
+	if settings, ok := body["settings"].(map[string]interface{}); ok {
+		out["settings"] = createSettings(settings)
+	}
+	return out, nil
+}
+
+// readOnlySettings are index settings GET <index> returns that can't be
+// given when creating an index
+var readOnlySettings = []string{"creation_date", "uuid", "version", "provided_name"}
+
+// createSettings drops the settings Elasticsearch sets itself from an
+// exported index's settings
+func createSettings(settings map[string]interface{}) map[string]interface{} {
+	index, ok := settings["index"].(map[string]interface{})
+	if !ok {
+		return settings
+	}
+	kept := make(map[string]interface{}, len(index))
+	for k, v := range index {
+		kept[k] = v
+	}
+	for _, k := range readOnlySettings {
+		delete(kept, k)
+	}
+	out := make(map[string]interface{}, len(settings))
+	for k, v := range settings {
+		out[k] = v
+	}
+	out["index"] = kept
+	return out
+}
+
+// MergeMapping returns base with overlay merged over it, object by object,
+// so a profile can add an analyzer or change one field without repeating
+// the rest. Values other than objects in overlay replace those in base.
+// Neither map is changed.
+func MergeMapping(base, overlay map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(base)+len(overlay))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range overlay {
+		inner, ok := v.(map[string]interface{})
+		if prev, prevOK := out[k].(map[string]interface{}); ok && prevOK {
+			out[k] = MergeMapping(prev, inner)
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// DefaultMapping returns the default index mapping
+func DefaultMapping() map[string]interface{} {
+	return map[string]interface{}{
+		"settings": map[string]interface{}{
+			"number_of_shards":   1,
+			"number_of_replicas": 0,
+		},
+		"mappings": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"title": map[string]interface{}{
+					"type": "text",
+					"fields": map[string]interface{}{
+						"keyword": map[string]interface{}{
+							"type": "keyword",
+						},
+					},
+				},
+				"uri": map[string]interface{}{
+					"type": "keyword",
+				},
+				"body": map[string]interface{}{
+					"type": "text",
+				},
+				"content_type": map[string]interface{}{
+					"type": "keyword",
+				},
+				"date": map[string]interface{}{
+					"type": "date",
+				},
+				models.WeightField: map[string]interface{}{
+					"type": "float",
+					"fields": map[string]interface{}{
+						"feature": map[string]interface{}{
+							"type": "rank_feature",
+						},
+					},
+				},
+			},
+		},
+	}
+}
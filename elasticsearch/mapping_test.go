@@ -0,0 +1,101 @@
+package elasticsearch
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadMapping(t *testing.T) {
+	properties := map[string]interface{}{"title": map[string]interface{}{"type": "text"}}
+
+	tests := []struct {
+		name    string
+		file    string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "create index body",
+			file: `{"settings": {"number_of_shards": 2}, "mappings": {"properties": {"title": {"type": "text"}}}}`,
+			want: map[string]interface{}{
+				"settings": map[string]interface{}{"number_of_shards": float64(2)},
+				"mappings": map[string]interface{}{"properties": properties},
+			},
+		},
+		{
+			name: "bare mapping",
+			file: `{"properties": {"title": {"type": "text"}}}`,
+			want: map[string]interface{}{"mappings": map[string]interface{}{"properties": properties}},
+		},
+		{
+			name: "exported index",
+			file: `{"ons": {
+				"aliases": {},
+				"mappings": {"properties": {"title": {"type": "text"}}},
+				"settings": {"index": {"number_of_shards": "2", "uuid": "x1", "creation_date": "1700000000000", "provided_name": "ons", "version": {"created": "7100099"}}}
+			}}`,
+			want: map[string]interface{}{
+				"settings": map[string]interface{}{"index": map[string]interface{}{"number_of_shards": "2"}},
+				"mappings": map[string]interface{}{"properties": properties},
+			},
+		},
+		{name: "no mappings", file: `{"settings": {}}`, wantErr: true},
+		{name: "not JSON", file: `title: text`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "mapping.json")
+			if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := LoadMapping(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadMapping() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadMapping() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeMapping(t *testing.T) {
+	base := DefaultMapping()
+	overlay := map[string]interface{}{
+		"settings": map[string]interface{}{
+			"analysis": map[string]interface{}{
+				"analyzer": map[string]interface{}{
+					"english_stem": map[string]interface{}{"tokenizer": "standard", "filter": []interface{}{"lowercase", "porter_stem"}},
+				},
+			},
+		},
+		"mappings": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"title": map[string]interface{}{"analyzer": "english_stem"},
+			},
+		},
+	}
+
+	got := MergeMapping(base, overlay)
+
+	settings := got["settings"].(map[string]interface{})
+	if settings["number_of_shards"] != 1 || settings["analysis"] == nil {
+		t.Errorf("settings = %v, want the base settings and the analysis added", settings)
+	}
+	properties := got["mappings"].(map[string]interface{})["properties"].(map[string]interface{})
+	title := properties["title"].(map[string]interface{})
+	if title["type"] != "text" || title["analyzer"] != "english_stem" || title["fields"] == nil {
+		t.Errorf("title = %v, want the base field with the analyzer set", title)
+	}
+	if properties["body"] == nil {
+		t.Error("body field was dropped")
+	}
+
+	baseTitle := base["mappings"].(map[string]interface{})["properties"].(map[string]interface{})["title"].(map[string]interface{})
+	if _, ok := baseTitle["analyzer"]; ok {
+		t.Error("MergeMapping() changed the base mapping")
+	}
+}
@@ -0,0 +1,132 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// MarkerIndex holds one load marker per test index. Markers live in their
+// own index so they never show up in the test index's search results.
+const MarkerIndex = "search-testbed-markers"
+
+// LoadMarker records which snapshot was last loaded into an index
+type LoadMarker struct {
+	Index       string    `json:"index"`
+	Fingerprint string    `json:"fingerprint"` // Hash of the documents and mapping loaded
+	Documents   int       `json:"documents"`
+	LoadedAt    time.Time `json:"loaded_at"`
+}
+
+// Markers stores the load marker of each test index
+type Markers interface {
+	// LoadMarker returns the index's marker, or nil if it has none
+	LoadMarker(ctx context.Context, index string) (*LoadMarker, error)
+	PutLoadMarker(ctx context.Context, marker LoadMarker) error
+	// DeleteLoadMarker removes the index's marker, if it has one
+	DeleteLoadMarker(ctx context.Context, index string) error
+}
+
+// LoadMarker returns the index's load marker, or nil if it has none
+func (c *Client) LoadMarker(ctx context.Context, index string) (*LoadMarker, error) {
+	res, err := c.es.Get(
+		MarkerIndex,
+		index,
+		c.es.Get.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, &Error{
+			Type:    ErrorTypeIndex,
+			Message: "failed to read load marker",
+			Err:     err,
+		}
+	}
+	defer res.Body.Close()
+
+	// The marker index or the marker itself doesn't exist yet
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return nil, &Error{
+			Type:    ErrorTypeIndex,
+			Message: fmt.Sprintf("read load marker error: %s", string(body)),
+		}
+	}
+
+	var doc struct {
+		Source LoadMarker `json:"_source"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode load marker: %w", err)
+	}
+	return &doc.Source, nil
+}
+
+// PutLoadMarker stores an index's load marker, replacing any earlier one
+func (c *Client) PutLoadMarker(ctx context.Context, marker LoadMarker) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := json.NewEncoder(buf).Encode(marker); err != nil {
+		return fmt.Errorf("encode load marker: %w", err)
+	}
+
+	res, err := c.es.Index(
+		MarkerIndex,
+		buf,
+		c.es.Index.WithContext(ctx),
+		c.es.Index.WithDocumentID(marker.Index),
+		c.es.Index.WithRefresh("true"),
+	)
+	if err != nil {
+		return &Error{
+			Type:    ErrorTypeIndex,
+			Message: "failed to store load marker",
+			Err:     err,
+		}
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return &Error{
+			Type:    ErrorTypeIndex,
+			Message: fmt.Sprintf("store load marker error: %s", string(body)),
+		}
+	}
+
+	return nil
+}
+
+// DeleteLoadMarker removes an index's load marker, if it has one
+func (c *Client) DeleteLoadMarker(ctx context.Context, index string) error {
+	res, err := c.es.Delete(
+		MarkerIndex,
+		index,
+		c.es.Delete.WithContext(ctx),
+		c.es.Delete.WithRefresh("true"),
+	)
+	if err != nil {
+		return &Error{
+			Type:    ErrorTypeIndex,
+			Message: "failed to delete load marker",
+			Err:     err,
+		}
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && res.StatusCode != http.StatusNotFound {
+		body, _ := io.ReadAll(res.Body)
+		return &Error{
+			Type:    ErrorTypeIndex,
+			Message: fmt.Sprintf("delete load marker error: %s", string(body)),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,504 @@
+// Package memory provides an in-memory stand-in for Elasticsearch. It keeps
+// indexes in process and scores documents with a simplified BM25, so the
+// loader, executor, runner and comparison can be exercised end to end in
+// tests and offline without a cluster. Results are deterministic: ties are
+// broken by document ID.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// BM25 parameters, as Elasticsearch's defaults
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// defaultSize matches Elasticsearch's default number of hits
+const defaultSize = 10
+
+// searchFields are the document fields that can be matched
+var searchFields = []string{"title", "body", "uri", "content_type"}
+
+// Client is an in-memory implementation of elasticsearch.API. Only the
+// match, match_phrase, multi_match (with field^boost) and match_all
+// queries are understood; they are found anywhere in a query, so compound
+// queries score as the sum of their text clauses. Everything else, such as
+// filters, scripts and rank features, is ignored.
+type Client struct {
+	mu      sync.RWMutex
+	indices map[string]*index
+	markers map[string]elasticsearch.LoadMarker
+}
+
+// index holds an index's documents and the term statistics built from them
+type index struct {
+	docs   []models.Document
+	byID   map[string]int
+	terms  []map[string]map[string]int // per document, field -> term -> count
+	length []map[string]int            // per document, field -> term count
+	df     map[string]map[string]int   // field -> term -> document frequency
+	avgLen map[string]float64          // field -> mean length
+}
+
+// NewClient creates an empty in-memory client
+func NewClient() *Client {
+	return &Client{indices: make(map[string]*index), markers: make(map[string]elasticsearch.LoadMarker)}
+}
+
+// NewClientWithIndex creates a client with one index already loaded
+func NewClientWithIndex(name string, docs []models.Document) *Client {
+	c := NewClient()
+	idx := &index{byID: make(map[string]int)}
+	idx.add(docs)
+	c.indices[name] = idx
+	return c
+}
+
+// Ping always succeeds
+func (c *Client) Ping(context.Context) error {
+	return nil
+}
+
+// IndexExists reports whether the index has been created
+func (c *Client) IndexExists(_ context.Context, name string) (bool, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, ok := c.indices[name]
+	return ok, nil
+}
+
+// CreateIndex creates an empty index. The mapping is ignored.
+func (c *Client) CreateIndex(_ context.Context, name string, _ map[string]interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.indices[name]; ok {
+		return &elasticsearch.Error{
+			Type:    elasticsearch.ErrorTypeIndex,
+			Message: fmt.Sprintf("create index error: index [%s] already exists", name),
+		}
+	}
+	c.indices[name] = &index{byID: make(map[string]int)}
+	return nil
+}
+
+// DeleteIndex removes an index and its documents
+func (c *Client) DeleteIndex(_ context.Context, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.indices[name]; !ok {
+		return missingIndex(name)
+	}
+	delete(c.indices, name)
+	return nil
+}
+
+// BulkIndex adds documents to an index, replacing any with the same ID. The
+// context's bulk progress function is told once, as there are no chunks.
+func (c *Client) BulkIndex(ctx context.Context, name string, docs []models.Document) error {
+	c.mu.Lock()
+	idx, ok := c.indices[name]
+	if !ok {
+		c.mu.Unlock()
+		return missingIndex(name)
+	}
+	idx.add(docs)
+	c.mu.Unlock()
+
+	if progress := elasticsearch.BulkProgress(ctx); progress != nil && len(docs) > 0 {
+		progress(len(docs), len(docs))
+	}
+	return nil
+}
+
+// RefreshIndex does nothing: documents are searchable as soon as they are
+// indexed
+func (c *Client) RefreshIndex(_ context.Context, name string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if _, ok := c.indices[name]; !ok {
+		return missingIndex(name)
+	}
+	return nil
+}
+
+// ClearCache does nothing: the fake keeps no caches
+func (c *Client) ClearCache(_ context.Context, name string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if _, ok := c.indices[name]; !ok {
+		return missingIndex(name)
+	}
+	return nil
+}
+
+// LoadMarker returns the index's load marker, or nil if it has none
+func (c *Client) LoadMarker(_ context.Context, name string) (*elasticsearch.LoadMarker, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	marker, ok := c.markers[name]
+	if !ok {
+		return nil, nil
+	}
+	return &marker, nil
+}
+
+// PutLoadMarker stores an index's load marker, replacing any earlier one
+func (c *Client) PutLoadMarker(_ context.Context, marker elasticsearch.LoadMarker) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.markers[marker.Index] = marker
+	return nil
+}
+
+// DeleteLoadMarker removes an index's load marker, if it has one
+func (c *Client) DeleteLoadMarker(_ context.Context, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.markers, name)
+	return nil
+}
+
+// WaitForHealth returns at once: an in-memory index is always green
+func (c *Client) WaitForHealth(_ context.Context, name, _ string, _ time.Duration) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if _, ok := c.indices[name]; !ok {
+		return missingIndex(name)
+	}
+	return nil
+}
+
+// CountDocuments returns the number of documents in an index
+func (c *Client) CountDocuments(_ context.Context, name string) (int, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	idx, ok := c.indices[name]
+	if !ok {
+		return 0, missingIndex(name)
+	}
+	return len(idx.docs), nil
+}
+
+// Fetch returns up to size documents ordered by ID
+func (c *Client) Fetch(_ context.Context, name string, size int) ([]models.Document, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	idx, ok := c.indices[name]
+	if !ok {
+		return nil, missingIndex(name)
+	}
+
+	docs := make([]models.Document, len(idx.docs))
+	copy(docs, idx.docs)
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+	if len(docs) > size {
+		docs = docs[:size]
+	}
+	return docs, nil
+}
+
+// Search scores every document against the query and returns the top hits
+// by score, then document ID
+func (c *Client) Search(_ context.Context, name string, query map[string]interface{}) (*elasticsearch.SearchResponse, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	idx, ok := c.indices[name]
+	if !ok {
+		return nil, missingIndex(name)
+	}
+	return idx.search(query), nil
+}
+
+// MultiSearch runs each query in turn
+func (c *Client) MultiSearch(ctx context.Context, name string, queries []map[string]interface{}) ([]elasticsearch.MultiSearchItem, error) {
+	items := make([]elasticsearch.MultiSearchItem, len(queries))
+	for i, q := range queries {
+		response, err := c.Search(ctx, name, q)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = elasticsearch.MultiSearchItem{SearchResponse: *response, Status: 200}
+	}
+	return items, nil
+}
+
+func missingIndex(name string) error {
+	return &elasticsearch.Error{
+		Type:    elasticsearch.ErrorTypeIndex,
+		Message: fmt.Sprintf("no such index [%s]", name),
+	}
+}
+
+// add indexes documents and rebuilds the term statistics
+func (idx *index) add(docs []models.Document) {
+	for _, doc := range docs {
+		if i, ok := idx.byID[doc.ID]; ok {
+			idx.docs[i] = doc
+			continue
+		}
+		idx.byID[doc.ID] = len(idx.docs)
+		idx.docs = append(idx.docs, doc)
+	}
+
+	idx.terms = make([]map[string]map[string]int, len(idx.docs))
+	idx.length = make([]map[string]int, len(idx.docs))
+	idx.df = make(map[string]map[string]int)
+	idx.avgLen = make(map[string]float64)
+
+	for i, doc := range idx.docs {
+		values := map[string]string{
+			"title":        doc.Title,
+			"body":         doc.Body,
+			"uri":          doc.URI,
+			"content_type": doc.ContentType,
+		}
+		idx.terms[i] = make(map[string]map[string]int, len(searchFields))
+		idx.length[i] = make(map[string]int, len(searchFields))
+		for _, field := range searchFields {
+			counts, n := termCounts(values[field])
+			idx.terms[i][field] = counts
+			idx.length[i][field] = n
+			idx.avgLen[field] += float64(n)
+
+			if idx.df[field] == nil {
+				idx.df[field] = make(map[string]int)
+			}
+			for term := range counts {
+				idx.df[field][term]++
+			}
+		}
+	}
+
+	if len(idx.docs) > 0 {
+		for field := range idx.avgLen {
+			idx.avgLen[field] /= float64(len(idx.docs))
+		}
+	}
+}
+
+// clause is a text match against one field
+type clause struct {
+	field string
+	text  string
+	boost float64
+}
+
+func (idx *index) search(query map[string]interface{}) *elasticsearch.SearchResponse {
+	clauses, matchAll := collectClauses(query["query"], nil, false)
+	if query["query"] == nil {
+		matchAll = true
+	}
+
+	type scored struct {
+		doc   int
+		score float64
+	}
+	var matches []scored
+	for i := range idx.docs {
+		score := 0.0
+		for _, c := range clauses {
+			score += idx.score(i, c)
+		}
+		if score == 0 && matchAll && len(clauses) == 0 {
+			score = 1
+		}
+		if score > 0 {
+			matches = append(matches, scored{doc: i, score: math.Round(score*10000) / 10000})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return idx.docs[matches[i].doc].ID < idx.docs[matches[j].doc].ID
+	})
+
+	size := defaultSize
+	if n, ok := toFloat(query["size"]); ok {
+		size = int(n)
+	}
+
+	response := &elasticsearch.SearchResponse{}
+	response.Hits.Total.Value = len(matches)
+	response.Hits.Total.Relation = "eq"
+	for i, m := range matches {
+		if i >= size {
+			break
+		}
+		doc := idx.docs[m.doc]
+		response.Hits.Hits = append(response.Hits.Hits, elasticsearch.Hit{
+			ID:    doc.ID,
+			Score: m.score,
+			Source: elasticsearch.HitSource{
+				Title:       doc.Title,
+				URI:         doc.URI,
+				Body:        doc.Body,
+				ContentType: doc.ContentType,
+				Date:        doc.Date,
+			},
+		})
+	}
+
+	return response
+}
+
+// score is the BM25 score of one document for one clause
+func (idx *index) score(doc int, c clause) float64 {
+	counts := idx.terms[doc][c.field]
+	if counts == nil {
+		return 0
+	}
+
+	n := float64(len(idx.docs))
+	norm := 1.0
+	if avg := idx.avgLen[c.field]; avg > 0 {
+		norm = 1 - b + b*float64(idx.length[doc][c.field])/avg
+	}
+
+	queryTerms, _ := termCounts(c.text)
+	total := 0.0
+	for term := range queryTerms {
+		tf := float64(counts[term])
+		if tf == 0 {
+			continue
+		}
+		df := float64(idx.df[c.field][term])
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		total += idf * tf * (k1 + 1) / (tf + k1*norm)
+	}
+
+	return total * c.boost
+}
+
+// collectClauses walks a query looking for match, match_phrase and
+// multi_match clauses, descending into compound queries. It also reports
+// whether the query contains match_all.
+func collectClauses(v interface{}, clauses []clause, matchAll bool) ([]clause, bool) {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		for key, child := range node {
+			switch key {
+			case "match", "match_phrase":
+				clauses = append(clauses, matchClauses(child)...)
+			case "multi_match":
+				clauses = append(clauses, multiMatchClauses(child)...)
+			case "match_all":
+				matchAll = true
+			default:
+				clauses, matchAll = collectClauses(child, clauses, matchAll)
+			}
+		}
+	case []interface{}:
+		for _, child := range node {
+			clauses, matchAll = collectClauses(child, clauses, matchAll)
+		}
+	}
+	return clauses, matchAll
+}
+
+func matchClauses(v interface{}) []clause {
+	fields, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var clauses []clause
+	for field, spec := range fields {
+		c := clause{field: field, boost: 1}
+		switch spec := spec.(type) {
+		case string:
+			c.text = spec
+		case map[string]interface{}:
+			c.text, _ = spec["query"].(string)
+			if b, ok := toFloat(spec["boost"]); ok {
+				c.boost = b
+			}
+		}
+		clauses = append(clauses, c)
+	}
+	return clauses
+}
+
+func multiMatchClauses(v interface{}) []clause {
+	spec, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	text, _ := spec["query"].(string)
+
+	var fields []string
+	switch f := spec["fields"].(type) {
+	case []interface{}:
+		for _, name := range f {
+			if s, ok := name.(string); ok {
+				fields = append(fields, s)
+			}
+		}
+	case []string:
+		fields = f
+	}
+
+	clauses := make([]clause, 0, len(fields))
+	for _, name := range fields {
+		c := clause{field: name, text: text, boost: 1}
+		if i := strings.IndexByte(name, '^'); i >= 0 {
+			c.field = name[:i]
+			if b, err := strconv.ParseFloat(name[i+1:], 64); err == nil {
+				c.boost = b
+			}
+		}
+		clauses = append(clauses, c)
+	}
+	return clauses
+}
+
+// termCounts lower-cases and splits text on anything but letters and
+// digits, returning each term's count and the total number of terms
+func termCounts(text string) (map[string]int, int) {
+	terms := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	counts := make(map[string]int, len(terms))
+	for _, term := range terms {
+		counts[term]++
+	}
+	return counts, len(terms)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+var _ elasticsearch.API = (*Client)(nil)
@@ -0,0 +1,148 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestClient_Search(t *testing.T) {
+	client := NewClientWithIndex("test", []models.Document{
+		{ID: "a", Title: "Rental prices", Body: "Inflation in rents."},
+		{ID: "b", Title: "Inflation", Body: "Prices rose."},
+		{ID: "c", Title: "Population", Body: "People."},
+	})
+
+	tests := []struct {
+		name  string
+		query map[string]interface{}
+		want  []string
+	}{
+		{
+			name: "match",
+			query: map[string]interface{}{"query": map[string]interface{}{
+				"match": map[string]interface{}{"body": "inflation"},
+			}},
+			want: []string{"a"},
+		},
+		{
+			name: "multi match with boost inside bool",
+			query: map[string]interface{}{"query": map[string]interface{}{
+				"bool": map[string]interface{}{"must": []interface{}{
+					map[string]interface{}{"multi_match": map[string]interface{}{
+						"query":  "inflation",
+						"fields": []interface{}{"title^3", "body"},
+					}},
+				}},
+			}},
+			want: []string{"b", "a"},
+		},
+		{
+			name: "size",
+			query: map[string]interface{}{
+				"query": map[string]interface{}{"match": map[string]interface{}{
+					"title": map[string]interface{}{"query": "prices inflation"},
+				}},
+				"size": 1,
+			},
+			want: []string{"b"},
+		},
+		{
+			name:  "match all",
+			query: map[string]interface{}{"query": map[string]interface{}{"match_all": map[string]interface{}{}}},
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			name: "unsupported clauses only",
+			query: map[string]interface{}{"query": map[string]interface{}{
+				"term": map[string]interface{}{"content_type": "bulletin"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := client.Search(context.Background(), "test", tt.query)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			hits := response.Hits.Hits
+			if len(hits) != len(tt.want) {
+				t.Fatalf("Search() returned %d hits, want %v", len(hits), tt.want)
+			}
+			for i, id := range tt.want {
+				if hits[i].ID != id {
+					t.Errorf("hit %d = %s, want %s", i, hits[i].ID, id)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_Indexing(t *testing.T) {
+	ctx := context.Background()
+	client := NewClient()
+
+	if _, err := client.Search(ctx, "test", nil); err == nil {
+		t.Error("expected searching a missing index to fail")
+	}
+	if err := client.BulkIndex(ctx, "test", []models.Document{{ID: "a"}}); err == nil {
+		t.Error("expected indexing into a missing index to fail")
+	}
+
+	if err := client.CreateIndex(ctx, "test", nil); err != nil {
+		t.Fatalf("CreateIndex() error = %v", err)
+	}
+	if err := client.CreateIndex(ctx, "test", nil); err == nil {
+		t.Error("expected creating an existing index to fail")
+	}
+
+	docs := []models.Document{
+		{ID: "b", Title: "GDP"},
+		{ID: "a", Title: "Inflation"},
+	}
+	if err := client.BulkIndex(ctx, "test", docs); err != nil {
+		t.Fatalf("BulkIndex() error = %v", err)
+	}
+	if err := client.BulkIndex(ctx, "test", []models.Document{{ID: "a", Title: "Consumer prices"}}); err != nil {
+		t.Fatalf("BulkIndex() error = %v", err)
+	}
+
+	count, err := client.CountDocuments(ctx, "test")
+	if err != nil || count != 2 {
+		t.Errorf("CountDocuments() = %d, %v, want 2 documents", count, err)
+	}
+
+	fetched, err := client.Fetch(ctx, "test", 10)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(fetched) != 2 || fetched[0].ID != "a" || fetched[0].Title != "Consumer prices" {
+		t.Errorf("expected documents by ID with a replaced, got %+v", fetched)
+	}
+
+	if err := client.DeleteIndex(ctx, "test"); err != nil {
+		t.Fatalf("DeleteIndex() error = %v", err)
+	}
+	if exists, _ := client.IndexExists(ctx, "test"); exists {
+		t.Error("expected the index to be deleted")
+	}
+}
+
+func TestClient_LengthNormalisation(t *testing.T) {
+	client := NewClientWithIndex("test", []models.Document{
+		{ID: "long", Body: "inflation figures with a great many other words in a much longer body text"},
+		{ID: "short", Body: "inflation figures"},
+	})
+
+	response, err := client.Search(context.Background(), "test", map[string]interface{}{
+		"query": map[string]interface{}{"match": map[string]interface{}{"body": "inflation"}},
+	})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if hits := response.Hits.Hits; len(hits) != 2 || hits[0].ID != "short" {
+		t.Errorf("expected the shorter document first, got %+v", hits)
+	}
+}
@@ -0,0 +1,94 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/elastic/go-elasticsearch/v7/esapi"
+)
+
+// MultiSearchItem is the response for one query within an msearch request.
+// Status and Error are set by Elasticsearch when that query failed.
+type MultiSearchItem struct {
+	SearchResponse
+	Status int             `json:"status"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// Err returns the item's error, or nil if the query succeeded
+func (i MultiSearchItem) Err() error {
+	if len(i.Error) == 0 {
+		return nil
+	}
+	return &Error{
+		Type:    ErrorTypeQuery,
+		Message: fmt.Sprintf("search error (status %d): %s", i.Status, string(i.Error)),
+	}
+}
+
+// MultiSearch executes several queries in a single _msearch request.
+// Responses are returned in the same order as the queries.
+func (c *Client) MultiSearch(ctx context.Context, index string, queries []map[string]interface{}) ([]MultiSearchItem, error) {
+	if len(queries) == 0 {
+		return nil, nil
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	enc := json.NewEncoder(buf)
+	for _, query := range queries {
+		// Empty header line: the index is set on the request itself
+		if _, err := buf.WriteString("{}\n"); err != nil {
+			return nil, fmt.Errorf("write header: %w", err)
+		}
+		if err := enc.Encode(query); err != nil {
+			return nil, fmt.Errorf("encode query: %w", err)
+		}
+	}
+
+	options := []func(*esapi.MsearchRequest){
+		c.es.Msearch.WithContext(ctx),
+		c.es.Msearch.WithIndex(index),
+	}
+	if id := OpaqueID(ctx); id != "" {
+		options = append(options, c.es.Msearch.WithOpaqueID(id))
+	}
+
+	res, err := c.es.Msearch(buf, options...)
+	if err != nil {
+		return nil, &Error{
+			Type:    ErrorTypeQuery,
+			Message: "failed to execute multi search",
+			Err:     err,
+		}
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return nil, &Error{
+			Type:    ErrorTypeQuery,
+			Message: fmt.Sprintf("multi search error: %s", string(body)),
+		}
+	}
+
+	var result struct {
+		Responses []MultiSearchItem `json:"responses"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode multi search response: %w", err)
+	}
+
+	if len(result.Responses) != len(queries) {
+		return nil, &Error{
+			Type: ErrorTypeQuery,
+			Message: fmt.Sprintf("multi search returned %d responses for %d queries",
+				len(result.Responses), len(queries)),
+		}
+	}
+
+	return result.Responses, nil
+}
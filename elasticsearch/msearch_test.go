@@ -0,0 +1,62 @@
+package elasticsearch
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_MultiSearch(t *testing.T) {
+	var lines int
+	var opaqueID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search_test/_msearch" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		opaqueID = r.Header.Get("X-Opaque-Id")
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			lines++
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"took":7,"responses":[
+			{"took":3,"status":200,"hits":{"hits":[{"_id":"1","_score":2.5,"_source":{"title":"GDP","uri":"/gdp"}}]}},
+			{"status":400,"error":{"type":"parsing_exception"}}
+		]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{URL: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	queries := []map[string]interface{}{
+		{"query": map[string]interface{}{"match_all": map[string]interface{}{}}},
+		{"query": map[string]interface{}{"bogus": map[string]interface{}{}}},
+	}
+
+	ctx := WithOpaqueID(context.Background(), "run-1/batch-1")
+	items, err := client.MultiSearch(ctx, "search_test", queries)
+	if err != nil {
+		t.Fatalf("multi search failed: %v", err)
+	}
+
+	if opaqueID != "run-1/batch-1" {
+		t.Errorf("X-Opaque-Id = %q, want run-1/batch-1", opaqueID)
+	}
+	if lines != 4 {
+		t.Errorf("expected 4 body lines, got %d", lines)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 responses, got %d", len(items))
+	}
+	if items[0].Err() != nil || items[0].Took != 3 || items[0].Hits.Hits[0].Source.URI != "/gdp" {
+		t.Errorf("unexpected first response: %+v", items[0])
+	}
+	if items[1].Err() == nil || !IsQueryError(items[1].Err()) {
+		t.Errorf("expected query error for second response")
+	}
+}
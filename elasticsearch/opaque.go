@@ -0,0 +1,18 @@
+package elasticsearch
+
+import "context"
+
+type opaqueIDKey struct{}
+
+// WithOpaqueID returns a context whose searches send id in the
+// X-Opaque-Id header. Elasticsearch copies it into its slow logs, task list
+// and audit trail, so testbed traffic can be picked out on a shared cluster.
+func WithOpaqueID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, opaqueIDKey{}, id)
+}
+
+// OpaqueID returns the request ID set on ctx, or "" if there is none
+func OpaqueID(ctx context.Context) string {
+	id, _ := ctx.Value(opaqueIDKey{}).(string)
+	return id
+}
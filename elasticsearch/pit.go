@@ -0,0 +1,93 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// pitKeepAlive is how long a point in time is kept between Fetch pages
+const pitKeepAlive = "2m"
+
+// openPointInTime opens a point in time on an index and returns its ID
+func (c *Client) openPointInTime(ctx context.Context, index string) (string, error) {
+	api := c.backend.PointInTime()
+	path := "/" + url.PathEscape(index) + api.Path + "?keep_alive=" + pitKeepAlive
+
+	var response map[string]interface{}
+	if err := c.perform(ctx, http.MethodPost, path, nil, &response); err != nil {
+		return "", fmt.Errorf("open point in time: %w", err)
+	}
+	id, _ := response[api.IDField].(string)
+	if id == "" {
+		return "", fmt.Errorf("open point in time: response has no %s", api.IDField)
+	}
+	return id, nil
+}
+
+// closePointInTime releases a point in time before its keep alive ends
+func (c *Client) closePointInTime(ctx context.Context, id string) error {
+	api := c.backend.PointInTime()
+	body := map[string]interface{}{api.IDField: id}
+	if api.CloseList {
+		body[api.IDField] = []string{id}
+	}
+	if err := c.perform(ctx, http.MethodDelete, api.Path, body, nil); err != nil {
+		return fmt.Errorf("close point in time: %w", err)
+	}
+	return nil
+}
+
+// pointInTimeQuery adds a point in time to a search body, sorted by the
+// backend's paging order
+func (c *Client) pointInTimeQuery(query map[string]interface{}, id string) map[string]interface{} {
+	query["pit"] = map[string]interface{}{"id": id, "keep_alive": pitKeepAlive}
+	query["sort"] = []interface{}{
+		map[string]interface{}{c.backend.PointInTime().Sort: "asc"},
+	}
+	return query
+}
+
+// perform sends a request the esapi package has no call for, decoding the
+// JSON response into out when it isn't nil
+func (c *Client) perform(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, path, reader)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.es.Perform(req)
+	if err != nil {
+		return &Error{Type: ErrorTypeConnection, Message: "request failed", Err: err}
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		data, _ := io.ReadAll(res.Body)
+		return &Error{Type: ErrorTypeQuery, Message: fmt.Sprintf("%s %s: %s", method, res.Status, data)}
+	}
+	if out == nil {
+		_, _ = io.Copy(io.Discard, res.Body)
+		return nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
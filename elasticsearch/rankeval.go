@@ -0,0 +1,63 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// RankEvalResponse represents the response of the _rank_eval API
+type RankEvalResponse struct {
+	MetricScore float64                        `json:"metric_score"`
+	Details     map[string]RankEvalQueryDetail `json:"details"`
+	Failures    map[string]json.RawMessage     `json:"failures"`
+}
+
+// RankEvalQueryDetail holds the _rank_eval result for a single request
+type RankEvalQueryDetail struct {
+	MetricScore float64 `json:"metric_score"`
+	UnratedDocs []struct {
+		Index string `json:"_index"`
+		ID    string `json:"_id"`
+	} `json:"unrated_docs"`
+}
+
+// RankEval submits queries and their ratings to the _rank_eval API
+func (c *Client) RankEval(ctx context.Context, index string, body map[string]interface{}) (*RankEvalResponse, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := json.NewEncoder(buf).Encode(body); err != nil {
+		return nil, fmt.Errorf("encode rank eval request: %w", err)
+	}
+
+	res, err := c.es.RankEval(
+		buf,
+		c.es.RankEval.WithContext(ctx),
+		c.es.RankEval.WithIndex(index),
+	)
+	if err != nil {
+		return nil, &Error{
+			Type:    ErrorTypeQuery,
+			Message: "failed to execute rank eval",
+			Err:     err,
+		}
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return nil, &Error{
+			Type:    ErrorTypeQuery,
+			Message: fmt.Sprintf("rank eval error: %s", string(body)),
+		}
+	}
+
+	var result RankEvalResponse
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode rank eval response: %w", err)
+	}
+
+	return &result, nil
+}
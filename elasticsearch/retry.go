@@ -0,0 +1,238 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Retry defaults, used for the zero value of each RetryConfig field
+const (
+	DefaultMaxRetries     = 3
+	DefaultInitialBackoff = 200 * time.Millisecond
+	DefaultMaxBackoff     = 5 * time.Second
+)
+
+// DefaultRetryOnStatus are the response statuses retried by default: too
+// many requests, and the gateway errors a restarting node gives
+var DefaultRetryOnStatus = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryConfig controls how requests that fail transiently are retried.
+// Network errors and the OnStatus responses are retried; the wait doubles
+// after each attempt, from InitialBackoff up to MaxBackoff.
+type RetryConfig struct {
+	Disable        bool          // Send every request once
+	MaxRetries     int           // Retries after the first attempt
+	InitialBackoff time.Duration // Wait before the first retry
+	MaxBackoff     time.Duration // Longest wait between attempts, including a Retry-After
+	OnStatus       []int         // Response statuses to retry
+}
+
+// withDefaults fills in unset fields
+func (c RetryConfig) withDefaults() RetryConfig {
+	if c.MaxRetries <= 0 {
+		c.MaxRetries = DefaultMaxRetries
+	}
+	if c.InitialBackoff <= 0 {
+		c.InitialBackoff = DefaultInitialBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = DefaultMaxBackoff
+	}
+	if c.MaxBackoff < c.InitialBackoff {
+		c.MaxBackoff = c.InitialBackoff
+	}
+	if len(c.OnStatus) == 0 {
+		c.OnStatus = DefaultRetryOnStatus
+	}
+	return c
+}
+
+// backoff returns the wait before a retry, counting from 1. The wait is
+// jittered between half and all of the exponential delay, so clients that
+// failed together don't retry together.
+func (c RetryConfig) backoff(retry int) time.Duration {
+	d := c.InitialBackoff
+	for i := 1; i < retry && d < c.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > c.MaxBackoff {
+		d = c.MaxBackoff
+	}
+	half := d / 2
+	// #nosec G404 - jitter doesn't need a secure source
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryTransport resends requests that failed with a network error or a
+// retryable status. With several nodes, each retry goes to the next one.
+type retryTransport struct {
+	next   http.RoundTripper
+	config RetryConfig
+	retry  map[int]bool
+	nodes  []*url.URL
+}
+
+func newRetryTransport(next http.RoundTripper, cfg RetryConfig, addresses []string) (http.RoundTripper, error) {
+	if cfg.Disable {
+		return next, nil
+	}
+
+	var nodes []*url.URL
+	if len(addresses) > 1 {
+		for _, a := range addresses {
+			u, err := url.Parse(strings.TrimRight(a, "/"))
+			if err != nil || u.Host == "" {
+				return nil, fmt.Errorf("invalid node URL %q", a)
+			}
+			nodes = append(nodes, u)
+		}
+	}
+
+	cfg = cfg.withDefaults()
+	retry := make(map[int]bool, len(cfg.OnStatus))
+	for _, status := range cfg.OnStatus {
+		retry[status] = true
+	}
+	return &retryTransport{next: next, config: cfg, retry: retry, nodes: nodes}, nil
+}
+
+// RoundTrip sends the request, retrying it until it succeeds, fails
+// permanently, runs out of retries or its context ends. The last response
+// or error is returned.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		out := req.Clone(ctx)
+		if attempt > 0 {
+			t.failover(out, attempt)
+		}
+		if body != nil {
+			out.Body = io.NopCloser(bytes.NewReader(body))
+			out.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+		}
+
+		res, err := t.next.RoundTrip(out)
+		if attempt >= t.config.MaxRetries || !t.shouldRetry(ctx, res, err) {
+			return res, err
+		}
+
+		wait := t.config.backoff(attempt + 1)
+		if res != nil {
+			if after, ok := retryAfter(res); ok {
+				wait = min(after, t.config.MaxBackoff)
+			}
+			// Drain the body so the connection can be reused
+			_, _ = io.Copy(io.Discard, res.Body)
+			_ = res.Body.Close()
+		}
+
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// failover points a retry at the node attempt places after the one the
+// request was first sent to
+func (t *retryTransport) failover(req *http.Request, attempt int) {
+	for i, node := range t.nodes {
+		if node.Scheme != req.URL.Scheme || node.Host != req.URL.Host || !strings.HasPrefix(req.URL.Path, node.Path) {
+			continue
+		}
+		target := t.nodes[(i+attempt)%len(t.nodes)]
+		req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+		req.URL.Path = target.Path + strings.TrimPrefix(req.URL.Path, node.Path)
+		req.URL.RawPath = ""
+		req.Host = ""
+		if target.User != nil {
+			req.URL.User = target.User
+		}
+		return
+	}
+}
+
+func (t *retryTransport) shouldRetry(ctx context.Context, res *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !permanent(err)
+	}
+	return t.retry[res.StatusCode]
+}
+
+// permanent reports whether a transport error will recur however often the
+// request is sent, such as an untrusted certificate. The caller's context
+// ending is checked by shouldRetry; an attempt timing out isn't permanent.
+func permanent(err error) bool {
+	var (
+		verifyErr    *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	return errors.As(err, &verifyErr) ||
+		errors.As(err, &authorityErr) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidErr)
+}
+
+// readBody reads and closes the request's body so every attempt can send
+// it again
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read request body: %w", err)
+	}
+	if err := req.Body.Close(); err != nil {
+		return nil, fmt.Errorf("close request body: %w", err)
+	}
+	return body, nil
+}
+
+// retryAfter reads a Retry-After header given in seconds
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(res.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// sleep waits for d or until the context ends
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
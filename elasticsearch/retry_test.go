@@ -0,0 +1,143 @@
+package elasticsearch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_Retry(t *testing.T) {
+	const query = `{"size":1}` + "\n"
+
+	tests := []struct {
+		name      string
+		retry     RetryConfig
+		statuses  []int
+		wantErr   bool
+		wantCalls int32
+	}{
+		{name: "recovers", statuses: []int{503, 429, 200}, wantCalls: 3},
+		{name: "gives up", retry: RetryConfig{MaxRetries: 2}, statuses: []int{502, 502, 502, 200}, wantErr: true, wantCalls: 3},
+		{name: "not retryable", statuses: []int{400, 200}, wantErr: true, wantCalls: 1},
+		{name: "own statuses", retry: RetryConfig{OnStatus: []int{500}}, statuses: []int{500, 200}, wantCalls: 2},
+		{name: "disabled", retry: RetryConfig{Disable: true}, statuses: []int{503, 200}, wantErr: true, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&calls, 1)
+				if body, _ := io.ReadAll(r.Body); string(body) != query {
+					t.Errorf("attempt %d sent body %q, want %q", n, body, query)
+				}
+				status := tt.statuses[n-1]
+				w.Header().Set("Content-Type", "application/json")
+				if status == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "0")
+				}
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte(`{"hits":{"total":{"value":0},"hits":[]}}`))
+			}))
+			defer server.Close()
+
+			retry := tt.retry
+			retry.InitialBackoff = time.Millisecond
+			client, err := NewClient(Config{URL: server.URL, Retry: retry})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			_, err = client.Search(context.Background(), "search_test", map[string]interface{}{"size": 1})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Search() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Search() made %d attempts, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestClient_RetryStopsWithContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{URL: server.URL, Retry: RetryConfig{InitialBackoff: time.Hour, MaxBackoff: time.Hour}})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.Search(ctx, "search_test", map[string]interface{}{}); err == nil {
+		t.Error("Search() expected an error once the context ended")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Search() kept waiting %v after the context ended", elapsed)
+	}
+}
+
+func TestRetryConfig_Backoff(t *testing.T) {
+	cfg := RetryConfig{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}.withDefaults()
+
+	tests := []struct {
+		retry int
+		max   time.Duration
+	}{
+		{retry: 1, max: 100 * time.Millisecond},
+		{retry: 2, max: 200 * time.Millisecond},
+		{retry: 4, max: 800 * time.Millisecond},
+		{retry: 10, max: time.Second},
+	}
+	for _, tt := range tests {
+		got := cfg.backoff(tt.retry)
+		if got < tt.max/2 || got > tt.max {
+			t.Errorf("backoff(%d) = %v, want between %v and %v", tt.retry, got, tt.max/2, tt.max)
+		}
+	}
+}
+
+func TestClient_RetryFailsOver(t *testing.T) {
+	var downCalls, upCalls int32
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&downCalls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&upCalls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"hits":{"total":{"value":0},"hits":[]}}`))
+	}))
+	defer up.Close()
+
+	client, err := NewClient(Config{
+		URL:       down.URL,
+		Addresses: []string{up.URL},
+		Retry:     RetryConfig{InitialBackoff: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err := client.Search(context.Background(), "search_test", map[string]interface{}{}); err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+	}
+	if downCalls == 0 || upCalls != 4 {
+		t.Errorf("Search() sent %d requests to the down node and %d to the up node, want some and 4", downCalls, upCalls)
+	}
+
+	if _, err := NewClient(Config{URL: up.URL, CloudID: "search-test:ZXUtd2VzdC0xLmF3cy5mb3VuZC5pbyRhYmMkZGVm"}); err == nil {
+		t.Error("NewClient() expected an error for both a cloud ID and a URL")
+	}
+}
@@ -0,0 +1,50 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// PutScript stores a Painless script under id. Elasticsearch compiles the
+// script on upload, so a script that fails to compile is rejected here
+// rather than part way through a run.
+func (c *Client) PutScript(ctx context.Context, id, source string) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	body := map[string]interface{}{
+		"script": map[string]interface{}{
+			"lang":   "painless",
+			"source": source,
+		},
+	}
+	if err := json.NewEncoder(buf).Encode(body); err != nil {
+		return fmt.Errorf("encode script: %w", err)
+	}
+
+	res, err := c.es.PutScript(
+		id,
+		buf,
+		c.es.PutScript.WithContext(ctx),
+	)
+	if err != nil {
+		return &Error{
+			Type:    ErrorTypeQuery,
+			Message: fmt.Sprintf("failed to store script %s", id),
+			Err:     err,
+		}
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return &Error{
+			Type:    ErrorTypeQuery,
+			Message: fmt.Sprintf("script %s rejected: %s", id, string(body)),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_PutScript(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "accepted", status: http.StatusOK},
+		{name: "compile error", status: http.StatusBadRequest, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got struct {
+				Script struct {
+					Lang   string `json:"lang"`
+					Source string `json:"source"`
+				} `json:"script"`
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPut || r.URL.Path != "/_scripts/boost" {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("decode body: %v", err)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				if tt.status != http.StatusOK {
+					_, _ = w.Write([]byte(`{"error":{"type":"script_exception","reason":"compile error"}}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"acknowledged":true}`))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(Config{URL: server.URL})
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			err = client.PutScript(context.Background(), "boost", "_score * 2")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PutScript() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "script_exception") {
+				t.Errorf("expected the Elasticsearch reason in the error, got %v", err)
+			}
+			if got.Script.Lang != "painless" || got.Script.Source != "_score * 2" {
+				t.Errorf("unexpected script body: %+v", got.Script)
+			}
+		})
+	}
+}
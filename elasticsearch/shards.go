@@ -0,0 +1,74 @@
+package elasticsearch
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// Search types accepted by SearchOptions.SearchType
+const (
+	// SearchTypeQueryThenFetch scores each shard with its own term statistics
+	SearchTypeQueryThenFetch = "query_then_fetch"
+	// SearchTypeDFSQueryThenFetch gathers term statistics from every shard
+	// first, so all shards score with index-wide IDF
+	SearchTypeDFSQueryThenFetch = "dfs_query_then_fetch"
+)
+
+// SearchOptions adjusts how a search is distributed across shards
+type SearchOptions struct {
+	SearchType string // query_then_fetch (the default) or dfs_query_then_fetch
+	Preference string // e.g. "_shards:0" to search a single shard
+}
+
+// ShardPreference returns the preference that restricts a search to one shard
+func ShardPreference(shard int) string {
+	return "_shards:" + strconv.Itoa(shard)
+}
+
+// ShardCopy is one primary or replica copy of an index shard
+type ShardCopy struct {
+	Shard   int    `json:"shard"`
+	Primary bool   `json:"primary"`
+	State   string `json:"state"`
+	Docs    int    `json:"docs"`
+	Node    string `json:"node,omitempty"`
+}
+
+// Shards lists the shard copies of an index with their document counts
+func (c *Client) Shards(ctx context.Context, index string) ([]ShardCopy, error) {
+	res, err := c.es.Cat.Shards(
+		c.es.Cat.Shards.WithContext(ctx),
+		c.es.Cat.Shards.WithIndex(index),
+		c.es.Cat.Shards.WithFormat("json"),
+	)
+	var rows []struct {
+		Shard  string `json:"shard"`
+		PriRep string `json:"prirep"`
+		State  string `json:"state"`
+		Docs   string `json:"docs"`
+		Node   string `json:"node"`
+	}
+	if err := decodeClusterResponse(res, err, "shards", &rows); err != nil {
+		return nil, err
+	}
+
+	copies := make([]ShardCopy, 0, len(rows))
+	for _, row := range rows {
+		shard, err := strconv.Atoi(row.Shard)
+		if err != nil {
+			return nil, fmt.Errorf("parse shard number %q: %w", row.Shard, err)
+		}
+		// Unassigned copies report no document count
+		docs, _ := strconv.Atoi(row.Docs)
+		copies = append(copies, ShardCopy{
+			Shard:   shard,
+			Primary: row.PriRep == "p",
+			State:   row.State,
+			Docs:    docs,
+			Node:    row.Node,
+		})
+	}
+
+	return copies, nil
+}
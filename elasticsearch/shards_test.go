@@ -0,0 +1,81 @@
+package elasticsearch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_SearchWith(t *testing.T) {
+	var searchType, preference string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		searchType = r.URL.Query().Get("search_type")
+		preference = r.URL.Query().Get("preference")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"took":1,"hits":{"hits":[{"_id":"a","_score":1.5}]}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{URL: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	res, err := client.SearchWith(context.Background(), "test", map[string]interface{}{}, SearchOptions{
+		SearchType: SearchTypeDFSQueryThenFetch,
+		Preference: ShardPreference(2),
+	})
+	if err != nil {
+		t.Fatalf("SearchWith() error = %v", err)
+	}
+	if searchType != "dfs_query_then_fetch" || preference != "_shards:2" {
+		t.Errorf("search_type = %q, preference = %q", searchType, preference)
+	}
+	if len(res.Hits.Hits) != 1 || res.Hits.Hits[0].Score != 1.5 {
+		t.Errorf("unexpected hits %+v", res.Hits.Hits)
+	}
+
+	if _, err := client.Search(context.Background(), "test", map[string]interface{}{}); err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if searchType != "" || preference != "" {
+		t.Errorf("Search() sent search_type = %q, preference = %q", searchType, preference)
+	}
+}
+
+func TestClient_Shards(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_cat/shards/test" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[
+			{"index":"test","shard":"0","prirep":"p","state":"STARTED","docs":"120","node":"node-1"},
+			{"index":"test","shard":"0","prirep":"r","state":"UNASSIGNED","docs":null,"node":null}
+		]`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{URL: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	shards, err := client.Shards(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("Shards() error = %v", err)
+	}
+	want := []ShardCopy{
+		{Shard: 0, Primary: true, State: "STARTED", Docs: 120, Node: "node-1"},
+		{Shard: 0, Primary: false, State: "UNASSIGNED"},
+	}
+	if len(shards) != len(want) {
+		t.Fatalf("got %d shard copies, want %d", len(shards), len(want))
+	}
+	for i := range want {
+		if shards[i] != want[i] {
+			t.Errorf("shard copy %d = %+v, want %+v", i, shards[i], want[i])
+		}
+	}
+}
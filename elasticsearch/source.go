@@ -0,0 +1,122 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// bufferPool holds request buffers reused across searches to avoid
+// allocating a fresh buffer for every query body
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+func getBuffer() *bytes.Buffer {
+	buf, ok := bufferPool.Get().(*bytes.Buffer)
+	if !ok {
+		return new(bytes.Buffer)
+	}
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	bufferPool.Put(buf)
+}
+
+// HitSource holds the typed fields of a hit's _source document.
+// Fields the test bed doesn't know about are kept undecoded in Extras.
+type HitSource struct {
+	Title       string                     `json:"title"`
+	URI         string                     `json:"uri"`
+	Body        string                     `json:"body"`
+	ContentType string                     `json:"content_type"`
+	Date        string                     `json:"date"`
+	Extras      map[string]json.RawMessage `json:"-"`
+}
+
+// knownSource has the same fields as HitSource without its UnmarshalJSON
+type knownSource HitSource
+
+// UnmarshalJSON decodes the known fields straight into strings. The raw
+// map is only built when the source contains fields beyond the known ones,
+// so the common case costs one allocation per field.
+func (s *HitSource) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*knownSource)(s)); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			return fmt.Errorf("decode source: %w", err)
+		}
+	}
+
+	if !hasUnknownSourceKeys(data) {
+		return nil
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return fmt.Errorf("decode source extras: %w", err)
+	}
+	for key := range all {
+		if isKnownSourceKey(key) {
+			delete(all, key)
+		}
+	}
+	s.Extras = all
+
+	return nil
+}
+
+func isKnownSourceKey(key string) bool {
+	switch key {
+	case "title", "uri", "body", "content_type", "date":
+		return true
+	}
+	return false
+}
+
+// hasUnknownSourceKeys scans the top-level keys of a JSON object without
+// allocating and reports whether any of them is not a known source field
+func hasUnknownSourceKeys(data []byte) bool {
+	depth := 0
+	expectKey := false
+
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case '{':
+			depth++
+			expectKey = depth == 1
+		case '[':
+			depth++
+		case '}', ']':
+			depth--
+		case ',':
+			expectKey = depth == 1
+		case '"':
+			end := i + 1
+			for end < len(data) && data[end] != '"' {
+				if data[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end > len(data) {
+				end = len(data)
+			}
+			if expectKey {
+				if !isKnownSourceKey(string(data[i+1 : end])) {
+					return true
+				}
+				expectKey = false
+			}
+			i = end
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,91 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestHitSource_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		want       HitSource
+		wantExtras []string
+	}{
+		{
+			name:  "known fields",
+			input: `{"title":"Inflation","uri":"/inflation","body":"CPI","content_type":"bulletin","date":"2024-01-15T00:00:00Z"}`,
+			want: HitSource{
+				Title:       "Inflation",
+				URI:         "/inflation",
+				Body:        "CPI",
+				ContentType: "bulletin",
+				Date:        "2024-01-15T00:00:00Z",
+			},
+		},
+		{
+			name:       "unknown fields kept as extras",
+			input:      `{"title":"GDP","topics":["economy"],"views":12}`,
+			want:       HitSource{Title: "GDP"},
+			wantExtras: []string{"topics", "views"},
+		},
+		{
+			name:  "non-string known field left empty",
+			input: `{"title":"GDP","date":20240115}`,
+			want:  HitSource{Title: "GDP"},
+		},
+		{
+			name:  "null source",
+			input: `null`,
+			want:  HitSource{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got HitSource
+			if err := json.Unmarshal([]byte(tt.input), &got); err != nil {
+				t.Fatalf("unmarshal failed: %v", err)
+			}
+
+			if got.Title != tt.want.Title || got.URI != tt.want.URI || got.Body != tt.want.Body ||
+				got.ContentType != tt.want.ContentType || got.Date != tt.want.Date {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+
+			if len(got.Extras) != len(tt.wantExtras) {
+				t.Fatalf("expected %d extras, got %d", len(tt.wantExtras), len(got.Extras))
+			}
+			for _, key := range tt.wantExtras {
+				if _, ok := got.Extras[key]; !ok {
+					t.Errorf("expected extra field %s", key)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSearchResponseDecode(b *testing.B) {
+	var sb strings.Builder
+	sb.WriteString(`{"hits":{"total":{"value":1000,"relation":"eq"},"hits":[`)
+	for i := 0; i < 1000; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `{"_index":"search_test","_id":"%d","_score":1.5,"_source":`+
+			`{"title":"Title %d","uri":"/doc-%d","body":"Some body text","content_type":"article","date":"2024-01-15T00:00:00Z"}}`,
+			i, i, i)
+	}
+	sb.WriteString(`]}}`)
+	data := []byte(sb.String())
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var resp SearchResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
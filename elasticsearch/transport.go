@@ -0,0 +1,214 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/ONSdigital/dis-search-test-bed/shared/redact"
+)
+
+// TransportConfig holds HTTP transport tuning options
+type TransportConfig struct {
+	CompressRequests           bool
+	DisableResponseCompression bool
+	MaxIdleConns               int
+	MaxIdleConnsPerHost        int
+	IdleConnTimeout            time.Duration
+	DisableKeepAlives          bool
+
+	// RequestTimeout bounds each attempt at a request, from sending it to
+	// reading the whole response; 0 leaves requests unbounded. DialTimeout
+	// bounds opening a connection.
+	RequestTimeout time.Duration
+	DialTimeout    time.Duration
+}
+
+// TLSConfig holds TLS options for HTTPS clusters
+type TLSConfig struct {
+	InsecureSkipVerify bool   // Don't verify the server certificate; for development only
+	CAFile             string // PEM bundle of extra CAs to trust as well as the system's
+	CertFile           string // PEM client certificate, for clusters requiring one
+	KeyFile            string // PEM key for CertFile
+}
+
+// enabled reports whether any TLS option is set
+func (c TLSConfig) enabled() bool {
+	return c.InsecureSkipVerify || c.CAFile != "" || c.CertFile != "" || c.KeyFile != ""
+}
+
+// build creates the tls.Config for the options
+func (c TLSConfig) build() (*tls.Config, error) {
+	// #nosec G402 - skipping verification is an explicit opt-in for development clusters
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: c.InsecureSkipVerify}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", c.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, fmt.Errorf("a client certificate needs both a cert file and a key file")
+		}
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsCfg, nil
+}
+
+// newTransport builds the HTTP transport used by the client. A custom
+// round tripper replaces the default transport, in which case the
+// connection pool, proxy and TLS settings are ignored but request
+// compression still applies.
+func newTransport(cfg Config) (http.RoundTripper, error) {
+	rt := cfg.RoundTripper
+	if rt == nil {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		if cfg.Proxy != "" {
+			proxy, err := url.Parse(cfg.Proxy)
+			if err != nil || proxy.Host == "" {
+				return nil, fmt.Errorf("invalid proxy URL %q", redact.URL(cfg.Proxy))
+			}
+			t.Proxy = http.ProxyURL(proxy)
+		}
+		if cfg.TLS.enabled() {
+			tlsCfg, err := cfg.TLS.build()
+			if err != nil {
+				return nil, err
+			}
+			t.TLSClientConfig = tlsCfg
+		}
+		if cfg.Transport.MaxIdleConns > 0 {
+			t.MaxIdleConns = cfg.Transport.MaxIdleConns
+		}
+		if cfg.Transport.MaxIdleConnsPerHost > 0 {
+			t.MaxIdleConnsPerHost = cfg.Transport.MaxIdleConnsPerHost
+		}
+		if cfg.Transport.IdleConnTimeout > 0 {
+			t.IdleConnTimeout = cfg.Transport.IdleConnTimeout
+		}
+		if cfg.Transport.DialTimeout > 0 {
+			t.DialContext = (&net.Dialer{
+				Timeout:   cfg.Transport.DialTimeout,
+				KeepAlive: 30 * time.Second,
+			}).DialContext
+		}
+		t.DisableKeepAlives = cfg.Transport.DisableKeepAlives
+		t.DisableCompression = cfg.Transport.DisableResponseCompression
+		rt = t
+	}
+
+	if cfg.Transport.CompressRequests {
+		rt = &gzipTransport{next: rt}
+	}
+	if cfg.Transport.RequestTimeout > 0 {
+		rt = &timeoutTransport{next: rt, timeout: cfg.Transport.RequestTimeout}
+	}
+
+	return rt, nil
+}
+
+// ErrAttemptTimeout is returned when one attempt at a request takes longer
+// than TransportConfig.RequestTimeout. Unlike the caller's context ending,
+// it is retried.
+var ErrAttemptTimeout = errors.New("request attempt timed out")
+
+// timeoutTransport gives up on requests that take longer than timeout
+type timeoutTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+}
+
+// RoundTrip sends the request with a deadline that lasts until the
+// response body is closed, so slow responses are cut off as well as slow
+// servers
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	res, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		if req.Context().Err() == nil && ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("request timed out after %s: %w", t.timeout, ErrAttemptTimeout)
+		}
+		return nil, err
+	}
+	res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// cancelOnClose releases a request's context once its response is read
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// gzipTransport compresses request bodies before handing them on
+type gzipTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip gzips the request body and sets Content-Encoding accordingly
+func (t *gzipTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Body == http.NoBody || req.Header.Get("Content-Encoding") != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read request body: %w", err)
+	}
+	if err := req.Body.Close(); err != nil {
+		return nil, fmt.Errorf("close request body: %w", err)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return nil, fmt.Errorf("compress request body: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("compress request body: %w", err)
+	}
+
+	compressed := buf.Bytes()
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(compressed))
+	out.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(compressed)), nil
+	}
+	out.ContentLength = int64(len(compressed))
+	out.Header.Set("Content-Encoding", "gzip")
+
+	return t.next.RoundTrip(out)
+}
@@ -0,0 +1,196 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewClient_TLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		tls     TLSConfig
+		wantErr bool
+	}{
+		{name: "unknown CA", wantErr: true},
+		{name: "CA bundle", tls: TLSConfig{CAFile: caFile}},
+		{name: "insecure", tls: TLSConfig{InsecureSkipVerify: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(Config{URL: server.URL, TLS: tt.tls})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			err = client.Ping(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ping() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewClient_TLSConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tlsCfg := range map[string]TLSConfig{
+		"missing CA file":  {CAFile: filepath.Join(dir, "missing.pem")},
+		"CA file not PEM":  {CAFile: notPEM},
+		"cert without key": {CertFile: notPEM},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewClient(Config{URL: "https://localhost:9200", TLS: tlsCfg}); err == nil {
+				t.Error("NewClient() expected an error")
+			}
+		})
+	}
+}
+
+func TestNewClient_Proxy(t *testing.T) {
+	var proxiedHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost = r.URL.Host
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer proxy.Close()
+
+	client, err := NewClient(Config{URL: "http://es.internal:9200", Proxy: proxy.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if proxiedHost != "es.internal:9200" {
+		t.Errorf("proxy saw host %q, want es.internal:9200", proxiedHost)
+	}
+
+	if _, err := NewClient(Config{URL: "http://es.internal:9200", Proxy: "::not a url"}); err == nil {
+		t.Error("NewClient() with a bad proxy URL expected an error")
+	}
+	_, err = NewClient(Config{URL: "http://es.internal:9200", Proxy: "http://bob:hunter2@"})
+	if err == nil || strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("NewClient() with a bad proxy URL = %v, want an error without the password", err)
+	}
+}
+
+func TestNewClient_Credentials(t *testing.T) {
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{name: "basic", cfg: Config{Username: "bob", Password: "hunter2"}, want: "Basic Ym9iOmh1bnRlcjI="},
+		{name: "API key wins", cfg: Config{Username: "bob", Password: "hunter2", APIKey: "a2V5"}, want: "APIKey a2V5"},
+		{name: "bearer token wins", cfg: Config{Username: "bob", Password: "hunter2", APIKey: "a2V5", BearerToken: "tok"}, want: "Bearer tok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.URL = server.URL
+			client, err := NewClient(tt.cfg)
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			if err := client.Ping(context.Background()); err != nil {
+				t.Fatalf("Ping() error = %v", err)
+			}
+			if auth != tt.want {
+				t.Errorf("Authorization = %q, want %q", auth, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewClient_RequestTimeout(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Path == "/slow/_search" {
+			// Read the body so the server notices the client hanging up
+			_, _ = io.Copy(io.Discard, r.Body)
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"hits":{"total":{"value":0},"hits":[]}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		URL:       server.URL,
+		Transport: TransportConfig{RequestTimeout: 50 * time.Millisecond},
+		Retry:     RetryConfig{MaxRetries: 2, InitialBackoff: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := client.Search(ctx, "fast", map[string]interface{}{}); err != nil {
+		t.Errorf("Search() of a fast index error = %v", err)
+	}
+
+	// A slow attempt is retried like any other transient failure
+	atomic.StoreInt32(&calls, 0)
+	start := time.Now()
+	_, err = client.Search(ctx, "slow", map[string]interface{}{})
+	if !errors.Is(err, ErrAttemptTimeout) || !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Errorf("Search() of a slow index error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Search() took %v despite the timeout", elapsed)
+	}
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("Search() made %d attempts, want 3: timed out attempts are retried", n)
+	}
+
+	// The caller's own deadline ends the request for good
+	atomic.StoreInt32(&calls, 0)
+	deadline, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.Search(deadline, "slow", map[string]interface{}{}); err == nil {
+		t.Error("Search() past the caller's deadline succeeded")
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Search() made %d attempts, want 1: the caller's deadline isn't retried", n)
+	}
+}
@@ -0,0 +1,85 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Validation is Elasticsearch's verdict on a query, from the _validate/query
+// API
+type Validation struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+
+	// Explanations hold the Lucene query each index would run, after
+	// multi-term queries such as wildcards are rewritten into the terms
+	// they match on one shard
+	Explanations []string `json:"explanations,omitempty"`
+}
+
+// ValidateQuery checks a search body's query clause against an index
+// without running it, explaining the rewritten Lucene query
+func (c *Client) ValidateQuery(ctx context.Context, index string, query map[string]interface{}) (*Validation, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	// _validate/query only accepts the query clause, not size, sort etc.
+	body := map[string]interface{}{}
+	if q, ok := query["query"]; ok {
+		body["query"] = q
+	}
+	if err := json.NewEncoder(buf).Encode(body); err != nil {
+		return nil, fmt.Errorf("encode query: %w", err)
+	}
+
+	res, err := c.es.Indices.ValidateQuery(
+		c.es.Indices.ValidateQuery.WithContext(ctx),
+		c.es.Indices.ValidateQuery.WithIndex(index),
+		c.es.Indices.ValidateQuery.WithBody(buf),
+		c.es.Indices.ValidateQuery.WithExplain(true),
+		c.es.Indices.ValidateQuery.WithRewrite(true),
+	)
+	if err != nil {
+		return nil, &Error{
+			Type:    ErrorTypeQuery,
+			Message: "validate query request failed",
+			Err:     err,
+		}
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return nil, &Error{
+			Type:    ErrorTypeQuery,
+			Message: fmt.Sprintf("validate query error: %s", string(body)),
+		}
+	}
+
+	var result struct {
+		Valid        bool   `json:"valid"`
+		Error        string `json:"error"`
+		Explanations []struct {
+			Valid       bool   `json:"valid"`
+			Explanation string `json:"explanation"`
+			Error       string `json:"error"`
+		} `json:"explanations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode validation: %w", err)
+	}
+
+	validation := &Validation{Valid: result.Valid, Error: result.Error}
+	for _, e := range result.Explanations {
+		if e.Explanation != "" {
+			validation.Explanations = append(validation.Explanations, e.Explanation)
+		}
+		if validation.Error == "" && e.Error != "" {
+			validation.Error = e.Error
+		}
+	}
+
+	return validation, nil
+}
@@ -0,0 +1,48 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_ValidateQuery(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/test/_validate/query" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("explain") != "true" || r.URL.Query().Get("rewrite") != "true" {
+			t.Errorf("unexpected query string %s", r.URL.RawQuery)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"valid":false,"explanations":[
+			{"index":"test","valid":false,"error":"no such field"}
+		]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{URL: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	validation, err := client.ValidateQuery(context.Background(), "test", map[string]interface{}{
+		"size":  20,
+		"query": map[string]interface{}{"match": map[string]interface{}{"title": "gdp"}},
+	})
+	if err != nil {
+		t.Fatalf("ValidateQuery() error = %v", err)
+	}
+	if _, ok := body["size"]; ok || body["query"] == nil {
+		t.Errorf("sent body %v, want the query clause only", body)
+	}
+	if validation.Valid || validation.Error != "no such field" {
+		t.Errorf("validation = %+v", validation)
+	}
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Analytics metrics
+const (
+	MetricPageViews = "page_views"
+	MetricClicks    = "clicks"
+)
+
+// PageStats holds the traffic recorded for one page
+type PageStats struct {
+	PageViews int64 `json:"page_views"`
+	Clicks    int64 `json:"clicks,omitempty"`
+}
+
+// Analytics holds page traffic imported from an analytics export, keyed by
+// URI path. It is stored alongside the index it was imported for.
+type Analytics struct {
+	ImportedAt time.Time            `json:"imported_at"`
+	Source     string               `json:"source"`
+	Pages      map[string]PageStats `json:"pages"`
+}
+
+// Traffic returns the value of a metric for a page, or 0 if the page has
+// no recorded traffic
+func (a *Analytics) Traffic(uri, metric string) int64 {
+	stats, ok := a.Pages[uri]
+	if !ok {
+		return 0
+	}
+	if metric == MetricClicks {
+		return stats.Clicks
+	}
+	return stats.PageViews
+}
+
+// Weights returns one metric as document weights for popularity boosting
+func (a *Analytics) Weights(metric string) (Weights, error) {
+	if metric != MetricPageViews && metric != MetricClicks {
+		return nil, fmt.Errorf("unknown analytics metric %q (expected %s or %s)",
+			metric, MetricPageViews, MetricClicks)
+	}
+
+	weights := make(Weights, len(a.Pages))
+	for uri := range a.Pages {
+		if v := a.Traffic(uri, metric); v > 0 {
+			weights[uri] = float64(v)
+		}
+	}
+
+	return weights, nil
+}
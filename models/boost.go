@@ -0,0 +1,88 @@
+package models
+
+import "fmt"
+
+// Boost presets
+const (
+	BoostRankFeature      = "rank_feature"
+	BoostFieldValueFactor = "field_value_factor"
+)
+
+// BoostConfig boosts an algorithm's queries by document weight using one
+// of the presets
+type BoostConfig struct {
+	Preset   string  `json:"preset"`             // rank_feature or field_value_factor
+	Boost    float64 `json:"boost,omitempty"`    // rank_feature clause boost, default 1
+	Factor   float64 `json:"factor,omitempty"`   // field_value_factor multiplier, default 1
+	Modifier string  `json:"modifier,omitempty"` // field_value_factor modifier, default log1p
+}
+
+// Validate checks the preset is known
+func (b *BoostConfig) Validate() error {
+	switch b.Preset {
+	case BoostRankFeature, BoostFieldValueFactor:
+		return nil
+	default:
+		return fmt.Errorf("unknown boost preset %q (expected %s or %s)",
+			b.Preset, BoostRankFeature, BoostFieldValueFactor)
+	}
+}
+
+// Wrap returns a copy of an es_query body whose query is boosted by
+// document weight. rank_feature adds a saturating weight clause to the
+// score; field_value_factor multiplies the score by the modified weight,
+// treating unweighted documents as weight 1. A body without a query
+// matches every document.
+func (b *BoostConfig) Wrap(esQuery map[string]interface{}) map[string]interface{} {
+	wrapped := make(map[string]interface{}, len(esQuery)+1)
+	for k, v := range esQuery {
+		wrapped[k] = v
+	}
+
+	inner, ok := esQuery["query"]
+	if !ok {
+		inner = map[string]interface{}{"match_all": map[string]interface{}{}}
+	}
+
+	switch b.Preset {
+	case BoostRankFeature:
+		boost := b.Boost
+		if boost == 0 {
+			boost = 1
+		}
+		wrapped["query"] = map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": inner,
+				"should": map[string]interface{}{
+					"rank_feature": map[string]interface{}{
+						"field": WeightFeatureField,
+						"boost": boost,
+					},
+				},
+			},
+		}
+	case BoostFieldValueFactor:
+		factor := b.Factor
+		if factor == 0 {
+			factor = 1
+		}
+		modifier := b.Modifier
+		if modifier == "" {
+			modifier = "log1p"
+		}
+		wrapped["query"] = map[string]interface{}{
+			"function_score": map[string]interface{}{
+				"query": inner,
+				"field_value_factor": map[string]interface{}{
+					"field":    WeightField,
+					"factor":   factor,
+					"modifier": modifier,
+					"missing":  1,
+				},
+				"boost_mode": "multiply",
+			},
+		}
+	}
+
+	return wrapped
+}
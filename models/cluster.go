@@ -0,0 +1,98 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ClusterSnapshot records the Elasticsearch cluster and index configuration
+// a run was executed against
+type ClusterSnapshot struct {
+	CapturedAt    time.Time      `json:"captured_at"`
+	ClusterName   string         `json:"cluster_name"`
+	ClusterUUID   string         `json:"cluster_uuid"`
+	Distribution  string         `json:"distribution,omitempty"` // elasticsearch or opensearch
+	Version       string         `json:"version"`
+	LuceneVersion string         `json:"lucene_version"`
+	Nodes         []NodeSnapshot `json:"nodes"`
+	Index         IndexSnapshot  `json:"index"`
+}
+
+// NodeSnapshot records a single node's version and roles
+type NodeSnapshot struct {
+	Name    string   `json:"name"`
+	Version string   `json:"version"`
+	Roles   []string `json:"roles"`
+}
+
+// IndexSnapshot records the settings of the index queried
+type IndexSnapshot struct {
+	Name             string `json:"name"`
+	UUID             string `json:"uuid"`
+	Health           string `json:"health"`
+	NumberOfShards   string `json:"number_of_shards"`
+	NumberOfReplicas string `json:"number_of_replicas"`
+	RefreshInterval  string `json:"refresh_interval"`
+	DocsCount        string `json:"docs_count"`
+}
+
+// Differences lists the settings that differ from another snapshot,
+// ignoring values that change on every run such as index UUIDs
+func (s ClusterSnapshot) Differences(other ClusterSnapshot) []string {
+	var diffs []string
+	check := func(name, current, previous string) {
+		if current != previous {
+			diffs = append(diffs, fmt.Sprintf("%s: %s → %s", name, orNone(previous), orNone(current)))
+		}
+	}
+
+	check("cluster", s.ClusterName, other.ClusterName)
+	check("distribution", s.distribution(), other.distribution())
+	check("version", s.Version, other.Version)
+	check("lucene version", s.LuceneVersion, other.LuceneVersion)
+	check("node count", fmt.Sprint(len(s.Nodes)), fmt.Sprint(len(other.Nodes)))
+	check("node versions", s.nodeVersions(), other.nodeVersions())
+	check("shards", s.Index.NumberOfShards, other.Index.NumberOfShards)
+	check("replicas", s.Index.NumberOfReplicas, other.Index.NumberOfReplicas)
+	check("refresh interval", s.Index.RefreshInterval, other.Index.RefreshInterval)
+	check("docs count", s.Index.DocsCount, other.Index.DocsCount)
+
+	return diffs
+}
+
+// nodeVersions summarises node versions, e.g. "7.10.0 x2, 7.17.1"
+func (s ClusterSnapshot) nodeVersions() string {
+	counts := make(map[string]int)
+	for _, n := range s.Nodes {
+		counts[n.Version]++
+	}
+
+	versions := make([]string, 0, len(counts))
+	for v, n := range counts {
+		if n > 1 {
+			v = fmt.Sprintf("%s x%d", v, n)
+		}
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+
+	return strings.Join(versions, ", ")
+}
+
+// distribution names the engine, counting snapshots from before it was
+// recorded as Elasticsearch
+func (s ClusterSnapshot) distribution() string {
+	if s.Distribution == "" && s.Version != "" {
+		return "elasticsearch"
+	}
+	return s.Distribution
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
@@ -0,0 +1,38 @@
+package models
+
+import "testing"
+
+func TestClusterSnapshot_Differences(t *testing.T) {
+	previous := ClusterSnapshot{
+		ClusterName: "test",
+		Version:     "7.10.2",
+		Nodes:       []NodeSnapshot{{Name: "a", Version: "7.10.2"}, {Name: "b", Version: "7.10.2"}},
+		Index:       IndexSnapshot{UUID: "u1", NumberOfShards: "1", NumberOfReplicas: "1", RefreshInterval: "1s"},
+	}
+
+	same := previous
+	same.Index.UUID = "u2"
+	if diffs := same.Differences(previous); len(diffs) != 0 {
+		t.Errorf("expected no differences, got %v", diffs)
+	}
+
+	changed := previous
+	changed.Version = "7.17.0"
+	changed.Nodes = []NodeSnapshot{{Name: "a", Version: "7.17.0"}, {Name: "b", Version: "7.10.2"}}
+	changed.Index.NumberOfShards = "3"
+
+	want := []string{
+		"version: 7.10.2 → 7.17.0",
+		"node versions: 7.10.2 x2 → 7.10.2, 7.17.0",
+		"shards: 1 → 3",
+	}
+	got := changed.Differences(previous)
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("difference %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
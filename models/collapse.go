@@ -0,0 +1,59 @@
+package models
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// GroupInnerHits names the inner hits a collapsed query asks for, so the
+// executor can count each group's documents
+const GroupInnerHits = "group"
+
+// CollapseConfig collapses a query's hits to the top document of each
+// group sharing a field value, e.g. the editions of a dataset series
+type CollapseConfig struct {
+	Field     string `json:"field"`                // Keyword field to group by, e.g. "series_id"
+	GroupSize int    `json:"group_size,omitempty"` // Documents fetched per group to count it; 0 skips counting
+}
+
+// Apply adds the collapse to a query's Elasticsearch body. A body that
+// already collapses on the same field is left as it is.
+func (c *CollapseConfig) Apply(esQuery map[string]interface{}) (map[string]interface{}, error) {
+	if c.Field == "" {
+		return nil, fmt.Errorf("collapse has no field")
+	}
+	collapse := map[string]interface{}{"field": c.Field}
+	if c.GroupSize > 0 {
+		collapse["inner_hits"] = map[string]interface{}{"name": GroupInnerHits, "size": c.GroupSize}
+	}
+
+	if existing, ok := esQuery["collapse"].(map[string]interface{}); ok {
+		if existing["field"] != c.Field {
+			return nil, fmt.Errorf("collapse field %s conflicts with the es_query's collapse on %v", c.Field, existing["field"])
+		}
+		if c.GroupSize == 0 || reflect.DeepEqual(existing, collapse) {
+			return esQuery, nil
+		}
+	}
+
+	applied := make(map[string]interface{}, len(esQuery)+1)
+	for k, v := range esQuery {
+		applied[k] = v
+	}
+	applied["collapse"] = collapse
+	return applied, nil
+}
+
+// CollapseField returns the field the query's hits are collapsed on, set
+// either by Collapse or by a collapse in the es_query itself, or ""
+func (q QueryConfig) CollapseField() string {
+	if q.Collapse != nil {
+		return q.Collapse.Field
+	}
+	if collapse, ok := q.ESQuery["collapse"].(map[string]interface{}); ok {
+		if field, ok := collapse["field"].(string); ok {
+			return field
+		}
+	}
+	return ""
+}
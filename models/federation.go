@@ -0,0 +1,81 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Federation merge strategies
+const (
+	FederationScore      = "score"      // Raw scores, highest first
+	FederationWeighted   = "weighted"   // Scores multiplied by each index's weight
+	FederationInterleave = "interleave" // Each index's next hit in turn, in index order
+	FederationRRF        = "rrf"        // Reciprocal rank fusion, weighted per index
+)
+
+// IndexPlaceholder stands for the configured test index in federated index
+// names, e.g. "{index}_topics"
+const IndexPlaceholder = "{index}"
+
+// FederationConfig fans each of an algorithm's queries out across several
+// indices, as the production API does for content, topics and time series,
+// and merges the hits into one ranked list
+type FederationConfig struct {
+	Indices  []FederatedIndex `json:"indices"`
+	Strategy string           `json:"strategy,omitempty"` // score (default), weighted, interleave or rrf
+}
+
+// FederatedIndex is one index a federated query is sent to
+type FederatedIndex struct {
+	Index  string  `json:"index"`            // Index name; {index} is replaced by the test index
+	Weight float64 `json:"weight,omitempty"` // Multiplier for the weighted and rrf strategies, default 1
+}
+
+// Validate checks the strategy is known and every index is named
+func (f *FederationConfig) Validate() error {
+	switch f.Strategy {
+	case "", FederationScore, FederationWeighted, FederationInterleave, FederationRRF:
+	default:
+		return fmt.Errorf("unknown federation strategy %q (expected %s, %s, %s or %s)",
+			f.Strategy, FederationScore, FederationWeighted, FederationInterleave, FederationRRF)
+	}
+	if len(f.Indices) < 2 {
+		return fmt.Errorf("federation needs at least two indices")
+	}
+	seen := make(map[string]bool, len(f.Indices))
+	for _, idx := range f.Indices {
+		if idx.Index == "" {
+			return fmt.Errorf("federated index has no name")
+		}
+		if idx.Weight < 0 {
+			return fmt.Errorf("federated index %s has a negative weight", idx.Index)
+		}
+		if seen[idx.Index] {
+			return fmt.Errorf("federated index %s is listed twice", idx.Index)
+		}
+		seen[idx.Index] = true
+	}
+	return nil
+}
+
+// IndexNames returns the indices to query, with the placeholder replaced
+// by index
+func (f *FederationConfig) IndexNames(index string) []string {
+	names := make([]string, len(f.Indices))
+	for i, idx := range f.Indices {
+		names[i] = strings.ReplaceAll(idx.Index, IndexPlaceholder, index)
+	}
+	return names
+}
+
+// Weights returns each index's weight, defaulting to 1
+func (f *FederationConfig) Weights() []float64 {
+	weights := make([]float64, len(f.Indices))
+	for i, idx := range f.Indices {
+		weights[i] = idx.Weight
+		if weights[i] == 0 {
+			weights[i] = 1
+		}
+	}
+	return weights
+}
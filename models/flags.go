@@ -0,0 +1,89 @@
+package models
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// EditorialFlags maps content URIs to the flags editors have set on them,
+// such as withdrawn, superseded or national-statistic
+type EditorialFlags map[string][]string
+
+// LoadEditorialFlags loads flags from a JSON file of the form
+// {"/uri": ["withdrawn"]} or a CSV file of uri,flag rows with an optional
+// header row, where a URI may appear on several rows. Flags are lowercased
+// and each URI's flags are sorted.
+func LoadEditorialFlags(path string) (EditorialFlags, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read flags file: %w", err)
+	}
+	defer f.Close()
+
+	var raw map[string][]string
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		raw, err = parseFlagsCSV(f)
+	} else {
+		err = json.NewDecoder(f).Decode(&raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse flags: %w", err)
+	}
+
+	flags := make(EditorialFlags, len(raw))
+	for uri, values := range raw {
+		uri = strings.TrimSpace(uri)
+		if uri == "" {
+			return nil, fmt.Errorf("flags file %s has an entry without a URI", path)
+		}
+		for _, v := range values {
+			flags.add(uri, v)
+		}
+	}
+	if len(flags) == 0 {
+		return nil, fmt.Errorf("flags file %s has no flagged URIs", path)
+	}
+	return flags, nil
+}
+
+func parseFlagsCSV(r io.Reader) (map[string][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string][]string)
+	for i, row := range rows {
+		if i == 0 && strings.EqualFold(strings.TrimSpace(row[0]), "uri") {
+			continue // header
+		}
+		raw[row[0]] = append(raw[row[0]], row[1])
+	}
+	return raw, nil
+}
+
+// add sets a flag on a URI once, keeping the URI's flags sorted
+func (f EditorialFlags) add(uri, flag string) {
+	flag = strings.ToLower(strings.TrimSpace(flag))
+	if flag == "" {
+		return
+	}
+	for _, existing := range f[uri] {
+		if existing == flag {
+			return
+		}
+	}
+	f[uri] = append(f[uri], flag)
+	sort.Strings(f[uri])
+}
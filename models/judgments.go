@@ -0,0 +1,120 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Judgments holds graded relevance judgments, keyed by query (ID or text)
+// and then by result URI. Grades are non-negative, higher is more relevant.
+type Judgments map[string]map[string]int
+
+// LoadJudgments loads judgments from a JSON file of the form
+// {"query": {"/uri": grade}}, or from a TREC-style qrels file with one
+// "query iteration uri grade" line per judgment
+func LoadJudgments(path string) (Judgments, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read judgments file: %w", err)
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] != '{' {
+		return parseQrels(data)
+	}
+
+	var judgments Judgments
+	if err := json.Unmarshal(data, &judgments); err != nil {
+		return nil, fmt.Errorf("parse judgments: %w", err)
+	}
+
+	return judgments, nil
+}
+
+// parseQrels parses TREC qrels lines: query, iteration (ignored), URI and
+// grade, separated by whitespace. The iteration column may be left out.
+// Blank lines and lines starting with # are skipped.
+func parseQrels(data []byte) (Judgments, error) {
+	judgments := make(Judgments)
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 3 && len(fields) != 4 {
+			return nil, fmt.Errorf("parse qrels line %d: want query, iteration, uri and grade, got %q", n+1, line)
+		}
+		query, uri := fields[0], fields[len(fields)-2]
+		grade, err := strconv.Atoi(fields[len(fields)-1])
+		if err != nil || grade < 0 {
+			return nil, fmt.Errorf("parse qrels line %d: grade %q is not a non-negative integer", n+1, fields[len(fields)-1])
+		}
+
+		if judgments[query] == nil {
+			judgments[query] = make(map[string]int)
+		}
+		judgments[query][uri] = grade
+	}
+
+	if len(judgments) == 0 {
+		return nil, fmt.Errorf("parse qrels: no judgments found")
+	}
+	return judgments, nil
+}
+
+// ForQuery returns the judgments for a query, looked up by its stable ID
+// first and then by its text, ignoring case if there is no exact match
+func (j Judgments) ForQuery(id, query string) map[string]int {
+	if grades, ok := j[id]; ok && id != "" {
+		return grades
+	}
+	if grades, ok := j[query]; ok {
+		return grades
+	}
+	for q, grades := range j {
+		if strings.EqualFold(q, query) {
+			return grades
+		}
+	}
+	return nil
+}
+
+// MaxGrade returns the highest grade used across all judgments
+func (j Judgments) MaxGrade() int {
+	maxGrade := 0
+	for _, grades := range j {
+		for _, g := range grades {
+			if g > maxGrade {
+				maxGrade = g
+			}
+		}
+	}
+	return maxGrade
+}
+
+// UnknownURIs returns, for each query, the judged URIs that are not in the
+// stored index, sorted. Judgments the index can't contain usually mean a
+// typo or a page that has moved, and silently count as missed results.
+func (j Judgments) UnknownURIs(index *StoredIndex) map[string][]string {
+	known := make(map[string]bool, len(index.Documents))
+	for _, doc := range index.Documents {
+		known[doc.URI] = true
+	}
+
+	unknown := make(map[string][]string)
+	for query, grades := range j {
+		for uri := range grades {
+			if !known[uri] {
+				unknown[query] = append(unknown[query], uri)
+			}
+		}
+		sort.Strings(unknown[query])
+	}
+	return unknown
+}
@@ -4,17 +4,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
 // Document represents a searchable document
 type Document struct {
-	ID          string `json:"id"`
-	Title       string `json:"title"`
-	URI         string `json:"uri"`
-	Body        string `json:"body"`
-	ContentType string `json:"content_type"`
-	Date        string `json:"date"`
+	ID          string  `json:"id"`
+	Title       string  `json:"title"`
+	URI         string  `json:"uri"`
+	Body        string  `json:"body"`
+	ContentType string  `json:"content_type"`
+	Date        string  `json:"date"`
+	Weight      float64 `json:"weight,omitempty"` // Merged from a weights file at load time
 }
 
 // StoredIndex represents a snapshot of an index
@@ -27,36 +30,125 @@ type StoredIndex struct {
 
 // QueryConfig defines a single query
 type QueryConfig struct {
+	ID          string                 `json:"id,omitempty"`
 	Query       string                 `json:"query"`
+	Aliases     []string               `json:"aliases,omitempty"` // Earlier query texts or IDs, for matching old runs
 	Description string                 `json:"description"`
+	Volatile    bool                   `json:"volatile,omitempty"` // Results expected to churn, e.g. sorted by date; kept out of regression checks
 	ESQuery     map[string]interface{} `json:"es_query"`
+	Collapse    *CollapseConfig        `json:"collapse,omitempty"` // Group hits by a field, one result per group
 }
 
 // AlgorithmConfig defines an algorithm with multiple queries
 type AlgorithmConfig struct {
-	Name        string        `json:"name"`
-	Description string        `json:"description"`
-	Queries     []QueryConfig `json:"queries"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Queries     []QueryConfig     `json:"queries"`
+	Script      *ScriptConfig     `json:"script,omitempty"`
+	Boost       *BoostConfig      `json:"boost,omitempty"`
+	Federation  *FederationConfig `json:"federation,omitempty"`
 }
 
 // SearchResult represents a single search result
 type SearchResult struct {
-	Rank        int     `json:"rank"`
-	Title       string  `json:"title"`
-	URI         string  `json:"uri"`
-	Date        string  `json:"date"`
-	ContentType string  `json:"content_type"`
-	Algorithm   string  `json:"algorithm"`
-	Score       float64 `json:"score"`
+	ID          string   `json:"id,omitempty"` // Elasticsearch document ID
+	Rank        int      `json:"rank"`
+	Title       string   `json:"title"`
+	URI         string   `json:"uri"`
+	Date        string   `json:"date"`
+	ContentType string   `json:"content_type"`
+	Algorithm   string   `json:"algorithm"`
+	Score       float64  `json:"score"`
+	Index       string   `json:"index,omitempty"`      // Index the hit came from, for federated queries
+	Group       string   `json:"group,omitempty"`      // Collapse key of the group this result stands for
+	GroupSize   int      `json:"group_size,omitempty"` // Documents in the group, when counted
+	Flags       []string `json:"flags,omitempty"`      // Editorial flags merged in at report time, e.g. withdrawn
 }
 
 // QueryResults represents results for a query
 type QueryResults struct {
+	QueryID     string         `json:"query_id,omitempty"`
 	Query       string         `json:"query"`
+	Aliases     []string       `json:"aliases,omitempty"`
 	Algorithm   string         `json:"algorithm"`
+	Corpus      string         `json:"corpus,omitempty"` // Named corpus the suite ran against, if any
 	Description string         `json:"description,omitempty"`
+	Volatile    bool           `json:"volatile,omitempty"`
 	RunAt       time.Time      `json:"run_at"`
+	TookMs      int            `json:"took_ms,omitempty"`
+	RequestID   string         `json:"request_id,omitempty"` // X-Opaque-Id sent with the search
 	Results     []SearchResult `json:"results"`
+
+	// RequestIDs replaces RequestID for a federated query, giving the
+	// X-Opaque-Id of the search sent to each index
+	RequestIDs []string `json:"request_ids,omitempty"`
+}
+
+// StableID returns the query's explicit ID, or a slug derived from its
+// text when no ID is configured
+func (q QueryConfig) StableID() string {
+	if q.ID != "" {
+		return q.ID
+	}
+	return Slugify(q.Query)
+}
+
+// AlgorithmLabel names the algorithm the results came from, followed by
+// "@corpus" when the suite ran against a named corpus
+func (qr QueryResults) AlgorithmLabel() string {
+	if qr.Corpus == "" {
+		return qr.Algorithm
+	}
+	return qr.Algorithm + "@" + qr.Corpus
+}
+
+// Key identifies the query across runs by algorithm, corpus and stable ID.
+// Results written before IDs existed fall back to a slug of the query text.
+func (qr QueryResults) Key() string {
+	id := qr.QueryID
+	if id == "" {
+		id = Slugify(qr.Query)
+	}
+	return qr.AlgorithmLabel() + "/" + id
+}
+
+// CandidateKeys returns the keys under which this query may appear in an
+// earlier run: its own key first, then one per alias. An alias can be an
+// earlier ID or an earlier query text.
+func (qr QueryResults) CandidateKeys() []string {
+	keys := []string{qr.Key()}
+	for _, alias := range qr.Aliases {
+		keys = append(keys, qr.AlgorithmLabel()+"/"+alias)
+		if slug := Slugify(alias); slug != alias {
+			keys = append(keys, qr.AlgorithmLabel()+"/"+slug)
+		}
+	}
+	return keys
+}
+
+// FindPrevious looks up this query's results in an earlier run, keyed by
+// Key, trying its aliases if the query isn't found under its current key
+func (qr QueryResults) FindPrevious(previousByKey map[string]QueryResults) (QueryResults, bool) {
+	for _, key := range qr.CandidateKeys() {
+		if prev, ok := previousByKey[key]; ok {
+			return prev, true
+		}
+	}
+	return QueryResults{}, false
+}
+
+// Matches reports whether text names this query, by its ID, its query
+// text or one of its aliases, ignoring case
+func (qr QueryResults) Matches(text string) bool {
+	if (qr.QueryID != "" && strings.EqualFold(text, qr.QueryID)) || strings.EqualFold(text, qr.Query) {
+		return true
+	}
+	for _, alias := range qr.Aliases {
+		if strings.EqualFold(text, alias) {
+			return true
+		}
+	}
+	return false
 }
 
 // ComparisonStats holds statistics for comparison
@@ -70,6 +162,74 @@ type ComparisonStats struct {
 	WorsedCount    int     `json:"worsed_count"`
 	UnchangedCount int     `json:"unchanged_count"`
 	AvgRankChange  float64 `json:"avg_rank_change"`
+	Overlap        float64 `json:"overlap"` // Share of the previous results still returned; 1 when there were none
+	RBO            float64 `json:"rbo"`     // Rank-biased overlap with the previous results
+
+	// Rank correlation over the results in both lists; nil when fewer than
+	// two results are shared
+	KendallTau *float64 `json:"kendall_tau,omitempty"`
+	Spearman   *float64 `json:"spearman,omitempty"`
+
+	// Results in both lists by how many places they moved
+	Movement MovementHistogram `json:"movement"`
+
+	// New results by the band of ranks they entered, and their count
+	// weighted by band, so a new top result outweighs one on page two
+	NewByBand []NewBandCount `json:"new_by_band,omitempty"`
+	NewImpact float64        `json:"new_impact"`
+}
+
+// NewBandCount is how many new results entered a band of ranks
+type NewBandCount struct {
+	Band    string `json:"band"`     // e.g. "1-3" or "11+"
+	MaxRank int    `json:"max_rank"` // 0 for a band with no end
+	Count   int    `json:"count"`
+}
+
+// MovementHistogram counts the results in both runs that moved by how many
+// places they moved, showing the shape behind an average rank change
+type MovementHistogram struct {
+	One       int `json:"1"`
+	TwoThree  int `json:"2-3"`
+	FourToTen int `json:"4-10"`
+	OverTen   int `json:"over_10"`
+}
+
+// Add counts a result that moved by change places, in either direction
+func (h *MovementHistogram) Add(change int) {
+	if change < 0 {
+		change = -change
+	}
+	switch {
+	case change == 0:
+	case change == 1:
+		h.One++
+	case change <= 3:
+		h.TwoThree++
+	case change <= 10:
+		h.FourToTen++
+	default:
+		h.OverTen++
+	}
+}
+
+// Merge adds another histogram's counts
+func (h *MovementHistogram) Merge(other MovementHistogram) {
+	h.One += other.One
+	h.TwoThree += other.TwoThree
+	h.FourToTen += other.FourToTen
+	h.OverTen += other.OverTen
+}
+
+// Total is the number of results that moved
+func (h MovementHistogram) Total() int {
+	return h.One + h.TwoThree + h.FourToTen + h.OverTen
+}
+
+// String summarises the counts in each movement band
+func (h MovementHistogram) String() string {
+	return fmt.Sprintf("1 place: %d | 2-3 places: %d | 4-10 places: %d | over 10: %d",
+		h.One, h.TwoThree, h.FourToTen, h.OverTen)
 }
 
 // LoadAlgorithms loads algorithm configurations from a file
@@ -84,5 +244,49 @@ func LoadAlgorithms(path string) ([]AlgorithmConfig, error) {
 		return nil, fmt.Errorf("parse queries: %w", err)
 	}
 
+	// Assign stable IDs and make sure they're unique within each algorithm
+	for a := range algorithms {
+		seen := make(map[string]string, len(algorithms[a].Queries))
+		for q := range algorithms[a].Queries {
+			qc := &algorithms[a].Queries[q]
+			qc.ID = qc.StableID()
+			if other, dup := seen[qc.ID]; dup {
+				return nil, fmt.Errorf("algorithm %s: queries %q and %q share id %q",
+					algorithms[a].Name, other, qc.Query, qc.ID)
+			}
+			seen[qc.ID] = qc.Query
+
+			if qc.Collapse != nil {
+				esQuery, err := qc.Collapse.Apply(qc.ESQuery)
+				if err != nil {
+					return nil, fmt.Errorf("algorithm %s, query %s: %w", algorithms[a].Name, qc.ID, err)
+				}
+				qc.ESQuery = esQuery
+			}
+		}
+
+		if boost := algorithms[a].Boost; boost != nil {
+			if err := boost.Validate(); err != nil {
+				return nil, fmt.Errorf("algorithm %s: %w", algorithms[a].Name, err)
+			}
+			for q := range algorithms[a].Queries {
+				qc := &algorithms[a].Queries[q]
+				qc.ESQuery = boost.Wrap(qc.ESQuery)
+			}
+		}
+
+		if federation := algorithms[a].Federation; federation != nil {
+			if err := federation.Validate(); err != nil {
+				return nil, fmt.Errorf("algorithm %s: %w", algorithms[a].Name, err)
+			}
+		}
+
+		if algorithms[a].Script != nil {
+			if err := algorithms[a].loadScript(filepath.Dir(path)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	return algorithms, nil
 }
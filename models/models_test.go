@@ -0,0 +1,584 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestQueryResults_Key(t *testing.T) {
+	tests := []struct {
+		name string
+		qr   QueryResults
+		want string
+	}{
+		{
+			name: "explicit id",
+			qr:   QueryResults{QueryID: "rpi", Query: "retail prices index", Algorithm: "bm25"},
+			want: "bm25/rpi",
+		},
+		{
+			name: "derived from query text",
+			qr:   QueryResults{Query: "Retail Prices  Index!", Algorithm: "bm25"},
+			want: "bm25/retail-prices-index",
+		},
+		{
+			name: "tagged by corpus",
+			qr:   QueryResults{QueryID: "rpi", Query: "retail prices index", Algorithm: "bm25", Corpus: "small"},
+			want: "bm25@small/rpi",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.qr.Key(); got != tt.want {
+				t.Errorf("Key() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryResults_FindPrevious(t *testing.T) {
+	previous := map[string]QueryResults{
+		"bm25/retail-price-index": {Query: "retail price index", Algorithm: "bm25"},
+		"bm25/cpi":                {QueryID: "cpi", Query: "consumer prices", Algorithm: "bm25"},
+	}
+
+	tests := []struct {
+		name      string
+		qr        QueryResults
+		wantQuery string
+		wantFound bool
+	}{
+		{
+			name:      "alias of old query text",
+			qr:        QueryResults{Query: "retail prices index", Algorithm: "bm25", Aliases: []string{"retail price index"}},
+			wantQuery: "retail price index",
+			wantFound: true,
+		},
+		{
+			name:      "alias of old id",
+			qr:        QueryResults{QueryID: "cpih", Query: "consumer prices", Algorithm: "bm25", Aliases: []string{"cpi"}},
+			wantQuery: "consumer prices",
+			wantFound: true,
+		},
+		{
+			name: "no match",
+			qr:   QueryResults{Query: "retail prices index", Algorithm: "bm25"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := tt.qr.FindPrevious(previous)
+			if found != tt.wantFound {
+				t.Fatalf("FindPrevious() found = %v, want %v", found, tt.wantFound)
+			}
+			if got.Query != tt.wantQuery {
+				t.Errorf("FindPrevious() query = %v, want %v", got.Query, tt.wantQuery)
+			}
+		})
+	}
+}
+
+func TestQueryResults_Matches(t *testing.T) {
+	qr := QueryResults{QueryID: "gdp", Query: "gross domestic product", Aliases: []string{"GDP growth"}}
+
+	tests := map[string]bool{
+		"gdp":                    true,
+		"GDP":                    true,
+		"Gross Domestic Product": true,
+		"gdp growth":             true,
+		"gross domestic":         false,
+		"":                       false,
+	}
+
+	for text, want := range tests {
+		if got := qr.Matches(text); got != want {
+			t.Errorf("Matches(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestLoadAlgorithms_Script(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "scripts"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	source := "_score * params.boost"
+	if err := os.WriteFile(filepath.Join(dir, "scripts", "boost.painless"), []byte(source), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	queries := `[{
+		"name": "Boosted BM25",
+		"script": {"file": "scripts/boost.painless", "params": {"boost": 2}},
+		"queries": [
+			{"query": "inflation", "es_query": {"query": {"match": {"body": "inflation"}}, "size": 10}},
+			{"query": "everything", "es_query": {"size": 5}}
+		]
+	}]`
+	path := filepath.Join(dir, "queries.json")
+	if err := os.WriteFile(path, []byte(queries), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	algorithms, err := LoadAlgorithms(path)
+	if err != nil {
+		t.Fatalf("LoadAlgorithms() error = %v", err)
+	}
+
+	alg := algorithms[0]
+	if alg.Script.Source != source {
+		t.Errorf("expected script source to be loaded, got %q", alg.Script.Source)
+	}
+	if alg.Script.ID != "testbed-boosted-bm25" {
+		t.Errorf("expected default script ID, got %q", alg.Script.ID)
+	}
+	if _, wrapped := alg.Queries[0].ESQuery["query"].(map[string]interface{})["script_score"]; wrapped {
+		t.Error("queries should not be wrapped until the script is applied")
+	}
+
+	alg.ApplyScript()
+
+	for _, qc := range alg.Queries {
+		scriptScore, ok := qc.ESQuery["query"].(map[string]interface{})["script_score"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("query %q not wrapped in script_score: %v", qc.Query, qc.ESQuery)
+		}
+		script := scriptScore["script"].(map[string]interface{})
+		if script["id"] != "testbed-boosted-bm25" || script["params"] == nil {
+			t.Errorf("unexpected script reference: %v", script)
+		}
+	}
+	if alg.Queries[0].ESQuery["size"] != float64(10) {
+		t.Errorf("expected other body fields to be kept, got %v", alg.Queries[0].ESQuery)
+	}
+	inner := alg.Queries[1].ESQuery["query"].(map[string]interface{})["script_score"].(map[string]interface{})["query"]
+	if _, ok := inner.(map[string]interface{})["match_all"]; !ok {
+		t.Errorf("expected match_all for a body without a query, got %v", inner)
+	}
+
+	record := alg.Script.Record(alg.Name)
+	if record.Source != source || len(record.SHA256) != 64 {
+		t.Errorf("unexpected script record: %+v", record)
+	}
+}
+
+func TestLoadAlgorithms_MissingScript(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "queries.json")
+	queries := `[{"name": "scripted", "script": {"file": "missing.painless"}, "queries": []}]`
+	if err := os.WriteFile(path, []byte(queries), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadAlgorithms(path); err == nil {
+		t.Error("expected an error for a missing script file")
+	}
+}
+
+func TestLoadWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    Weights
+		wantErr bool
+	}{
+		{
+			name:    "json",
+			file:    "weights.json",
+			content: `{"/economy": 1500, "doc-2": 20}`,
+			want:    Weights{"/economy": 1500, "doc-2": 20},
+		},
+		{
+			name:    "csv with header",
+			file:    "weights.csv",
+			content: "uri,pageviews\n/economy,1500\ndoc-2, 20\n",
+			want:    Weights{"/economy": 1500, "doc-2": 20},
+		},
+		{
+			name:    "csv with bad weight",
+			file:    "weights.csv",
+			content: "/economy,1500\n/people,many\n",
+			wantErr: true,
+		},
+		{
+			name:    "negative weight",
+			file:    "weights.json",
+			content: `{"/economy": -1}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := LoadWeights(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadWeights() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("LoadWeights() = %v, want %v", got, tt.want)
+			}
+			for k, w := range tt.want {
+				if got[k] != w {
+					t.Errorf("weight for %s = %v, want %v", k, got[k], w)
+				}
+			}
+		})
+	}
+}
+
+func TestWeights_Apply(t *testing.T) {
+	docs := []Document{
+		{ID: "doc-1", URI: "/economy"},
+		{ID: "doc-2", URI: "/people"},
+		{ID: "doc-3", URI: "/business"},
+	}
+	weights := Weights{"/economy": 1500, "doc-2": 20, "/missing": 5}
+
+	if matched := weights.Apply(docs); matched != 2 {
+		t.Errorf("Apply() matched %d documents, want 2", matched)
+	}
+	if docs[0].Weight != 1500 || docs[1].Weight != 20 || docs[2].Weight != 0 {
+		t.Errorf("unexpected weights: %v, %v, %v", docs[0].Weight, docs[1].Weight, docs[2].Weight)
+	}
+}
+
+func TestBoostConfig_Wrap(t *testing.T) {
+	esQuery := map[string]interface{}{
+		"query": map[string]interface{}{"match": map[string]interface{}{"body": "inflation"}},
+		"size":  10,
+	}
+
+	tests := []struct {
+		name  string
+		boost BoostConfig
+		outer string
+		path  []string // keys from the outer query to the weight clause
+		field string
+	}{
+		{
+			name:  "rank feature",
+			boost: BoostConfig{Preset: BoostRankFeature},
+			outer: "bool",
+			path:  []string{"should", "rank_feature"},
+			field: WeightFeatureField,
+		},
+		{
+			name:  "field value factor",
+			boost: BoostConfig{Preset: BoostFieldValueFactor},
+			outer: "function_score",
+			path:  []string{"field_value_factor"},
+			field: WeightField,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.boost.Validate(); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+
+			wrapped := tt.boost.Wrap(esQuery)
+			if wrapped["size"] != 10 {
+				t.Errorf("expected other body fields to be kept, got %v", wrapped)
+			}
+			outer, ok := wrapped["query"].(map[string]interface{})[tt.outer].(map[string]interface{})
+			if !ok {
+				t.Fatalf("expected a %s query, got %v", tt.outer, wrapped["query"])
+			}
+			clause := outer
+			for _, key := range tt.path {
+				clause, _ = clause[key].(map[string]interface{})
+			}
+			if clause["field"] != tt.field {
+				t.Errorf("expected the boost to use %s, got %v", tt.field, outer)
+			}
+		})
+	}
+
+	if err := (&BoostConfig{Preset: "popularity"}).Validate(); err == nil {
+		t.Error("expected an unknown preset to be rejected")
+	}
+}
+
+func TestFederationConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		federation FederationConfig
+		wantErr    bool
+	}{
+		{name: "default strategy", federation: FederationConfig{
+			Indices: []FederatedIndex{{Index: "{index}"}, {Index: "{index}_topics", Weight: 0.5}}}},
+		{name: "unknown strategy", wantErr: true, federation: FederationConfig{Strategy: "best",
+			Indices: []FederatedIndex{{Index: "a"}, {Index: "b"}}}},
+		{name: "one index", wantErr: true, federation: FederationConfig{
+			Indices: []FederatedIndex{{Index: "a"}}}},
+		{name: "listed twice", wantErr: true, federation: FederationConfig{
+			Indices: []FederatedIndex{{Index: "a"}, {Index: "a"}}}},
+		{name: "negative weight", wantErr: true, federation: FederationConfig{
+			Indices: []FederatedIndex{{Index: "a"}, {Index: "b", Weight: -1}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.federation.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	f := FederationConfig{Indices: []FederatedIndex{{Index: "{index}"}, {Index: "{index}_topics", Weight: 0.5}}}
+	if got := f.IndexNames("ons"); !reflect.DeepEqual(got, []string{"ons", "ons_topics"}) {
+		t.Errorf("IndexNames() = %v", got)
+	}
+	if got := f.Weights(); !reflect.DeepEqual(got, []float64{1, 0.5}) {
+		t.Errorf("Weights() = %v", got)
+	}
+}
+
+func TestCollapseConfig_Apply(t *testing.T) {
+	esQuery := map[string]interface{}{"query": map[string]interface{}{"match_all": map[string]interface{}{}}}
+
+	c := CollapseConfig{Field: "series_id", GroupSize: 3}
+	applied, err := c.Apply(esQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"field":      "series_id",
+		"inner_hits": map[string]interface{}{"name": GroupInnerHits, "size": 3},
+	}
+	if !reflect.DeepEqual(applied["collapse"], want) {
+		t.Errorf("collapse = %v, want %v", applied["collapse"], want)
+	}
+	if _, ok := esQuery["collapse"]; ok {
+		t.Error("Apply() changed the original query")
+	}
+	if field := (QueryConfig{ESQuery: applied}).CollapseField(); field != "series_id" {
+		t.Errorf("CollapseField() = %q, want the es_query's collapse field", field)
+	}
+
+	conflicting := map[string]interface{}{"collapse": map[string]interface{}{"field": "topic"}}
+	if _, err := c.Apply(conflicting); err == nil {
+		t.Error("Apply() accepted a conflicting collapse field")
+	}
+	if _, err := (&CollapseConfig{}).Apply(esQuery); err == nil {
+		t.Error("Apply() accepted a collapse without a field")
+	}
+}
+
+func TestJudgments_ForQuery(t *testing.T) {
+	judgments := Judgments{
+		"cpi-id":    {"/cpi": 3},
+		"inflation": {"/inflation": 2},
+	}
+
+	tests := []struct {
+		name  string
+		id    string
+		query string
+		want  string
+	}{
+		{name: "by id", id: "cpi-id", query: "consumer prices", want: "/cpi"},
+		{name: "by text", id: "other", query: "inflation", want: "/inflation"},
+		{name: "by text ignoring case", query: "Inflation", want: "/inflation"},
+		{name: "no judgments", query: "gdp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grades := judgments.ForQuery(tt.id, tt.query)
+			if tt.want == "" {
+				if grades != nil {
+					t.Errorf("expected no judgments, got %v", grades)
+				}
+				return
+			}
+			if _, ok := grades[tt.want]; !ok {
+				t.Errorf("expected judgments for %s, got %v", tt.want, grades)
+			}
+		})
+	}
+}
+
+func TestLoadJudgments_Qrels(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    Judgments
+		wantErr bool
+	}{
+		{
+			name:    "trec qrels",
+			content: "# query iter uri grade\ncpi 0 /cpi 3\ncpi 0 /inflation 1\n\ngdp 0 /gdp 2\n",
+			want:    Judgments{"cpi": {"/cpi": 3, "/inflation": 1}, "gdp": {"/gdp": 2}},
+		},
+		{
+			name:    "without iteration",
+			content: "cpi /cpi 3\n",
+			want:    Judgments{"cpi": {"/cpi": 3}},
+		},
+		{name: "bad grade", content: "cpi 0 /cpi high\n", wantErr: true},
+		{name: "negative grade", content: "cpi 0 /cpi -1\n", wantErr: true},
+		{name: "too few columns", content: "cpi /cpi\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "qrels.txt")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := LoadJudgments(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadJudgments() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("LoadJudgments() = %v, want %v", got, tt.want)
+			}
+			for query, grades := range tt.want {
+				for uri, grade := range grades {
+					if got[query][uri] != grade {
+						t.Errorf("grade of %s for %s = %d, want %d", uri, query, got[query][uri], grade)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestJudgments_UnknownURIs(t *testing.T) {
+	judgments := Judgments{
+		"cpi": {"/cpi": 3, "/cpii": 1, "/old": 0},
+		"gdp": {"/gdp": 2},
+	}
+	index := &StoredIndex{Documents: []Document{{URI: "/cpi"}, {URI: "/gdp"}}}
+
+	got := judgments.UnknownURIs(index)
+	want := map[string][]string{"cpi": {"/cpii", "/old"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnknownURIs() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadWatchlist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watchlist.txt")
+	content := "# Headline bulletins\n/economy/inflation/cpi\n\n  /census/2021  \n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadWatchlist(path)
+	if err != nil {
+		t.Fatalf("LoadWatchlist() error = %v", err)
+	}
+	if want := (Watchlist{"/economy/inflation/cpi", "/census/2021"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadWatchlist() = %v, want %v", got, want)
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.txt")
+	if err := os.WriteFile(empty, []byte("# nothing yet\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadWatchlist(empty); err == nil {
+		t.Error("LoadWatchlist() expected an error for a watchlist without URIs")
+	}
+}
+
+func TestSingleLine(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain", "Consumer price inflation, UK: May 2024", "Consumer price inflation, UK: May 2024"},
+		{"line breaks", "Labour market\r\noverview,\nUK", "Labour market overview, UK"},
+		{"tabs and padding", "\t Births \t in England  ", "Births in England"},
+		{"unicode separators", "Wales Cymru ", "Wales Cymru"},
+		{"control characters", "GDP\x00\x1bestimate", "GDP estimate"},
+		{"right to left", "مؤشر أسعار المستهلك", "مؤشر أسعار المستهلك"},
+		{"empty", " \n ", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SingleLine(tt.text); got != tt.want {
+				t.Errorf("SingleLine(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadEditorialFlags(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "flags.json")
+	if err := os.WriteFile(jsonPath, []byte(`{"/old/cpi": ["Withdrawn", "superseded", "withdrawn"]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	csvPath := filepath.Join(dir, "flags.csv")
+	csvContent := "uri,flag\n# Retired bulletins\n/old/cpi,withdrawn\n/census/2021,national-statistic\n/old/cpi,Superseded\n"
+	if err := os.WriteFile(csvPath, []byte(csvContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want EditorialFlags
+	}{
+		{path: jsonPath, want: EditorialFlags{"/old/cpi": {"superseded", "withdrawn"}}},
+		{path: csvPath, want: EditorialFlags{
+			"/old/cpi":     {"superseded", "withdrawn"},
+			"/census/2021": {"national-statistic"},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := LoadEditorialFlags(tt.path)
+		if err != nil {
+			t.Fatalf("LoadEditorialFlags(%s) error = %v", filepath.Base(tt.path), err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("LoadEditorialFlags(%s) = %v, want %v", filepath.Base(tt.path), got, tt.want)
+		}
+	}
+
+	empty := filepath.Join(dir, "empty.csv")
+	if err := os.WriteFile(empty, []byte("uri,flag\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadEditorialFlags(empty); err == nil {
+		t.Error("LoadEditorialFlags() expected an error for a file without flagged URIs")
+	}
+}
+
+func TestMovementHistogram_Add(t *testing.T) {
+	var h MovementHistogram
+	for _, change := range []int{0, 1, -1, 2, -3, 4, 10, -11, 40} {
+		h.Add(change)
+	}
+	want := MovementHistogram{One: 2, TwoThree: 2, FourToTen: 2, OverTen: 2}
+	if h != want {
+		t.Errorf("histogram = %+v, want %+v", h, want)
+	}
+	if h.Total() != 8 {
+		t.Errorf("Total() = %d, want 8", h.Total())
+	}
+
+	h.Merge(MovementHistogram{One: 1, OverTen: 3})
+	if h.One != 3 || h.OverTen != 5 {
+		t.Errorf("after Merge histogram = %+v, want One 3 and OverTen 5", h)
+	}
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ScriptConfig attaches a Painless scoring script to an algorithm. Every
+// query in the algorithm is wrapped in a script_score query that calls it.
+type ScriptConfig struct {
+	ID     string                 `json:"id,omitempty"` // Stored script ID, defaults to one derived from the algorithm name
+	File   string                 `json:"file"`         // Script source, relative to the queries file
+	Params map[string]interface{} `json:"params,omitempty"`
+	Source string                 `json:"-"`
+}
+
+// ScriptRecord is the script content used by an algorithm in a run
+type ScriptRecord struct {
+	Algorithm string                 `json:"algorithm"`
+	ID        string                 `json:"id"`
+	File      string                 `json:"file"`
+	SHA256    string                 `json:"sha256"`
+	Params    map[string]interface{} `json:"params,omitempty"`
+	Source    string                 `json:"source"`
+}
+
+// Record returns the script as recorded alongside a run's results
+func (s *ScriptConfig) Record(algorithm string) ScriptRecord {
+	sum := sha256.Sum256([]byte(s.Source))
+	return ScriptRecord{
+		Algorithm: algorithm,
+		ID:        s.ID,
+		File:      s.File,
+		SHA256:    hex.EncodeToString(sum[:]),
+		Params:    s.Params,
+		Source:    s.Source,
+	}
+}
+
+// Wrap returns a copy of an es_query body whose query is scored by the
+// script. A body without a query scores every document.
+func (s *ScriptConfig) Wrap(esQuery map[string]interface{}) map[string]interface{} {
+	wrapped := make(map[string]interface{}, len(esQuery)+1)
+	for k, v := range esQuery {
+		wrapped[k] = v
+	}
+
+	inner, ok := esQuery["query"]
+	if !ok {
+		inner = map[string]interface{}{"match_all": map[string]interface{}{}}
+	}
+
+	script := map[string]interface{}{"id": s.ID}
+	if len(s.Params) > 0 {
+		script["params"] = s.Params
+	}
+
+	wrapped["query"] = map[string]interface{}{
+		"script_score": map[string]interface{}{
+			"query":  inner,
+			"script": script,
+		},
+	}
+
+	return wrapped
+}
+
+// loadScript reads the algorithm's script source
+func (a *AlgorithmConfig) loadScript(baseDir string) error {
+	s := a.Script
+	if s.File == "" {
+		return fmt.Errorf("algorithm %s: script has no file", a.Name)
+	}
+
+	path := s.File
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("algorithm %s: read script: %w", a.Name, err)
+	}
+	s.Source = string(source)
+
+	if s.ID == "" {
+		s.ID = "testbed-" + Slugify(a.Name)
+	}
+
+	return nil
+}
+
+// ApplyScript wraps each of the algorithm's queries in a script_score query
+// calling its stored script. Algorithms without a script are unchanged.
+func (a *AlgorithmConfig) ApplyScript() {
+	if a.Script == nil {
+		return
+	}
+	for q := range a.Queries {
+		a.Queries[q].ESQuery = a.Script.Wrap(a.Queries[q].ESQuery)
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Slugify converts text into a lowercase, hyphen-separated identifier
+// suitable for file names and stable keys
+func Slugify(text string) string {
+	var b strings.Builder
+	pendingHyphen := false
+
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if pendingHyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			pendingHyphen = false
+			continue
+		}
+		pendingHyphen = true
+	}
+
+	return b.String()
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SingleLine flattens text onto one line for line-oriented output such as
+// CSV rows and text reports. Line breaks, tabs and other control or
+// separator characters become single spaces and surrounding space is
+// trimmed. Other characters, including right-to-left scripts, are kept.
+func SingleLine(text string) string {
+	return strings.Join(strings.FieldsFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r) ||
+			unicode.In(r, unicode.Zl, unicode.Zp)
+	}), " ")
+}
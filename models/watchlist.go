@@ -0,0 +1,34 @@
+package models
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Watchlist holds the URIs of must-have documents, such as headline
+// bulletins, whose disappearance from the top results is always reported
+type Watchlist []string
+
+// LoadWatchlist loads a watchlist file of one URI per line. Blank lines and
+// lines starting with # are skipped.
+func LoadWatchlist(path string) (Watchlist, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read watchlist file: %w", err)
+	}
+
+	var watchlist Watchlist
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		watchlist = append(watchlist, line)
+	}
+
+	if len(watchlist) == 0 {
+		return nil, fmt.Errorf("parse watchlist: no URIs found")
+	}
+	return watchlist, nil
+}
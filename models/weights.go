@@ -0,0 +1,95 @@
+package models
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// WeightField is the document field holding a document's weight. It is
+// mapped as a float for field_value_factor with a rank_feature sub-field.
+const WeightField = "weight"
+
+// WeightFeatureField is the rank_feature sub-field of WeightField
+const WeightFeatureField = WeightField + ".feature"
+
+// Weights holds a per-document weight, such as page views, keyed by
+// document URI or ID
+type Weights map[string]float64
+
+// LoadWeights loads weights from a JSON file of the form {"/uri": 123}
+// or a CSV file of key,weight rows with an optional header row
+func LoadWeights(path string) (Weights, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read weights file: %w", err)
+	}
+	defer f.Close()
+
+	var weights Weights
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		weights, err = parseWeightsCSV(f)
+	} else {
+		err = json.NewDecoder(f).Decode(&weights)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse weights: %w", err)
+	}
+
+	for key, w := range weights {
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return nil, fmt.Errorf("weight for %s must be a non-negative number, got %v", key, w)
+		}
+	}
+
+	return weights, nil
+}
+
+func parseWeightsCSV(r io.Reader) (Weights, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	weights := make(Weights, len(rows))
+	for i, row := range rows {
+		w, err := strconv.ParseFloat(strings.TrimSpace(row[1]), 64)
+		if err != nil {
+			if i == 0 {
+				continue // header
+			}
+			return nil, fmt.Errorf("line %d: invalid weight %q", i+1, row[1])
+		}
+		weights[strings.TrimSpace(row[0])] = w
+	}
+
+	return weights, nil
+}
+
+// Apply sets each document's weight from its URI, or failing that its ID,
+// and returns how many documents were given a weight. Documents without an
+// entry keep no weight.
+func (w Weights) Apply(docs []Document) int {
+	matched := 0
+	for i := range docs {
+		weight, ok := w[docs[i].URI]
+		if !ok {
+			weight, ok = w[docs[i].ID]
+		}
+		if ok {
+			docs[i].Weight = weight
+			matched++
+		}
+	}
+	return matched
+}
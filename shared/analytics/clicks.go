@@ -0,0 +1,157 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// DefaultMaxGrade is the highest grade given to implicit judgments
+const DefaultMaxGrade = 3
+
+// Click is a row of a click log: a result clicked for a query. Clicks
+// counts how many times, 1 unless the log is aggregated.
+type Click struct {
+	Query    string
+	URI      string
+	Position int
+	Clicks   int64
+}
+
+// ImportClicks reads a CSV click log with query, clicked page and position
+// columns, and an optional clicks column for aggregated logs. Queries are
+// lower-cased and pages reduced to their path.
+func (i *Importer) ImportClicks(r io.Reader) ([]Click, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("click log is empty")
+		}
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	queryCol, err := findColumn(header, i.options.QueryColumn, queryHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("query column: %w", err)
+	}
+	uriCol, err := findColumn(header, i.options.URIColumn, uriHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("page column: %w", err)
+	}
+	posCol, err := findColumn(header, i.options.PositionColumn, posHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("position column: %w", err)
+	}
+	clicksCol, err := findColumn(header, i.options.ClicksColumn, clicksHeaders)
+	if err != nil && i.options.ClicksColumn != "" {
+		return nil, fmt.Errorf("clicks column: %w", err)
+	}
+
+	var clicks []Click
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read row: %w", err)
+		}
+
+		query := strings.ToLower(strings.TrimSpace(field(row, queryCol)))
+		uri := PagePath(field(row, uriCol))
+		if query == "" || uri == "" {
+			continue
+		}
+
+		position, err := strconv.Atoi(strings.TrimSpace(field(row, posCol)))
+		if err != nil || position < 1 {
+			return nil, fmt.Errorf("line %d: invalid position %q", line, field(row, posCol))
+		}
+
+		count := int64(1)
+		if clicksCol >= 0 {
+			if count, err = parseCount(field(row, clicksCol)); err != nil {
+				return nil, fmt.Errorf("line %d: clicks: %w", line, err)
+			}
+		}
+
+		clicks = append(clicks, Click{Query: query, URI: uri, Position: position, Clicks: count})
+	}
+
+	return clicks, nil
+}
+
+// JudgmentOptions controls how clicks are turned into grades
+type JudgmentOptions struct {
+	MaxGrade     int     // Grade given to a query's most clicked result (default 3)
+	MinClicks    int64   // Results clicked fewer times are left unjudged
+	PositionBias float64 // Exponent η of the examination model 1/position^η; 0 disables correction
+}
+
+// DeriveJudgments grades each clicked result by its click-through share for
+// the query. With position bias correction each click is weighted by the
+// inverse of the chance its position is looked at, so a click at rank 5
+// counts for more than one at rank 1. Shares are scaled against the query's
+// top result: the top result gets MaxGrade and the rest proportionally
+// fewer, with at least grade 1 for any result that met MinClicks.
+func DeriveJudgments(clicks []Click, options JudgmentOptions) models.Judgments {
+	maxGrade := options.MaxGrade
+	if maxGrade <= 0 {
+		maxGrade = DefaultMaxGrade
+	}
+
+	type tally struct {
+		clicks   int64
+		weighted float64
+	}
+	byQuery := make(map[string]map[string]*tally)
+	for _, c := range clicks {
+		uris, ok := byQuery[c.Query]
+		if !ok {
+			uris = make(map[string]*tally)
+			byQuery[c.Query] = uris
+		}
+		t, ok := uris[c.URI]
+		if !ok {
+			t = &tally{}
+			uris[c.URI] = t
+		}
+		t.clicks += c.Clicks
+		t.weighted += float64(c.Clicks) * math.Pow(float64(c.Position), options.PositionBias)
+	}
+
+	judgments := make(models.Judgments, len(byQuery))
+	for query, uris := range byQuery {
+		top := 0.0
+		for _, t := range uris {
+			if t.clicks >= options.MinClicks && t.weighted > top {
+				top = t.weighted
+			}
+		}
+		if top == 0 {
+			continue
+		}
+
+		grades := make(map[string]int)
+		for uri, t := range uris {
+			if t.clicks < options.MinClicks || t.weighted == 0 {
+				continue
+			}
+			grades[uri] = int(math.Ceil(t.weighted / top * float64(maxGrade)))
+		}
+		judgments[query] = grades
+	}
+
+	return judgments
+}
@@ -0,0 +1,95 @@
+package analytics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImporter_ImportClicks(t *testing.T) {
+	log := `# Search click log
+Search term,Clicked URL,Position,Clicks
+Inflation ,https://www.ons.gov.uk/economy/inflation/,1,10
+inflation,/economy/cpi,3,2
+,/economy,1,5
+`
+	clicks, err := NewImporter(Options{}).ImportClicks(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("ImportClicks() error = %v", err)
+	}
+
+	want := []Click{
+		{Query: "inflation", URI: "/economy/inflation", Position: 1, Clicks: 10},
+		{Query: "inflation", URI: "/economy/cpi", Position: 3, Clicks: 2},
+	}
+	if len(clicks) != len(want) {
+		t.Fatalf("ImportClicks() = %+v, want %+v", clicks, want)
+	}
+	for i := range want {
+		if clicks[i] != want[i] {
+			t.Errorf("clicks[%d] = %+v, want %+v", i, clicks[i], want[i])
+		}
+	}
+
+	if _, err := NewImporter(Options{}).ImportClicks(strings.NewReader("query,uri,position\ngdp,/gdp,first\n")); err == nil {
+		t.Error("expected an invalid position to be rejected")
+	}
+}
+
+func TestDeriveJudgments(t *testing.T) {
+	clicks := []Click{
+		{Query: "inflation", URI: "/a", Position: 1, Clicks: 12},
+		{Query: "inflation", URI: "/b", Position: 4, Clicks: 4},
+		{Query: "inflation", URI: "/c", Position: 2, Clicks: 1},
+		{Query: "gdp", URI: "/gdp", Position: 1, Clicks: 1},
+	}
+
+	tests := []struct {
+		name    string
+		options JudgmentOptions
+		want    map[string]map[string]int
+	}{
+		{
+			name:    "click share",
+			options: JudgmentOptions{},
+			want: map[string]map[string]int{
+				"inflation": {"/a": 3, "/b": 1, "/c": 1},
+				"gdp":       {"/gdp": 3},
+			},
+		},
+		{
+			name:    "position bias correction",
+			options: JudgmentOptions{PositionBias: 1},
+			want: map[string]map[string]int{
+				"inflation": {"/b": 3, "/a": 3, "/c": 1},
+				"gdp":       {"/gdp": 3},
+			},
+		},
+		{
+			name:    "minimum clicks and max grade",
+			options: JudgmentOptions{MinClicks: 2, MaxGrade: 4},
+			want: map[string]map[string]int{
+				"inflation": {"/a": 4, "/b": 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DeriveJudgments(clicks, tt.options)
+			if len(got) != len(tt.want) {
+				t.Fatalf("DeriveJudgments() = %v, want %v", got, tt.want)
+			}
+			for query, grades := range tt.want {
+				if len(got[query]) != len(grades) {
+					t.Errorf("%s: got %v, want %v", query, got[query], grades)
+					continue
+				}
+				for uri, grade := range grades {
+					if got[query][uri] != grade {
+						t.Errorf("%s %s: grade %d, want %d", query, uri, got[query][uri], grade)
+					}
+				}
+			}
+		})
+	}
+}
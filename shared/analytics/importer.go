@@ -0,0 +1,181 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// Header names recognised for each column when none is configured. Matching
+// ignores case, spaces and underscores.
+var (
+	uriHeaders    = []string{"uri", "url", "page", "pagepath", "pagepathandscreenclass", "landingpage", "clickedurl", "clickeduri"}
+	viewsHeaders  = []string{"pageviews", "views", "screenpageviews", "sessions"}
+	clicksHeaders = []string{"clicks", "clickcount", "totalclicks"}
+	queryHeaders  = []string{"query", "searchterm", "searchquery", "keyword", "term"}
+	posHeaders    = []string{"position", "rank", "clickposition", "resultposition"}
+)
+
+// Options configures how an export is read
+type Options struct {
+	Source         string // Description of the export, e.g. its file name
+	URIColumn      string // Header of the page column (detected if empty)
+	ViewsColumn    string // Header of the page views column (detected if empty)
+	ClicksColumn   string // Header of the clicks column (optional, detected if empty)
+	QueryColumn    string // Header of the search query column in click logs (detected if empty)
+	PositionColumn string // Header of the clicked position column in click logs (detected if empty)
+}
+
+// Importer reads analytics exports into page traffic
+type Importer struct {
+	options Options
+}
+
+// NewImporter creates a new importer
+func NewImporter(options Options) *Importer {
+	return &Importer{options: options}
+}
+
+// Import reads a CSV analytics export. Lines starting with # (as written
+// by some analytics tools) are skipped, URIs are reduced to their path and
+// rows for the same path are summed.
+func (i *Importer) Import(r io.Reader) (*models.Analytics, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("export is empty")
+		}
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	uriCol, err := findColumn(header, i.options.URIColumn, uriHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("page column: %w", err)
+	}
+	viewsCol, err := findColumn(header, i.options.ViewsColumn, viewsHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("page views column: %w", err)
+	}
+	clicksCol, err := findColumn(header, i.options.ClicksColumn, clicksHeaders)
+	if err != nil && i.options.ClicksColumn != "" {
+		return nil, fmt.Errorf("clicks column: %w", err)
+	}
+
+	analytics := &models.Analytics{
+		ImportedAt: time.Now(),
+		Source:     i.options.Source,
+		Pages:      make(map[string]models.PageStats),
+	}
+
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read row: %w", err)
+		}
+
+		uri := PagePath(field(row, uriCol))
+		if uri == "" {
+			continue
+		}
+
+		views, err := parseCount(field(row, viewsCol))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: page views: %w", line, err)
+		}
+		var clicks int64
+		if clicksCol >= 0 {
+			if clicks, err = parseCount(field(row, clicksCol)); err != nil {
+				return nil, fmt.Errorf("line %d: clicks: %w", line, err)
+			}
+		}
+
+		stats := analytics.Pages[uri]
+		stats.PageViews += views
+		stats.Clicks += clicks
+		analytics.Pages[uri] = stats
+	}
+
+	return analytics, nil
+}
+
+// PagePath reduces a page URL or path to its path without a query string,
+// fragment or trailing slash
+func PagePath(uri string) string {
+	path := strings.TrimSpace(uri)
+	if path == "" {
+		return ""
+	}
+	if u, err := url.Parse(path); err == nil {
+		path = u.Path
+	}
+	path = strings.TrimRight(path, "/")
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
+
+// findColumn returns the index of the named column, or of the first
+// column matching one of the candidates. It returns -1 and an error if
+// there is no such column.
+func findColumn(header []string, name string, candidates []string) (int, error) {
+	if name != "" {
+		candidates = []string{name}
+	}
+	for _, want := range candidates {
+		for i, h := range header {
+			if normaliseHeader(h) == normaliseHeader(want) {
+				return i, nil
+			}
+		}
+	}
+	if name != "" {
+		return -1, fmt.Errorf("no column named %q", name)
+	}
+	return -1, fmt.Errorf("none of the columns %q is recognised", header)
+}
+
+func normaliseHeader(h string) string {
+	h = strings.ToLower(strings.TrimSpace(h))
+	return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(h)
+}
+
+func field(row []string, col int) string {
+	if col < 0 || col >= len(row) {
+		return ""
+	}
+	return row[col]
+}
+
+// parseCount parses a count, allowing thousands separators. Empty cells
+// count as zero.
+func parseCount(s string) (int64, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	if s == "" {
+		return 0, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil && n >= 0 {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f < 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("invalid count %q", s)
+	}
+	return int64(f), nil
+}
@@ -0,0 +1,94 @@
+package analytics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestImporter_Import(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		export  string
+		want    map[string]models.PageStats
+		wantErr bool
+	}{
+		{
+			name: "detected columns with comments and duplicates",
+			export: `# Analytics export
+# Date range: 2024-01-01 to 2024-01-31
+Page path,Views,Clicks
+/economy/inflation,"1,200",40
+https://www.ons.gov.uk/economy/inflation/?tab=1,300,10
+/people,75,
+`,
+			want: map[string]models.PageStats{
+				"/economy/inflation": {PageViews: 1500, Clicks: 50},
+				"/people":            {PageViews: 75},
+			},
+		},
+		{
+			name:    "named columns",
+			options: Options{URIColumn: "landing", ViewsColumn: "hits"},
+			export:  "landing,hits\n/economy,10\n",
+			want:    map[string]models.PageStats{"/economy": {PageViews: 10}},
+		},
+		{
+			name:    "unrecognised columns",
+			export:  "a,b\n/economy,10\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing named clicks column",
+			options: Options{ClicksColumn: "taps"},
+			export:  "page,views\n/economy,10\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid count",
+			export:  "page,views\n/economy,lots\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewImporter(tt.options).Import(strings.NewReader(tt.export))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Import() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got.Pages) != len(tt.want) {
+				t.Fatalf("Import() pages = %v, want %v", got.Pages, tt.want)
+			}
+			for uri, stats := range tt.want {
+				if got.Pages[uri] != stats {
+					t.Errorf("pages[%s] = %+v, want %+v", uri, got.Pages[uri], stats)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalytics_Weights(t *testing.T) {
+	traffic := &models.Analytics{Pages: map[string]models.PageStats{
+		"/economy": {PageViews: 1500, Clicks: 40},
+		"/people":  {PageViews: 75},
+	}}
+
+	weights, err := traffic.Weights(models.MetricClicks)
+	if err != nil {
+		t.Fatalf("Weights() error = %v", err)
+	}
+	if len(weights) != 1 || weights["/economy"] != 40 {
+		t.Errorf("expected only pages with clicks to be weighted, got %v", weights)
+	}
+
+	if _, err := traffic.Weights("bounces"); err == nil {
+		t.Error("expected an unknown metric to be rejected")
+	}
+}
@@ -0,0 +1,138 @@
+package analytics
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/metrics"
+)
+
+// Oracle is a baseline ranking for each query that orders pages purely by
+// observed clicks, keyed by lower-cased query text
+type Oracle map[string][]string
+
+// NewOracle ranks each query's clicked pages by total clicks, breaking ties
+// by the best position the page was clicked at
+func NewOracle(clicks []Click) Oracle {
+	type tally struct {
+		clicks int64
+		best   int
+	}
+	byQuery := make(map[string]map[string]*tally)
+	for _, c := range clicks {
+		uris, ok := byQuery[c.Query]
+		if !ok {
+			uris = make(map[string]*tally)
+			byQuery[c.Query] = uris
+		}
+		t, ok := uris[c.URI]
+		if !ok {
+			t = &tally{best: c.Position}
+			uris[c.URI] = t
+		}
+		t.clicks += c.Clicks
+		if c.Position < t.best {
+			t.best = c.Position
+		}
+	}
+
+	oracle := make(Oracle, len(byQuery))
+	for query, uris := range byQuery {
+		ranking := make([]string, 0, len(uris))
+		for uri := range uris {
+			ranking = append(ranking, uri)
+		}
+		sort.Slice(ranking, func(i, j int) bool {
+			a, b := uris[ranking[i]], uris[ranking[j]]
+			if a.clicks != b.clicks {
+				return a.clicks > b.clicks
+			}
+			if a.best != b.best {
+				return a.best < b.best
+			}
+			return ranking[i] < ranking[j]
+		})
+		oracle[query] = ranking
+	}
+
+	return oracle
+}
+
+// Ranking returns the click ranking for a query, ignoring case
+func (o Oracle) Ranking(query string) []string {
+	return o[strings.ToLower(strings.TrimSpace(query))]
+}
+
+// QueryAgreement is how closely one query's results match the oracle
+type QueryAgreement struct {
+	Query   string  `json:"query"`
+	Overlap float64 `json:"overlap"`
+	RBO     float64 `json:"rbo"`
+}
+
+// Agreement is how closely an algorithm's rankings match the oracle,
+// averaged over the queries that have click data
+type Agreement struct {
+	Algorithm string           `json:"algorithm"`
+	K         int              `json:"k"`
+	Queries   int              `json:"queries"`
+	Overlap   float64          `json:"overlap"` // Mean share of the oracle's top K in the algorithm's top K
+	RBO       float64          `json:"rbo"`     // Mean rank-biased overlap with the oracle
+	PerQuery  []QueryAgreement `json:"per_query"`
+}
+
+// Compare measures each algorithm's agreement with the oracle over the
+// top k results. Queries without click data are skipped; algorithms are
+// ordered by RBO, closest first.
+func (o Oracle) Compare(results []models.QueryResults, k int) []Agreement {
+	if k <= 0 {
+		k = metrics.DefaultK
+	}
+
+	byAlgorithm := make(map[string]*Agreement)
+	var order []string
+	for _, qr := range results {
+		reference := o.Ranking(qr.Query)
+		if len(reference) == 0 {
+			continue
+		}
+
+		ranking := make([]string, 0, len(qr.Results))
+		for _, r := range qr.Results {
+			ranking = append(ranking, PagePath(r.URI))
+		}
+
+		algorithm := qr.AlgorithmLabel()
+		a, ok := byAlgorithm[algorithm]
+		if !ok {
+			a = &Agreement{Algorithm: algorithm, K: k}
+			byAlgorithm[algorithm] = a
+			order = append(order, algorithm)
+		}
+		a.PerQuery = append(a.PerQuery, QueryAgreement{
+			Query:   qr.Query,
+			Overlap: metrics.Overlap(ranking, reference, k),
+			RBO:     metrics.RankBiasedOverlap(ranking, reference, k, metrics.DefaultRBOPersistence),
+		})
+	}
+
+	agreements := make([]Agreement, 0, len(order))
+	for _, name := range order {
+		a := byAlgorithm[name]
+		a.Queries = len(a.PerQuery)
+		for _, q := range a.PerQuery {
+			a.Overlap += q.Overlap
+			a.RBO += q.RBO
+		}
+		a.Overlap /= float64(a.Queries)
+		a.RBO /= float64(a.Queries)
+		agreements = append(agreements, *a)
+	}
+
+	sort.SliceStable(agreements, func(i, j int) bool {
+		return agreements[i].RBO > agreements[j].RBO
+	})
+
+	return agreements
+}
@@ -0,0 +1,54 @@
+package analytics
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestNewOracle(t *testing.T) {
+	oracle := NewOracle([]Click{
+		{Query: "inflation", URI: "/b", Position: 3, Clicks: 2},
+		{Query: "inflation", URI: "/a", Position: 1, Clicks: 5},
+		{Query: "inflation", URI: "/c", Position: 2, Clicks: 2},
+	})
+
+	got := oracle.Ranking("Inflation ")
+	want := []string{"/a", "/c", "/b"}
+	if len(got) != len(want) {
+		t.Fatalf("Ranking() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Ranking()[%d] = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestOracle_Compare(t *testing.T) {
+	oracle := Oracle{"inflation": {"/a", "/b"}}
+	results := []models.QueryResults{
+		{Query: "Inflation", Algorithm: "bm25", Results: []models.SearchResult{
+			{URI: "/b"}, {URI: "/x"}, {URI: "/a/"},
+		}},
+		{Query: "inflation", Algorithm: "boosted", Results: []models.SearchResult{
+			{URI: "/a"}, {URI: "/b"},
+		}},
+		{Query: "gdp", Algorithm: "bm25", Results: []models.SearchResult{{URI: "/g"}}},
+	}
+
+	agreements := oracle.Compare(results, 3)
+	if len(agreements) != 2 {
+		t.Fatalf("expected 2 algorithms, got %+v", agreements)
+	}
+	if agreements[0].Algorithm != "boosted" || agreements[0].RBO != 1 || agreements[0].Overlap != 1 {
+		t.Errorf("expected boosted to agree exactly, got %+v", agreements[0])
+	}
+	bm25 := agreements[1]
+	if bm25.Queries != 1 {
+		t.Errorf("expected queries without clicks to be skipped, got %d", bm25.Queries)
+	}
+	if bm25.Overlap != 1 || bm25.RBO >= 1 {
+		t.Errorf("expected full overlap but imperfect order for bm25, got %+v", bm25)
+	}
+}
@@ -0,0 +1,450 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/comparison"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/shared/paths"
+)
+
+var (
+	metadataQueryLine = regexp.MustCompile(`^\s+\d+\. (.*) \(([^()]*)\) - (\d+) results$`)
+	reportQueryLine   = regexp.MustCompile(`^Query: (.*)$`)
+	reportAlgLine     = regexp.MustCompile(`^Algorithm: (.*)$`)
+	reportIDLine      = regexp.MustCompile(`^ID: (.*)$`)
+	reportTotalLine   = regexp.MustCompile(`^  Total Results: (\d+)$`)
+	crossQuery1Line   = regexp.MustCompile(`^\S+ Query 1: (.*) \(([^()]*)\)$`)
+	crossQuery2Line   = regexp.MustCompile(`^\S+ Query 2: (.*) \(([^()]*)\)$`)
+	crossCommonLine   = regexp.MustCompile(`^  \S+ Common Results: (\d+)$`)
+	crossOnly1Line    = regexp.MustCompile(`^  \S+ Only in Query 1: (\d+)$`)
+	crossOnly2Line    = regexp.MustCompile(`^  \S+ Only in Query 2: (\d+)$`)
+	crossRankDiffLine = regexp.MustCompile(`^  Ranking Differences: (\d+)$`)
+)
+
+// statsPatterns match the lines of a historical report whose wording comes
+// from the report labels
+type statsPatterns struct {
+	newLine     *regexp.Regexp
+	movedLine   *regexp.Regexp
+	summaryLine *regexp.Regexp
+}
+
+func newStatsPatterns(labels comparison.Labels) statsPatterns {
+	q := regexp.QuoteMeta
+	return statsPatterns{
+		newLine: regexp.MustCompile(fmt.Sprintf(`^  %s: (\d+) \| %s: (\d+)$`,
+			q(labels.New), q(labels.Removed))),
+		movedLine: regexp.MustCompile(fmt.Sprintf(`^  %s: (\d+) \| %s: (\d+) \| %s: (\d+)$`,
+			q(labels.Improved), q(labels.Worsened), q(labels.Unchanged))),
+		summaryLine: regexp.MustCompile(fmt.Sprintf(`^(%s|%s|%s|%s): (\d+)$`,
+			q(labels.TotalNew), q(labels.TotalRemoved), q(labels.TotalImproved), q(labels.TotalWorsened))),
+	}
+}
+
+// checkCSV compares per-query row counts in results.csv with results.json
+func (a *Auditor) checkCSV(report *Report, results []models.QueryResults) error {
+	const source = "results.csv"
+	path := filepath.Join(a.runFolder, source)
+	if !fileExists(path) {
+		report.SkippedChecks = append(report.SkippedChecks, "results.csv not found")
+		return nil
+	}
+
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return fmt.Errorf("open CSV: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("read CSV header: %w", err)
+	}
+
+	queryCol, algCol := indexOf(header, "query"), indexOf(header, "algorithm")
+	corpusCol := indexOf(header, "corpus") // Absent from CSVs written before corpora
+	if queryCol < 0 || algCol < 0 {
+		report.addIssue(SeverityError, source, "header is missing query or algorithm column")
+		return nil
+	}
+
+	counts := make(map[string]int)
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			report.addIssue(SeverityError, source, "unreadable row: %v", err)
+			return nil
+		}
+		if len(row) != len(header) {
+			report.addIssue(SeverityError, source, "row has %d columns, header has %d", len(row), len(header))
+			continue
+		}
+		algorithm := models.QueryResults{Algorithm: row[algCol]}
+		if corpusCol >= 0 {
+			algorithm.Corpus = row[corpusCol]
+		}
+		counts[queryLabel(row[queryCol], algorithm.AlgorithmLabel())]++
+	}
+	report.FilesChecked = append(report.FilesChecked, source)
+
+	expected := make(map[string]int, len(results))
+	for _, qr := range results {
+		expected[queryLabel(qr.Query, qr.AlgorithmLabel())] += len(qr.Results)
+	}
+
+	compareCounts(report, source, "rows", expected, counts)
+	return nil
+}
+
+// checkMetadata compares the per-query result counts recorded in
+// manifest.json, or in metadata.txt for runs written before manifests
+// existed
+func (a *Auditor) checkMetadata(report *Report, results []models.QueryResults) error {
+	listed := make(map[string]int)
+	source := output.ManifestFileName
+	manifest, err := output.LoadManifest(a.runFolder)
+	switch {
+	case err == nil && manifest.Results != nil:
+		for _, c := range manifest.Results.Counts {
+			listed[queryLabel(c.Query, c.Algorithm)] = c.Results
+		}
+	case err == nil:
+		report.SkippedChecks = append(report.SkippedChecks, "manifest.json has no results")
+		return nil
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	default:
+		source = output.MetadataFileName
+		path := filepath.Join(a.runFolder, source)
+		if !fileExists(path) {
+			report.SkippedChecks = append(report.SkippedChecks, "manifest.json and metadata.txt not found")
+			return nil
+		}
+
+		lines, err := readLines(path)
+		if err != nil {
+			return fmt.Errorf("read metadata: %w", err)
+		}
+		for _, line := range lines {
+			if m := metadataQueryLine.FindStringSubmatch(line); m != nil {
+				n, _ := strconv.Atoi(m[3])
+				listed[queryLabel(m[1], m[2])] = n
+			}
+		}
+	}
+	report.FilesChecked = append(report.FilesChecked, source)
+
+	expected := make(map[string]int, len(results))
+	for _, qr := range results {
+		expected[queryLabel(qr.Query, qr.AlgorithmLabel())] = len(qr.Results)
+	}
+
+	compareCounts(report, source, "results", expected, listed)
+	return nil
+}
+
+// checkHistorical recomputes the statistics in the historical text report
+// with the calculator and compares them to what the report says
+func (a *Auditor) checkHistorical(report *Report, results []models.QueryResults) error {
+	source := comparison.ReportFileName(a.layout.ReportNames().Historical, comparison.FormatText)
+	path := filepath.Join(a.layout.ReportsFolder(a.runFolder), source)
+	if !fileExists(path) {
+		report.SkippedChecks = append(report.SkippedChecks, source+" not found")
+		return nil
+	}
+
+	previousPath := a.previousPath
+	if previousPath == "" {
+		var err error
+		previousPath, err = a.comparedWith()
+		if err != nil {
+			report.SkippedChecks = append(report.SkippedChecks,
+				"historical report not recomputed: no previous run found")
+			return nil
+		}
+	}
+	report.PreviousPath = previousPath
+
+	previous, err := output.LoadResults(previousPath)
+	if err != nil {
+		return fmt.Errorf("load previous results: %w", err)
+	}
+
+	lines, err := readLines(path)
+	if err != nil {
+		return fmt.Errorf("read historical report: %w", err)
+	}
+	report.FilesChecked = append(report.FilesChecked, source)
+
+	currentByKey := indexByKey(results)
+	previousByKey := indexByKey(previous)
+	calc := comparison.NewCalculator(a.matcher)
+	labels := a.labels.WithDefaults()
+	patterns := newStatsPatterns(labels)
+
+	var block models.QueryResults
+	var label string
+	var reported models.ComparisonStats
+	var sawStats bool
+	reportedTotals := make(map[string]int)
+	totals := make(map[string]int)
+
+	verify := func() {
+		if label == "" {
+			return
+		}
+		curr, okCurr := currentByKey[block.Key()]
+		prev, okPrev := curr.FindPrevious(previousByKey)
+		if !okCurr || !okPrev {
+			report.addIssue(SeverityError, source, "%s: reported but not present in both runs", label)
+			return
+		}
+		want := calc.CalculateHistorical(curr, prev)
+		totals[labels.TotalNew] += want.NewResults
+		totals[labels.TotalRemoved] += want.RemovedCount
+		totals[labels.TotalImproved] += want.ImprovedCount
+		totals[labels.TotalWorsened] += want.WorsedCount
+
+		if !sawStats {
+			report.addIssue(SeverityError, source,
+				"%s: statistics lines not found (do the report labels match the configuration?)", label)
+			return
+		}
+
+		checkStat(report, source, label, "total results", reported.TotalResults, want.TotalResults)
+		checkStat(report, source, label, "new", reported.NewResults, want.NewResults)
+		checkStat(report, source, label, "removed", reported.RemovedCount, want.RemovedCount)
+		checkStat(report, source, label, "improved", reported.ImprovedCount, want.ImprovedCount)
+		checkStat(report, source, label, "worsened", reported.WorsedCount, want.WorsedCount)
+		checkStat(report, source, label, "unchanged", reported.UnchangedCount, want.UnchangedCount)
+	}
+
+	for _, line := range lines {
+		if m := reportQueryLine.FindStringSubmatch(line); m != nil {
+			verify()
+			block, label = models.QueryResults{Query: m[1]}, ""
+			reported, sawStats = models.ComparisonStats{}, false
+			continue
+		}
+		if m := reportAlgLine.FindStringSubmatch(line); m != nil {
+			block.Algorithm = m[1]
+			label = queryLabel(block.Query, block.Algorithm)
+			continue
+		}
+		if m := reportIDLine.FindStringSubmatch(line); m != nil {
+			block.QueryID = m[1]
+			continue
+		}
+		if m := reportTotalLine.FindStringSubmatch(line); m != nil {
+			reported.TotalResults, _ = strconv.Atoi(m[1])
+			continue
+		}
+		if m := patterns.newLine.FindStringSubmatch(line); m != nil {
+			reported.NewResults, _ = strconv.Atoi(m[1])
+			reported.RemovedCount, _ = strconv.Atoi(m[2])
+			sawStats = true
+			continue
+		}
+		if m := patterns.movedLine.FindStringSubmatch(line); m != nil {
+			reported.ImprovedCount, _ = strconv.Atoi(m[1])
+			reported.WorsedCount, _ = strconv.Atoi(m[2])
+			reported.UnchangedCount, _ = strconv.Atoi(m[3])
+			continue
+		}
+		if m := patterns.summaryLine.FindStringSubmatch(line); m != nil {
+			reportedTotals[m[1]], _ = strconv.Atoi(m[2])
+		}
+	}
+	verify()
+
+	for _, name := range sortedKeys(reportedTotals) {
+		if reportedTotals[name] != totals[name] {
+			report.addIssue(SeverityError, source, "summary: %s is %d, recomputed %d from the queries listed",
+				name, reportedTotals[name], totals[name])
+		}
+	}
+
+	return nil
+}
+
+// checkCrossQuery recomputes the pair statistics in the cross-query text
+// report
+func (a *Auditor) checkCrossQuery(report *Report, results []models.QueryResults) error {
+	source := comparison.ReportFileName(a.layout.ReportNames().CrossQuery, comparison.FormatText)
+	path := filepath.Join(a.layout.ReportsFolder(a.runFolder), source)
+	if !fileExists(path) {
+		report.SkippedChecks = append(report.SkippedChecks, source+" not found")
+		return nil
+	}
+
+	lines, err := readLines(path)
+	if err != nil {
+		return fmt.Errorf("read cross-query report: %w", err)
+	}
+	report.FilesChecked = append(report.FilesChecked, source)
+
+	byLabel := indexResults(results)
+	calc := comparison.NewCalculator(a.matcher)
+
+	var label1, label2 string
+	var reported comparison.CrossQueryStats
+
+	verify := func() {
+		if label1 == "" || label2 == "" {
+			return
+		}
+		pair := label1 + " vs " + label2
+		q1, ok1 := byLabel[label1]
+		q2, ok2 := byLabel[label2]
+		if !ok1 || !ok2 {
+			report.addIssue(SeverityError, source, "%s: reported but not present in results", pair)
+			return
+		}
+		want := calc.CalculateCrossQuery(q1, q2)
+		checkStat(report, source, pair, "common", reported.CommonResults, want.CommonResults)
+		checkStat(report, source, pair, "only in query 1", reported.OnlyInQuery1, want.OnlyInQuery1)
+		checkStat(report, source, pair, "only in query 2", reported.OnlyInQuery2, want.OnlyInQuery2)
+		checkStat(report, source, pair, "ranking differences", reported.RankingDiffCount, want.RankingDiffCount)
+	}
+
+	for _, line := range lines {
+		if m := crossQuery1Line.FindStringSubmatch(line); m != nil {
+			verify()
+			label1, label2 = queryLabel(m[1], m[2]), ""
+			reported = comparison.CrossQueryStats{}
+			continue
+		}
+		if m := crossQuery2Line.FindStringSubmatch(line); m != nil {
+			label2 = queryLabel(m[1], m[2])
+			continue
+		}
+		if m := crossCommonLine.FindStringSubmatch(line); m != nil {
+			reported.CommonResults, _ = strconv.Atoi(m[1])
+			continue
+		}
+		if m := crossOnly1Line.FindStringSubmatch(line); m != nil {
+			reported.OnlyInQuery1, _ = strconv.Atoi(m[1])
+			continue
+		}
+		if m := crossOnly2Line.FindStringSubmatch(line); m != nil {
+			reported.OnlyInQuery2, _ = strconv.Atoi(m[1])
+			continue
+		}
+		if m := crossRankDiffLine.FindStringSubmatch(line); m != nil {
+			reported.RankingDiffCount, _ = strconv.Atoi(m[1])
+		}
+	}
+	verify()
+
+	return nil
+}
+
+// comparedWith returns the results file the historical report was made
+// against: the one compare recorded in the manifest, else the baseline as
+// compare defaults to it, else the run before this one
+func (a *Auditor) comparedWith() (string, error) {
+	manifest, err := output.LoadManifest(a.runFolder)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	if manifest != nil && manifest.ComparedWith != "" {
+		return manifest.ComparedWith, nil
+	}
+
+	if a.baseline != "" && fileExists(a.baseline) && !paths.SameFolder(filepath.Dir(a.baseline), a.runFolder) {
+		return a.baseline, nil
+	}
+	return a.layout.FindPreviousResults(filepath.Dir(a.runFolder), filepath.Join(a.runFolder, "results.json"))
+}
+
+func checkStat(report *Report, source, label, name string, reported, recomputed int) {
+	if reported != recomputed {
+		report.addIssue(SeverityError, source, "%s: %s is %d in report, recomputed %d",
+			label, name, reported, recomputed)
+	}
+}
+
+func compareCounts(report *Report, source, unit string, expected, actual map[string]int) {
+	for _, label := range sortedKeys(expected) {
+		got, ok := actual[label]
+		switch {
+		case !ok:
+			report.addIssue(SeverityError, source, "%s: missing", label)
+		case got != expected[label]:
+			report.addIssue(SeverityError, source, "%s: %d %s, results.json has %d",
+				label, got, unit, expected[label])
+		}
+	}
+	for _, label := range sortedKeys(actual) {
+		if _, ok := expected[label]; !ok {
+			report.addIssue(SeverityError, source, "%s: not present in results.json", label)
+		}
+	}
+}
+
+func indexResults(results []models.QueryResults) map[string]models.QueryResults {
+	m := make(map[string]models.QueryResults, len(results))
+	for _, qr := range results {
+		m[queryLabel(qr.Query, qr.AlgorithmLabel())] = qr
+	}
+	return m
+}
+
+func indexByKey(results []models.QueryResults) map[string]models.QueryResults {
+	m := make(map[string]models.QueryResults, len(results))
+	for _, qr := range results {
+		m[qr.Key()] = qr
+	}
+	return m
+}
+
+func indexOf(values []string, want string) int {
+	for i, v := range values {
+		if strings.EqualFold(v, want) {
+			return i
+		}
+	}
+	return -1
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,218 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/comparison"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+	"github.com/ONSdigital/dis-search-test-bed/shared/paths"
+)
+
+// Severity indicates how serious an audit finding is
+type Severity string
+
+const (
+	// SeverityError marks numbers that are inconsistent with each other
+	SeverityError Severity = "error"
+	// SeverityWarning marks data that is suspicious but not necessarily wrong
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a single audit finding
+type Issue struct {
+	Severity Severity
+	Source   string // file or check that produced the finding
+	Message  string
+}
+
+// Report holds the outcome of auditing a run folder
+type Report struct {
+	RunFolder     string
+	PreviousPath  string
+	QueriesCount  int
+	ResultsCount  int
+	FilesChecked  []string
+	Issues        []Issue
+	SkippedChecks []string
+}
+
+// HasErrors reports whether any error-level issues were found
+func (r *Report) HasErrors() bool {
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Report) addIssue(severity Severity, source, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, Issue{
+		Severity: severity,
+		Source:   source,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Auditor recomputes statistics from raw results and checks them against
+// the artefacts written to a run folder
+type Auditor struct {
+	runFolder    string
+	previousPath string
+	baseline     string
+	matcher      comparison.Matcher
+	labels       comparison.Labels
+	layout       paths.Layout
+}
+
+// NewAuditor creates an auditor for a run folder. previousPath is the
+// results file the historical comparison was made against; if empty, the
+// file the run's manifest records compare using is checked against, else
+// the baseline, else the run folder preceding this one.
+func NewAuditor(runFolder, previousPath string) *Auditor {
+	return &Auditor{
+		runFolder:    runFolder,
+		previousPath: previousPath,
+		layout:       paths.DefaultLayout,
+	}
+}
+
+// SetLayout sets how run folders are named and where their comparison
+// reports are, for finding the previous run and the reports to check
+func (a *Auditor) SetLayout(layout paths.Layout) {
+	a.layout = layout
+}
+
+// SetBaseline sets the results file of the pinned baseline run, which
+// compare measures against by default
+func (a *Auditor) SetBaseline(path string) {
+	a.baseline = path
+}
+
+// SetMatcher sets how results are paired when recomputing comparison
+// stats. It should match the matcher the reports were generated with.
+func (a *Auditor) SetMatcher(m comparison.Matcher) {
+	a.matcher = m
+}
+
+// SetLabels sets the report terminology used to parse the historical report
+func (a *Auditor) SetLabels(labels comparison.Labels) {
+	a.labels = labels
+}
+
+// Run performs all checks and returns the findings
+func (a *Auditor) Run() (*Report, error) {
+	report := &Report{RunFolder: a.runFolder}
+
+	resultsPath := filepath.Join(a.runFolder, "results.json")
+	results, err := output.LoadResults(resultsPath)
+	if err != nil {
+		return nil, fmt.Errorf("load results: %w", err)
+	}
+	report.FilesChecked = append(report.FilesChecked, "results.json")
+	report.QueriesCount = len(results)
+	for _, qr := range results {
+		report.ResultsCount += len(qr.Results)
+	}
+
+	checkResults(report, results)
+
+	if err := a.checkCSV(report, results); err != nil {
+		return nil, err
+	}
+	if err := a.checkMetadata(report, results); err != nil {
+		return nil, err
+	}
+	if err := a.checkHistorical(report, results); err != nil {
+		return nil, err
+	}
+	if err := a.checkCrossQuery(report, results); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// checkResults flags problems within the raw result lists themselves
+func checkResults(report *Report, results []models.QueryResults) {
+	const source = "results.json"
+	seenQueries := make(map[string]bool, len(results))
+
+	for _, qr := range results {
+		label := queryLabel(qr.Query, qr.AlgorithmLabel())
+
+		if seenQueries[label] {
+			report.addIssue(SeverityError, source, "%s appears more than once", label)
+		}
+		seenQueries[label] = true
+
+		ranks := make([]int, 0, len(qr.Results))
+		seenURIs := make(map[string]int, len(qr.Results))
+
+		for i, r := range qr.Results {
+			ranks = append(ranks, r.Rank)
+
+			if r.URI == "" {
+				report.addIssue(SeverityWarning, source, "%s: result #%d has no URI", label, r.Rank)
+			} else if firstRank, dup := seenURIs[r.URI]; dup {
+				report.addIssue(SeverityError, source, "%s: duplicate URI %s at #%d and #%d",
+					label, r.URI, firstRank, r.Rank)
+			} else {
+				seenURIs[r.URI] = r.Rank
+			}
+
+			if r.Algorithm != "" && r.Algorithm != qr.Algorithm {
+				report.addIssue(SeverityError, source, "%s: result #%d is tagged with algorithm %s",
+					label, r.Rank, r.Algorithm)
+			}
+
+			if i > 0 && r.Score > qr.Results[i-1].Score {
+				report.addIssue(SeverityWarning, source, "%s: score increases from #%d to #%d (%.4f → %.4f)",
+					label, qr.Results[i-1].Rank, r.Rank, qr.Results[i-1].Score, r.Score)
+			}
+		}
+
+		checkRanks(report, label, ranks)
+	}
+}
+
+// checkRanks verifies ranks run 1..n without gaps or repeats
+func checkRanks(report *Report, label string, ranks []int) {
+	const source = "results.json"
+
+	sorted := append([]int(nil), ranks...)
+	sort.Ints(sorted)
+
+	expected := 1
+	for i, rank := range sorted {
+		switch {
+		case i > 0 && rank == sorted[i-1]:
+			report.addIssue(SeverityError, source, "%s: rank #%d is used more than once", label, rank)
+		case rank != expected:
+			report.addIssue(SeverityError, source, "%s: rank gap, expected #%d but found #%d", label, expected, rank)
+			expected = rank + 1
+		default:
+			expected++
+		}
+	}
+
+	for i, rank := range ranks {
+		if rank != i+1 {
+			report.addIssue(SeverityWarning, source, "%s: results are not stored in rank order", label)
+			return
+		}
+	}
+}
+
+func queryLabel(query, algorithm string) string {
+	return fmt.Sprintf("%q (%s)", query, algorithm)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+)
+
+func TestCheckResults(t *testing.T) {
+	tests := []struct {
+		name       string
+		results    []models.SearchResult
+		wantErrors []string
+	}{
+		{
+			name: "consistent results",
+			results: []models.SearchResult{
+				{Rank: 1, URI: "/a", Score: 3},
+				{Rank: 2, URI: "/b", Score: 2},
+			},
+		},
+		{
+			name: "rank gap",
+			results: []models.SearchResult{
+				{Rank: 1, URI: "/a"},
+				{Rank: 3, URI: "/b"},
+			},
+			wantErrors: []string{"rank gap, expected #2 but found #3"},
+		},
+		{
+			name: "duplicate URI",
+			results: []models.SearchResult{
+				{Rank: 1, URI: "/a"},
+				{Rank: 2, URI: "/a"},
+			},
+			wantErrors: []string{"duplicate URI /a at #1 and #2"},
+		},
+		{
+			name: "repeated rank",
+			results: []models.SearchResult{
+				{Rank: 1, URI: "/a"},
+				{Rank: 1, URI: "/b"},
+				{Rank: 2, URI: "/c"},
+			},
+			wantErrors: []string{"rank #1 is used more than once"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &Report{}
+			checkResults(report, []models.QueryResults{
+				{Query: "inflation", Algorithm: "bm25", Results: tt.results},
+			})
+
+			var errs []string
+			for _, issue := range report.Issues {
+				if issue.Severity == SeverityError {
+					errs = append(errs, issue.Message)
+				}
+			}
+
+			if len(errs) != len(tt.wantErrors) {
+				t.Fatalf("expected %d errors, got %d: %v", len(tt.wantErrors), len(errs), errs)
+			}
+			for i, want := range tt.wantErrors {
+				if !strings.Contains(errs[i], want) {
+					t.Errorf("error %q does not contain %q", errs[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestAuditor_ComparedWith(t *testing.T) {
+	base := t.TempDir()
+	runFolder := func(name string) string {
+		folder := filepath.Join(base, name)
+		if err := os.MkdirAll(folder, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(folder, "results.json"), []byte("[]"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return folder
+	}
+	baseline := runFolder("run_2024-01-01_09-00-00.000")
+	before := runFolder("run_2024-01-02_09-00-00.000")
+	audited := runFolder("run_2024-01-03_09-00-00.000")
+	recorded := filepath.Join(before, "results.json")
+
+	tests := []struct {
+		name     string
+		folder   string
+		baseline string
+		record   string
+		want     string
+	}{
+		{name: "run before", folder: audited, want: filepath.Join(before, "results.json")},
+		{name: "baseline", folder: audited, baseline: baseline, want: filepath.Join(baseline, "results.json")},
+		{name: "audited run is the baseline", folder: baseline, baseline: baseline, want: ""},
+		{name: "recorded by compare", folder: audited, baseline: baseline, record: recorded, want: recorded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := output.RecordComparison(tt.folder, tt.record); err != nil {
+				t.Fatal(err)
+			}
+			auditor := NewAuditor(tt.folder, "")
+			if tt.baseline != "" {
+				auditor.SetBaseline(filepath.Join(tt.baseline, "results.json"))
+			}
+
+			got, err := auditor.comparedWith()
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("comparedWith() = %s, want no previous run", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("comparedWith() = %s, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+// Package clock lets time-dependent code such as run folder names, result
+// timestamps and report headers be given a fixed time in tests.
+package clock
+
+import "time"
+
+// Clock tells the time
+type Clock interface {
+	Now() time.Time
+}
+
+// Real is the system clock
+type Real struct{}
+
+// Now returns the current time
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// Fixed is a clock stopped at a given time
+type Fixed time.Time
+
+// Now returns the fixed time
+func (f Fixed) Now() time.Time {
+	return time.Time(f)
+}
+
+// OrReal returns c, or the system clock if c is nil
+func OrReal(c Clock) Clock {
+	if c == nil {
+		return Real{}
+	}
+	return c
+}
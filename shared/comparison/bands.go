@@ -0,0 +1,110 @@
+package comparison
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// PositionBand is a range of ranks new results are counted in, weighted by
+// how much a new result there matters. A band runs from the rank after the
+// previous band's MaxRank down to its own.
+type PositionBand struct {
+	MaxRank int     // Last rank of the band; 0 for a final band with no end
+	Weight  float64 // What a new result in the band adds to the new result impact
+}
+
+// DefaultPositionBands tell new results in the top 3 from those on the rest
+// of the first page and beyond
+var DefaultPositionBands = []PositionBand{
+	{MaxRank: 3, Weight: 1},
+	{MaxRank: 10, Weight: 0.5},
+	{Weight: 0.1},
+}
+
+// ValidatePositionBands checks bands rise in rank and only the last is
+// open ended. Ranks below the last band's MaxRank aren't counted in any band.
+func ValidatePositionBands(bands []PositionBand) error {
+	prev := 0
+	for i, b := range bands {
+		switch {
+		case b.Weight < 0:
+			return fmt.Errorf("position band %d: weight %g is negative", i+1, b.Weight)
+		case b.MaxRank < 0:
+			return fmt.Errorf("position band %d: max rank %d is negative", i+1, b.MaxRank)
+		case b.MaxRank == 0 && i != len(bands)-1:
+			return fmt.Errorf("position band %d: only the last band may be open ended", i+1)
+		case b.MaxRank != 0 && b.MaxRank <= prev:
+			return fmt.Errorf("position band %d: max rank %d doesn't follow %d", i+1, b.MaxRank, prev)
+		}
+		prev = b.MaxRank
+	}
+	return nil
+}
+
+// positionBands returns the configured bands, or the defaults
+func positionBands(options Options) []PositionBand {
+	if len(options.PositionBands) > 0 {
+		return options.PositionBands
+	}
+	return DefaultPositionBands
+}
+
+// bandLabel names the ranks a band covers, e.g. "1-3", "4" or "11+"
+func bandLabel(bands []PositionBand, i int) string {
+	first := 1
+	if i > 0 {
+		first = bands[i-1].MaxRank + 1
+	}
+	switch last := bands[i].MaxRank; {
+	case last == 0:
+		return strconv.Itoa(first) + "+"
+	case last == first:
+		return strconv.Itoa(first)
+	default:
+		return fmt.Sprintf("%d-%d", first, last)
+	}
+}
+
+// newBandCounts returns a zero count for each band
+func newBandCounts(bands []PositionBand) []models.NewBandCount {
+	counts := make([]models.NewBandCount, len(bands))
+	for i, b := range bands {
+		counts[i] = models.NewBandCount{Band: bandLabel(bands, i), MaxRank: b.MaxRank}
+	}
+	return counts
+}
+
+// bandOf returns the index of the band holding rank, or -1
+func bandOf(bands []PositionBand, rank int) int {
+	for i, b := range bands {
+		if b.MaxRank == 0 || rank <= b.MaxRank {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeBandCounts adds one query's band counts to a running total
+func mergeBandCounts(total, counts []models.NewBandCount) []models.NewBandCount {
+	if total == nil {
+		total = make([]models.NewBandCount, len(counts))
+		copy(total, counts)
+		return total
+	}
+	for i := range counts {
+		total[i].Count += counts[i].Count
+	}
+	return total
+}
+
+// formatBandCounts gives band counts on one line, e.g. "1-3: 1 | 4-10: 0"
+func formatBandCounts(counts []models.NewBandCount) string {
+	parts := make([]string, len(counts))
+	for i, c := range counts {
+		parts[i] = fmt.Sprintf("%s: %d", c.Band, c.Count)
+	}
+	return strings.Join(parts, " | ")
+}
@@ -0,0 +1,138 @@
+package comparison
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestValidatePositionBands(t *testing.T) {
+	tests := []struct {
+		name    string
+		bands   []PositionBand
+		wantErr bool
+	}{
+		{name: "defaults", bands: DefaultPositionBands},
+		{name: "closed", bands: []PositionBand{{MaxRank: 1, Weight: 2}, {MaxRank: 5, Weight: 1}}},
+		{name: "not rising", bands: []PositionBand{{MaxRank: 5}, {MaxRank: 5}}, wantErr: true},
+		{name: "open band first", bands: []PositionBand{{Weight: 1}, {MaxRank: 10}}, wantErr: true},
+		{name: "negative weight", bands: []PositionBand{{MaxRank: 3, Weight: -1}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidatePositionBands(tt.bands); (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePositionBands() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCalculator_NewByBand(t *testing.T) {
+	results := func(uris ...string) models.QueryResults {
+		qr := models.QueryResults{Query: "inflation"}
+		for i, uri := range uris {
+			qr.Results = append(qr.Results, models.SearchResult{Rank: i + 1, URI: uri})
+		}
+		return qr
+	}
+	prev := results("/a", "/b", "/c", "/d", "/e")
+
+	tests := []struct {
+		name       string
+		bands      []PositionBand
+		curr       models.QueryResults
+		wantCounts []int
+		wantLabels []string
+		wantImpact float64
+	}{
+		{
+			name:       "new at the top",
+			curr:       results("/new", "/a", "/b", "/c", "/d"),
+			wantCounts: []int{1, 0, 0},
+			wantLabels: []string{"1-3", "4-10", "11+"},
+			wantImpact: 1,
+		},
+		{
+			name:       "new at the bottom",
+			curr:       results("/a", "/b", "/c", "/d", "/new"),
+			wantCounts: []int{0, 1, 0},
+			wantLabels: []string{"1-3", "4-10", "11+"},
+			wantImpact: 0.5,
+		},
+		{
+			name:       "configured bands",
+			bands:      []PositionBand{{MaxRank: 1, Weight: 3}, {MaxRank: 4, Weight: 1}},
+			curr:       results("/new", "/a", "/b", "/c", "/new2"),
+			wantCounts: []int{1, 0},
+			wantLabels: []string{"1", "2-4"},
+			wantImpact: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc := NewCalculator(nil)
+			calc.SetPositionBands(tt.bands)
+			stats := calc.CalculateHistorical(tt.curr, prev)
+
+			var counts []int
+			var labels []string
+			for _, b := range stats.NewByBand {
+				counts = append(counts, b.Count)
+				labels = append(labels, b.Band)
+			}
+			if !reflect.DeepEqual(counts, tt.wantCounts) || !reflect.DeepEqual(labels, tt.wantLabels) {
+				t.Errorf("bands %v = %v, want %v = %v", labels, counts, tt.wantLabels, tt.wantCounts)
+			}
+			if stats.NewImpact != tt.wantImpact {
+				t.Errorf("NewImpact = %v, want %v", stats.NewImpact, tt.wantImpact)
+			}
+		})
+	}
+}
+
+func TestThresholds_CheckNewByBand(t *testing.T) {
+	limit := func(n int) *int { return &n }
+	stats := func(top int, impact float64) models.ComparisonStats {
+		return models.ComparisonStats{
+			NewByBand: []models.NewBandCount{{Band: "1-3", MaxRank: 3, Count: top}, {Band: "4+"}},
+			NewImpact: impact,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		maxNewTop *int
+		stats     models.ComparisonStats
+		want      []string
+	}{
+		{name: "within limits", maxNewTop: limit(1), stats: stats(1, 2)},
+		{
+			name:      "new results at the top",
+			maxNewTop: limit(1),
+			stats:     stats(2, 2),
+			want:      []string{"2 new results at ranks 1-3 (max 1)"},
+		},
+		{
+			name:      "no new results allowed at the top",
+			maxNewTop: limit(0),
+			stats:     stats(1, 1),
+			want:      []string{"1 new results at ranks 1-3 (max 0)"},
+		},
+		{name: "top band unchecked", stats: stats(5, 1)},
+		{
+			name:      "high impact",
+			maxNewTop: limit(1),
+			stats:     stats(0, 2.5),
+			want:      []string{"new result impact 2.50 (max 2.00)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thresholds := Thresholds{MaxNewTop: tt.maxNewTop, MaxNewImpact: 2}
+			if got := thresholds.Check(tt.stats, RelevanceChange{}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,38 +4,94 @@ import (
 	"math"
 
 	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/metrics"
 )
 
 // Calculator performs comparison calculations
-type Calculator struct{}
+type Calculator struct {
+	matcher     Matcher
+	persistence float64
+	bands       []PositionBand
+}
+
+// NewCalculator creates a calculator that pairs results using matcher,
+// or by URI if matcher is nil
+func NewCalculator(matcher Matcher) *Calculator {
+	return &Calculator{
+		matcher:     matcherOrDefault(matcher),
+		persistence: metrics.DefaultRBOPersistence,
+		bands:       DefaultPositionBands,
+	}
+}
+
+// newCalculator creates a calculator from comparison options
+func newCalculator(options Options) *Calculator {
+	calc := NewCalculator(options.Matcher)
+	calc.SetRBOPersistence(options.RBOPersistence)
+	calc.SetPositionBands(options.PositionBands)
+	return calc
+}
+
+// SetPositionBands sets the bands of ranks new results are counted in. No
+// bands keeps the defaults.
+func (c *Calculator) SetPositionBands(bands []PositionBand) {
+	if len(bands) > 0 {
+		c.bands = bands
+	}
+}
+
+// SetRBOPersistence sets how steeply rank-biased overlap favours the top
+// ranks: each rank weighs p times the one above. Values outside (0, 1)
+// keep the default.
+func (c *Calculator) SetRBOPersistence(p float64) {
+	if p > 0 && p < 1 {
+		c.persistence = p
+	}
+}
+
+// rbo is the rank-biased overlap of two result lists over their full depth
+func (c *Calculator) rbo(a, b []models.SearchResult) float64 {
+	return metrics.RankBiasedOverlap(c.keys(a), c.keys(b), max(len(a), len(b)), c.persistence)
+}
 
-// NewCalculator creates a new calculator
-func NewCalculator() *Calculator {
-	return &Calculator{}
+// correlation is the Kendall tau and Spearman rank correlation of the
+// results two lists share, or nil when they share fewer than two
+func (c *Calculator) correlation(a, b []models.SearchResult) (tau, rho *float64) {
+	keysA, keysB := c.keys(a), c.keys(b)
+	t, ok := metrics.KendallTau(keysA, keysB)
+	if !ok {
+		return nil, nil
+	}
+	r, _ := metrics.Spearman(keysA, keysB)
+	return &t, &r
+}
+
+func (c *Calculator) keys(results []models.SearchResult) []string {
+	keys := make([]string, len(results))
+	for i, r := range results {
+		keys[i] = c.matcher.Key(r)
+	}
+	return keys
 }
 
 // CalculateHistorical computes statistics between current and previous results
 func (c *Calculator) CalculateHistorical(curr, prev models.QueryResults) models.ComparisonStats {
 	stats := models.ComparisonStats{
 		Query:        curr.Query,
-		Algorithm:    curr.Algorithm,
+		Algorithm:    curr.AlgorithmLabel(),
 		TotalResults: len(curr.Results),
 	}
 
-	prevMap := make(map[string]models.SearchResult)
-	for _, r := range prev.Results {
-		prevMap[r.URI] = r
-	}
-
-	currURIs := make(map[string]bool)
+	prevMap := makeResultMap(c.matcher, prev.Results)
+	currKeys := makeResultSet(c.matcher, curr.Results)
 	var totalRankChange int
+	stats.NewByBand = newBandCounts(c.bands)
 
 	for _, r := range curr.Results {
-		currURIs[r.URI] = true
-
-		if prevResult, existed := prevMap[r.URI]; existed {
+		if prevResult, existed := prevMap[c.matcher.Key(r)]; existed {
 			rankChange := prevResult.Rank - r.Rank
 			totalRankChange += int(math.Abs(float64(rankChange)))
+			stats.Movement.Add(rankChange)
 
 			if rankChange > 0 {
 				stats.ImprovedCount++
@@ -46,11 +102,15 @@ func (c *Calculator) CalculateHistorical(curr, prev models.QueryResults) models.
 			}
 		} else {
 			stats.NewResults++
+			if i := bandOf(c.bands, r.Rank); i >= 0 {
+				stats.NewByBand[i].Count++
+				stats.NewImpact += c.bands[i].Weight
+			}
 		}
 	}
 
 	for _, prevResult := range prev.Results {
-		if !currURIs[prevResult.URI] {
+		if !currKeys[c.matcher.Key(prevResult)] {
 			stats.RemovedCount++
 		}
 	}
@@ -58,6 +118,12 @@ func (c *Calculator) CalculateHistorical(curr, prev models.QueryResults) models.
 	if len(curr.Results) > 0 {
 		stats.AvgRankChange = float64(totalRankChange) / float64(len(curr.Results))
 	}
+	stats.Overlap = 1
+	if len(prev.Results) > 0 {
+		stats.Overlap = float64(len(prev.Results)-stats.RemovedCount) / float64(len(prev.Results))
+	}
+	stats.RBO = c.rbo(curr.Results, prev.Results)
+	stats.KendallTau, stats.Spearman = c.correlation(curr.Results, prev.Results)
 
 	return stats
 }
@@ -69,20 +135,13 @@ func (c *Calculator) CalculateCrossQuery(q1, q2 models.QueryResults) CrossQueryS
 		Query2Name: q2.Query,
 	}
 
-	q1Map := make(map[string]models.SearchResult)
-	q2Map := make(map[string]models.SearchResult)
-
-	for _, r := range q1.Results {
-		q1Map[r.URI] = r
-	}
-	for _, r := range q2.Results {
-		q2Map[r.URI] = r
-	}
+	q1Map := makeResultMap(c.matcher, q1.Results)
+	q2Map := makeResultMap(c.matcher, q2.Results)
 
 	var totalRankDiff int
 
 	for _, r1 := range q1.Results {
-		if r2, exists := q2Map[r1.URI]; exists {
+		if r2, exists := q2Map[c.matcher.Key(r1)]; exists {
 			stats.CommonResults++
 			if r1.Rank != r2.Rank {
 				totalRankDiff += int(math.Abs(float64(r1.Rank - r2.Rank)))
@@ -94,7 +153,7 @@ func (c *Calculator) CalculateCrossQuery(q1, q2 models.QueryResults) CrossQueryS
 	}
 
 	for _, r2 := range q2.Results {
-		if _, exists := q1Map[r2.URI]; !exists {
+		if _, exists := q1Map[c.matcher.Key(r2)]; !exists {
 			stats.OnlyInQuery2++
 		}
 	}
@@ -102,17 +161,22 @@ func (c *Calculator) CalculateCrossQuery(q1, q2 models.QueryResults) CrossQueryS
 	if stats.RankingDiffCount > 0 {
 		stats.AvgRankingDiff = float64(totalRankDiff) / float64(stats.RankingDiffCount)
 	}
+	stats.RBO = c.rbo(q1.Results, q2.Results)
+	stats.KendallTau, stats.Spearman = c.correlation(q1.Results, q2.Results)
 
 	return stats
 }
 
 // CrossQueryStats holds statistics for comparing two query result sets
 type CrossQueryStats struct {
-	Query1Name       string
-	Query2Name       string
-	CommonResults    int
-	OnlyInQuery1     int
-	OnlyInQuery2     int
-	RankingDiffCount int
-	AvgRankingDiff   float64
+	Query1Name       string   `json:"query1_name"`
+	Query2Name       string   `json:"query2_name"`
+	CommonResults    int      `json:"common_results"`
+	OnlyInQuery1     int      `json:"only_in_query1"`
+	OnlyInQuery2     int      `json:"only_in_query2"`
+	RankingDiffCount int      `json:"ranking_diff_count"`
+	AvgRankingDiff   float64  `json:"avg_ranking_diff"`
+	RBO              float64  `json:"rbo"`                   // Rank-biased overlap, weighting agreement near the top
+	KendallTau       *float64 `json:"kendall_tau,omitempty"` // Rank correlation over common results; nil with fewer than two
+	Spearman         *float64 `json:"spearman,omitempty"`
 }
@@ -0,0 +1,78 @@
+package comparison
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func ranked(uris ...string) models.QueryResults {
+	qr := models.QueryResults{Query: "q", Algorithm: "bm25"}
+	for i, uri := range uris {
+		qr.Results = append(qr.Results, models.SearchResult{Rank: i + 1, URI: uri})
+	}
+	return qr
+}
+
+func TestCalculator_RBO(t *testing.T) {
+	base := ranked("/a", "/b", "/c", "/d")
+
+	tests := []struct {
+		name        string
+		other       models.QueryResults
+		persistence float64
+		want        float64
+	}{
+		{name: "identical", other: ranked("/a", "/b", "/c", "/d"), want: 1},
+		{name: "disjoint", other: ranked("/w", "/x", "/y", "/z"), want: 0},
+		{
+			name:        "top two swapped",
+			other:       ranked("/b", "/a", "/c", "/d"),
+			persistence: 0.5,
+			want:        (0 + 0.5 + 0.25 + 0.125) / (1 + 0.5 + 0.25 + 0.125),
+		},
+		{
+			name:        "bottom two swapped",
+			other:       ranked("/a", "/b", "/d", "/c"),
+			persistence: 0.5,
+			want:        (1 + 0.5 + 0.25*2.0/3 + 0.125) / (1 + 0.5 + 0.25 + 0.125),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc := NewCalculator(nil)
+			calc.SetRBOPersistence(tt.persistence)
+
+			historical := calc.CalculateHistorical(tt.other, base)
+			if math.Abs(historical.RBO-tt.want) > 1e-9 {
+				t.Errorf("CalculateHistorical() RBO = %.4f, want %.4f", historical.RBO, tt.want)
+			}
+			cross := calc.CalculateCrossQuery(base, tt.other)
+			if math.Abs(cross.RBO-tt.want) > 1e-9 {
+				t.Errorf("CalculateCrossQuery() RBO = %.4f, want %.4f", cross.RBO, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculator_Correlation(t *testing.T) {
+	calc := NewCalculator(nil)
+
+	stats := calc.CalculateHistorical(ranked("/b", "/a", "/x", "/c"), ranked("/a", "/b", "/c"))
+	if stats.KendallTau == nil || stats.Spearman == nil {
+		t.Fatal("CalculateHistorical() gave no correlation for three common results")
+	}
+	if want := 1.0 / 3; math.Abs(*stats.KendallTau-want) > 1e-9 {
+		t.Errorf("KendallTau = %.4f, want %.4f", *stats.KendallTau, want)
+	}
+	if want := 0.5; math.Abs(*stats.Spearman-want) > 1e-9 {
+		t.Errorf("Spearman = %.4f, want %.4f", *stats.Spearman, want)
+	}
+
+	cross := calc.CalculateCrossQuery(ranked("/a", "/b"), ranked("/a", "/c"))
+	if cross.KendallTau != nil || cross.Spearman != nil {
+		t.Errorf("CalculateCrossQuery() gave a correlation for one common result")
+	}
+}
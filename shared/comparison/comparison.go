@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/preview"
 )
 
 // Mode represents the comparison mode
@@ -17,14 +18,34 @@ const (
 	ModeCrossQuery
 	// ModeBoth generates both reports
 	ModeBoth
+	// ModeScoreDrift reports score changes for queries whose ranking is
+	// unchanged since the previous run
+	ModeScoreDrift
 )
 
 // Options configures comparison output
 type Options struct {
-	ShowUnchanged  bool
-	HighlightNew   bool
-	ShowScores     bool
-	MaxRankDisplay int
+	ShowUnchanged      bool
+	HighlightNew       bool
+	ShowScores         bool
+	MaxRankDisplay     int
+	DiversityK         int // Rank cut-off for diversity measures
+	VisibilityK        int // Rank cut-off for theme visibility shares
+	Thresholds         Thresholds
+	Previewer          *preview.Previewer    // Adds body previews to results when set
+	Matcher            Matcher               // Pairs results across lists; URI when nil
+	Labels             Labels                // Report terminology; defaults fill empty fields
+	Judgments          models.Judgments      // Graded judgments for NDCG; no relevance scores when empty
+	RelevanceK         int                   // Rank cut-off for NDCG
+	RBOPersistence     float64               // Rank-biased overlap weighting; the metrics default when unset
+	Watchlist          models.Watchlist      // Must-have URIs reported whenever they leave the top K
+	WatchlistK         int                   // Rank cut-off for the watchlist
+	Flags              models.EditorialFlags // Editorial flags the results were annotated with by AnnotateFlags
+	WarnFlags          []string              // Flags reported whenever a result carrying one is in the top K
+	FlagsK             int                   // Rank cut-off for flag warnings
+	MarkdownRows       int                   // Rows per table and items per list in Markdown reports
+	PositionBands      []PositionBand        // Bands of ranks new results are counted in; the defaults when unset
+	BootstrapResamples int                   // Resamples for confidence intervals; the metrics default when unset
 }
 
 // Comparison handles generating comparison reports
@@ -59,6 +80,13 @@ func (c *Comparison) Generate() (string, error) {
 		if err := c.generateCrossQuery(formatter); err != nil {
 			return "", err
 		}
+	case ModeScoreDrift:
+		if len(c.previous) == 0 {
+			return "", fmt.Errorf("no previous results to compare against")
+		}
+		if err := formatter.FormatScoreDrift(c.current, c.previous); err != nil {
+			return "", err
+		}
 	case ModeBoth:
 		// This shouldn't be used directly - use separate calls instead
 		return "", fmt.Errorf("use ModeHistorical and ModeCrossQuery separately")
@@ -86,27 +114,65 @@ func (c *Comparison) GetSummary() Summary {
 		Mode: c.modeString(),
 	}
 
+	if c.mode == ModeCrossQuery {
+		summary.Correlation = crossQueryCorrelation(newCalculator(c.options), c.current)
+	}
 	if c.mode != ModeHistorical {
 		return summary
 	}
 
-	// Calculate statistics for historical comparison
-	calc := NewCalculator()
-	for i, curr := range c.current {
-		if i >= len(c.previous) {
+	// Calculate statistics over the queries present in both runs
+	summary.Coverage = CalculateCoverage(c.current, c.previous)
+	summary.QueriesCompared = summary.Coverage.Compared
+
+	calc := newCalculator(c.options)
+	previousByKey := indexByKey(c.previous)
+	for _, curr := range c.current {
+		prev, ok := curr.FindPrevious(previousByKey)
+		if !ok {
 			continue
 		}
 
-		stats := calc.CalculateHistorical(curr, c.previous[i])
+		stats := calc.CalculateHistorical(curr, prev)
 		summary.NewResults += stats.NewResults
 		summary.RemovedResults += stats.RemovedCount
 		summary.ImprovedRankings += stats.ImprovedCount
 		summary.WorsenedRankings += stats.WorsedCount
+		summary.Movement.Merge(stats.Movement)
+		summary.NewByBand = mergeBandCounts(summary.NewByBand, stats.NewByBand)
+		summary.NewImpact += stats.NewImpact
+		summary.Correlation.add(stats.KendallTau, stats.Spearman)
+		if !curr.Volatile {
+			summary.Gated.add(stats)
+		}
+	}
+	summary.Correlation.finish()
+
+	summary.Diversity = summariseDiversity(c.current, c.previous, diversityK(c.options))
+	summary.Relevance = summariseRelevance(c.current, c.previous, c.options.Judgments, relevanceK(c.options))
+	summary.Intervals = calculateIntervals(calc, c.current, c.previous, c.options)
+	for _, drop := range c.WatchlistDrops() {
+		summary.WatchlistDrops++
+		if !drop.Volatile {
+			summary.Gated.WatchlistDrops++
+		}
 	}
+	summary.FlaggedResults = len(c.FlaggedResults())
+	summary.Verdict = CalculateVerdict(c.current, c.previous, c.options)
 
 	return summary
 }
 
+// indexByKey maps query results by their stable key so runs can be matched
+// even when queries were reordered or their display text changed
+func indexByKey(results []models.QueryResults) map[string]models.QueryResults {
+	m := make(map[string]models.QueryResults, len(results))
+	for _, qr := range results {
+		m[qr.Key()] = qr
+	}
+	return m
+}
+
 func (c *Comparison) modeString() string {
 	switch c.mode {
 	case ModeHistorical:
@@ -115,16 +181,49 @@ func (c *Comparison) modeString() string {
 		return "Cross-Query"
 	case ModeBoth:
 		return "Both"
+	case ModeScoreDrift:
+		return "Score Drift"
 	default:
 		return "Unknown"
 	}
 }
 
-// Summary contains comparison summary statistics
+// Summary contains comparison summary statistics. Result counts cover
+// only the queries present in both runs.
 type Summary struct {
-	Mode             string
-	NewResults       int
-	RemovedResults   int
-	ImprovedRankings int
-	WorsenedRankings int
+	Mode             string           `json:"mode"`
+	QueriesCompared  int              `json:"queries_compared"`
+	Coverage         Coverage         `json:"coverage"`
+	NewResults       int              `json:"new_results"`
+	RemovedResults   int              `json:"removed_results"`
+	ImprovedRankings int              `json:"improved_rankings"`
+	WorsenedRankings int              `json:"worsened_rankings"`
+	Diversity        DiversitySummary `json:"diversity"`
+	Relevance        RelevanceSummary `json:"relevance"`
+	Intervals        *Intervals       `json:"intervals,omitempty"` // Set when at least two queries are compared
+	WatchlistDrops   int              `json:"watchlist_drops"`     // Watchlist documents that left a query's top K
+	FlaggedResults   int              `json:"flagged_results"`     // Top K results carrying a warn flag, e.g. withdrawn
+	Correlation      RankCorrelation  `json:"correlation"`
+	Verdict          Verdict          `json:"verdict"`
+
+	// Results in both runs by how many places they moved, over every query
+	Movement models.MovementHistogram `json:"movement"`
+
+	// New results by the band of ranks they entered, and their weighted
+	// count, over every query
+	NewByBand []models.NewBandCount `json:"new_by_band,omitempty"`
+	NewImpact float64               `json:"new_impact"`
+
+	// Gated repeats the result counts without volatile queries, for gate
+	// rules
+	Gated GateTotals `json:"gated"`
+}
+
+// NewInTopBand is the number of new results that entered the first band of
+// ranks, e.g. the top 3
+func (s Summary) NewInTopBand() int {
+	if len(s.NewByBand) == 0 {
+		return 0
+	}
+	return s.NewByBand[0].Count
 }
@@ -0,0 +1,74 @@
+package comparison
+
+import (
+	"fmt"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// RankCorrelation is the mean rank correlation over pairs of result lists
+// sharing at least two results
+type RankCorrelation struct {
+	Lists         int     `json:"lists"` // Pairs of lists with a correlation
+	AvgKendallTau float64 `json:"avg_kendall_tau"`
+	AvgSpearman   float64 `json:"avg_spearman"`
+}
+
+// add includes one pair's correlation in the running totals
+func (r *RankCorrelation) add(tau, rho *float64) {
+	if tau == nil || rho == nil {
+		return
+	}
+	r.Lists++
+	r.AvgKendallTau += *tau
+	r.AvgSpearman += *rho
+}
+
+// finish turns the running totals into means
+func (r *RankCorrelation) finish() {
+	if r.Lists > 0 {
+		r.AvgKendallTau /= float64(r.Lists)
+		r.AvgSpearman /= float64(r.Lists)
+	}
+}
+
+// crossQueryCorrelation is the mean rank correlation over every pair of
+// queries in a run
+func crossQueryCorrelation(calc *Calculator, queries []models.QueryResults) RankCorrelation {
+	var correlation RankCorrelation
+	for i := 0; i < len(queries)-1; i++ {
+		for j := i + 1; j < len(queries); j++ {
+			stats := calc.CalculateCrossQuery(queries[i], queries[j])
+			correlation.add(stats.KendallTau, stats.Spearman)
+		}
+	}
+	correlation.finish()
+	return correlation
+}
+
+func (f *Formatter) writeCorrelation(tau, rho *float64) error {
+	if tau == nil || rho == nil {
+		if err := f.writef("  Rank Correlation: n/a (fewer than 2 common results)\n"); err != nil {
+			return fmt.Errorf("write rank correlation: %w", err)
+		}
+		return nil
+	}
+	if err := f.writef("  Rank Correlation: Kendall τ %.2f | Spearman ρ %.2f\n", *tau, *rho); err != nil {
+		return fmt.Errorf("write rank correlation: %w", err)
+	}
+	return nil
+}
+
+func (f *Formatter) writeCorrelationSummary(correlation RankCorrelation, what string) error {
+	if correlation.Lists == 0 {
+		return nil
+	}
+	if err := f.writef("\nRank correlation over %d %s with 2+ common results:\n", correlation.Lists, what); err != nil {
+		return fmt.Errorf("write rank correlation header: %w", err)
+	}
+	if err := f.writef("  Mean Kendall τ: %.2f | Mean Spearman ρ: %.2f\n",
+		correlation.AvgKendallTau, correlation.AvgSpearman); err != nil {
+		return fmt.Errorf("write rank correlation means: %w", err)
+	}
+	return nil
+}
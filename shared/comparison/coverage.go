@@ -0,0 +1,82 @@
+package comparison
+
+import (
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// Coverage statuses for a query across two runs
+const (
+	CoverageBoth         = "both"
+	CoverageCurrentOnly  = "current"
+	CoveragePreviousOnly = "previous"
+)
+
+// QueryCoverage records which runs a query appears in
+type QueryCoverage struct {
+	Key       string `json:"key"`
+	Query     string `json:"query"`
+	Algorithm string `json:"algorithm"`
+	Status    string `json:"status"`
+}
+
+// Coverage describes how the query sets of two runs overlap. Queries are
+// listed in current-run order, followed by those only in the previous run.
+type Coverage struct {
+	CurrentCount  int             `json:"current_count"`
+	PreviousCount int             `json:"previous_count"`
+	Compared      int             `json:"compared"`
+	CurrentOnly   int             `json:"current_only"`
+	PreviousOnly  int             `json:"previous_only"`
+	Queries       []QueryCoverage `json:"queries"`
+}
+
+// CalculateCoverage matches the queries of two runs by key and alias.
+// Queries only in the previous run were either removed from the suite or
+// failed in the current run.
+func CalculateCoverage(current, previous []models.QueryResults) Coverage {
+	coverage := Coverage{
+		CurrentCount:  len(current),
+		PreviousCount: len(previous),
+		Queries:       make([]QueryCoverage, 0, len(current)),
+	}
+
+	previousByKey := indexByKey(previous)
+	matched := make(map[string]bool, len(previous))
+
+	for _, curr := range current {
+		qc := QueryCoverage{
+			Key:       curr.Key(),
+			Query:     curr.Query,
+			Algorithm: curr.AlgorithmLabel(),
+			Status:    CoverageCurrentOnly,
+		}
+		if prev, ok := curr.FindPrevious(previousByKey); ok {
+			qc.Status = CoverageBoth
+			matched[prev.Key()] = true
+			coverage.Compared++
+		} else {
+			coverage.CurrentOnly++
+		}
+		coverage.Queries = append(coverage.Queries, qc)
+	}
+
+	for _, prev := range previous {
+		if matched[prev.Key()] {
+			continue
+		}
+		coverage.PreviousOnly++
+		coverage.Queries = append(coverage.Queries, QueryCoverage{
+			Key:       prev.Key(),
+			Query:     prev.Query,
+			Algorithm: prev.AlgorithmLabel(),
+			Status:    CoveragePreviousOnly,
+		})
+	}
+
+	return coverage
+}
+
+// IsComplete reports whether both runs cover exactly the same queries
+func (c Coverage) IsComplete() bool {
+	return c.CurrentOnly == 0 && c.PreviousOnly == 0
+}
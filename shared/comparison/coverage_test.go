@@ -0,0 +1,47 @@
+package comparison
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestCalculateCoverage(t *testing.T) {
+	current := []models.QueryResults{
+		{Query: "gdp", Algorithm: "bm25"},
+		{Query: "retail prices index", Algorithm: "bm25", Aliases: []string{"retail price index"}},
+		{Query: "wages", Algorithm: "bm25"},
+	}
+	previous := []models.QueryResults{
+		{Query: "retail price index", Algorithm: "bm25"},
+		{Query: "unemployment", Algorithm: "bm25"},
+		{Query: "gdp", Algorithm: "bm25"},
+	}
+
+	coverage := CalculateCoverage(current, previous)
+
+	if coverage.Compared != 2 {
+		t.Errorf("expected 2 compared, got %d", coverage.Compared)
+	}
+	if coverage.CurrentOnly != 1 || coverage.PreviousOnly != 1 {
+		t.Errorf("expected 1 current-only and 1 previous-only, got %d and %d",
+			coverage.CurrentOnly, coverage.PreviousOnly)
+	}
+	if coverage.IsComplete() {
+		t.Error("expected coverage to be incomplete")
+	}
+
+	wantStatuses := []string{CoverageBoth, CoverageBoth, CoverageCurrentOnly, CoveragePreviousOnly}
+	if len(coverage.Queries) != len(wantStatuses) {
+		t.Fatalf("expected %d queries in union, got %d", len(wantStatuses), len(coverage.Queries))
+	}
+	for i, want := range wantStatuses {
+		if coverage.Queries[i].Status != want {
+			t.Errorf("query %d (%s): status %s, want %s",
+				i, coverage.Queries[i].Query, coverage.Queries[i].Status, want)
+		}
+	}
+	if coverage.Queries[3].Query != "unemployment" {
+		t.Errorf("expected unemployment to be listed last, got %s", coverage.Queries[3].Query)
+	}
+}
@@ -0,0 +1,141 @@
+package comparison
+
+import (
+	"fmt"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/metrics"
+)
+
+// DiversityChange holds a query's top-K diversity in both runs
+type DiversityChange struct {
+	Current  metrics.Diversity `json:"current"`
+	Previous metrics.Diversity `json:"previous"`
+}
+
+// DiversitySummary averages top-K diversity over all compared queries
+type DiversitySummary struct {
+	K                   int     `json:"k"`
+	Queries             int     `json:"queries"`
+	AvgContentTypes     float64 `json:"avg_content_types"`
+	PrevAvgContentTypes float64 `json:"prev_avg_content_types"`
+	AvgTopics           float64 `json:"avg_topics"`
+	PrevAvgTopics       float64 `json:"prev_avg_topics"`
+	AvgSimilarity       float64 `json:"avg_similarity"`
+	PrevAvgSimilarity   float64 `json:"prev_avg_similarity"`
+	MoreSimilarQueries  int     `json:"more_similar_queries"`
+	LessSimilarQueries  int     `json:"less_similar_queries"`
+
+	// Paired tests of per-query intra-list similarity, set when at least two
+	// queries are compared
+	SimilaritySignificance *metrics.Significance `json:"similarity_significance,omitempty"`
+}
+
+// similarityChangeCutoff is the change in intra-list similarity treated as
+// a meaningful shift towards or away from near-duplicate results
+const similarityChangeCutoff = 0.05
+
+func diversityK(options Options) int {
+	if options.DiversityK > 0 {
+		return options.DiversityK
+	}
+	return metrics.DefaultK
+}
+
+func calculateDiversityChange(curr, prev models.QueryResults, k int) DiversityChange {
+	return DiversityChange{
+		Current:  metrics.CalculateDiversity(curr.Results, k),
+		Previous: metrics.CalculateDiversity(prev.Results, k),
+	}
+}
+
+// summariseDiversity averages diversity over the queries present in both runs
+func summariseDiversity(current, previous []models.QueryResults, k int) DiversitySummary {
+	summary := DiversitySummary{K: k}
+	previousByKey := indexByKey(previous)
+	var currentSimilarity, previousSimilarity []float64
+
+	for _, curr := range current {
+		prev, ok := curr.FindPrevious(previousByKey)
+		if !ok {
+			continue
+		}
+
+		change := calculateDiversityChange(curr, prev, k)
+		summary.Queries++
+		summary.AvgContentTypes += float64(change.Current.DistinctContentTypes)
+		summary.PrevAvgContentTypes += float64(change.Previous.DistinctContentTypes)
+		summary.AvgTopics += float64(change.Current.DistinctTopics)
+		summary.PrevAvgTopics += float64(change.Previous.DistinctTopics)
+		summary.AvgSimilarity += change.Current.IntraListSimilarity
+		summary.PrevAvgSimilarity += change.Previous.IntraListSimilarity
+		currentSimilarity = append(currentSimilarity, change.Current.IntraListSimilarity)
+		previousSimilarity = append(previousSimilarity, change.Previous.IntraListSimilarity)
+
+		delta := change.Current.IntraListSimilarity - change.Previous.IntraListSimilarity
+		switch {
+		case delta > similarityChangeCutoff:
+			summary.MoreSimilarQueries++
+		case delta < -similarityChangeCutoff:
+			summary.LessSimilarQueries++
+		}
+	}
+
+	if summary.Queries > 0 {
+		n := float64(summary.Queries)
+		summary.AvgContentTypes /= n
+		summary.PrevAvgContentTypes /= n
+		summary.AvgTopics /= n
+		summary.PrevAvgTopics /= n
+		summary.AvgSimilarity /= n
+		summary.PrevAvgSimilarity /= n
+	}
+	if s, ok := metrics.TestPaired(currentSimilarity, previousSimilarity); ok {
+		summary.SimilaritySignificance = &s
+	}
+
+	return summary
+}
+
+func (f *Formatter) writeDiversity(change DiversityChange) error {
+	if err := f.writef("  Diversity@%d: Content Types: %d → %d | Topics: %d → %d | Similarity: %.2f → %.2f\n",
+		change.Current.K,
+		change.Previous.DistinctContentTypes, change.Current.DistinctContentTypes,
+		change.Previous.DistinctTopics, change.Current.DistinctTopics,
+		change.Previous.IntraListSimilarity, change.Current.IntraListSimilarity); err != nil {
+		return fmt.Errorf("write diversity: %w", err)
+	}
+	return nil
+}
+
+func (f *Formatter) writeDiversitySummary(summary DiversitySummary) error {
+	if summary.Queries == 0 {
+		return nil
+	}
+
+	if err := f.writef("\nDiversity (top %d, averaged over %d queries):\n", summary.K, summary.Queries); err != nil {
+		return fmt.Errorf("write diversity header: %w", err)
+	}
+	if err := f.writef("  Distinct content types: %.2f → %.2f\n",
+		summary.PrevAvgContentTypes, summary.AvgContentTypes); err != nil {
+		return fmt.Errorf("write diversity content types: %w", err)
+	}
+	if err := f.writef("  Distinct topics: %.2f → %.2f\n",
+		summary.PrevAvgTopics, summary.AvgTopics); err != nil {
+		return fmt.Errorf("write diversity topics: %w", err)
+	}
+	if err := f.writef("  Intra-list similarity: %.2f → %.2f\n",
+		summary.PrevAvgSimilarity, summary.AvgSimilarity); err != nil {
+		return fmt.Errorf("write diversity similarity: %w", err)
+	}
+	if s := summary.SimilaritySignificance; s != nil {
+		if err := f.writef("  Similarity significance over %d pairs: %s\n", s.Pairs, s); err != nil {
+			return fmt.Errorf("write diversity significance: %w", err)
+		}
+	}
+	if err := f.writef("  Queries with more similar results: %d | less similar: %d\n",
+		summary.MoreSimilarQueries, summary.LessSimilarQueries); err != nil {
+		return fmt.Errorf("write diversity shifts: %w", err)
+	}
+	return nil
+}
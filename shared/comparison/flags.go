@@ -0,0 +1,137 @@
+package comparison
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/metrics"
+)
+
+// FlaggedResult is a result in a query's top K carrying a flag that is
+// always reported, such as a withdrawn page
+type FlaggedResult struct {
+	Query     string   `json:"query"`
+	QueryID   string   `json:"query_id,omitempty"`
+	Algorithm string   `json:"algorithm"`
+	URI       string   `json:"uri"`
+	Rank      int      `json:"rank"`
+	Flags     []string `json:"flags"` // The reported flags the result carries
+}
+
+// AnnotateFlags sets the editorial flags of every result whose URI is
+// flagged. URIs are compared normalised, so trailing slashes and case don't
+// matter. Results are updated in place.
+func AnnotateFlags(results []models.QueryResults, flags models.EditorialFlags) {
+	if len(flags) == 0 {
+		return
+	}
+
+	byURI := make(map[string][]string, len(flags))
+	for uri, f := range flags {
+		key := NormaliseURI(uri)
+		byURI[key] = append(byURI[key], f...)
+	}
+
+	for i := range results {
+		for j := range results[i].Results {
+			r := &results[i].Results[j]
+			if f, ok := byURI[NormaliseURI(r.URI)]; ok {
+				r.Flags = f
+			}
+		}
+	}
+}
+
+// FindFlaggedResults reports every annotated result in the top k of the
+// current results that carries one of the warn flags
+func FindFlaggedResults(current []models.QueryResults, warnFlags []string, k int) []FlaggedResult {
+	if len(warnFlags) == 0 {
+		return nil
+	}
+	if k <= 0 {
+		k = metrics.DefaultK
+	}
+
+	warn := make(map[string]bool, len(warnFlags))
+	for _, f := range warnFlags {
+		warn[strings.ToLower(f)] = true
+	}
+
+	var flagged []FlaggedResult
+	for _, qr := range current {
+		for _, r := range qr.Results {
+			if r.Rank > k {
+				continue
+			}
+			var matched []string
+			for _, f := range r.Flags {
+				if warn[f] {
+					matched = append(matched, f)
+				}
+			}
+			if len(matched) == 0 {
+				continue
+			}
+			flagged = append(flagged, FlaggedResult{
+				Query:     qr.Query,
+				QueryID:   qr.QueryID,
+				Algorithm: qr.AlgorithmLabel(),
+				URI:       r.URI,
+				Rank:      r.Rank,
+				Flags:     matched,
+			})
+		}
+	}
+	return flagged
+}
+
+func flagsK(options Options) int {
+	if options.FlagsK > 0 {
+		return options.FlagsK
+	}
+	return metrics.DefaultK
+}
+
+// FlaggedResults returns the current top K results carrying a warn flag
+func (c *Comparison) FlaggedResults() []FlaggedResult {
+	return FindFlaggedResults(c.current, c.options.WarnFlags, flagsK(c.options))
+}
+
+// Describe summarises a flagged result in one line
+func (r FlaggedResult) Describe() string {
+	return fmt.Sprintf("%s (%s): %s is #%d and %s", r.Query, r.Algorithm, r.URI, r.Rank, strings.Join(r.Flags, ", "))
+}
+
+func (f *Formatter) writeFlaggedResults(flagged []FlaggedResult, k int) error {
+	if len(f.options.Flags) == 0 || len(f.options.WarnFlags) == 0 {
+		return nil
+	}
+
+	if err := f.writef("\nFlagged content (%s, top %d):\n", strings.Join(f.options.WarnFlags, ", "), k); err != nil {
+		return fmt.Errorf("write flagged content header: %w", err)
+	}
+	if len(flagged) == 0 {
+		if err := f.writef("  No flagged content in the top %d\n", k); err != nil {
+			return fmt.Errorf("write flagged content: %w", err)
+		}
+		return nil
+	}
+	for _, r := range flagged {
+		if err := f.writef("  %s %s\n", iconWarning, r.Describe()); err != nil {
+			return fmt.Errorf("write flagged result: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeFlags notes the editorial flags of a result
+func (f *Formatter) writeFlags(change RankingChange) error {
+	if len(change.Flags) == 0 {
+		return nil
+	}
+	if err := f.writef("         Flags: %s\n", strings.Join(change.Flags, ", ")); err != nil {
+		return fmt.Errorf("write flags: %w", err)
+	}
+	return nil
+}
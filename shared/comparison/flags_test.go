@@ -0,0 +1,92 @@
+package comparison
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestAnnotateFlags(t *testing.T) {
+	results := []models.QueryResults{ranked("/census", "/Old/CPI/", "/gdp")}
+	flags := models.EditorialFlags{"/old/cpi": {"withdrawn"}, "/gdp": {"national-statistic"}}
+
+	AnnotateFlags(results, flags)
+
+	var got [][]string
+	for _, r := range results[0].Results {
+		got = append(got, r.Flags)
+	}
+	want := [][]string{nil, {"withdrawn"}, {"national-statistic"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AnnotateFlags() set %v, want %v", got, want)
+	}
+}
+
+func TestFindFlaggedResults(t *testing.T) {
+	current := []models.QueryResults{ranked("/census", "/old/cpi", "/gdp", "/old/rpi")}
+	AnnotateFlags(current, models.EditorialFlags{
+		"/old/cpi": {"superseded", "withdrawn"},
+		"/gdp":     {"national-statistic"},
+		"/old/rpi": {"withdrawn"},
+	})
+
+	tests := []struct {
+		name      string
+		warnFlags []string
+		k         int
+		want      []FlaggedResult
+	}{
+		{
+			name:      "top k only",
+			warnFlags: []string{"Withdrawn"},
+			k:         3,
+			want:      []FlaggedResult{{Query: "q", Algorithm: "bm25", URI: "/old/cpi", Rank: 2, Flags: []string{"withdrawn"}}},
+		},
+		{
+			name:      "only warn flags reported",
+			warnFlags: []string{"withdrawn", "superseded"},
+			k:         4,
+			want: []FlaggedResult{
+				{Query: "q", Algorithm: "bm25", URI: "/old/cpi", Rank: 2, Flags: []string{"superseded", "withdrawn"}},
+				{Query: "q", Algorithm: "bm25", URI: "/old/rpi", Rank: 4, Flags: []string{"withdrawn"}},
+			},
+		},
+		{
+			name: "no warn flags",
+			k:    4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FindFlaggedResults(current, tt.warnFlags, tt.k)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindFlaggedResults() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatHistorical_Flags(t *testing.T) {
+	flags := models.EditorialFlags{"/old/cpi": {"withdrawn"}}
+	current := []models.QueryResults{ranked("/old/cpi", "/census")}
+	previous := []models.QueryResults{ranked("/census", "/old/cpi")}
+	AnnotateFlags(current, flags)
+	AnnotateFlags(previous, flags)
+
+	var buf bytes.Buffer
+	f := NewFormatter(&buf, Options{Flags: flags, WarnFlags: []string{"withdrawn"}, FlagsK: 5})
+	if err := f.FormatHistorical(current, previous); err != nil {
+		t.Fatalf("FormatHistorical() error = %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"Flags: withdrawn", "Flagged content (withdrawn, top 5):", "q (bm25): /old/cpi is #1 and withdrawn"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatHistorical() output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"slices"
 	"strings"
 
 	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/preview"
 )
 
 // Symbol constants for formatting output
@@ -22,8 +24,6 @@ const (
 	iconQuery1     = "🔍"
 	iconQuery2     = "🔎"
 	iconMatch      = "🎯"
-	newLabel       = "[NEW]"
-	removedLabel   = "[REMOVED]"
 	unchangedLabel = "[---]"
 	infoLabel      = "[INFO]"
 	separatorChar  = "="
@@ -50,6 +50,7 @@ type RankChangeIndicators struct {
 type Formatter struct {
 	writer  io.Writer
 	options Options
+	labels  Labels
 }
 
 // writef is a helper that handles fprintf errors
@@ -63,6 +64,7 @@ func NewFormatter(writer io.Writer, options Options) *Formatter {
 	return &Formatter{
 		writer:  writer,
 		options: options,
+		labels:  options.Labels.WithDefaults(),
 	}
 }
 
@@ -75,48 +77,95 @@ func (f *Formatter) FormatHistorical(current, previous []models.QueryResults) er
 	if err := f.writef("Generated: %s\n", current[0].RunAt.Format("2006-01-02 15:04:05")); err != nil {
 		return fmt.Errorf("write generated timestamp: %w", err)
 	}
+	if err := f.writeVerdict(CalculateVerdict(current, previous, f.options)); err != nil {
+		return err
+	}
 	if err := f.writef("%s\n\n", strings.Repeat(separatorChar, 70)); err != nil {
 		return fmt.Errorf("write separator: %w", err)
 	}
 
-	calc := NewCalculator()
+	calc := newCalculator(f.options)
+	previousByKey := indexByKey(previous)
+	stopAfter := f.options.Thresholds.StopAfter
+	regressions := 0
 
 	for i, curr := range current {
-		if i >= len(previous) {
-			if err := f.writef("\n%s Query %d exists in current but not in previous\n", infoLabel, i+1); err != nil {
-				return fmt.Errorf("write info message: %w", err)
+		if reachedStop(stopAfter, regressions) {
+			if err := f.writef("\n%s Stopped after %d regressions: detail for the remaining %d queries is left out, the summary below covers them all\n",
+				infoLabel, regressions, len(current)-i); err != nil {
+				return fmt.Errorf("write stop message: %w", err)
 			}
-			continue
+			break
 		}
 
-		prev := previous[i]
-		stats := calc.CalculateHistorical(curr, prev)
-
-		if err := f.writeQueryHeader(curr); err != nil {
-			return err
-		}
-		if err := f.writeStats(stats); err != nil {
-			return err
-		}
-		if err := f.writef("\n"); err != nil {
-			return fmt.Errorf("write newline: %w", err)
+		prev, ok := curr.FindPrevious(previousByKey)
+		if !ok {
+			if err := f.writef("\n%s Query %q (%s) exists in current but not in previous\n",
+				infoLabel, curr.Query, curr.AlgorithmLabel()); err != nil {
+				return fmt.Errorf("write info message: %w", err)
+			}
+			continue
 		}
 
-		if err := f.writeRankingChanges(curr, prev); err != nil {
+		outcome, err := f.writeHistoricalQuery(calc, curr, prev)
+		if err != nil {
 			return err
 		}
-		if err := f.writeRemovedResults(curr, prev); err != nil {
-			return err
+		if outcome == QueryFailed {
+			regressions++
 		}
 	}
 
-	if err := f.writeSummary(current, previous); err != nil {
+	coverage := CalculateCoverage(current, previous)
+	if err := f.writeCoverage(coverage); err != nil {
+		return err
+	}
+	if err := f.writeSummary(current, previous, coverage); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// writeHistoricalQuery writes the detail for a query present in both runs
+// and returns its threshold outcome
+func (f *Formatter) writeHistoricalQuery(calc *Calculator, curr, prev models.QueryResults) (string, error) {
+	stats := calc.CalculateHistorical(curr, prev)
+	outcome, reasons := checkQuery(f.options, curr, prev, stats)
+
+	if err := f.writeQueryHeader(curr); err != nil {
+		return "", err
+	}
+	if prev.Query != curr.Query {
+		if err := f.writef("Previously: %s\n\n", prev.Query); err != nil {
+			return "", fmt.Errorf("write previous query: %w", err)
+		}
+	}
+	if err := f.writeStats(stats); err != nil {
+		return "", err
+	}
+	if err := f.writeDiversity(calculateDiversityChange(curr, prev, diversityK(f.options))); err != nil {
+		return "", err
+	}
+	if err := f.writeRelevance(calculateRelevanceChange(curr, prev, f.options.Judgments, relevanceK(f.options))); err != nil {
+		return "", err
+	}
+	if err := f.writeQueryOutcome(outcome, reasons); err != nil {
+		return "", err
+	}
+	if err := f.writef("\n"); err != nil {
+		return "", fmt.Errorf("write newline: %w", err)
+	}
+
+	if err := f.writeRankingChanges(curr, prev); err != nil {
+		return "", err
+	}
+	if err := f.writeRemovedResults(curr, prev); err != nil {
+		return "", err
+	}
+	return outcome, nil
+}
+
 // FormatCrossQuery formats cross-query comparison
 func (f *Formatter) FormatCrossQuery(queries []models.QueryResults) error {
 	if len(queries) < 2 {
@@ -137,7 +186,7 @@ func (f *Formatter) FormatCrossQuery(queries []models.QueryResults) error {
 		return fmt.Errorf("write separator: %w", err)
 	}
 
-	calc := NewCalculator()
+	calc := newCalculator(f.options)
 
 	for i := 0; i < len(queries)-1; i++ {
 		for j := i + 1; j < len(queries); j++ {
@@ -162,7 +211,7 @@ func (f *Formatter) FormatCrossQuery(queries []models.QueryResults) error {
 		}
 	}
 
-	return nil
+	return f.writeCorrelationSummary(crossQueryCorrelation(calc, queries), "query pairs")
 }
 
 func (f *Formatter) writeQueryHeader(query models.QueryResults) error {
@@ -172,14 +221,24 @@ func (f *Formatter) writeQueryHeader(query models.QueryResults) error {
 	if err := f.writef("Query: %s\n", query.Query); err != nil {
 		return fmt.Errorf("write query: %w", err)
 	}
-	if err := f.writef("Algorithm: %s\n", query.Algorithm); err != nil {
+	if err := f.writef("Algorithm: %s\n", query.AlgorithmLabel()); err != nil {
 		return fmt.Errorf("write algorithm: %w", err)
 	}
+	if query.QueryID != "" {
+		if err := f.writef("ID: %s\n", query.QueryID); err != nil {
+			return fmt.Errorf("write query id: %w", err)
+		}
+	}
 	if query.Description != "" {
-		if err := f.writef("Description: %s\n", query.Description); err != nil {
+		if err := f.writef("Description: %s\n", models.SingleLine(query.Description)); err != nil {
 			return fmt.Errorf("write description: %w", err)
 		}
 	}
+	if query.Volatile {
+		if err := f.writef("Volatile: results expected to churn, not checked against thresholds\n"); err != nil {
+			return fmt.Errorf("write volatile: %w", err)
+		}
+	}
 	if err := f.writef("%s\n\n", strings.Repeat(separatorChar, 70)); err != nil {
 		return fmt.Errorf("write separator: %w", err)
 	}
@@ -193,16 +252,41 @@ func (f *Formatter) writeStats(stats models.ComparisonStats) error {
 	if err := f.writef("  Total Results: %d\n", stats.TotalResults); err != nil {
 		return fmt.Errorf("write total results: %w", err)
 	}
-	if err := f.writef("  New: %d | Removed: %d\n", stats.NewResults, stats.RemovedCount); err != nil {
+	if err := f.writef("  %s: %d | %s: %d\n",
+		f.labels.New, stats.NewResults, f.labels.Removed, stats.RemovedCount); err != nil {
 		return fmt.Errorf("write new/removed: %w", err)
 	}
-	if err := f.writef("  Improved: %d | Worsened: %d | Unchanged: %d\n",
-		stats.ImprovedCount, stats.WorsedCount, stats.UnchangedCount); err != nil {
+	if err := f.writeNewByBand("  ", stats.NewByBand, stats.NewImpact); err != nil {
+		return err
+	}
+	if err := f.writef("  %s: %d | %s: %d | %s: %d\n",
+		f.labels.Improved, stats.ImprovedCount, f.labels.Worsened, stats.WorsedCount,
+		f.labels.Unchanged, stats.UnchangedCount); err != nil {
 		return fmt.Errorf("write improved/worsened: %w", err)
 	}
 	if err := f.writef("  Avg Rank Change: %.2f positions\n", stats.AvgRankChange); err != nil {
 		return fmt.Errorf("write avg rank change: %w", err)
 	}
+	if err := f.writef("  Moved: %s\n", stats.Movement); err != nil {
+		return fmt.Errorf("write places moved: %w", err)
+	}
+	if err := f.writef("  Overlap: %.0f%% of previous results kept\n", stats.Overlap*100); err != nil {
+		return fmt.Errorf("write overlap: %w", err)
+	}
+	if err := f.writef("  Rank-Biased Overlap: %.3f\n", stats.RBO); err != nil {
+		return fmt.Errorf("write rank-biased overlap: %w", err)
+	}
+	return f.writeCorrelation(stats.KendallTau, stats.Spearman)
+}
+
+// writeNewByBand notes where new results entered, when any did
+func (f *Formatter) writeNewByBand(indent string, counts []models.NewBandCount, impact float64) error {
+	if !slices.ContainsFunc(counts, func(c models.NewBandCount) bool { return c.Count > 0 }) {
+		return nil
+	}
+	if err := f.writef("%s%s by rank: %s (impact %.2f)\n", indent, f.labels.New, formatBandCounts(counts), impact); err != nil {
+		return fmt.Errorf("write new results by rank: %w", err)
+	}
 	return nil
 }
 
@@ -218,6 +302,15 @@ type RankingChange struct {
 	PrevRank    int
 	PrevScore   float64
 	IsUnchanged bool
+	Preview     *preview.Preview
+
+	// Collapsed results stand for a group; PrevURI is the group's top
+	// document in the previous run when that has changed
+	Group     string
+	GroupSize int
+	PrevURI   string
+
+	Flags []string // Editorial flags, e.g. withdrawn
 }
 
 // RankingComparison holds detailed comparison between two ranked results
@@ -239,7 +332,7 @@ type RankingComparison struct {
 func (f *Formatter) compareRankings(r1, r2 models.SearchResult) RankingComparison {
 	comp := RankingComparison{
 		URI:             r1.URI,
-		Title:           r1.Title,
+		Title:           models.SingleLine(r1.Title),
 		R1Rank:          r1.Rank,
 		R2Rank:          r2.Rank,
 		R1Score:         r1.Score,
@@ -336,7 +429,8 @@ func abs(n int) int {
 }
 
 func (f *Formatter) writeRankingChanges(curr, prev models.QueryResults) error {
-	prevMap := makeURIMap(prev.Results)
+	matcher := matcherOrDefault(f.options.Matcher)
+	prevMap := makeResultMap(matcher, prev.Results)
 
 	displayCount := len(curr.Results)
 	if f.options.MaxRankDisplay > 0 && f.options.MaxRankDisplay < displayCount {
@@ -349,9 +443,14 @@ func (f *Formatter) writeRankingChanges(curr, prev models.QueryResults) error {
 
 	for i := 0; i < displayCount; i++ {
 		r := curr.Results[i]
-		prevResult, existed := prevMap[r.URI]
+		prevResult, existed := prevMap[matcher.Key(r)]
 
 		change := f.determineRankingChange(r, prevResult, existed)
+		if f.options.Previewer != nil {
+			if p, ok := f.options.Previewer.Preview(r.URI, curr.Query); ok {
+				change.Preview = &p
+			}
+		}
 		if err := f.writeRankingChangeRow(change); err != nil {
 			return err
 		}
@@ -364,11 +463,17 @@ func (f *Formatter) writeRankingChanges(curr, prev models.QueryResults) error {
 func (f *Formatter) determineRankingChange(curr, prev models.SearchResult, existedInPrevious bool) RankingChange {
 	change := RankingChange{
 		Rank:        curr.Rank,
-		Title:       curr.Title,
+		Title:       models.SingleLine(curr.Title),
 		URI:         curr.URI,
 		Score:       curr.Score,
 		ContentType: curr.ContentType,
 		Date:        curr.Date,
+		Group:       curr.Group,
+		GroupSize:   curr.GroupSize,
+		Flags:       curr.Flags,
+	}
+	if existedInPrevious && curr.Group != "" && prev.URI != curr.URI {
+		change.PrevURI = prev.URI
 	}
 
 	if !existedInPrevious {
@@ -400,11 +505,11 @@ func (f *Formatter) writeRankingChangeRow(change RankingChange) error {
 
 func (f *Formatter) writeNewResult(change RankingChange) error {
 	if f.options.HighlightNew {
-		if err := f.writef("%s %s #%d: %s\n", iconNew, newLabel, change.Rank, change.Title); err != nil {
+		if err := f.writef("%s %s #%d: %s\n", iconNew, tag(f.labels.New), change.Rank, change.Title); err != nil {
 			return fmt.Errorf("write new result: %w", err)
 		}
 	} else {
-		if err := f.writef("%s #%d: %s\n", newLabel, change.Rank, change.Title); err != nil {
+		if err := f.writef("%s #%d: %s\n", tag(f.labels.New), change.Rank, change.Title); err != nil {
 			return fmt.Errorf("write new result: %w", err)
 		}
 	}
@@ -416,9 +521,21 @@ func (f *Formatter) writeNewResult(change RankingChange) error {
 		}
 	}
 
-	if err := f.writef("         URI: %s\n\n", change.URI); err != nil {
+	if err := f.writef("         URI: %s\n", change.URI); err != nil {
 		return fmt.Errorf("write uri: %w", err)
 	}
+	if err := f.writeGroup(change); err != nil {
+		return err
+	}
+	if err := f.writeFlags(change); err != nil {
+		return err
+	}
+	if err := f.writePreview(change.Preview); err != nil {
+		return err
+	}
+	if err := f.writef("\n"); err != nil {
+		return fmt.Errorf("write newline: %w", err)
+	}
 
 	return nil
 }
@@ -441,6 +558,12 @@ func (f *Formatter) writeUnchangedResult(change RankingChange) error {
 			}
 		}
 	}
+	if err := f.writeGroup(change); err != nil {
+		return err
+	}
+	if err := f.writeFlags(change); err != nil {
+		return err
+	}
 
 	if err := f.writef("\n"); err != nil {
 		return fmt.Errorf("write newline: %w", err)
@@ -449,6 +572,45 @@ func (f *Formatter) writeUnchangedResult(change RankingChange) error {
 	return nil
 }
 
+// writeGroup notes the group a collapsed result stands for, and the group's
+// earlier top document when it changed
+func (f *Formatter) writeGroup(change RankingChange) error {
+	if change.Group == "" {
+		return nil
+	}
+	line := "         Group: " + change.Group
+	if change.GroupSize > 0 {
+		line += fmt.Sprintf(" (%d documents)", change.GroupSize)
+	}
+	if change.PrevURI != "" {
+		line += " | top document was " + change.PrevURI
+	}
+	if err := f.writef("%s\n", line); err != nil {
+		return fmt.Errorf("write group: %w", err)
+	}
+	return nil
+}
+
+func (f *Formatter) writePreview(p *preview.Preview) error {
+	if p == nil {
+		return nil
+	}
+
+	if err := f.writef("         Preview: %s\n", p.Snippet); err != nil {
+		return fmt.Errorf("write preview: %w", err)
+	}
+
+	hits := make([]string, len(p.Hits))
+	for i, hit := range p.Hits {
+		hits[i] = fmt.Sprintf("%s=%d", hit.Term, hit.Count)
+	}
+	if err := f.writef("         Term hits: %d (%s)\n", p.TotalHits, strings.Join(hits, ", ")); err != nil {
+		return fmt.Errorf("write term hits: %w", err)
+	}
+
+	return nil
+}
+
 func (f *Formatter) writeImprovedOrWorsenedResult(change RankingChange) error {
 	rankDiff := change.PrevRank - change.Rank
 	indicators := f.getRankChangeIndicators(rankDiff)
@@ -469,9 +631,21 @@ func (f *Formatter) writeImprovedOrWorsenedResult(change RankingChange) error {
 		}
 	}
 
-	if err := f.writef("         URI: %s\n\n", change.URI); err != nil {
+	if err := f.writef("         URI: %s\n", change.URI); err != nil {
 		return fmt.Errorf("write uri: %w", err)
 	}
+	if err := f.writeGroup(change); err != nil {
+		return err
+	}
+	if err := f.writeFlags(change); err != nil {
+		return err
+	}
+	if err := f.writePreview(change.Preview); err != nil {
+		return err
+	}
+	if err := f.writef("\n"); err != nil {
+		return fmt.Errorf("write newline: %w", err)
+	}
 
 	return nil
 }
@@ -491,7 +665,8 @@ func (f *Formatter) getRankChangeIndicators(rankDiff int) RankChangeIndicators {
 }
 
 func (f *Formatter) writeRemovedResults(curr, prev models.QueryResults) error {
-	currURIs := makeURISet(curr.Results)
+	matcher := matcherOrDefault(f.options.Matcher)
+	currKeys := makeResultSet(matcher, curr.Results)
 
 	if err := f.writef("\n--- Removed from Results ---\n"); err != nil {
 		return fmt.Errorf("write removed header: %w", err)
@@ -499,7 +674,7 @@ func (f *Formatter) writeRemovedResults(curr, prev models.QueryResults) error {
 
 	removedCount := 0
 	for _, prevResult := range prev.Results {
-		if !currURIs[prevResult.URI] {
+		if !currKeys[matcher.Key(prevResult)] {
 			if err := f.writeRemovedResult(prevResult); err != nil {
 				return err
 			}
@@ -522,7 +697,7 @@ func (f *Formatter) writeRemovedResults(curr, prev models.QueryResults) error {
 
 func (f *Formatter) writeRemovedResult(result models.SearchResult) error {
 	if err := f.writef("%s %s Was #%d: %s\n",
-		iconRemoved, removedLabel, result.Rank, result.Title); err != nil {
+		iconRemoved, tag(f.labels.Removed), result.Rank, models.SingleLine(result.Title)); err != nil {
 		return fmt.Errorf("write removed result: %w", err)
 	}
 
@@ -540,7 +715,52 @@ func (f *Formatter) writeRemovedResult(result models.SearchResult) error {
 	return nil
 }
 
-func (f *Formatter) writeSummary(current, previous []models.QueryResults) error {
+func (f *Formatter) writeCoverage(coverage Coverage) error {
+	if err := f.writef("\n%s\n", strings.Repeat(separatorChar, 70)); err != nil {
+		return fmt.Errorf("write separator: %w", err)
+	}
+	if err := f.writef("Query Coverage\n"); err != nil {
+		return fmt.Errorf("write coverage header: %w", err)
+	}
+	if err := f.writef("%s\n\n", strings.Repeat(separatorChar, 70)); err != nil {
+		return fmt.Errorf("write separator: %w", err)
+	}
+
+	if err := f.writef("Queries in current run: %d\n", coverage.CurrentCount); err != nil {
+		return fmt.Errorf("write current count: %w", err)
+	}
+	if err := f.writef("Queries in previous run: %d\n", coverage.PreviousCount); err != nil {
+		return fmt.Errorf("write previous count: %w", err)
+	}
+	if err := f.writef("In both runs (compared): %d\n", coverage.Compared); err != nil {
+		return fmt.Errorf("write compared count: %w", err)
+	}
+	if err := f.writef("Only in current run (added): %d\n", coverage.CurrentOnly); err != nil {
+		return fmt.Errorf("write current only count: %w", err)
+	}
+	if err := f.writef("Only in previous run (removed or failed): %d\n\n", coverage.PreviousOnly); err != nil {
+		return fmt.Errorf("write previous only count: %w", err)
+	}
+
+	for _, q := range coverage.Queries {
+		var label string
+		switch q.Status {
+		case CoverageBoth:
+			label = "[BOTH]    "
+		case CoverageCurrentOnly:
+			label = "[CURRENT] "
+		default:
+			label = "[PREVIOUS]"
+		}
+		if err := f.writef("  %s %s (%s)\n", label, q.Query, q.Algorithm); err != nil {
+			return fmt.Errorf("write coverage row: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (f *Formatter) writeSummary(current, previous []models.QueryResults, coverage Coverage) error {
 	if err := f.writef("\n%s\n", strings.Repeat(separatorChar, 70)); err != nil {
 		return fmt.Errorf("write separator: %w", err)
 	}
@@ -551,40 +771,105 @@ func (f *Formatter) writeSummary(current, previous []models.QueryResults) error
 		return fmt.Errorf("write separator: %w", err)
 	}
 
-	calc := NewCalculator()
-	totalNew := 0
-	totalRemoved := 0
-	totalImproved := 0
-	totalWorsened := 0
+	calc := newCalculator(f.options)
+	totals := sumHistorical(calc, current, previous)
+	if err := f.writeTotals(totals, coverage); err != nil {
+		return err
+	}
 
-	for i, curr := range current {
-		if i >= len(previous) {
+	if err := f.writeCorrelationSummary(totals.correlation, "queries"); err != nil {
+		return err
+	}
+
+	if err := f.writeRelevanceSummary(summariseRelevance(current, previous, f.options.Judgments, relevanceK(f.options))); err != nil {
+		return err
+	}
+
+	if err := f.writeIntervals(calculateIntervals(calc, current, previous, f.options)); err != nil {
+		return err
+	}
+
+	k := watchlistK(f.options)
+	if err := f.writeWatchlistDrops(FindWatchlistDrops(current, previous, f.options.Watchlist, k), k); err != nil {
+		return err
+	}
+
+	fk := flagsK(f.options)
+	if err := f.writeFlaggedResults(FindFlaggedResults(current, f.options.WarnFlags, fk), fk); err != nil {
+		return err
+	}
+
+	if err := f.writeDiversitySummary(summariseDiversity(current, previous, diversityK(f.options))); err != nil {
+		return err
+	}
+
+	if err := f.writeVisibility(CalculateVisibility(current, previous, visibilityK(f.options))); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// historicalTotals sums the changes of every query present in both runs
+type historicalTotals struct {
+	new, removed, improved, worsened int
+	movement                         models.MovementHistogram
+	newByBand                        []models.NewBandCount
+	newImpact                        float64
+	correlation                      RankCorrelation
+}
+
+func sumHistorical(calc *Calculator, current, previous []models.QueryResults) historicalTotals {
+	var totals historicalTotals
+	previousByKey := indexByKey(previous)
+
+	for _, curr := range current {
+		prev, ok := curr.FindPrevious(previousByKey)
+		if !ok {
 			continue
 		}
 
-		stats := calc.CalculateHistorical(curr, previous[i])
-		totalNew += stats.NewResults
-		totalRemoved += stats.RemovedCount
-		totalImproved += stats.ImprovedCount
-		totalWorsened += stats.WorsedCount
-	}
+		stats := calc.CalculateHistorical(curr, prev)
+		totals.new += stats.NewResults
+		totals.removed += stats.RemovedCount
+		totals.improved += stats.ImprovedCount
+		totals.worsened += stats.WorsedCount
+		totals.movement.Merge(stats.Movement)
+		totals.newByBand = mergeBandCounts(totals.newByBand, stats.NewByBand)
+		totals.newImpact += stats.NewImpact
+		totals.correlation.add(stats.KendallTau, stats.Spearman)
+	}
+	totals.correlation.finish()
+	return totals
+}
 
-	if err := f.writef("Total queries compared: %d\n", len(current)); err != nil {
+func (f *Formatter) writeTotals(totals historicalTotals, coverage Coverage) error {
+	if err := f.writef("Total queries compared: %d\n", coverage.Compared); err != nil {
 		return fmt.Errorf("write total queries: %w", err)
 	}
-	if err := f.writef("Total new results: %d\n", totalNew); err != nil {
+	if !coverage.IsComplete() {
+		if err := f.writef("(Totals cover only the queries present in both runs; see Query Coverage above)\n"); err != nil {
+			return fmt.Errorf("write coverage note: %w", err)
+		}
+	}
+	if err := f.writef("%s: %d\n", f.labels.TotalNew, totals.new); err != nil {
 		return fmt.Errorf("write total new: %w", err)
 	}
-	if err := f.writef("Total removed results: %d\n", totalRemoved); err != nil {
+	if err := f.writeNewByBand("", totals.newByBand, totals.newImpact); err != nil {
+		return err
+	}
+	if err := f.writef("%s: %d\n", f.labels.TotalRemoved, totals.removed); err != nil {
 		return fmt.Errorf("write total removed: %w", err)
 	}
-	if err := f.writef("Total improved rankings: %d\n", totalImproved); err != nil {
+	if err := f.writef("%s: %d\n", f.labels.TotalImproved, totals.improved); err != nil {
 		return fmt.Errorf("write total improved: %w", err)
 	}
-	if err := f.writef("Total worsened rankings: %d\n", totalWorsened); err != nil {
+	if err := f.writef("%s: %d\n", f.labels.TotalWorsened, totals.worsened); err != nil {
 		return fmt.Errorf("write total worsened: %w", err)
 	}
-
+	if err := f.writef("Results moved: %s\n", totals.movement); err != nil {
+		return fmt.Errorf("write places moved: %w", err)
+	}
 	return nil
 }
 
@@ -598,10 +883,10 @@ func (f *Formatter) writeCrossQueryHeader(q1, q2 models.QueryResults) error {
 	if err := f.writef("%s\n", strings.Repeat(separatorChar, 70)); err != nil {
 		return fmt.Errorf("write separator: %w", err)
 	}
-	if err := f.writef("%s Query 1: %s (%s)\n", iconQuery1, q1.Query, q1.Algorithm); err != nil {
+	if err := f.writef("%s Query 1: %s (%s)\n", iconQuery1, q1.Query, q1.AlgorithmLabel()); err != nil {
 		return fmt.Errorf("write query1: %w", err)
 	}
-	if err := f.writef("%s Query 2: %s (%s)\n", iconQuery2, q2.Query, q2.Algorithm); err != nil {
+	if err := f.writef("%s Query 2: %s (%s)\n", iconQuery2, q2.Query, q2.AlgorithmLabel()); err != nil {
 		return fmt.Errorf("write query2: %w", err)
 	}
 	if err := f.writef("%s\n\n", strings.Repeat(dashChar, 70)); err != nil {
@@ -631,12 +916,16 @@ func (f *Formatter) writeCrossQueryStats(stats CrossQueryStats) error {
 			return fmt.Errorf("write avg ranking difference: %w", err)
 		}
 	}
-	return nil
+	if err := f.writef("  Rank-Biased Overlap: %.3f\n", stats.RBO); err != nil {
+		return fmt.Errorf("write rank-biased overlap: %w", err)
+	}
+	return f.writeCorrelation(stats.KendallTau, stats.Spearman)
 }
 
 func (f *Formatter) writeCrossQueryResults(q1, q2 models.QueryResults) error {
-	q1Map := makeURIMap(q1.Results)
-	q2Map := makeURIMap(q2.Results)
+	matcher := matcherOrDefault(f.options.Matcher)
+	q1Map := makeResultMap(matcher, q1.Results)
+	q2Map := makeResultMap(matcher, q2.Results)
 
 	displayCount := len(q1.Results)
 	if f.options.MaxRankDisplay > 0 && f.options.MaxRankDisplay < displayCount {
@@ -666,7 +955,7 @@ func (f *Formatter) writeOnlyInQuery1Results(q1 models.QueryResults, q2Map map[s
 	onlyInQ1 := 0
 	for i := 0; i < displayCount && i < len(q1.Results); i++ {
 		r := q1.Results[i]
-		if _, exists := q2Map[r.URI]; !exists {
+		if _, exists := q2Map[f.matchKey(r)]; !exists {
 			if err := f.writeCrossQueryResultQ1(r); err != nil {
 				return err
 			}
@@ -695,7 +984,7 @@ func (f *Formatter) writeOnlyInQuery2Results(q2 models.QueryResults, q1Map map[s
 	onlyInQ2 := 0
 	for i := 0; i < displayCount && i < len(q2.Results); i++ {
 		r := q2.Results[i]
-		if _, exists := q1Map[r.URI]; !exists {
+		if _, exists := q1Map[f.matchKey(r)]; !exists {
 			if err := f.writeCrossQueryResultQ2(r); err != nil {
 				return err
 			}
@@ -724,7 +1013,7 @@ func (f *Formatter) writeCrossQueryRankingDifferences(q1 models.QueryResults, q2
 	hasDifferences := false
 	for i := 0; i < displayCount && i < len(q1.Results); i++ {
 		r1 := q1.Results[i]
-		r2, exists := q2Map[r1.URI]
+		r2, exists := q2Map[f.matchKey(r1)]
 		if !exists || r1.Rank == r2.Rank {
 			continue
 		}
@@ -749,7 +1038,7 @@ func (f *Formatter) writeCrossQueryRankingDifferences(q1 models.QueryResults, q2
 }
 
 func (f *Formatter) writeCrossQueryResultQ1(r models.SearchResult) error {
-	if err := f.writef("%s #%d: %s\n", iconQuery1, r.Rank, r.Title); err != nil {
+	if err := f.writef("%s #%d: %s\n", iconQuery1, r.Rank, models.SingleLine(r.Title)); err != nil {
 		return fmt.Errorf("write result: %w", err)
 	}
 	if f.options.ShowScores {
@@ -764,7 +1053,7 @@ func (f *Formatter) writeCrossQueryResultQ1(r models.SearchResult) error {
 }
 
 func (f *Formatter) writeCrossQueryResultQ2(r models.SearchResult) error {
-	if err := f.writef("%s #%d: %s\n", iconQuery2, r.Rank, r.Title); err != nil {
+	if err := f.writef("%s #%d: %s\n", iconQuery2, r.Rank, models.SingleLine(r.Title)); err != nil {
 		return fmt.Errorf("write result: %w", err)
 	}
 	if f.options.ShowScores {
@@ -800,7 +1089,7 @@ func (f *Formatter) writeCrossQueryRankingDifference(r1, r2 models.SearchResult)
 	}
 
 	if err := f.writef("%s [%s%d] %s - %s %s\n",
-		indicators.Symbol, indicators.Arrow, change, r1.Title, statusIcon, comp.Winner); err != nil {
+		indicators.Symbol, indicators.Arrow, change, comp.Title, statusIcon, comp.Winner); err != nil {
 		return fmt.Errorf("write ranking diff: %w", err)
 	}
 
@@ -830,18 +1119,8 @@ func (f *Formatter) writeCrossQueryRankingDifference(r1, r2 models.SearchResult)
 }
 
 // Helper functions
-func makeURIMap(results []models.SearchResult) map[string]models.SearchResult {
-	m := make(map[string]models.SearchResult, len(results))
-	for _, r := range results {
-		m[r.URI] = r
-	}
-	return m
-}
 
-func makeURISet(results []models.SearchResult) map[string]bool {
-	m := make(map[string]bool, len(results))
-	for _, r := range results {
-		m[r.URI] = true
-	}
-	return m
+// matchKey returns the key used to pair r with results from another list
+func (f *Formatter) matchKey(r models.SearchResult) string {
+	return matcherOrDefault(f.options.Matcher).Key(r)
 }
@@ -0,0 +1,159 @@
+package comparison
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// Gate metrics, measured over the whole historical comparison. Result
+// counts leave out volatile queries, which are expected to churn.
+const (
+	GateRemoved        = "removed"         // Results that left the compared queries
+	GateWorsened       = "worsened"        // Results ranked lower than before
+	GateNew            = "new"             // Results that entered the compared queries
+	GateNewTop         = "new-top"         // New results in the first position band
+	GateNewImpact      = "new-impact"      // New results weighted by position band
+	GateRegressions    = "regressions"     // Queries beyond the per-query thresholds
+	GateNDCGDrop       = "ndcg-drop"       // Fall in mean NDCG over judged queries
+	GateWatchlistDrops = "watchlist-drops" // Watchlist documents that left a top K
+	GateFlagged        = "flagged"         // Top K results carrying a warn flag
+	GateMissing        = "missing"         // Queries in the previous run but not the current one
+)
+
+// gateMetrics reads each gate metric from a summary
+var gateMetrics = map[string]func(Summary) float64{
+	GateRemoved:        func(s Summary) float64 { return float64(s.Gated.RemovedResults) },
+	GateWorsened:       func(s Summary) float64 { return float64(s.Gated.WorsenedRankings) },
+	GateNew:            func(s Summary) float64 { return float64(s.Gated.NewResults) },
+	GateNewTop:         func(s Summary) float64 { return float64(s.Gated.NewInTopBand) },
+	GateNewImpact:      func(s Summary) float64 { return s.Gated.NewImpact },
+	GateRegressions:    func(s Summary) float64 { return float64(len(s.Verdict.Regressions)) },
+	GateNDCGDrop:       func(s Summary) float64 { return s.Relevance.PrevAvgNDCG - s.Relevance.AvgNDCG },
+	GateWatchlistDrops: func(s Summary) float64 { return float64(s.Gated.WatchlistDrops) },
+	GateFlagged:        func(s Summary) float64 { return float64(s.FlaggedResults) },
+	GateMissing:        func(s Summary) float64 { return float64(s.Coverage.PreviousOnly) },
+}
+
+// GateTotals are the result counts of the queries gate rules check
+type GateTotals struct {
+	NewResults       int `json:"new_results"`
+	RemovedResults   int `json:"removed_results"`
+	WorsenedRankings int `json:"worsened_rankings"`
+
+	// New results in the first position band, and weighted by band
+	NewInTopBand int     `json:"new_in_top_band"`
+	NewImpact    float64 `json:"new_impact"`
+
+	WatchlistDrops int `json:"watchlist_drops"`
+}
+
+// add counts one query's results
+func (t *GateTotals) add(stats models.ComparisonStats) {
+	t.NewResults += stats.NewResults
+	t.RemovedResults += stats.RemovedCount
+	t.WorsenedRankings += stats.WorsedCount
+	if len(stats.NewByBand) > 0 {
+		t.NewInTopBand += stats.NewByBand[0].Count
+	}
+	t.NewImpact += stats.NewImpact
+}
+
+// GateRule fails the gate when a metric goes over (or reaches, with >=) a
+// limit
+type GateRule struct {
+	Metric    string
+	Limit     float64
+	Inclusive bool // >= rather than >
+}
+
+// String gives the rule in the form it is parsed from
+func (r GateRule) String() string {
+	op := ">"
+	if r.Inclusive {
+		op = ">="
+	}
+	return r.Metric + op + strconv.FormatFloat(r.Limit, 'f', -1, 64)
+}
+
+// Gate is a set of rules that decide whether a comparison fails, e.g. so CI
+// can stop on a search regression
+type Gate []GateRule
+
+// GateMetrics lists the metrics a gate rule can check
+func GateMetrics() []string {
+	names := make([]string, 0, len(gateMetrics))
+	for name := range gateMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseGate parses comma-separated rules such as
+// "removed>5,worsened>10,ndcg-drop>0.05". An empty spec gives an empty gate,
+// which never fails.
+func ParseGate(spec string) (Gate, error) {
+	var gate Gate
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		i := strings.Index(part, ">")
+		if i < 0 {
+			return nil, fmt.Errorf("rule %q: want metric>limit or metric>=limit", part)
+		}
+		rule := GateRule{Metric: strings.TrimSpace(part[:i])}
+		limit := part[i+1:]
+		if strings.HasPrefix(limit, "=") {
+			rule.Inclusive = true
+			limit = limit[1:]
+		}
+
+		if _, ok := gateMetrics[rule.Metric]; !ok {
+			return nil, fmt.Errorf("rule %q: unknown metric %q (supported: %s)",
+				part, rule.Metric, strings.Join(GateMetrics(), ", "))
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(limit), 64)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: invalid limit: %w", part, err)
+		}
+		rule.Limit = value
+		gate = append(gate, rule)
+	}
+	return gate, nil
+}
+
+// Uses reports whether any rule checks the metric
+func (g Gate) Uses(metric string) bool {
+	for _, r := range g {
+		if r.Metric == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns a description of each rule the summary breaks. ndcg-drop
+// rules are skipped when no compared query has judgments.
+func (g Gate) Check(s Summary) []string {
+	var failures []string
+	for _, r := range g {
+		if r.Metric == GateNDCGDrop && s.Relevance.Queries == 0 {
+			continue
+		}
+		value := gateMetrics[r.Metric](s)
+		if value > r.Limit || (r.Inclusive && value == r.Limit) {
+			// Round away float noise such as 0.8-0.7 = 0.10000000000000009
+			failures = append(failures, fmt.Sprintf("%s is %s (rule %s)",
+				r.Metric, strconv.FormatFloat(math.Round(value*1e4)/1e4, 'f', -1, 64), r))
+		}
+	}
+	return failures
+}
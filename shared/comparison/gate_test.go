@@ -0,0 +1,114 @@
+package comparison
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestParseGate(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Gate
+		wantErr bool
+	}{
+		{spec: "", want: nil},
+		{
+			spec: "removed>5, worsened>=10,ndcg-drop>0.05",
+			want: Gate{
+				{Metric: GateRemoved, Limit: 5},
+				{Metric: GateWorsened, Limit: 10, Inclusive: true},
+				{Metric: GateNDCGDrop, Limit: 0.05},
+			},
+		},
+		{spec: "removed", wantErr: true},
+		{spec: "dropped>5", wantErr: true},
+		{spec: "removed>lots", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseGate(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseGate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseGate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGate_Check(t *testing.T) {
+	gate, err := ParseGate("removed>5,worsened>=10,ndcg-drop>0.05")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		summary Summary
+		want    []string
+	}{
+		{
+			name:    "within limits",
+			summary: Summary{Gated: GateTotals{RemovedResults: 5, WorsenedRankings: 9}},
+		},
+		{
+			name:    "limits broken",
+			summary: Summary{Gated: GateTotals{RemovedResults: 6, WorsenedRankings: 10}},
+			want:    []string{"removed is 6 (rule removed>5)", "worsened is 10 (rule worsened>=10)"},
+		},
+		{
+			name:    "NDCG drop",
+			summary: Summary{Relevance: RelevanceSummary{Queries: 3, PrevAvgNDCG: 0.8, AvgNDCG: 0.7}},
+			want:    []string{"ndcg-drop is 0.1 (rule ndcg-drop>0.05)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gate.Check(tt.summary); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGate_CheckSkipsVolatile(t *testing.T) {
+	results := func(query string, volatile bool, uris ...string) models.QueryResults {
+		qr := models.QueryResults{Query: query, Volatile: volatile}
+		for i, uri := range uris {
+			qr.Results = append(qr.Results, models.SearchResult{Rank: i + 1, URI: uri})
+		}
+		return qr
+	}
+	previous := []models.QueryResults{
+		results("inflation", false, "/cpi", "/rpi"),
+		results("latest releases", true, "/a", "/b", "/c"),
+	}
+	current := []models.QueryResults{
+		results("inflation", false, "/cpi", "/rpi"),
+		results("latest releases", true, "/d", "/e", "/f"),
+	}
+
+	gate, err := ParseGate("removed>0,new>0,worsened>0,new-top>0,new-impact>0,watchlist-drops>0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := Options{Watchlist: models.Watchlist{"/a"}}
+	summary := NewComparison(current, previous, options, ModeHistorical).GetSummary()
+	if summary.RemovedResults != 3 {
+		t.Errorf("RemovedResults = %d, want the volatile query's churn counted", summary.RemovedResults)
+	}
+	if summary.WatchlistDrops != 1 {
+		t.Errorf("WatchlistDrops = %d, want the volatile query's drop counted", summary.WatchlistDrops)
+	}
+	if summary.NewInTopBand() != 3 {
+		t.Errorf("NewInTopBand() = %d, want the volatile query's churn counted", summary.NewInTopBand())
+	}
+	if got := gate.Check(summary); got != nil {
+		t.Errorf("Check() = %q, want the volatile query's churn ignored", got)
+	}
+}
@@ -0,0 +1,82 @@
+package comparison
+
+import "math"
+
+// Heatmap is a run's churn on one grid: a row per query and a column per
+// current rank, each cell holding the result shown at that rank
+type Heatmap struct {
+	Ranks int          `json:"ranks"` // Columns; deeper results are left out
+	Rows  []HeatmapRow `json:"rows"`
+}
+
+// HeatmapRow is one query's results by current rank
+type HeatmapRow struct {
+	Slug      string        `json:"slug"`
+	Query     string        `json:"query"`
+	Algorithm string        `json:"algorithm"`
+	Cells     []HeatmapCell `json:"cells"`   // One per rank; cells past the last result are empty
+	Removed   int           `json:"removed"` // Previous results no longer returned
+}
+
+// HeatmapCell is the result at one rank and how far it moved
+type HeatmapCell struct {
+	URI        string `json:"uri,omitempty"`
+	Title      string `json:"title,omitempty"`
+	Status     string `json:"status,omitempty"` // Empty when the query returned fewer results
+	PrevRank   int    `json:"prev_rank,omitempty"`
+	RankChange int    `json:"rank_change"`
+}
+
+// minMagnitude keeps a move of one rank visibly coloured
+const minMagnitude = 0.2
+
+// Magnitude scales how far the result moved to between 0 and 1, reaching 1
+// at a move across every column. New results are always 1.
+func (c HeatmapCell) Magnitude(ranks int) float64 {
+	switch {
+	case c.Status == StatusNew:
+		return 1
+	case c.RankChange == 0 || ranks <= 1:
+		return 0
+	}
+	m := math.Abs(float64(c.RankChange)) / float64(ranks-1)
+	return math.Min(1, math.Max(minMagnitude, m))
+}
+
+// BuildHeatmap lays out the movements of each query by current rank, with
+// a column for every rank down to the deepest result of any query. maxRanks
+// caps the columns when it is above 0.
+func BuildHeatmap(queries []QueryComparison, maxRanks int) Heatmap {
+	ranks := 0
+	for _, q := range queries {
+		for _, m := range q.Movements {
+			ranks = max(ranks, m.Rank)
+		}
+	}
+	if maxRanks > 0 {
+		ranks = min(ranks, maxRanks)
+	}
+
+	heatmap := Heatmap{Ranks: ranks, Rows: make([]HeatmapRow, 0, len(queries))}
+	for _, q := range queries {
+		row := HeatmapRow{Slug: q.Slug, Query: q.Query, Algorithm: q.Algorithm, Cells: make([]HeatmapCell, ranks)}
+		for _, m := range q.Movements {
+			if m.Status == StatusRemoved {
+				row.Removed++
+				continue
+			}
+			if m.Rank < 1 || m.Rank > ranks {
+				continue
+			}
+			row.Cells[m.Rank-1] = HeatmapCell{
+				URI:        m.URI,
+				Title:      m.Title,
+				Status:     m.Status,
+				PrevRank:   m.PrevRank,
+				RankChange: m.RankChange,
+			}
+		}
+		heatmap.Rows = append(heatmap.Rows, row)
+	}
+	return heatmap
+}
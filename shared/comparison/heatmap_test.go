@@ -0,0 +1,81 @@
+package comparison
+
+import "testing"
+
+func TestBuildHeatmap(t *testing.T) {
+	queries := []QueryComparison{
+		{
+			Slug: "bm25-inflation", Query: "inflation", Algorithm: "bm25",
+			Movements: []Movement{
+				{URI: "/rpi", Status: StatusImproved, Rank: 1, PrevRank: 3, RankChange: 2},
+				{URI: "/cpi", Status: StatusNew, Rank: 2},
+				{URI: "/hpi", Status: StatusWorsened, Rank: 4, PrevRank: 1, RankChange: -3},
+				{URI: "/old", Status: StatusRemoved, PrevRank: 2},
+			},
+		},
+		{
+			Slug: "bm25-gdp", Query: "gdp", Algorithm: "bm25",
+			Movements: []Movement{
+				{URI: "/gdp", Status: StatusUnchanged, Rank: 1, PrevRank: 1},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		maxRanks  int
+		wantRanks int
+	}{
+		{name: "deepest rank", maxRanks: 0, wantRanks: 4},
+		{name: "capped", maxRanks: 2, wantRanks: 2},
+		{name: "cap above the deepest rank", maxRanks: 20, wantRanks: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			heatmap := BuildHeatmap(queries, tt.maxRanks)
+			if heatmap.Ranks != tt.wantRanks {
+				t.Fatalf("Ranks = %d, want %d", heatmap.Ranks, tt.wantRanks)
+			}
+			if len(heatmap.Rows) != 2 {
+				t.Fatalf("got %d rows, want 2", len(heatmap.Rows))
+			}
+			for _, row := range heatmap.Rows {
+				if len(row.Cells) != tt.wantRanks {
+					t.Errorf("%s has %d cells, want %d", row.Query, len(row.Cells), tt.wantRanks)
+				}
+			}
+
+			inflation := heatmap.Rows[0]
+			if inflation.Removed != 1 {
+				t.Errorf("Removed = %d, want 1", inflation.Removed)
+			}
+			if got := inflation.Cells[1].Status; got != StatusNew {
+				t.Errorf("rank 2 status = %q, want new", got)
+			}
+			if got := heatmap.Rows[1].Cells[1].Status; got != "" {
+				t.Errorf("gdp rank 2 status = %q, want an empty cell", got)
+			}
+		})
+	}
+}
+
+func TestHeatmapCell_Magnitude(t *testing.T) {
+	tests := []struct {
+		name string
+		cell HeatmapCell
+		want float64
+	}{
+		{name: "new", cell: HeatmapCell{Status: StatusNew}, want: 1},
+		{name: "unchanged", cell: HeatmapCell{Status: StatusUnchanged}, want: 0},
+		{name: "small move", cell: HeatmapCell{Status: StatusImproved, RankChange: 1}, want: minMagnitude},
+		{name: "half way", cell: HeatmapCell{Status: StatusWorsened, RankChange: -5}, want: 0.5},
+		{name: "whole list", cell: HeatmapCell{Status: StatusImproved, RankChange: 10}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cell.Magnitude(11); got != tt.want {
+				t.Errorf("Magnitude(11) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package comparison
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// htmlStyle lays out the report and the heatmap grid
+const htmlStyle = `body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; }
+td, th { padding: 2px 6px; text-align: left; }
+.summary td:last-child { text-align: right; }
+.heatmap td.cell { width: 18px; height: 18px; padding: 0; border: 1px solid #fff; }
+.heatmap th.rank { font-weight: normal; font-size: 0.8em; text-align: center; }
+.heatmap td.removed { text-align: right; }
+.legend span { display: inline-block; padding: 2px 8px; margin-right: 4px; }
+`
+
+// Heatmap cell colours, as RGB
+var (
+	colourImproved = [3]int{26, 152, 80}
+	colourWorsened = [3]int{215, 48, 39}
+	colourNew      = [3]int{69, 117, 180}
+	colourSame     = "#eeeeee"
+	colourEmpty    = "#ffffff"
+)
+
+// renderHTML renders a standalone HTML page. The historical report has the
+// verdict, summary and a rank heatmap of the whole run; the others are the
+// text report in a pre block.
+func (c *Comparison) renderHTML() ([]byte, error) {
+	var buf bytes.Buffer
+	title := "Search comparison: " + c.modeString()
+	fmt.Fprintf(&buf, "<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>\n%s</style>\n</head>\n<body>\n<h1>%s</h1>\n",
+		html.EscapeString(title), htmlStyle, html.EscapeString(title))
+
+	if c.mode != ModeHistorical {
+		report, err := c.Generate()
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&buf, "<pre>%s</pre>\n</body>\n</html>\n", html.EscapeString(strings.TrimRight(report, "\n")))
+		return buf.Bytes(), nil
+	}
+
+	if len(c.previous) == 0 {
+		return nil, fmt.Errorf("no previous results to compare against")
+	}
+
+	labels := c.options.Labels.WithDefaults()
+	summary := c.GetSummary()
+	fmt.Fprintf(&buf, "<p><strong>%s</strong></p>\n", html.EscapeString(summary.Verdict.Badge()))
+	writeHTMLSummary(&buf, summary, labels)
+	writeHTMLHeatmap(&buf, BuildHeatmap(c.QueryComparisons(), c.options.MaxRankDisplay), labels)
+
+	buf.WriteString("</body>\n</html>\n")
+	return buf.Bytes(), nil
+}
+
+func writeHTMLSummary(buf *bytes.Buffer, summary Summary, labels Labels) {
+	buf.WriteString("<table class=\"summary\">\n")
+	row := func(label string, value int) {
+		fmt.Fprintf(buf, "<tr><td>%s</td><td>%d</td></tr>\n", html.EscapeString(label), value)
+	}
+	row("Queries compared", summary.QueriesCompared)
+	row(labels.TotalNew, summary.NewResults)
+	row(labels.TotalRemoved, summary.RemovedResults)
+	row(labels.TotalImproved, summary.ImprovedRankings)
+	row(labels.TotalWorsened, summary.WorsenedRankings)
+	buf.WriteString("</table>\n")
+}
+
+// writeHTMLHeatmap writes the heatmap grid, a row per query and a column per
+// rank, with each cell coloured by how far its result moved
+func writeHTMLHeatmap(buf *bytes.Buffer, heatmap Heatmap, labels Labels) {
+	buf.WriteString("<h2>Rank heatmap</h2>\n")
+	fmt.Fprintf(buf, "<p class=\"legend\"><span style=\"background:%s\">%s</span><span style=\"background:%s\">%s</span><span style=\"background:%s\">%s</span><span style=\"background:%s\">Unchanged</span></p>\n",
+		rgba(colourImproved, 1), html.EscapeString(labels.Improved),
+		rgba(colourWorsened, 1), html.EscapeString(labels.Worsened),
+		rgba(colourNew, 1), html.EscapeString(labels.New),
+		colourSame)
+	if len(heatmap.Rows) == 0 {
+		buf.WriteString("<p>No queries to compare.</p>\n")
+		return
+	}
+
+	buf.WriteString("<table class=\"heatmap\">\n<tr><th>Query</th><th>Algorithm</th>")
+	for rank := 1; rank <= heatmap.Ranks; rank++ {
+		fmt.Fprintf(buf, "<th class=\"rank\">%d</th>", rank)
+	}
+	fmt.Fprintf(buf, "<th>%s</th></tr>\n", html.EscapeString(labels.Removed))
+
+	for _, row := range heatmap.Rows {
+		fmt.Fprintf(buf, "<tr id=\"%s\"><td>%s</td><td>%s</td>", html.EscapeString(row.Slug),
+			html.EscapeString(models.SingleLine(row.Query)), html.EscapeString(row.Algorithm))
+		for rank, cell := range row.Cells {
+			fmt.Fprintf(buf, "<td class=\"cell\" style=\"background:%s\" title=\"%s\"></td>",
+				cellColour(cell, heatmap.Ranks), html.EscapeString(cellTitle(cell, rank+1)))
+		}
+		fmt.Fprintf(buf, "<td class=\"removed\">%d</td></tr>\n", row.Removed)
+	}
+	buf.WriteString("</table>\n")
+}
+
+func cellColour(cell HeatmapCell, ranks int) string {
+	switch {
+	case cell.Status == "":
+		return colourEmpty
+	case cell.Status == StatusNew:
+		return rgba(colourNew, 1)
+	case cell.RankChange > 0:
+		return rgba(colourImproved, cell.Magnitude(ranks))
+	case cell.RankChange < 0:
+		return rgba(colourWorsened, cell.Magnitude(ranks))
+	default:
+		return colourSame
+	}
+}
+
+// cellTitle describes a cell's result for its tooltip
+func cellTitle(cell HeatmapCell, rank int) string {
+	switch cell.Status {
+	case "":
+		return fmt.Sprintf("#%d: no result", rank)
+	case StatusNew:
+		return fmt.Sprintf("#%d %s: new", rank, cell.URI)
+	default:
+		return fmt.Sprintf("#%d %s: was #%d", rank, cell.URI, cell.PrevRank)
+	}
+}
+
+func rgba(rgb [3]int, alpha float64) string {
+	return fmt.Sprintf("rgba(%d,%d,%d,%.2f)", rgb[0], rgb[1], rgb[2], alpha)
+}
@@ -0,0 +1,96 @@
+package comparison
+
+import (
+	"fmt"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/metrics"
+)
+
+// bootstrapSeed fixes the resampling so the same runs always give the same
+// intervals
+const bootstrapSeed = 1
+
+// Intervals holds bootstrap confidence intervals, resampled over queries,
+// for the per-query means in a historical summary
+type Intervals struct {
+	Queries       int               `json:"queries"`
+	Resamples     int               `json:"resamples"`
+	Level         float64           `json:"level"`
+	AvgRankChange metrics.Interval  `json:"avg_rank_change"`
+	Overlap       metrics.Interval  `json:"overlap"`
+	NDCG          *metrics.Interval `json:"ndcg,omitempty"` // Set when at least two queries are judged
+}
+
+func bootstrapResamples(options Options) int {
+	if options.BootstrapResamples > 0 {
+		return options.BootstrapResamples
+	}
+	return metrics.DefaultResamples
+}
+
+// calculateIntervals bootstraps the mean average rank change, overlap and
+// NDCG over the queries present in both runs. It returns nil when fewer
+// than two queries are compared.
+func calculateIntervals(calc *Calculator, current, previous []models.QueryResults, options Options) *Intervals {
+	var rankChanges, overlaps, currentNDCG []float64
+	previousByKey := indexByKey(previous)
+	k := relevanceK(options)
+
+	for _, curr := range current {
+		prev, ok := curr.FindPrevious(previousByKey)
+		if !ok {
+			continue
+		}
+		stats := calc.CalculateHistorical(curr, prev)
+		rankChanges = append(rankChanges, stats.AvgRankChange)
+		overlaps = append(overlaps, stats.Overlap)
+		if change := calculateRelevanceChange(curr, prev, options.Judgments, k); change.Judged {
+			currentNDCG = append(currentNDCG, change.Current)
+		}
+	}
+
+	resamples := bootstrapResamples(options)
+	rankChange, ok := metrics.BootstrapMean(rankChanges, resamples, bootstrapSeed)
+	if !ok {
+		return nil
+	}
+	overlap, _ := metrics.BootstrapMean(overlaps, resamples, bootstrapSeed)
+
+	intervals := &Intervals{
+		Queries:       len(rankChanges),
+		Resamples:     resamples,
+		Level:         metrics.ConfidenceLevel,
+		AvgRankChange: rankChange,
+		Overlap:       overlap,
+	}
+	if ndcg, ok := metrics.BootstrapMean(currentNDCG, resamples, bootstrapSeed); ok {
+		intervals.NDCG = &ndcg
+	}
+	return intervals
+}
+
+func (f *Formatter) writeIntervals(intervals *Intervals) error {
+	if intervals == nil {
+		return nil
+	}
+
+	if err := f.writef("\nConfidence intervals (%.0f%%, %d bootstrap resamples over %d queries):\n",
+		intervals.Level*100, intervals.Resamples, intervals.Queries); err != nil {
+		return fmt.Errorf("write intervals header: %w", err)
+	}
+	r := intervals.AvgRankChange
+	if err := f.writef("  Avg rank change: %.2f [%.2f, %.2f]\n", r.Mean, r.Lower, r.Upper); err != nil {
+		return fmt.Errorf("write rank change interval: %w", err)
+	}
+	o := intervals.Overlap
+	if err := f.writef("  Overlap: %.0f%% [%.0f%%, %.0f%%]\n", o.Mean*100, o.Lower*100, o.Upper*100); err != nil {
+		return fmt.Errorf("write overlap interval: %w", err)
+	}
+	if n := intervals.NDCG; n != nil {
+		if err := f.writef("  NDCG@%d: %.3f [%.3f, %.3f]\n", relevanceK(f.options), n.Mean, n.Lower, n.Upper); err != nil {
+			return fmt.Errorf("write NDCG interval: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package comparison
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestCalculateIntervals(t *testing.T) {
+	results := func(uris ...string) []models.SearchResult {
+		out := make([]models.SearchResult, len(uris))
+		for i, uri := range uris {
+			out[i] = models.SearchResult{Rank: i + 1, URI: uri}
+		}
+		return out
+	}
+
+	previous := []models.QueryResults{
+		{Query: "cpi", Results: results("/a", "/b")},
+		{Query: "gdp", Results: results("/c", "/d")},
+		{Query: "jobs", Results: results("/e", "/f")},
+	}
+	current := []models.QueryResults{
+		{Query: "cpi", Results: results("/a", "/b")},
+		{Query: "gdp", Results: results("/d", "/x")},
+		{Query: "jobs", Results: results("/e", "/f")},
+	}
+	options := Options{Judgments: models.Judgments{"cpi": {"/a": 1}, "gdp": {"/c": 1}}, BootstrapResamples: 200}
+
+	intervals := calculateIntervals(newCalculator(options), current, previous, options)
+	if intervals == nil {
+		t.Fatal("calculateIntervals() = nil, want intervals over 3 queries")
+	}
+	if intervals.Queries != 3 || intervals.Resamples != 200 {
+		t.Errorf("intervals = %+v, want 3 queries and 200 resamples", intervals)
+	}
+	if o := intervals.Overlap; o.Mean != 5.0/6 || o.Lower < 0.5 || o.Upper != 1 {
+		t.Errorf("Overlap = %+v, want mean 5/6 within [0.5, 1]", o)
+	}
+	if n := intervals.NDCG; n == nil || n.Mean != 0.5 || n.Lower != 0 || n.Upper != 1 {
+		t.Errorf("NDCG = %+v, want mean 0.5 within [0, 1]", n)
+	}
+
+	if got := calculateIntervals(newCalculator(options), current[:1], previous, options); got != nil {
+		t.Errorf("calculateIntervals() over one query = %+v, want nil", got)
+	}
+
+	report, err := NewComparison(current, previous, options, ModeHistorical).Generate()
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	for _, want := range []string{"Confidence intervals (95%, 200 bootstrap resamples over 3 queries)", "  Overlap: 83% ["} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package comparison
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// HistoricalReport is the JSON form of the historical report
+type HistoricalReport struct {
+	Mode           string            `json:"mode"`
+	Generated      time.Time         `json:"generated"`
+	Summary        Summary           `json:"summary"`
+	Queries        []QueryComparison `json:"queries"`
+	WatchlistDrops []WatchlistDrop   `json:"watchlist_drops,omitempty"`
+	FlaggedResults []FlaggedResult   `json:"flagged_results,omitempty"`
+}
+
+// CrossQueryReport is the JSON form of the cross-query report
+type CrossQueryReport struct {
+	Mode        string          `json:"mode"`
+	Generated   time.Time       `json:"generated"`
+	Correlation RankCorrelation `json:"correlation"`
+	Pairs       []QueryPair     `json:"pairs"`
+}
+
+// ScoreDriftReport is the JSON form of the score drift report
+type ScoreDriftReport struct {
+	Mode      string     `json:"mode"`
+	Generated time.Time  `json:"generated"`
+	Drift     ScoreDrift `json:"drift"`
+}
+
+// QueryPair is the cross-query comparison of two result lists from one run
+type QueryPair struct {
+	Query1     string          `json:"query1"`
+	Algorithm1 string          `json:"algorithm1"`
+	Query2     string          `json:"query2"`
+	Algorithm2 string          `json:"algorithm2"`
+	Stats      CrossQueryStats `json:"stats"`
+	Results    []PairedResult  `json:"results"`
+}
+
+// PairedResult is one result's rank in each list of a pair; a rank of 0
+// means the result is not in that list
+type PairedResult struct {
+	URI      string `json:"uri"`
+	Title    string `json:"title"`
+	Rank1    int    `json:"rank1,omitempty"`
+	Rank2    int    `json:"rank2,omitempty"`
+	RankDiff int    `json:"rank_diff"` // Rank1 - Rank2 when in both lists
+}
+
+// QueryPairs compares every pair of result lists in the current run, in
+// the order the cross-query report gives them
+func (c *Comparison) QueryPairs() []QueryPair {
+	calc := newCalculator(c.options)
+	matcher := matcherOrDefault(c.options.Matcher)
+
+	var pairs []QueryPair
+	for i := 0; i < len(c.current)-1; i++ {
+		for j := i + 1; j < len(c.current); j++ {
+			q1, q2 := c.current[i], c.current[j]
+			pairs = append(pairs, QueryPair{
+				Query1:     q1.Query,
+				Algorithm1: q1.AlgorithmLabel(),
+				Query2:     q2.Query,
+				Algorithm2: q2.AlgorithmLabel(),
+				Stats:      calc.CalculateCrossQuery(q1, q2),
+				Results:    pairResults(matcher, q1.Results, q2.Results),
+			})
+		}
+	}
+	return pairs
+}
+
+// pairResults lists the results of the first list with their rank in the
+// second, followed by the results only in the second
+func pairResults(matcher Matcher, r1, r2 []models.SearchResult) []PairedResult {
+	second := makeResultMap(matcher, r2)
+	first := makeResultSet(matcher, r1)
+
+	paired := make([]PairedResult, 0, len(r1))
+	for _, r := range r1 {
+		p := PairedResult{URI: r.URI, Title: r.Title, Rank1: r.Rank}
+		if other, ok := second[matcher.Key(r)]; ok {
+			p.Rank2 = other.Rank
+			p.RankDiff = r.Rank - other.Rank
+		}
+		paired = append(paired, p)
+	}
+	for _, r := range r2 {
+		if !first[matcher.Key(r)] {
+			paired = append(paired, PairedResult{URI: r.URI, Title: r.Title, Rank2: r.Rank})
+		}
+	}
+	return paired
+}
+
+// renderJSON renders the report as indented JSON for dashboards and
+// notebooks
+func (c *Comparison) renderJSON() ([]byte, error) {
+	var generated time.Time
+	if len(c.current) > 0 {
+		generated = c.current[0].RunAt
+	}
+
+	var report interface{}
+	switch c.mode {
+	case ModeHistorical:
+		if len(c.previous) == 0 {
+			return nil, fmt.Errorf("no previous results to compare against")
+		}
+		report = HistoricalReport{
+			Mode:           "historical",
+			Generated:      generated,
+			Summary:        c.GetSummary(),
+			Queries:        c.QueryComparisons(),
+			WatchlistDrops: c.WatchlistDrops(),
+			FlaggedResults: c.FlaggedResults(),
+		}
+	case ModeCrossQuery:
+		report = CrossQueryReport{
+			Mode:        "cross-query",
+			Generated:   generated,
+			Correlation: crossQueryCorrelation(newCalculator(c.options), c.current),
+			Pairs:       c.QueryPairs(),
+		}
+	case ModeScoreDrift:
+		if len(c.previous) == 0 {
+			return nil, fmt.Errorf("no previous results to compare against")
+		}
+		report = ScoreDriftReport{Mode: "score-drift", Generated: generated, Drift: c.ScoreDrift()}
+	default:
+		return nil, fmt.Errorf("no JSON report for comparison mode %s", c.modeString())
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal report: %w", err)
+	}
+	return append(data, '\n'), nil
+}
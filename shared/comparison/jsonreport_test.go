@@ -0,0 +1,42 @@
+package comparison
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestRender_CrossQueryJSON(t *testing.T) {
+	cpi, inflation := ranked("/a", "/b", "/c"), ranked("/b", "/a", "/d")
+	cpi.Query, inflation.Query = "cpi", "inflation"
+	current := []models.QueryResults{cpi, inflation}
+
+	data, err := NewComparison(current, nil, Options{}, ModeCrossQuery).Render(FormatJSON)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	var report CrossQueryReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("report is not valid JSON: %v", err)
+	}
+	if report.Mode != "cross-query" || len(report.Pairs) != 1 {
+		t.Fatalf("report = %+v, want one cross-query pair", report)
+	}
+
+	pair := report.Pairs[0]
+	if pair.Stats.CommonResults != 2 || pair.Stats.OnlyInQuery1 != 1 || pair.Stats.OnlyInQuery2 != 1 {
+		t.Errorf("stats = %+v, want 2 common and 1 only in each", pair.Stats)
+	}
+	want := []PairedResult{
+		{URI: "/a", Rank1: 1, Rank2: 2, RankDiff: -1},
+		{URI: "/b", Rank1: 2, Rank2: 1, RankDiff: 1},
+		{URI: "/c", Rank1: 3},
+		{URI: "/d", Rank2: 3},
+	}
+	if !reflect.DeepEqual(pair.Results, want) {
+		t.Errorf("results = %+v, want %+v", pair.Results, want)
+	}
+}
@@ -0,0 +1,83 @@
+package comparison
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
+)
+
+// junitReportName names the JUnit report as a whole
+const junitReportName = "search-testbed"
+
+// renderJUnit gives the historical comparison as JUnit XML, one test suite
+// per algorithm and one test case per query. A query fails when it breaks
+// the regression thresholds; new and volatile queries are skipped. Other
+// modes have no pass or fail outcome, so give an empty report.
+func (c *Comparison) renderJUnit() ([]byte, error) {
+	var suites []output.JUnitSuite
+	if c.mode == ModeHistorical {
+		if len(c.previous) == 0 {
+			return nil, fmt.Errorf("no previous results to compare against")
+		}
+		suites = c.junitSuites()
+	}
+
+	var buf bytes.Buffer
+	if err := output.WriteJUnit(&buf, junitReportName, suites); err != nil {
+		return nil, fmt.Errorf("write JUnit report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *Comparison) junitSuites() []output.JUnitSuite {
+	calc := newCalculator(c.options)
+	previousByKey := indexByKey(c.previous)
+
+	var suites []output.JUnitSuite
+	suiteIndex := make(map[string]int)
+	for _, curr := range c.current {
+		algorithm := curr.AlgorithmLabel()
+		i, ok := suiteIndex[algorithm]
+		if !ok {
+			i = len(suites)
+			suiteIndex[algorithm] = i
+			suites = append(suites, output.JUnitSuite{Name: algorithm, Timestamp: curr.RunAt})
+		}
+
+		tc := output.JUnitCase{
+			Name:      curr.Query,
+			ClassName: algorithm,
+			Time:      time.Duration(curr.TookMs) * time.Millisecond,
+		}
+		prev, found := curr.FindPrevious(previousByKey)
+		switch {
+		case !found:
+			tc.Skipped = "new query, no previous results"
+		case curr.Volatile:
+			tc.Skipped = "volatile query, not checked"
+		default:
+			stats := calc.CalculateHistorical(curr, prev)
+			if outcome, reasons := checkQuery(c.options, curr, prev, stats); outcome == QueryFailed {
+				tc.Failure = &output.JUnitFailure{
+					Message: strings.Join(reasons, ", "),
+					Type:    "regression",
+					Detail:  junitDetail(curr, stats),
+				}
+			}
+		}
+		suites[i].Cases = append(suites[i].Cases, tc)
+	}
+	return suites
+}
+
+// junitDetail describes a regressed query's changes for the failure body
+func junitDetail(curr models.QueryResults, stats models.ComparisonStats) string {
+	return fmt.Sprintf("Query %q (%s) regressed against the previous run:\n"+
+		"new results: %d\nremoved results: %d\nimproved rankings: %d\nworsened rankings: %d\navg rank change: %.2f\n",
+		curr.Query, curr.AlgorithmLabel(), stats.NewResults, stats.RemovedCount,
+		stats.ImprovedCount, stats.WorsedCount, stats.AvgRankChange)
+}
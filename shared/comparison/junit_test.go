@@ -0,0 +1,63 @@
+package comparison
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestRender_JUnit(t *testing.T) {
+	stable, regressed, volatile, fresh := ranked("/a", "/b"), ranked("/x", "/y"), ranked("/v"), ranked("/n")
+	stable.Query, regressed.Query, volatile.Query, fresh.Query = "cpi", "gdp", "news", "new"
+	volatile.Volatile = true
+	prevRegressed, prevVolatile := ranked("/a", "/b"), ranked("/w")
+	prevRegressed.Query, prevVolatile.Query = "gdp", "news"
+	prevStable := stable
+
+	current := []models.QueryResults{stable, regressed, volatile, fresh}
+	previous := []models.QueryResults{prevStable, prevRegressed, prevVolatile}
+	opts := Options{Thresholds: Thresholds{MaxRemovedResults: 1}}
+
+	data, err := NewComparison(current, previous, opts, ModeHistorical).Render(FormatJUnit)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	var report struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Skipped  int `xml:"skipped,attr"`
+		Suites   []struct {
+			Name  string `xml:"name,attr"`
+			Cases []struct {
+				Name    string `xml:"name,attr"`
+				Failure *struct {
+					Message string `xml:"message,attr"`
+				} `xml:"failure"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal(data, &report); err != nil {
+		t.Fatalf("report is not valid XML: %v", err)
+	}
+	if report.Tests != 4 || report.Failures != 1 || report.Skipped != 2 {
+		t.Errorf("tests = %d, failures = %d, skipped = %d, want 4, 1 and 2",
+			report.Tests, report.Failures, report.Skipped)
+	}
+	if len(report.Suites) != 1 || report.Suites[0].Name != "bm25" {
+		t.Fatalf("suites = %+v, want one bm25 suite", report.Suites)
+	}
+	failed := report.Suites[0].Cases[1]
+	if failed.Name != "gdp" || failed.Failure == nil || failed.Failure.Message != "2 removed results (max 1)" {
+		t.Errorf("second case = %+v, want gdp failing on removed results", failed)
+	}
+
+	data, err = NewComparison(current, nil, opts, ModeCrossQuery).Render(FormatJUnit)
+	if err != nil {
+		t.Fatalf("Render() cross-query error = %v", err)
+	}
+	if err := xml.Unmarshal(data, &report); err != nil || report.Tests != 0 {
+		t.Errorf("cross-query report has %d tests (err %v), want an empty report", report.Tests, err)
+	}
+}
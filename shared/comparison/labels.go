@@ -0,0 +1,59 @@
+package comparison
+
+import "strings"
+
+// Labels are the terms used in historical reports, so reports can follow
+// house terminology or be translated. Empty fields fall back to the defaults.
+type Labels struct {
+	New           string
+	Removed       string
+	Improved      string
+	Worsened      string
+	Unchanged     string
+	TotalNew      string
+	TotalRemoved  string
+	TotalImproved string
+	TotalWorsened string
+}
+
+// DefaultLabels returns the standard English report terms
+func DefaultLabels() Labels {
+	return Labels{
+		New:           "New",
+		Removed:       "Removed",
+		Improved:      "Improved",
+		Worsened:      "Worsened",
+		Unchanged:     "Unchanged",
+		TotalNew:      "Total new results",
+		TotalRemoved:  "Total removed results",
+		TotalImproved: "Total improved rankings",
+		TotalWorsened: "Total worsened rankings",
+	}
+}
+
+// WithDefaults fills any empty label with its default
+func (l Labels) WithDefaults() Labels {
+	d := DefaultLabels()
+	fill := func(v *string, def string) {
+		if strings.TrimSpace(*v) == "" {
+			*v = def
+		}
+	}
+
+	fill(&l.New, d.New)
+	fill(&l.Removed, d.Removed)
+	fill(&l.Improved, d.Improved)
+	fill(&l.Worsened, d.Worsened)
+	fill(&l.Unchanged, d.Unchanged)
+	fill(&l.TotalNew, d.TotalNew)
+	fill(&l.TotalRemoved, d.TotalRemoved)
+	fill(&l.TotalImproved, d.TotalImproved)
+	fill(&l.TotalWorsened, d.TotalWorsened)
+
+	return l
+}
+
+// tag formats a label as the bracketed marker shown against a result
+func tag(label string) string {
+	return "[" + strings.ToUpper(label) + "]"
+}
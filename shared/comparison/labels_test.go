@@ -0,0 +1,52 @@
+package comparison
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestLabels_WithDefaults(t *testing.T) {
+	got := Labels{New: "Newydd", Worsened: "  "}.WithDefaults()
+
+	if got.New != "Newydd" {
+		t.Errorf("expected override kept, got %q", got.New)
+	}
+	if got.Worsened != "Worsened" || got.TotalNew != "Total new results" {
+		t.Errorf("expected defaults for unset labels, got %+v", got)
+	}
+}
+
+func TestFormatHistorical_Labels(t *testing.T) {
+	previous := []models.QueryResults{
+		{Query: "inflation", Algorithm: "bm25", Results: []models.SearchResult{{Rank: 1, URI: "/a", Title: "A"}}},
+	}
+	current := []models.QueryResults{
+		{Query: "inflation", Algorithm: "bm25", Results: []models.SearchResult{{Rank: 1, URI: "/b", Title: "B"}}},
+	}
+
+	var buf bytes.Buffer
+	f := NewFormatter(&buf, Options{
+		HighlightNew: true,
+		Labels:       Labels{New: "Newydd", Removed: "Wedi'u dileu", TotalNew: "Cyfanswm canlyniadau newydd"},
+	})
+	if err := f.FormatHistorical(current, previous); err != nil {
+		t.Fatalf("format failed: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"  Newydd: 1 | Wedi'u dileu: 1\n",
+		"  Improved: 0 | Worsened: 0 | Unchanged: 0\n",
+		"[NEWYDD] #1: B",
+		"[WEDI'U DILEU] Was #1: A",
+		"Cyfanswm canlyniadau newydd: 1\n",
+		"Total removed results: 1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected report to contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package comparison
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// DefaultMarkdownRows caps the rows of each table and the items of each
+// list in Markdown reports when no limit is configured
+const DefaultMarkdownRows = 20
+
+func markdownRows(options Options) int {
+	if options.MarkdownRows > 0 {
+		return options.MarkdownRows
+	}
+	return DefaultMarkdownRows
+}
+
+// renderMarkdown renders the report as GitHub-flavoured Markdown, short
+// enough to paste into a pull request comment. Only the historical report
+// has a Markdown layout; the others are the text report in a code block.
+func (c *Comparison) renderMarkdown() ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "## Search comparison: %s\n\n", c.modeString())
+
+	if c.mode != ModeHistorical {
+		report, err := c.Generate()
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&buf, "```text\n%s\n```\n", strings.TrimRight(report, "\n"))
+		return buf.Bytes(), nil
+	}
+
+	if len(c.previous) == 0 {
+		return nil, fmt.Errorf("no previous results to compare against")
+	}
+
+	labels := c.options.Labels.WithDefaults()
+	rows := markdownRows(c.options)
+	summary := c.GetSummary()
+
+	writeMarkdownVerdict(&buf, summary.Verdict, rows)
+	writeMarkdownSummary(&buf, summary, labels)
+
+	queries := c.QueryComparisons()
+	writeMarkdownQueries(&buf, queries, labels, rows)
+	writeMarkdownDetails(&buf, queries, labels, rows)
+
+	return buf.Bytes(), nil
+}
+
+func writeMarkdownVerdict(buf *bytes.Buffer, verdict Verdict, rows int) {
+	fmt.Fprintf(buf, "**%s**\n\n", verdict.Badge())
+	if len(verdict.Regressions) == 0 {
+		return
+	}
+	for i, r := range verdict.Regressions {
+		if i == rows {
+			fmt.Fprintf(buf, "- _…and %d more_\n", len(verdict.Regressions)-rows)
+			break
+		}
+		fmt.Fprintf(buf, "- **%s** (%s): %s\n", mdText(r.Query), mdText(r.Algorithm), strings.Join(r.Reasons, ", "))
+	}
+	buf.WriteString("\n")
+}
+
+func writeMarkdownSummary(buf *bytes.Buffer, summary Summary, labels Labels) {
+	buf.WriteString("| Summary | |\n|---|---:|\n")
+	fmt.Fprintf(buf, "| Queries compared | %d |\n", summary.QueriesCompared)
+	fmt.Fprintf(buf, "| %s | %d |\n", mdCell(labels.TotalNew), summary.NewResults)
+	fmt.Fprintf(buf, "| %s | %d |\n", mdCell(labels.TotalRemoved), summary.RemovedResults)
+	fmt.Fprintf(buf, "| %s | %d |\n", mdCell(labels.TotalImproved), summary.ImprovedRankings)
+	fmt.Fprintf(buf, "| %s | %d |\n", mdCell(labels.TotalWorsened), summary.WorsenedRankings)
+	if m := summary.Movement; m.Total() > 0 {
+		fmt.Fprintf(buf, "| Moved 1 / 2-3 / 4-10 / over 10 places | %d / %d / %d / %d |\n",
+			m.One, m.TwoThree, m.FourToTen, m.OverTen)
+	}
+	if i := summary.Intervals; i != nil {
+		fmt.Fprintf(buf, "| Avg rank change (%.0f%% CI) | %.2f [%.2f, %.2f] |\n",
+			i.Level*100, i.AvgRankChange.Mean, i.AvgRankChange.Lower, i.AvgRankChange.Upper)
+		fmt.Fprintf(buf, "| Overlap (%.0f%% CI) | %.0f%% [%.0f%%, %.0f%%] |\n",
+			i.Level*100, i.Overlap.Mean*100, i.Overlap.Lower*100, i.Overlap.Upper*100)
+	}
+	if r := summary.Relevance; r.Queries > 0 {
+		fmt.Fprintf(buf, "| Mean NDCG@%d | %.3f → %.3f |\n", r.K, r.PrevAvgNDCG, r.AvgNDCG)
+		if i := summary.Intervals; i != nil && i.NDCG != nil {
+			fmt.Fprintf(buf, "| NDCG@%d (%.0f%% CI) | %.3f [%.3f, %.3f] |\n",
+				r.K, i.Level*100, i.NDCG.Mean, i.NDCG.Lower, i.NDCG.Upper)
+		}
+		if s := r.Significance; s != nil {
+			fmt.Fprintf(buf, "| NDCG significance | %s |\n", mdCell(s.String()))
+		}
+	}
+	if c := summary.Correlation; c.Lists > 0 {
+		fmt.Fprintf(buf, "| Mean Kendall τ / Spearman ρ | %.2f / %.2f |\n", c.AvgKendallTau, c.AvgSpearman)
+	}
+	if summary.WatchlistDrops > 0 {
+		fmt.Fprintf(buf, "| Watchlist drops | %d |\n", summary.WatchlistDrops)
+	}
+	if summary.FlaggedResults > 0 {
+		fmt.Fprintf(buf, "| Flagged results | %d |\n", summary.FlaggedResults)
+	}
+	buf.WriteString("\n")
+
+	if cov := summary.Coverage; !cov.IsComplete() {
+		fmt.Fprintf(buf, "%s Query sets differ: %d only in the current run, %d only in the previous run\n\n",
+			iconWarning, cov.CurrentOnly, cov.PreviousOnly)
+	}
+}
+
+// writeMarkdownQueries writes a table of the queries whose results changed,
+// most removed and worsened first
+func writeMarkdownQueries(buf *bytes.Buffer, queries []QueryComparison, labels Labels, rows int) {
+	var changed []QueryComparison
+	for _, q := range queries {
+		s := q.Stats
+		if s.NewResults+s.RemovedCount+s.ImprovedCount+s.WorsedCount > 0 {
+			changed = append(changed, q)
+		}
+	}
+
+	buf.WriteString("### Changed queries\n\n")
+	if len(changed) == 0 {
+		buf.WriteString("No results changed.\n")
+		return
+	}
+
+	sort.SliceStable(changed, func(i, j int) bool {
+		return changed[i].Stats.RemovedCount+changed[i].Stats.WorsedCount >
+			changed[j].Stats.RemovedCount+changed[j].Stats.WorsedCount
+	})
+
+	fmt.Fprintf(buf, "| Query | Algorithm | %s | %s | %s | %s | Avg rank change | RBO |\n",
+		mdCell(labels.New), mdCell(labels.Removed), mdCell(labels.Improved), mdCell(labels.Worsened))
+	buf.WriteString("|---|---|---:|---:|---:|---:|---:|---:|\n")
+	for i, q := range changed {
+		if i == rows {
+			break
+		}
+		s := q.Stats
+		fmt.Fprintf(buf, "| %s | %s | %d | %d | %d | %d | %.2f | %.3f |\n",
+			mdCell(q.Query), mdCell(q.Algorithm), s.NewResults, s.RemovedCount, s.ImprovedCount, s.WorsedCount,
+			s.AvgRankChange, s.RBO)
+	}
+	if len(changed) > rows {
+		fmt.Fprintf(buf, "\n_…and %d more changed queries_\n", len(changed)-rows)
+	}
+}
+
+// writeMarkdownDetails writes a collapsed list of new and removed results
+// for each query that gained or lost any
+func writeMarkdownDetails(buf *bytes.Buffer, queries []QueryComparison, labels Labels, rows int) {
+	shown := 0
+	for _, q := range queries {
+		if q.Stats.NewResults+q.Stats.RemovedCount == 0 {
+			continue
+		}
+		if shown == rows {
+			break
+		}
+		shown++
+
+		var added, removed []Movement
+		for _, m := range q.Movements {
+			switch m.Status {
+			case StatusNew:
+				added = append(added, m)
+			case StatusRemoved:
+				removed = append(removed, m)
+			}
+		}
+
+		fmt.Fprintf(buf, "\n<details>\n<summary><b>%s</b> (%s): %d %s, %d %s</summary>\n\n",
+			mdHTML(q.Query), mdHTML(q.Algorithm), len(added), strings.ToLower(labels.New),
+			len(removed), strings.ToLower(labels.Removed))
+		writeMarkdownMovements(buf, labels.New, added, rows, func(m Movement) string {
+			return fmt.Sprintf("#%d", m.Rank)
+		})
+		writeMarkdownMovements(buf, labels.Removed, removed, rows, func(m Movement) string {
+			return fmt.Sprintf("was #%d", m.PrevRank)
+		})
+		buf.WriteString("</details>\n")
+	}
+}
+
+func writeMarkdownMovements(buf *bytes.Buffer, label string, movements []Movement, rows int, rank func(Movement) string) {
+	if len(movements) == 0 {
+		return
+	}
+	fmt.Fprintf(buf, "**%s**\n", mdText(label))
+	for i, m := range movements {
+		if i == rows {
+			fmt.Fprintf(buf, "- _…and %d more_\n", len(movements)-rows)
+			break
+		}
+		fmt.Fprintf(buf, "- %s %s `%s`\n", rank(m), mdText(m.Title), m.URI)
+	}
+	buf.WriteString("\n")
+}
+
+// mdEscaper escapes characters Markdown would read as formatting or HTML
+var mdEscaper = strings.NewReplacer("*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", "&lt;", ">", "&gt;")
+
+// mdText flattens text onto one line and escapes it for Markdown
+func mdText(s string) string {
+	return mdEscaper.Replace(models.SingleLine(s))
+}
+
+// mdCell escapes text for a table cell
+func mdCell(s string) string {
+	return strings.ReplaceAll(mdText(s), "|", `\|`)
+}
+
+// mdHTML escapes text inside an HTML element
+func mdHTML(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(models.SingleLine(s))
+}
@@ -0,0 +1,141 @@
+package comparison
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// Matcher names
+const (
+	MatchURI           = "uri"
+	MatchID            = "id"
+	MatchNormalisedURI = "normalised_uri"
+)
+
+// Matcher decides when results from two lists are the same document.
+// Results with equal keys are treated as the same document.
+type Matcher interface {
+	Key(r models.SearchResult) string
+}
+
+// MatcherFunc adapts a function to the Matcher interface
+type MatcherFunc func(r models.SearchResult) string
+
+// Key calls f(r)
+func (f MatcherFunc) Key(r models.SearchResult) string {
+	return f(r)
+}
+
+// URIMatcher matches results by their exact URI
+type URIMatcher struct{}
+
+// Key returns the result's URI
+func (URIMatcher) Key(r models.SearchResult) string {
+	return r.URI
+}
+
+// IDMatcher matches results by Elasticsearch document ID, falling back to
+// URI for results stored before IDs were recorded
+type IDMatcher struct{}
+
+// Key returns the result's document ID, or its URI if it has none
+func (IDMatcher) Key(r models.SearchResult) string {
+	if r.ID == "" {
+		return "uri:" + r.URI
+	}
+	return "id:" + r.ID
+}
+
+// NormalisedURIMatcher matches results by URI ignoring case, scheme, host,
+// query string, fragment and trailing slashes
+type NormalisedURIMatcher struct{}
+
+// Key returns the normalised URI
+func (NormalisedURIMatcher) Key(r models.SearchResult) string {
+	return NormaliseURI(r.URI)
+}
+
+// NormaliseURI reduces a URI to a lower-case path without a trailing slash
+func NormaliseURI(uri string) string {
+	path := strings.TrimSpace(uri)
+	if u, err := url.Parse(path); err == nil {
+		path = u.Path
+	}
+	path = strings.ToLower(strings.TrimRight(path, "/"))
+	if path == "" {
+		return "/"
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
+
+// NewMatcher returns the matcher with the given name. An empty name
+// selects URI matching.
+func NewMatcher(name string) (Matcher, error) {
+	switch name {
+	case "", MatchURI:
+		return GroupAware(URIMatcher{}), nil
+	case MatchID:
+		return GroupAware(IDMatcher{}), nil
+	case MatchNormalisedURI:
+		return GroupAware(NormalisedURIMatcher{}), nil
+	default:
+		return nil, fmt.Errorf("unknown matcher %q (expected %s, %s or %s)",
+			name, MatchURI, MatchID, MatchNormalisedURI)
+	}
+}
+
+// groupMatcher matches collapsed results by the group they stand for, so a
+// group whose top document changed between runs is still the same result
+type groupMatcher struct {
+	Matcher
+}
+
+// Key returns the result's group, or the wrapped matcher's key for results
+// of queries that aren't collapsed
+func (g groupMatcher) Key(r models.SearchResult) string {
+	if r.Group != "" {
+		return "group:" + r.Group
+	}
+	return g.Matcher.Key(r)
+}
+
+// GroupAware wraps m so collapsed results match by their group
+func GroupAware(m Matcher) Matcher {
+	if _, ok := m.(groupMatcher); ok {
+		return m
+	}
+	return groupMatcher{Matcher: m}
+}
+
+// matcherOrDefault returns m, or URI matching if m is nil, matching
+// collapsed results by group either way
+func matcherOrDefault(m Matcher) Matcher {
+	if m == nil {
+		return GroupAware(URIMatcher{})
+	}
+	return GroupAware(m)
+}
+
+// makeResultMap indexes results by their match key
+func makeResultMap(m Matcher, results []models.SearchResult) map[string]models.SearchResult {
+	out := make(map[string]models.SearchResult, len(results))
+	for _, r := range results {
+		out[m.Key(r)] = r
+	}
+	return out
+}
+
+// makeResultSet returns the set of match keys in results
+func makeResultSet(m Matcher, results []models.SearchResult) map[string]bool {
+	out := make(map[string]bool, len(results))
+	for _, r := range results {
+		out[m.Key(r)] = true
+	}
+	return out
+}
@@ -0,0 +1,99 @@
+package comparison
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestNormaliseURI(t *testing.T) {
+	tests := map[string]string{
+		"/economy/inflation":                       "/economy/inflation",
+		"/Economy/Inflation/":                      "/economy/inflation",
+		"https://www.ons.gov.uk/economy/inflation": "/economy/inflation",
+		"/economy/inflation?page=2#section":        "/economy/inflation",
+		"economy/inflation":                        "/economy/inflation",
+		"":                                         "/",
+		"https://www.ons.gov.uk":                   "/",
+	}
+
+	for uri, want := range tests {
+		if got := NormaliseURI(uri); got != want {
+			t.Errorf("NormaliseURI(%q) = %q, want %q", uri, got, want)
+		}
+	}
+}
+
+func TestCalculator_Matchers(t *testing.T) {
+	prev := models.QueryResults{Results: []models.SearchResult{
+		{ID: "1", Rank: 1, URI: "/economy/inflation/"},
+		{ID: "2", Rank: 2, URI: "/economy/gdp"},
+	}}
+	curr := models.QueryResults{Results: []models.SearchResult{
+		{ID: "2", Rank: 1, URI: "/economy/GDP"},
+		{ID: "1", Rank: 2, URI: "/economy/inflation"},
+	}}
+
+	tests := []struct {
+		name        string
+		matcher     Matcher
+		wantNew     int
+		wantMoved   int
+		wantRemoved int
+	}{
+		{name: "nil defaults to uri", matcher: nil, wantNew: 2, wantRemoved: 2},
+		{name: "uri", matcher: URIMatcher{}, wantNew: 2, wantRemoved: 2},
+		{name: "normalised uri", matcher: NormalisedURIMatcher{}, wantMoved: 2},
+		{name: "id", matcher: IDMatcher{}, wantMoved: 2},
+		{
+			name:        "custom func",
+			matcher:     MatcherFunc(func(r models.SearchResult) string { return strings.ToLower(r.URI) }),
+			wantNew:     1,
+			wantMoved:   1,
+			wantRemoved: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := NewCalculator(tt.matcher).CalculateHistorical(curr, prev)
+			moved := stats.ImprovedCount + stats.WorsedCount + stats.UnchangedCount
+			if stats.NewResults != tt.wantNew || moved != tt.wantMoved || stats.RemovedCount != tt.wantRemoved {
+				t.Errorf("got new=%d moved=%d removed=%d, want new=%d moved=%d removed=%d",
+					stats.NewResults, moved, stats.RemovedCount, tt.wantNew, tt.wantMoved, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestCalculator_CollapsedGroups(t *testing.T) {
+	// Each group's top document changed, but the groups only swapped places
+	prev := models.QueryResults{Results: []models.SearchResult{
+		{Rank: 1, URI: "/cpi/2023", Group: "cpi"},
+		{Rank: 2, URI: "/gdp/2023", Group: "gdp"},
+		{Rank: 3, URI: "/bulletin"},
+	}}
+	curr := models.QueryResults{Results: []models.SearchResult{
+		{Rank: 1, URI: "/gdp/2024", Group: "gdp"},
+		{Rank: 2, URI: "/cpi/2024", Group: "cpi"},
+		{Rank: 3, URI: "/bulletin"},
+	}}
+
+	stats := NewCalculator(URIMatcher{}).CalculateHistorical(curr, prev)
+	if stats.NewResults != 0 || stats.RemovedCount != 0 || stats.ImprovedCount != 1 || stats.WorsedCount != 1 {
+		t.Errorf("got new=%d removed=%d improved=%d worsened=%d, want the two groups moved",
+			stats.NewResults, stats.RemovedCount, stats.ImprovedCount, stats.WorsedCount)
+	}
+}
+
+func TestNewMatcher(t *testing.T) {
+	for _, name := range []string{"", MatchURI, MatchID, MatchNormalisedURI} {
+		if _, err := NewMatcher(name); err != nil {
+			t.Errorf("NewMatcher(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := NewMatcher("title"); err == nil {
+		t.Error("expected error for unknown matcher")
+	}
+}
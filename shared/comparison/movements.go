@@ -0,0 +1,160 @@
+package comparison
+
+import (
+	"strconv"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// Movement statuses
+const (
+	StatusNew       = "new"
+	StatusRemoved   = "removed"
+	StatusImproved  = "improved"
+	StatusWorsened  = "worsened"
+	StatusUnchanged = "unchanged"
+)
+
+// Movement describes how a single result moved between two runs
+type Movement struct {
+	URI        string  `json:"uri"`
+	Title      string  `json:"title"`
+	Status     string  `json:"status"`
+	Rank       int     `json:"rank,omitempty"`
+	PrevRank   int     `json:"prev_rank,omitempty"`
+	RankChange int     `json:"rank_change"`
+	Score      float64 `json:"score,omitempty"`
+	PrevScore  float64 `json:"prev_score,omitempty"`
+}
+
+// QueryComparison holds the historical comparison for a single query
+type QueryComparison struct {
+	Slug        string                 `json:"slug"`
+	QueryID     string                 `json:"query_id,omitempty"`
+	Query       string                 `json:"query"`
+	Algorithm   string                 `json:"algorithm"`
+	Description string                 `json:"description,omitempty"`
+	Stats       models.ComparisonStats `json:"stats"`
+	Diversity   DiversityChange        `json:"diversity"`
+	Relevance   *RelevanceChange       `json:"relevance,omitempty"` // Set when the query is judged
+	Outcome     string                 `json:"outcome,omitempty"`   // Threshold check: pass, fail or volatile; unset without thresholds
+	Reasons     []string               `json:"reasons,omitempty"`   // Thresholds a failed query broke
+	Movements   []Movement             `json:"movements"`
+}
+
+// Key identifies the compared query as models.QueryResults.Key does, so a
+// comparison can be matched to the results it was made from
+func (q QueryComparison) Key() string {
+	id := q.QueryID
+	if id == "" {
+		id = models.Slugify(q.Query)
+	}
+	return q.Algorithm + "/" + id
+}
+
+// QueryComparisons returns a per-query breakdown of a historical
+// comparison, with slugs that are unique within the comparison
+func (c *Comparison) QueryComparisons() []QueryComparison {
+	if c.mode != ModeHistorical {
+		return nil
+	}
+
+	calc := newCalculator(c.options)
+	usedSlugs := make(map[string]int)
+	comparisons := make([]QueryComparison, 0, len(c.current))
+
+	previousByKey := indexByKey(c.previous)
+
+	for _, curr := range c.current {
+		prev, ok := curr.FindPrevious(previousByKey)
+		if !ok {
+			continue
+		}
+
+		id := curr.QueryID
+		if id == "" {
+			id = curr.Query
+		}
+		slug := models.Slugify(curr.AlgorithmLabel() + " " + id)
+		usedSlugs[slug]++
+		if n := usedSlugs[slug]; n > 1 {
+			slug = models.Slugify(slug + " " + strconv.Itoa(n))
+		}
+
+		var relevance *RelevanceChange
+		if change := calculateRelevanceChange(curr, prev, c.options.Judgments, relevanceK(c.options)); change.Judged {
+			relevance = &change
+		}
+
+		stats := calc.CalculateHistorical(curr, prev)
+		outcome, reasons := checkQuery(c.options, curr, prev, stats)
+		comparisons = append(comparisons, QueryComparison{
+			Slug:        slug,
+			QueryID:     curr.QueryID,
+			Query:       curr.Query,
+			Algorithm:   curr.AlgorithmLabel(),
+			Description: curr.Description,
+			Stats:       stats,
+			Diversity:   calculateDiversityChange(curr, prev, diversityK(c.options)),
+			Relevance:   relevance,
+			Outcome:     outcome,
+			Reasons:     reasons,
+			Movements:   buildMovements(matcherOrDefault(c.options.Matcher), curr, prev),
+		})
+	}
+
+	return comparisons
+}
+
+// buildMovements lists every result in the current run with its change
+// since the previous run, followed by results that were removed
+func buildMovements(matcher Matcher, curr, prev models.QueryResults) []Movement {
+	prevMap := makeResultMap(matcher, prev.Results)
+	currKeys := makeResultSet(matcher, curr.Results)
+	movements := make([]Movement, 0, len(curr.Results))
+
+	for _, r := range curr.Results {
+		m := Movement{
+			URI:   r.URI,
+			Title: r.Title,
+			Rank:  r.Rank,
+			Score: r.Score,
+		}
+
+		p, existed := prevMap[matcher.Key(r)]
+		if !existed {
+			m.Status = StatusNew
+			movements = append(movements, m)
+			continue
+		}
+
+		m.PrevRank = p.Rank
+		m.PrevScore = p.Score
+		m.RankChange = p.Rank - r.Rank
+		switch {
+		case m.RankChange > 0:
+			m.Status = StatusImproved
+		case m.RankChange < 0:
+			m.Status = StatusWorsened
+		default:
+			m.Status = StatusUnchanged
+		}
+
+		movements = append(movements, m)
+	}
+
+	for _, p := range prev.Results {
+		if currKeys[matcher.Key(p)] {
+			continue
+		}
+		movements = append(movements, Movement{
+			URI:       p.URI,
+			Title:     p.Title,
+			Status:    StatusRemoved,
+			PrevRank:  p.Rank,
+			PrevScore: p.Score,
+		})
+	}
+
+	return movements
+}
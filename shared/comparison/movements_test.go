@@ -0,0 +1,50 @@
+package comparison
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestQueryComparison_Key(t *testing.T) {
+	result := func(id, query string) models.QueryResults {
+		return models.QueryResults{
+			QueryID: id, Query: query, Algorithm: "bm25", Corpus: "small",
+			Results: []models.SearchResult{{Rank: 1, URI: "/cpi"}},
+		}
+	}
+	// Two queries with the same text told apart only by their IDs, and one
+	// without an ID
+	current := []models.QueryResults{result("cpi-uk", "inflation"), result("cpi-wales", "inflation"), result("", "Retail Prices")}
+
+	comparisons := NewComparison(current, current, Options{}, ModeHistorical).QueryComparisons()
+	if len(comparisons) != len(current) {
+		t.Fatalf("QueryComparisons() returned %d, want %d", len(comparisons), len(current))
+	}
+	for i, qc := range comparisons {
+		if got, want := qc.Key(), current[i].Key(); got != want {
+			t.Errorf("Key() = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestQueryComparisons_SlugPerCorpus(t *testing.T) {
+	result := func(corpus string) models.QueryResults {
+		return models.QueryResults{
+			QueryID: "cpi", Query: "inflation", Algorithm: "bm25", Corpus: corpus,
+			Results: []models.SearchResult{{Rank: 1, URI: "/cpi"}},
+		}
+	}
+	// The same query and algorithm against two corpora
+	current := []models.QueryResults{result("small"), result("large")}
+
+	comparisons := NewComparison(current, current, Options{}, ModeHistorical).QueryComparisons()
+	if len(comparisons) != 2 {
+		t.Fatalf("QueryComparisons() returned %d, want 2", len(comparisons))
+	}
+	for i, want := range []string{models.Slugify("bm25@small cpi"), models.Slugify("bm25@large cpi")} {
+		if comparisons[i].Slug != want {
+			t.Errorf("Slug = %q, want %q", comparisons[i].Slug, want)
+		}
+	}
+}
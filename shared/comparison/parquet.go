@@ -0,0 +1,60 @@
+package comparison
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ONSdigital/dis-search-test-bed/shared/parquet"
+)
+
+// movementColumns are the columns of the movements Parquet table. Ranks
+// and scores are null on the side of the comparison a result is missing
+// from.
+var movementColumns = []parquet.Column{
+	{Name: "query", Type: parquet.String},
+	{Name: "query_id", Type: parquet.String},
+	{Name: "algorithm", Type: parquet.String},
+	{Name: "uri", Type: parquet.String},
+	{Name: "title", Type: parquet.String},
+	{Name: "status", Type: parquet.String},
+	{Name: "rank", Type: parquet.Int64, Optional: true},
+	{Name: "prev_rank", Type: parquet.Int64, Optional: true},
+	{Name: "rank_change", Type: parquet.Int64},
+	{Name: "score", Type: parquet.Double, Optional: true},
+	{Name: "prev_score", Type: parquet.Double, Optional: true},
+	{Name: "outcome", Type: parquet.String},
+}
+
+// WriteMovementsParquet writes every result movement of a historical
+// comparison as a Parquet table, one row per result
+func WriteMovementsParquet(w io.Writer, queries []QueryComparison) error {
+	pw := parquet.NewWriter(w, movementColumns)
+	for _, q := range queries {
+		for _, m := range q.Movements {
+			var rank, score, prevRank, prevScore any
+			if m.Status != StatusRemoved {
+				rank, score = m.Rank, m.Score
+			}
+			if m.Status != StatusNew {
+				prevRank, prevScore = m.PrevRank, m.PrevScore
+			}
+			if err := pw.Write(
+				q.Query,
+				q.QueryID,
+				q.Algorithm,
+				m.URI,
+				m.Title,
+				m.Status,
+				rank,
+				prevRank,
+				m.RankChange,
+				score,
+				prevScore,
+				q.Outcome,
+			); err != nil {
+				return fmt.Errorf("write movement: %w", err)
+			}
+		}
+	}
+	return pw.Close()
+}
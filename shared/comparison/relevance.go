@@ -0,0 +1,155 @@
+package comparison
+
+import (
+	"fmt"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/metrics"
+)
+
+// ndcgChangeCutoff is the change in NDCG treated as a real improvement or
+// regression rather than noise
+const ndcgChangeCutoff = 0.01
+
+// RelevanceChange holds a query's NDCG@K in both runs, scored against the
+// judgments. Judged is false when the query has no relevant judgments, in
+// which case NDCG is undefined.
+type RelevanceChange struct {
+	K        int     `json:"k"`
+	Judged   bool    `json:"judged"`
+	Current  float64 `json:"current_ndcg"`
+	Previous float64 `json:"previous_ndcg"`
+}
+
+// Delta is the change in NDCG since the previous run
+func (r RelevanceChange) Delta() float64 {
+	return r.Current - r.Previous
+}
+
+// RelevanceSummary averages NDCG@K over the compared queries with judgments
+type RelevanceSummary struct {
+	K           int     `json:"k"`
+	Queries     int     `json:"queries"`  // Compared queries with judgments
+	Unjudged    int     `json:"unjudged"` // Compared queries without
+	AvgNDCG     float64 `json:"avg_ndcg"`
+	PrevAvgNDCG float64 `json:"prev_avg_ndcg"`
+	Improved    int     `json:"improved"`
+	Worsened    int     `json:"worsened"`
+
+	// Paired tests of per-query NDCG, set when at least two queries are judged
+	Significance *metrics.Significance `json:"significance,omitempty"`
+}
+
+func relevanceK(options Options) int {
+	if options.RelevanceK > 0 {
+		return options.RelevanceK
+	}
+	return metrics.DefaultK
+}
+
+// calculateRelevanceChange scores both runs of a query against its
+// judgments, which are keyed by result URI
+func calculateRelevanceChange(curr, prev models.QueryResults, judgments models.Judgments, k int) RelevanceChange {
+	change := RelevanceChange{K: k}
+	grades := judgments.ForQuery(curr.QueryID, curr.Query)
+	if grades == nil {
+		return change
+	}
+
+	var ok bool
+	change.Current, ok = metrics.NDCG(resultURIs(curr.Results), grades, k)
+	if !ok {
+		return change
+	}
+	change.Previous, _ = metrics.NDCG(resultURIs(prev.Results), grades, k)
+	change.Judged = true
+	return change
+}
+
+// summariseRelevance averages NDCG over the queries present in both runs
+func summariseRelevance(current, previous []models.QueryResults, judgments models.Judgments, k int) RelevanceSummary {
+	summary := RelevanceSummary{K: k}
+	if len(judgments) == 0 {
+		return summary
+	}
+	previousByKey := indexByKey(previous)
+	var currentNDCG, previousNDCG []float64
+
+	for _, curr := range current {
+		prev, ok := curr.FindPrevious(previousByKey)
+		if !ok {
+			continue
+		}
+
+		change := calculateRelevanceChange(curr, prev, judgments, k)
+		if !change.Judged {
+			summary.Unjudged++
+			continue
+		}
+
+		summary.Queries++
+		summary.AvgNDCG += change.Current
+		summary.PrevAvgNDCG += change.Previous
+		currentNDCG = append(currentNDCG, change.Current)
+		previousNDCG = append(previousNDCG, change.Previous)
+		switch {
+		case change.Delta() > ndcgChangeCutoff:
+			summary.Improved++
+		case change.Delta() < -ndcgChangeCutoff:
+			summary.Worsened++
+		}
+	}
+
+	if summary.Queries > 0 {
+		summary.AvgNDCG /= float64(summary.Queries)
+		summary.PrevAvgNDCG /= float64(summary.Queries)
+	}
+	if s, ok := metrics.TestPaired(currentNDCG, previousNDCG); ok {
+		summary.Significance = &s
+	}
+
+	return summary
+}
+
+func resultURIs(results []models.SearchResult) []string {
+	uris := make([]string, len(results))
+	for i, r := range results {
+		uris[i] = r.URI
+	}
+	return uris
+}
+
+func (f *Formatter) writeRelevance(change RelevanceChange) error {
+	if !change.Judged {
+		return nil
+	}
+	if err := f.writef("  NDCG@%d: %.3f → %.3f (%+.3f)\n",
+		change.K, change.Previous, change.Current, change.Delta()); err != nil {
+		return fmt.Errorf("write relevance: %w", err)
+	}
+	return nil
+}
+
+func (f *Formatter) writeRelevanceSummary(summary RelevanceSummary) error {
+	if summary.Queries == 0 {
+		return nil
+	}
+
+	if err := f.writef("\nRelevance (NDCG@%d over %d judged queries):\n", summary.K, summary.Queries); err != nil {
+		return fmt.Errorf("write relevance header: %w", err)
+	}
+	if err := f.writef("  Mean NDCG: %.3f → %.3f (%+.3f)\n",
+		summary.PrevAvgNDCG, summary.AvgNDCG, summary.AvgNDCG-summary.PrevAvgNDCG); err != nil {
+		return fmt.Errorf("write relevance mean: %w", err)
+	}
+	if err := f.writef("  Queries improved: %d | worsened: %d | unjudged: %d\n",
+		summary.Improved, summary.Worsened, summary.Unjudged); err != nil {
+		return fmt.Errorf("write relevance shifts: %w", err)
+	}
+	if s := summary.Significance; s != nil {
+		if err := f.writef("  Significance over %d pairs: %s\n", s.Pairs, s); err != nil {
+			return fmt.Errorf("write relevance significance: %w", err)
+		}
+	}
+	return nil
+}
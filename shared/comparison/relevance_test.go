@@ -0,0 +1,70 @@
+package comparison
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestSummariseRelevance(t *testing.T) {
+	results := func(uris ...string) []models.SearchResult {
+		out := make([]models.SearchResult, len(uris))
+		for i, uri := range uris {
+			out[i] = models.SearchResult{Rank: i + 1, URI: uri}
+		}
+		return out
+	}
+
+	judgments := models.Judgments{
+		"cpi":       {"/cpi": 3, "/inflation": 1},
+		"inflation": {"/inflation": 2},
+	}
+	previous := []models.QueryResults{
+		{QueryID: "cpi", Query: "consumer prices", Algorithm: "bm25", Results: results("/inflation", "/cpi")},
+		{Query: "inflation", Algorithm: "bm25", Results: results("/inflation")},
+		{Query: "gdp", Algorithm: "bm25", Results: results("/gdp")},
+	}
+	current := []models.QueryResults{
+		{QueryID: "cpi", Query: "consumer prices", Algorithm: "bm25", Results: results("/cpi", "/inflation")},
+		{Query: "inflation", Algorithm: "bm25", Results: results("/other", "/inflation")},
+		{Query: "gdp", Algorithm: "bm25", Results: results("/gdp")},
+	}
+
+	summary := summariseRelevance(current, previous, judgments, 10)
+	if summary.Queries != 2 || summary.Unjudged != 1 {
+		t.Fatalf("summary = %+v, want 2 judged and 1 unjudged query", summary)
+	}
+	if summary.Improved != 1 || summary.Worsened != 1 {
+		t.Errorf("summary = %+v, want 1 improved and 1 worsened", summary)
+	}
+	if summary.AvgNDCG >= 1 || summary.PrevAvgNDCG >= 1 {
+		t.Errorf("summary = %+v, want neither run to be ideal", summary)
+	}
+	if sig := summary.Significance; sig == nil || sig.Pairs != 2 || sig.Significant() {
+		t.Errorf("summary.Significance = %+v, want 2 pairs and no significant change", sig)
+	}
+
+	if s := summariseRelevance(current, previous, nil, 10); s.Queries != 0 || s.Unjudged != 0 {
+		t.Errorf("expected no relevance summary without judgments, got %+v", s)
+	}
+
+	comp := NewComparison(current, previous, Options{Judgments: judgments}, ModeHistorical)
+	report, err := comp.Generate()
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	for _, want := range []string{"NDCG@10: 0.", "Relevance (NDCG@10 over 2 judged queries)", "Significance over 2 pairs: t-test p="} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+	if got := comp.GetSummary().Relevance; got.Queries != 2 {
+		t.Errorf("GetSummary().Relevance = %+v, want 2 judged queries", got)
+	}
+	for _, qc := range comp.QueryComparisons() {
+		if (qc.Relevance != nil) != (qc.Query != "gdp") {
+			t.Errorf("query %q relevance = %+v", qc.Query, qc.Relevance)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package comparison
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Report formats
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+	FormatJUnit    = "junit"
+	FormatHTML     = "html"
+)
+
+// reportExtensions maps each supported report format to its file extension
+var reportExtensions = map[string]string{
+	FormatText:     ".txt",
+	FormatMarkdown: ".md",
+	FormatJSON:     ".json",
+	FormatJUnit:    ".xml",
+	FormatHTML:     ".html",
+}
+
+// SupportedFormats lists the report formats Render can produce
+func SupportedFormats() []string {
+	formats := make([]string, 0, len(reportExtensions))
+	for format := range reportExtensions {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// ValidateFormats checks every format is supported and none is repeated
+func ValidateFormats(formats []string) error {
+	if len(formats) == 0 {
+		return fmt.Errorf("no report formats given")
+	}
+
+	seen := make(map[string]bool, len(formats))
+	for _, format := range formats {
+		if _, ok := reportExtensions[format]; !ok {
+			return fmt.Errorf("unsupported report format %q (supported: %s)",
+				format, strings.Join(SupportedFormats(), ", "))
+		}
+		if seen[format] {
+			return fmt.Errorf("report format %q listed more than once", format)
+		}
+		seen[format] = true
+	}
+
+	return nil
+}
+
+// ReportFileName returns the file name for a report in the given format,
+// e.g. "comparison_historical.txt" for base "comparison_historical"
+func ReportFileName(base, format string) string {
+	return base + reportExtensions[format]
+}
+
+// Render generates the comparison report in the given format
+func (c *Comparison) Render(format string) ([]byte, error) {
+	switch format {
+	case FormatText:
+		report, err := c.Generate()
+		if err != nil {
+			return nil, err
+		}
+		return []byte(report), nil
+	case FormatMarkdown:
+		return c.renderMarkdown()
+	case FormatJSON:
+		return c.renderJSON()
+	case FormatJUnit:
+		return c.renderJUnit()
+	case FormatHTML:
+		return c.renderHTML()
+	default:
+		return nil, fmt.Errorf("unsupported report format %q", format)
+	}
+}
@@ -0,0 +1,112 @@
+package comparison
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+func TestValidateFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		formats []string
+		wantErr bool
+	}{
+		{name: "text", formats: []string{FormatText}},
+		{name: "text and markdown", formats: []string{FormatText, FormatMarkdown}},
+		{name: "empty", formats: nil, wantErr: true},
+		{name: "unsupported", formats: []string{FormatText, "pdf"}, wantErr: true},
+		{name: "repeated", formats: []string{FormatText, FormatText}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFormats(tt.formats)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateFormats(%v) error = %v, wantErr %v", tt.formats, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReportFileName(t *testing.T) {
+	if got := ReportFileName("comparison_historical", FormatText); got != "comparison_historical.txt" {
+		t.Errorf("unexpected file name %q", got)
+	}
+}
+
+// TestRender_Golden checks the text, Markdown, JSON, JUnit and HTML reports byte for
+// byte. Run with -update after an intended format change to rewrite the
+// golden files.
+func TestRender_Golden(t *testing.T) {
+	previousAt := time.Date(2024, 1, 14, 9, 0, 0, 0, time.UTC)
+	currentAt := previousAt.Add(24 * time.Hour)
+
+	result := func(rank int, uri, title string, score float64) models.SearchResult {
+		return models.SearchResult{Rank: rank, URI: uri, Title: title, ContentType: "bulletin", Algorithm: "bm25", Score: score}
+	}
+	previous := []models.QueryResults{{
+		QueryID: "inflation", Query: "inflation", Algorithm: "bm25", RunAt: previousAt,
+		Results: []models.SearchResult{
+			result(1, "/economy/cpi", "Consumer price inflation", 5.1),
+			result(2, "/economy/rpi", "Retail prices index", 4.2),
+			result(3, "/economy/hpi", "House price index", 3.3),
+		},
+	}}
+	current := []models.QueryResults{{
+		QueryID: "inflation", Query: "inflation", Algorithm: "bm25", RunAt: currentAt,
+		Results: []models.SearchResult{
+			result(1, "/economy/rpi", "Retail prices index", 5.6),
+			result(2, "/economy/cpi", "Consumer price inflation", 5.0),
+			result(3, "/economy/rents", "Private rental prices", 2.9),
+		},
+	}}
+
+	comp := NewComparison(current, previous, Options{
+		ShowUnchanged:  true,
+		HighlightNew:   true,
+		ShowScores:     true,
+		MaxRankDisplay: 20,
+		Thresholds:     Thresholds{MaxRemovedResults: 3},
+	}, ModeHistorical)
+
+	goldens := map[string]string{
+		FormatText:     "historical.golden",
+		FormatMarkdown: "historical.md.golden",
+		FormatJSON:     "historical.json.golden",
+		FormatJUnit:    "historical.xml.golden",
+		FormatHTML:     "historical.html.golden",
+	}
+	for format, name := range goldens {
+		t.Run(format, func(t *testing.T) {
+			got, err := comp.Render(format)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+
+			golden := filepath.Join("testdata", name)
+			if *update {
+				if err := os.MkdirAll("testdata", 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden, got, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("read golden file (run with -update to create it): %v", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("report differs from %s (run with -update if intended):\n%s", golden, got)
+			}
+		})
+	}
+}
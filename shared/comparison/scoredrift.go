@@ -0,0 +1,253 @@
+package comparison
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// DriftBucket counts the queries whose largest relative score change falls
+// in a band
+type DriftBucket struct {
+	Label   string `json:"label"`
+	Queries int    `json:"queries"`
+}
+
+// driftBands are the upper bounds (exclusive) of the relative change bands,
+// with the last band catching everything above
+var driftBands = []struct {
+	label string
+	upper float64
+}{
+	{"unchanged", 1e-12},
+	{"< 0.1%", 0.001},
+	{"0.1% – 1%", 0.01},
+	{"1% – 5%", 0.05},
+	{">= 5%", math.Inf(1)},
+}
+
+// QueryDrift is the score drift of one query whose ranking didn't change.
+// Deltas are current minus previous score; relative changes are taken
+// against the previous score. A result that moved off a zero score has no
+// relative change, so FromZero is set and the query counts in the top band.
+type QueryDrift struct {
+	Query        string  `json:"query"`
+	QueryID      string  `json:"query_id,omitempty"`
+	Algorithm    string  `json:"algorithm"`
+	Results      int     `json:"results"`
+	MeanDelta    float64 `json:"mean_delta"`
+	MeanAbsDelta float64 `json:"mean_abs_delta"`
+	MaxAbsDelta  float64 `json:"max_abs_delta"`
+	MaxRelChange float64 `json:"max_rel_change"`
+	FromZero     bool    `json:"from_zero,omitempty"`
+}
+
+// ScoreDrift summarises how scores moved for queries that returned the same
+// ranking in both runs. A drift that hasn't reordered anything yet usually
+// means index statistics (document counts, IDF) changed, and is an early
+// warning of ranking changes to come.
+type ScoreDrift struct {
+	Compared     int           `json:"compared"`
+	Identical    int           `json:"identical"`
+	Reordered    int           `json:"reordered"`
+	Rising       int           `json:"rising"`
+	Falling      int           `json:"falling"`
+	MeanAbsDelta float64       `json:"mean_abs_delta"`
+	MaxAbsDelta  float64       `json:"max_abs_delta"`
+	Distribution []DriftBucket `json:"distribution"`
+	Queries      []QueryDrift  `json:"queries"`
+}
+
+// CalculateScoreDrift measures score drift for every query present in both
+// runs whose results are the same documents in the same order, pairing
+// results with matcher (URI if nil). Queries are ordered by largest
+// relative change first.
+func CalculateScoreDrift(current, previous []models.QueryResults, matcher Matcher) ScoreDrift {
+	m := matcherOrDefault(matcher)
+	drift := ScoreDrift{}
+	for _, band := range driftBands {
+		drift.Distribution = append(drift.Distribution, DriftBucket{Label: band.label})
+	}
+
+	previousByKey := indexByKey(previous)
+	for _, curr := range current {
+		prev, ok := curr.FindPrevious(previousByKey)
+		if !ok {
+			continue
+		}
+		drift.Compared++
+
+		if !sameRanking(m, curr.Results, prev.Results) {
+			drift.Reordered++
+			continue
+		}
+		drift.Identical++
+
+		qd := queryDrift(curr, prev)
+		drift.Queries = append(drift.Queries, qd)
+		drift.MeanAbsDelta += qd.MeanAbsDelta
+		drift.MaxAbsDelta = math.Max(drift.MaxAbsDelta, qd.MaxAbsDelta)
+		switch {
+		case qd.MeanDelta > 0:
+			drift.Rising++
+		case qd.MeanDelta < 0:
+			drift.Falling++
+		}
+		drift.Distribution[driftBand(qd)].Queries++
+	}
+
+	if drift.Identical > 0 {
+		drift.MeanAbsDelta /= float64(drift.Identical)
+	}
+
+	sort.SliceStable(drift.Queries, func(i, j int) bool {
+		a, b := drift.Queries[i], drift.Queries[j]
+		if a.FromZero != b.FromZero {
+			return a.FromZero
+		}
+		return a.MaxRelChange > b.MaxRelChange
+	})
+
+	return drift
+}
+
+// driftBand returns the index of the band a query's largest relative change
+// falls in. Scores that moved off zero go in the top band.
+func driftBand(qd QueryDrift) int {
+	if qd.FromZero {
+		return len(driftBands) - 1
+	}
+	for i, band := range driftBands {
+		if qd.MaxRelChange < band.upper {
+			return i
+		}
+	}
+	return len(driftBands) - 1
+}
+
+// sameRanking reports whether both lists hold the same documents in the
+// same order
+func sameRanking(m Matcher, current, previous []models.SearchResult) bool {
+	if len(current) != len(previous) {
+		return false
+	}
+	for i := range current {
+		if m.Key(current[i]) != m.Key(previous[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// queryDrift measures the score changes between two identical rankings
+func queryDrift(curr, prev models.QueryResults) QueryDrift {
+	qd := QueryDrift{
+		Query:     curr.Query,
+		QueryID:   curr.QueryID,
+		Algorithm: curr.AlgorithmLabel(),
+		Results:   len(curr.Results),
+	}
+	if qd.Results == 0 {
+		return qd
+	}
+
+	for i, r := range curr.Results {
+		before := prev.Results[i].Score
+		delta := r.Score - before
+		qd.MeanDelta += delta
+		qd.MeanAbsDelta += math.Abs(delta)
+		qd.MaxAbsDelta = math.Max(qd.MaxAbsDelta, math.Abs(delta))
+		switch {
+		case before != 0:
+			qd.MaxRelChange = math.Max(qd.MaxRelChange, math.Abs(delta/before))
+		case delta != 0:
+			qd.FromZero = true
+		}
+	}
+	qd.MeanDelta /= float64(qd.Results)
+	qd.MeanAbsDelta /= float64(qd.Results)
+
+	return qd
+}
+
+// FormatScoreDrift formats the score drift report
+func (f *Formatter) FormatScoreDrift(current, previous []models.QueryResults) error {
+	if len(current) == 0 {
+		return fmt.Errorf("no current results to format")
+	}
+
+	drift := CalculateScoreDrift(current, previous, f.options.Matcher)
+
+	if err := f.writef("Generated: %s\n", current[0].RunAt.Format("2006-01-02 15:04:05")); err != nil {
+		return fmt.Errorf("write generated timestamp: %w", err)
+	}
+	if err := f.writef("Score drift for queries whose ranking is unchanged\n"); err != nil {
+		return fmt.Errorf("write title: %w", err)
+	}
+	if err := f.writef("%s\n\n", strings.Repeat(separatorChar, 70)); err != nil {
+		return fmt.Errorf("write separator: %w", err)
+	}
+
+	if err := f.writef("Queries compared: %d\n", drift.Compared); err != nil {
+		return fmt.Errorf("write compared: %w", err)
+	}
+	if err := f.writef("Identical rankings: %d | Reordered (not included): %d\n",
+		drift.Identical, drift.Reordered); err != nil {
+		return fmt.Errorf("write identical: %w", err)
+	}
+	if drift.Identical == 0 {
+		return f.writef("\n%s No query returned the same ranking in both runs\n", infoLabel)
+	}
+
+	if err := f.writef("Scores rising: %d | falling: %d | unchanged: %d\n",
+		drift.Rising, drift.Falling, drift.Identical-drift.Rising-drift.Falling); err != nil {
+		return fmt.Errorf("write direction: %w", err)
+	}
+	if err := f.writef("Mean |Δ| per result: %.4f | Largest |Δ|: %.4f\n",
+		drift.MeanAbsDelta, drift.MaxAbsDelta); err != nil {
+		return fmt.Errorf("write deltas: %w", err)
+	}
+
+	if err := f.writef("\nLargest relative score change per query:\n"); err != nil {
+		return fmt.Errorf("write distribution header: %w", err)
+	}
+	for _, b := range drift.Distribution {
+		if err := f.writef("  %-12s %4d %s\n", b.Label, b.Queries,
+			strings.Repeat("#", b.Queries)); err != nil {
+			return fmt.Errorf("write distribution row: %w", err)
+		}
+	}
+
+	if err := f.writef("\n--- Per-Query Drift (largest first) ---\n\n"); err != nil {
+		return fmt.Errorf("write queries header: %w", err)
+	}
+	for _, qd := range drift.Queries {
+		change := fmt.Sprintf("%.2f%%", qd.MaxRelChange*100)
+		if qd.FromZero {
+			change = "from zero"
+		}
+		if err := f.writef("%s (%s): %d results | mean Δ %+.4f | max |Δ| %.4f | max change %s\n",
+			models.SingleLine(qd.Query), qd.Algorithm, qd.Results,
+			qd.MeanDelta, qd.MaxAbsDelta, change); err != nil {
+			return fmt.Errorf("write query drift: %w", err)
+		}
+	}
+
+	if drift.Rising == drift.Identical || drift.Falling == drift.Identical {
+		if err := f.writef("\n%s Scores moved the same way for every query, which usually means index\n"+
+			"statistics changed (document counts, term frequencies) rather than the queries.\n",
+			iconWarning); err != nil {
+			return fmt.Errorf("write drift note: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ScoreDrift returns the score drift of queries with unchanged rankings
+func (c *Comparison) ScoreDrift() ScoreDrift {
+	return CalculateScoreDrift(c.current, c.previous, c.options.Matcher)
+}
@@ -0,0 +1,108 @@
+package comparison
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestCalculateScoreDrift(t *testing.T) {
+	scored := func(query string, pairs ...interface{}) models.QueryResults {
+		qr := models.QueryResults{Query: query, Algorithm: "bm25"}
+		for i := 0; i < len(pairs); i += 2 {
+			qr.Results = append(qr.Results, models.SearchResult{
+				Rank:  i/2 + 1,
+				URI:   pairs[i].(string),
+				Score: pairs[i+1].(float64),
+			})
+		}
+		return qr
+	}
+
+	previous := []models.QueryResults{
+		scored("inflation", "/a", 10.0, "/b", 5.0),
+		scored("wages", "/c", 4.0, "/d", 2.0),
+		scored("births", "/e", 3.0, "/f", 1.0),
+		scored("deaths", "/g", 2.0),
+		scored("prices", "/i", 0.0),
+	}
+	current := []models.QueryResults{
+		scored("inflation", "/a", 10.2, "/b", 5.05), // 2% and 1% rises
+		scored("wages", "/c", 4.0, "/d", 2.0),       // unchanged
+		scored("births", "/f", 3.0, "/e", 1.0),      // reordered
+		scored("census", "/h", 1.0),                 // not in previous run
+		scored("prices", "/i", 0.5),                 // rose from zero
+	}
+
+	got := CalculateScoreDrift(current, previous, nil)
+
+	if got.Compared != 4 || got.Identical != 3 || got.Reordered != 1 {
+		t.Fatalf("compared/identical/reordered = %d/%d/%d, want 4/3/1",
+			got.Compared, got.Identical, got.Reordered)
+	}
+	if got.Rising != 2 || got.Falling != 0 {
+		t.Errorf("rising/falling = %d/%d, want 2/0", got.Rising, got.Falling)
+	}
+	if len(got.Queries) != 3 || got.Queries[0].Query != "prices" || got.Queries[1].Query != "inflation" {
+		t.Fatalf("queries = %+v, want prices then inflation first", got.Queries)
+	}
+	if !got.Queries[0].FromZero {
+		t.Error("prices should be marked as drifting from zero")
+	}
+
+	inflation := got.Queries[1]
+	checks := []struct {
+		name      string
+		got, want float64
+	}{
+		{"mean delta", inflation.MeanDelta, 0.125},
+		{"max abs delta", inflation.MaxAbsDelta, 0.2},
+		{"max rel change", inflation.MaxRelChange, 0.02},
+		{"suite mean abs delta", got.MeanAbsDelta, 0.625 / 3},
+	}
+	for _, c := range checks {
+		if math.Abs(c.got-c.want) > 1e-9 {
+			t.Errorf("%s = %f, want %f", c.name, c.got, c.want)
+		}
+	}
+
+	wantBuckets := map[string]int{"unchanged": 1, "1% – 5%": 1, ">= 5%": 1}
+	for _, b := range got.Distribution {
+		if b.Queries != wantBuckets[b.Label] {
+			t.Errorf("bucket %q = %d, want %d", b.Label, b.Queries, wantBuckets[b.Label])
+		}
+	}
+}
+
+func TestRender_ScoreDrift(t *testing.T) {
+	results := []models.QueryResults{{
+		Query:     "inflation",
+		Algorithm: "bm25",
+		Results:   []models.SearchResult{{Rank: 1, URI: "/a", Score: 2}},
+	}}
+	drifted := []models.QueryResults{{
+		Query:     "inflation",
+		Algorithm: "bm25",
+		Results:   []models.SearchResult{{Rank: 1, URI: "/a", Score: 2.5}},
+	}}
+
+	report, err := NewComparison(drifted, results, Options{}, ModeScoreDrift).Render(FormatText)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	for _, want := range []string{
+		"Identical rankings: 1 | Reordered (not included): 0",
+		"inflation (bm25): 1 results | mean Δ +0.5000 | max |Δ| 0.5000 | max change 25.00%",
+		"index\nstatistics changed",
+	} {
+		if !strings.Contains(string(report), want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+
+	if _, err := NewComparison(drifted, nil, Options{}, ModeScoreDrift).Render(FormatText); err == nil {
+		t.Error("Render() without previous results should fail")
+	}
+}
@@ -0,0 +1,275 @@
+package comparison
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// Thresholds are the per-query limits beyond which a query counts as
+// regressed. A zero value disables that rule, except for MaxNewTop, which
+// is unset when nil so that 0 can allow no new results in the top band.
+type Thresholds struct {
+	MaxRemovedResults   int
+	MaxWorsenedRankings int
+	MaxAvgRankChange    float64
+	MinOverlap          float64 // Share of the previous results still returned
+	MinRBO              float64
+	MinKendallTau       float64 // Skipped when fewer than two results are shared
+	MinNDCG             float64 // Skipped for queries without judgments
+	MaxNewTop           *int    // New results in the first position band, e.g. the top 3
+	MaxNewImpact        float64 // New results weighted by position band
+
+	// StopAfter ends the per-query detail of the historical report once this
+	// many queries have regressed; the summary still covers every query. 0
+	// never stops.
+	StopAfter int
+}
+
+// Enabled reports whether any threshold rule is set
+func (t Thresholds) Enabled() bool {
+	return t.MaxRemovedResults > 0 || t.MaxWorsenedRankings > 0 || t.MaxAvgRankChange > 0 ||
+		t.MinOverlap > 0 || t.MinRBO > 0 || t.MinKendallTau != 0 || t.MinNDCG > 0 ||
+		t.MaxNewTop != nil || t.MaxNewImpact > 0
+}
+
+// Check returns the reasons a query's stats and relevance break the
+// thresholds, if any
+func (t Thresholds) Check(stats models.ComparisonStats, relevance RelevanceChange) []string {
+	var reasons []string
+	for _, rule := range thresholdRules {
+		if reason := rule(t, stats, relevance); reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+	return reasons
+}
+
+// thresholdRule returns why a query breaks one threshold, or "" if it is
+// within it or the threshold is unset
+type thresholdRule func(t Thresholds, stats models.ComparisonStats, relevance RelevanceChange) string
+
+// thresholdRules are checked, and their reasons given, in this order
+var thresholdRules = []thresholdRule{
+	checkRemoved,
+	checkWorsened,
+	checkAvgRankChange,
+	checkOverlap,
+	checkRBO,
+	checkKendallTau,
+	checkNewTop,
+	checkNewImpact,
+	checkNDCG,
+}
+
+func checkRemoved(t Thresholds, stats models.ComparisonStats, _ RelevanceChange) string {
+	if t.MaxRemovedResults > 0 && stats.RemovedCount > t.MaxRemovedResults {
+		return fmt.Sprintf("%d removed results (max %d)", stats.RemovedCount, t.MaxRemovedResults)
+	}
+	return ""
+}
+
+func checkWorsened(t Thresholds, stats models.ComparisonStats, _ RelevanceChange) string {
+	if t.MaxWorsenedRankings > 0 && stats.WorsedCount > t.MaxWorsenedRankings {
+		return fmt.Sprintf("%d worsened rankings (max %d)", stats.WorsedCount, t.MaxWorsenedRankings)
+	}
+	return ""
+}
+
+func checkAvgRankChange(t Thresholds, stats models.ComparisonStats, _ RelevanceChange) string {
+	if t.MaxAvgRankChange > 0 && stats.AvgRankChange > t.MaxAvgRankChange {
+		return fmt.Sprintf("avg rank change %.2f (max %.2f)", stats.AvgRankChange, t.MaxAvgRankChange)
+	}
+	return ""
+}
+
+func checkOverlap(t Thresholds, stats models.ComparisonStats, _ RelevanceChange) string {
+	if t.MinOverlap > 0 && stats.Overlap < t.MinOverlap {
+		return fmt.Sprintf("overlap %.2f (min %.2f)", stats.Overlap, t.MinOverlap)
+	}
+	return ""
+}
+
+func checkRBO(t Thresholds, stats models.ComparisonStats, _ RelevanceChange) string {
+	if t.MinRBO > 0 && stats.RBO < t.MinRBO {
+		return fmt.Sprintf("RBO %.3f (min %.3f)", stats.RBO, t.MinRBO)
+	}
+	return ""
+}
+
+func checkKendallTau(t Thresholds, stats models.ComparisonStats, _ RelevanceChange) string {
+	if t.MinKendallTau != 0 && stats.KendallTau != nil && *stats.KendallTau < t.MinKendallTau {
+		return fmt.Sprintf("Kendall tau %.2f (min %.2f)", *stats.KendallTau, t.MinKendallTau)
+	}
+	return ""
+}
+
+func checkNewTop(t Thresholds, stats models.ComparisonStats, _ RelevanceChange) string {
+	if t.MaxNewTop != nil && len(stats.NewByBand) > 0 && stats.NewByBand[0].Count > *t.MaxNewTop {
+		return fmt.Sprintf("%d new results at ranks %s (max %d)",
+			stats.NewByBand[0].Count, stats.NewByBand[0].Band, *t.MaxNewTop)
+	}
+	return ""
+}
+
+func checkNewImpact(t Thresholds, stats models.ComparisonStats, _ RelevanceChange) string {
+	if t.MaxNewImpact > 0 && stats.NewImpact > t.MaxNewImpact {
+		return fmt.Sprintf("new result impact %.2f (max %.2f)", stats.NewImpact, t.MaxNewImpact)
+	}
+	return ""
+}
+
+func checkNDCG(t Thresholds, _ models.ComparisonStats, relevance RelevanceChange) string {
+	if t.MinNDCG > 0 && relevance.Judged && relevance.Current < t.MinNDCG {
+		return fmt.Sprintf("NDCG@%d %.3f (min %.3f)", relevance.K, relevance.Current, t.MinNDCG)
+	}
+	return ""
+}
+
+// Outcome of checking one query against the thresholds
+const (
+	QueryPassed    = "pass"
+	QueryFailed    = "fail"
+	QueryUnchecked = "volatile" // Volatile queries are expected to churn
+)
+
+// checkQuery checks a query present in both runs against the thresholds,
+// returning its outcome and the reasons it failed. The outcome is empty when
+// no threshold is set.
+func checkQuery(options Options, curr, prev models.QueryResults, stats models.ComparisonStats) (string, []string) {
+	switch {
+	case !options.Thresholds.Enabled():
+		return "", nil
+	case curr.Volatile:
+		return QueryUnchecked, nil
+	}
+
+	relevance := calculateRelevanceChange(curr, prev, options.Judgments, relevanceK(options))
+	if reasons := options.Thresholds.Check(stats, relevance); len(reasons) > 0 {
+		return QueryFailed, reasons
+	}
+	return QueryPassed, nil
+}
+
+// Regression is a query that broke one or more thresholds
+type Regression struct {
+	Query     string   `json:"query"`
+	Algorithm string   `json:"algorithm"`
+	Reasons   []string `json:"reasons"`
+}
+
+// Verdict is the headline outcome of a historical comparison
+type Verdict struct {
+	Checked     bool         `json:"checked"`
+	Regressions []Regression `json:"regressions"`
+	Volatile    int          `json:"volatile,omitempty"` // Volatile queries left unchecked
+}
+
+// Truncated reports whether the historical report stopped giving per-query
+// detail because too many queries regressed
+func (v Verdict) Truncated(stopAfter int) bool {
+	return reachedStop(stopAfter, len(v.Regressions))
+}
+
+// reachedStop reports whether enough queries have regressed for the
+// historical report to stop giving per-query detail
+func reachedStop(stopAfter, regressions int) bool {
+	return stopAfter > 0 && regressions >= stopAfter
+}
+
+// Passed reports whether no query regressed beyond the thresholds
+func (v Verdict) Passed() bool {
+	return len(v.Regressions) == 0
+}
+
+// Headline returns the verdict as plain text
+func (v Verdict) Headline() string {
+	switch {
+	case !v.Checked:
+		return "No regression thresholds configured"
+	case v.Passed():
+		return "No significant regressions"
+	case len(v.Regressions) == 1:
+		return "1 query regressed beyond thresholds"
+	default:
+		return fmt.Sprintf("%d queries regressed beyond thresholds", len(v.Regressions))
+	}
+}
+
+// Badge returns the headline with its icon, as shown at the top of the report
+func (v Verdict) Badge() string {
+	switch {
+	case !v.Checked:
+		return "ℹ️ " + v.Headline()
+	case v.Passed():
+		return "✅ " + v.Headline()
+	default:
+		return iconWarning + " " + v.Headline()
+	}
+}
+
+// CalculateVerdict checks every query present in both runs against the
+// options' thresholds, pairing results with its matcher. Queries marked
+// volatile are expected to churn and are counted but not checked.
+func CalculateVerdict(current, previous []models.QueryResults, options Options) Verdict {
+	verdict := Verdict{Checked: options.Thresholds.Enabled()}
+	if !verdict.Checked {
+		return verdict
+	}
+
+	calc := newCalculator(options)
+	previousByKey := indexByKey(previous)
+	for _, curr := range current {
+		prev, ok := curr.FindPrevious(previousByKey)
+		if !ok {
+			continue
+		}
+
+		outcome, reasons := checkQuery(options, curr, prev, calc.CalculateHistorical(curr, prev))
+		switch outcome {
+		case QueryUnchecked:
+			verdict.Volatile++
+		case QueryFailed:
+			verdict.Regressions = append(verdict.Regressions, Regression{
+				Query:     curr.Query,
+				Algorithm: curr.AlgorithmLabel(),
+				Reasons:   reasons,
+			})
+		}
+	}
+
+	return verdict
+}
+
+// writeQueryOutcome gives a query's threshold check in the text report
+func (f *Formatter) writeQueryOutcome(outcome string, reasons []string) error {
+	var err error
+	switch outcome {
+	case QueryPassed:
+		err = f.writef("Thresholds: PASS\n")
+	case QueryFailed:
+		err = f.writef("Thresholds: FAIL (%s)\n", strings.Join(reasons, ", "))
+	}
+	if err != nil {
+		return fmt.Errorf("write threshold outcome: %w", err)
+	}
+	return nil
+}
+
+func (f *Formatter) writeVerdict(verdict Verdict) error {
+	if err := f.writef("%s\n", verdict.Badge()); err != nil {
+		return fmt.Errorf("write verdict: %w", err)
+	}
+	for _, r := range verdict.Regressions {
+		if err := f.writef("  - %q (%s): %s\n", r.Query, r.Algorithm, strings.Join(r.Reasons, ", ")); err != nil {
+			return fmt.Errorf("write regression: %w", err)
+		}
+	}
+	if verdict.Volatile > 0 {
+		if err := f.writef("  (%d volatile queries not checked)\n", verdict.Volatile); err != nil {
+			return fmt.Errorf("write volatile count: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package comparison
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestThresholds_Check(t *testing.T) {
+	thresholds := Thresholds{MaxRemovedResults: 2, MaxWorsenedRankings: 3, MaxAvgRankChange: 1.5}
+
+	tests := []struct {
+		name        string
+		stats       models.ComparisonStats
+		wantReasons int
+	}{
+		{name: "within limits", stats: models.ComparisonStats{RemovedCount: 2, WorsedCount: 3, AvgRankChange: 1.5}},
+		{name: "too many removed", stats: models.ComparisonStats{RemovedCount: 3}, wantReasons: 1},
+		{name: "all rules broken", stats: models.ComparisonStats{RemovedCount: 5, WorsedCount: 4, AvgRankChange: 2}, wantReasons: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := thresholds.Check(tt.stats, RelevanceChange{}); len(got) != tt.wantReasons {
+				t.Errorf("expected %d reasons, got %v", tt.wantReasons, got)
+			}
+		})
+	}
+
+	if (Thresholds{}).Check(models.ComparisonStats{RemovedCount: 10}, RelevanceChange{}) != nil {
+		t.Error("expected zero thresholds to disable every rule")
+	}
+}
+
+func TestThresholds_CheckMinimums(t *testing.T) {
+	thresholds := Thresholds{MinOverlap: 0.5, MinRBO: 0.6, MinKendallTau: 0.2, MinNDCG: 0.7}
+	tau := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name      string
+		stats     models.ComparisonStats
+		relevance RelevanceChange
+		want      []string
+	}{
+		{
+			name:      "above minimums",
+			stats:     models.ComparisonStats{Overlap: 0.5, RBO: 0.6, KendallTau: tau(0.2)},
+			relevance: RelevanceChange{K: 10, Judged: true, Current: 0.7},
+		},
+		{
+			name:  "no correlation or judgments to check",
+			stats: models.ComparisonStats{Overlap: 1, RBO: 1},
+		},
+		{
+			name:      "below minimums",
+			stats:     models.ComparisonStats{Overlap: 0.25, RBO: 0.4, KendallTau: tau(-0.5)},
+			relevance: RelevanceChange{K: 10, Judged: true, Current: 0.5},
+			want: []string{
+				"overlap 0.25 (min 0.50)",
+				"RBO 0.400 (min 0.600)",
+				"Kendall tau -0.50 (min 0.20)",
+				"NDCG@10 0.500 (min 0.700)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := thresholds.Check(tt.stats, tt.relevance); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateVerdict(t *testing.T) {
+	results := func(uris ...string) []models.SearchResult {
+		out := make([]models.SearchResult, len(uris))
+		for i, uri := range uris {
+			out[i] = models.SearchResult{Rank: i + 1, URI: uri}
+		}
+		return out
+	}
+
+	previous := []models.QueryResults{
+		{Query: "inflation", Algorithm: "bm25", Results: results("/a", "/b", "/c")},
+		{Query: "gdp", Algorithm: "bm25", Results: results("/d", "/e")},
+	}
+	current := []models.QueryResults{
+		{Query: "inflation", Algorithm: "bm25", Results: results("/x", "/y", "/z")},
+		{Query: "gdp", Algorithm: "bm25", Results: results("/d", "/e")},
+	}
+
+	if v := CalculateVerdict(current, previous, Options{Thresholds: Thresholds{}}); v.Checked || v.Badge() != "ℹ️ No regression thresholds configured" {
+		t.Errorf("expected unchecked verdict, got %+v", v)
+	}
+
+	v := CalculateVerdict(current, previous, Options{Thresholds: Thresholds{MaxRemovedResults: 1}})
+	if v.Passed() || len(v.Regressions) != 1 || v.Regressions[0].Query != "inflation" {
+		t.Fatalf("expected inflation to regress, got %+v", v)
+	}
+	if v.Headline() != "1 query regressed beyond thresholds" {
+		t.Errorf("unexpected headline %q", v.Headline())
+	}
+
+	if v := CalculateVerdict(current, previous, Options{Thresholds: Thresholds{MaxRemovedResults: 3}}); !v.Passed() {
+		t.Errorf("expected no regressions, got %+v", v.Regressions)
+	}
+
+	current[0].Volatile = true
+	if v := CalculateVerdict(current, previous, Options{Thresholds: Thresholds{MaxRemovedResults: 1}}); !v.Passed() || v.Volatile != 1 {
+		t.Errorf("expected the volatile query to be counted but not checked, got %+v", v)
+	}
+}
+
+func TestFormatHistorical_StopAfter(t *testing.T) {
+	results := func(uris ...string) []models.SearchResult {
+		out := make([]models.SearchResult, len(uris))
+		for i, uri := range uris {
+			out[i] = models.SearchResult{Rank: i + 1, URI: uri}
+		}
+		return out
+	}
+
+	var current, previous []models.QueryResults
+	for _, q := range []string{"cpi", "gdp", "jobs", "rents"} {
+		previous = append(previous, models.QueryResults{Query: q, Algorithm: "bm25", Results: results("/a", "/b")})
+		current = append(current, models.QueryResults{Query: q, Algorithm: "bm25", Results: results("/x", "/y")})
+	}
+
+	thresholds := Thresholds{MaxRemovedResults: 1, StopAfter: 2}
+	report, err := NewComparison(current, previous, Options{Thresholds: thresholds}, ModeHistorical).Generate()
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if !strings.Contains(report, "Query: gdp") || strings.Contains(report, "Query: jobs") {
+		t.Errorf("expected detail for the first 2 regressions only, got:\n%s", report)
+	}
+	if !strings.Contains(report, "Stopped after 2 regressions: detail for the remaining 2 queries is left out") {
+		t.Errorf("expected a stop notice, got:\n%s", report)
+	}
+	if !strings.Contains(report, "4 queries regressed beyond thresholds") {
+		t.Errorf("expected the verdict to cover every query, got:\n%s", report)
+	}
+
+	verdict := CalculateVerdict(current, previous, Options{Thresholds: thresholds})
+	if !verdict.Truncated(thresholds.StopAfter) || verdict.Truncated(0) {
+		t.Errorf("Truncated() wrong for %d regressions", len(verdict.Regressions))
+	}
+
+	// Stopping at exactly as many regressions as there are still truncates
+	// the report when queries follow the last one shown
+	current[3].Results, current[1].Results = previous[3].Results, previous[1].Results
+	report, err = NewComparison(current, previous, Options{Thresholds: thresholds}, ModeHistorical).Generate()
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	verdict = CalculateVerdict(current, previous, Options{Thresholds: thresholds})
+	if stopped := strings.Contains(report, "Stopped after 2 regressions"); !stopped || !verdict.Truncated(thresholds.StopAfter) {
+		t.Errorf("report stopped = %v, Truncated() = %v for %d regressions, want both true",
+			stopped, verdict.Truncated(thresholds.StopAfter), len(verdict.Regressions))
+	}
+}
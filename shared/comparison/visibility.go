@@ -0,0 +1,138 @@
+package comparison
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/metrics"
+)
+
+// DefaultVisibilityK is the number of top results counted as visible
+const DefaultVisibilityK = 3
+
+// unknownTheme labels results whose URI has no top-level section
+const unknownTheme = "(none)"
+
+// ThemeShare records how many top-K slots a theme held in each run
+type ThemeShare struct {
+	Theme         string  `json:"theme"`
+	Count         int     `json:"count"`
+	Share         float64 `json:"share"`
+	PreviousCount int     `json:"previous_count"`
+	PreviousShare float64 `json:"previous_share"`
+	ShareChange   float64 `json:"share_change"`
+}
+
+// Visibility aggregates top-K slots by theme across the whole query suite.
+// Shares are fractions of all top-K slots in a run, so runs with different
+// numbers of queries remain comparable.
+type Visibility struct {
+	K             int          `json:"k"`
+	Slots         int          `json:"slots"`
+	PreviousSlots int          `json:"previous_slots"`
+	Themes        []ThemeShare `json:"themes"`
+}
+
+// CalculateVisibility counts the themes of the top k results of every query
+// in each run. Themes are ordered by current share, largest first.
+func CalculateVisibility(current, previous []models.QueryResults, k int) Visibility {
+	if k <= 0 {
+		k = DefaultVisibilityK
+	}
+
+	v := Visibility{K: k}
+	currentCounts, currentSlots := countThemes(current, k)
+	previousCounts, previousSlots := countThemes(previous, k)
+	v.Slots = currentSlots
+	v.PreviousSlots = previousSlots
+
+	themes := make(map[string]bool, len(currentCounts)+len(previousCounts))
+	for theme := range currentCounts {
+		themes[theme] = true
+	}
+	for theme := range previousCounts {
+		themes[theme] = true
+	}
+
+	for theme := range themes {
+		ts := ThemeShare{
+			Theme:         theme,
+			Count:         currentCounts[theme],
+			PreviousCount: previousCounts[theme],
+		}
+		if currentSlots > 0 {
+			ts.Share = float64(ts.Count) / float64(currentSlots)
+		}
+		if previousSlots > 0 {
+			ts.PreviousShare = float64(ts.PreviousCount) / float64(previousSlots)
+		}
+		ts.ShareChange = ts.Share - ts.PreviousShare
+		v.Themes = append(v.Themes, ts)
+	}
+
+	sort.Slice(v.Themes, func(i, j int) bool {
+		if v.Themes[i].Share != v.Themes[j].Share {
+			return v.Themes[i].Share > v.Themes[j].Share
+		}
+		if v.Themes[i].PreviousShare != v.Themes[j].PreviousShare {
+			return v.Themes[i].PreviousShare > v.Themes[j].PreviousShare
+		}
+		return v.Themes[i].Theme < v.Themes[j].Theme
+	})
+
+	return v
+}
+
+func countThemes(results []models.QueryResults, k int) (map[string]int, int) {
+	counts := make(map[string]int)
+	slots := 0
+
+	for _, qr := range results {
+		for _, r := range qr.Results {
+			if r.Rank > k {
+				continue
+			}
+			theme := metrics.Topic(r.URI)
+			if theme == "" {
+				theme = unknownTheme
+			}
+			counts[theme]++
+			slots++
+		}
+	}
+
+	return counts, slots
+}
+
+// Visibility returns the top-K theme shares for the current and previous runs
+func (c *Comparison) Visibility() Visibility {
+	return CalculateVisibility(c.current, c.previous, visibilityK(c.options))
+}
+
+func visibilityK(options Options) int {
+	if options.VisibilityK > 0 {
+		return options.VisibilityK
+	}
+	return DefaultVisibilityK
+}
+
+func (f *Formatter) writeVisibility(v Visibility) error {
+	if len(v.Themes) == 0 {
+		return nil
+	}
+
+	if err := f.writef("\nTop-%d Visibility by Theme (%d slots, previously %d):\n",
+		v.K, v.Slots, v.PreviousSlots); err != nil {
+		return fmt.Errorf("write visibility header: %w", err)
+	}
+	for _, ts := range v.Themes {
+		if err := f.writef("  %-30s %5.1f%% → %5.1f%% (%+.1f pts) [%d → %d]\n",
+			ts.Theme, ts.PreviousShare*100, ts.Share*100, ts.ShareChange*100,
+			ts.PreviousCount, ts.Count); err != nil {
+			return fmt.Errorf("write visibility row: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package comparison
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestCalculateVisibility(t *testing.T) {
+	results := func(uris ...string) []models.SearchResult {
+		out := make([]models.SearchResult, len(uris))
+		for i, uri := range uris {
+			out[i] = models.SearchResult{Rank: i + 1, URI: uri}
+		}
+		return out
+	}
+
+	current := []models.QueryResults{
+		{Query: "inflation", Results: results("/economy/a", "/economy/b", "/people/a", "/economy/c")},
+		{Query: "wages", Results: results("/employment/a", "/economy/d")},
+	}
+	previous := []models.QueryResults{
+		{Query: "inflation", Results: results("/people/a", "/people/b", "/economy/a")},
+	}
+
+	got := CalculateVisibility(current, previous, 3)
+
+	if got.Slots != 5 || got.PreviousSlots != 3 {
+		t.Fatalf("slots = %d/%d, want 5/3", got.Slots, got.PreviousSlots)
+	}
+
+	want := []struct {
+		theme     string
+		count     int
+		prevCount int
+		change    float64
+	}{
+		{"economy", 3, 1, 3.0/5 - 1.0/3},
+		{"people", 1, 2, 1.0/5 - 2.0/3},
+		{"employment", 1, 0, 1.0 / 5},
+	}
+	if len(got.Themes) != len(want) {
+		t.Fatalf("expected %d themes, got %d", len(want), len(got.Themes))
+	}
+	for i, w := range want {
+		ts := got.Themes[i]
+		if ts.Theme != w.theme || ts.Count != w.count || ts.PreviousCount != w.prevCount {
+			t.Errorf("theme %d = %+v, want %s %d/%d", i, ts, w.theme, w.count, w.prevCount)
+		}
+		if math.Abs(ts.ShareChange-w.change) > 1e-9 {
+			t.Errorf("%s share change = %f, want %f", ts.Theme, ts.ShareChange, w.change)
+		}
+	}
+}
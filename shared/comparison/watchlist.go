@@ -0,0 +1,125 @@
+package comparison
+
+import (
+	"fmt"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/metrics"
+)
+
+// WatchlistDrop is a watchlist document that fell out of a query's top K
+type WatchlistDrop struct {
+	Query        string `json:"query"`
+	QueryID      string `json:"query_id,omitempty"`
+	Algorithm    string `json:"algorithm"`
+	URI          string `json:"uri"`
+	PreviousRank int    `json:"previous_rank"`
+	CurrentRank  int    `json:"current_rank,omitempty"` // 0 when no longer returned at all
+	Volatile     bool   `json:"volatile,omitempty"`     // The query is expected to churn; gates leave the drop out
+}
+
+// FindWatchlistDrops reports every query where a watchlist URI was in the
+// top k of the previous results and is not in the top k of the current
+// ones. URIs are compared normalised, so trailing slashes and case don't
+// matter. Drops from volatile queries are reported too, marked volatile, as
+// a must-have document vanishing matters even where churn is expected.
+func FindWatchlistDrops(current, previous []models.QueryResults, watchlist models.Watchlist, k int) []WatchlistDrop {
+	if len(watchlist) == 0 {
+		return nil
+	}
+	if k <= 0 {
+		k = metrics.DefaultK
+	}
+
+	watched := make(map[string]string, len(watchlist))
+	for _, uri := range watchlist {
+		watched[NormaliseURI(uri)] = uri
+	}
+
+	var drops []WatchlistDrop
+	previousByKey := indexByKey(previous)
+	for _, curr := range current {
+		prev, ok := curr.FindPrevious(previousByKey)
+		if !ok {
+			continue
+		}
+
+		currentRanks := make(map[string]int, len(curr.Results))
+		for _, r := range curr.Results {
+			if key := NormaliseURI(r.URI); currentRanks[key] == 0 {
+				currentRanks[key] = r.Rank
+			}
+		}
+
+		for _, r := range prev.Results {
+			if r.Rank > k {
+				continue
+			}
+			key := NormaliseURI(r.URI)
+			uri, ok := watched[key]
+			if !ok {
+				continue
+			}
+			if rank := currentRanks[key]; rank == 0 || rank > k {
+				drops = append(drops, WatchlistDrop{
+					Query:        curr.Query,
+					QueryID:      curr.QueryID,
+					Algorithm:    curr.AlgorithmLabel(),
+					URI:          uri,
+					PreviousRank: r.Rank,
+					CurrentRank:  rank,
+					Volatile:     curr.Volatile,
+				})
+			}
+		}
+	}
+
+	return drops
+}
+
+func watchlistK(options Options) int {
+	if options.WatchlistK > 0 {
+		return options.WatchlistK
+	}
+	return metrics.DefaultK
+}
+
+// WatchlistDrops returns the watchlist documents that fell out of the top K
+// since the previous run
+func (c *Comparison) WatchlistDrops() []WatchlistDrop {
+	return FindWatchlistDrops(c.current, c.previous, c.options.Watchlist, watchlistK(c.options))
+}
+
+// Describe summarises a drop in one line
+func (d WatchlistDrop) Describe() string {
+	label := d.Algorithm
+	if d.Volatile {
+		label += ", volatile"
+	}
+	if d.CurrentRank == 0 {
+		return fmt.Sprintf("%s (%s): %s was #%d, now not returned", d.Query, label, d.URI, d.PreviousRank)
+	}
+	return fmt.Sprintf("%s (%s): %s was #%d, now #%d", d.Query, label, d.URI, d.PreviousRank, d.CurrentRank)
+}
+
+func (f *Formatter) writeWatchlistDrops(drops []WatchlistDrop, k int) error {
+	if len(f.options.Watchlist) == 0 {
+		return nil
+	}
+
+	if err := f.writef("\nWatchlist (%d documents, top %d):\n", len(f.options.Watchlist), k); err != nil {
+		return fmt.Errorf("write watchlist header: %w", err)
+	}
+	if len(drops) == 0 {
+		if err := f.writef("  No watchlist documents left the top %d\n", k); err != nil {
+			return fmt.Errorf("write watchlist: %w", err)
+		}
+		return nil
+	}
+	for _, d := range drops {
+		if err := f.writef("  %s %s\n", iconWarning, d.Describe()); err != nil {
+			return fmt.Errorf("write watchlist drop: %w", err)
+		}
+	}
+	return nil
+}
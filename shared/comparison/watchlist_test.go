@@ -0,0 +1,66 @@
+package comparison
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestFindWatchlistDrops(t *testing.T) {
+	previous := []models.QueryResults{
+		ranked("/census", "/cpi", "/gdp"),
+		{Query: "other", Algorithm: "bm25", Results: []models.SearchResult{{Rank: 1, URI: "/census"}}},
+	}
+	watchlist := models.Watchlist{"/Census/", "/cpi", "/gdp", "/never-seen"}
+
+	tests := []struct {
+		name    string
+		current []models.QueryResults
+		k       int
+		want    []WatchlistDrop
+	}{
+		{
+			name:    "all kept",
+			current: []models.QueryResults{ranked("/cpi", "/census", "/gdp")},
+			k:       3,
+		},
+		{
+			name:    "pushed below k",
+			current: []models.QueryResults{ranked("/x", "/y", "/cpi", "/census", "/gdp")},
+			k:       3,
+			want: []WatchlistDrop{
+				{Query: "q", Algorithm: "bm25", URI: "/Census/", PreviousRank: 1, CurrentRank: 4},
+				{Query: "q", Algorithm: "bm25", URI: "/gdp", PreviousRank: 3, CurrentRank: 5},
+			},
+		},
+		{
+			name:    "no longer returned",
+			current: []models.QueryResults{ranked("/census", "/gdp")},
+			k:       3,
+			want:    []WatchlistDrop{{Query: "q", Algorithm: "bm25", URI: "/cpi", PreviousRank: 2}},
+		},
+		{
+			name: "volatile query",
+			current: []models.QueryResults{
+				{Query: "q", Algorithm: "bm25", Volatile: true, Results: ranked("/census", "/gdp").Results},
+			},
+			k:    3,
+			want: []WatchlistDrop{{Query: "q", Algorithm: "bm25", URI: "/cpi", PreviousRank: 2, Volatile: true}},
+		},
+		{
+			name:    "previously outside k",
+			current: []models.QueryResults{ranked("/census", "/cpi")},
+			k:       2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FindWatchlistDrops(tt.current, previous, watchlist, tt.k)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindWatchlistDrops() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
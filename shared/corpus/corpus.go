@@ -0,0 +1,157 @@
+// Package corpus builds the named document sets a query suite is run
+// across, so algorithm behaviour can be compared as the corpus grows or
+// narrows to a topic. Corpora are seeded, so the same definition always
+// yields the same documents.
+package corpus
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/testdata"
+)
+
+// Modes of building a corpus
+const (
+	ModeFile   = "file"   // Documents from a JSON file, optionally a seeded subset
+	ModeRandom = "random" // Generated documents
+)
+
+// namePattern keeps corpus names usable in index names and file names
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Spec defines one named corpus
+type Spec struct {
+	Name          string
+	Description   string
+	Mode          string
+	SourceFile    string
+	Seed          int64
+	DocumentCount int      // Documents to generate, or to sample from the file (0 keeps all)
+	ContentTypes  []string // Keep only these content types, if set
+	URIPrefixes   []string // Keep only URIs starting with one of these, if set
+	IDStrategy    string   // How document IDs are assigned: sequential (default) or uri_hash
+}
+
+// Validate checks the specs have unique, usable names and known modes
+func Validate(specs []Spec) error {
+	seen := make(map[string]bool, len(specs))
+	for i, s := range specs {
+		if !namePattern.MatchString(s.Name) {
+			return fmt.Errorf("corpus %d: name %q must be lowercase letters, digits, '-' or '_'", i+1, s.Name)
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("corpus %q is defined more than once", s.Name)
+		}
+		seen[s.Name] = true
+
+		switch s.Mode {
+		case ModeFile:
+			if s.SourceFile == "" {
+				return fmt.Errorf("corpus %q: mode is 'file' but source_file is not specified", s.Name)
+			}
+		case ModeRandom:
+			if s.DocumentCount <= 0 {
+				return fmt.Errorf("corpus %q: mode is 'random' but document_count is not set", s.Name)
+			}
+		default:
+			return fmt.Errorf("corpus %q: unknown mode %q", s.Name, s.Mode)
+		}
+		if err := testdata.ValidateIDStrategy(s.IDStrategy); err != nil {
+			return fmt.Errorf("corpus %q: %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// Build returns the corpus documents. A file corpus with a document count
+// takes a seeded sample of the file, kept in file order; because the sample
+// is a prefix of one seeded shuffle, smaller corpora with the same seed are
+// subsets of larger ones. Content type and URI filters apply before IDs are
+// assigned by the spec's ID strategy.
+func Build(spec Spec) ([]models.Document, error) {
+	var docs []models.Document
+	switch spec.Mode {
+	case ModeFile:
+		loaded, err := testdata.LoadDocumentsFromFile(spec.SourceFile)
+		if err != nil {
+			return nil, fmt.Errorf("corpus %s: %w", spec.Name, err)
+		}
+		docs = sample(loaded, spec.Seed, spec.DocumentCount)
+	case ModeRandom:
+		docs = testdata.GetSampleDocumentsWithSeed(spec.Seed, spec.DocumentCount)
+	default:
+		return nil, fmt.Errorf("corpus %s: unknown mode %q", spec.Name, spec.Mode)
+	}
+
+	docs = filter(docs, spec.ContentTypes, spec.URIPrefixes)
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("corpus %s: no documents left after filtering", spec.Name)
+	}
+	if err := testdata.AssignIDs(docs, spec.IDStrategy); err != nil {
+		return nil, fmt.Errorf("corpus %s: %w", spec.Name, err)
+	}
+	return docs, nil
+}
+
+// sample picks n documents with a seeded shuffle, keeping their original
+// order. A non-positive n, or one covering every document, keeps them all.
+func sample(docs []models.Document, seed int64, n int) []models.Document {
+	if n <= 0 || n >= len(docs) {
+		return docs
+	}
+
+	keep := make([]bool, len(docs))
+	for _, i := range rand.New(rand.NewSource(seed)).Perm(len(docs))[:n] {
+		keep[i] = true
+	}
+
+	sampled := make([]models.Document, 0, n)
+	for i, doc := range docs {
+		if keep[i] {
+			sampled = append(sampled, doc)
+		}
+	}
+	return sampled
+}
+
+// filter keeps documents of the given content types under the given URI
+// prefixes. Empty lists don't filter.
+func filter(docs []models.Document, contentTypes, uriPrefixes []string) []models.Document {
+	if len(contentTypes) == 0 && len(uriPrefixes) == 0 {
+		return docs
+	}
+
+	var kept []models.Document
+	for _, doc := range docs {
+		if len(contentTypes) > 0 && !contains(contentTypes, doc.ContentType) {
+			continue
+		}
+		if len(uriPrefixes) > 0 && !hasAnyPrefix(doc.URI, uriPrefixes) {
+			continue
+		}
+		kept = append(kept, doc)
+	}
+	return kept
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
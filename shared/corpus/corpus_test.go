@@ -0,0 +1,234 @@
+package corpus
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/testdata"
+)
+
+func writeDocs(t *testing.T, n int) string {
+	t.Helper()
+	var docs []models.Document
+	for i := 1; i <= n; i++ {
+		contentType, prefix := "article", "/go-"
+		if i%2 == 0 {
+			contentType, prefix = "tutorial", "/rust-"
+		}
+		docs = append(docs, models.Document{
+			ID:          fmt.Sprintf("%d", i),
+			URI:         fmt.Sprintf("%sdoc-%d", prefix, i),
+			ContentType: contentType,
+		})
+	}
+
+	data, err := json.Marshal(docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "documents.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func ids(docs []models.Document) []string {
+	out := make([]string, len(docs))
+	for i, d := range docs {
+		out[i] = d.ID
+	}
+	return out
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		specs   []Spec
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			specs: []Spec{
+				{Name: "small", Mode: ModeFile, SourceFile: "docs.json", DocumentCount: 10},
+				{Name: "generated_50", Mode: ModeRandom, DocumentCount: 50},
+			},
+		},
+		{name: "missing name", specs: []Spec{{Mode: ModeFile, SourceFile: "docs.json"}}, wantErr: true},
+		{name: "unusable name", specs: []Spec{{Name: "Go Only", Mode: ModeFile, SourceFile: "docs.json"}}, wantErr: true},
+		{
+			name: "duplicate name",
+			specs: []Spec{
+				{Name: "small", Mode: ModeFile, SourceFile: "docs.json"},
+				{Name: "small", Mode: ModeFile, SourceFile: "docs.json"},
+			},
+			wantErr: true,
+		},
+		{name: "file without source", specs: []Spec{{Name: "small", Mode: ModeFile}}, wantErr: true},
+		{name: "random without count", specs: []Spec{{Name: "small", Mode: ModeRandom}}, wantErr: true},
+		{name: "unknown mode", specs: []Spec{{Name: "small", Mode: "sql"}}, wantErr: true},
+		{name: "unknown id strategy", specs: []Spec{{Name: "small", Mode: ModeRandom, DocumentCount: 5, IDStrategy: "uuid"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.specs); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuild_FileSample(t *testing.T) {
+	path := writeDocs(t, 20)
+
+	small, err := Build(Spec{Name: "small", Mode: ModeFile, SourceFile: path, Seed: 7, DocumentCount: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	medium, err := Build(Spec{Name: "medium", Mode: ModeFile, SourceFile: path, Seed: 7, DocumentCount: 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := Build(Spec{Name: "all", Mode: ModeFile, SourceFile: path, Seed: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(small) != 5 || len(medium) != 12 || len(all) != 20 {
+		t.Fatalf("sizes = %d, %d, %d, want 5, 12, 20", len(small), len(medium), len(all))
+	}
+
+	inMedium := make(map[string]bool)
+	for _, id := range ids(medium) {
+		inMedium[id] = true
+	}
+	for _, id := range ids(small) {
+		if !inMedium[id] {
+			t.Errorf("document %s of the small corpus is missing from the medium corpus", id)
+		}
+	}
+
+	again, err := Build(Spec{Name: "small", Mode: ModeFile, SourceFile: path, Seed: 7, DocumentCount: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(ids(again)) != fmt.Sprint(ids(small)) {
+		t.Errorf("same seed built %v, then %v", ids(small), ids(again))
+	}
+}
+
+func TestBuild_URIHashIDs(t *testing.T) {
+	small, err := Build(Spec{Name: "small", Mode: ModeRandom, Seed: 7, DocumentCount: 5, IDStrategy: testdata.IDURIHash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	large, err := Build(Spec{Name: "large", Mode: ModeRandom, Seed: 7, DocumentCount: 12, IDStrategy: testdata.IDURIHash})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byURI := make(map[string]string)
+	for _, doc := range large {
+		byURI[doc.URI] = doc.ID
+	}
+	for _, doc := range small {
+		if doc.ID != testdata.URIHashID(doc.URI) {
+			t.Errorf("%s has ID %s, want the hash of its URI", doc.URI, doc.ID)
+		}
+		if byURI[doc.URI] != doc.ID {
+			t.Errorf("%s has ID %s with 5 documents but %s with 12", doc.URI, doc.ID, byURI[doc.URI])
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "documents.json")
+	dup := `[{"id":"1","uri":"/a"},{"id":"2","uri":"/a"}]`
+	if err := os.WriteFile(path, []byte(dup), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Build(Spec{Name: "dup", Mode: ModeFile, SourceFile: path, IDStrategy: testdata.IDURIHash}); err == nil {
+		t.Error("Build() of documents sharing a URI succeeded, want an error")
+	}
+}
+
+func TestBuild_Filters(t *testing.T) {
+	path := writeDocs(t, 10)
+
+	tests := []struct {
+		name    string
+		spec    Spec
+		wantIDs string
+		wantErr bool
+	}{
+		{
+			name:    "content type",
+			spec:    Spec{Name: "tutorials", Mode: ModeFile, SourceFile: path, ContentTypes: []string{"tutorial"}},
+			wantIDs: "[2 4 6 8 10]",
+		},
+		{
+			name:    "uri prefix",
+			spec:    Spec{Name: "go", Mode: ModeFile, SourceFile: path, URIPrefixes: []string{"/go-"}},
+			wantIDs: "[1 3 5 7 9]",
+		},
+		{
+			name: "nothing left",
+			spec: Spec{Name: "empty", Mode: ModeFile, SourceFile: path,
+				ContentTypes: []string{"tutorial"}, URIPrefixes: []string{"/go-"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := Build(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := fmt.Sprint(ids(docs)); !tt.wantErr && got != tt.wantIDs {
+				t.Errorf("Build() = %s, want %s", got, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestSummarise(t *testing.T) {
+	runAt := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	result := func(corpus string, uris ...string) models.QueryResults {
+		qr := models.QueryResults{QueryID: "q1", Query: "inflation", Algorithm: "bm25", Corpus: corpus, RunAt: runAt}
+		for i, uri := range uris {
+			qr.Results = append(qr.Results, models.SearchResult{Rank: i + 1, URI: uri, Score: float64(10 - i)})
+		}
+		return qr
+	}
+
+	results := []models.QueryResults{
+		result("small", "/a", "/x"),
+		result("large", "/a", "/b"),
+		{QueryID: "q2", Query: "gdp", Algorithm: "bm25", Corpus: "small", RunAt: runAt},
+	}
+
+	rows := Summarise(results, []string{"small", "large"}, map[string]int{"small": 5, "large": 20}, 2)
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+
+	small, large := rows[0], rows[1]
+	if small.Corpus != "small" || small.Queries != 2 || small.ZeroResults != 1 {
+		t.Errorf("small row = %+v", small)
+	}
+	if math.Abs(small.AvgResults-1) > 1e-9 || math.Abs(small.AvgTopScore-10) > 1e-9 {
+		t.Errorf("small averages = %.2f results, %.2f top score, want 1, 10", small.AvgResults, small.AvgTopScore)
+	}
+	if math.Abs(small.Overlap-0.5) > 1e-9 {
+		t.Errorf("small overlap = %.2f, want 0.5", small.Overlap)
+	}
+	if large.Overlap != 1 || large.Documents != 20 {
+		t.Errorf("large row = %+v, want overlap 1 with 20 documents", large)
+	}
+}
@@ -0,0 +1,282 @@
+package corpus
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/metrics"
+)
+
+// Default limits beyond which an algorithm counts as degrading with scale
+const (
+	// DefaultMaxLatencyExponent allows p95 latency to grow with the square
+	// root of the corpus size. An inverted index should grow far slower;
+	// an exponent near 1 means latency grows with the corpus.
+	DefaultMaxLatencyExponent = 0.5
+
+	// DefaultMinStability is the lowest acceptable share of a corpus's top
+	// K still in the top K of the next larger corpus
+	DefaultMinStability = 0.5
+)
+
+// ScalingThresholds are the limits behind the scaling verdict. A zero value
+// disables that rule.
+type ScalingThresholds struct {
+	MaxLatencyExponent float64 `json:"max_latency_exponent"`
+	MinStability       float64 `json:"min_stability"`
+}
+
+// ScalingPoint is one algorithm's behaviour on one corpus
+type ScalingPoint struct {
+	Corpus      string  `json:"corpus"`
+	Documents   int     `json:"documents"`
+	Queries     int     `json:"queries"`
+	ZeroResults int     `json:"zero_results"`
+	MeanTookMs  float64 `json:"mean_took_ms"`
+	P95TookMs   int     `json:"p95_took_ms"`
+
+	// Stability is the mean share of each query's top K on the next smaller
+	// corpus that is still in its top K here. The smallest corpus has none.
+	Stability *float64 `json:"stability,omitempty"`
+}
+
+// AlgorithmScaling is how one algorithm behaves as the corpus grows
+type AlgorithmScaling struct {
+	Algorithm string         `json:"algorithm"`
+	Points    []ScalingPoint `json:"points"` // Smallest corpus first
+
+	// LatencyExponent is the slope of log p95 latency against log corpus
+	// size: 0 when latency is flat, 1 when it grows with the corpus
+	LatencyExponent float64 `json:"latency_exponent"`
+
+	// MinStability is the lowest stability between consecutive corpora
+	MinStability float64 `json:"min_stability"`
+
+	Reasons []string `json:"reasons,omitempty"` // Why the algorithm counts as degrading
+}
+
+// Degrades reports whether the algorithm broke a scaling threshold
+func (a AlgorithmScaling) Degrades() bool {
+	return len(a.Reasons) > 0
+}
+
+// ScalingReport compares every algorithm across corpora of different sizes
+type ScalingReport struct {
+	K          int                `json:"k"`
+	Thresholds ScalingThresholds  `json:"thresholds"`
+	Corpora    []string           `json:"corpora"` // Smallest first
+	Algorithms []AlgorithmScaling `json:"algorithms"`
+	Degraded   int                `json:"degraded"`
+}
+
+// ScalingChain picks the corpora a scaling report can compare: those that
+// differ only in size. Corpora narrowed by content type or URI prefix are
+// left out, as are those drawn from another source than the most common
+// one, since comparing them would measure the filter rather than the size.
+// documents gives each corpus's size; the chain's sizes are returned with
+// the names of the corpora left out.
+func ScalingChain(specs []Spec, documents map[string]int) (map[string]int, []string) {
+	var sources []string
+	counts := make(map[string]int)
+	for _, s := range specs {
+		if _, ok := documents[s.Name]; !ok || s.filtered() {
+			continue
+		}
+		if counts[s.source()] == 0 {
+			sources = append(sources, s.source())
+		}
+		counts[s.source()]++
+	}
+	chosen := ""
+	for _, source := range sources {
+		if counts[source] > counts[chosen] {
+			chosen = source
+		}
+	}
+
+	chain := make(map[string]int)
+	var excluded []string
+	for _, s := range specs {
+		size, ok := documents[s.Name]
+		switch {
+		case !ok:
+			continue
+		case s.filtered() || s.source() != chosen:
+			excluded = append(excluded, s.Name)
+		default:
+			chain[s.Name] = size
+		}
+	}
+	return chain, excluded
+}
+
+// filtered reports whether the corpus keeps only some content types or URIs
+func (s Spec) filtered() bool {
+	return len(s.ContentTypes) > 0 || len(s.URIPrefixes) > 0
+}
+
+// source identifies what a corpus's documents are drawn from, so corpora
+// sampled from the same documents can be told apart from the rest
+func (s Spec) source() string {
+	if s.Mode == ModeRandom {
+		return fmt.Sprintf("%s:%d", s.Mode, s.Seed)
+	}
+	return s.Mode + ":" + s.SourceFile
+}
+
+// Scaling measures per-query latency and result stability of each algorithm
+// as the corpus grows. documents gives each corpus's size; results from
+// corpora without a size are ignored.
+func Scaling(results []models.QueryResults, documents map[string]int, k int, thresholds ScalingThresholds) ScalingReport {
+	if k <= 0 {
+		k = metrics.DefaultK
+	}
+	report := ScalingReport{K: k, Thresholds: thresholds}
+
+	for name := range documents {
+		report.Corpora = append(report.Corpora, name)
+	}
+	sort.Slice(report.Corpora, func(i, j int) bool {
+		a, b := report.Corpora[i], report.Corpora[j]
+		if documents[a] != documents[b] {
+			return documents[a] < documents[b]
+		}
+		return a < b
+	})
+
+	// Results by algorithm, then corpus, then query
+	byAlgorithm := make(map[string]map[string]map[string]models.QueryResults)
+	var algorithms []string
+	for _, qr := range results {
+		if _, ok := documents[qr.Corpus]; !ok {
+			continue
+		}
+		if byAlgorithm[qr.Algorithm] == nil {
+			byAlgorithm[qr.Algorithm] = make(map[string]map[string]models.QueryResults)
+			algorithms = append(algorithms, qr.Algorithm)
+		}
+		if byAlgorithm[qr.Algorithm][qr.Corpus] == nil {
+			byAlgorithm[qr.Algorithm][qr.Corpus] = make(map[string]models.QueryResults)
+		}
+		byAlgorithm[qr.Algorithm][qr.Corpus][suiteKey(qr)] = qr
+	}
+
+	for _, alg := range algorithms {
+		scaling := algorithmScaling(alg, report.Corpora, documents, byAlgorithm[alg], k)
+		scaling.Reasons = thresholds.check(scaling)
+		if scaling.Degrades() {
+			report.Degraded++
+		}
+		report.Algorithms = append(report.Algorithms, scaling)
+	}
+
+	return report
+}
+
+func algorithmScaling(algorithm string, corpora []string, documents map[string]int,
+	byCorpus map[string]map[string]models.QueryResults, k int) AlgorithmScaling {
+	scaling := AlgorithmScaling{Algorithm: algorithm, MinStability: 1}
+
+	var previous map[string]models.QueryResults
+	for _, name := range corpora {
+		queries, ok := byCorpus[name]
+		if !ok {
+			continue
+		}
+
+		point := ScalingPoint{Corpus: name, Documents: documents[name], Queries: len(queries)}
+		var took []int
+		for _, qr := range queries {
+			took = append(took, qr.TookMs)
+			point.MeanTookMs += float64(qr.TookMs)
+			if len(qr.Results) == 0 {
+				point.ZeroResults++
+			}
+		}
+		point.MeanTookMs /= float64(len(queries))
+		point.P95TookMs = metrics.Percentile(took, 0.95)
+
+		if previous != nil {
+			if stability, ok := stability(queries, previous, k); ok {
+				point.Stability = &stability
+				scaling.MinStability = math.Min(scaling.MinStability, stability)
+			}
+		}
+
+		scaling.Points = append(scaling.Points, point)
+		previous = queries
+	}
+
+	scaling.LatencyExponent = latencyExponent(scaling.Points)
+	return scaling
+}
+
+// check returns the reasons the algorithm breaks the thresholds, if any
+func (t ScalingThresholds) check(a AlgorithmScaling) []string {
+	var reasons []string
+	if t.MaxLatencyExponent > 0 && a.LatencyExponent > t.MaxLatencyExponent {
+		reasons = append(reasons, fmt.Sprintf("p95 latency grows with corpus size^%.2f (max %.2f)",
+			a.LatencyExponent, t.MaxLatencyExponent))
+	}
+	if t.MinStability > 0 && a.MinStability < t.MinStability {
+		reasons = append(reasons, fmt.Sprintf("only %.0f%% of top results kept as the corpus grows (min %.0f%%)",
+			a.MinStability*100, t.MinStability*100))
+	}
+	return reasons
+}
+
+// stability is the mean share of each query's top k on the smaller corpus
+// still in its top k on the larger one. Queries without results on the
+// smaller corpus are skipped.
+func stability(larger, smaller map[string]models.QueryResults, k int) (float64, bool) {
+	total, compared := 0.0, 0
+	for key, small := range smaller {
+		large, ok := larger[key]
+		if !ok || len(small.Results) == 0 {
+			continue
+		}
+		total += metrics.Overlap(uris(large.Results), uris(small.Results), k)
+		compared++
+	}
+	if compared == 0 {
+		return 0, false
+	}
+	return total / float64(compared), true
+}
+
+// latencyExponent fits log p95 latency against log corpus size by least
+// squares. Latency is floored at 1ms, so sub-millisecond queries read as
+// flat rather than as infinitely fast.
+func latencyExponent(points []ScalingPoint) float64 {
+	var xs, ys []float64
+	for _, p := range points {
+		if p.Documents <= 0 {
+			continue
+		}
+		xs = append(xs, math.Log(float64(p.Documents)))
+		ys = append(ys, math.Log(math.Max(float64(p.P95TookMs), 1)))
+	}
+	if len(xs) < 2 {
+		return 0
+	}
+
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= float64(len(xs))
+	meanY /= float64(len(ys))
+
+	var cov, varX float64
+	for i := range xs {
+		cov += (xs[i] - meanX) * (ys[i] - meanY)
+		varX += (xs[i] - meanX) * (xs[i] - meanX)
+	}
+	if varX == 0 {
+		return 0
+	}
+	return cov / varX
+}
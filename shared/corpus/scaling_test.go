@@ -0,0 +1,84 @@
+package corpus
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestScaling(t *testing.T) {
+	result := func(algorithm, corpus string, tookMs int, uris ...string) models.QueryResults {
+		qr := models.QueryResults{QueryID: "q1", Query: "inflation", Algorithm: algorithm, Corpus: corpus, TookMs: tookMs}
+		for i, uri := range uris {
+			qr.Results = append(qr.Results, models.SearchResult{Rank: i + 1, URI: uri})
+		}
+		return qr
+	}
+
+	results := []models.QueryResults{
+		// Flat latency, top results kept as the corpus grows
+		result("bm25", "small", 4, "/a", "/b"),
+		result("bm25", "medium", 4, "/a", "/b"),
+		result("bm25", "large", 4, "/a", "/c"),
+		// Latency grows with the corpus and the top results churn
+		result("fuzzy", "small", 1, "/a", "/b"),
+		result("fuzzy", "medium", 10, "/x", "/y"),
+		result("fuzzy", "large", 100, "/x", "/z"),
+		// Results from a corpus without a size are ignored
+		result("bm25", "unsized", 1000),
+	}
+	sizes := map[string]int{"large": 1000, "small": 10, "medium": 100}
+
+	report := Scaling(results, sizes, 2, ScalingThresholds{MaxLatencyExponent: 0.5, MinStability: 0.5})
+
+	if got := report.Corpora; len(got) != 3 || got[0] != "small" || got[2] != "large" {
+		t.Fatalf("corpora = %v, want smallest first", got)
+	}
+	if len(report.Algorithms) != 2 || report.Degraded != 1 {
+		t.Fatalf("got %d algorithms, %d degraded, want 2, 1", len(report.Algorithms), report.Degraded)
+	}
+
+	bm25, fuzzy := report.Algorithms[0], report.Algorithms[1]
+	if bm25.Degrades() || bm25.LatencyExponent != 0 {
+		t.Errorf("bm25 = %+v, want flat latency and no degradation", bm25)
+	}
+	if len(bm25.Points) != 3 || bm25.Points[0].Stability != nil {
+		t.Errorf("bm25 points = %+v, want 3 with no stability on the smallest", bm25.Points)
+	}
+	if s := bm25.Points[2].Stability; s == nil || math.Abs(*s-0.5) > 1e-9 {
+		t.Errorf("bm25 large stability = %v, want 0.5", s)
+	}
+
+	if math.Abs(fuzzy.LatencyExponent-1) > 1e-9 {
+		t.Errorf("fuzzy latency exponent = %.3f, want 1", fuzzy.LatencyExponent)
+	}
+	if fuzzy.MinStability != 0 || len(fuzzy.Reasons) != 2 {
+		t.Errorf("fuzzy = %+v, want stability 0 and both reasons", fuzzy)
+	}
+}
+
+func TestScalingChain(t *testing.T) {
+	file := func(name string, count int) Spec {
+		return Spec{Name: name, Mode: ModeFile, SourceFile: "docs.json", Seed: 1, DocumentCount: count}
+	}
+	specs := []Spec{
+		file("small", 10),
+		file("medium", 25),
+		file("large", 0),
+		{Name: "go-only", Mode: ModeFile, SourceFile: "docs.json", URIPrefixes: []string{"/go-"}},
+		{Name: "articles", Mode: ModeFile, SourceFile: "docs.json", ContentTypes: []string{"article"}},
+		{Name: "generated", Mode: ModeRandom, Seed: 7, DocumentCount: 500},
+		file("not-run", 5),
+	}
+	documents := map[string]int{"small": 10, "medium": 25, "large": 40, "go-only": 8, "articles": 12, "generated": 500}
+
+	chain, excluded := ScalingChain(specs, documents)
+	if want := map[string]int{"small": 10, "medium": 25, "large": 40}; !reflect.DeepEqual(chain, want) {
+		t.Errorf("chain = %v, want %v", chain, want)
+	}
+	if want := []string{"go-only", "articles", "generated"}; !reflect.DeepEqual(excluded, want) {
+		t.Errorf("excluded = %v, want %v", excluded, want)
+	}
+}
@@ -0,0 +1,102 @@
+package corpus
+
+import (
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/metrics"
+)
+
+// Row summarises one algorithm's results on one corpus
+type Row struct {
+	Corpus      string  `json:"corpus"`
+	Documents   int     `json:"documents"`
+	Algorithm   string  `json:"algorithm"`
+	Queries     int     `json:"queries"`
+	ZeroResults int     `json:"zero_results"`
+	AvgResults  float64 `json:"avg_results"`
+	AvgTopScore float64 `json:"avg_top_score"`
+
+	// Overlap is the mean top-k overlap of each query's results with the
+	// same query on the reference corpus, by URI. It is 1 for the
+	// reference itself and 0 when nothing can be compared.
+	Overlap float64 `json:"overlap"`
+}
+
+// Summarise returns one row per corpus and algorithm, in corpus then
+// algorithm order. documents gives each corpus's size; the largest corpus
+// is the reference the others are compared with at rank k.
+func Summarise(results []models.QueryResults, corpora []string, documents map[string]int, k int) []Row {
+	reference := ""
+	for _, name := range corpora {
+		if reference == "" || documents[name] > documents[reference] {
+			reference = name
+		}
+	}
+
+	referenceURIs := make(map[string][]string)
+	for _, qr := range results {
+		if qr.Corpus == reference {
+			referenceURIs[suiteKey(qr)] = uris(qr.Results)
+		}
+	}
+
+	var rows []Row
+	index := make(map[string]int)
+	compared := make(map[string]int)
+	for _, name := range corpora {
+		for _, qr := range results {
+			if qr.Corpus != name {
+				continue
+			}
+
+			key := name + "@" + qr.Algorithm
+			i, ok := index[key]
+			if !ok {
+				i = len(rows)
+				index[key] = i
+				rows = append(rows, Row{Corpus: name, Documents: documents[name], Algorithm: qr.Algorithm})
+			}
+
+			row := &rows[i]
+			row.Queries++
+			row.AvgResults += float64(len(qr.Results))
+			if len(qr.Results) == 0 {
+				row.ZeroResults++
+			} else {
+				row.AvgTopScore += qr.Results[0].Score
+			}
+			if ref, ok := referenceURIs[suiteKey(qr)]; ok && len(ref) > 0 {
+				row.Overlap += metrics.Overlap(uris(qr.Results), ref, k)
+				compared[key]++
+			}
+		}
+	}
+
+	for key, i := range index {
+		row := &rows[i]
+		if answered := row.Queries - row.ZeroResults; answered > 0 {
+			row.AvgTopScore /= float64(answered)
+		}
+		if row.Queries > 0 {
+			row.AvgResults /= float64(row.Queries)
+		}
+		if n := compared[key]; n > 0 {
+			row.Overlap /= float64(n)
+		}
+	}
+
+	return rows
+}
+
+// suiteKey identifies a query of the suite regardless of corpus
+func suiteKey(qr models.QueryResults) string {
+	qr.Corpus = ""
+	return qr.Key()
+}
+
+func uris(results []models.SearchResult) []string {
+	out := make([]string, len(results))
+	for i, r := range results {
+		out[i] = r.URI
+	}
+	return out
+}
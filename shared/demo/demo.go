@@ -0,0 +1,135 @@
+// Package demo provides a small self-contained corpus and query suite for
+// running the whole pipeline against the in-memory Elasticsearch fake.
+// The documents are invented stand-ins for real statistical releases.
+package demo
+
+import (
+	"time"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// IndexName is the index name recorded for demo searches
+const IndexName = "demo"
+
+// Corpus returns the demo documents
+func Corpus() []models.Document {
+	return []models.Document{
+		doc("d01", "Consumer price inflation, UK: latest release",
+			"/economy/inflationandpriceindices/bulletins/consumerpriceinflation", "bulletin", "2024-01-17",
+			"Consumer price inflation rose in the latest month. The consumer prices index including owner occupiers housing costs rose, with food and energy prices the largest contributors to inflation."),
+		doc("d02", "Inflation and price indices",
+			"/economy/inflationandpriceindices", "topic", "2023-11-01",
+			"Price indices, deflators and inflation measures, including the consumer prices index and the retail prices index."),
+		doc("d03", "Retail prices index: annual rate",
+			"/economy/inflationandpriceindices/timeseries/czbh", "timeseries", "2024-01-17",
+			"Annual rate of change of the retail prices index, a legacy measure of inflation."),
+		doc("d04", "Shoppers' basket of goods and services",
+			"/economy/inflationandpriceindices/articles/basketofgoods", "article", "2023-03-15",
+			"How the basket of goods and services used to measure consumer prices is chosen, and which items were added this year."),
+		doc("d05", "Gross domestic product: quarterly estimate",
+			"/economy/grossdomesticproductgdp/bulletins/quarterlyestimate", "bulletin", "2023-12-22",
+			"Gross domestic product grew slightly in the quarter. Services output rose while production and construction fell."),
+		doc("d06", "GDP monthly estimate",
+			"/economy/grossdomesticproductgdp/bulletins/gdpmonthlyestimate", "bulletin", "2024-01-12",
+			"Monthly gross domestic product estimate. GDP is estimated to have grown, driven by services."),
+		doc("d07", "Gross domestic product and productivity",
+			"/economy/grossdomesticproductgdp", "topic", "2023-06-30",
+			"Estimates of the size and growth of the economy, productivity and output per hour."),
+		doc("d08", "Labour market overview",
+			"/employmentandlabourmarket/peopleinwork/bulletins/labourmarketoverview", "bulletin", "2024-01-16",
+			"The unemployment rate was little changed. Employment rate estimates rose and the number of vacancies fell for the eighteenth consecutive period."),
+		doc("d09", "Unemployment rate (aged 16 and over, seasonally adjusted)",
+			"/employmentandlabourmarket/peoplenotinwork/unemployment/timeseries/mgsx", "timeseries", "2024-01-16",
+			"Unemployment rate for people aged 16 and over."),
+		doc("d10", "Young people not in education, employment or training",
+			"/employmentandlabourmarket/peoplenotinwork/unemployment/bulletins/youngpeople", "bulletin", "2023-11-23",
+			"Estimates of young people who are not in education, employment or training, with unemployment among young people broadly unchanged."),
+		doc("d11", "House price index",
+			"/economy/inflationandpriceindices/bulletins/housepriceindex", "bulletin", "2024-01-17",
+			"Average house prices increased over the year. House price inflation was highest in the north and lowest in London."),
+		doc("d12", "Private rental prices",
+			"/economy/inflationandpriceindices/bulletins/privaterentalprices", "bulletin", "2024-01-17",
+			"Private rental prices paid by tenants rose, the largest annual rise since records began. Rents increased in every region."),
+		doc("d13", "Housing affordability",
+			"/peoplepopulationandcommunity/housing/bulletins/housingaffordability", "bulletin", "2023-03-22",
+			"House prices compared with earnings. Housing became slightly more affordable as earnings grew faster than house prices."),
+		doc("d14", "Population estimates",
+			"/peoplepopulationandcommunity/populationandmigration/populationestimates", "topic", "2023-10-04",
+			"Estimates of the population by age and sex, including mid-year estimates for local areas."),
+	}
+}
+
+func doc(id, title, uri, contentType, date, body string) models.Document {
+	return models.Document{
+		ID:          id,
+		Title:       title,
+		URI:         uri,
+		ContentType: contentType,
+		Date:        date + "T00:00:00Z",
+		Body:        body,
+	}
+}
+
+// Index returns the demo corpus as a stored index
+func Index(generatedAt time.Time) *models.StoredIndex {
+	return &models.StoredIndex{
+		GeneratedAt: generatedAt,
+		Version:     "demo",
+		SourceIndex: IndexName,
+		Documents:   Corpus(),
+	}
+}
+
+// queries are the demo searches, shared by every algorithm
+var queries = []struct {
+	id, text, description string
+}{
+	{"inflation", "inflation", "Broad single-term query"},
+	{"gdp", "gross domestic product", "Multi-word economic measure"},
+	{"unemployment", "unemployment rate", "Headline labour market statistic"},
+	{"house-prices", "house prices", "Housing query with overlapping topics"},
+	{"cpi", "consumer prices index", "Specific index name"},
+}
+
+// Suite returns the current demo algorithms
+func Suite() []models.AlgorithmConfig {
+	return []models.AlgorithmConfig{
+		algorithm("bm25", "Title and body matching", "title^2", "body"),
+		algorithm("title_boost", "Strong title boost with URI matching", "title^4", "body", "uri^0.5"),
+	}
+}
+
+// PreviousSuite returns the algorithms as they were for the canned
+// previous run, so the demo comparison has changes to report
+func PreviousSuite() []models.AlgorithmConfig {
+	return []models.AlgorithmConfig{
+		algorithm("bm25", "Body matching only", "body"),
+		algorithm("title_boost", "Moderate title boost", "title^2", "body"),
+	}
+}
+
+func algorithm(name, description string, fields ...string) models.AlgorithmConfig {
+	alg := models.AlgorithmConfig{Name: name, Description: description}
+	for _, q := range queries {
+		fieldList := make([]interface{}, len(fields))
+		for i, f := range fields {
+			fieldList[i] = f
+		}
+		alg.Queries = append(alg.Queries, models.QueryConfig{
+			ID:          q.id,
+			Query:       q.text,
+			Description: q.description,
+			ESQuery: map[string]interface{}{
+				"query": map[string]interface{}{
+					"multi_match": map[string]interface{}{
+						"query":  q.text,
+						"fields": fieldList,
+					},
+				},
+				"size": 10,
+			},
+		})
+	}
+	return alg
+}
@@ -0,0 +1,53 @@
+package demo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch/memory"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/queryexec"
+)
+
+func TestSuites(t *testing.T) {
+	executor := queryexec.NewExecutor(memory.NewClientWithIndex(IndexName, Corpus()), IndexName, false)
+
+	run := func(algorithms []models.AlgorithmConfig) map[string][]string {
+		rankings := make(map[string][]string)
+		for _, alg := range algorithms {
+			for _, qc := range alg.Queries {
+				qr, err := executor.Execute(context.Background(), qc, alg.Name)
+				if err != nil {
+					t.Fatalf("Execute() error = %v", err)
+				}
+				if len(qr.Results) == 0 {
+					t.Errorf("%s %q returned no results", alg.Name, qc.Query)
+				}
+				for _, r := range qr.Results {
+					rankings[qr.Key()] = append(rankings[qr.Key()], r.URI)
+				}
+			}
+		}
+		return rankings
+	}
+
+	current := run(Suite())
+	previous := run(PreviousSuite())
+
+	changed := 0
+	for key, uris := range current {
+		if len(uris) != len(previous[key]) {
+			changed++
+			continue
+		}
+		for i := range uris {
+			if uris[i] != previous[key][i] {
+				changed++
+				break
+			}
+		}
+	}
+	if changed == 0 {
+		t.Error("expected the demo suites to rank at least one query differently")
+	}
+}
@@ -0,0 +1,167 @@
+package experiment
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// QueryPlaceholder is replaced by each query's text in bucket templates
+const QueryPlaceholder = "{{query}}"
+
+// Experiment describes a production A/B experiment
+type Experiment struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Queries     []string `json:"queries"` // Query texts to run through every bucket
+	Buckets     []Bucket `json:"buckets"`
+}
+
+// Bucket is one arm of an experiment. It either carries the exact ES query
+// template used in production, or names an algorithm in the queries file.
+type Bucket struct {
+	Name      string                 `json:"name"`
+	Traffic   float64                `json:"traffic"` // Share of traffic, in percent
+	Algorithm string                 `json:"algorithm,omitempty"`
+	Template  map[string]interface{} `json:"template,omitempty"`
+}
+
+// Load reads an experiment definition from a JSON file
+func Load(path string) (*Experiment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read experiment file: %w", err)
+	}
+
+	var exp Experiment
+	if err := json.Unmarshal(data, &exp); err != nil {
+		return nil, fmt.Errorf("parse experiment: %w", err)
+	}
+
+	if err := exp.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &exp, nil
+}
+
+// Validate checks that the experiment is complete and internally consistent
+func (e *Experiment) Validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("experiment has no name")
+	}
+	if len(e.Buckets) == 0 {
+		return fmt.Errorf("experiment %s has no buckets", e.Name)
+	}
+
+	seen := make(map[string]bool, len(e.Buckets))
+	var total float64
+	for _, b := range e.Buckets {
+		if b.Name == "" {
+			return fmt.Errorf("experiment %s has a bucket with no name", e.Name)
+		}
+		if seen[b.Name] {
+			return fmt.Errorf("experiment %s has duplicate bucket %s", e.Name, b.Name)
+		}
+		seen[b.Name] = true
+
+		if b.Template == nil && b.Algorithm == "" {
+			return fmt.Errorf("bucket %s needs a template or an algorithm", b.Name)
+		}
+		if b.Template != nil && len(e.Queries) == 0 {
+			return fmt.Errorf("bucket %s uses a template but the experiment lists no queries", b.Name)
+		}
+		total += b.Traffic
+	}
+
+	if total > 100.0001 {
+		return fmt.Errorf("experiment %s bucket traffic adds up to %.1f%%", e.Name, total)
+	}
+
+	return nil
+}
+
+// AlgorithmName returns the algorithm name used for a bucket's results
+func (e *Experiment) AlgorithmName(b Bucket) string {
+	return e.Name + "." + b.Name
+}
+
+// Generate builds one algorithm per bucket. Template buckets are expanded
+// for every experiment query; algorithm buckets copy the queries of the
+// named algorithm from existing, restricted to the experiment queries if
+// any are listed.
+func (e *Experiment) Generate(existing []models.AlgorithmConfig) ([]models.AlgorithmConfig, error) {
+	byName := make(map[string]models.AlgorithmConfig, len(existing))
+	for _, alg := range existing {
+		byName[alg.Name] = alg
+	}
+
+	wanted := make(map[string]bool, len(e.Queries))
+	for _, q := range e.Queries {
+		wanted[q] = true
+	}
+
+	algorithms := make([]models.AlgorithmConfig, 0, len(e.Buckets))
+	for _, b := range e.Buckets {
+		alg := models.AlgorithmConfig{
+			Name:        e.AlgorithmName(b),
+			Description: fmt.Sprintf("Experiment %s, bucket %s (%.1f%% of traffic)", e.Name, b.Name, b.Traffic),
+		}
+
+		if b.Template != nil {
+			for _, q := range e.Queries {
+				alg.Queries = append(alg.Queries, models.QueryConfig{
+					Query:   q,
+					ESQuery: expandTemplate(b.Template, q).(map[string]interface{}),
+				})
+			}
+		} else {
+			source, ok := byName[b.Algorithm]
+			if !ok {
+				return nil, fmt.Errorf("bucket %s: algorithm %s not found in queries file", b.Name, b.Algorithm)
+			}
+			alg.Description += ", using " + b.Algorithm
+			alg.Script = source.Script
+			alg.Federation = source.Federation
+			for _, qc := range source.Queries {
+				if len(wanted) > 0 && !wanted[qc.Query] {
+					continue
+				}
+				alg.Queries = append(alg.Queries, qc)
+			}
+		}
+
+		if len(alg.Queries) == 0 {
+			return nil, fmt.Errorf("bucket %s has no queries", b.Name)
+		}
+		algorithms = append(algorithms, alg)
+	}
+
+	return algorithms, nil
+}
+
+// expandTemplate deep-copies a template, substituting the query text for
+// every placeholder in string values
+func expandTemplate(v interface{}, query string) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, child := range val {
+			out[k] = expandTemplate(child, query)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, child := range val {
+			out[i] = expandTemplate(child, query)
+		}
+		return out
+	case string:
+		return strings.ReplaceAll(val, QueryPlaceholder, query)
+	default:
+		return val
+	}
+}
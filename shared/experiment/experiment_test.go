@@ -0,0 +1,66 @@
+package experiment
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestExperiment_Generate(t *testing.T) {
+	exp := &Experiment{
+		Name:    "trial",
+		Queries: []string{"inflation", "gdp"},
+		Buckets: []Bucket{
+			{
+				Name:    "control",
+				Traffic: 50,
+				Template: map[string]interface{}{
+					"query": map[string]interface{}{
+						"match": map[string]interface{}{"title": "{{query}}"},
+					},
+				},
+			},
+			{Name: "treatment", Traffic: 50, Algorithm: "title_boost"},
+		},
+	}
+	if err := exp.Validate(); err != nil {
+		t.Fatalf("validate failed: %v", err)
+	}
+
+	existing := []models.AlgorithmConfig{
+		{
+			Name:       "title_boost",
+			Federation: &models.FederationConfig{Indices: []models.FederatedIndex{{Index: "{index}"}, {Index: "topics"}}},
+			Queries: []models.QueryConfig{
+				{Query: "inflation"},
+				{Query: "unemployment"},
+				{Query: "gdp"},
+			},
+		},
+	}
+
+	algorithms, err := exp.Generate(existing)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	if len(algorithms) != 2 {
+		t.Fatalf("expected 2 algorithms, got %d", len(algorithms))
+	}
+	if algorithms[0].Name != "trial.control" {
+		t.Errorf("unexpected algorithm name %s", algorithms[0].Name)
+	}
+
+	match := algorithms[0].Queries[1].ESQuery["query"].(map[string]interface{})["match"].(map[string]interface{})
+	if match["title"] != "gdp" {
+		t.Errorf("expected placeholder to be replaced with gdp, got %v", match["title"])
+	}
+
+	if algorithms[1].Federation != existing[0].Federation {
+		t.Error("expected treatment to keep the federation of the algorithm it uses")
+	}
+
+	if len(algorithms[1].Queries) != 2 {
+		t.Errorf("expected treatment to be restricted to 2 experiment queries, got %d", len(algorithms[1].Queries))
+	}
+}
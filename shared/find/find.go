@@ -0,0 +1,76 @@
+package find
+
+import (
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// Criteria selects results within stored runs. Text criteria are
+// case-insensitive substring matches; empty criteria match everything.
+type Criteria struct {
+	URI       string
+	Title     string
+	Query     string
+	Algorithm string
+	MaxRank   int // Only match results at or above this rank; 0 for any rank
+}
+
+// Match is a single result that met the criteria
+type Match struct {
+	Run       string  `json:"run"`
+	QueryID   string  `json:"query_id,omitempty"`
+	Query     string  `json:"query"`
+	Algorithm string  `json:"algorithm"`
+	Rank      int     `json:"rank"`
+	Score     float64 `json:"score"`
+	Title     string  `json:"title"`
+	URI       string  `json:"uri"`
+}
+
+// IsEmpty reports whether no result criteria were given
+func (c Criteria) IsEmpty() bool {
+	return c.URI == "" && c.Title == ""
+}
+
+// Search returns the results of one run that meet the criteria, in query
+// then rank order
+func Search(run string, results []models.QueryResults, criteria Criteria) []Match {
+	uri := strings.ToLower(criteria.URI)
+	title := strings.ToLower(criteria.Title)
+
+	var matches []Match
+	for _, qr := range results {
+		if criteria.Algorithm != "" && qr.Algorithm != criteria.Algorithm {
+			continue
+		}
+		if criteria.Query != "" && !qr.Matches(criteria.Query) {
+			continue
+		}
+
+		for _, r := range qr.Results {
+			if criteria.MaxRank > 0 && r.Rank > criteria.MaxRank {
+				continue
+			}
+			if uri != "" && !strings.Contains(strings.ToLower(r.URI), uri) {
+				continue
+			}
+			if title != "" && !strings.Contains(strings.ToLower(r.Title), title) {
+				continue
+			}
+
+			matches = append(matches, Match{
+				Run:       run,
+				QueryID:   qr.QueryID,
+				Query:     qr.Query,
+				Algorithm: qr.AlgorithmLabel(),
+				Rank:      r.Rank,
+				Score:     r.Score,
+				Title:     r.Title,
+				URI:       r.URI,
+			})
+		}
+	}
+
+	return matches
+}
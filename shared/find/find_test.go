@@ -0,0 +1,55 @@
+package find
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestSearch(t *testing.T) {
+	results := []models.QueryResults{
+		{
+			Query:     "inflation",
+			Algorithm: "bm25",
+			Results: []models.SearchResult{
+				{Rank: 1, Title: "Consumer price inflation", URI: "/economy/inflation"},
+				{Rank: 2, Title: "Labour market", URI: "/employmentandlabourmarket/overview"},
+			},
+		},
+		{
+			Query:     "jobs",
+			Algorithm: "title_boost",
+			Results: []models.SearchResult{
+				{Rank: 1, Title: "Vacancies", URI: "/EmploymentAndLabourMarket/vacancies"},
+				{Rank: 5, Title: "Employment rates", URI: "/employmentandlabourmarket/rates"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		criteria  Criteria
+		wantRanks []int
+	}{
+		{name: "uri substring ignores case", criteria: Criteria{URI: "/employment"}, wantRanks: []int{2, 1, 5}},
+		{name: "limited to top ranks", criteria: Criteria{URI: "/employment", MaxRank: 2}, wantRanks: []int{2, 1}},
+		{name: "filtered by algorithm", criteria: Criteria{URI: "/employment", Algorithm: "bm25"}, wantRanks: []int{2}},
+		{name: "filtered by query", criteria: Criteria{Title: "e", Query: "JOBS"}, wantRanks: []int{1, 5}},
+		{name: "title substring", criteria: Criteria{Title: "inflation"}, wantRanks: []int{1}},
+		{name: "no match", criteria: Criteria{URI: "/people"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Search("run_1", results, tt.criteria)
+			if len(got) != len(tt.wantRanks) {
+				t.Fatalf("expected %d matches, got %+v", len(tt.wantRanks), got)
+			}
+			for i, m := range got {
+				if m.Rank != tt.wantRanks[i] || m.Run != "run_1" {
+					t.Errorf("match %d = %+v, want rank %d", i, m, tt.wantRanks[i])
+				}
+			}
+		})
+	}
+}
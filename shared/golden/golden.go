@@ -0,0 +1,170 @@
+// Package golden reads and writes golden results files: expected results of
+// a query suite, checked into version control so changes in search
+// behaviour show up as diffs in review. Only what identifies the ranked
+// results is kept, in a fixed order, so regenerating an unchanged suite
+// leaves the file byte for byte the same.
+package golden
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// FormatVersion is the version of the golden file format written
+const FormatVersion = 1
+
+// File is a golden results file
+type File struct {
+	Version int     `json:"version"`
+	Queries []Query `json:"queries"`
+}
+
+// Query holds the expected results of one query and algorithm
+type Query struct {
+	QueryID   string   `json:"query_id,omitempty"`
+	Query     string   `json:"query"`
+	Algorithm string   `json:"algorithm"`
+	Corpus    string   `json:"corpus,omitempty"`
+	Volatile  bool     `json:"volatile,omitempty"` // Results expected to churn; not checked
+	Results   []Result `json:"results"`
+}
+
+// Result is one expected result. Scores are rounded so float noise between
+// runs doesn't rewrite the file.
+type Result struct {
+	Rank  int     `json:"rank"`
+	ID    string  `json:"id,omitempty"`
+	URI   string  `json:"uri"`
+	Title string  `json:"title"`
+	Score float64 `json:"score"`
+}
+
+// New builds a golden file from a run's results, sorted by algorithm,
+// corpus and query
+func New(results []models.QueryResults) File {
+	f := File{Version: FormatVersion, Queries: make([]Query, 0, len(results))}
+	for _, qr := range results {
+		q := Query{
+			QueryID:   qr.QueryID,
+			Query:     qr.Query,
+			Algorithm: qr.Algorithm,
+			Corpus:    qr.Corpus,
+			Volatile:  qr.Volatile,
+			Results:   make([]Result, 0, len(qr.Results)),
+		}
+		for _, r := range qr.Results {
+			q.Results = append(q.Results, Result{
+				Rank:  r.Rank,
+				ID:    r.ID,
+				URI:   r.URI,
+				Title: r.Title,
+				Score: math.Round(r.Score*10000) / 10000,
+			})
+		}
+		f.Queries = append(f.Queries, q)
+	}
+	sort.SliceStable(f.Queries, func(i, j int) bool {
+		a, b := f.Queries[i], f.Queries[j]
+		if a.Algorithm != b.Algorithm {
+			return a.Algorithm < b.Algorithm
+		}
+		if a.Corpus != b.Corpus {
+			return a.Corpus < b.Corpus
+		}
+		return queryKey(a) < queryKey(b)
+	})
+	return f
+}
+
+// Results returns the golden file's queries as query results, so they can
+// be compared like a run's
+func (f File) Results() []models.QueryResults {
+	results := make([]models.QueryResults, 0, len(f.Queries))
+	for _, q := range f.Queries {
+		qr := models.QueryResults{
+			QueryID:   q.QueryID,
+			Query:     q.Query,
+			Algorithm: q.Algorithm,
+			Corpus:    q.Corpus,
+			Volatile:  q.Volatile,
+			Results:   make([]models.SearchResult, 0, len(q.Results)),
+		}
+		for _, r := range q.Results {
+			qr.Results = append(qr.Results, models.SearchResult{
+				ID:        r.ID,
+				Rank:      r.Rank,
+				Title:     r.Title,
+				URI:       r.URI,
+				Algorithm: q.Algorithm,
+				Score:     r.Score,
+			})
+		}
+		results = append(results, qr)
+	}
+	return results
+}
+
+// WithoutVolatile drops the queries marked volatile in either the golden or
+// the current results, since their results are expected to churn, and
+// returns how many were dropped
+func WithoutVolatile(expected, current []models.QueryResults) ([]models.QueryResults, []models.QueryResults, int) {
+	volatile := make(map[string]bool)
+	for _, results := range [][]models.QueryResults{expected, current} {
+		for _, qr := range results {
+			if qr.Volatile {
+				volatile[qr.Key()] = true
+			}
+		}
+	}
+	keep := func(results []models.QueryResults) []models.QueryResults {
+		kept := make([]models.QueryResults, 0, len(results))
+		for _, qr := range results {
+			if !volatile[qr.Key()] {
+				kept = append(kept, qr)
+			}
+		}
+		return kept
+	}
+	return keep(expected), keep(current), len(volatile)
+}
+
+// Write saves results as a golden file
+func Write(path string, results []models.QueryResults) error {
+	data, err := json.MarshalIndent(New(results), "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal golden results: %w", err)
+	}
+	// #nosec G306 - golden files are meant to be committed
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("write golden results: %w", err)
+	}
+	return nil
+}
+
+// Load reads a golden file
+func Load(path string) (File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, fmt.Errorf("read golden results: %w", err)
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return File{}, fmt.Errorf("parse golden results: %w", err)
+	}
+	if f.Version != FormatVersion {
+		return File{}, fmt.Errorf("unsupported golden results version %d, want %d", f.Version, FormatVersion)
+	}
+	return f, nil
+}
+
+func queryKey(q Query) string {
+	if q.QueryID != "" {
+		return q.QueryID
+	}
+	return models.Slugify(q.Query)
+}
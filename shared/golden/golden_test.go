@@ -0,0 +1,94 @@
+package golden
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestWriteLoad(t *testing.T) {
+	results := []models.QueryResults{
+		{Query: "wages", Algorithm: "title_boost", RunAt: time.Now(), TookMs: 12,
+			Results: []models.SearchResult{{Rank: 1, URI: "/wages", Title: "Wages", Score: 2.123456789}}},
+		{QueryID: "cpi", Query: "inflation", Algorithm: "bm25", RunAt: time.Now(), RequestID: "r1",
+			Results: []models.SearchResult{{Rank: 1, ID: "d1", URI: "/cpi", Title: "CPI", Score: 1.5, Date: "2024-01-01"}}},
+		{Query: "gdp", Algorithm: "bm25", Results: []models.SearchResult{}},
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "golden.json")
+	if err := Write(path, results); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	var order []string
+	for _, q := range f.Queries {
+		order = append(order, q.Algorithm+"/"+queryKey(q))
+	}
+	if want := []string{"bm25/cpi", "bm25/gdp", "title_boost/wages"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	if got := f.Queries[2].Results[0].Score; got != 2.1235 {
+		t.Errorf("score = %v, want 2.1235", got)
+	}
+
+	back := f.Results()
+	if back[0].Key() != results[1].Key() || back[0].Results[0].ID != "d1" || !back[0].RunAt.IsZero() {
+		t.Errorf("Results()[0] = %+v, want the cpi query without run details", back[0])
+	}
+
+	// Regenerating from the same results, in any order, rewrites the same bytes
+	first, _ := os.ReadFile(path)
+	if err := Write(path, []models.QueryResults{results[2], results[0], results[1]}); err != nil {
+		t.Fatal(err)
+	}
+	second, _ := os.ReadFile(path)
+	if !bytes.Equal(first, second) {
+		t.Errorf("rewritten golden file differs:\n%s\n---\n%s", first, second)
+	}
+}
+
+func TestLoad_RejectsUnknownVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden.json")
+	if err := os.WriteFile(path, []byte(`{"version": 2, "queries": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load() accepted version 2")
+	}
+}
+
+func TestWithoutVolatile(t *testing.T) {
+	expected := []models.QueryResults{
+		{Query: "inflation", Algorithm: "bm25"},
+		{Query: "latest releases", Algorithm: "bm25", Volatile: true},
+		{Query: "new this week", Algorithm: "bm25"},
+	}
+	current := []models.QueryResults{
+		{Query: "inflation", Algorithm: "bm25"},
+		{Query: "latest releases", Algorithm: "bm25", Volatile: true},
+		{Query: "new this week", Algorithm: "bm25", Volatile: true}, // Marked since the golden file was written
+	}
+
+	gotExpected, gotCurrent, dropped := WithoutVolatile(expected, current)
+	if dropped != 2 {
+		t.Errorf("dropped = %d, want 2", dropped)
+	}
+	if len(gotExpected) != 1 || len(gotCurrent) != 1 || gotExpected[0].Query != "inflation" || gotCurrent[0].Query != "inflation" {
+		t.Errorf("WithoutVolatile() kept %v and %v, want only inflation", gotExpected, gotCurrent)
+	}
+
+	// Volatile survives a round trip through a golden file
+	if back := New(current).Results(); !back[1].Volatile {
+		t.Errorf("Results() = %+v, want latest releases marked volatile", back[1])
+	}
+}
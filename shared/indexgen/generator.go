@@ -3,28 +3,35 @@ package indexgen
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
 	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/clock"
 )
 
 const version = "2.0.0"
 
 // Generator handles index generation
 type Generator struct {
-	client  *elasticsearch.Client
+	client  elasticsearch.API
 	verbose bool
+	clock   clock.Clock
 }
 
 // NewGenerator creates a new index generator
-func NewGenerator(client *elasticsearch.Client, verbose bool) *Generator {
+func NewGenerator(client elasticsearch.API, verbose bool) *Generator {
 	return &Generator{
 		client:  client,
 		verbose: verbose,
+		clock:   clock.Real{},
 	}
 }
 
+// SetClock sets the clock used to stamp generated indexes
+func (g *Generator) SetClock(c clock.Clock) {
+	g.clock = clock.OrReal(c)
+}
+
 // Generate fetches documents and creates a stored index
 func (g *Generator) Generate(ctx context.Context, sourceIndex string, count int) (*models.StoredIndex, error) {
 	docs, err := g.client.Fetch(ctx, sourceIndex, count)
@@ -33,7 +40,7 @@ func (g *Generator) Generate(ctx context.Context, sourceIndex string, count int)
 	}
 
 	stored := &models.StoredIndex{
-		GeneratedAt: time.Now(),
+		GeneratedAt: g.clock.Now(),
 		Version:     version,
 		SourceIndex: sourceIndex,
 		Documents:   docs,
@@ -1,9 +1,12 @@
 package indexgen
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch/memory"
 	"github.com/ONSdigital/dis-search-test-bed/models"
 )
 
@@ -46,3 +49,101 @@ func TestSaver_SaveIndex(t *testing.T) {
 			len(index.Documents), len(loaded.Documents))
 	}
 }
+
+func TestLoader_SkipsUnchangedSnapshot(t *testing.T) {
+	ctx := context.Background()
+	client := memory.NewClient()
+	stored := &models.StoredIndex{Documents: []models.Document{
+		{ID: "1", Title: "Inflation", URI: "/inflation"},
+		{ID: "2", Title: "Wages", URI: "/wages"},
+	}}
+	changed := &models.StoredIndex{Documents: []models.Document{
+		{ID: "1", Title: "Inflation and prices", URI: "/inflation"},
+		{ID: "2", Title: "Wages", URI: "/wages"},
+	}}
+	mapping := map[string]interface{}{"mappings": map[string]interface{}{"properties": map[string]interface{}{
+		"title": map[string]interface{}{"type": "text", "analyzer": "english"},
+	}}}
+
+	tests := []struct {
+		name        string
+		stored      *models.StoredIndex
+		mapping     map[string]interface{}
+		force       bool
+		before      func()
+		wantSkipped bool
+	}{
+		{name: "first load", stored: stored},
+		{name: "same snapshot", stored: stored, wantSkipped: true},
+		{name: "forced", stored: stored, force: true},
+		{name: "changed document", stored: changed},
+		{name: "index deleted since", stored: changed, before: func() {
+			_ = client.DeleteIndex(ctx, "test")
+		}},
+		{name: "changed snapshot loaded", stored: changed, wantSkipped: true},
+		{name: "changed mapping", stored: changed, mapping: mapping},
+		{name: "changed mapping loaded", stored: changed, mapping: mapping, wantSkipped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.before != nil {
+				tt.before()
+			}
+			loader := NewLoader()
+			loader.SetForceReload(tt.force)
+			loader.SetMapping(tt.mapping)
+			if err := loader.LoadIntoElasticsearch(ctx, client, "test", tt.stored); err != nil {
+				t.Fatalf("LoadIntoElasticsearch() error = %v", err)
+			}
+			if loader.Skipped() != tt.wantSkipped {
+				t.Errorf("Skipped() = %v, want %v", loader.Skipped(), tt.wantSkipped)
+			}
+			if count, _ := client.CountDocuments(ctx, "test"); count != len(tt.stored.Documents) {
+				t.Errorf("index holds %d documents, want %d", count, len(tt.stored.Documents))
+			}
+		})
+	}
+}
+
+// laggingClient hides documents from the first searches after a load, as a
+// cluster does until every shard has refreshed
+type laggingClient struct {
+	*memory.Client
+	stale int
+}
+
+func (c *laggingClient) Search(ctx context.Context, index string, query map[string]interface{}) (*elasticsearch.SearchResponse, error) {
+	res, err := c.Client.Search(ctx, index, query)
+	if err == nil && c.stale > 0 {
+		c.stale--
+		res.Hits.Total.Value = 0
+	}
+	return res, err
+}
+
+func TestLoader_WaitsUntilSearchable(t *testing.T) {
+	stored := &models.StoredIndex{Documents: []models.Document{{ID: "1", Title: "Inflation"}}}
+
+	tests := []struct {
+		name    string
+		stale   int
+		timeout time.Duration
+		wantErr bool
+	}{
+		{name: "searchable at once", timeout: time.Second},
+		{name: "catches up", stale: 2, timeout: time.Second},
+		{name: "never catches up", stale: 1000, timeout: 50 * time.Millisecond, wantErr: true},
+		{name: "no wait", stale: 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &laggingClient{Client: memory.NewClient(), stale: tt.stale}
+			loader := NewLoader()
+			loader.SetReadiness(elasticsearch.HealthGreen, tt.timeout)
+			err := loader.LoadIntoElasticsearch(context.Background(), client, "test", stored)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadIntoElasticsearch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,23 +2,73 @@ package indexgen
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
 	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/clock"
+	"github.com/ONSdigital/dis-search-test-bed/shared/output"
 )
 
 // Loader handles loading stored indexes
-type Loader struct{}
+type Loader struct {
+	forceReload  bool
+	skipped      bool
+	health       string
+	readyTimeout time.Duration
+	mapping      map[string]interface{}
+}
+
+// readyPollInterval is how often the sentinel search is retried while the
+// index catches up
+const readyPollInterval = 100 * time.Millisecond
 
 // NewLoader creates a new loader
 func NewLoader() *Loader {
 	return &Loader{}
 }
 
+// SetForceReload reloads indexes even when the snapshot is already loaded
+func (l *Loader) SetForceReload(force bool) {
+	l.forceReload = force
+}
+
+// SetReadiness makes LoadIntoElasticsearch wait, for up to timeout, until
+// the index reaches the given cluster health and a sentinel search sees
+// every document. An empty health or elasticsearch.HealthNone skips the
+// health check; a zero timeout
+// skips waiting altogether.
+func (l *Loader) SetReadiness(health string, timeout time.Duration) {
+	l.health = health
+	l.readyTimeout = timeout
+}
+
+// SetMapping creates indexes with the given create index body in place of
+// elasticsearch.DefaultMapping
+func (l *Loader) SetMapping(mapping map[string]interface{}) {
+	l.mapping = mapping
+}
+
+// Mapping returns the create index body indexes are loaded with
+func (l *Loader) Mapping() map[string]interface{} {
+	if l.mapping != nil {
+		return l.mapping
+	}
+	return elasticsearch.DefaultMapping()
+}
+
+// Skipped reports whether the last LoadIntoElasticsearch found the snapshot
+// already loaded and left the index as it was
+func (l *Loader) Skipped() bool {
+	return l.skipped
+}
+
 // Load reads a stored index from disk
 func (l *Loader) Load(path string) (*models.StoredIndex, error) {
 	data, err := os.ReadFile(path)
@@ -34,9 +84,47 @@ func (l *Loader) Load(path string) (*models.StoredIndex, error) {
 	return &index, nil
 }
 
-// LoadIntoElasticsearch loads a stored index into Elasticsearch
-func (l *Loader) LoadIntoElasticsearch(ctx context.Context, client *elasticsearch.Client,
+// Fingerprint hashes the documents of a stored index together with the
+// mapping they are loaded with, so any change to either gives a new value
+func Fingerprint(stored *models.StoredIndex, mapping map[string]interface{}) (string, error) {
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	if err := enc.Encode(mapping); err != nil {
+		return "", fmt.Errorf("encode mapping: %w", err)
+	}
+	if err := enc.Encode(stored.Documents); err != nil {
+		return "", fmt.Errorf("encode documents: %w", err)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// LoadIntoElasticsearch loads a stored index into Elasticsearch. When the
+// client keeps load markers and the index's marker shows the same snapshot
+// was loaded last, with the same document count, the index is left as it is
+// unless SetForceReload was given. Either way, it returns once the index is
+// ready to search, as set by SetReadiness.
+func (l *Loader) LoadIntoElasticsearch(ctx context.Context, client elasticsearch.Indexer,
 	indexName string, stored *models.StoredIndex) error {
+	l.skipped = false
+
+	mapping := l.Mapping()
+	fingerprint, err := Fingerprint(stored, mapping)
+	if err != nil {
+		return fmt.Errorf("fingerprint index: %w", err)
+	}
+
+	markers, hasMarkers := client.(elasticsearch.Markers)
+	if hasMarkers && !l.forceReload {
+		loaded, err := isLoaded(ctx, client, markers, indexName, fingerprint, len(stored.Documents))
+		if err != nil {
+			return err
+		}
+		if loaded {
+			l.skipped = true
+			return l.waitUntilReady(ctx, client, indexName, len(stored.Documents))
+		}
+	}
+
 	// Delete if exists
 	exists, err := client.IndexExists(ctx, indexName)
 	if err != nil {
@@ -44,13 +132,19 @@ func (l *Loader) LoadIntoElasticsearch(ctx context.Context, client *elasticsearc
 	}
 
 	if exists {
+		// Drop the marker first, so a reload that fails part way isn't
+		// mistaken for a complete one next time
+		if hasMarkers {
+			if err := markers.DeleteLoadMarker(ctx, indexName); err != nil {
+				return fmt.Errorf("delete load marker: %w", err)
+			}
+		}
 		if err := client.DeleteIndex(ctx, indexName); err != nil {
 			return fmt.Errorf("delete index: %w", err)
 		}
 	}
 
 	// Create index
-	mapping := elasticsearch.DefaultMapping()
 	if err := client.CreateIndex(ctx, indexName, mapping); err != nil {
 		return fmt.Errorf("create index: %w", err)
 	}
@@ -65,7 +159,90 @@ func (l *Loader) LoadIntoElasticsearch(ctx context.Context, client *elasticsearc
 		return fmt.Errorf("refresh index: %w", err)
 	}
 
-	return nil
+	if hasMarkers {
+		marker := elasticsearch.LoadMarker{
+			Index:       indexName,
+			Fingerprint: fingerprint,
+			Documents:   len(stored.Documents),
+			LoadedAt:    clock.Real{}.Now(),
+		}
+		if err := markers.PutLoadMarker(ctx, marker); err != nil {
+			return fmt.Errorf("store load marker: %w", err)
+		}
+	}
+
+	return l.waitUntilReady(ctx, client, indexName, len(stored.Documents))
+}
+
+// waitUntilReady waits for the index to reach the configured health and
+// for a sentinel search to count every loaded document, so the first
+// queries of a run never see a partially refreshed index
+func (l *Loader) waitUntilReady(ctx context.Context, client elasticsearch.Indexer, indexName string, documents int) error {
+	if l.readyTimeout <= 0 {
+		return nil
+	}
+	clk := clock.Real{}
+	deadline := clk.Now().Add(l.readyTimeout)
+
+	if waiter, ok := client.(elasticsearch.HealthWaiter); ok && l.health != "" && l.health != elasticsearch.HealthNone {
+		if err := waiter.WaitForHealth(ctx, indexName, l.health, l.readyTimeout); err != nil {
+			return fmt.Errorf("wait for %s health: %w", l.health, err)
+		}
+	}
+
+	searcher, ok := client.(elasticsearch.Searcher)
+	if !ok {
+		return nil
+	}
+	sentinel := map[string]interface{}{
+		"size":             0,
+		"track_total_hits": true,
+		"query":            map[string]interface{}{"match_all": map[string]interface{}{}},
+	}
+	for {
+		res, err := searcher.Search(ctx, indexName, sentinel)
+		if err != nil {
+			return fmt.Errorf("sentinel search: %w", err)
+		}
+		if res.Hits.Total.Value == documents {
+			return nil
+		}
+		if clk.Now().After(deadline) {
+			return fmt.Errorf("index %s not searchable after %s: sentinel search found %d of %d documents",
+				indexName, l.readyTimeout, res.Hits.Total.Value, documents)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(readyPollInterval):
+		}
+	}
+}
+
+// isLoaded reports whether an index still holds the snapshot with the
+// given fingerprint: its marker matches and its document count agrees
+func isLoaded(ctx context.Context, client elasticsearch.Indexer, markers elasticsearch.Markers,
+	indexName, fingerprint string, documents int) (bool, error) {
+	marker, err := markers.LoadMarker(ctx, indexName)
+	if err != nil {
+		return false, fmt.Errorf("read load marker: %w", err)
+	}
+	if marker == nil || marker.Fingerprint != fingerprint || marker.Documents != documents {
+		return false, nil
+	}
+
+	exists, err := client.IndexExists(ctx, indexName)
+	if err != nil {
+		return false, fmt.Errorf("check index: %w", err)
+	}
+	if !exists {
+		return false, nil
+	}
+	count, err := client.CountDocuments(ctx, indexName)
+	if err != nil {
+		return false, fmt.Errorf("count documents: %w", err)
+	}
+	return count == documents, nil
 }
 
 // Saver handles saving indexes
@@ -92,32 +269,10 @@ func (s *Saver) SaveIndex(index *models.StoredIndex) error {
 		return fmt.Errorf("write index: %w", err)
 	}
 
-	// Create metadata
-	metadataPath := filepath.Join(s.runFolder, "metadata.txt")
-	metadata := fmt.Sprintf(`Search Test Bed - Index Generation
-Generated: %s
-Version: %s
-
-Index Information:
-- Source Index: %s
-- Document Count: %d
-
-Files in this folder:
-- index.json        : Generated test index
-- metadata.txt      : This file
-- results.csv       : Query results (created when running queries)
-- results.json      : Query results in JSON format
-- comparison.txt    : Comparison report (created when comparing)
-`,
-		index.GeneratedAt.Format("2006-01-02 15:04:05"),
-		index.Version,
-		index.SourceIndex,
-		len(index.Documents),
-	)
-
-	// #nosec G306 - output files are test results, not sensitive
-	if err := os.WriteFile(metadataPath, []byte(metadata), 0644); err != nil {
-		return fmt.Errorf("write metadata: %w", err)
+	if err := output.UpdateManifest(s.runFolder, func(m *output.Manifest) {
+		m.Index = output.NewIndexInfo(index)
+	}); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
 	}
 
 	return nil
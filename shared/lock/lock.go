@@ -0,0 +1,126 @@
+// Package lock provides advisory file locks so that processes sharing a
+// data directory (CI jobs and people running the tool by hand) take turns
+// updating the same files rather than overwriting each other's output.
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileName is the lock file created inside a locked directory
+const FileName = ".lock"
+
+// retryInterval is how often a contended lock is retried while waiting
+const retryInterval = 100 * time.Millisecond
+
+// ErrLocked is returned when a lock is still held by another process after
+// the wait has expired
+var ErrLocked = errors.New("locked by another process")
+
+// ErrReadOnly is returned when the lock file can't be created because the
+// directory is read-only or not writable by this user
+var ErrReadOnly = errors.New("directory is read-only")
+
+// Lock is an exclusive advisory lock held on a file
+type Lock struct {
+	path string
+	file *os.File
+}
+
+// Acquire takes an exclusive lock on path, creating the file if needed. If
+// another process holds the lock, it is retried until wait has passed, after
+// which the error wraps ErrLocked and names the holder.
+func Acquire(path string, wait time.Duration) (*Lock, error) {
+	// #nosec G304 - lock path is built from the configured data directory
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		if isReadOnly(err) {
+			return nil, fmt.Errorf("open lock file: %w: %w", ErrReadOnly, err)
+		}
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(wait)
+	for {
+		err = tryLock(f)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errWouldBlock) {
+			_ = f.Close()
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+		if !time.Now().Before(deadline) {
+			holder := readHolder(f)
+			_ = f.Close()
+			return nil, fmt.Errorf("%s is %w (%s); retry once it finishes", path, ErrLocked, holder)
+		}
+		time.Sleep(retryInterval)
+	}
+
+	if err := writeHolder(f); err != nil {
+		_ = unlock(f)
+		_ = f.Close()
+		return nil, fmt.Errorf("record lock holder: %w", err)
+	}
+
+	return &Lock{path: path, file: f}, nil
+}
+
+// AcquireDir locks a directory through the lock file inside it
+func AcquireDir(dir string, wait time.Duration) (*Lock, error) {
+	return Acquire(filepath.Join(dir, FileName), wait)
+}
+
+// Release unlocks the lock. The lock file is left in place, since removing
+// it could let two later processes lock different files.
+func (l *Lock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+
+	err := l.file.Truncate(0)
+	if unlockErr := unlock(l.file); unlockErr != nil && err == nil {
+		err = unlockErr
+	}
+	if closeErr := l.file.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	l.file = nil
+
+	if err != nil {
+		return fmt.Errorf("release lock %s: %w", l.path, err)
+	}
+	return nil
+}
+
+// writeHolder records who holds the lock, for contention errors
+func writeHolder(f *os.File) error {
+	host, _ := os.Hostname()
+	holder := fmt.Sprintf("pid %d on %s since %s: %s\n", os.Getpid(), host,
+		time.Now().Format(time.RFC3339), strings.Join(os.Args, " "))
+
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt([]byte(holder), 0); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// readHolder returns the holder recorded in the lock file, if any
+func readHolder(f *os.File) string {
+	buf := make([]byte, 512)
+	n, _ := f.ReadAt(buf, 0)
+	holder := strings.TrimSpace(string(buf[:n]))
+	if holder == "" {
+		return "holder unknown"
+	}
+	return "held by " + holder
+}
@@ -0,0 +1,27 @@
+//go:build !unix
+
+package lock
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+var errWouldBlock = errors.New("lock would block")
+
+// tryLock always succeeds: advisory locks are only enforced on unix
+// systems, which is where CI and shared data directories run
+func tryLock(*os.File) error {
+	return nil
+}
+
+func unlock(*os.File) error {
+	return nil
+}
+
+// isReadOnly reports whether opening a file failed because the directory
+// isn't writable
+func isReadOnly(err error) bool {
+	return errors.Is(err, fs.ErrPermission)
+}
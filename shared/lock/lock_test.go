@@ -0,0 +1,76 @@
+//go:build unix
+
+package lock
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAcquireDir(t *testing.T) {
+	dir := t.TempDir()
+
+	first, err := AcquireDir(dir, 0)
+	if err != nil {
+		t.Fatalf("AcquireDir() error = %v", err)
+	}
+
+	_, err = AcquireDir(dir, 2*retryInterval)
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("AcquireDir() on held lock error = %v, want ErrLocked", err)
+	}
+	if !strings.Contains(err.Error(), "held by pid") {
+		t.Errorf("contention error %q does not name the holder", err)
+	}
+
+	if err := first.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if err := first.Release(); err != nil {
+		t.Errorf("second Release() error = %v", err)
+	}
+
+	second, err := AcquireDir(dir, 0)
+	if err != nil {
+		t.Fatalf("AcquireDir() after release error = %v", err)
+	}
+	_ = second.Release()
+}
+
+func TestAcquire_Waits(t *testing.T) {
+	path := t.TempDir() + "/results.lock"
+
+	held, err := Acquire(path, 0)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	go func() {
+		time.Sleep(2 * retryInterval)
+		_ = held.Release()
+	}()
+
+	l, err := Acquire(path, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Acquire() did not wait for release: %v", err)
+	}
+	_ = l.Release()
+}
+
+func TestAcquireDir_ReadOnly(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can write to read-only directories")
+	}
+
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chmod(dir, 0755) }()
+
+	if _, err := AcquireDir(dir, 0); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("AcquireDir() error = %v, want ErrReadOnly", err)
+	}
+}
@@ -0,0 +1,90 @@
+package metrics
+
+// DefaultRBOPersistence weights the top of a ranking: at 0.9 the first
+// 10 ranks carry about 86% of the weight
+const DefaultRBOPersistence = 0.9
+
+// Overlap returns the fraction of the reference's top k items that also
+// appear in the ranking's top k. It is 0 if the reference is empty.
+func Overlap(ranking, reference []string, k int) float64 {
+	if k <= 0 {
+		k = DefaultK
+	}
+	ranking = topK(ranking, k)
+	reference = topK(reference, k)
+	if len(reference) == 0 {
+		return 0
+	}
+
+	inRanking := make(map[string]bool, len(ranking))
+	for _, item := range ranking {
+		inRanking[item] = true
+	}
+
+	shared := 0
+	for _, item := range reference {
+		if inRanking[item] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(reference))
+}
+
+// RankBiasedOverlap compares the top k of two rankings, giving more weight
+// to agreement near the top. At each depth d the share of items common to
+// both prefixes is weighted by p^(d-1); the result is normalised so that
+// identical rankings score 1 and disjoint rankings 0.
+func RankBiasedOverlap(a, b []string, k int, p float64) float64 {
+	if k <= 0 {
+		k = DefaultK
+	}
+	if p <= 0 || p >= 1 {
+		p = DefaultRBOPersistence
+	}
+
+	depth := len(a)
+	if len(b) > depth {
+		depth = len(b)
+	}
+	if depth > k {
+		depth = k
+	}
+	if depth == 0 {
+		return 0
+	}
+
+	seenA := make(map[string]bool, depth)
+	seenB := make(map[string]bool, depth)
+	shared := 0
+	weight := 1.0
+	var sum, total float64
+
+	for d := 0; d < depth; d++ {
+		if d < len(a) {
+			if seenB[a[d]] {
+				shared++
+			}
+			seenA[a[d]] = true
+		}
+		if d < len(b) {
+			if seenA[b[d]] {
+				shared++
+			}
+			seenB[b[d]] = true
+		}
+
+		sum += weight * float64(shared) / float64(d+1)
+		total += weight
+		weight *= p
+	}
+
+	return sum / total
+}
+
+func topK(items []string, k int) []string {
+	if len(items) > k {
+		return items[:k]
+	}
+	return items
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOverlap(t *testing.T) {
+	tests := []struct {
+		name      string
+		ranking   []string
+		reference []string
+		k         int
+		want      float64
+	}{
+		{name: "identical", ranking: []string{"a", "b"}, reference: []string{"a", "b"}, k: 2, want: 1},
+		{name: "outside k", ranking: []string{"a", "x", "b"}, reference: []string{"b", "c"}, k: 2, want: 0},
+		{name: "within k", ranking: []string{"a", "x", "b"}, reference: []string{"b", "c"}, k: 3, want: 0.5},
+		{name: "empty reference", ranking: []string{"a"}, k: 3, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Overlap(tt.ranking, tt.reference, tt.k); got != tt.want {
+				t.Errorf("Overlap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRankBiasedOverlap(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		want float64
+	}{
+		{name: "identical", a: []string{"a", "b", "c"}, b: []string{"a", "b", "c"}, want: 1},
+		{name: "disjoint", a: []string{"a", "b"}, b: []string{"c", "d"}, want: 0},
+		{name: "swapped top pair", a: []string{"a", "b"}, b: []string{"b", "a"}, want: (0 + 0.5*1) / 1.5},
+		{name: "empty", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RankBiasedOverlap(tt.a, tt.b, 10, 0.5)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("RankBiasedOverlap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	topAgrees := RankBiasedOverlap([]string{"a", "b", "x"}, []string{"a", "b", "c"}, 10, 0.9)
+	bottomAgrees := RankBiasedOverlap([]string{"x", "b", "c"}, []string{"a", "b", "c"}, 10, 0.9)
+	if topAgrees <= bottomAgrees {
+		t.Errorf("expected agreement at the top to weigh more: %v <= %v", topAgrees, bottomAgrees)
+	}
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Bootstrap defaults
+const (
+	DefaultResamples = 1000
+	ConfidenceLevel  = 0.95
+)
+
+// Interval is a mean with its bootstrap confidence interval
+type Interval struct {
+	Mean  float64 `json:"mean"`
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+// BootstrapMean resamples values with replacement and returns their mean
+// with the percentile interval at ConfidenceLevel. The same seed gives the
+// same interval. ok is false when there are fewer than two values.
+func BootstrapMean(values []float64, resamples int, seed int64) (interval Interval, ok bool) {
+	n := len(values)
+	if n < 2 {
+		return Interval{}, false
+	}
+	if resamples <= 0 {
+		resamples = DefaultResamples
+	}
+
+	for _, v := range values {
+		interval.Mean += v
+	}
+	interval.Mean /= float64(n)
+
+	rng := rand.New(rand.NewSource(seed))
+	means := make([]float64, resamples)
+	for i := range means {
+		sum := 0.0
+		for j := 0; j < n; j++ {
+			sum += values[rng.Intn(n)]
+		}
+		means[i] = sum / float64(n)
+	}
+	sort.Float64s(means)
+
+	tail := (1 - ConfidenceLevel) / 2
+	lower := int(math.Floor(tail * float64(resamples)))
+	upper := int(math.Ceil((1-tail)*float64(resamples))) - 1
+	interval.Lower = means[lower]
+	interval.Upper = means[min(max(upper, lower), resamples-1)]
+	return interval, true
+}
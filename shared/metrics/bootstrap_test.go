@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBootstrapMean(t *testing.T) {
+	values := []float64{0.2, 0.4, 0.5, 0.6, 0.9, 0.3, 0.7, 0.5}
+
+	interval, ok := BootstrapMean(values, 2000, 42)
+	if !ok {
+		t.Fatal("BootstrapMean() ok = false, want true")
+	}
+	if math.Abs(interval.Mean-0.5125) > 1e-9 {
+		t.Errorf("Mean = %.4f, want 0.5125", interval.Mean)
+	}
+	if interval.Lower >= interval.Mean || interval.Upper <= interval.Mean {
+		t.Errorf("interval = %+v, want the mean strictly inside", interval)
+	}
+	if interval.Lower < 0.2 || interval.Upper > 0.9 {
+		t.Errorf("interval = %+v, want bounds within the range of the values", interval)
+	}
+
+	again, _ := BootstrapMean(values, 2000, 42)
+	if again != interval {
+		t.Errorf("same seed gave %+v, then %+v", interval, again)
+	}
+
+	constant, _ := BootstrapMean([]float64{1, 1, 1}, 0, 1)
+	if constant != (Interval{Mean: 1, Lower: 1, Upper: 1}) {
+		t.Errorf("constant values gave %+v, want a zero-width interval at 1", constant)
+	}
+
+	if _, ok := BootstrapMean([]float64{1}, 100, 1); ok {
+		t.Error("BootstrapMean() ok = true for a single value")
+	}
+}
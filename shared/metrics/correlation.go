@@ -0,0 +1,69 @@
+package metrics
+
+// KendallTau returns Kendall's rank correlation between two rankings over
+// the items they share: 1 when the shared items come in the same order, -1
+// when reversed. ok is false when fewer than two items are shared.
+func KendallTau(a, b []string) (tau float64, ok bool) {
+	ranksA, ranksB := commonRanks(a, b)
+	n := len(ranksA)
+	if n < 2 {
+		return 0, false
+	}
+
+	concordant, discordant := 0, 0
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if (ranksA[i]-ranksA[j])*(ranksB[i]-ranksB[j]) > 0 {
+				concordant++
+			} else {
+				discordant++
+			}
+		}
+	}
+	return float64(concordant-discordant) / float64(n*(n-1)/2), true
+}
+
+// Spearman returns Spearman's rank correlation between two rankings over
+// the items they share, each re-ranked 1..n among the shared items. ok is
+// false when fewer than two items are shared.
+func Spearman(a, b []string) (rho float64, ok bool) {
+	ranksA, ranksB := commonRanks(a, b)
+	n := len(ranksA)
+	if n < 2 {
+		return 0, false
+	}
+
+	sumSquares := 0
+	for i := range ranksA {
+		d := ranksA[i] - ranksB[i]
+		sumSquares += d * d
+	}
+	return 1 - 6*float64(sumSquares)/float64(n*(n*n-1)), true
+}
+
+// commonRanks returns the rank of each shared item in a and in b, counting
+// only shared items, in the order the items appear in a. Repeated items
+// keep their first position.
+func commonRanks(a, b []string) (ranksA, ranksB []int) {
+	inA := make(map[string]bool, len(a))
+	for _, item := range a {
+		inA[item] = true
+	}
+
+	positionB := make(map[string]int, len(b))
+	for _, item := range b {
+		if _, seen := positionB[item]; !seen && inA[item] {
+			positionB[item] = len(positionB) + 1
+		}
+	}
+
+	seen := make(map[string]bool, len(positionB))
+	for _, item := range a {
+		if rank, ok := positionB[item]; ok && !seen[item] {
+			seen[item] = true
+			ranksA = append(ranksA, len(ranksA)+1)
+			ranksB = append(ranksB, rank)
+		}
+	}
+	return ranksA, ranksB
+}
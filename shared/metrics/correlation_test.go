@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRankCorrelation(t *testing.T) {
+	tests := []struct {
+		name         string
+		a, b         []string
+		wantTau      float64
+		wantSpearman float64
+		wantOK       bool
+	}{
+		{name: "same order", a: []string{"/a", "/b", "/c"}, b: []string{"/a", "/b", "/c"}, wantTau: 1, wantSpearman: 1, wantOK: true},
+		{name: "reversed", a: []string{"/a", "/b", "/c"}, b: []string{"/c", "/b", "/a"}, wantTau: -1, wantSpearman: -1, wantOK: true},
+		{
+			name:         "one swap among shared items",
+			a:            []string{"/a", "/x", "/b", "/c", "/d"},
+			b:            []string{"/b", "/a", "/y", "/c", "/d"},
+			wantTau:      4.0 / 6,
+			wantSpearman: 1 - 6*2.0/(4*15),
+			wantOK:       true,
+		},
+		{name: "one shared item", a: []string{"/a", "/b"}, b: []string{"/a", "/c"}, wantOK: false},
+		{name: "nothing shared", a: []string{"/a"}, b: []string{"/b"}, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tau, ok := KendallTau(tt.a, tt.b)
+			if ok != tt.wantOK || math.Abs(tau-tt.wantTau) > 1e-9 {
+				t.Errorf("KendallTau() = %.4f, %v, want %.4f, %v", tau, ok, tt.wantTau, tt.wantOK)
+			}
+			rho, ok := Spearman(tt.a, tt.b)
+			if ok != tt.wantOK || math.Abs(rho-tt.wantSpearman) > 1e-9 {
+				t.Errorf("Spearman() = %.4f, %v, want %.4f, %v", rho, ok, tt.wantSpearman, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// DefaultK is the rank cut-off used when none is configured
+const DefaultK = 10
+
+// Diversity describes how varied the top K results of a query are
+type Diversity struct {
+	K                    int     `json:"k"`
+	DistinctContentTypes int     `json:"distinct_content_types"`
+	DistinctTopics       int     `json:"distinct_topics"`
+	IntraListSimilarity  float64 `json:"intra_list_similarity"` // Mean pairwise title similarity, 0 to 1
+}
+
+// CalculateDiversity measures the diversity of the top k results. Topics
+// are taken from the first segment of each result's URI; similarity is
+// the Jaccard overlap of title words, averaged over all pairs.
+func CalculateDiversity(results []models.SearchResult, k int) Diversity {
+	if k <= 0 {
+		k = DefaultK
+	}
+	if len(results) > k {
+		results = results[:k]
+	}
+
+	d := Diversity{K: k}
+	contentTypes := make(map[string]bool)
+	topics := make(map[string]bool)
+	titleWords := make([]map[string]bool, 0, len(results))
+
+	for _, r := range results {
+		if r.ContentType != "" {
+			contentTypes[r.ContentType] = true
+		}
+		if topic := Topic(r.URI); topic != "" {
+			topics[topic] = true
+		}
+		titleWords = append(titleWords, wordSet(r.Title))
+	}
+
+	d.DistinctContentTypes = len(contentTypes)
+	d.DistinctTopics = len(topics)
+
+	pairs := 0
+	var total float64
+	for i := 0; i < len(titleWords); i++ {
+		for j := i + 1; j < len(titleWords); j++ {
+			total += jaccard(titleWords[i], titleWords[j])
+			pairs++
+		}
+	}
+	if pairs > 0 {
+		d.IntraListSimilarity = total / float64(pairs)
+	}
+
+	return d
+}
+
+// Topic returns the top-level section of a URI, e.g. "economy" for
+// "/economy/inflationandpriceindices/bulletins/..."
+func Topic(uri string) string {
+	uri = strings.TrimPrefix(uri, "/")
+	if i := strings.IndexByte(uri, '/'); i >= 0 {
+		uri = uri[:i]
+	}
+	return strings.ToLower(uri)
+}
+
+func wordSet(text string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+
+	intersection := 0
+	for w := range a {
+		if b[w] {
+			intersection++
+		}
+	}
+	union := len(a) + len(b) - intersection
+
+	return float64(intersection) / float64(union)
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestCalculateDiversity(t *testing.T) {
+	results := []models.SearchResult{
+		{Title: "Consumer price inflation", URI: "/economy/inflation/a", ContentType: "bulletin"},
+		{Title: "Consumer price inflation", URI: "/economy/inflation/b", ContentType: "bulletin"},
+		{Title: "Labour market overview", URI: "/employmentandlabourmarket/a", ContentType: "article"},
+		{Title: "Ignored past k", URI: "/people/a", ContentType: "dataset"},
+	}
+
+	tests := []struct {
+		name           string
+		k              int
+		wantTypes      int
+		wantTopics     int
+		wantSimilarity float64
+	}{
+		{name: "cut-off at k", k: 3, wantTypes: 2, wantTopics: 2, wantSimilarity: 1.0 / 3},
+		{name: "first two identical titles", k: 2, wantTypes: 1, wantTopics: 1, wantSimilarity: 1},
+		{name: "single result", k: 1, wantTypes: 1, wantTopics: 1, wantSimilarity: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CalculateDiversity(results, tt.k)
+			if got.DistinctContentTypes != tt.wantTypes {
+				t.Errorf("content types = %d, want %d", got.DistinctContentTypes, tt.wantTypes)
+			}
+			if got.DistinctTopics != tt.wantTopics {
+				t.Errorf("topics = %d, want %d", got.DistinctTopics, tt.wantTopics)
+			}
+			if math.Abs(got.IntraListSimilarity-tt.wantSimilarity) > 1e-9 {
+				t.Errorf("similarity = %f, want %f", got.IntraListSimilarity, tt.wantSimilarity)
+			}
+		})
+	}
+}
+
+func TestTopic(t *testing.T) {
+	tests := map[string]string{
+		"/economy/inflationandpriceindices/bulletins/x": "economy",
+		"economy":   "economy",
+		"/People/a": "people",
+		"":          "",
+	}
+
+	for uri, want := range tests {
+		if got := Topic(uri); got != want {
+			t.Errorf("Topic(%q) = %q, want %q", uri, got, want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// Latency is how long an algorithm's queries took in Elasticsearch
+type Latency struct {
+	Algorithm string  `json:"algorithm"`
+	Queries   int     `json:"queries"`
+	MeanMs    float64 `json:"mean_ms"`
+	P95Ms     int     `json:"p95_ms"`
+}
+
+// MeasureLatency returns the mean and p95 took time of each algorithm's
+// queries, in the order the algorithms first appear
+func MeasureLatency(results []models.QueryResults) []Latency {
+	took := make(map[string][]int)
+	var order []string
+	for _, qr := range results {
+		algorithm := qr.AlgorithmLabel()
+		if _, ok := took[algorithm]; !ok {
+			order = append(order, algorithm)
+		}
+		took[algorithm] = append(took[algorithm], qr.TookMs)
+	}
+
+	latencies := make([]Latency, 0, len(order))
+	for _, algorithm := range order {
+		l := Latency{Algorithm: algorithm, Queries: len(took[algorithm]), P95Ms: Percentile(took[algorithm], 0.95)}
+		for _, ms := range took[algorithm] {
+			l.MeanMs += float64(ms)
+		}
+		l.MeanMs /= float64(l.Queries)
+		latencies = append(latencies, l)
+	}
+	return latencies
+}
+
+// Percentile returns the nearest-rank percentile p (0 to 1) of values
+func Percentile(values []int, p float64) int {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
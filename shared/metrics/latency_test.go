@@ -0,0 +1,22 @@
+package metrics
+
+import "testing"
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		values []int
+		p      float64
+		want   int
+	}{
+		{values: nil, p: 0.95, want: 0},
+		{values: []int{7}, p: 0.95, want: 7},
+		{values: []int{5, 1, 3, 2, 4}, p: 0.5, want: 3},
+		{values: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 100}, p: 0.95, want: 100},
+	}
+
+	for _, tt := range tests {
+		if got := Percentile(tt.values, tt.p); got != tt.want {
+			t.Errorf("Percentile(%v, %.2f) = %d, want %d", tt.values, tt.p, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// NDCG returns the normalised discounted cumulative gain of the top k of a
+// ranking, given graded judgments of its items. Gain is 2^grade - 1 and is
+// discounted by log2(rank + 1); the result is divided by the DCG of the
+// ideal ordering of every judged item, so it runs from 0 to 1. Unjudged
+// items count as not relevant. ok is false when no item is judged relevant,
+// since NDCG is undefined without an ideal ranking.
+func NDCG(ranking []string, grades map[string]int, k int) (ndcg float64, ok bool) {
+	if k <= 0 {
+		k = DefaultK
+	}
+
+	ideal := make([]int, 0, len(grades))
+	for _, g := range grades {
+		if g > 0 {
+			ideal = append(ideal, g)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(ideal)))
+
+	idealDCG := dcg(ideal, k)
+	if idealDCG == 0 {
+		return 0, false
+	}
+
+	actual := make([]int, 0, k)
+	for _, item := range topK(ranking, k) {
+		actual = append(actual, grades[item])
+	}
+	return dcg(actual, k) / idealDCG, true
+}
+
+// dcg is the discounted cumulative gain of the first k grades
+func dcg(grades []int, k int) float64 {
+	total := 0.0
+	for i, g := range grades {
+		if i >= k {
+			break
+		}
+		if g > 0 {
+			total += (math.Pow(2, float64(g)) - 1) / math.Log2(float64(i+2))
+		}
+	}
+	return total
+}
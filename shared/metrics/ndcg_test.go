@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNDCG(t *testing.T) {
+	grades := map[string]int{"/a": 3, "/b": 2, "/c": 1, "/d": 0}
+
+	tests := []struct {
+		name    string
+		ranking []string
+		grades  map[string]int
+		k       int
+		want    float64
+		wantOK  bool
+	}{
+		{name: "ideal order", ranking: []string{"/a", "/b", "/c"}, grades: grades, k: 3, want: 1, wantOK: true},
+		{
+			name:    "reversed",
+			ranking: []string{"/c", "/b", "/a"},
+			grades:  grades,
+			k:       3,
+			want:    (1 + 3/math.Log2(3) + 7/2.0) / (7 + 3/math.Log2(3) + 1/2.0),
+			wantOK:  true,
+		},
+		{
+			name:    "unjudged and irrelevant results",
+			ranking: []string{"/x", "/d", "/a"},
+			grades:  grades,
+			k:       3,
+			want:    (7 / 2.0) / (7 + 3/math.Log2(3) + 1/2.0),
+			wantOK:  true,
+		},
+		{
+			name:    "relevant result below the cut-off",
+			ranking: []string{"/x", "/a"},
+			grades:  grades,
+			k:       1,
+			want:    0,
+			wantOK:  true,
+		},
+		{name: "nothing judged relevant", ranking: []string{"/d"}, grades: map[string]int{"/d": 0}, k: 3, wantOK: false},
+		{name: "no judgments", ranking: []string{"/a"}, grades: nil, k: 3, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NDCG(tt.ranking, tt.grades, tt.k)
+			if ok != tt.wantOK {
+				t.Fatalf("NDCG() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("NDCG() = %.6f, want %.6f", got, tt.want)
+			}
+		})
+	}
+}
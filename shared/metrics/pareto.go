@@ -0,0 +1,45 @@
+package metrics
+
+import "sort"
+
+// Tradeoff places an algorithm by relevance (higher is better) and latency
+// (lower is better)
+type Tradeoff struct {
+	Algorithm string  `json:"algorithm"`
+	Relevance float64 `json:"relevance"`
+	LatencyMs float64 `json:"latency_ms"`
+
+	// Frontier is true when no other algorithm is at least as relevant and
+	// at least as fast, and strictly better on one of the two
+	Frontier    bool   `json:"frontier"`
+	DominatedBy string `json:"dominated_by,omitempty"` // The fastest algorithm that beats this one
+}
+
+// ParetoFrontier marks the algorithms on the relevance-latency Pareto
+// frontier: those no other algorithm beats on both. It returns the
+// tradeoffs ordered fastest first.
+func ParetoFrontier(tradeoffs []Tradeoff) []Tradeoff {
+	sorted := append([]Tradeoff(nil), tradeoffs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].LatencyMs != sorted[j].LatencyMs {
+			return sorted[i].LatencyMs < sorted[j].LatencyMs
+		}
+		return sorted[i].Relevance > sorted[j].Relevance
+	})
+
+	for i := range sorted {
+		sorted[i].Frontier, sorted[i].DominatedBy = true, ""
+		for j, other := range sorted {
+			if i != j && dominates(other, sorted[i]) {
+				sorted[i].Frontier, sorted[i].DominatedBy = false, other.Algorithm
+				break
+			}
+		}
+	}
+	return sorted
+}
+
+func dominates(a, b Tradeoff) bool {
+	return a.Relevance >= b.Relevance && a.LatencyMs <= b.LatencyMs &&
+		(a.Relevance > b.Relevance || a.LatencyMs < b.LatencyMs)
+}
@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParetoFrontier(t *testing.T) {
+	got := ParetoFrontier([]Tradeoff{
+		{Algorithm: "hybrid", Relevance: 0.8, LatencyMs: 40},
+		{Algorithm: "bm25", Relevance: 0.6, LatencyMs: 5},
+		{Algorithm: "boosted", Relevance: 0.55, LatencyMs: 12},
+		{Algorithm: "script", Relevance: 0.8, LatencyMs: 90},
+		{Algorithm: "title", Relevance: 0.7, LatencyMs: 12},
+	})
+
+	want := []Tradeoff{
+		{Algorithm: "bm25", Relevance: 0.6, LatencyMs: 5, Frontier: true},
+		{Algorithm: "title", Relevance: 0.7, LatencyMs: 12, Frontier: true},
+		{Algorithm: "boosted", Relevance: 0.55, LatencyMs: 12, DominatedBy: "bm25"},
+		{Algorithm: "hybrid", Relevance: 0.8, LatencyMs: 40, Frontier: true},
+		{Algorithm: "script", Relevance: 0.8, LatencyMs: 90, DominatedBy: "hybrid"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParetoFrontier() =\n%+v\nwant\n%+v", got, want)
+	}
+}
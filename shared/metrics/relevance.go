@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"sort"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// PrecisionAtK returns the share of the top k of a ranking judged relevant
+// (grade above 0). Rankings shorter than k are still divided by k, so
+// returning fewer results is not rewarded.
+func PrecisionAtK(ranking []string, grades map[string]int, k int) float64 {
+	if k <= 0 {
+		k = DefaultK
+	}
+	return float64(relevantIn(topK(ranking, k), grades)) / float64(k)
+}
+
+// RecallAtK returns the share of the items judged relevant that appear in
+// the top k of a ranking. ok is false when no item is judged relevant.
+func RecallAtK(ranking []string, grades map[string]int, k int) (recall float64, ok bool) {
+	if k <= 0 {
+		k = DefaultK
+	}
+	relevant := 0
+	for _, g := range grades {
+		if g > 0 {
+			relevant++
+		}
+	}
+	if relevant == 0 {
+		return 0, false
+	}
+	return float64(relevantIn(topK(ranking, k), grades)) / float64(relevant), true
+}
+
+// ReciprocalRank returns 1/rank of the first relevant item in a ranking, or
+// 0 if none is relevant
+func ReciprocalRank(ranking []string, grades map[string]int) float64 {
+	for i, item := range ranking {
+		if grades[item] > 0 {
+			return 1 / float64(i+1)
+		}
+	}
+	return 0
+}
+
+func relevantIn(items []string, grades map[string]int) int {
+	n := 0
+	for _, item := range items {
+		if grades[item] > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// QueryQuality is how well one algorithm answered one judged query
+type QueryQuality struct {
+	Query          string  `json:"query"`
+	QueryID        string  `json:"query_id,omitempty"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+	ReciprocalRank float64 `json:"reciprocal_rank"`
+	NDCG           float64 `json:"ndcg"`
+}
+
+// Quality is an algorithm's mean Precision@K, Recall@K, MRR and NDCG@K over
+// the queries with relevant judgments
+type Quality struct {
+	Algorithm string         `json:"algorithm"`
+	K         int            `json:"k"`
+	Queries   int            `json:"queries"`
+	Precision float64        `json:"precision"`
+	Recall    float64        `json:"recall"`
+	MRR       float64        `json:"mrr"`
+	NDCG      float64        `json:"ndcg"`
+	PerQuery  []QueryQuality `json:"per_query"`
+}
+
+// ScoreQuality scores each algorithm's results against graded judgments.
+// Queries with nothing judged relevant are skipped; algorithms are ordered
+// by MRR, best first.
+func ScoreQuality(results []models.QueryResults, judgments models.Judgments, k int) []Quality {
+	if k <= 0 {
+		k = DefaultK
+	}
+
+	byAlgorithm := make(map[string]*Quality)
+	var order []string
+	for _, qr := range results {
+		grades := judgments.ForQuery(qr.QueryID, qr.Query)
+		ranking := make([]string, 0, len(qr.Results))
+		for _, r := range qr.Results {
+			ranking = append(ranking, r.URI)
+		}
+		recall, ok := RecallAtK(ranking, grades, k)
+		if !ok {
+			continue
+		}
+		ndcg, _ := NDCG(ranking, grades, k)
+
+		algorithm := qr.AlgorithmLabel()
+		q, exists := byAlgorithm[algorithm]
+		if !exists {
+			q = &Quality{Algorithm: algorithm, K: k}
+			byAlgorithm[algorithm] = q
+			order = append(order, algorithm)
+		}
+		q.PerQuery = append(q.PerQuery, QueryQuality{
+			Query:          qr.Query,
+			QueryID:        qr.QueryID,
+			Precision:      PrecisionAtK(ranking, grades, k),
+			Recall:         recall,
+			ReciprocalRank: ReciprocalRank(ranking, grades),
+			NDCG:           ndcg,
+		})
+	}
+
+	qualities := make([]Quality, 0, len(order))
+	for _, name := range order {
+		q := byAlgorithm[name]
+		q.Queries = len(q.PerQuery)
+		for _, pq := range q.PerQuery {
+			q.Precision += pq.Precision
+			q.Recall += pq.Recall
+			q.MRR += pq.ReciprocalRank
+			q.NDCG += pq.NDCG
+		}
+		q.Precision /= float64(q.Queries)
+		q.Recall /= float64(q.Queries)
+		q.MRR /= float64(q.Queries)
+		q.NDCG /= float64(q.Queries)
+		qualities = append(qualities, *q)
+	}
+
+	sort.SliceStable(qualities, func(i, j int) bool {
+		return qualities[i].MRR > qualities[j].MRR
+	})
+
+	return qualities
+}
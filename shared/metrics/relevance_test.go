@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestRankedRelevance(t *testing.T) {
+	grades := map[string]int{"/a": 2, "/b": 1, "/c": 0}
+
+	tests := []struct {
+		name          string
+		ranking       []string
+		grades        map[string]int
+		k             int
+		wantPrecision float64
+		wantRecall    float64
+		wantRecallOK  bool
+		wantRR        float64
+	}{
+		{
+			name:          "all relevant first",
+			ranking:       []string{"/a", "/b", "/c"},
+			grades:        grades,
+			k:             2,
+			wantPrecision: 1,
+			wantRecall:    1,
+			wantRecallOK:  true,
+			wantRR:        1,
+		},
+		{
+			name:          "relevant result below the cut-off",
+			ranking:       []string{"/c", "/x", "/b"},
+			grades:        grades,
+			k:             2,
+			wantPrecision: 0,
+			wantRecall:    0,
+			wantRecallOK:  true,
+			wantRR:        1.0 / 3,
+		},
+		{
+			name:          "short ranking",
+			ranking:       []string{"/b"},
+			grades:        grades,
+			k:             4,
+			wantPrecision: 0.25,
+			wantRecall:    0.5,
+			wantRecallOK:  true,
+			wantRR:        1,
+		},
+		{
+			name:          "nothing judged relevant",
+			ranking:       []string{"/c"},
+			grades:        map[string]int{"/c": 0},
+			k:             2,
+			wantPrecision: 0,
+			wantRecallOK:  false,
+			wantRR:        0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PrecisionAtK(tt.ranking, tt.grades, tt.k); math.Abs(got-tt.wantPrecision) > 1e-9 {
+				t.Errorf("PrecisionAtK() = %.3f, want %.3f", got, tt.wantPrecision)
+			}
+			recall, ok := RecallAtK(tt.ranking, tt.grades, tt.k)
+			if ok != tt.wantRecallOK || math.Abs(recall-tt.wantRecall) > 1e-9 {
+				t.Errorf("RecallAtK() = %.3f, %v, want %.3f, %v", recall, ok, tt.wantRecall, tt.wantRecallOK)
+			}
+			if got := ReciprocalRank(tt.ranking, tt.grades); math.Abs(got-tt.wantRR) > 1e-9 {
+				t.Errorf("ReciprocalRank() = %.3f, want %.3f", got, tt.wantRR)
+			}
+		})
+	}
+}
+
+func TestScoreQuality(t *testing.T) {
+	results := []models.QueryResults{
+		{Query: "cpi", Algorithm: "bm25", Results: []models.SearchResult{{URI: "/x"}, {URI: "/cpi"}}},
+		{Query: "gdp", QueryID: "gdp-q", Algorithm: "bm25", Results: []models.SearchResult{{URI: "/gdp"}}},
+		{Query: "cpi", Algorithm: "boosted", Results: []models.SearchResult{{URI: "/cpi"}, {URI: "/x"}}},
+		{Query: "unjudged", Algorithm: "boosted", Results: []models.SearchResult{{URI: "/y"}}},
+	}
+	judgments := models.Judgments{
+		"cpi":   {"/cpi": 2},
+		"gdp-q": {"/gdp": 1, "/gdp-2": 1},
+	}
+
+	got := ScoreQuality(results, judgments, 2)
+	if len(got) != 2 {
+		t.Fatalf("ScoreQuality() returned %d algorithms, want 2", len(got))
+	}
+
+	boosted, bm25 := got[0], got[1]
+	if boosted.Algorithm != "boosted" || bm25.Algorithm != "bm25" {
+		t.Fatalf("algorithms ordered %s, %s, want boosted first by MRR", boosted.Algorithm, bm25.Algorithm)
+	}
+	if boosted.Queries != 1 || boosted.MRR != 1 || boosted.Precision != 0.5 || boosted.Recall != 1 || boosted.NDCG != 1 {
+		t.Errorf("boosted = %+v, want 1 query with MRR 1, P 0.5, R 1, NDCG 1", boosted)
+	}
+	if bm25.Queries != 2 {
+		t.Fatalf("bm25 scored %d queries, want 2", bm25.Queries)
+	}
+	if math.Abs(bm25.MRR-0.75) > 1e-9 || math.Abs(bm25.Recall-0.75) > 1e-9 || math.Abs(bm25.Precision-0.5) > 1e-9 {
+		t.Errorf("bm25 = P %.3f R %.3f MRR %.3f, want P 0.5 R 0.75 MRR 0.75", bm25.Precision, bm25.Recall, bm25.MRR)
+	}
+}
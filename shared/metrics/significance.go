@@ -0,0 +1,276 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// SignificanceLevel is the p-value below which a difference between runs is
+// reported as significant
+const SignificanceLevel = 0.05
+
+// exactWilcoxonMax is the largest number of non-zero differences for which
+// the Wilcoxon p-value is computed exactly rather than approximated
+const exactWilcoxonMax = 25
+
+// Significance holds paired tests of a per-query metric measured in two
+// runs. Both p-values are two-sided.
+type Significance struct {
+	Pairs     int     `json:"pairs"`
+	MeanDiff  float64 `json:"mean_diff"` // Mean of current minus previous
+	TTestP    float64 `json:"t_test_p"`
+	WilcoxonP float64 `json:"wilcoxon_p"`
+}
+
+// Significant reports whether both tests put the difference below the
+// significance level
+func (s Significance) Significant() bool {
+	return s.TTestP < SignificanceLevel && s.WilcoxonP < SignificanceLevel
+}
+
+// String summarises both tests, e.g. "t-test p=0.012, Wilcoxon p=0.031,
+// significant at 0.05"
+func (s Significance) String() string {
+	verdict := "not significant"
+	if s.Significant() {
+		verdict = "significant"
+	}
+	return fmt.Sprintf("t-test p=%s, Wilcoxon p=%s, %s at %.2f",
+		formatP(s.TTestP), formatP(s.WilcoxonP), verdict, SignificanceLevel)
+}
+
+func formatP(p float64) string {
+	if p < 0.001 {
+		return "<0.001"
+	}
+	return fmt.Sprintf("%.3f", p)
+}
+
+// TestPaired runs the paired t-test and Wilcoxon signed-rank test over
+// per-query values from the current and previous runs. ok is false when
+// there are fewer than two pairs.
+func TestPaired(current, previous []float64) (s Significance, ok bool) {
+	tp, ok := PairedTTest(current, previous)
+	if !ok {
+		return Significance{}, false
+	}
+	wp, _ := WilcoxonSignedRank(current, previous)
+
+	diffs := differences(current, previous)
+	for _, d := range diffs {
+		s.MeanDiff += d
+	}
+	s.MeanDiff /= float64(len(diffs))
+	s.Pairs = len(diffs)
+	s.TTestP = tp
+	s.WilcoxonP = wp
+	return s, true
+}
+
+// PairedTTest returns the two-sided p-value of Student's paired t-test on
+// current[i]-previous[i]. ok is false when there are fewer than two pairs.
+func PairedTTest(current, previous []float64) (p float64, ok bool) {
+	diffs := differences(current, previous)
+	n := len(diffs)
+	if n < 2 {
+		return 0, false
+	}
+
+	mean := 0.0
+	for _, d := range diffs {
+		mean += d
+	}
+	mean /= float64(n)
+	variance := 0.0
+	for _, d := range diffs {
+		variance += (d - mean) * (d - mean)
+	}
+	variance /= float64(n - 1)
+
+	if variance == 0 {
+		if mean == 0 {
+			return 1, true
+		}
+		return 0, true
+	}
+
+	t := mean / math.Sqrt(variance/float64(n))
+	df := float64(n - 1)
+	return regularizedIncompleteBeta(df/(df+t*t), df/2, 0.5), true
+}
+
+// WilcoxonSignedRank returns the two-sided p-value of the Wilcoxon
+// signed-rank test on current[i]-previous[i]. Zero differences are dropped
+// and tied magnitudes share their average rank. The p-value is exact for up
+// to 25 non-zero differences and uses the normal approximation, with tie
+// and continuity corrections, above that. ok is false when there are fewer
+// than two pairs.
+func WilcoxonSignedRank(current, previous []float64) (p float64, ok bool) {
+	all := differences(current, previous)
+	if len(all) < 2 {
+		return 0, false
+	}
+
+	var diffs []float64
+	for _, d := range all {
+		if d != 0 {
+			diffs = append(diffs, d)
+		}
+	}
+	n := len(diffs)
+	if n == 0 {
+		return 1, true
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return math.Abs(diffs[i]) < math.Abs(diffs[j]) })
+
+	// Ranks are doubled so that average ranks of ties stay whole numbers
+	ranks := make([]int, n)
+	var tieCorrection float64
+	for i := 0; i < n; {
+		j := i
+		for j+1 < n && math.Abs(diffs[j+1]) == math.Abs(diffs[i]) {
+			j++
+		}
+		for k := i; k <= j; k++ {
+			ranks[k] = i + j + 2
+		}
+		t := float64(j - i + 1)
+		tieCorrection += t*t*t - t
+		i = j + 1
+	}
+
+	positive := 0
+	for i, d := range diffs {
+		if d > 0 {
+			positive += ranks[i]
+		}
+	}
+
+	if n <= exactWilcoxonMax {
+		return exactWilcoxonP(ranks, positive), true
+	}
+
+	w := float64(positive) / 2
+	nf := float64(n)
+	mean := nf * (nf + 1) / 4
+	variance := nf*(nf+1)*(2*nf+1)/24 - tieCorrection/48
+	if variance <= 0 {
+		return 1, true
+	}
+	z := math.Max(math.Abs(w-mean)-0.5, 0) / math.Sqrt(variance)
+	return math.Erfc(z / math.Sqrt2), true
+}
+
+// exactWilcoxonP counts the sign assignments whose positive rank sum is at
+// least as extreme as the observed one. ranks and observed are doubled.
+func exactWilcoxonP(ranks []int, observed int) float64 {
+	total := 0
+	for _, r := range ranks {
+		total += r
+	}
+
+	// counts[s] is the number of subsets of ranks summing to s
+	counts := make([]float64, total+1)
+	counts[0] = 1
+	for _, r := range ranks {
+		for s := total; s >= r; s-- {
+			counts[s] += counts[s-r]
+		}
+	}
+
+	var below, above, all float64
+	for s, c := range counts {
+		all += c
+		if s <= observed {
+			below += c
+		}
+		if s >= observed {
+			above += c
+		}
+	}
+	return math.Min(1, 2*math.Min(below, above)/all)
+}
+
+func differences(current, previous []float64) []float64 {
+	n := min(len(current), len(previous))
+	diffs := make([]float64, n)
+	for i := range diffs {
+		diffs[i] = current[i] - previous[i]
+	}
+	return diffs
+}
+
+// regularizedIncompleteBeta returns I_x(a, b), evaluated with the continued
+// fraction on whichever side converges quickly
+func regularizedIncompleteBeta(x, a, b float64) float64 {
+	switch {
+	case x <= 0:
+		return 0
+	case x >= 1:
+		return 1
+	}
+
+	lgab, _ := math.Lgamma(a + b)
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(x, a, b) / a
+	}
+	return 1 - front*betaContinuedFraction(1-x, b, a)/b
+}
+
+// betaContinuedFraction evaluates the continued fraction for the incomplete
+// beta function by the modified Lentz method
+func betaContinuedFraction(x, a, b float64) float64 {
+	const (
+		maxIterations = 300
+		epsilon       = 1e-14
+		tiny          = 1e-300
+	)
+
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+
+	for m := 1; m <= maxIterations; m++ {
+		mf := float64(m)
+
+		even := mf * (b - mf) * x / ((a + 2*mf - 1) * (a + 2*mf))
+		d = 1 + even*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + even/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+
+		odd := -(a + mf) * (a + b + mf) * x / ((a + 2*mf) * (a + 2*mf + 1))
+		d = 1 + odd*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + odd/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
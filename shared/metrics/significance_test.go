@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPairedTests(t *testing.T) {
+	tests := []struct {
+		name         string
+		current      []float64
+		previous     []float64
+		wantOK       bool
+		wantTTest    float64
+		wantWilcoxon float64
+	}{
+		{
+			name:         "consistent improvement",
+			current:      []float64{1, 2, 3, 4, 5},
+			previous:     []float64{0, 0, 0, 0, 0},
+			wantOK:       true,
+			wantTTest:    0.013236,
+			wantWilcoxon: 0.0625,
+		},
+		{
+			name:         "one-degree-of-freedom t",
+			current:      []float64{1, 3},
+			previous:     []float64{1, 1},
+			wantOK:       true,
+			wantTTest:    0.5,
+			wantWilcoxon: 1,
+		},
+		{
+			name:         "small mixed change",
+			current:      []float64{1, 0, 2},
+			previous:     []float64{0, 1, 0},
+			wantOK:       true,
+			wantTTest:    1 - math.Sqrt(4.0/18),
+			wantWilcoxon: 0.75,
+		},
+		{
+			name:         "symmetric ties",
+			current:      []float64{1, 0, 2, 0},
+			previous:     []float64{0, 1, 0, 2},
+			wantOK:       true,
+			wantTTest:    1,
+			wantWilcoxon: 1,
+		},
+		{
+			name:         "no change",
+			current:      []float64{0.5, 0.7},
+			previous:     []float64{0.5, 0.7},
+			wantOK:       true,
+			wantTTest:    1,
+			wantWilcoxon: 1,
+		},
+		{
+			name:     "too few pairs",
+			current:  []float64{1},
+			previous: []float64{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp, ok := PairedTTest(tt.current, tt.previous)
+			if ok != tt.wantOK || math.Abs(tp-tt.wantTTest) > 1e-5 {
+				t.Errorf("PairedTTest() = %.6f, %v, want %.6f, %v", tp, ok, tt.wantTTest, tt.wantOK)
+			}
+			wp, ok := WilcoxonSignedRank(tt.current, tt.previous)
+			if ok != tt.wantOK || math.Abs(wp-tt.wantWilcoxon) > 1e-9 {
+				t.Errorf("WilcoxonSignedRank() = %.6f, %v, want %.6f, %v", wp, ok, tt.wantWilcoxon, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestWilcoxonSignedRank_NormalApproximation(t *testing.T) {
+	var current, previous []float64
+	for i := 1; i <= 30; i++ {
+		current = append(current, float64(i))
+		previous = append(previous, 0)
+	}
+
+	// W+ = 465 against a mean of 232.5 and a variance of 2363.75
+	p, ok := WilcoxonSignedRank(current, previous)
+	want := math.Erfc((232.5 - 0.5) / math.Sqrt(2363.75) / math.Sqrt2)
+	if !ok || math.Abs(p-want) > 1e-12 {
+		t.Errorf("WilcoxonSignedRank() = %g, %v, want %g", p, ok, want)
+	}
+}
+
+func TestTestPaired(t *testing.T) {
+	s, ok := TestPaired([]float64{0.9, 0.8, 0.7, 0.95, 0.85, 0.75}, []float64{0.5, 0.6, 0.4, 0.55, 0.5, 0.45})
+	if !ok {
+		t.Fatal("TestPaired() ok = false, want true")
+	}
+	if s.Pairs != 6 || math.Abs(s.MeanDiff-0.325) > 1e-9 {
+		t.Errorf("TestPaired() = %+v, want 6 pairs with mean difference 0.325", s)
+	}
+	if !s.Significant() {
+		t.Errorf("Significant() = false for p-values %.4f and %.4f", s.TTestP, s.WilcoxonP)
+	}
+}
@@ -9,8 +9,11 @@ import (
 	"github.com/ONSdigital/dis-search-test-bed/models"
 )
 
-// WriteCSV writes query results to a CSV file
-func WriteCSV(path string, results []models.QueryResults) error {
+// WriteCSV writes query results to a CSV file, sorted by one of the Sort*
+// constants. Fields containing commas or quotes are quoted, and
+// titles are flattened onto one line so spreadsheet tools that split
+// records on line breaks keep the columns aligned.
+func WriteCSV(path string, results []models.QueryResults, by string) error {
 	f, err := os.Create(path)
 	if err != nil {
 		return fmt.Errorf("create file: %w", err)
@@ -23,6 +26,7 @@ func WriteCSV(path string, results []models.QueryResults) error {
 	// Write header
 	if err := w.Write([]string{
 		"query",
+		"query_id",
 		"algorithm",
 		"rank",
 		"title",
@@ -30,25 +34,27 @@ func WriteCSV(path string, results []models.QueryResults) error {
 		"date",
 		"content_type",
 		"score",
+		"corpus",
 	}); err != nil {
 		return fmt.Errorf("write header: %w", err)
 	}
 
 	// Write data
-	for _, qr := range results {
-		for _, r := range qr.Results {
-			if err := w.Write([]string{
-				qr.Query,
-				r.Algorithm,
-				strconv.Itoa(r.Rank),
-				r.Title,
-				r.URI,
-				r.Date,
-				r.ContentType,
-				fmt.Sprintf("%.4f", r.Score),
-			}); err != nil {
-				return fmt.Errorf("write row: %w", err)
-			}
+	for _, row := range sortRows(results, by) {
+		qr, r := row.list, row.result
+		if err := w.Write([]string{
+			qr.Query,
+			qr.QueryID,
+			r.Algorithm,
+			strconv.Itoa(r.Rank),
+			models.SingleLine(r.Title),
+			r.URI,
+			r.Date,
+			r.ContentType,
+			fmt.Sprintf("%.4f", r.Score),
+			qr.Corpus,
+		}); err != nil {
+			return fmt.Errorf("write row: %w", err)
 		}
 	}
 
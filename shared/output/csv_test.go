@@ -0,0 +1,56 @@
+package output
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestWriteCSV_AwkwardTitles(t *testing.T) {
+	titles := []string{
+		"Consumer price inflation, UK: May 2024",
+		`Deaths registered weekly, "provisional" figures`,
+		"Labour market overview,\nUK: June 2024",
+		"Ystadegau'r Gymraeg – Arolwg Defnydd Iaith, 2019 i 2020 ✨",
+		"مؤشر أسعار المستهلك، المملكة المتحدة",
+	}
+
+	var results []models.SearchResult
+	for i, title := range titles {
+		results = append(results, models.SearchResult{Rank: i + 1, Title: title, URI: "/doc", Algorithm: "bm25"})
+	}
+	path := filepath.Join(t.TempDir(), "results.csv")
+	err := WriteCSV(path, []models.QueryResults{{Query: "cpi, uk", Algorithm: "bm25", Results: results}}, SortRun)
+	if err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("read CSV: %v", err)
+	}
+	if len(rows) != len(titles)+1 {
+		t.Fatalf("got %d rows, want %d", len(rows), len(titles)+1)
+	}
+	for i, row := range rows[1:] {
+		if len(row) != len(rows[0]) {
+			t.Errorf("row %d has %d columns, want %d", i+1, len(row), len(rows[0]))
+			continue
+		}
+		if row[0] != "cpi, uk" {
+			t.Errorf("row %d query = %q", i+1, row[0])
+		}
+		if want := models.SingleLine(titles[i]); row[4] != want {
+			t.Errorf("row %d title = %q, want %q", i+1, row[4], want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/ONSdigital/dis-search-test-bed/models"
 )
@@ -14,10 +15,50 @@ func WriteJSON(path string, results []models.QueryResults) error {
 	if err != nil {
 		return fmt.Errorf("marshal results: %w", err)
 	}
-	// #nosec G306 - output files are test results, not sensitive
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	if err := writeFileAtomic(path, data); err != nil {
 		return fmt.Errorf("write file: %w", err)
 	}
 
 	return nil
 }
+
+// WriteJSONFile writes any value to a JSON file, creating its directory if needed
+func WriteJSONFile(path string, v interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal JSON: %w", err)
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file beside path and renames it
+// into place, so readers never see a partly written file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// #nosec G302 - output files are test results, not sensitive
+	if err := os.Chmod(tmp.Name(), resultFileMode); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,126 @@
+package output
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// JUnitSuite is a group of test cases, shown as one suite by CI test
+// reporting
+type JUnitSuite struct {
+	Name      string
+	Timestamp time.Time
+	Cases     []JUnitCase
+}
+
+// JUnitCase is one test case. A case with a failure failed; one with a skip
+// reason was skipped; any other passed.
+type JUnitCase struct {
+	Name      string
+	ClassName string
+	Time      time.Duration
+	Failure   *JUnitFailure
+	Skipped   string // Why the case was skipped
+}
+
+// JUnitFailure describes why a test case failed
+type JUnitFailure struct {
+	Message string
+	Type    string
+	Detail  string
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Detail  string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// WriteJUnit writes test suites as a JUnit XML report under the given name
+func WriteJUnit(w io.Writer, name string, suites []JUnitSuite) error {
+	report := junitTestSuites{Name: name, Suites: make([]junitTestSuite, 0, len(suites))}
+
+	var total time.Duration
+	for _, suite := range suites {
+		ts := junitTestSuite{Name: suite.Name, Tests: len(suite.Cases)}
+		if !suite.Timestamp.IsZero() {
+			ts.Timestamp = suite.Timestamp.UTC().Format("2006-01-02T15:04:05")
+		}
+
+		var suiteTime time.Duration
+		for _, tc := range suite.Cases {
+			c := junitTestCase{Name: tc.Name, ClassName: tc.ClassName, Time: junitSeconds(tc.Time)}
+			switch {
+			case tc.Failure != nil:
+				c.Failure = &junitFailure{Message: tc.Failure.Message, Type: tc.Failure.Type, Detail: tc.Failure.Detail}
+				ts.Failures++
+			case tc.Skipped != "":
+				c.Skipped = &junitSkipped{Message: tc.Skipped}
+				ts.Skipped++
+			}
+			suiteTime += tc.Time
+			ts.Cases = append(ts.Cases, c)
+		}
+		ts.Time = junitSeconds(suiteTime)
+
+		report.Tests += ts.Tests
+		report.Failures += ts.Failures
+		report.Skipped += ts.Skipped
+		total += suiteTime
+		report.Suites = append(report.Suites, ts)
+	}
+	report.Time = junitSeconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("encode report: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	return nil
+}
+
+// junitSeconds formats a duration as JUnit's decimal seconds
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,338 @@
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/paths"
+)
+
+// ManifestFileName is the run folder file describing how the run was
+// produced
+const ManifestFileName = "manifest.json"
+
+// MetadataFileName is the run folder file rendering the manifest as text
+const MetadataFileName = "metadata.txt"
+
+// Manifest records how a run folder was produced: the commands that wrote
+// to it, the config and queries they used, and what the index and results
+// hold. metadata.txt is rendered from it.
+type Manifest struct {
+	RunID       string       `json:"run_id"`
+	Index       *IndexInfo   `json:"index,omitempty"`
+	Results     *ResultsInfo `json:"results,omitempty"`
+	Labels      []string     `json:"labels,omitempty"`
+	Invocations []Invocation `json:"invocations"` // Commands that wrote the index and results, oldest first
+
+	// ComparedWith is the results file the last historical comparison was
+	// made against, so audit can recompute it against the same run
+	ComparedWith string `json:"compared_with,omitempty"`
+}
+
+// Invocation is one command that wrote a run's index or results
+type Invocation struct {
+	Command     string    `json:"command"` // e.g. "query --queries config/queries.json"
+	At          time.Time `json:"at"`
+	Version     string    `json:"version"` // CLI version
+	Commit      string    `json:"commit,omitempty"`
+	ConfigFile  string    `json:"config_file,omitempty"`
+	ConfigHash  string    `json:"config_hash,omitempty"` // See HashFile
+	QueriesFile string    `json:"queries_file,omitempty"`
+	QueriesHash string    `json:"queries_hash,omitempty"`
+
+	MappingProfile string `json:"mapping_profile,omitempty"` // Settings and mappings profile the index was created with
+}
+
+// IndexInfo describes the stored index of a run
+type IndexInfo struct {
+	Source      string    `json:"source"`
+	Documents   int       `json:"documents"`
+	Version     string    `json:"version"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// ResultsInfo describes the query results of a run
+type ResultsInfo struct {
+	GeneratedAt time.Time    `json:"generated_at"`
+	Queries     int          `json:"queries"`
+	Algorithms  []string     `json:"algorithms"`
+	CacheMode   string       `json:"cache_mode,omitempty"`
+	ExportSort  string       `json:"export_sort,omitempty"`
+	Counts      []QueryCount `json:"counts"` // Results returned per query, in results order
+}
+
+// QueryCount is the number of results one query returned
+type QueryCount struct {
+	Query     string `json:"query"`
+	Algorithm string `json:"algorithm"` // Algorithm label, with any corpus
+	Results   int    `json:"results"`
+}
+
+// NewIndexInfo describes a stored index
+func NewIndexInfo(index *models.StoredIndex) *IndexInfo {
+	return &IndexInfo{
+		Source:      index.SourceIndex,
+		Documents:   len(index.Documents),
+		Version:     index.Version,
+		GeneratedAt: index.GeneratedAt,
+	}
+}
+
+// LastInvocation returns the most recent command that wrote to the run
+func (m *Manifest) LastInvocation() (Invocation, bool) {
+	if len(m.Invocations) == 0 {
+		return Invocation{}, false
+	}
+	return m.Invocations[len(m.Invocations)-1], true
+}
+
+// lastWith returns the most recent invocation for which has is true
+func (m *Manifest) lastWith(has func(Invocation) bool) Invocation {
+	for i := len(m.Invocations) - 1; i >= 0; i-- {
+		if has(m.Invocations[i]) {
+			return m.Invocations[i]
+		}
+	}
+	return Invocation{}
+}
+
+// Differences describes how the inputs of this run differ from another's,
+// e.g. "queries: sha256:1a2b… → sha256:3c4d…", so a comparison can say
+// whether the queries, config, index or CLI changed in between. Inputs
+// either manifest doesn't record are skipped.
+func (m *Manifest) Differences(other Manifest) []string {
+	var diffs []string
+	check := func(name, current, previous string) {
+		if current != "" && previous != "" && current != previous {
+			diffs = append(diffs, fmt.Sprintf("%s: %s → %s", name, previous, current))
+		}
+	}
+
+	hasQueries := func(inv Invocation) bool { return inv.QueriesHash != "" }
+	hasConfig := func(inv Invocation) bool { return inv.ConfigHash != "" }
+	check("queries", shortHash(m.lastWith(hasQueries).QueriesHash), shortHash(other.lastWith(hasQueries).QueriesHash))
+	check("config", shortHash(m.lastWith(hasConfig).ConfigHash), shortHash(other.lastWith(hasConfig).ConfigHash))
+
+	current, _ := m.LastInvocation()
+	previous, _ := other.LastInvocation()
+	check("CLI version", current.Version, previous.Version)
+
+	// No profile is a setting of its own, so a run with one is told apart
+	// from a run without
+	if cur, prev := m.lastWith(hasQueries), other.lastWith(hasQueries); cur.QueriesHash != "" && prev.QueriesHash != "" {
+		check("mapping profile", profileOrNone(cur.MappingProfile), profileOrNone(prev.MappingProfile))
+	}
+
+	if m.Index != nil && other.Index != nil {
+		check("index source", m.Index.Source, other.Index.Source)
+		check("index documents", fmt.Sprint(m.Index.Documents), fmt.Sprint(other.Index.Documents))
+	}
+	return diffs
+}
+
+// LoadManifest reads a run folder's manifest. The error wraps
+// fs.ErrNotExist for runs written before manifests existed.
+func LoadManifest(runFolder string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(runFolder, ManifestFileName))
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// UpdateManifest applies update to a run folder's manifest, creating it if
+// needed, then saves it and re-renders metadata.txt
+func UpdateManifest(runFolder string, update func(m *Manifest)) error {
+	m, err := LoadManifest(runFolder)
+	if errors.Is(err, fs.ErrNotExist) {
+		m, err = &Manifest{}, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	m.RunID = paths.RunID(runFolder)
+	update(m)
+	if m.Labels, err = paths.Labels(runFolder); err != nil {
+		return err
+	}
+
+	if err := WriteJSONFile(filepath.Join(runFolder, ManifestFileName), m); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(runFolder, MetadataFileName), []byte(m.Render())); err != nil {
+		return fmt.Errorf("write metadata: %w", err)
+	}
+	return nil
+}
+
+// RecordInvocation adds a command to a run folder's manifest
+func RecordInvocation(runFolder string, inv Invocation) error {
+	return UpdateManifest(runFolder, func(m *Manifest) {
+		m.Invocations = append(m.Invocations, inv)
+	})
+}
+
+// RecordComparison notes in a run folder's manifest the results file its
+// historical comparison was made against
+func RecordComparison(runFolder, previousPath string) error {
+	return UpdateManifest(runFolder, func(m *Manifest) {
+		m.ComparedWith = previousPath
+	})
+}
+
+// HashFile returns the SHA-256 of a file's content as "sha256:<hex>"
+func HashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", filepath.Base(path), err)
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// profileOrNone names a mapping profile for display
+func profileOrNone(profile string) string {
+	if profile == "" {
+		return "none"
+	}
+	return profile
+}
+
+// shortHash trims a HashFile hash for display
+func shortHash(hash string) string {
+	if len(hash) > len("sha256:")+12 {
+		return hash[:len("sha256:")+12]
+	}
+	return hash
+}
+
+// Render writes the manifest as the human-readable metadata.txt
+func (m *Manifest) Render() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Search Test Bed - Run Metadata\nRun ID: %s\n", m.RunID)
+	if len(m.Labels) > 0 {
+		fmt.Fprintf(&b, "Labels: %s\n", strings.Join(m.Labels, ", "))
+	}
+
+	if m.Index != nil {
+		fmt.Fprintf(&b, `
+Index Information:
+- Source: %s
+- Document Count: %d
+- Version: %s
+- Generated: %s
+`,
+			m.Index.Source,
+			m.Index.Documents,
+			m.Index.Version,
+			m.Index.GeneratedAt.Format("2006-01-02 15:04:05"),
+		)
+	}
+
+	if r := m.Results; r != nil {
+		fmt.Fprintf(&b, `
+Query Results:
+- Generated: %s
+- Total Queries: %d
+- Algorithms Used: %s
+`,
+			r.GeneratedAt.Format("2006-01-02 15:04:05"),
+			r.Queries,
+			strings.Join(r.Algorithms, ", "),
+		)
+		if r.CacheMode != "" {
+			fmt.Fprintf(&b, "- Cache Mode: %s\n", r.CacheMode)
+		}
+		if r.ExportSort != "" {
+			fmt.Fprintf(&b, "- Export Sort: %s\n", r.ExportSort)
+		}
+		b.WriteString("\nQueries:\n")
+		for i, c := range r.Counts {
+			fmt.Fprintf(&b, "  %d. %s (%s) - %d results\n", i+1, c.Query, c.Algorithm, c.Results)
+		}
+	}
+
+	if m.ComparedWith != "" {
+		fmt.Fprintf(&b, "\nCompared with: %s\n", m.ComparedWith)
+	}
+
+	if len(m.Invocations) > 0 {
+		b.WriteString("\nCommands:\n")
+		for _, inv := range m.Invocations {
+			fmt.Fprintf(&b, "- %s: search-testbed %s (version %s)\n",
+				inv.At.Format("2006-01-02 15:04:05"), inv.Command, inv.Version)
+			if inv.ConfigFile != "" {
+				fmt.Fprintf(&b, "    config:  %s (%s)\n", inv.ConfigFile, shortHash(inv.ConfigHash))
+			}
+			if inv.QueriesFile != "" {
+				fmt.Fprintf(&b, "    queries: %s (%s)\n", inv.QueriesFile, shortHash(inv.QueriesHash))
+			}
+			if inv.MappingProfile != "" {
+				fmt.Fprintf(&b, "    mapping: profile %s\n", inv.MappingProfile)
+			}
+		}
+	}
+
+	b.WriteString(folderContents)
+	return b.String()
+}
+
+// folderContents ends metadata.txt with a guide to the files a run folder
+// may hold
+const folderContents = `
+Files in this folder:
+- manifest.json           : How this run was produced, as JSON (this file renders it)
+- index.json              : Generated test index
+- mapping.json            : Index settings and mappings the index was loaded with
+- results.csv             : Query results in CSV format
+- results_<algorithm>.csv : One algorithm's results (output.split_csv)
+- results.parquet         : Query results as a Parquet table (output.parquet)
+- results.json            : Query results in JSON format
+- metadata.txt            : This file
+- rank_eval.json          : Elasticsearch _rank_eval scores (when run with --judgments)
+- cluster.json            : Cluster versions and index settings at query time
+- scripts.json            : Scoring scripts used by scripted algorithms
+- weights.json            : Per-document weights merged in at load time
+- analytics.json          : Page views and clicks imported with 'import-analytics'
+- baseline.json           : Agreement with the click-popularity baseline ('baseline')
+- corpora/<name>.json     : Documents of each named corpus ('run')
+- corpus_scaling.json     : Latency and result stability by corpus size ('run', 'corpus-scaling')
+- qa_sample.csv           : Sampled queries and top results for manual review ('sample')
+- watchlist.json          : Watchlist documents that left the top K since the previous run
+- pareto.json             : Relevance against latency per algorithm ('pareto')
+- judgments.json          : Relevance grades recorded with 'judge'
+- trace.jsonl             : Every search sent, with its X-Opaque-Id request ID
+- timings.json            : Time each command spent per phase
+- labels.json             : Labels given with --label, for compare --with name:<label>
+- profiles/               : Go CPU, heap and execution trace profiles, when asked for
+
+Comparison Reports (generated by 'compare' command):
+- comparison_historical.txt  : Historical comparison (vs previous run)
+- comparison_cross_query.txt : Cross-query comparison (within this run)
+- comparison_*.md, *.json    : The same reports as Markdown or JSON (output.report_formats)
+- comparison_*.xml           : Historical regressions as JUnit XML test results
+- comparisons/<slug>.json    : Per-query historical comparison data
+- movements.parquet          : Every result's movement as a Parquet table (output.parquet)
+- visibility.json            : Top-K share of results by theme, vs previous run
+- comparison_score_drift.txt : Score drift where rankings are unchanged ('--mode score-drift')
+- score_drift.json           : Score drift data for the same report
+- suite_diff.json            : Two query suites compared query by query ('compare-suites')
+
+These are saved in a subfolder instead when output.layout.reports_dir is
+set, and the comparison_* reports take the names in
+output.layout.report_names.
+`
@@ -0,0 +1,125 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestManifest(t *testing.T) {
+	runFolder := filepath.Join(t.TempDir(), "run_2024-01-01_10-00-00")
+	if err := os.Mkdir(runFolder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	// generate saves the index, then query writes results into the same run
+	index := &models.StoredIndex{SourceIndex: "ons", Version: "1.0", GeneratedAt: at,
+		Documents: []models.Document{{ID: "a"}, {ID: "b"}}}
+	if err := UpdateManifest(runFolder, func(m *Manifest) { m.Index = NewIndexInfo(index) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordInvocation(runFolder, Invocation{Command: "generate", At: at, Version: "v1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	results := []models.QueryResults{
+		{Query: "inflation", Algorithm: "bm25", RunAt: at, Results: make([]models.SearchResult, 3)},
+		{Query: "inflation", Algorithm: "title_boost", Corpus: "small", RunAt: at},
+	}
+	if err := NewWriter(runFolder).WriteAll(results, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := LoadManifest(runFolder)
+	if err != nil {
+		t.Fatalf("LoadManifest() error = %v", err)
+	}
+	if m.RunID != "run_2024-01-01_10-00-00" || m.Index == nil || m.Index.Documents != 2 {
+		t.Errorf("manifest = %+v, want the run ID and the index kept from generate", m)
+	}
+	wantCounts := []QueryCount{
+		{Query: "inflation", Algorithm: "bm25", Results: 3},
+		{Query: "inflation", Algorithm: "title_boost@small", Results: 0},
+	}
+	if m.Results == nil || !reflect.DeepEqual(m.Results.Counts, wantCounts) {
+		t.Fatalf("results = %+v, want counts %v", m.Results, wantCounts)
+	}
+	if !reflect.DeepEqual(m.Results.Algorithms, []string{"bm25", "title_boost"}) {
+		t.Errorf("algorithms = %v", m.Results.Algorithms)
+	}
+	if inv, ok := m.LastInvocation(); !ok || inv.Command != "generate" {
+		t.Errorf("LastInvocation() = %+v, %v, want generate", inv, ok)
+	}
+
+	metadata, err := os.ReadFile(filepath.Join(runFolder, MetadataFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"- Document Count: 2",
+		"  1. inflation (bm25) - 3 results",
+		"  2. inflation (title_boost@small) - 0 results",
+		"search-testbed generate (version v1)",
+	} {
+		if !strings.Contains(string(metadata), want) {
+			t.Errorf("metadata.txt is missing %q:\n%s", want, metadata)
+		}
+	}
+}
+
+func TestHashFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queries.json")
+	if err := os.WriteFile(path, []byte("[]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := HashFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "sha256:4f53cda18c2baa0c0354bb5f9a3ecbe5ed12ab4d8e11ba873c2f11161202b945"; got != want {
+		t.Errorf("HashFile() = %s, want %s", got, want)
+	}
+}
+
+func TestManifestDifferences(t *testing.T) {
+	previous := Manifest{
+		Index: &IndexInfo{Source: "ons", Documents: 100},
+		Invocations: []Invocation{
+			{Command: "generate", Version: "v1", ConfigHash: "sha256:aaaaaaaaaaaaaaaa"},
+			{Command: "query", Version: "v1", ConfigHash: "sha256:aaaaaaaaaaaaaaaa", QueriesHash: "sha256:bbbbbbbbbbbbbbbb"},
+		},
+	}
+	current := previous
+	if diffs := current.Differences(previous); len(diffs) != 0 {
+		t.Errorf("Differences() of the same manifest = %v, want none", diffs)
+	}
+
+	current = Manifest{
+		Index: &IndexInfo{Source: "ons", Documents: 120},
+		Invocations: []Invocation{
+			{Command: "query", Version: "v2", ConfigHash: "sha256:aaaaaaaaaaaaaaaa", QueriesHash: "sha256:cccccccccccccccc"},
+		},
+	}
+	want := []string{
+		"queries: sha256:bbbbbbbbbbbb → sha256:cccccccccccc",
+		"CLI version: v1 → v2",
+		"index documents: 100 → 120",
+	}
+	if diffs := current.Differences(previous); !reflect.DeepEqual(diffs, want) {
+		t.Errorf("Differences() = %v, want %v", diffs, want)
+	}
+
+	stemmed := previous
+	stemmed.Invocations = append([]Invocation{}, previous.Invocations...)
+	stemmed.Invocations[1].MappingProfile = "english-stemming"
+	want = []string{"mapping profile: none → english-stemming"}
+	if diffs := stemmed.Differences(previous); !reflect.DeepEqual(diffs, want) {
+		t.Errorf("Differences() with a mapping profile = %v, want %v", diffs, want)
+	}
+}
@@ -0,0 +1,66 @@
+package output
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/parquet"
+)
+
+// ResultsParquetFileName is the run folder file holding the results as a
+// Parquet table
+const ResultsParquetFileName = "results.parquet"
+
+// resultColumns are the columns of the results Parquet table: the CSV
+// columns plus when and how fast each query ran
+var resultColumns = []parquet.Column{
+	{Name: "query", Type: parquet.String},
+	{Name: "query_id", Type: parquet.String},
+	{Name: "algorithm", Type: parquet.String},
+	{Name: "corpus", Type: parquet.String},
+	{Name: "rank", Type: parquet.Int64},
+	{Name: "title", Type: parquet.String},
+	{Name: "uri", Type: parquet.String},
+	{Name: "date", Type: parquet.String},
+	{Name: "content_type", Type: parquet.String},
+	{Name: "score", Type: parquet.Double},
+	{Name: "took_ms", Type: parquet.Int64},
+	{Name: "run_at", Type: parquet.Timestamp, Optional: true},
+}
+
+// WriteParquet writes query results to a Parquet file with one row per
+// result, sorted by one of the Sort* constants
+func WriteParquet(path string, results []models.QueryResults, by string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create file: %w", err)
+	}
+	defer closeFile(f)
+
+	pw := parquet.NewWriter(f, resultColumns)
+	for _, row := range sortRows(results, by) {
+		qr, r := row.list, row.result
+		var runAt any
+		if !qr.RunAt.IsZero() {
+			runAt = qr.RunAt
+		}
+		if err := pw.Write(
+			qr.Query,
+			qr.QueryID,
+			r.Algorithm,
+			qr.Corpus,
+			r.Rank,
+			r.Title,
+			r.URI,
+			r.Date,
+			r.ContentType,
+			r.Score,
+			qr.TookMs,
+			runAt,
+		); err != nil {
+			return fmt.Errorf("write row: %w", err)
+		}
+	}
+	return pw.Close()
+}
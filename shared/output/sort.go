@@ -0,0 +1,100 @@
+package output
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// How exported results are sorted
+const (
+	SortRun       = "run"       // As the queries ran
+	SortQuery     = "query"     // By query text, then in run order
+	SortAlgorithm = "algorithm" // By algorithm, then in run order
+	SortRank      = "rank"      // Every list's first result, then every list's second, and so on
+)
+
+// ValidateSort checks how results are to be sorted; empty means SortRun
+func ValidateSort(by string) error {
+	switch by {
+	case "", SortRun, SortQuery, SortAlgorithm, SortRank:
+		return nil
+	default:
+		return fmt.Errorf("unknown results sort %q (want %s, %s, %s or %s)",
+			by, SortRun, SortQuery, SortAlgorithm, SortRank)
+	}
+}
+
+// sortResults returns the result lists in export order. Sorting by rank
+// interleaves rows, not lists, so it keeps the lists in run order.
+func sortResults(results []models.QueryResults, by string) []models.QueryResults {
+	ordered := append([]models.QueryResults(nil), results...)
+	switch by {
+	case SortQuery:
+		sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Query < ordered[j].Query })
+	case SortAlgorithm:
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return ordered[i].AlgorithmLabel() < ordered[j].AlgorithmLabel()
+		})
+	}
+	return ordered
+}
+
+// resultRow is one result with the list it came from
+type resultRow struct {
+	list   *models.QueryResults
+	result models.SearchResult
+}
+
+// sortRows flattens result lists into rows in export order
+func sortRows(results []models.QueryResults, by string) []resultRow {
+	lists := sortResults(results, by)
+	var rows []resultRow
+
+	if by != SortRank {
+		for i := range lists {
+			for _, r := range lists[i].Results {
+				rows = append(rows, resultRow{list: &lists[i], result: r})
+			}
+		}
+		return rows
+	}
+
+	for pos := 0; ; pos++ {
+		added := false
+		for i := range lists {
+			if pos < len(lists[i].Results) {
+				rows = append(rows, resultRow{list: &lists[i], result: lists[i].Results[pos]})
+				added = true
+			}
+		}
+		if !added {
+			return rows
+		}
+	}
+}
+
+// splitByAlgorithm groups result lists by algorithm, in the order the
+// algorithms first appear
+func splitByAlgorithm(results []models.QueryResults) ([]string, map[string][]models.QueryResults) {
+	var algorithms []string
+	byAlgorithm := make(map[string][]models.QueryResults)
+	for _, qr := range results {
+		algorithm := qr.AlgorithmLabel()
+		if _, ok := byAlgorithm[algorithm]; !ok {
+			algorithms = append(algorithms, algorithm)
+		}
+		byAlgorithm[algorithm] = append(byAlgorithm[algorithm], qr)
+	}
+	return algorithms, byAlgorithm
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._@-]+`)
+
+// AlgorithmCSVFileName is the run folder file holding one algorithm's
+// results when CSVs are split by algorithm
+func AlgorithmCSVFileName(algorithm string) string {
+	return "results_" + unsafeFileChars.ReplaceAllString(algorithm, "_") + ".csv"
+}
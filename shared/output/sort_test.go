@@ -0,0 +1,80 @@
+package output
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestSortRows(t *testing.T) {
+	list := func(query, algorithm string, uris ...string) models.QueryResults {
+		qr := models.QueryResults{Query: query, Algorithm: algorithm}
+		for i, uri := range uris {
+			qr.Results = append(qr.Results, models.SearchResult{Rank: i + 1, URI: uri, Algorithm: algorithm})
+		}
+		return qr
+	}
+	results := []models.QueryResults{
+		list("gdp", "bm25", "/g1", "/g2"),
+		list("cpi", "bm25", "/c1"),
+		list("gdp", "boosted", "/g3", "/g4"),
+		list("cpi", "boosted", "/c2", "/c3"),
+	}
+
+	tests := []struct {
+		by   string
+		want []string
+	}{
+		{by: "", want: []string{"/g1", "/g2", "/c1", "/g3", "/g4", "/c2", "/c3"}},
+		{by: SortQuery, want: []string{"/c1", "/c2", "/c3", "/g1", "/g2", "/g3", "/g4"}},
+		{by: SortAlgorithm, want: []string{"/g1", "/g2", "/c1", "/g3", "/g4", "/c2", "/c3"}},
+		{by: SortRank, want: []string{"/g1", "/c1", "/g3", "/c2", "/g2", "/g4", "/c3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.by, func(t *testing.T) {
+			var got []string
+			for _, row := range sortRows(results, tt.by) {
+				got = append(got, row.result.URI)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sortRows(%q) = %v, want %v", tt.by, got, tt.want)
+			}
+		})
+	}
+
+	if err := ValidateSort("score"); err == nil {
+		t.Error("ValidateSort(\"score\") = nil, want an error")
+	}
+}
+
+func TestWriteAll_SplitCSV(t *testing.T) {
+	dir := t.TempDir()
+	results := []models.QueryResults{
+		{Query: "cpi", Algorithm: "bm25", Results: []models.SearchResult{{Rank: 1, URI: "/a", Algorithm: "bm25"}}},
+		{Query: "cpi", Algorithm: "boosted", Corpus: "full", Results: []models.SearchResult{{Rank: 1, URI: "/b", Algorithm: "boosted"}}},
+	}
+
+	writer := NewWriter(dir)
+	writer.SetSplitCSV(true)
+	if err := writer.WriteAll(results, nil); err != nil {
+		t.Fatalf("WriteAll() error = %v", err)
+	}
+
+	for name, want := range map[string]string{"results_bm25.csv": "/a", "results_boosted@full.csv": "/b"} {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("missing %s: %v", name, err)
+			continue
+		}
+		rows, err := csv.NewReader(f).ReadAll()
+		_ = f.Close()
+		if err != nil || len(rows) != 2 || rows[1][5] != want {
+			t.Errorf("%s rows = %v, %v, want one row for %s", name, rows, err, want)
+		}
+	}
+}
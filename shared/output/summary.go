@@ -0,0 +1,100 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/paths"
+)
+
+// RunSummary describes what a run folder holds
+type RunSummary struct {
+	Documents  int      // Documents in the run's index or corpora; -1 if none were saved
+	Queries    int      // Distinct queries in the results
+	Algorithms []string // Algorithms in the order they first appear in the results
+	HasResults bool
+	Compared   bool     // Whether compare has saved reports for the run
+	Labels     []string // Labels given with --label
+}
+
+// SummariseRun reads a run folder's index and results. layout says where
+// and under what names the run's comparison reports are saved.
+func SummariseRun(runFolder string, layout paths.Layout) (RunSummary, error) {
+	summary := RunSummary{Documents: -1}
+
+	documents, err := countDocuments(filepath.Join(runFolder, "index.json"))
+	switch {
+	case err == nil:
+		summary.Documents = documents
+	case !errors.Is(err, fs.ErrNotExist):
+		return summary, err
+	default:
+		sizes, err := LoadCorpusSizes(runFolder)
+		if err != nil {
+			return summary, err
+		}
+		if len(sizes) > 0 {
+			summary.Documents = 0
+			for _, size := range sizes {
+				summary.Documents += size
+			}
+		}
+	}
+
+	resultsPath := filepath.Join(runFolder, "results.json")
+	if _, err := os.Stat(resultsPath); err == nil {
+		results, err := LoadResults(resultsPath)
+		if err != nil {
+			return summary, err
+		}
+		summary.HasResults = true
+
+		queries := make(map[string]bool)
+		algorithms := make(map[string]bool)
+		for _, qr := range results {
+			id := qr.QueryID
+			if id == "" {
+				id = models.Slugify(qr.Query)
+			}
+			queries[id] = true
+			if label := qr.AlgorithmLabel(); !algorithms[label] {
+				algorithms[label] = true
+				summary.Algorithms = append(summary.Algorithms, label)
+			}
+		}
+		summary.Queries = len(queries)
+	}
+
+	reports, err := layout.ReportFiles(runFolder)
+	if err != nil {
+		return summary, fmt.Errorf("list comparison reports: %w", err)
+	}
+	summary.Compared = len(reports) > 0
+
+	if summary.Labels, err = paths.Labels(runFolder); err != nil {
+		return summary, err
+	}
+
+	return summary, nil
+}
+
+// countDocuments counts the documents in a saved index without decoding
+// them
+func countDocuments(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var index struct {
+		Documents []json.RawMessage `json:"documents"`
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return 0, fmt.Errorf("parse %s: %w", filepath.Base(path), err)
+	}
+	return len(index.Documents), nil
+}
@@ -0,0 +1,72 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/paths"
+)
+
+func TestSummariseRun(t *testing.T) {
+	runFolder := t.TempDir()
+	index := models.StoredIndex{Documents: []models.Document{{ID: "a"}, {ID: "b"}, {ID: "c"}}}
+	if err := WriteJSONFile(filepath.Join(runFolder, "index.json"), index); err != nil {
+		t.Fatal(err)
+	}
+	results := []models.QueryResults{
+		{QueryID: "cpi", Query: "inflation", Algorithm: "bm25"},
+		{QueryID: "cpi", Query: "inflation", Algorithm: "title_boost"},
+		{Query: "gross domestic product", Algorithm: "bm25"},
+	}
+	if err := WriteJSONFile(filepath.Join(runFolder, "results.json"), results); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := paths.AddLabels(runFolder, []string{"synonym-boost"}); err != nil {
+		t.Fatal(err)
+	}
+
+	layout := paths.Layout{ReportsDir: "comparison", Reports: paths.ReportNames{Historical: "vs_baseline"}}
+	got, err := SummariseRun(runFolder, layout)
+	if err != nil {
+		t.Fatalf("SummariseRun() error = %v", err)
+	}
+	want := RunSummary{
+		Documents:  3,
+		Queries:    2,
+		Algorithms: []string{"bm25", "title_boost"},
+		HasResults: true,
+		Labels:     []string{"synonym-boost"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SummariseRun() = %+v, want %+v", got, want)
+	}
+
+	if err := WriteText(filepath.Join(runFolder, "comparison", "vs_baseline.txt"), "report"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := SummariseRun(runFolder, layout); !got.Compared {
+		t.Error("SummariseRun() did not find the comparison report")
+	}
+}
+
+func TestSummariseRun_Empty(t *testing.T) {
+	runFolder := t.TempDir()
+	got, err := SummariseRun(runFolder, paths.DefaultLayout)
+	if err != nil {
+		t.Fatalf("SummariseRun() error = %v", err)
+	}
+	if got.Documents != -1 || got.HasResults || got.Compared {
+		t.Errorf("SummariseRun() = %+v, want an empty run", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(runFolder, "index.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SummariseRun(runFolder, paths.DefaultLayout); err == nil {
+		t.Error("SummariseRun() accepted a corrupt index")
+	}
+}
@@ -3,8 +3,10 @@ package output
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/ONSdigital/dis-search-test-bed/models"
 )
@@ -18,6 +20,10 @@ const (
 // Writer handles writing output files
 type Writer struct {
 	outputDir string
+	cacheMode string
+	sortBy    string
+	splitCSV  bool
+	parquet   bool
 }
 
 // NewWriter creates a new output writer
@@ -25,6 +31,26 @@ func NewWriter(outputDir string) *Writer {
 	return &Writer{outputDir: outputDir}
 }
 
+// SetCacheMode records the cache mode the queries ran under in the manifest
+func (w *Writer) SetCacheMode(mode string) {
+	w.cacheMode = mode
+}
+
+// SetSort sets how exported results are sorted (see ValidateSort)
+func (w *Writer) SetSort(by string) {
+	w.sortBy = by
+}
+
+// SetSplitCSV also writes each algorithm's results to its own CSV
+func (w *Writer) SetSplitCSV(split bool) {
+	w.splitCSV = split
+}
+
+// SetParquet also writes the results as a Parquet table
+func (w *Writer) SetParquet(enabled bool) {
+	w.parquet = enabled
+}
+
 // WriteAll writes all output files (CSV, JSON, and metadata)
 func (w *Writer) WriteAll(results []models.QueryResults, index *models.StoredIndex) error {
 	// Ensure output directory exists
@@ -34,20 +60,34 @@ func (w *Writer) WriteAll(results []models.QueryResults, index *models.StoredInd
 
 	// Write CSV
 	csvPath := filepath.Join(w.outputDir, "results.csv")
-	if err := WriteCSV(csvPath, results); err != nil {
+	if err := WriteCSV(csvPath, results, w.sortBy); err != nil {
 		return fmt.Errorf("write CSV: %w", err)
 	}
+	if w.splitCSV {
+		algorithms, byAlgorithm := splitByAlgorithm(results)
+		for _, algorithm := range algorithms {
+			path := filepath.Join(w.outputDir, AlgorithmCSVFileName(algorithm))
+			if err := WriteCSV(path, byAlgorithm[algorithm], w.sortBy); err != nil {
+				return fmt.Errorf("write %s CSV: %w", algorithm, err)
+			}
+		}
+	}
+
+	if w.parquet {
+		if err := WriteParquet(filepath.Join(w.outputDir, ResultsParquetFileName), results, w.sortBy); err != nil {
+			return fmt.Errorf("write Parquet: %w", err)
+		}
+	}
 
 	// Write JSON
 	jsonPath := filepath.Join(w.outputDir, "results.json")
-	if err := WriteJSON(jsonPath, results); err != nil {
+	if err := WriteJSON(jsonPath, sortResults(results, w.sortBy)); err != nil {
 		return fmt.Errorf("write JSON: %w", err)
 	}
 
-	// Write metadata
-	metadataPath := filepath.Join(w.outputDir, "metadata.txt")
-	if err := w.writeMetadata(metadataPath, results, index); err != nil {
-		return fmt.Errorf("write metadata: %w", err)
+	// Write the manifest and metadata.txt
+	if err := w.writeManifest(results, index); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
 	}
 
 	// Copy index if provided (only if not already there)
@@ -68,106 +108,179 @@ func (w *Writer) WriteAll(results []models.QueryResults, index *models.StoredInd
 	return nil
 }
 
-func (w *Writer) writeMetadata(path string, results []models.QueryResults, index *models.StoredIndex) error {
+// writeManifest records the results, and the index when one is given, in
+// the run folder's manifest
+func (w *Writer) writeManifest(results []models.QueryResults, index *models.StoredIndex) error {
 	if len(results) == 0 {
 		return fmt.Errorf("no results to write metadata for")
 	}
 
-	// Check if metadata already exists (from generate step)
-	existingMetadata, _ := os.ReadFile(path)
+	info := &ResultsInfo{
+		GeneratedAt: results[0].RunAt,
+		Queries:     len(results),
+		Counts:      make([]QueryCount, len(results)),
+		CacheMode:   w.cacheMode,
+	}
+	if w.sortBy != SortRun {
+		info.ExportSort = w.sortBy
+	}
+	seen := make(map[string]bool)
+	for i, qr := range results {
+		if !seen[qr.Algorithm] {
+			seen[qr.Algorithm] = true
+			info.Algorithms = append(info.Algorithms, qr.Algorithm)
+		}
+		info.Counts[i] = QueryCount{Query: qr.Query, Algorithm: qr.AlgorithmLabel(), Results: len(qr.Results)}
+	}
 
-	metadata := fmt.Sprintf(`Search Test Bed - Run Results
-Generated: %s
+	return UpdateManifest(w.outputDir, func(m *Manifest) {
+		m.Results = info
+		if index != nil {
+			m.Index = NewIndexInfo(index)
+		}
+	})
+}
 
-Query Results:
-- Total Queries: %d
-- Algorithms Used: %s
+// LoadResults loads query results from a JSON file
+func LoadResults(path string) ([]models.QueryResults, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read results file: %w", err)
+	}
+	defer f.Close()
 
-Queries:
-`,
-		results[0].RunAt.Format("2006-01-02 15:04:05"),
-		len(results),
-		extractAlgorithms(results),
-	)
+	return ReadResults(f)
+}
 
-	for i, result := range results {
-		metadata += fmt.Sprintf("  %d. %s (%s) - %d results\n",
-			i+1, result.Query, result.Algorithm, len(result.Results))
+// ReadResults reads query results in the results.json format from r
+func ReadResults(r io.Reader) ([]models.QueryResults, error) {
+	var results []models.QueryResults
+	if err := json.NewDecoder(r).Decode(&results); err != nil {
+		return nil, fmt.Errorf("parse results: %w", err)
 	}
 
-	if index != nil {
-		metadata += fmt.Sprintf(`
-Index Information:
-- Source: %s
-- Document Count: %d
-- Version: %s
-`,
-			index.SourceIndex,
-			len(index.Documents),
-			index.Version,
-		)
-	} else if len(existingMetadata) > 0 {
-		// If metadata already exists (from generate), append query info
-		metadata = string(existingMetadata) + "\n" + metadata
-	}
-
-	metadata += `
-Files in this folder:
-- index.json              : Generated test index
-- results.csv             : Query results in CSV format
-- results.json            : Query results in JSON format
-- metadata.txt            : This file
-
-Comparison Reports (generated by 'compare' command):
-- comparison_historical.txt  : Historical comparison (vs previous run)
-- comparison_cross_query.txt : Cross-query comparison (within this run)
-`
-
-	return os.WriteFile(path, []byte(metadata), resultFileMode)
+	return results, nil
 }
 
-func extractAlgorithms(results []models.QueryResults) string {
-	algMap := make(map[string]bool)
-	for _, r := range results {
-		algMap[r.Algorithm] = true
-	}
+// CorporaDirName is the run folder directory holding the documents of each
+// corpus the run command used
+const CorporaDirName = "corpora"
 
-	algs := make([]string, 0, len(algMap))
-	for alg := range algMap {
-		algs = append(algs, alg)
+// ClusterFileName is the run folder file holding the cluster snapshot
+const ClusterFileName = "cluster.json"
+
+// MappingFileName is the run folder file holding the index settings and
+// mappings the index was loaded with
+const MappingFileName = "mapping.json"
+
+// ScriptsFileName is the run folder file holding the scoring scripts used
+const ScriptsFileName = "scripts.json"
+
+// WeightsFileName is the run folder file holding the document weights used
+const WeightsFileName = "weights.json"
+
+// AnalyticsFileName is the run folder file holding imported page traffic
+const AnalyticsFileName = "analytics.json"
+
+// BaselineFileName is the run folder file holding agreement with the click baseline
+const BaselineFileName = "baseline.json"
+
+// CorpusScalingFileName is the run folder file holding the corpus scaling report
+const CorpusScalingFileName = "corpus_scaling.json"
+
+// QASampleFileName is the run folder file holding the manual review sample
+const QASampleFileName = "qa_sample.csv"
+
+// WatchlistFileName is the run folder file holding watchlist documents that
+// left the top K since the previous run
+const WatchlistFileName = "watchlist.json"
+
+// JudgmentsFileName is the run folder file holding judgments recorded with
+// the judge command
+const JudgmentsFileName = "judgments.json"
+
+// ParetoFileName is the run folder file holding the relevance-latency
+// Pareto report
+const ParetoFileName = "pareto.json"
+
+// TimingsFileName is the run folder file holding each command's time per
+// phase
+const TimingsFileName = "timings.json"
+
+// TraceFileName is the run folder file logging every search sent to
+// Elasticsearch, one JSON object per line
+const TraceFileName = "trace.jsonl"
+
+// MovementsParquetFileName is the run folder file holding every result's
+// movement since the previous run as a Parquet table
+const MovementsParquetFileName = "movements.parquet"
+
+// SuiteDiffFileName is the run folder file holding the query-by-query
+// comparison of two query suites
+const SuiteDiffFileName = "suite_diff.json"
+
+// ProfilesDirName is the run folder directory holding Go profiles written by
+// the hidden --cpuprofile, --memprofile and --trace flags
+const ProfilesDirName = "profiles"
+
+// LoadCorpusSizes returns the document count of each corpus saved in a run
+// folder by the run command
+func LoadCorpusSizes(runFolder string) (map[string]int, error) {
+	files, err := filepath.Glob(filepath.Join(runFolder, CorporaDirName, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("list corpora: %w", err)
 	}
 
-	if len(algs) == 0 {
-		return "none"
+	sizes := make(map[string]int, len(files))
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read corpus: %w", err)
+		}
+		var index models.StoredIndex
+		if err := json.Unmarshal(data, &index); err != nil {
+			return nil, fmt.Errorf("parse corpus %s: %w", filepath.Base(path), err)
+		}
+		sizes[strings.TrimSuffix(filepath.Base(path), ".json")] = len(index.Documents)
 	}
-	if len(algs) == 1 {
-		return algs[0]
+
+	return sizes, nil
+}
+
+// LoadAnalytics loads the page traffic imported into a run folder
+func LoadAnalytics(runFolder string) (*models.Analytics, error) {
+	data, err := os.ReadFile(filepath.Join(runFolder, AnalyticsFileName))
+	if err != nil {
+		return nil, fmt.Errorf("read analytics: %w", err)
 	}
 
-	result := algs[0]
-	for i := 1; i < len(algs); i++ {
-		result += ", " + algs[i]
+	var analytics models.Analytics
+	if err := json.Unmarshal(data, &analytics); err != nil {
+		return nil, fmt.Errorf("parse analytics: %w", err)
 	}
-	return result
+
+	return &analytics, nil
 }
 
-// LoadResults loads query results from a JSON file
-func LoadResults(path string) ([]models.QueryResults, error) {
-	data, err := os.ReadFile(path)
+// LoadClusterSnapshot loads the cluster snapshot saved in a run folder
+func LoadClusterSnapshot(runFolder string) (*models.ClusterSnapshot, error) {
+	data, err := os.ReadFile(filepath.Join(runFolder, ClusterFileName))
 	if err != nil {
-		return nil, fmt.Errorf("read results file: %w", err)
+		return nil, fmt.Errorf("read cluster snapshot: %w", err)
 	}
 
-	var results []models.QueryResults
-	if err := json.Unmarshal(data, &results); err != nil {
-		return nil, fmt.Errorf("parse results: %w", err)
+	var snapshot models.ClusterSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("parse cluster snapshot: %w", err)
 	}
 
-	return results, nil
+	return &snapshot, nil
 }
 
-// WriteText writes text content to a file
+// WriteText writes text content to a file, creating its directory if needed
 func WriteText(path, content string) error {
-	// #nosec G306 - output is test data, not sensitive
-	return os.WriteFile(path, []byte(content), resultFileMode)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+	return writeFileAtomic(path, []byte(content))
 }
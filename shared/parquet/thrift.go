@@ -0,0 +1,90 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Thrift compact protocol type codes
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the structs of the Parquet footer and page headers
+// with the Thrift compact protocol
+type thriftWriter struct {
+	buf    bytes.Buffer
+	fields []int16 // Last field ID written in each open struct
+}
+
+func (t *thriftWriter) varint(v uint64) {
+	t.buf.Write(binary.AppendUvarint(nil, v))
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func (t *thriftWriter) fieldHeader(id int16, typ byte) {
+	last := &t.fields[len(t.fields)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(zigzag(int64(id)))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) beginStruct() {
+	t.fields = append(t.fields, 0)
+}
+
+func (t *thriftWriter) endStruct() {
+	t.buf.WriteByte(0)
+	t.fields = t.fields[:len(t.fields)-1]
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.fieldHeader(id, thriftI32)
+	t.varint(zigzag(int64(v)))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.fieldHeader(id, thriftI64)
+	t.varint(zigzag(v))
+}
+
+func (t *thriftWriter) str(id int16, s string) {
+	t.fieldHeader(id, thriftBinary)
+	t.varint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
+
+func (t *thriftWriter) structField(id int16) {
+	t.fieldHeader(id, thriftStruct)
+	t.beginStruct()
+}
+
+func (t *thriftWriter) listField(id int16, elemType byte, size int) {
+	t.fieldHeader(id, thriftList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		t.buf.WriteByte(0xf0 | elemType)
+		t.varint(uint64(size))
+	}
+}
+
+func (t *thriftWriter) listI32(v int32) {
+	t.varint(zigzag(int64(v)))
+}
+
+func (t *thriftWriter) listString(s string) {
+	t.varint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
@@ -0,0 +1,318 @@
+// Package parquet writes flat tables as Parquet files for loading into
+// pandas, Spark or DuckDB. It covers just what exports need: one row group,
+// PLAIN-encoded uncompressed pages, and string, integer, float, boolean and
+// timestamp columns that may be optional.
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// Type is a column's value type
+type Type int
+
+// Column types
+const (
+	String    Type = iota // UTF-8 text, written from string
+	Int64                 // Written from int or int64
+	Double                // Written from float64
+	Bool                  // Written from bool
+	Timestamp             // Millisecond precision, written from time.Time
+)
+
+// Column describes one column of a table. Optional columns accept nil.
+type Column struct {
+	Name     string
+	Type     Type
+	Optional bool
+}
+
+// Parquet enum values used in the footer and page headers
+const (
+	physicalBoolean   = 0
+	physicalInt64     = 2
+	physicalDouble    = 5
+	physicalByteArray = 6
+
+	repetitionRequired = 0
+	repetitionOptional = 1
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	codecUncompressed = 0
+	pageData          = 0
+)
+
+var magic = []byte("PAR1")
+
+// column buffers one column's values until the file is written
+type column struct {
+	Column
+	present   []bool // Definition levels: false where the value is null
+	values    bytes.Buffer
+	lastValue int // Length of values before the last one was added
+	bools     []bool
+}
+
+// Writer buffers rows and writes them as a Parquet file on Close
+type Writer struct {
+	w       io.Writer
+	columns []*column
+	rows    int64
+}
+
+// NewWriter creates a writer for a table with the given columns
+func NewWriter(w io.Writer, columns []Column) *Writer {
+	cols := make([]*column, len(columns))
+	for i, c := range columns {
+		cols[i] = &column{Column: c}
+	}
+	return &Writer{w: w, columns: cols}
+}
+
+// Write adds a row, one value per column in column order
+func (w *Writer) Write(values ...any) error {
+	if len(values) != len(w.columns) {
+		return fmt.Errorf("row has %d values, want %d", len(values), len(w.columns))
+	}
+	for i, v := range values {
+		if err := w.columns[i].add(v); err != nil {
+			// Drop the values already added so the columns stay aligned
+			for _, c := range w.columns[:i] {
+				c.truncate(w.rows)
+			}
+			return fmt.Errorf("column %s: %w", w.columns[i].Name, err)
+		}
+	}
+	w.rows++
+	return nil
+}
+
+// truncate drops any values after the first rows
+func (c *column) truncate(rows int64) {
+	if int64(len(c.present)) <= rows {
+		return
+	}
+	if c.present[rows] {
+		switch c.Type {
+		case Bool:
+			c.bools = c.bools[:len(c.bools)-1]
+		default:
+			c.values.Truncate(c.lastValue)
+		}
+	}
+	c.present = c.present[:rows]
+}
+
+func (c *column) add(v any) error {
+	if v == nil {
+		if !c.Optional {
+			return fmt.Errorf("null value in a required column")
+		}
+		c.present = append(c.present, false)
+		return nil
+	}
+
+	c.lastValue = c.values.Len()
+	switch c.Type {
+	case String:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("got %T, want string", v)
+		}
+		_ = binary.Write(&c.values, binary.LittleEndian, uint32(len(s)))
+		c.values.WriteString(s)
+	case Int64:
+		switch n := v.(type) {
+		case int:
+			_ = binary.Write(&c.values, binary.LittleEndian, int64(n))
+		case int64:
+			_ = binary.Write(&c.values, binary.LittleEndian, n)
+		default:
+			return fmt.Errorf("got %T, want int or int64", v)
+		}
+	case Double:
+		f, ok := v.(float64)
+		if !ok {
+			return fmt.Errorf("got %T, want float64", v)
+		}
+		_ = binary.Write(&c.values, binary.LittleEndian, math.Float64bits(f))
+	case Bool:
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("got %T, want bool", v)
+		}
+		c.bools = append(c.bools, b)
+	case Timestamp:
+		t, ok := v.(time.Time)
+		if !ok {
+			return fmt.Errorf("got %T, want time.Time", v)
+		}
+		_ = binary.Write(&c.values, binary.LittleEndian, t.UnixMilli())
+	default:
+		return fmt.Errorf("unknown column type %d", c.Type)
+	}
+	c.present = append(c.present, true)
+	return nil
+}
+
+// Close writes the buffered rows as a Parquet file. It does not close the
+// underlying writer.
+func (w *Writer) Close() error {
+	var file bytes.Buffer
+	file.Write(magic)
+
+	type chunk struct {
+		offset, size int64
+	}
+	chunks := make([]chunk, len(w.columns))
+	var totalSize int64
+	for i, c := range w.columns {
+		page := c.page()
+
+		var header thriftWriter
+		header.beginStruct()
+		header.i32(1, pageData)
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(len(page)))
+		header.structField(5)
+		header.i32(1, int32(w.rows))
+		header.i32(2, encodingPlain)
+		header.i32(3, encodingRLE)
+		header.i32(4, encodingRLE)
+		header.endStruct()
+		header.endStruct()
+
+		chunks[i] = chunk{offset: int64(file.Len()), size: int64(header.buf.Len() + len(page))}
+		totalSize += chunks[i].size
+		file.Write(header.buf.Bytes())
+		file.Write(page)
+	}
+
+	var meta thriftWriter
+	meta.beginStruct()
+	meta.i32(1, 1)
+	meta.listField(2, thriftStruct, len(w.columns)+1)
+	meta.beginStruct()
+	meta.str(4, "schema")
+	meta.i32(5, int32(len(w.columns)))
+	meta.endStruct()
+	for _, c := range w.columns {
+		meta.beginStruct()
+		meta.i32(1, c.physicalType())
+		repetition := int32(repetitionRequired)
+		if c.Optional {
+			repetition = repetitionOptional
+		}
+		meta.i32(3, repetition)
+		meta.str(4, c.Name)
+		switch c.Type {
+		case String:
+			meta.i32(6, convertedUTF8)
+		case Timestamp:
+			meta.i32(6, convertedTimestampMillis)
+		}
+		meta.endStruct()
+	}
+	meta.i64(3, w.rows)
+	meta.listField(4, thriftStruct, 1)
+	meta.beginStruct()
+	meta.listField(1, thriftStruct, len(w.columns))
+	for i, c := range w.columns {
+		meta.beginStruct()
+		meta.i64(2, chunks[i].offset)
+		meta.structField(3)
+		meta.i32(1, c.physicalType())
+		meta.listField(2, thriftI32, 2)
+		meta.listI32(encodingPlain)
+		meta.listI32(encodingRLE)
+		meta.listField(3, thriftBinary, 1)
+		meta.listString(c.Name)
+		meta.i32(4, codecUncompressed)
+		meta.i64(5, w.rows)
+		meta.i64(6, chunks[i].size)
+		meta.i64(7, chunks[i].size)
+		meta.i64(9, chunks[i].offset)
+		meta.endStruct()
+		meta.endStruct()
+	}
+	meta.i64(2, totalSize)
+	meta.i64(3, w.rows)
+	meta.endStruct()
+	meta.str(6, "search-testbed")
+	meta.endStruct()
+
+	file.Write(meta.buf.Bytes())
+	_ = binary.Write(&file, binary.LittleEndian, uint32(meta.buf.Len()))
+	file.Write(magic)
+
+	if _, err := w.w.Write(file.Bytes()); err != nil {
+		return fmt.Errorf("write parquet: %w", err)
+	}
+	return nil
+}
+
+func (c *column) physicalType() int32 {
+	switch c.Type {
+	case String:
+		return physicalByteArray
+	case Double:
+		return physicalDouble
+	case Bool:
+		return physicalBoolean
+	default:
+		return physicalInt64
+	}
+}
+
+// page is the column's data page body: definition levels for optional
+// columns, then the PLAIN-encoded values that are present
+func (c *column) page() []byte {
+	var page bytes.Buffer
+	if c.Optional {
+		levels := definitionLevels(c.present)
+		_ = binary.Write(&page, binary.LittleEndian, uint32(len(levels)))
+		page.Write(levels)
+	}
+	if c.Type == Bool {
+		packed := make([]byte, (len(c.bools)+7)/8)
+		for i, b := range c.bools {
+			if b {
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+		page.Write(packed)
+	} else {
+		page.Write(c.values.Bytes())
+	}
+	return page.Bytes()
+}
+
+// definitionLevels encodes whether each value is present as RLE runs of
+// the RLE/bit-packed hybrid encoding, with a bit width of 1
+func definitionLevels(present []bool) []byte {
+	var out []byte
+	for i := 0; i < len(present); {
+		j := i
+		for j < len(present) && present[j] == present[i] {
+			j++
+		}
+		out = binary.AppendUvarint(out, uint64(j-i)<<1)
+		if present[i] {
+			out = append(out, 1)
+		} else {
+			out = append(out, 0)
+		}
+		i = j
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func TestThriftWriter(t *testing.T) {
+	var tw thriftWriter
+	tw.beginStruct()
+	tw.i32(1, 1)
+	tw.str(4, "ab")
+	tw.i64(20, 3)
+	tw.endStruct()
+
+	// Short field headers pack the ID delta with the type; a jump of more
+	// than 15 needs the type then the zigzag ID
+	want := []byte{0x15, 0x02, 0x38, 0x02, 'a', 'b', 0x06, 0x28, 0x06, 0x00}
+	if got := tw.buf.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("encoded % x, want % x", got, want)
+	}
+}
+
+func TestDefinitionLevels(t *testing.T) {
+	got := definitionLevels([]bool{true, true, false, true})
+	want := []byte{4, 1, 2, 0, 2, 1}
+	if !bytes.Equal(got, want) {
+		t.Errorf("definitionLevels() = % x, want % x", got, want)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, []Column{
+		{Name: "query", Type: String},
+		{Name: "rank", Type: Int64, Optional: true},
+		{Name: "score", Type: Double},
+		{Name: "judged", Type: Bool},
+		{Name: "at", Type: Timestamp},
+	})
+	at := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	if err := w.Write("cpi", 1, 2.5, true, at); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Write("gdp", nil, 1.0, false, at); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Write(nil, 1, 1.0, true, at); err == nil {
+		t.Error("Write() accepted null in a required column")
+	}
+	if err := w.Write("jobs", "1", 1.0, true, at); err == nil {
+		t.Error("Write() accepted a string in an integer column")
+	}
+	if w.rows != 2 || len(w.columns[0].present) != 2 || w.columns[0].values.Len() != 14 {
+		t.Errorf("rejected rows left values behind")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, magic) || !bytes.HasSuffix(data, magic) {
+		t.Fatalf("file does not start and end with %q", magic)
+	}
+	footer := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if footer <= 0 || footer > len(data)-12 {
+		t.Fatalf("footer length %d out of range for a %d byte file", footer, len(data))
+	}
+	if !bytes.Contains(data[len(data)-8-footer:], []byte("judged")) {
+		t.Error("footer is missing the column names")
+	}
+	// The first column's page body is each value's length and bytes
+	if !bytes.Contains(data, []byte("\x03\x00\x00\x00cpi\x03\x00\x00\x00gdp")) {
+		t.Error("string values not PLAIN encoded")
+	}
+}
@@ -0,0 +1,99 @@
+package paths
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// LabelsFileName is the run folder file holding the labels given to a run
+const LabelsFileName = "labels.json"
+
+// LabelPrefix marks a run given by label, e.g. "name:synonym-boost"
+const LabelPrefix = "name:"
+
+// runLabels is the content of a run's labels file
+type runLabels struct {
+	Labels []string `json:"labels"`
+}
+
+// ValidateLabel checks a label can name a run
+func ValidateLabel(label string) error {
+	if strings.TrimSpace(label) == "" {
+		return errors.New("label is empty")
+	}
+	if strings.TrimSpace(label) != label {
+		return fmt.Errorf("label %q has leading or trailing spaces", label)
+	}
+	return nil
+}
+
+// Labels returns the labels given to a run, if any
+func Labels(runFolder string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(runFolder, LabelsFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read labels: %w", err)
+	}
+	var l runLabels
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("parse labels: %w", err)
+	}
+	return l.Labels, nil
+}
+
+// AddLabels adds labels to a run, keeping any it already has
+func AddLabels(runFolder string, labels []string) error {
+	existing, err := Labels(runFolder)
+	if err != nil {
+		return err
+	}
+	for _, label := range labels {
+		if err := ValidateLabel(label); err != nil {
+			return err
+		}
+		if !slices.Contains(existing, label) {
+			existing = append(existing, label)
+		}
+	}
+
+	data, err := json.MarshalIndent(runLabels{Labels: existing}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal labels: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(runFolder, LabelsFileName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("write labels: %w", err)
+	}
+	return nil
+}
+
+// FindLabelled returns the newest run folder with the given label
+func (l Layout) FindLabelled(baseDir, label string) (string, error) {
+	folders, err := l.ListRunFolders(baseDir)
+	if err != nil {
+		return "", err
+	}
+	for _, folder := range folders {
+		labels, err := Labels(folder)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", RunID(folder), err)
+		}
+		if slices.Contains(labels, label) {
+			return folder, nil
+		}
+	}
+	return "", fmt.Errorf("no run labelled %q in %s", label, baseDir)
+}
+
+// IsReference reports whether run names a run indirectly: a pointer,
+// previous, or a label with LabelPrefix
+func IsReference(run string) bool {
+	return IsPointer(run) || run == PreviousRun || strings.HasPrefix(run, LabelPrefix)
+}
@@ -0,0 +1,62 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLabels(t *testing.T) {
+	baseDir := t.TempDir()
+	older := filepath.Join(baseDir, "run_2024-01-15_10-30-00.000")
+	newer := filepath.Join(baseDir, "run_2024-01-16_10-30-00.000")
+	for _, folder := range []string{older, newer} {
+		if err := os.Mkdir(folder, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(folder, "results.json"), []byte("[]"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := AddLabels(older, []string{"synonym-boost", "v1"}); err != nil {
+		t.Fatalf("AddLabels() error = %v", err)
+	}
+	if err := AddLabels(older, []string{"v1", "reviewed"}); err != nil {
+		t.Fatalf("AddLabels() error = %v", err)
+	}
+	if err := AddLabels(newer, []string{"v1"}); err != nil {
+		t.Fatalf("AddLabels() error = %v", err)
+	}
+	if err := AddLabels(newer, []string{" "}); err == nil {
+		t.Error("AddLabels() accepted a blank label")
+	}
+
+	labels, err := Labels(older)
+	if err != nil {
+		t.Fatalf("Labels() error = %v", err)
+	}
+	if want := []string{"synonym-boost", "v1", "reviewed"}; !reflect.DeepEqual(labels, want) {
+		t.Errorf("Labels() = %v, want %v", labels, want)
+	}
+
+	tests := []struct {
+		run     string
+		want    string
+		wantErr bool
+	}{
+		{run: "name:synonym-boost", want: older},
+		{run: "name:v1", want: newer},
+		{run: "name:missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := DefaultLayout.ResolveResults(baseDir, tt.run)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ResolveResults(%s) error = %v, wantErr %v", tt.run, err, tt.wantErr)
+		}
+		if !tt.wantErr && got != filepath.Join(tt.want, "results.json") {
+			t.Errorf("ResolveResults(%s) = %s, want the results of %s", tt.run, got, tt.want)
+		}
+	}
+}
@@ -1,32 +1,209 @@
 package paths
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/ONSdigital/dis-search-test-bed/shared/clock"
 )
 
-// CreateRunFolder creates a timestamped run folder
-func CreateRunFolder(baseDir string) (string, error) {
-	timestamp := time.Now().Format("2006-01-02_15-04-05")
-	runFolder := filepath.Join(baseDir, "run_"+timestamp)
+// runFolderLayout names run folders to the millisecond. Folders from older
+// versions have no fractional part and still parse with it.
+const runFolderLayout = "2006-01-02_15-04-05.000"
+
+// maxRunFolderSuffix bounds the attempts to find a free run folder name
+const maxRunFolderSuffix = 100
+
+// DefaultFolderTemplate names run folders after the time they were created
+const DefaultFolderTemplate = "run_{time}"
+
+// Folder template placeholders
+const (
+	placeholderTime       = "{time}"
+	placeholderTag        = "{tag}"
+	placeholderExperiment = "{experiment}"
+)
+
+// timePattern matches the {time} part of a run folder name, with or without
+// milliseconds
+const timePattern = `(\d{4}-\d{2}-\d{2}_\d{2}-\d{2}-\d{2}(?:\.\d+)?)`
+
+// Layout describes how run folders are named and arranged. Folder is a
+// folder name template where {time} is the creation time and {tag} and
+// {experiment} are replaced by Tag and Experiment, e.g.
+// "{experiment}_run_{time}". Only folders whose names fit the template are
+// discovered as runs; while Tag or Experiment is empty, its placeholder
+// matches any value.
+type Layout struct {
+	Folder     string
+	Tag        string
+	Experiment string
+	ReportsDir string // Run folder subdirectory for comparison reports; empty for the run folder itself
+
+	Reports ReportNames
+}
+
+// Default base names of the comparison reports
+const (
+	DefaultHistoricalReport = "comparison_historical"
+	DefaultCrossQueryReport = "comparison_cross_query"
+	DefaultScoreDriftReport = "comparison_score_drift"
+)
+
+// comparisonsDir is the reports subdirectory holding the per-query
+// comparisons
+const comparisonsDir = "comparisons"
+
+// ReportNames are the base names compare saves its reports under, each once
+// per format with the format's extension. Empty names take the defaults.
+type ReportNames struct {
+	Historical string
+	CrossQuery string
+	ScoreDrift string
+}
+
+// DefaultLayout is the layout of run folders when none is configured
+var DefaultLayout = Layout{Folder: DefaultFolderTemplate}
+
+func (l Layout) template() string {
+	if l.Folder == "" {
+		return DefaultFolderTemplate
+	}
+	return l.Folder
+}
+
+// Validate checks the folder template and reports directory
+func (l Layout) Validate() error {
+	tmpl := l.template()
+	if strings.Count(tmpl, placeholderTime) != 1 {
+		return fmt.Errorf("folder template %q must contain %s once", tmpl, placeholderTime)
+	}
+	if strings.ContainsAny(tmpl, `/\*?[`) {
+		return fmt.Errorf("folder template %q must be a single folder name without wildcards", tmpl)
+	}
+	rest := strings.NewReplacer(placeholderTime, "", placeholderTag, "", placeholderExperiment, "").Replace(tmpl)
+	if strings.ContainsAny(rest, "{}") {
+		return fmt.Errorf("folder template %q has an unknown placeholder; use %s, %s or %s",
+			tmpl, placeholderTime, placeholderTag, placeholderExperiment)
+	}
+	for _, v := range []struct{ placeholder, value string }{
+		{placeholderTag, l.Tag},
+		{placeholderExperiment, l.Experiment},
+	} {
+		if strings.ContainsAny(v.value, `/\*?[`) || v.value == "." || v.value == ".." {
+			return fmt.Errorf("%s %q can't be used in a folder name", strings.Trim(v.placeholder, "{}"), v.value)
+		}
+	}
+	if l.ReportsDir != "" && !filepath.IsLocal(l.ReportsDir) {
+		return fmt.Errorf("reports directory %q must be a path inside the run folder", l.ReportsDir)
+	}
+	return l.ReportNames().validate()
+}
+
+// validate checks every report name is a distinct plain file name
+func (n ReportNames) validate() error {
+	seen := make(map[string]bool, 3)
+	for _, name := range []string{n.Historical, n.CrossQuery, n.ScoreDrift} {
+		if strings.ContainsAny(name, `/\*?[`) || name == "." || name == ".." || name == comparisonsDir {
+			return fmt.Errorf("report name %q must be a single file name without wildcards", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("report name %q is used for more than one report", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// name returns the folder name for a run created at the given time
+func (l Layout) name(at time.Time) string {
+	return strings.NewReplacer(
+		placeholderTime, at.Format(runFolderLayout),
+		placeholderTag, l.Tag,
+		placeholderExperiment, l.Experiment,
+	).Replace(l.template())
+}
+
+// pattern matches the names of run folders in this layout, capturing the
+// time. Names may end with the suffix added to keep concurrent runs apart.
+func (l Layout) pattern() *regexp.Regexp {
+	valuePattern := func(value string) string {
+		if value == "" {
+			return `.*?`
+		}
+		return regexp.QuoteMeta(value)
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	tmpl := l.template()
+	for tmpl != "" {
+		i := strings.IndexByte(tmpl, '{')
+		if i < 0 {
+			b.WriteString(regexp.QuoteMeta(tmpl))
+			break
+		}
+		b.WriteString(regexp.QuoteMeta(tmpl[:i]))
+		tmpl = tmpl[i:]
+		switch {
+		case strings.HasPrefix(tmpl, placeholderTime):
+			b.WriteString(timePattern)
+			tmpl = tmpl[len(placeholderTime):]
+		case strings.HasPrefix(tmpl, placeholderTag):
+			b.WriteString(valuePattern(l.Tag))
+			tmpl = tmpl[len(placeholderTag):]
+		case strings.HasPrefix(tmpl, placeholderExperiment):
+			b.WriteString(valuePattern(l.Experiment))
+			tmpl = tmpl[len(placeholderExperiment):]
+		default:
+			b.WriteString(regexp.QuoteMeta(tmpl[:1]))
+			tmpl = tmpl[1:]
+		}
+	}
+	b.WriteString(`(?:_\d+)?$`)
+	return regexp.MustCompile(b.String())
+}
+
+// CreateRunFolder creates a run folder named from the clock's current time.
+// Folders are created exclusively: if one for the same millisecond already
+// exists, a numeric suffix is added (run_..._2, run_..._3) so concurrent
+// runs never share a folder.
+func (l Layout) CreateRunFolder(baseDir string, clk clock.Clock) (string, error) {
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		return "", fmt.Errorf("create base directory: %w", err)
+	}
 
-	if err := os.MkdirAll(runFolder, 0755); err != nil {
-		return "", fmt.Errorf("create run folder: %w", err)
+	name := l.name(clock.OrReal(clk).Now())
+	for attempt := 1; attempt <= maxRunFolderSuffix; attempt++ {
+		runFolder := filepath.Join(baseDir, name)
+		if attempt > 1 {
+			runFolder = fmt.Sprintf("%s_%d", runFolder, attempt)
+		}
+
+		err := os.Mkdir(runFolder, 0755)
+		if err == nil {
+			return runFolder, nil
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("create run folder: %w", err)
+		}
 	}
 
-	return runFolder, nil
+	return "", fmt.Errorf("create run folder: %s already used %d times", name, maxRunFolderSuffix)
 }
 
 // FindLatestIndex finds the most recent index.json file
-func FindLatestIndex(baseDir string) (string, error) {
-	pattern := filepath.Join(baseDir, "run_*", "index.json")
-	matches, err := filepath.Glob(pattern)
+func (l Layout) FindLatestIndex(baseDir string) (string, error) {
+	matches, err := l.findRunFiles(baseDir, "index.json")
 	if err != nil {
-		return "", fmt.Errorf("glob pattern: %w", err)
+		return "", err
 	}
 
 	if len(matches) == 0 {
@@ -34,7 +211,7 @@ func FindLatestIndex(baseDir string) (string, error) {
 	}
 
 	// Sort by modification time
-	sort.Slice(matches, func(i, j int) bool {
+	sort.SliceStable(matches, func(i, j int) bool {
 		infoI, _ := os.Stat(matches[i])
 		infoJ, _ := os.Stat(matches[j])
 		return infoI.ModTime().After(infoJ.ModTime())
@@ -44,89 +221,261 @@ func FindLatestIndex(baseDir string) (string, error) {
 }
 
 // FindLatestResults finds the most recent results.json file
-func FindLatestResults(baseDir string) (string, error) {
-	pattern := filepath.Join(baseDir, "run_*", "results.json")
-	matches, err := filepath.Glob(pattern)
+func (l Layout) FindLatestResults(baseDir string) (string, error) {
+	matches, err := l.findRunFiles(baseDir, "results.json")
 	if err != nil {
-		return "", fmt.Errorf("glob pattern: %w", err)
+		return "", err
 	}
 
 	if len(matches) == 0 {
 		return "", fmt.Errorf("no results files found in %s", baseDir)
 	}
 
-	// Sort by path (which includes timestamp)
-	sort.Slice(matches, func(i, j int) bool {
-		return matches[i] > matches[j]
-	})
-
 	return matches[0], nil
 }
 
-// FindPreviousResults finds the previous results.json file
-func FindPreviousResults(baseDir, currentPath string) (string, error) {
-	pattern := filepath.Join(baseDir, "run_*", "results.json")
-	matches, err := filepath.Glob(pattern)
+// FindPreviousResults finds the results.json of the newest run created
+// before the one holding currentPath. Results from outside baseDir have no
+// place among its runs, so the latest run is previous to them.
+func (l Layout) FindPreviousResults(baseDir, currentPath string) (string, error) {
+	current, err := filepath.Abs(filepath.Dir(currentPath))
 	if err != nil {
-		return "", fmt.Errorf("glob pattern: %w", err)
+		return "", fmt.Errorf("resolve current results: %w", err)
+	}
+	base, err := filepath.Abs(baseDir)
+	if err != nil {
+		return "", fmt.Errorf("resolve base directory: %w", err)
 	}
 
-	if len(matches) < 2 {
-		return "", fmt.Errorf("no previous results found")
+	folders, err := l.ListRunFolders(baseDir)
+	if err != nil {
+		return "", err
 	}
 
-	// Sort by path (which includes timestamp) in descending order
-	sort.Slice(matches, func(i, j int) bool {
-		return matches[i] > matches[j]
-	})
+	// Folders are listed newest first, so the runs before the current one
+	// follow it
+	earlier := folders
+	if filepath.Dir(current) == base {
+		earlier = nil
+		for i, folder := range folders {
+			if filepath.Join(base, filepath.Base(folder)) == current {
+				earlier = folders[i+1:]
+				break
+			}
+		}
+	}
 
-	// Find the previous one (not the current)
-	for _, match := range matches {
-		if match != currentPath {
-			return match, nil
+	for _, folder := range earlier {
+		path := filepath.Join(folder, "results.json")
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
 		}
 	}
 
 	return "", fmt.Errorf("no previous results found")
 }
 
-// ListRunFolders lists all run folders in the base directory
-func ListRunFolders(baseDir string) ([]string, error) {
-	pattern := filepath.Join(baseDir, "run_*")
-	matches, err := filepath.Glob(pattern)
+// findRunFiles returns the named file of every run folder that has one,
+// newest run first
+func (l Layout) findRunFiles(baseDir, name string) ([]string, error) {
+	folders, err := l.ListRunFolders(baseDir)
 	if err != nil {
-		return nil, fmt.Errorf("glob pattern: %w", err)
+		return nil, err
+	}
+
+	var files []string
+	for _, folder := range folders {
+		path := filepath.Join(folder, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
+
+// PreviousRun names the run before the latest wherever a run can be given
+const PreviousRun = "previous"
+
+// ResolveResults finds the results.json for a run. The run may be a
+// results file, a run folder, a run folder name under baseDir, a pointer
+// name (latest or baseline), previous, or name:<label>; if it is empty, or
+// latest has not been set, the latest run is used.
+func (l Layout) ResolveResults(baseDir, run string) (string, error) {
+	if run == "" {
+		return l.FindLatestResults(baseDir)
+	}
+	if label, ok := strings.CutPrefix(run, LabelPrefix); ok {
+		folder, err := l.FindLabelled(baseDir, label)
+		if err != nil {
+			return "", err
+		}
+		run = folder
+	}
+	if run == PreviousRun {
+		if _, err := os.Stat(run); err != nil {
+			latest, err := l.FindLatestResults(baseDir)
+			if err != nil {
+				return "", err
+			}
+			return l.FindPreviousResults(baseDir, latest)
+		}
 	}
 
-	// Filter for directories only
-	var folders []string
-	for _, match := range matches {
-		info, err := os.Stat(match)
-		if err == nil && info.IsDir() {
-			folders = append(folders, match)
+	candidates := []string{run, filepath.Join(baseDir, run)}
+	if IsPointer(run) {
+		if _, err := os.Stat(run); err != nil {
+			folder, err := ResolvePointer(baseDir, run)
+			switch {
+			case err == nil:
+				candidates = []string{folder}
+			case run == LatestPointer && errors.Is(err, ErrNoPointer):
+				// Runs from before the pointer existed
+				return l.FindLatestResults(baseDir)
+			default:
+				return "", err
+			}
 		}
 	}
 
-	// Sort by name (which includes timestamp)
-	sort.Slice(folders, func(i, j int) bool {
-		return folders[i] > folders[j]
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			return candidate, nil
+		}
+		path := filepath.Join(candidate, "results.json")
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("no results.json in %s", candidate)
+		}
+		return path, nil
+	}
+
+	return "", fmt.Errorf("run not found: %s", run)
+}
+
+// ListRunFolders lists the run folders in the base directory whose names
+// fit the layout, newest first
+func (l Layout) ListRunFolders(baseDir string) ([]string, error) {
+	entries, err := os.ReadDir(baseDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read base directory: %w", err)
+	}
+
+	type run struct {
+		folder string
+		at     time.Time
+	}
+	pattern := l.pattern()
+	var runs []run
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		at, ok := parseTime(pattern, entry.Name())
+		if !ok {
+			continue
+		}
+		runs = append(runs, run{folder: filepath.Join(baseDir, entry.Name()), at: at})
+	}
+
+	// Sort by time, then by name so suffixed folders follow the first
+	sort.Slice(runs, func(i, j int) bool {
+		if !runs[i].at.Equal(runs[j].at) {
+			return runs[i].at.After(runs[j].at)
+		}
+		return runs[i].folder > runs[j].folder
 	})
 
+	folders := make([]string, len(runs))
+	for i, r := range runs {
+		folders[i] = r.folder
+	}
 	return folders, nil
 }
 
-// ExtractTimestamp extracts timestamp from run folder name
-func ExtractTimestamp(runFolder string) (time.Time, error) {
-	base := filepath.Base(runFolder)
-	if !strings.HasPrefix(base, "run_") {
+// ReportsFolder returns the folder a run's comparison reports are written to
+func (l Layout) ReportsFolder(runFolder string) string {
+	return filepath.Join(runFolder, l.ReportsDir)
+}
+
+// ComparisonsFolder returns the folder a run's per-query comparisons are
+// written to
+func (l Layout) ComparisonsFolder(runFolder string) string {
+	return filepath.Join(l.ReportsFolder(runFolder), comparisonsDir)
+}
+
+// ReportNames returns the report base names, with defaults for any not set
+func (l Layout) ReportNames() ReportNames {
+	n := l.Reports
+	if n.Historical == "" {
+		n.Historical = DefaultHistoricalReport
+	}
+	if n.CrossQuery == "" {
+		n.CrossQuery = DefaultCrossQueryReport
+	}
+	if n.ScoreDrift == "" {
+		n.ScoreDrift = DefaultScoreDriftReport
+	}
+	return n
+}
+
+// ReportFiles lists the comparison reports saved for a run, in every format
+func (l Layout) ReportFiles(runFolder string) ([]string, error) {
+	n := l.ReportNames()
+	var files []string
+	for _, name := range []string{n.Historical, n.CrossQuery, n.ScoreDrift} {
+		matches, err := filepath.Glob(filepath.Join(l.ReportsFolder(runFolder), name+".*"))
+		if err != nil {
+			return nil, fmt.Errorf("list %s reports: %w", name, err)
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// Timestamp extracts the creation time from a run folder name, ignoring any
+// suffix added to keep concurrent runs apart
+func (l Layout) Timestamp(runFolder string) (time.Time, error) {
+	base := RunID(runFolder)
+	at, ok := parseTime(l.pattern(), base)
+	if !ok {
 		return time.Time{}, fmt.Errorf("invalid run folder name: %s", base)
 	}
+	return at, nil
+}
+
+// parseTime matches a folder name against a layout pattern and parses the
+// time it captures
+func parseTime(pattern *regexp.Regexp, name string) (time.Time, bool) {
+	// Parsing accepts an optional fractional second, so this matches both
+	// current and second-resolution folder names
+	const layout = "2006-01-02_15-04-05"
 
-	timestampStr := strings.TrimPrefix(base, "run_")
-	t, err := time.Parse("2006-01-02_15-04-05", timestampStr)
+	m := pattern.FindStringSubmatch(name)
+	if m == nil {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(layout, m[1])
 	if err != nil {
-		return time.Time{}, fmt.Errorf("parse timestamp: %w", err)
+		return time.Time{}, false
 	}
+	return t, true
+}
+
+// SameFolder reports whether two paths name the same existing folder,
+// following symlinks
+func SameFolder(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
 
-	return t, nil
+// RunID returns the ID of a run, which is its folder name
+func RunID(runFolder string) string {
+	return filepath.Base(runFolder)
 }
@@ -0,0 +1,331 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dis-search-test-bed/shared/clock"
+)
+
+func TestCreateRunFolder(t *testing.T) {
+	baseDir := t.TempDir()
+	at := clock.Fixed(time.Date(2024, 1, 15, 10, 30, 0, 250*int(time.Millisecond), time.UTC))
+
+	want := []string{
+		"run_2024-01-15_10-30-00.250",
+		"run_2024-01-15_10-30-00.250_2",
+		"run_2024-01-15_10-30-00.250_3",
+	}
+	for _, name := range want {
+		got, err := DefaultLayout.CreateRunFolder(baseDir, at)
+		if err != nil {
+			t.Fatalf("CreateRunFolder() error = %v", err)
+		}
+		if got != filepath.Join(baseDir, name) {
+			t.Errorf("CreateRunFolder() = %s, want %s", got, name)
+		}
+
+		ts, err := DefaultLayout.Timestamp(got)
+		if err != nil {
+			t.Fatalf("Timestamp(%s) error = %v", got, err)
+		}
+		if !ts.Equal(time.Time(at)) {
+			t.Errorf("Timestamp(%s) = %v, want %v", got, ts, time.Time(at))
+		}
+	}
+}
+
+func TestLayoutTimestamp(t *testing.T) {
+	tests := []struct {
+		name    string
+		folder  string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name:   "seconds",
+			folder: "data/run_2024-01-15_10-30-00",
+			want:   time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:   "seconds with suffix",
+			folder: "run_2024-01-15_10-30-00_2",
+			want:   time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:   "milliseconds",
+			folder: "run_2024-01-15_10-30-00.007",
+			want:   time.Date(2024, 1, 15, 10, 30, 0, 7*int(time.Millisecond), time.UTC),
+		},
+		{
+			name:   "milliseconds with suffix",
+			folder: "run_2024-01-15_10-30-00.007_12",
+			want:   time.Date(2024, 1, 15, 10, 30, 0, 7*int(time.Millisecond), time.UTC),
+		},
+		{name: "not a run", folder: "results", wantErr: true},
+		{name: "bad timestamp", folder: "run_latest", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DefaultLayout.Timestamp(tt.folder)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Timestamp() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Timestamp() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLayoutValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		layout  Layout
+		wantErr bool
+	}{
+		{name: "default", layout: Layout{}},
+		{name: "tag and experiment", layout: Layout{Folder: "{experiment}_run_{time}_{tag}", Tag: "v2", Experiment: "boosts"}},
+		{name: "reports subfolder", layout: Layout{ReportsDir: "comparison"}},
+		{name: "no time", layout: Layout{Folder: "run_{tag}"}, wantErr: true},
+		{name: "time twice", layout: Layout{Folder: "{time}_{time}"}, wantErr: true},
+		{name: "unknown placeholder", layout: Layout{Folder: "run_{time}_{user}"}, wantErr: true},
+		{name: "nested folder", layout: Layout{Folder: "{experiment}/run_{time}"}, wantErr: true},
+		{name: "tag with separator", layout: Layout{Tag: "a/b"}, wantErr: true},
+		{name: "reports outside run", layout: Layout{ReportsDir: "../reports"}, wantErr: true},
+		{name: "report names", layout: Layout{Reports: ReportNames{Historical: "vs_baseline", ScoreDrift: "drift"}}},
+		{name: "report name with separator", layout: Layout{Reports: ReportNames{CrossQuery: "reports/cross"}}, wantErr: true},
+		{name: "report names clash", layout: Layout{Reports: ReportNames{Historical: DefaultCrossQueryReport}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.layout.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLayoutListRunFolders(t *testing.T) {
+	baseDir := t.TempDir()
+	for _, name := range []string{
+		"boosts_run_2024-01-15_10-30-00.000_v1",
+		"boosts_run_2024-01-16_09-00-00.000_v2",
+		"boosts_run_2024-01-16_09-00-00.000_v2_2",
+		"synonyms_run_2024-01-14_08-00-00.000_v1",
+		"run_2024-01-17_10-00-00.000",
+		"notes",
+	} {
+		if err := os.Mkdir(filepath.Join(baseDir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		layout Layout
+		want   []string
+	}{
+		{
+			name:   "default",
+			layout: DefaultLayout,
+			want:   []string{"run_2024-01-17_10-00-00.000"},
+		},
+		{
+			name:   "any experiment or tag, newest first",
+			layout: Layout{Folder: "{experiment}_run_{time}_{tag}"},
+			want: []string{
+				"boosts_run_2024-01-16_09-00-00.000_v2_2",
+				"boosts_run_2024-01-16_09-00-00.000_v2",
+				"boosts_run_2024-01-15_10-30-00.000_v1",
+				"synonyms_run_2024-01-14_08-00-00.000_v1",
+			},
+		},
+		{
+			name:   "one experiment",
+			layout: Layout{Folder: "{experiment}_run_{time}_{tag}", Experiment: "synonyms"},
+			want:   []string{"synonyms_run_2024-01-14_08-00-00.000_v1"},
+		},
+		{
+			name:   "one tag",
+			layout: Layout{Folder: "{experiment}_run_{time}_{tag}", Tag: "v1"},
+			want: []string{
+				"boosts_run_2024-01-15_10-30-00.000_v1",
+				"synonyms_run_2024-01-14_08-00-00.000_v1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folders, err := tt.layout.ListRunFolders(baseDir)
+			if err != nil {
+				t.Fatalf("ListRunFolders() error = %v", err)
+			}
+			got := make([]string, len(folders))
+			for i, folder := range folders {
+				got[i] = filepath.Base(folder)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListRunFolders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLayoutCreateRunFolder(t *testing.T) {
+	layout := Layout{Folder: "{experiment}_run_{time}_{tag}", Tag: "v2", Experiment: "boosts"}
+	at := clock.Fixed(time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC))
+
+	got, err := layout.CreateRunFolder(t.TempDir(), at)
+	if err != nil {
+		t.Fatalf("CreateRunFolder() error = %v", err)
+	}
+	if want := "boosts_run_2024-01-15_10-30-00.000_v2"; filepath.Base(got) != want {
+		t.Errorf("CreateRunFolder() = %s, want %s", filepath.Base(got), want)
+	}
+	ts, err := layout.Timestamp(got)
+	if err != nil || !ts.Equal(time.Time(at)) {
+		t.Errorf("Timestamp() = %v, %v, want %v", ts, err, time.Time(at))
+	}
+}
+
+func TestSameFolder(t *testing.T) {
+	baseDir := t.TempDir()
+	run := filepath.Join(baseDir, "run_2024-01-15_10-30-00")
+	other := filepath.Join(baseDir, "run_2024-01-16_10-30-00")
+	link := filepath.Join(baseDir, "latest")
+	for _, dir := range []string{run, other} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Base(run), link); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{"same path", run, run, true},
+		{"relative parts", run, filepath.Join(other, "..", filepath.Base(run)), true},
+		{"symlink", link, run, true},
+		{"different folders", run, other, false},
+		{"missing folder", run, filepath.Join(baseDir, "missing"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SameFolder(tt.a, tt.b); got != tt.want {
+				t.Errorf("SameFolder(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLayoutFindPreviousResults(t *testing.T) {
+	baseDir := t.TempDir()
+	results := func(run string) string { return filepath.Join(baseDir, run, "results.json") }
+	for _, run := range []string{
+		"run_2024-01-13_10-00-00",
+		"run_2024-01-14_10-00-00",
+		"run_2024-01-15_10-00-00", // no results
+		"run_2024-01-16_10-00-00",
+	} {
+		if err := os.Mkdir(filepath.Join(baseDir, run), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if run != "run_2024-01-15_10-00-00" {
+			if err := os.WriteFile(results(run), []byte("[]"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	outside := filepath.Join(t.TempDir(), "results.json")
+
+	tests := []struct {
+		name    string
+		current string
+		want    string
+		wantErr bool
+	}{
+		{"latest", results("run_2024-01-16_10-00-00"), results("run_2024-01-14_10-00-00"), false},
+		{"middle", results("run_2024-01-14_10-00-00"), results("run_2024-01-13_10-00-00"), false},
+		{"unclean path", filepath.Join(baseDir, ".", "run_2024-01-14_10-00-00", "results.json"), results("run_2024-01-13_10-00-00"), false},
+		{"oldest", results("run_2024-01-13_10-00-00"), "", true},
+		{"unknown run", results("run_2024-01-17_10-00-00"), "", true},
+		{"outside base directory", outside, results("run_2024-01-16_10-00-00"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DefaultLayout.FindPreviousResults(baseDir, tt.current)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FindPreviousResults() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FindPreviousResults() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLayoutFindPreviousResults_RelativeBaseDir(t *testing.T) {
+	// Resolved so the working directory reads back as the same path
+	baseDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, run := range []string{"run_2024-01-14_10-00-00", "run_2024-01-16_10-00-00"} {
+		if err := os.Mkdir(filepath.Join(baseDir, run), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(baseDir, run, "results.json"), []byte("[]"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(baseDir)
+
+	// An absolute current path against a relative base directory
+	got, err := DefaultLayout.FindPreviousResults(".", filepath.Join(baseDir, "run_2024-01-16_10-00-00", "results.json"))
+	if err != nil {
+		t.Fatalf("FindPreviousResults() error = %v", err)
+	}
+	if want := filepath.Join("run_2024-01-14_10-00-00", "results.json"); got != want {
+		t.Errorf("FindPreviousResults() = %s, want %s", got, want)
+	}
+}
+
+func TestLayoutReportFiles(t *testing.T) {
+	runFolder := t.TempDir()
+	layout := Layout{ReportsDir: "comparison", Reports: ReportNames{Historical: "vs_baseline"}}
+
+	files := []string{"vs_baseline.txt", "vs_baseline.html", DefaultCrossQueryReport + ".md", "visibility.json"}
+	if err := os.MkdirAll(layout.ComparisonsFolder(runFolder), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range files {
+		if err := os.WriteFile(filepath.Join(layout.ReportsFolder(runFolder), name), []byte("report"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := layout.ReportFiles(runFolder)
+	if err != nil {
+		t.Fatalf("ReportFiles() error = %v", err)
+	}
+	reports := filepath.Join(runFolder, "comparison")
+	want := []string{
+		filepath.Join(reports, "vs_baseline.html"),
+		filepath.Join(reports, "vs_baseline.txt"),
+		filepath.Join(reports, DefaultCrossQueryReport+".md"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReportFiles() = %v, want %v", got, want)
+	}
+}
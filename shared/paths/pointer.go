@@ -0,0 +1,107 @@
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dis-search-test-bed/shared/lock"
+)
+
+// Pointers kept in the base directory so scripts can find a run without
+// listing folders
+const (
+	LatestPointer   = "latest"   // The run whose results were written last
+	BaselinePointer = "baseline" // The run promoted as the reference for comparisons
+)
+
+// ErrNoPointer is returned when a pointer has not been set
+var ErrNoPointer = errors.New("pointer not set")
+
+// IsPointer reports whether name is one of the pointer names
+func IsPointer(name string) bool {
+	return name == LatestPointer || name == BaselinePointer
+}
+
+// SetPointer points baseDir/name at a run folder. It is a relative symlink
+// where the file system allows one, otherwise a file holding the run folder
+// name. The pointer is replaced atomically, so readers never see it missing,
+// and updates hold the base directory's lock, waiting up to wait for another
+// process to finish its own.
+func SetPointer(baseDir, name, runFolder string, wait time.Duration) error {
+	target, err := filepath.Rel(baseDir, runFolder)
+	if err != nil {
+		if target, err = filepath.Abs(runFolder); err != nil {
+			return fmt.Errorf("resolve run folder: %w", err)
+		}
+	}
+
+	l, err := lock.AcquireDir(baseDir, wait)
+	if err != nil {
+		return fmt.Errorf("lock %s pointer: %w", name, err)
+	}
+	defer func() { _ = l.Release() }()
+
+	// The temporary name is unique, so the empty file can make way for a
+	// symlink without clashing with another update
+	f, err := os.CreateTemp(baseDir, "."+name+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("write %s pointer: %w", name, err)
+	}
+	tmp := f.Name()
+	_ = f.Close()
+	_ = os.Remove(tmp)
+
+	path := filepath.Join(baseDir, name)
+	if err := os.Symlink(target, tmp); err != nil {
+		if err := os.WriteFile(tmp, []byte(target+"\n"), 0644); err != nil {
+			return fmt.Errorf("write %s pointer: %w", name, err)
+		}
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("update %s pointer: %w", name, err)
+	}
+	return nil
+}
+
+// ResolvePointer returns the run folder baseDir/name points at
+func ResolvePointer(baseDir, name string) (string, error) {
+	path := filepath.Join(baseDir, name)
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("%s %w in %s", name, ErrNoPointer, baseDir)
+	}
+	if err != nil {
+		return "", fmt.Errorf("read %s pointer: %w", name, err)
+	}
+
+	var target string
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err = os.Readlink(path)
+		if err != nil {
+			return "", fmt.Errorf("read %s pointer: %w", name, err)
+		}
+	case info.IsDir():
+		return path, nil
+	default:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read %s pointer: %w", name, err)
+		}
+		target = strings.TrimSpace(string(data))
+	}
+
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(baseDir, target)
+	}
+	if info, err := os.Stat(target); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%s points at %s, which is not a run folder", name, target)
+	}
+	return target, nil
+}
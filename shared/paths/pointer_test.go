@@ -0,0 +1,89 @@
+package paths
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPointers(t *testing.T) {
+	baseDir := t.TempDir()
+	first := filepath.Join(baseDir, "run_2024-01-15_10-30-00.000")
+	second := filepath.Join(baseDir, "run_2024-01-16_10-30-00.000")
+	for _, folder := range []string{first, second} {
+		if err := os.Mkdir(folder, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(folder, "results.json"), []byte("[]"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := ResolvePointer(baseDir, BaselinePointer); !errors.Is(err, ErrNoPointer) {
+		t.Errorf("ResolvePointer() error = %v, want ErrNoPointer before a baseline is set", err)
+	}
+	got, err := DefaultLayout.ResolveResults(baseDir, LatestPointer)
+	if want := filepath.Join(second, "results.json"); err != nil || got != want {
+		t.Errorf("ResolveResults() = %s, %v, want the newest run %s before latest is set", got, err, want)
+	}
+
+	for _, folder := range []string{second, first} {
+		if err := SetPointer(baseDir, LatestPointer, folder, 0); err != nil {
+			t.Fatalf("SetPointer() error = %v", err)
+		}
+		got, err := ResolvePointer(baseDir, LatestPointer)
+		if err != nil {
+			t.Fatalf("ResolvePointer() error = %v", err)
+		}
+		if got != folder {
+			t.Errorf("ResolvePointer() = %s, want %s", got, folder)
+		}
+	}
+
+	got, err = DefaultLayout.ResolveResults(baseDir, LatestPointer)
+	if err != nil {
+		t.Fatalf("ResolveResults() error = %v", err)
+	}
+	if want := filepath.Join(first, "results.json"); got != want {
+		t.Errorf("ResolveResults() = %s, want %s", got, want)
+	}
+
+	got, err = DefaultLayout.ResolveResults(baseDir, PreviousRun)
+	if want := filepath.Join(first, "results.json"); err != nil || got != want {
+		t.Errorf("ResolveResults(previous) = %s, %v, want %s", got, err, want)
+	}
+
+	// Pointers are not runs
+	folders, err := DefaultLayout.ListRunFolders(baseDir)
+	if err != nil || len(folders) != 2 {
+		t.Errorf("ListRunFolders() = %v, %v, want the 2 run folders", folders, err)
+	}
+}
+
+func TestResolvePointer_File(t *testing.T) {
+	baseDir := t.TempDir()
+	folder := filepath.Join(baseDir, "run_2024-01-15_10-30-00.000")
+	if err := os.Mkdir(folder, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Where symlinks aren't available the pointer is a file naming the run
+	if err := os.WriteFile(filepath.Join(baseDir, BaselinePointer), []byte(filepath.Base(folder)+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ResolvePointer(baseDir, BaselinePointer)
+	if err != nil {
+		t.Fatalf("ResolvePointer() error = %v", err)
+	}
+	if got != folder {
+		t.Errorf("ResolvePointer() = %s, want %s", got, folder)
+	}
+
+	if err := os.RemoveAll(folder); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ResolvePointer(baseDir, BaselinePointer); err == nil {
+		t.Error("ResolvePointer() accepted a pointer to a deleted run")
+	}
+}
@@ -0,0 +1,44 @@
+//go:build unix
+
+package paths
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/shared/lock"
+)
+
+func TestSetPointer_Locked(t *testing.T) {
+	baseDir := t.TempDir()
+	folder := filepath.Join(baseDir, "run_2024-01-15_10-30-00.000")
+	if err := os.Mkdir(folder, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	held, err := lock.AcquireDir(baseDir, 0)
+	if err != nil {
+		t.Fatalf("AcquireDir() error = %v", err)
+	}
+	if err := SetPointer(baseDir, LatestPointer, folder, 0); !errors.Is(err, lock.ErrLocked) {
+		t.Errorf("SetPointer() with the base directory locked error = %v, want ErrLocked", err)
+	}
+	if _, err := os.Lstat(filepath.Join(baseDir, LatestPointer)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("pointer written while the base directory was locked: %v", err)
+	}
+
+	if err := held.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if err := SetPointer(baseDir, LatestPointer, folder, 0); err != nil {
+		t.Fatalf("SetPointer() after release error = %v", err)
+	}
+
+	// No temporary files are left behind
+	matches, _ := filepath.Glob(filepath.Join(baseDir, ".*.tmp"))
+	if len(matches) > 0 {
+		t.Errorf("temporary files left in base directory: %v", matches)
+	}
+}
@@ -0,0 +1,387 @@
+// Package preflight flags queries that are likely to be expensive before they
+// run on a shared cluster. Each query is checked against heuristics for
+// costly constructs (wildcards, huge boolean queries, scripts, deep paging)
+// and, when a validator is available, with Elasticsearch's _validate/query
+// API, which also shows how far multi-term queries expand once rewritten.
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// Issue severities
+const (
+	SeverityWarning = "warning" // Likely to be slow or heavy on the cluster
+	SeverityError   = "error"   // Elasticsearch will reject the query
+)
+
+// Rules an issue can come from
+const (
+	RuleInvalid         = "invalid"
+	RuleWildcard        = "wildcard"
+	RuleLeadingWildcard = "leading_wildcard"
+	RuleShortPrefix     = "short_prefix"
+	RuleRegexp          = "regexp"
+	RuleFuzzy           = "fuzzy"
+	RuleHugeBool        = "huge_bool"
+	RuleHugeTerms       = "huge_terms"
+	RuleScript          = "script"
+	RuleLargeSize       = "large_size"
+	RuleDeepPaging      = "deep_paging"
+	RuleExpansion       = "expansion"
+)
+
+// Limits are the sizes beyond which a query is flagged
+type Limits struct {
+	MaxBoolClauses      int // Clauses in one bool query
+	MaxTerms            int // Values in one terms query
+	MaxSize             int // Results requested
+	MaxResultWindow     int // from + size; Elasticsearch rejects more by default
+	MaxRewrittenClauses int // Term clauses after rewriting; Lucene's default limit is 1024
+	MinPrefixLength     int // Shorter prefixes match too many terms
+}
+
+// DefaultLimits returns the limits used when none are configured
+func DefaultLimits() Limits {
+	return Limits{
+		MaxBoolClauses:      100,
+		MaxTerms:            500,
+		MaxSize:             1000,
+		MaxResultWindow:     10000,
+		MaxRewrittenClauses: 1024,
+		MinPrefixLength:     3,
+	}
+}
+
+// Issue is one reason a query may be expensive or rejected
+type Issue struct {
+	Severity string `json:"severity"`
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+}
+
+// QueryCheck is the outcome of checking one query
+type QueryCheck struct {
+	Query     string  `json:"query"`
+	QueryID   string  `json:"query_id,omitempty"`
+	Algorithm string  `json:"algorithm"`
+	Issues    []Issue `json:"issues,omitempty"`
+
+	// RewrittenClauses counts the term clauses in the query Elasticsearch
+	// would run, once validated. It is 0 when the query wasn't validated.
+	RewrittenClauses int `json:"rewritten_clauses,omitempty"`
+}
+
+// Has reports whether the check found an issue of the given severity
+func (q QueryCheck) Has(severity string) bool {
+	for _, issue := range q.Issues {
+		if issue.Severity == severity {
+			return true
+		}
+	}
+	return false
+}
+
+// Report is the outcome of checking a query suite
+type Report struct {
+	Index     string       `json:"index,omitempty"`
+	Validated bool         `json:"validated"` // Whether Elasticsearch validated the queries
+	Queries   []QueryCheck `json:"queries"`
+	Warnings  int          `json:"warnings"` // Queries with at least one warning
+	Errors    int          `json:"errors"`   // Queries Elasticsearch would reject
+}
+
+// Validator validates queries without running them
+type Validator interface {
+	ValidateQuery(ctx context.Context, index string, query map[string]interface{}) (*elasticsearch.Validation, error)
+}
+
+// Checker checks queries before they run
+type Checker struct {
+	validator Validator
+	index     string
+	limits    Limits
+}
+
+// NewChecker creates a checker. With a nil validator only the heuristics
+// run, so no cluster is needed.
+func NewChecker(validator Validator, index string, limits Limits) *Checker {
+	return &Checker{validator: validator, index: index, limits: limits}
+}
+
+// Run checks every query of every algorithm
+func (c *Checker) Run(ctx context.Context, algorithms []models.AlgorithmConfig) (Report, error) {
+	report := Report{Index: c.index, Validated: c.validator != nil}
+
+	for _, alg := range algorithms {
+		for _, qc := range alg.Queries {
+			check, err := c.Check(ctx, alg, qc)
+			if err != nil {
+				return report, fmt.Errorf("check %q (%s): %w", qc.Query, alg.Name, err)
+			}
+
+			if check.Has(SeverityError) {
+				report.Errors++
+			}
+			if check.Has(SeverityWarning) {
+				report.Warnings++
+			}
+			report.Queries = append(report.Queries, check)
+		}
+	}
+
+	return report, nil
+}
+
+// Check checks one query. Stored scoring scripts aren't uploaded by a
+// pre-flight, so a scripted algorithm's queries are validated unwrapped and
+// flagged for the script.
+func (c *Checker) Check(ctx context.Context, alg models.AlgorithmConfig, qc models.QueryConfig) (QueryCheck, error) {
+	check := QueryCheck{Query: qc.Query, QueryID: qc.ID, Algorithm: alg.Name}
+	check.Issues = Analyse(qc.ESQuery, c.limits)
+	if alg.Script != nil {
+		check.Issues = append(check.Issues, Issue{
+			Severity: SeverityWarning,
+			Rule:     RuleScript,
+			Message:  fmt.Sprintf("stored script %s scores every matching document", alg.Script.ID),
+		})
+	}
+
+	if c.validator == nil {
+		return check, nil
+	}
+
+	validation, err := c.validator.ValidateQuery(ctx, c.index, qc.ESQuery)
+	if err != nil {
+		return check, err
+	}
+	if !validation.Valid {
+		message := validation.Error
+		if message == "" {
+			message = "Elasticsearch rejected the query"
+		}
+		check.Issues = append(check.Issues, Issue{Severity: SeverityError, Rule: RuleInvalid, Message: message})
+		return check, nil
+	}
+
+	for _, explanation := range validation.Explanations {
+		check.RewrittenClauses = max(check.RewrittenClauses, countClauses(explanation))
+	}
+	if c.limits.MaxRewrittenClauses > 0 && check.RewrittenClauses > c.limits.MaxRewrittenClauses {
+		check.Issues = append(check.Issues, Issue{
+			Severity: SeverityWarning,
+			Rule:     RuleExpansion,
+			Message: fmt.Sprintf("rewrites to %d term clauses (limit %d)",
+				check.RewrittenClauses, c.limits.MaxRewrittenClauses),
+		})
+	}
+
+	return check, nil
+}
+
+// clausePattern matches a field:term clause in a Lucene query explanation
+var clausePattern = regexp.MustCompile(`[\w.]+:[^\s()]+`)
+
+// countClauses counts the field:term clauses in a Lucene query explanation
+func countClauses(explanation string) int {
+	return len(clausePattern.FindAllString(explanation, -1))
+}
+
+// Analyse flags costly constructs in a search body without a cluster
+func Analyse(body map[string]interface{}, limits Limits) []Issue {
+	a := analyser{limits: limits}
+
+	size, hasSize := number(body["size"])
+	from, _ := number(body["from"])
+	if limits.MaxSize > 0 && hasSize && size > limits.MaxSize {
+		a.warn(RuleLargeSize, "requests %d results (max %d)", size, limits.MaxSize)
+	}
+	if limits.MaxResultWindow > 0 && from+size > limits.MaxResultWindow {
+		a.issues = append(a.issues, Issue{
+			Severity: SeverityError,
+			Rule:     RuleDeepPaging,
+			Message:  fmt.Sprintf("from + size is %d, beyond the result window of %d", from+size, limits.MaxResultWindow),
+		})
+	}
+
+	a.walk(body["query"])
+	return a.issues
+}
+
+type analyser struct {
+	limits Limits
+	issues []Issue
+}
+
+func (a *analyser) warn(rule, format string, args ...interface{}) {
+	a.issues = append(a.issues, Issue{Severity: SeverityWarning, Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+// walk visits every clause of a query, flagging the costly ones
+func (a *analyser) walk(node interface{}) {
+	switch v := node.(type) {
+	case []interface{}:
+		for _, child := range v {
+			a.walk(child)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			a.clause(key, v[key])
+			a.walk(v[key])
+		}
+	}
+}
+
+// clause flags a single named query clause if its type is a costly one
+func (a *analyser) clause(name string, body interface{}) {
+	switch name {
+	case "wildcard":
+		a.wildcard(body)
+	case "regexp":
+		a.regexp(body)
+	case "prefix":
+		a.prefix(body)
+	case "fuzzy":
+		a.fuzzy(body)
+	case "query_string", "simple_query_string":
+		a.queryString(name, body)
+	case "bool":
+		a.boolQuery(body)
+	case "terms":
+		a.terms(body)
+	case "script_score":
+		a.warn(RuleScript, "script_score runs a script for every matching document")
+	}
+}
+
+func (a *analyser) wildcard(body interface{}) {
+	for field, pattern := range fieldValues(body, "value", "wildcard") {
+		if strings.HasPrefix(pattern, "*") || strings.HasPrefix(pattern, "?") {
+			a.warn(RuleLeadingWildcard, "leading wildcard %q on %s scans every term in the field", pattern, field)
+		} else {
+			a.warn(RuleWildcard, "wildcard %q on %s expands to every matching term", pattern, field)
+		}
+	}
+}
+
+func (a *analyser) regexp(body interface{}) {
+	for field, pattern := range fieldValues(body, "value") {
+		if strings.HasPrefix(pattern, ".") {
+			a.warn(RuleLeadingWildcard, "regexp %q on %s starts with a wildcard and scans every term", pattern, field)
+		} else {
+			a.warn(RuleRegexp, "regexp %q on %s expands to every matching term", pattern, field)
+		}
+	}
+}
+
+func (a *analyser) prefix(body interface{}) {
+	for field, prefix := range fieldValues(body, "value") {
+		if len([]rune(prefix)) < a.limits.MinPrefixLength {
+			a.warn(RuleShortPrefix, "prefix %q on %s is shorter than %d characters", prefix, field, a.limits.MinPrefixLength)
+		}
+	}
+}
+
+func (a *analyser) fuzzy(body interface{}) {
+	for field, term := range fieldValues(body, "value") {
+		a.warn(RuleFuzzy, "fuzzy query %q on %s expands to every term within the edit distance", term, field)
+	}
+}
+
+// queryString flags wildcard terms in the query syntax of a query_string
+// or simple_query_string clause
+func (a *analyser) queryString(name string, body interface{}) {
+	q, ok := body.(map[string]interface{})
+	if !ok {
+		return
+	}
+	text, _ := q["query"].(string)
+	for _, token := range strings.Fields(text) {
+		token = strings.TrimLeft(token, "+-(\"")
+		if strings.HasPrefix(token, "*") || strings.HasPrefix(token, "?") {
+			a.warn(RuleLeadingWildcard, "%s term %q starts with a wildcard and scans every term", name, token)
+		} else if strings.ContainsAny(token, "*?") {
+			a.warn(RuleWildcard, "%s term %q expands to every matching term", name, token)
+		}
+	}
+}
+
+// boolQuery flags a bool query with more direct clauses than the limit
+func (a *analyser) boolQuery(body interface{}) {
+	b, ok := body.(map[string]interface{})
+	if !ok {
+		return
+	}
+	clauses := 0
+	for _, occur := range []string{"must", "should", "filter", "must_not"} {
+		switch c := b[occur].(type) {
+		case []interface{}:
+			clauses += len(c)
+		case map[string]interface{}:
+			clauses++
+		}
+	}
+	if a.limits.MaxBoolClauses > 0 && clauses > a.limits.MaxBoolClauses {
+		a.warn(RuleHugeBool, "bool query has %d clauses (max %d)", clauses, a.limits.MaxBoolClauses)
+	}
+}
+
+// terms flags a terms query with more values than the limit
+func (a *analyser) terms(body interface{}) {
+	t, ok := body.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for field, values := range t {
+		if list, ok := values.([]interface{}); ok && a.limits.MaxTerms > 0 && len(list) > a.limits.MaxTerms {
+			a.warn(RuleHugeTerms, "terms query on %s has %d values (max %d)", field, len(list), a.limits.MaxTerms)
+		}
+	}
+}
+
+// fieldValues reads the field → value pairs of a term-level query, which
+// take either {"field": "value"} or {"field": {"value": "..."}}
+func fieldValues(body interface{}, keys ...string) map[string]string {
+	values := make(map[string]string)
+	fields, ok := body.(map[string]interface{})
+	if !ok {
+		return values
+	}
+	for field, v := range fields {
+		switch val := v.(type) {
+		case string:
+			values[field] = val
+		case map[string]interface{}:
+			for _, key := range keys {
+				if s, ok := val[key].(string); ok {
+					values[field] = s
+					break
+				}
+			}
+		}
+	}
+	return values
+}
+
+// number reads a JSON number, which decodes as float64
+func number(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int(n), true
+	case int:
+		return n, true
+	}
+	return 0, false
+}
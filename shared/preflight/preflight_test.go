@@ -0,0 +1,146 @@
+package preflight
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func body(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatalf("bad test body: %v", err)
+	}
+	return m
+}
+
+func rules(issues []Issue) []string {
+	out := make([]string, len(issues))
+	for i, issue := range issues {
+		out[i] = issue.Rule
+	}
+	return out
+}
+
+func TestAnalyse(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "plain match",
+			body: `{"query":{"match":{"title":"inflation"}}}`,
+		},
+		{
+			name: "leading wildcard",
+			body: `{"query":{"wildcard":{"title":{"value":"*flation"}}}}`,
+			want: []string{RuleLeadingWildcard},
+		},
+		{
+			name: "trailing wildcard",
+			body: `{"query":{"wildcard":{"title":"infl*"}}}`,
+			want: []string{RuleWildcard},
+		},
+		{
+			name: "query string wildcards",
+			body: `{"query":{"query_string":{"query":"+*price infla*"}}}`,
+			want: []string{RuleLeadingWildcard, RuleWildcard},
+		},
+		{
+			name: "short prefix and fuzzy",
+			body: `{"query":{"bool":{"should":[{"prefix":{"title":"in"}},{"fuzzy":{"title":"inflaton"}}]}}}`,
+			want: []string{RuleShortPrefix, RuleFuzzy},
+		},
+		{
+			name: "regexp",
+			body: `{"query":{"regexp":{"title":".*price"}}}`,
+			want: []string{RuleLeadingWildcard},
+		},
+		{
+			name: "script score",
+			body: `{"query":{"script_score":{"query":{"match_all":{}},"script":{"source":"_score"}}}}`,
+			want: []string{RuleScript},
+		},
+		{
+			name: "deep paging",
+			body: `{"from":9990,"size":20,"query":{"match_all":{}}}`,
+			want: []string{RuleDeepPaging},
+		},
+		{
+			name: "large size",
+			body: `{"size":5000,"query":{"match_all":{}}}`,
+			want: []string{RuleLargeSize},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rules(Analyse(body(t, tt.body), DefaultLimits()))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Analyse() rules = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Analyse() rules = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyse_HugeClauses(t *testing.T) {
+	limits := Limits{MaxBoolClauses: 2, MaxTerms: 3}
+	got := rules(Analyse(body(t, `{"query":{"bool":{
+		"should":[{"term":{"a":"1"}},{"term":{"a":"2"}}],
+		"filter":{"terms":{"id":["1","2","3","4"]}}
+	}}}`), limits))
+
+	if len(got) != 2 || got[0] != RuleHugeBool || got[1] != RuleHugeTerms {
+		t.Errorf("Analyse() rules = %v, want huge_bool then huge_terms", got)
+	}
+}
+
+type fakeValidator map[string]*elasticsearch.Validation
+
+func (f fakeValidator) ValidateQuery(_ context.Context, _ string, query map[string]interface{}) (*elasticsearch.Validation, error) {
+	match := query["query"].(map[string]interface{})["match"].(map[string]interface{})
+	return f[match["title"].(string)], nil
+}
+
+func TestChecker_Run(t *testing.T) {
+	query := func(text string) models.QueryConfig {
+		return models.QueryConfig{Query: text, ESQuery: map[string]interface{}{
+			"query": map[string]interface{}{"match": map[string]interface{}{"title": text}},
+		}}
+	}
+	validator := fakeValidator{
+		"gdp":       {Valid: true, Explanations: []string{"title:gdp"}},
+		"broken":    {Valid: false, Error: "failed to create query"},
+		"expensive": {Valid: true, Explanations: []string{"title:a title:b title:c"}},
+	}
+	algorithms := []models.AlgorithmConfig{
+		{Name: "bm25", Queries: []models.QueryConfig{query("gdp"), query("broken"), query("expensive")}},
+	}
+
+	limits := DefaultLimits()
+	limits.MaxRewrittenClauses = 2
+	report, err := NewChecker(validator, "test", limits).Run(context.Background(), algorithms)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if !report.Validated || report.Errors != 1 || report.Warnings != 1 {
+		t.Fatalf("report = %+v, want 1 error and 1 warning", report)
+	}
+	if got := rules(report.Queries[1].Issues); len(got) != 1 || got[0] != RuleInvalid {
+		t.Errorf("broken query rules = %v, want invalid", got)
+	}
+	if q := report.Queries[2]; q.RewrittenClauses != 3 || !q.Has(SeverityWarning) {
+		t.Errorf("expensive query = %+v, want 3 clauses and a warning", q)
+	}
+}
@@ -0,0 +1,156 @@
+package preview
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// DefaultLength is the preview length in characters when none is configured
+const DefaultLength = 200
+
+// TermHit is the number of times a query term occurs in a document body
+type TermHit struct {
+	Term  string `json:"term"`
+	Count int    `json:"count"`
+}
+
+// Preview is a truncated document body with query-term hit counts
+type Preview struct {
+	Snippet   string    `json:"snippet"`
+	Hits      []TermHit `json:"hits"`
+	TotalHits int       `json:"total_hits"`
+}
+
+// Previewer builds previews from the documents of a stored index
+type Previewer struct {
+	bodies map[string]string
+	length int
+}
+
+// NewPreviewer indexes the stored documents by URI. Snippets are cut to
+// length characters, or DefaultLength if length is not positive.
+func NewPreviewer(index *models.StoredIndex, length int) *Previewer {
+	if length <= 0 {
+		length = DefaultLength
+	}
+
+	p := &Previewer{
+		bodies: make(map[string]string),
+		length: length,
+	}
+	if index != nil {
+		for _, doc := range index.Documents {
+			p.bodies[doc.URI] = doc.Body
+		}
+	}
+
+	return p
+}
+
+// Preview returns the preview of the document at uri for the given query.
+// It returns false if the document isn't in the stored index.
+func (p *Previewer) Preview(uri, query string) (Preview, bool) {
+	body, ok := p.bodies[uri]
+	if !ok {
+		return Preview{}, false
+	}
+	body = strings.Join(strings.Fields(body), " ")
+
+	terms := Terms(query)
+	counts := make(map[string]int, len(terms))
+	for _, term := range terms {
+		counts[term] = 0
+	}
+
+	firstHit := -1
+	for _, w := range tokenize(body) {
+		if _, isTerm := counts[w.text]; !isTerm {
+			continue
+		}
+		counts[w.text]++
+		if firstHit < 0 {
+			firstHit = w.start
+		}
+	}
+
+	preview := Preview{Snippet: snippet(body, firstHit, p.length)}
+	for _, term := range terms {
+		preview.Hits = append(preview.Hits, TermHit{Term: term, Count: counts[term]})
+		preview.TotalHits += counts[term]
+	}
+
+	return preview, true
+}
+
+// Terms splits a query into its distinct lower-case words, in query order
+func Terms(query string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, w := range tokenize(query) {
+		if !seen[w.text] {
+			seen[w.text] = true
+			terms = append(terms, w.text)
+		}
+	}
+	return terms
+}
+
+type word struct {
+	text  string
+	start int // Byte offset in the source text
+}
+
+func tokenize(text string) []word {
+	var words []word
+	start := -1
+
+	for i, r := range text {
+		isWordRune := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWordRune && start < 0:
+			start = i
+		case !isWordRune && start >= 0:
+			words = append(words, word{text: strings.ToLower(text[start:i]), start: start})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, word{text: strings.ToLower(text[start:]), start: start})
+	}
+
+	return words
+}
+
+// snippet cuts body to about length characters, starting shortly before the
+// first hit (a byte offset) if that would otherwise fall outside the snippet
+func snippet(body string, firstHit, length int) string {
+	runes := []rune(body)
+	if len(runes) <= length {
+		return body
+	}
+
+	start := 0
+	if firstHit > 0 {
+		if hitRune := utf8.RuneCountInString(body[:firstHit]); hitRune > length/2 {
+			start = hitRune - length/4
+		}
+	}
+
+	end := start + length
+	if end > len(runes) {
+		end = len(runes)
+		start = end - length
+	}
+
+	out := strings.TrimSpace(string(runes[start:end]))
+	if start > 0 {
+		out = "…" + out
+	}
+	if end < len(runes) {
+		out += "…"
+	}
+	return out
+}
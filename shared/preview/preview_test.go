@@ -0,0 +1,44 @@
+package preview
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestPreviewer_Preview(t *testing.T) {
+	index := &models.StoredIndex{
+		Documents: []models.Document{
+			{URI: "/short", Body: "Consumer price inflation rose.  Inflation\nis measured by CPI."},
+			{URI: "/long", Body: strings.Repeat("filler ", 40) + "inflation figures" + strings.Repeat(" more", 40)},
+		},
+	}
+	p := NewPreviewer(index, 80)
+
+	got, ok := p.Preview("/short", "Inflation rate inflation")
+	if !ok {
+		t.Fatal("expected preview for stored document")
+	}
+	if got.Snippet != "Consumer price inflation rose. Inflation is measured by CPI." {
+		t.Errorf("unexpected snippet %q", got.Snippet)
+	}
+	if len(got.Hits) != 2 || got.Hits[0] != (TermHit{"inflation", 2}) || got.Hits[1] != (TermHit{"rate", 0}) {
+		t.Errorf("unexpected hits %+v", got.Hits)
+	}
+	if got.TotalHits != 2 {
+		t.Errorf("total hits = %d, want 2", got.TotalHits)
+	}
+
+	got, _ = p.Preview("/long", "inflation")
+	if !strings.HasPrefix(got.Snippet, "…") || !strings.HasSuffix(got.Snippet, "…") {
+		t.Errorf("expected snippet cut on both sides, got %q", got.Snippet)
+	}
+	if !strings.Contains(got.Snippet, "inflation") {
+		t.Errorf("expected snippet to include the first hit, got %q", got.Snippet)
+	}
+
+	if _, ok := p.Preview("/missing", "inflation"); ok {
+		t.Error("expected no preview for a document outside the index")
+	}
+}
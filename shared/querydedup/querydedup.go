@@ -0,0 +1,226 @@
+// Package querydedup finds queries a suite runs more than once under
+// trivially different text, which add run time without adding coverage.
+package querydedup
+
+import (
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// Kinds of duplicate
+const (
+	KindRepeated  = "repeated"  // One algorithm runs near-identical query texts
+	KindIdentical = "identical" // Algorithms send the same request for a query
+)
+
+// stopWords are dropped when normalising, as they rarely change results
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "for": true, "in": true,
+	"of": true, "on": true, "the": true, "to": true,
+}
+
+// Normalise reduces a query to the words it searches for: lowercased,
+// without punctuation or stop words, with plurals made singular and in
+// sorted order, so "The CPI rates" and "rate of cpi" normalise alike
+func Normalise(text string) string {
+	words := strings.Split(models.Slugify(text), "-")
+	kept := words[:0]
+	for _, w := range words {
+		if w == "" || stopWords[w] {
+			continue
+		}
+		if len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") {
+			w = strings.TrimSuffix(w, "s")
+		}
+		kept = append(kept, w)
+	}
+	sort.Strings(kept)
+	return strings.Join(kept, " ")
+}
+
+// groupKey is the normalised text a query is grouped by; a query of stop
+// words alone is only grouped with its exact repeats
+func groupKey(text string) string {
+	if key := Normalise(text); key != "" {
+		return key
+	}
+	return text
+}
+
+// Entry is one query of a duplicate group
+type Entry struct {
+	Algorithm string `json:"algorithm"`
+	QueryID   string `json:"query_id"`
+	Query     string `json:"query"`
+}
+
+// Group is a set of queries that duplicate each other, in suite order
+type Group struct {
+	Kind       string  `json:"kind"`
+	Normalised string  `json:"normalised"`
+	Entries    []Entry `json:"entries"`
+}
+
+// Find reports the suite's duplicates: queries of one algorithm that
+// normalise alike, then queries that several algorithms send identically
+func Find(algorithms []models.AlgorithmConfig) []Group {
+	return append(repeated(algorithms), identical(algorithms)...)
+}
+
+// repeated groups the queries of each algorithm that normalise alike and
+// send the same body apart from their text
+func repeated(algorithms []models.AlgorithmConfig) []Group {
+	var groups []Group
+	for _, alg := range algorithms {
+		var algGroups []Group
+		var firsts []models.QueryConfig
+		for _, qc := range alg.Queries {
+			entry := Entry{Algorithm: alg.Name, QueryID: qc.ID, Query: qc.Query}
+			if i := matchRepeat(firsts, qc); i >= 0 {
+				algGroups[i].Entries = append(algGroups[i].Entries, entry)
+				continue
+			}
+			firsts = append(firsts, qc)
+			algGroups = append(algGroups, Group{Kind: KindRepeated, Normalised: groupKey(qc.Query), Entries: []Entry{entry}})
+		}
+		groups = append(groups, multiples(algGroups)...)
+	}
+	return groups
+}
+
+// matchRepeat returns the index of the query qc repeats, or -1
+func matchRepeat(firsts []models.QueryConfig, qc models.QueryConfig) int {
+	key := groupKey(qc.Query)
+	for i, first := range firsts {
+		if groupKey(first.Query) == key &&
+			reflect.DeepEqual(mask(first.ESQuery, first.Query), mask(qc.ESQuery, qc.Query)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// placeholder stands in for the query text when comparing bodies
+const placeholder = "{{query}}"
+
+// mask copies a body with the query text replaced by a placeholder, so
+// bodies differing only in their text compare equal
+func mask(node interface{}, text string) interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			out[key] = mask(child, text)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			out[i] = mask(child, text)
+		}
+		return out
+	case string:
+		if text != "" && strings.Contains(v, text) {
+			return strings.ReplaceAll(v, text, placeholder)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// identical groups queries that different algorithms send with the same
+// text and request body, which compares an algorithm with itself
+func identical(algorithms []models.AlgorithmConfig) []Group {
+	type sent struct {
+		alg   models.AlgorithmConfig
+		query models.QueryConfig
+	}
+	byText := make(map[string][]sent)
+	var order []string
+	for _, alg := range algorithms {
+		for _, qc := range alg.Queries {
+			if _, ok := byText[qc.Query]; !ok {
+				order = append(order, qc.Query)
+			}
+			byText[qc.Query] = append(byText[qc.Query], sent{alg: alg, query: qc})
+		}
+	}
+
+	var groups []Group
+	for _, text := range order {
+		var textGroups []Group
+		var firsts []sent
+		for _, s := range byText[text] {
+			entry := Entry{Algorithm: s.alg.Name, QueryID: s.query.ID, Query: s.query.Query}
+			matched := false
+			for i, first := range firsts {
+				if first.alg.Name != s.alg.Name && sameRequest(first.alg, first.query, s.alg, s.query) {
+					textGroups[i].Entries = append(textGroups[i].Entries, entry)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				firsts = append(firsts, s)
+				textGroups = append(textGroups, Group{Kind: KindIdentical, Normalised: Normalise(text), Entries: []Entry{entry}})
+			}
+		}
+		groups = append(groups, multiples(textGroups)...)
+	}
+	return groups
+}
+
+// sameRequest reports whether two algorithms run a query identically
+func sameRequest(algA models.AlgorithmConfig, a models.QueryConfig, algB models.AlgorithmConfig, b models.QueryConfig) bool {
+	return reflect.DeepEqual(a.ESQuery, b.ESQuery) &&
+		reflect.DeepEqual(algA.Script, algB.Script) &&
+		reflect.DeepEqual(algA.Federation, algB.Federation)
+}
+
+// multiples keeps the groups of more than one query
+func multiples(groups []Group) []Group {
+	var kept []Group
+	for _, g := range groups {
+		if len(g.Entries) > 1 {
+			kept = append(kept, g)
+		}
+	}
+	return kept
+}
+
+// Merge drops the repeated queries of each algorithm, keeping the first of
+// each group. The dropped texts and IDs become aliases of the kept query so
+// results from earlier runs still pair with it. It returns the merged
+// suite and how many queries were dropped; identical requests across
+// algorithms are left alone, as each algorithm's results are still wanted.
+func Merge(algorithms []models.AlgorithmConfig) ([]models.AlgorithmConfig, int) {
+	merged := make([]models.AlgorithmConfig, len(algorithms))
+	dropped := 0
+	for a, alg := range algorithms {
+		kept := make([]models.QueryConfig, 0, len(alg.Queries))
+		for _, qc := range alg.Queries {
+			i := matchRepeat(kept, qc)
+			if i < 0 {
+				kept = append(kept, qc)
+				continue
+			}
+			first := &kept[i]
+			aliases := slices.Clone(first.Aliases)
+			for _, alias := range append([]string{qc.ID, qc.Query}, qc.Aliases...) {
+				if alias != first.ID && alias != first.Query && !slices.Contains(aliases, alias) {
+					aliases = append(aliases, alias)
+				}
+			}
+			first.Aliases = aliases
+			dropped++
+		}
+		alg.Queries = kept
+		merged[a] = alg
+	}
+	return merged, dropped
+}
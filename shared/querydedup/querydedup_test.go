@@ -0,0 +1,78 @@
+package querydedup
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func match(text string) map[string]interface{} {
+	return map[string]interface{}{"query": map[string]interface{}{"match": map[string]interface{}{"body": text}}}
+}
+
+func filtered(text, contentType string) map[string]interface{} {
+	return map[string]interface{}{"query": map[string]interface{}{"bool": map[string]interface{}{
+		"must":   []interface{}{map[string]interface{}{"match": map[string]interface{}{"body": text}}},
+		"filter": []interface{}{map[string]interface{}{"term": map[string]interface{}{"type": contentType}}},
+	}}}
+}
+
+func TestNormalise(t *testing.T) {
+	tests := map[string]string{
+		"The CPI rates":       "cpi rate",
+		"rate of CPI":         "cpi rate",
+		"  cost-of-living!  ": "cost living",
+		"business":            "business",
+		"the":                 "",
+	}
+	for text, want := range tests {
+		if got := Normalise(text); got != want {
+			t.Errorf("Normalise(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	algorithms := []models.AlgorithmConfig{
+		{Name: "bm25", Queries: []models.QueryConfig{
+			{ID: "cpi", Query: "CPI rates", ESQuery: match("CPI rates")},
+			{ID: "gdp", Query: "gdp", ESQuery: match("gdp")},
+			{ID: "cpi-2", Query: "rate of cpi", ESQuery: match("rate of cpi")},
+			{ID: "golang-articles", Query: "golang", ESQuery: filtered("golang", "article")},
+			{ID: "golang-tutorials", Query: "golang", ESQuery: filtered("golang", "tutorial")},
+		}},
+		{Name: "bm25_copy", Queries: []models.QueryConfig{
+			{ID: "gdp", Query: "gdp", ESQuery: match("gdp")},
+		}},
+		{Name: "boosted", Script: &models.ScriptConfig{ID: "rerank"}, Queries: []models.QueryConfig{
+			{ID: "gdp", Query: "gdp", ESQuery: match("gdp")},
+		}},
+	}
+
+	got := Find(algorithms)
+	want := []Group{
+		{Kind: KindRepeated, Normalised: "cpi rate", Entries: []Entry{
+			{Algorithm: "bm25", QueryID: "cpi", Query: "CPI rates"},
+			{Algorithm: "bm25", QueryID: "cpi-2", Query: "rate of cpi"},
+		}},
+		{Kind: KindIdentical, Normalised: "gdp", Entries: []Entry{
+			{Algorithm: "bm25", QueryID: "gdp", Query: "gdp"},
+			{Algorithm: "bm25_copy", QueryID: "gdp", Query: "gdp"},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Find() = %+v, want %+v", got, want)
+	}
+
+	merged, dropped := Merge(algorithms)
+	if dropped != 1 || len(merged[0].Queries) != 4 {
+		t.Fatalf("Merge() dropped %d, left %d bm25 queries, want 1 and 4", dropped, len(merged[0].Queries))
+	}
+	if want := []string{"cpi-2", "rate of cpi"}; !reflect.DeepEqual(merged[0].Queries[0].Aliases, want) {
+		t.Errorf("merged query aliases = %v, want %v", merged[0].Queries[0].Aliases, want)
+	}
+	if len(algorithms[0].Queries) != 5 || algorithms[0].Queries[0].Aliases != nil {
+		t.Error("Merge() changed the suite it was given")
+	}
+}
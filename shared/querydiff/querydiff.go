@@ -0,0 +1,271 @@
+// Package querydiff compares two query configuration files semantically:
+// which algorithms and queries were added, removed or modified, and where
+// each Elasticsearch query body changed, ignoring formatting and key order.
+package querydiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// Kind says how an algorithm, query or value changed
+type Kind string
+
+// Change kinds
+const (
+	Added    Kind = "added"
+	Removed  Kind = "removed"
+	Modified Kind = "modified"
+)
+
+// ValueChange is one changed value within a structure, addressed by a path
+// such as "es_query.query.multi_match.fields[0]"
+type ValueChange struct {
+	Path string `json:"path"`
+	Kind Kind   `json:"kind"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// QueryChange is an added, removed or modified query
+type QueryChange struct {
+	ID        string        `json:"id"`
+	Query     string        `json:"query"`
+	Kind      Kind          `json:"kind"`
+	RenamedTo string        `json:"renamed_to,omitempty"` // New ID when paired through an alias
+	Fields    []ValueChange `json:"fields,omitempty"`     // Changes to the query's text, description, aliases or volatility
+	ESQuery   []ValueChange `json:"es_query,omitempty"`   // Changes to the Elasticsearch query body
+}
+
+// AlgorithmChange is an added, removed or modified algorithm
+type AlgorithmChange struct {
+	Name     string        `json:"name"`
+	Kind     Kind          `json:"kind"`
+	Settings []ValueChange `json:"settings,omitempty"` // Changes to the description, script or boost
+	Queries  []QueryChange `json:"queries,omitempty"`
+}
+
+// Report lists every changed algorithm, in the new file's order followed
+// by removed algorithms
+type Report struct {
+	Algorithms []AlgorithmChange `json:"algorithms"`
+}
+
+// IsEmpty reports whether the files are equivalent
+func (r Report) IsEmpty() bool {
+	return len(r.Algorithms) == 0
+}
+
+// Diff compares two query configurations. Algorithms are paired by name
+// and queries by ID, or through an alias of the new query naming the old
+// one's ID or text.
+func Diff(old, new []models.AlgorithmConfig) Report {
+	oldByName := make(map[string]models.AlgorithmConfig, len(old))
+	for _, alg := range old {
+		oldByName[alg.Name] = alg
+	}
+
+	var report Report
+	seen := make(map[string]bool, len(new))
+	for _, alg := range new {
+		seen[alg.Name] = true
+		prev, ok := oldByName[alg.Name]
+		if !ok {
+			report.Algorithms = append(report.Algorithms, AlgorithmChange{
+				Name:    alg.Name,
+				Kind:    Added,
+				Queries: wholeQueries(alg.Queries, Added),
+			})
+			continue
+		}
+		if change := diffAlgorithm(prev, alg); len(change.Settings) > 0 || len(change.Queries) > 0 {
+			report.Algorithms = append(report.Algorithms, change)
+		}
+	}
+	for _, alg := range old {
+		if !seen[alg.Name] {
+			report.Algorithms = append(report.Algorithms, AlgorithmChange{
+				Name:    alg.Name,
+				Kind:    Removed,
+				Queries: wholeQueries(alg.Queries, Removed),
+			})
+		}
+	}
+	return report
+}
+
+// wholeQueries lists the queries of an added or removed algorithm
+func wholeQueries(queries []models.QueryConfig, kind Kind) []QueryChange {
+	changes := make([]QueryChange, len(queries))
+	for i, q := range queries {
+		changes[i] = QueryChange{ID: q.ID, Query: q.Query, Kind: kind}
+	}
+	return changes
+}
+
+// diffAlgorithm compares the settings and queries of an algorithm in both
+// files
+func diffAlgorithm(old, new models.AlgorithmConfig) AlgorithmChange {
+	change := AlgorithmChange{Name: new.Name, Kind: Modified}
+	change.Settings = append(change.Settings, diffValues("description", old.Description, new.Description)...)
+	change.Settings = append(change.Settings, diffValues("script", normalise(old.Script), normalise(new.Script))...)
+	change.Settings = append(change.Settings, diffValues("boost", normalise(old.Boost), normalise(new.Boost))...)
+	change.Settings = append(change.Settings, diffValues("federation", normalise(old.Federation), normalise(new.Federation))...)
+
+	oldByID := make(map[string]int, len(old.Queries))
+	for i, q := range old.Queries {
+		oldByID[q.ID] = i
+	}
+	paired := make(map[int]bool, len(old.Queries))
+	for _, q := range new.Queries {
+		i, ok := oldByID[q.ID]
+		if !ok || paired[i] {
+			ok = false
+			for _, alias := range q.Aliases {
+				for j, prev := range old.Queries {
+					if !paired[j] && (prev.ID == alias || prev.Query == alias || prev.ID == models.Slugify(alias)) {
+						i, ok = j, true
+						break
+					}
+				}
+				if ok {
+					break
+				}
+			}
+		}
+		if !ok {
+			change.Queries = append(change.Queries, QueryChange{ID: q.ID, Query: q.Query, Kind: Added})
+			continue
+		}
+		paired[i] = true
+		if qc := diffQuery(old.Queries[i], q); qc != nil {
+			change.Queries = append(change.Queries, *qc)
+		}
+	}
+	for i, q := range old.Queries {
+		if !paired[i] {
+			change.Queries = append(change.Queries, QueryChange{ID: q.ID, Query: q.Query, Kind: Removed})
+		}
+	}
+	return change
+}
+
+// diffQuery compares a query in both files, returning nil if it is
+// unchanged
+func diffQuery(old, new models.QueryConfig) *QueryChange {
+	qc := QueryChange{ID: old.ID, Query: new.Query, Kind: Modified}
+	if old.ID != new.ID {
+		qc.RenamedTo = new.ID
+	}
+	qc.Fields = append(qc.Fields, diffValues("query", old.Query, new.Query)...)
+	qc.Fields = append(qc.Fields, diffValues("description", old.Description, new.Description)...)
+	qc.Fields = append(qc.Fields, diffValues("aliases", normalise(old.Aliases), normalise(new.Aliases))...)
+	qc.Fields = append(qc.Fields, diffValues("volatile", old.Volatile, new.Volatile)...)
+	qc.ESQuery = diffValues("es_query", normalise(old.ESQuery), normalise(new.ESQuery))
+
+	if qc.RenamedTo == "" && len(qc.Fields) == 0 && len(qc.ESQuery) == 0 {
+		return nil
+	}
+	return &qc
+}
+
+// normalise converts a value to its generic JSON form, so numbers written
+// as 2 and 2.0 compare equal and structs compare like the maps they encode
+func normalise(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// diffValues lists the differences between two generic JSON values, one
+// per changed leaf. Objects are compared key by key and arrays index by
+// index.
+func diffValues(path string, old, new any) []ValueChange {
+	switch {
+	case old == nil && new == nil:
+		return nil
+	case old == nil:
+		return []ValueChange{{Path: path, Kind: Added, New: new}}
+	case new == nil:
+		return []ValueChange{{Path: path, Kind: Removed, Old: old}}
+	}
+
+	oldMap, oldIsMap := old.(map[string]any)
+	newMap, newIsMap := new.(map[string]any)
+	if oldIsMap && newIsMap {
+		keys := make(map[string]bool, len(oldMap)+len(newMap))
+		for k := range oldMap {
+			keys[k] = true
+		}
+		for k := range newMap {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+
+		var changes []ValueChange
+		for _, k := range sorted {
+			changes = append(changes, diffValues(path+"."+k, oldMap[k], newMap[k])...)
+		}
+		return changes
+	}
+
+	oldList, oldIsList := old.([]any)
+	newList, newIsList := new.([]any)
+	if oldIsList && newIsList {
+		var changes []ValueChange
+		for i := 0; i < max(len(oldList), len(newList)); i++ {
+			child := path + "[" + strconv.Itoa(i) + "]"
+			var o, n any
+			if i < len(oldList) {
+				o = oldList[i]
+			}
+			if i < len(newList) {
+				n = newList[i]
+			}
+			changes = append(changes, diffValues(child, o, n)...)
+		}
+		return changes
+	}
+
+	if reflect.DeepEqual(old, new) {
+		return nil
+	}
+	return []ValueChange{{Path: path, Kind: Modified, Old: old, New: new}}
+}
+
+// String describes a value change on one line, e.g.
+// "es_query.size: 10 → 20"
+func (c ValueChange) String() string {
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("%s: added %s", c.Path, formatValue(c.New))
+	case Removed:
+		return fmt.Sprintf("%s: removed %s", c.Path, formatValue(c.Old))
+	default:
+		return fmt.Sprintf("%s: %s → %s", c.Path, formatValue(c.Old), formatValue(c.New))
+	}
+}
+
+// formatValue writes a value as compact JSON
+func formatValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
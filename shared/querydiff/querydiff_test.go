@@ -0,0 +1,110 @@
+package querydiff
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestDiff(t *testing.T) {
+	old := []models.AlgorithmConfig{
+		{Name: "bm25", Description: "Baseline", Queries: []models.QueryConfig{
+			{ID: "cpi", Query: "inflation", ESQuery: map[string]interface{}{
+				"query": map[string]interface{}{"multi_match": map[string]interface{}{
+					"query": "inflation", "fields": []interface{}{"title^2", "summary"},
+				}},
+				"size": 10,
+			}},
+			{ID: "gdp", Query: "gdp", ESQuery: map[string]interface{}{"size": 10}},
+			{ID: "old", Query: "housing"},
+			{ID: "trade", Query: "trade"},
+		}},
+		{Name: "legacy", Queries: []models.QueryConfig{{ID: "x", Query: "x"}}},
+	}
+	new := []models.AlgorithmConfig{
+		{Name: "bm25", Description: "Baseline", Queries: []models.QueryConfig{
+			{ID: "cpi", Query: "inflation", ESQuery: map[string]interface{}{
+				"size": 10.0,
+				"query": map[string]interface{}{"multi_match": map[string]interface{}{
+					"fields": []interface{}{"title^3", "summary", "keywords"}, "query": "inflation",
+				}},
+			}},
+			{ID: "gdp", Query: "gdp", ESQuery: map[string]interface{}{"size": 10}},
+			{ID: "houses", Query: "housing", Aliases: []string{"old"}},
+			{ID: "wages", Query: "wages"},
+		}},
+		{Name: "semantic", Queries: []models.QueryConfig{{ID: "y", Query: "y"}}},
+	}
+
+	report := Diff(old, new)
+
+	var names []string
+	var kinds []Kind
+	for _, alg := range report.Algorithms {
+		names = append(names, alg.Name)
+		kinds = append(kinds, alg.Kind)
+	}
+	if want := []string{"bm25", "semantic", "legacy"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("algorithms = %v, want %v", names, want)
+	}
+	if want := []Kind{Modified, Added, Removed}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("algorithm kinds = %v, want %v", kinds, want)
+	}
+
+	bm25 := report.Algorithms[0]
+	if len(bm25.Settings) != 0 {
+		t.Errorf("settings changes = %v, want none", bm25.Settings)
+	}
+	byID := make(map[string]QueryChange)
+	for _, q := range bm25.Queries {
+		byID[q.ID] = q
+	}
+	if len(bm25.Queries) != 4 {
+		t.Errorf("query changes = %+v, want cpi, old, wages and trade", bm25.Queries)
+	}
+	if _, ok := byID["gdp"]; ok {
+		t.Error("unchanged query gdp reported as changed")
+	}
+
+	wantCPI := []ValueChange{
+		{Path: "es_query.query.multi_match.fields[0]", Kind: Modified, Old: "title^2", New: "title^3"},
+		{Path: "es_query.query.multi_match.fields[2]", Kind: Added, New: "keywords"},
+	}
+	if got := byID["cpi"].ESQuery; !reflect.DeepEqual(got, wantCPI) {
+		t.Errorf("cpi es_query changes = %+v, want %+v", got, wantCPI)
+	}
+
+	renamed := byID["old"]
+	if renamed.Kind != Modified || renamed.RenamedTo != "houses" || len(renamed.ESQuery) != 0 {
+		t.Errorf("renamed query = %+v, want modified and renamed to houses", renamed)
+	}
+	if byID["wages"].Kind != Added || byID["trade"].Kind != Removed {
+		t.Errorf("wages = %s, trade = %s, want added and removed", byID["wages"].Kind, byID["trade"].Kind)
+	}
+}
+
+func TestDiffIdentical(t *testing.T) {
+	algs := []models.AlgorithmConfig{{Name: "bm25", Queries: []models.QueryConfig{
+		{ID: "cpi", Query: "inflation", ESQuery: map[string]interface{}{"size": 10}},
+	}}}
+	if report := Diff(algs, algs); !report.IsEmpty() {
+		t.Errorf("Diff() of identical files = %+v, want empty", report)
+	}
+}
+
+func TestValueChangeString(t *testing.T) {
+	tests := []struct {
+		change ValueChange
+		want   string
+	}{
+		{ValueChange{Path: "size", Kind: Modified, Old: 10.0, New: 20.0}, "size: 10 → 20"},
+		{ValueChange{Path: "query.match.title", Kind: Added, New: "cpi"}, `query.match.title: added "cpi"`},
+		{ValueChange{Path: "fields[1]", Kind: Removed, Old: "summary"}, `fields[1]: removed "summary"`},
+	}
+	for _, tt := range tests {
+		if got := tt.change.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package queryexec
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
+)
+
+// Cache modes, setting the state of Elasticsearch's caches before the
+// measured queries run so latency is compared like for like
+const (
+	CacheAsIs  = "as-is" // Leave caches as earlier requests left them
+	CacheClear = "clear" // Clear the index's caches before each query: cold-cache latency
+	CacheWarm  = "warm"  // Run every query once, unmeasured: warm-cache latency
+)
+
+// ValidateCacheMode checks mode is a known cache mode
+func ValidateCacheMode(mode string) error {
+	switch mode {
+	case CacheAsIs, CacheClear, CacheWarm:
+		return nil
+	default:
+		return fmt.Errorf("unknown cache mode %q: use %s, %s or %s", mode, CacheAsIs, CacheClear, CacheWarm)
+	}
+}
+
+// SetCacheMode sets how caches are prepared before the queries run.
+// Clearing needs clearer; the other modes ignore it.
+func (r *Runner) SetCacheMode(mode string, clearer elasticsearch.CacheClearer) {
+	r.cacheMode = mode
+	r.clearer = clearer
+}
+
+// prepareCaches checks caches can be cleared, or warms them, as the cache
+// mode says
+func (r *Runner) prepareCaches(ctx context.Context, queries []BatchQuery) error {
+	switch r.cacheMode {
+	case CacheClear:
+		if r.clearer == nil {
+			return fmt.Errorf("clear caches: no client to clear them with")
+		}
+		r.printer.Info("Clearing caches on %s before each query", strings.Join(r.indices(queries), ", "))
+	case CacheWarm:
+		r.printer.Info("Warming caches with %d unmeasured queries", len(queries))
+		for _, q := range queries {
+			if err := r.executor.warm(ctx, q); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				r.printer.Debug("  Warm-up %s (%s) failed: %v", q.Query.Query, q.Algorithm, err)
+			}
+		}
+	}
+	return nil
+}
+
+// clearCaches clears the caches of every index the queries search when the
+// cache mode is clear, so each query, or each batch of queries sent in one
+// msearch request, runs against cold caches
+func (r *Runner) clearCaches(ctx context.Context, queries []BatchQuery) error {
+	if r.cacheMode != CacheClear {
+		return nil
+	}
+	for _, index := range r.indices(queries) {
+		r.printer.Debug("  Clearing caches on %s", index)
+		if err := r.clearer.ClearCache(ctx, index); err != nil {
+			return fmt.Errorf("clear caches: %w", err)
+		}
+	}
+	return nil
+}
+
+// indices lists every index the queries search: the executor's own and
+// those of any federation, each once
+func (r *Runner) indices(queries []BatchQuery) []string {
+	indices := []string{r.executor.index}
+	seen := map[string]bool{r.executor.index: true}
+	for _, q := range queries {
+		if q.Federation == nil {
+			continue
+		}
+		for _, name := range q.Federation.IndexNames(r.executor.index) {
+			if !seen[name] {
+				seen[name] = true
+				indices = append(indices, name)
+			}
+		}
+	}
+	return indices
+}
@@ -0,0 +1,77 @@
+package queryexec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch/memory"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+)
+
+// countingClient counts searches and cache clears against the fake
+type countingClient struct {
+	*memory.Client
+	searches, clears int
+}
+
+func (c *countingClient) Search(ctx context.Context, index string, query map[string]interface{}) (*elasticsearch.SearchResponse, error) {
+	c.searches++
+	return c.Client.Search(ctx, index, query)
+}
+
+func (c *countingClient) ClearCache(ctx context.Context, index string) error {
+	c.clears++
+	return c.Client.ClearCache(ctx, index)
+}
+
+func TestRunner_CacheMode(t *testing.T) {
+	docs := []models.Document{{ID: "1", Title: "Inflation", URI: "/cpi", Body: "Prices rose."}}
+	algorithms := []models.AlgorithmConfig{testAlgorithm("bm25", "title", "body")}
+
+	tests := []struct {
+		name         string
+		mode         string
+		batchSize    int
+		wantSearches int
+		wantClears   int
+	}{
+		{name: "as-is", mode: CacheAsIs, wantSearches: 2},
+		{name: "clear before each query", mode: CacheClear, wantSearches: 2, wantClears: 2},
+		{name: "clear before each batch", mode: CacheClear, batchSize: 10, wantClears: 1},
+		{name: "warm", mode: CacheWarm, wantSearches: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &countingClient{Client: memory.NewClientWithIndex("test", docs)}
+			runner := NewRunner(NewExecutor(client, "test", false), ui.NewPrinter(false))
+			runner.SetCacheMode(tt.mode, client)
+			runner.SetBatchSize(tt.batchSize)
+
+			results, err := runner.RunAlgorithms(context.Background(), algorithms)
+			if err != nil {
+				t.Fatalf("RunAlgorithms() error = %v", err)
+			}
+			if len(results) != 2 {
+				t.Errorf("RunAlgorithms() returned %d results, want 2 measured queries", len(results))
+			}
+			if client.searches != tt.wantSearches || client.clears != tt.wantClears {
+				t.Errorf("searches = %d, clears = %d, want %d and %d",
+					client.searches, client.clears, tt.wantSearches, tt.wantClears)
+			}
+		})
+	}
+}
+
+func TestValidateCacheMode(t *testing.T) {
+	for _, mode := range []string{CacheAsIs, CacheClear, CacheWarm} {
+		if err := ValidateCacheMode(mode); err != nil {
+			t.Errorf("ValidateCacheMode(%q) error = %v", mode, err)
+		}
+	}
+	if err := ValidateCacheMode("cold"); err == nil {
+		t.Error("ValidateCacheMode(\"cold\") expected an error")
+	}
+}
@@ -2,69 +2,275 @@ package queryexec
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
 	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/clock"
 )
 
 // Executor handles query execution
 type Executor struct {
-	client  *elasticsearch.Client
+	client  elasticsearch.Searcher
 	index   string
 	verbose bool
+	clock   clock.Clock
+	runID   string
+	trace   *Trace
+	batches int
 }
 
 // NewExecutor creates a new query executor
-func NewExecutor(client *elasticsearch.Client, index string, verbose bool) *Executor {
+func NewExecutor(client elasticsearch.Searcher, index string, verbose bool) *Executor {
 	return &Executor{
 		client:  client,
 		index:   index,
 		verbose: verbose,
+		clock:   clock.Real{},
 	}
 }
 
+// SetClock sets the clock used to stamp results with their run time
+func (e *Executor) SetClock(c clock.Clock) {
+	e.clock = clock.OrReal(c)
+}
+
+// SetRunID sets the run ID that request IDs start with. Requests carry no
+// ID until it is set.
+func (e *Executor) SetRunID(id string) {
+	e.runID = id
+}
+
+// SetTrace records every query sent to Elasticsearch in trace
+func (e *Executor) SetTrace(trace *Trace) {
+	e.trace = trace
+}
+
+// requestID returns the X-Opaque-Id for a request: the run ID followed by
+// the given parts, or "" when no run ID is set. Callers end the parts with
+// the index, so the same query run against several corpora or federated
+// indices gets a request ID for each.
+func (e *Executor) requestID(parts ...string) string {
+	if e.runID == "" {
+		return ""
+	}
+	return strings.Join(append([]string{e.runID}, parts...), "/")
+}
+
+// withRequestID tags ctx with a request ID, if there is one
+func withRequestID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return elasticsearch.WithOpaqueID(ctx, id)
+}
+
 // Execute runs a single query and returns results
 func (e *Executor) Execute(ctx context.Context, qc models.QueryConfig, algorithm string) (models.QueryResults, error) {
-	query := qc.ESQuery
-	if query["size"] == nil {
-		query["size"] = 20
+	return e.execute(ctx, qc, algorithm, false)
+}
+
+// warm runs a query to fill caches, leaving it out of the results
+func (e *Executor) warm(ctx context.Context, q BatchQuery) error {
+	var err error
+	if q.Federation != nil {
+		_, err = e.executeFederated(ctx, q.Query, q.Algorithm, q.Federation, true)
+	} else {
+		_, err = e.execute(ctx, q.Query, q.Algorithm, true)
+	}
+	return err
+}
+
+func (e *Executor) execute(ctx context.Context, qc models.QueryConfig, algorithm string, warmup bool) (models.QueryResults, error) {
+	parts := []string{algorithm, qc.StableID(), e.index}
+	if warmup {
+		parts = append(parts, "warmup")
 	}
+	id := e.requestID(parts...)
 
-	response, err := e.client.Search(ctx, e.index, query)
+	response, err := e.client.Search(withRequestID(ctx, id), e.index, prepareQuery(qc))
+	entry := e.traceEntry(id, qc, algorithm)
+	entry.Warmup = warmup
 	if err != nil {
+		entry.Error = err.Error()
+		e.trace.Record(entry)
 		return models.QueryResults{}, fmt.Errorf("execute search: %w", err)
 	}
+	entry.TookMs, entry.Hits = response.Took, len(response.Hits.Hits)
+	e.trace.Record(entry)
+
+	results := mapResults(response, qc, algorithm, e.clock.Now())
+	results.RequestID = id
+	return results, nil
+}
+
+func (e *Executor) traceEntry(id string, qc models.QueryConfig, algorithm string) TraceEntry {
+	return TraceEntry{
+		Time:      e.clock.Now(),
+		RequestID: id,
+		Index:     e.index,
+		Algorithm: algorithm,
+		QueryID:   qc.StableID(),
+		Query:     qc.Query,
+	}
+}
+
+// BatchQuery pairs a query with the algorithm it belongs to
+type BatchQuery struct {
+	Query      models.QueryConfig
+	Algorithm  string
+	Federation *models.FederationConfig // The algorithm's federation, if any
+}
+
+// BatchResult holds the outcome of one query within a batch
+type BatchResult struct {
+	Results models.QueryResults
+	Err     error
+}
+
+// ExecuteBatch runs several queries in a single msearch request. A failure
+// of the whole request is returned as an error; failures of individual
+// queries are reported in their BatchResult. Federated queries span several
+// indices, so they are sent on their own.
+func (e *Executor) ExecuteBatch(ctx context.Context, batch []BatchQuery) ([]BatchResult, error) {
+	var federated []int
+	var plain []BatchQuery
+	for i, bq := range batch {
+		if bq.Federation != nil {
+			federated = append(federated, i)
+		} else {
+			plain = append(plain, bq)
+		}
+	}
+	if len(federated) == 0 {
+		return e.executeMulti(ctx, batch)
+	}
+
+	plainResults, err := e.executeMulti(ctx, plain)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]BatchResult, 0, len(batch))
+	for i, bq := range batch {
+		if len(federated) > 0 && federated[0] == i {
+			federated = federated[1:]
+			qr, err := e.ExecuteFederated(ctx, bq.Query, bq.Algorithm, bq.Federation)
+			results = append(results, BatchResult{Results: qr, Err: err})
+			continue
+		}
+		results = append(results, plainResults[0])
+		plainResults = plainResults[1:]
+	}
+	return results, nil
+}
+
+// executeMulti runs queries against the executor's index in one msearch
+// request
+func (e *Executor) executeMulti(ctx context.Context, batch []BatchQuery) ([]BatchResult, error) {
+	if len(batch) == 0 {
+		return nil, nil
+	}
+	queries := make([]map[string]interface{}, len(batch))
+	for i, bq := range batch {
+		queries[i] = prepareQuery(bq.Query)
+	}
+
+	e.batches++
+	id := e.requestID(fmt.Sprintf("batch-%d", e.batches), e.index)
+
+	responses, err := e.client.MultiSearch(withRequestID(ctx, id), e.index, queries)
+	if err != nil {
+		for _, bq := range batch {
+			entry := e.traceEntry(id, bq.Query, bq.Algorithm)
+			entry.Error = err.Error()
+			e.trace.Record(entry)
+		}
+		return nil, fmt.Errorf("execute multi search: %w", err)
+	}
+
+	results := make([]BatchResult, len(batch))
+	for i, item := range responses {
+		entry := e.traceEntry(id, batch[i].Query, batch[i].Algorithm)
+		if err := item.Err(); err != nil {
+			entry.Error = err.Error()
+			e.trace.Record(entry)
+			results[i].Err = fmt.Errorf("execute search: %w", err)
+			continue
+		}
+		entry.TookMs, entry.Hits = item.Took, len(item.Hits.Hits)
+		e.trace.Record(entry)
+
+		results[i].Results = mapResults(&item.SearchResponse, batch[i].Query, batch[i].Algorithm, e.clock.Now())
+		results[i].Results.RequestID = id
+	}
+
+	return results, nil
+}
+
+// defaultSize is the number of results asked for when a query sets none
+const defaultSize = 20
+
+func prepareQuery(qc models.QueryConfig) map[string]interface{} {
+	query := qc.ESQuery
+	if query["size"] == nil {
+		query["size"] = defaultSize
+	}
+	return query
+}
 
+func mapResults(response *elasticsearch.SearchResponse, qc models.QueryConfig, algorithm string, runAt time.Time) models.QueryResults {
 	results := make([]models.SearchResult, 0, len(response.Hits.Hits))
 	for i, hit := range response.Hits.Hits {
 		result := models.SearchResult{
+			ID:          hit.ID,
 			Rank:        i + 1,
-			Title:       getStringField(hit.Source, "title"),
-			URI:         getStringField(hit.Source, "uri"),
-			Date:        formatDate(getStringField(hit.Source, "date")),
-			ContentType: getStringField(hit.Source, "content_type"),
+			Title:       hit.Source.Title,
+			URI:         hit.Source.URI,
+			Date:        formatDate(hit.Source.Date),
+			ContentType: hit.Source.ContentType,
 			Algorithm:   algorithm,
 			Score:       hit.Score,
 		}
+		if field := qc.CollapseField(); field != "" {
+			result.Group, result.GroupSize = collapseGroup(hit, field)
+		}
 		results = append(results, result)
 	}
 
 	return models.QueryResults{
+		QueryID:     qc.StableID(),
 		Query:       qc.Query,
+		Aliases:     qc.Aliases,
 		Algorithm:   algorithm,
 		Description: qc.Description,
-		RunAt:       time.Now(),
+		Volatile:    qc.Volatile,
+		RunAt:       runAt,
+		TookMs:      response.Took,
 		Results:     results,
-	}, nil
+	}
 }
 
-func getStringField(m map[string]interface{}, key string) string {
-	if v, ok := m[key].(string); ok {
-		return v
+// collapseGroup returns the collapse key a hit stands for and, when inner
+// hits were fetched, how many documents its group holds
+func collapseGroup(hit elasticsearch.Hit, field string) (string, int) {
+	var group string
+	if values := hit.Fields[field]; len(values) > 0 {
+		group = fmt.Sprint(values[0])
+	} else if raw, ok := hit.Source.Extras[field]; ok {
+		var value interface{}
+		if json.Unmarshal(raw, &value) == nil && value != nil {
+			group = fmt.Sprint(value)
+		}
+	}
+
+	size := 0
+	if inner, ok := hit.InnerHits[models.GroupInnerHits]; ok {
+		size = inner.Hits.Total.Value
 	}
-	return ""
+	return group, size
 }
 
 func formatDate(dateStr string) string {
@@ -1,8 +1,11 @@
 package queryexec
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
 	"github.com/ONSdigital/dis-search-test-bed/models"
 )
 
@@ -77,3 +80,31 @@ func TestAverageScore(t *testing.T) {
 		})
 	}
 }
+
+func TestMapResults_Collapsed(t *testing.T) {
+	var response elasticsearch.SearchResponse
+	body := `{"hits": {"hits": [
+		{"_id": "cpi-2024", "_score": 3, "_source": {"uri": "/cpi/2024"}, "fields": {"series_id": ["cpi"]},
+		 "inner_hits": {"group": {"hits": {"total": {"value": 12}, "hits": []}}}},
+		{"_id": "gdp-2024", "_score": 2, "_source": {"uri": "/gdp/2024", "series_id": "gdp"}}
+	]}}`
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		t.Fatal(err)
+	}
+
+	qc := models.QueryConfig{Query: "prices", Collapse: &models.CollapseConfig{Field: "series_id", GroupSize: 1}}
+	results := mapResults(&response, qc, "bm25", time.Time{}).Results
+
+	want := []struct {
+		rank  int
+		group string
+		size  int
+	}{{1, "cpi", 12}, {2, "gdp", 0}}
+	for i, w := range want {
+		r := results[i]
+		if r.Rank != w.rank || r.Group != w.group || r.GroupSize != w.size {
+			t.Errorf("result %d = rank %d group %q size %d, want rank %d group %q size %d",
+				i, r.Rank, r.Group, r.GroupSize, w.rank, w.group, w.size)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package queryexec
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// rrfK is the rank constant of reciprocal rank fusion, as Elasticsearch uses
+const rrfK = 60
+
+// ExecuteFederated sends a query to every index of a federation and merges
+// the hits into one ranked list, as the production API does
+func (e *Executor) ExecuteFederated(ctx context.Context, qc models.QueryConfig, algorithm string,
+	federation *models.FederationConfig) (models.QueryResults, error) {
+	return e.executeFederated(ctx, qc, algorithm, federation, false)
+}
+
+func (e *Executor) executeFederated(ctx context.Context, qc models.QueryConfig, algorithm string,
+	federation *models.FederationConfig, warmup bool) (models.QueryResults, error) {
+	query := prepareQuery(qc)
+	size := defaultSize
+	if n, ok := query["size"].(int); ok {
+		size = n
+	} else if n, ok := query["size"].(float64); ok {
+		size = int(n)
+	}
+
+	names := federation.IndexNames(e.index)
+	lists := make([][]elasticsearch.Hit, len(names))
+	var took int
+	var ids []string
+	for i, name := range names {
+		parts := []string{algorithm, qc.StableID(), name}
+		if warmup {
+			parts = append(parts, "warmup")
+		}
+		id := e.requestID(parts...)
+		if id != "" {
+			ids = append(ids, id)
+		}
+
+		response, err := e.client.Search(withRequestID(ctx, id), name, query)
+		entry := e.traceEntry(id, qc, algorithm)
+		entry.Index = name
+		entry.Warmup = warmup
+		if err != nil {
+			entry.Error = err.Error()
+			e.trace.Record(entry)
+			return models.QueryResults{}, fmt.Errorf("execute search on %s: %w", name, err)
+		}
+		entry.TookMs, entry.Hits = response.Took, len(response.Hits.Hits)
+		e.trace.Record(entry)
+
+		for _, hit := range response.Hits.Hits {
+			if hit.Index == "" {
+				hit.Index = name
+			}
+			lists[i] = append(lists[i], hit)
+		}
+		// The production API queries the indices in parallel, so the
+		// slowest one is what the user waits for
+		took = max(took, response.Took)
+	}
+
+	merged := &elasticsearch.SearchResponse{Took: took}
+	merged.Hits.Hits = mergeHits(lists, federation.Weights(), federation.Strategy, size)
+	results := mapResults(merged, qc, algorithm, e.clock.Now())
+	for i, hit := range merged.Hits.Hits {
+		results.Results[i].Index = hit.Index
+	}
+	results.RequestIDs = ids
+	return results, nil
+}
+
+// mergeHits merges the hits each index returned into one list of at most
+// size hits, as the strategy says. A document returned by more than one
+// index, going by URI, is kept once, at its best position. The weighted and
+// rrf strategies replace each hit's score with its merged score.
+func mergeHits(lists [][]elasticsearch.Hit, weights []float64, strategy string, size int) []elasticsearch.Hit {
+	type candidate struct {
+		hit   elasticsearch.Hit
+		score float64
+		list  int
+		pos   int
+	}
+	var candidates []candidate
+	for i, hits := range lists {
+		for pos, hit := range hits {
+			c := candidate{hit: hit, score: hit.Score, list: i, pos: pos}
+			switch strategy {
+			case models.FederationWeighted:
+				c.score = hit.Score * weights[i]
+				c.hit.Score = c.score
+			case models.FederationRRF:
+				c.score = weights[i] / float64(rrfK+pos+1)
+				c.hit.Score = c.score
+			}
+			candidates = append(candidates, c)
+		}
+	}
+
+	sort.SliceStable(candidates, func(a, b int) bool {
+		ca, cb := candidates[a], candidates[b]
+		if strategy == models.FederationInterleave {
+			if ca.pos != cb.pos {
+				return ca.pos < cb.pos
+			}
+			return ca.list < cb.list
+		}
+		if ca.score != cb.score {
+			return ca.score > cb.score
+		}
+		if ca.list != cb.list {
+			return ca.list < cb.list
+		}
+		return ca.pos < cb.pos
+	})
+
+	seen := make(map[string]bool, len(candidates))
+	merged := make([]elasticsearch.Hit, 0, min(size, len(candidates)))
+	for _, c := range candidates {
+		if len(merged) == size {
+			break
+		}
+		key := c.hit.Source.URI
+		if key == "" {
+			key = c.hit.Index + "/" + c.hit.ID
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		merged = append(merged, c.hit)
+	}
+	return merged
+}
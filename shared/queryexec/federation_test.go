@@ -0,0 +1,118 @@
+package queryexec
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch/memory"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/indexgen"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+)
+
+func hit(index, uri string, score float64) elasticsearch.Hit {
+	h := elasticsearch.Hit{Index: index, ID: uri, Score: score}
+	h.Source.URI = uri
+	return h
+}
+
+func TestMergeHits(t *testing.T) {
+	lists := [][]elasticsearch.Hit{
+		{hit("content", "/a", 9), hit("content", "/b", 5), hit("content", "/shared", 1)},
+		{hit("topics", "/t1", 3), hit("topics", "/shared", 2)},
+	}
+
+	tests := []struct {
+		strategy string
+		weights  []float64
+		size     int
+		want     []string
+	}{
+		{strategy: models.FederationScore, weights: []float64{1, 1}, size: 10,
+			want: []string{"/a", "/b", "/t1", "/shared"}},
+		{strategy: models.FederationWeighted, weights: []float64{1, 4}, size: 10,
+			want: []string{"/t1", "/a", "/shared", "/b"}},
+		{strategy: models.FederationInterleave, weights: []float64{1, 1}, size: 10,
+			want: []string{"/a", "/t1", "/b", "/shared"}},
+		{strategy: models.FederationRRF, weights: []float64{1, 1}, size: 10,
+			want: []string{"/a", "/t1", "/b", "/shared"}},
+		{strategy: models.FederationScore, weights: []float64{1, 1}, size: 2,
+			want: []string{"/a", "/b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			var got []string
+			for _, h := range mergeHits(lists, tt.weights, tt.strategy, tt.size) {
+				got = append(got, h.Source.URI)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeHits() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRunner_Federated fans a query out across two indices, one at a time
+// and batched, and checks both merge the same results
+func TestRunner_Federated(t *testing.T) {
+	ctx := context.Background()
+	client := memory.NewClient()
+	for index, docs := range map[string][]models.Document{
+		"test": {
+			{ID: "1", Title: "Consumer price inflation", URI: "/economy/cpi"},
+			{ID: "2", Title: "Population", URI: "/people/population"},
+		},
+		"test_topics": {
+			{ID: "t1", Title: "Inflation and price indices", URI: "/topics/inflation"},
+		},
+	} {
+		stored := &models.StoredIndex{Documents: docs}
+		if err := indexgen.NewLoader().LoadIntoElasticsearch(ctx, client, index, stored); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	alg := testAlgorithm("federated", "title")
+	alg.Federation = &models.FederationConfig{
+		Strategy: models.FederationInterleave,
+		Indices:  []models.FederatedIndex{{Index: "{index}"}, {Index: "{index}_topics"}},
+	}
+
+	var runs [][]models.QueryResults
+	for _, batchSize := range []int{0, 10} {
+		executor := NewExecutor(client, "test", false)
+		executor.SetRunID("run-1")
+		runner := NewRunner(executor, ui.NewPrinter(false))
+		runner.SetBatchSize(batchSize)
+		results, err := runner.RunAlgorithms(ctx, []models.AlgorithmConfig{alg, testAlgorithm("plain", "title")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		runs = append(runs, results)
+	}
+
+	for _, results := range runs {
+		if len(results) != 4 {
+			t.Fatalf("got %d query results, want 4", len(results))
+		}
+		var got []string
+		for _, r := range results[0].Results {
+			got = append(got, r.Index+":"+r.URI)
+		}
+		if want := []string{"test:/economy/cpi", "test_topics:/topics/inflation"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("federated inflation results = %v, want %v", got, want)
+		}
+		if len(results[2].Results) != 1 || results[2].Results[0].Index != "" {
+			t.Errorf("plain inflation results = %+v, want one untagged result", results[2].Results)
+		}
+		want := []string{"run-1/federated/inflation/test", "run-1/federated/inflation/test_topics"}
+		if results[0].RequestID != "" || !reflect.DeepEqual(results[0].RequestIDs, want) {
+			t.Errorf("federated request IDs = %q, %v, want %v", results[0].RequestID, results[0].RequestIDs, want)
+		}
+	}
+	if !reflect.DeepEqual(runs[0][0].Results, runs[1][0].Results) {
+		t.Errorf("batched federated results differ: %+v vs %+v", runs[0][0].Results, runs[1][0].Results)
+	}
+}
@@ -3,14 +3,21 @@ package queryexec
 import (
 	"context"
 
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
 	"github.com/ONSdigital/dis-search-test-bed/models"
 	"github.com/ONSdigital/dis-search-test-bed/ui"
 )
 
+// PhaseQueries names the query phase in progress events
+const PhaseQueries = "queries"
+
 // Runner manages running multiple queries
 type Runner struct {
-	executor *Executor
-	printer  *ui.Printer
+	executor  *Executor
+	printer   *ui.Printer
+	batchSize int
+	cacheMode string
+	clearer   elasticsearch.CacheClearer
 }
 
 // NewRunner creates a new query runner
@@ -21,8 +28,26 @@ func NewRunner(executor *Executor, printer *ui.Printer) *Runner {
 	}
 }
 
+// SetBatchSize enables msearch batching with up to size queries per
+// request. A size of 0 or 1 runs queries one at a time.
+func (r *Runner) SetBatchSize(size int) {
+	r.batchSize = size
+}
+
 // RunAlgorithms executes all queries for all algorithms
 func (r *Runner) RunAlgorithms(ctx context.Context, algorithms []models.AlgorithmConfig) ([]models.QueryResults, error) {
+	queries := suite(algorithms)
+	if err := r.prepareCaches(ctx, queries); err != nil {
+		return nil, err
+	}
+
+	progress := ui.NewProgress(PhaseQueries, len(queries))
+	defer progress.Done()
+
+	if r.batchSize > 1 {
+		return r.runBatched(ctx, queries, progress)
+	}
+
 	var allResults []models.QueryResults
 
 	for algIdx, alg := range algorithms {
@@ -35,7 +60,19 @@ func (r *Runner) RunAlgorithms(ctx context.Context, algorithms []models.Algorith
 		for qIdx, query := range alg.Queries {
 			r.printer.Info("  [Query %d/%d] %s", qIdx+1, len(alg.Queries), query.Query)
 
-			result, err := r.executor.Execute(ctx, query, alg.Name)
+			q := BatchQuery{Query: query, Algorithm: alg.Name, Federation: alg.Federation}
+			if err := r.clearCaches(ctx, []BatchQuery{q}); err != nil {
+				return nil, err
+			}
+
+			var result models.QueryResults
+			var err error
+			if alg.Federation != nil {
+				result, err = r.executor.ExecuteFederated(ctx, query, alg.Name, alg.Federation)
+			} else {
+				result, err = r.executor.Execute(ctx, query, alg.Name)
+			}
+			progress.Add(1)
 			if err != nil {
 				r.printer.Error("    Failed: %v", err)
 				continue
@@ -51,6 +88,60 @@ func (r *Runner) RunAlgorithms(ctx context.Context, algorithms []models.Algorith
 	return allResults, nil
 }
 
+// runBatched executes all queries through msearch requests of up to
+// batchSize queries, keeping results in suite order
+func (r *Runner) runBatched(ctx context.Context, pending []BatchQuery, progress *ui.Progress) ([]models.QueryResults, error) {
+	batchCount := (len(pending) + r.batchSize - 1) / r.batchSize
+	allResults := make([]models.QueryResults, 0, len(pending))
+
+	for batchIdx := 0; batchIdx < batchCount; batchIdx++ {
+		start := batchIdx * r.batchSize
+		end := start + r.batchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		batch := pending[start:end]
+
+		r.printer.Info("[Batch %d/%d] %d queries", batchIdx+1, batchCount, len(batch))
+
+		if err := r.clearCaches(ctx, batch); err != nil {
+			return nil, err
+		}
+		results, err := r.executor.ExecuteBatch(ctx, batch)
+		progress.Add(len(batch))
+		if err != nil {
+			r.printer.Error("  Failed: %v", err)
+			continue
+		}
+
+		for i, res := range results {
+			if res.Err != nil {
+				r.printer.Error("  %s (%s) failed: %v", batch[i].Query.Query, batch[i].Algorithm, res.Err)
+				continue
+			}
+
+			r.printer.Success("  %s (%s): %d results in %dms (avg score: %.4f)",
+				res.Results.Query, res.Results.Algorithm, len(res.Results.Results),
+				res.Results.TookMs, averageScore(res.Results.Results))
+
+			allResults = append(allResults, res.Results)
+		}
+	}
+
+	return allResults, nil
+}
+
+// suite lists every query of every algorithm, in order
+func suite(algorithms []models.AlgorithmConfig) []BatchQuery {
+	var queries []BatchQuery
+	for _, alg := range algorithms {
+		for _, query := range alg.Queries {
+			queries = append(queries, BatchQuery{Query: query, Algorithm: alg.Name, Federation: alg.Federation})
+		}
+	}
+	return queries
+}
+
 func averageScore(results []models.SearchResult) float64 {
 	if len(results) == 0 {
 		return 0
@@ -0,0 +1,96 @@
+package queryexec
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch/memory"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/comparison"
+	"github.com/ONSdigital/dis-search-test-bed/shared/indexgen"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+)
+
+func testAlgorithm(name string, fields ...interface{}) models.AlgorithmConfig {
+	alg := models.AlgorithmConfig{Name: name}
+	for _, q := range []string{"inflation", "house prices"} {
+		alg.Queries = append(alg.Queries, models.QueryConfig{
+			ID:    models.Slugify(q),
+			Query: q,
+			ESQuery: map[string]interface{}{
+				"query": map[string]interface{}{
+					"multi_match": map[string]interface{}{"query": q, "fields": fields},
+				},
+			},
+		})
+	}
+	return alg
+}
+
+// TestRunner_EndToEnd loads an index into the in-memory fake, runs two
+// versions of an algorithm and compares them, as query and compare do
+func TestRunner_EndToEnd(t *testing.T) {
+	ctx := context.Background()
+	stored := &models.StoredIndex{Documents: []models.Document{
+		{ID: "1", Title: "Consumer price inflation", URI: "/economy/cpi", Body: "Prices rose."},
+		{ID: "2", Title: "House price index", URI: "/economy/hpi", Body: "House prices and inflation in housing."},
+		{ID: "3", Title: "Private rents", URI: "/economy/rents", Body: "Rental prices rose with inflation."},
+		{ID: "4", Title: "Population", URI: "/people/population", Body: "Population estimates."},
+	}}
+
+	client := memory.NewClient()
+	if err := indexgen.NewLoader().LoadIntoElasticsearch(ctx, client, "test", stored); err != nil {
+		t.Fatalf("LoadIntoElasticsearch() error = %v", err)
+	}
+
+	printer := ui.NewPrinter(false)
+	run := func(alg models.AlgorithmConfig, batchSize int) []models.QueryResults {
+		runner := NewRunner(NewExecutor(client, "test", false), printer)
+		runner.SetBatchSize(batchSize)
+		results, err := runner.RunAlgorithms(ctx, []models.AlgorithmConfig{alg})
+		if err != nil {
+			t.Fatalf("RunAlgorithms() error = %v", err)
+		}
+		return results
+	}
+
+	previous := run(testAlgorithm("bm25", "body"), 0)
+	current := run(testAlgorithm("bm25", "title^3", "body"), 0)
+	batched := run(testAlgorithm("bm25", "title^3", "body"), 5)
+
+	if len(current) != 2 || len(batched) != 2 {
+		t.Fatalf("expected results for both queries, got %d and %d", len(current), len(batched))
+	}
+	for i := range current {
+		if len(current[i].Results) != len(batched[i].Results) {
+			t.Fatalf("batched results differ for %q", current[i].Query)
+		}
+		for j := range current[i].Results {
+			if current[i].Results[j].URI != batched[i].Results[j].URI {
+				t.Errorf("batched ranking differs for %q at rank %d", current[i].Query, j+1)
+			}
+		}
+	}
+
+	if first := current[0].Results[0]; first.URI != "/economy/cpi" || first.ID != "1" {
+		t.Errorf("expected the title match first with the title boost, got %+v", first)
+	}
+
+	comp := comparison.NewComparison(current, previous, comparison.Options{}, comparison.ModeHistorical)
+	summary := comp.GetSummary()
+	if summary.QueriesCompared != 2 {
+		t.Errorf("expected both queries compared, got %d", summary.QueriesCompared)
+	}
+	if summary.ImprovedRankings+summary.WorsenedRankings+summary.NewResults == 0 {
+		t.Error("expected the title boost to change some rankings")
+	}
+
+	report, err := comp.Render(comparison.FormatText)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !strings.Contains(string(report), "Query: house prices") {
+		t.Errorf("expected the report to cover each query, got:\n%s", report)
+	}
+}
@@ -0,0 +1,61 @@
+package queryexec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// TraceEntry is one query sent to Elasticsearch, as recorded in the trace
+// log. RequestID is the X-Opaque-Id the request carried; queries batched
+// into one msearch request share it.
+type TraceEntry struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id"`
+	Index     string    `json:"index"`
+	Algorithm string    `json:"algorithm"`
+	QueryID   string    `json:"query_id"`
+	Query     string    `json:"query"`
+	Warmup    bool      `json:"warmup,omitempty"` // Unmeasured cache warm-up query
+	TookMs    int       `json:"took_ms"`
+	Hits      int       `json:"hits"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Trace writes trace entries as JSON Lines
+type Trace struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewTrace creates a trace log writing to w
+func NewTrace(w io.Writer) *Trace {
+	return &Trace{enc: json.NewEncoder(w)}
+}
+
+// Record appends an entry to the log. Write errors don't stop the run; the
+// first one is kept for Err.
+func (t *Trace) Record(entry TraceEntry) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.enc.Encode(entry); err != nil && t.err == nil {
+		t.err = fmt.Errorf("write trace entry: %w", err)
+	}
+}
+
+// Err returns the first error writing the log, if any
+func (t *Trace) Err() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
@@ -0,0 +1,55 @@
+package queryexec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch/memory"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/ui"
+)
+
+func TestRunner_Trace(t *testing.T) {
+	docs := []models.Document{{ID: "1", Title: "Inflation", URI: "/cpi", Body: "Prices rose."}}
+	client := memory.NewClientWithIndex("test", docs)
+
+	var buf bytes.Buffer
+	trace := NewTrace(&buf)
+	executor := NewExecutor(client, "test", false)
+	executor.SetRunID("run-1")
+	executor.SetTrace(trace)
+	runner := NewRunner(executor, ui.NewPrinter(false))
+	runner.SetCacheMode(CacheWarm, client)
+
+	results, err := runner.RunAlgorithms(context.Background(), []models.AlgorithmConfig{testAlgorithm("bm25", "title", "body")})
+	if err != nil {
+		t.Fatalf("RunAlgorithms() error = %v", err)
+	}
+	if err := trace.Err(); err != nil {
+		t.Fatalf("trace error = %v", err)
+	}
+	if got, want := results[0].RequestID, "run-1/bm25/inflation/test"; got != want {
+		t.Errorf("RequestID = %q, want %q", got, want)
+	}
+
+	var entries []TraceEntry
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var entry TraceEntry
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatalf("decode trace: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("trace has %d entries, want 2 warm-up and 2 measured", len(entries))
+	}
+	if e := entries[0]; !e.Warmup || e.RequestID != "run-1/bm25/inflation/test/warmup" {
+		t.Errorf("first entry = %+v, want the inflation warm-up", e)
+	}
+	if e := entries[2]; e.Warmup || e.RequestID != "run-1/bm25/inflation/test" || e.Index != "test" || e.Hits != 1 {
+		t.Errorf("third entry = %+v, want the measured inflation query with 1 hit", e)
+	}
+}
@@ -0,0 +1,224 @@
+package queryfeatures
+
+import (
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// maxExamples is how many exercising queries are listed per feature
+const maxExamples = 3
+
+// SearchFeature is a feature of the production search service and how a
+// query body shows it is exercised
+type SearchFeature struct {
+	Name        string
+	Description string
+	uses        func(body map[string]interface{}) bool
+}
+
+// SearchFeatures are the production features coverage is reported for
+var SearchFeatures = []SearchFeature{
+	{
+		Name:        "filters",
+		Description: "filter, must_not or post_filter clauses",
+		uses:        usesFilters,
+	},
+	{
+		Name:        "sorting",
+		Description: "a sort other than by score",
+		uses:        usesSorting,
+	},
+	{
+		Name:        "typeahead",
+		Description: "prefix matching or a completion suggester",
+		uses:        usesTypeahead,
+	},
+	{
+		Name:        "spelling",
+		Description: "a term or phrase suggester, or fuzzy matching",
+		uses:        usesSpelling,
+	},
+	{
+		Name:        "pagination",
+		Description: "from beyond the first page, or search_after",
+		uses:        usesPagination,
+	},
+	{
+		Name:        "topic pages",
+		Description: "a clause on a topic field",
+		uses:        usesTopics,
+	},
+}
+
+// FeatureCoverage is how much of the suite exercises one search feature
+type FeatureCoverage struct {
+	Feature     string   `json:"feature"`
+	Description string   `json:"description"`
+	Queries     int      `json:"queries"`              // Queries exercising the feature
+	Algorithms  []string `json:"algorithms,omitempty"` // Algorithms with at least one such query
+	Examples    []string `json:"examples,omitempty"`   // A few of the queries, as algorithm/id
+}
+
+// Covered reports whether any query exercises the feature
+func (c FeatureCoverage) Covered() bool {
+	return c.Queries > 0
+}
+
+// Coverage reports, for each of the SearchFeatures in order, which queries
+// of the suite exercise it
+func Coverage(algorithms []models.AlgorithmConfig) []FeatureCoverage {
+	report := make([]FeatureCoverage, len(SearchFeatures))
+	for i, feature := range SearchFeatures {
+		c := FeatureCoverage{Feature: feature.Name, Description: feature.Description}
+		for _, alg := range algorithms {
+			used := false
+			for _, qc := range alg.Queries {
+				if qc.ESQuery == nil || !feature.uses(qc.ESQuery) {
+					continue
+				}
+				c.Queries++
+				used = true
+				if len(c.Examples) < maxExamples {
+					c.Examples = append(c.Examples, alg.Name+"/"+qc.StableID())
+				}
+			}
+			if used {
+				c.Algorithms = append(c.Algorithms, alg.Name)
+			}
+		}
+		report[i] = c
+	}
+	return report
+}
+
+// Uncovered lists the features no query exercises
+func Uncovered(report []FeatureCoverage) []FeatureCoverage {
+	var uncovered []FeatureCoverage
+	for _, c := range report {
+		if !c.Covered() {
+			uncovered = append(uncovered, c)
+		}
+	}
+	return uncovered
+}
+
+func usesFilters(body map[string]interface{}) bool {
+	return scanBody(body, make(map[string]bool)).filtered
+}
+
+func usesSorting(body map[string]interface{}) bool {
+	sort, ok := body["sort"]
+	if !ok {
+		return false
+	}
+	list, ok := sort.([]interface{})
+	if !ok {
+		list = []interface{}{sort}
+	}
+	for _, s := range list {
+		switch v := s.(type) {
+		case string:
+			if v != "_score" {
+				return true
+			}
+		case map[string]interface{}:
+			for field := range v {
+				if field != "_score" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func usesTypeahead(body map[string]interface{}) bool {
+	return anyKey(body, func(key string, value interface{}) bool {
+		switch key {
+		case "match_phrase_prefix", "match_bool_prefix", "prefix", "completion":
+			_, ok := value.(map[string]interface{})
+			return ok
+		case "multi_match":
+			t, _ := mapValue(value, "type").(string)
+			return t == "phrase_prefix" || t == "bool_prefix"
+		}
+		return false
+	})
+}
+
+func usesSpelling(body map[string]interface{}) bool {
+	if anyKey(body["suggest"], func(key string, _ interface{}) bool {
+		return key == "term" || key == "phrase"
+	}) {
+		return true
+	}
+	return anyKey(body["query"], func(key string, value interface{}) bool {
+		if key == "fuzziness" {
+			return true
+		}
+		_, ok := value.(map[string]interface{})
+		return key == "fuzzy" && ok
+	})
+}
+
+func usesPagination(body map[string]interface{}) bool {
+	if _, ok := body["search_after"]; ok {
+		return true
+	}
+	from, _ := body["from"].(float64)
+	return from > 0
+}
+
+func usesTopics(body map[string]interface{}) bool {
+	// Aggregating on topics doesn't scope the query to one
+	scoped := []interface{}{body["query"], body["post_filter"]}
+	return anyKey(scoped, func(key string, value interface{}) bool {
+		switch key {
+		case "term", "terms", "match", "match_phrase", "prefix", "exists":
+		default:
+			return false
+		}
+		clause, ok := value.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if field, ok := clause["field"].(string); ok {
+			return strings.Contains(strings.ToLower(field), "topic")
+		}
+		for field := range clause {
+			if strings.Contains(strings.ToLower(field), "topic") {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// anyKey reports whether match holds for any key of any object within node
+func anyKey(node interface{}, match func(key string, value interface{}) bool) bool {
+	switch v := node.(type) {
+	case []interface{}:
+		for _, child := range v {
+			if anyKey(child, match) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for key, child := range v {
+			if match(key, child) || anyKey(child, match) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mapValue returns a key's value when node is an object
+func mapValue(node interface{}, key string) interface{} {
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return m[key]
+}
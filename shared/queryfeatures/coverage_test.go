@@ -0,0 +1,60 @@
+package queryfeatures
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestCoverage(t *testing.T) {
+	algs := []models.AlgorithmConfig{
+		{Name: "bm25", Queries: []models.QueryConfig{
+			{ID: "cpi", ESQuery: body(t, `{"query": {"match": {"title": {"query": "cpi", "fuzziness": "AUTO"}}}, "sort": ["_score"]}`)},
+			{ID: "latest", ESQuery: body(t, `{"query": {"match_all": {}}, "sort": [{"date": "desc"}], "from": 10}`)},
+		}},
+		{Name: "scoped", Queries: []models.QueryConfig{
+			{ID: "housing", ESQuery: body(t, `{
+				"query": {"bool": {
+					"must": [{"match_phrase_prefix": {"title": "hous"}}],
+					"filter": [{"term": {"topics": "housing"}}]
+				}},
+				"aggs": {"topics": {"terms": {"field": "topics"}}}
+			}`)},
+			{ID: "by-type", ESQuery: body(t, `{"query": {"match": {"body": "gdp"}}, "aggs": {"t": {"terms": {"field": "topics"}}}}`)},
+			{ID: "boosted", ESQuery: body(t, `{"query": {"function_score": {
+				"query": {"match": {"body": "gdp"}},
+				"functions": [{"filter": {"term": {"type": "bulletin"}}, "weight": 2}]
+			}}}`)},
+		}},
+	}
+
+	report := Coverage(algs)
+	got := make(map[string][]string, len(report))
+	for _, c := range report {
+		got[c.Feature] = c.Examples
+		if c.Covered() != (c.Queries > 0) || (c.Queries > 0) != (len(c.Algorithms) > 0) {
+			t.Errorf("%s = %+v is inconsistent", c.Feature, c)
+		}
+	}
+	want := map[string][]string{
+		"filters":     {"scoped/housing"},
+		"sorting":     {"bm25/latest"},
+		"typeahead":   {"scoped/housing"},
+		"spelling":    {"bm25/cpi"},
+		"pagination":  {"bm25/latest"},
+		"topic pages": {"scoped/housing"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Coverage() examples = %v, want %v", got, want)
+	}
+
+	uncovered := Uncovered(Coverage(algs[:1]))
+	var names []string
+	for _, c := range uncovered {
+		names = append(names, c.Feature)
+	}
+	if want := []string{"filters", "typeahead", "topic pages"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Uncovered() = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,204 @@
+// Package queryfeatures summarises which Elasticsearch features the
+// algorithms of a query suite use, so their definitions can be reviewed and
+// compared without reading every es_query body.
+package queryfeatures
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// DefaultMultiMatchType is the multi_match type Elasticsearch uses when a
+// query doesn't set one
+const DefaultMultiMatchType = "best_fields"
+
+// clauses are the query DSL clause names counted as clauses; other keys
+// are parameters or field names
+var clauses = map[string]bool{
+	"bool": true, "boosting": true, "constant_score": true, "dis_max": true,
+	"function_score": true, "script_score": true, "nested": true,
+	"has_child": true, "has_parent": true, "match": true, "match_phrase": true,
+	"match_phrase_prefix": true, "match_bool_prefix": true, "multi_match": true,
+	"combined_fields": true, "query_string": true, "simple_query_string": true,
+	"term": true, "terms": true, "terms_set": true, "range": true,
+	"exists": true, "prefix": true, "wildcard": true, "regexp": true,
+	"fuzzy": true, "ids": true, "match_all": true, "match_none": true,
+	"rank_feature": true, "distance_feature": true, "more_like_this": true,
+	"geo_distance": true, "geo_bounding_box": true, "geo_shape": true,
+	"knn": true, "pinned": true,
+}
+
+// Features summarises the queries of one algorithm. Counts are numbers of
+// queries using the feature.
+type Features struct {
+	Algorithm       string         `json:"algorithm"`
+	Queries         int            `json:"queries"`
+	Clauses         map[string]int `json:"clauses"`                     // Clause type → queries using it
+	MultiMatchTypes map[string]int `json:"multi_match_types,omitempty"` // multi_match type → queries using it
+	FieldBoosts     []string       `json:"field_boosts,omitempty"`      // Distinct field^boost weightings, e.g. "title^2"
+	Boosted         int            `json:"boosted"`                     // Queries with a clause boost or field weighting
+	FunctionScore   int            `json:"function_score"`              // Queries with function_score or script_score
+	Filtered        int            `json:"filtered"`                    // Queries with filter, must_not or post_filter
+	Fuzzy           int            `json:"fuzzy"`                       // Queries with fuzziness or a fuzzy clause
+	AvgClauses      float64        `json:"avg_clauses"`                 // Mean clauses per query
+	MaxDepth        int            `json:"max_depth"`                   // Deepest nesting of clauses
+	Script          bool           `json:"script"`                      // The algorithm reranks with a script
+}
+
+// ClauseTypes lists the algorithm's clause types, most used first
+func (f Features) ClauseTypes() []string {
+	types := make([]string, 0, len(f.Clauses))
+	for t := range f.Clauses {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if f.Clauses[types[i]] != f.Clauses[types[j]] {
+			return f.Clauses[types[i]] > f.Clauses[types[j]]
+		}
+		return types[i] < types[j]
+	})
+	return types
+}
+
+// Analyse summarises the features of each algorithm, in file order
+func Analyse(algorithms []models.AlgorithmConfig) []Features {
+	report := make([]Features, len(algorithms))
+	for i, alg := range algorithms {
+		f := Features{
+			Algorithm:       alg.Name,
+			Queries:         len(alg.Queries),
+			Clauses:         make(map[string]int),
+			MultiMatchTypes: make(map[string]int),
+			Script:          alg.Script != nil,
+		}
+		fieldBoosts := make(map[string]bool)
+		total := 0
+		for _, qc := range alg.Queries {
+			s := scanBody(qc.ESQuery, fieldBoosts)
+
+			for c := range s.clauses {
+				f.Clauses[c]++
+			}
+			for t := range s.multiMatch {
+				f.MultiMatchTypes[t]++
+			}
+			total += s.count
+			f.MaxDepth = max(f.MaxDepth, s.depth)
+			if s.boosted {
+				f.Boosted++
+			}
+			if s.clauses["function_score"] || s.clauses["script_score"] {
+				f.FunctionScore++
+			}
+			if s.filtered {
+				f.Filtered++
+			}
+			if s.fuzzy {
+				f.Fuzzy++
+			}
+		}
+		if f.Queries > 0 {
+			f.AvgClauses = float64(total) / float64(f.Queries)
+		}
+		for fb := range fieldBoosts {
+			f.FieldBoosts = append(f.FieldBoosts, fb)
+		}
+		sort.Strings(f.FieldBoosts)
+		report[i] = f
+	}
+	return report
+}
+
+// scanBody scans an es_query body, adding its field weightings to
+// fieldBoosts
+func scanBody(body map[string]interface{}, fieldBoosts map[string]bool) scan {
+	s := scan{clauses: make(map[string]bool), multiMatch: make(map[string]bool), fieldBoosts: fieldBoosts}
+	s.walk(body["query"], 0)
+	if _, ok := body["post_filter"]; ok {
+		s.filtered = true
+	}
+	return s
+}
+
+// scan collects the features of one es_query body
+type scan struct {
+	clauses     map[string]bool
+	multiMatch  map[string]bool
+	fieldBoosts map[string]bool
+	count       int
+	depth       int
+	boosted     bool
+	filtered    bool
+	fuzzy       bool
+}
+
+// walk visits every value of a body; depth counts the clauses enclosing
+// node
+func (s *scan) walk(node interface{}, depth int) {
+	switch v := node.(type) {
+	case []interface{}:
+		for _, child := range v {
+			s.walk(child, depth)
+		}
+	case map[string]interface{}:
+		for key, child := range v {
+			childDepth := depth
+			switch key {
+			case "boost", "weight":
+				s.boosted = true
+			case "fuzziness":
+				s.fuzzy = true
+			case "fields":
+				s.scanFields(child)
+			}
+			if body, ok := child.(map[string]interface{}); ok && clauses[key] {
+				s.clause(key, body)
+				childDepth = depth + 1
+				s.depth = max(s.depth, childDepth)
+			}
+			s.walk(child, childDepth)
+		}
+	}
+}
+
+// clause records one query clause. Only the filters of bool, constant_score
+// and knn clauses narrow the results; a function_score function's filter
+// just picks the documents it scores.
+func (s *scan) clause(name string, body map[string]interface{}) {
+	s.clauses[name] = true
+	s.count++
+	switch name {
+	case "bool":
+		_, filter := body["filter"]
+		_, mustNot := body["must_not"]
+		s.filtered = s.filtered || filter || mustNot
+	case "constant_score", "knn":
+		if _, ok := body["filter"]; ok {
+			s.filtered = true
+		}
+	case "fuzzy":
+		s.fuzzy = true
+	case "multi_match":
+		t, _ := body["type"].(string)
+		if t == "" {
+			t = DefaultMultiMatchType
+		}
+		s.multiMatch[t] = true
+	}
+}
+
+// scanFields records the field weightings of a fields list, e.g. "title^2"
+func (s *scan) scanFields(fields interface{}) {
+	list, ok := fields.([]interface{})
+	if !ok {
+		return
+	}
+	for _, f := range list {
+		if name, ok := f.(string); ok && strings.Contains(name, "^") {
+			s.fieldBoosts[name] = true
+			s.boosted = true
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package queryfeatures
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func body(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	var b map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &b); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestAnalyse(t *testing.T) {
+	algs := []models.AlgorithmConfig{
+		{Name: "bm25", Queries: []models.QueryConfig{
+			{Query: "a", ESQuery: body(t, `{"query": {"multi_match": {"query": "a", "fields": ["title^2", "body"]}}, "size": 10}`)},
+			{Query: "b", ESQuery: body(t, `{"query": {"match": {"body": {"query": "b", "fuzziness": "AUTO"}}}}`)},
+		}},
+		{Name: "recency", Script: &models.ScriptConfig{}, Queries: []models.QueryConfig{
+			{Query: "c", ESQuery: body(t, `{
+				"query": {"function_score": {
+					"query": {"bool": {
+						"must": [{"multi_match": {"query": "c", "type": "cross_fields", "fields": ["title^3"]}}],
+						"filter": [{"term": {"type": "bulletin"}}]
+					}},
+					"functions": [{"gauss": {"date": {"origin": "now", "scale": "30d"}}, "weight": 2}]
+				}},
+				"aggs": {"types": {"terms": {"field": "type"}}}
+			}`)},
+		}},
+	}
+
+	report := Analyse(algs)
+	if len(report) != 2 {
+		t.Fatalf("Analyse() returned %d algorithms, want 2", len(report))
+	}
+
+	bm25 := report[0]
+	if bm25.Queries != 2 || bm25.AvgClauses != 1 || bm25.MaxDepth != 1 {
+		t.Errorf("bm25 = %+v, want 2 queries of one clause each", bm25)
+	}
+	if !reflect.DeepEqual(bm25.Clauses, map[string]int{"multi_match": 1, "match": 1}) {
+		t.Errorf("bm25 clauses = %v", bm25.Clauses)
+	}
+	if !reflect.DeepEqual(bm25.MultiMatchTypes, map[string]int{DefaultMultiMatchType: 1}) {
+		t.Errorf("bm25 multi_match types = %v, want the default", bm25.MultiMatchTypes)
+	}
+	if bm25.Boosted != 1 || bm25.Fuzzy != 1 || bm25.Filtered != 0 || bm25.FunctionScore != 0 || bm25.Script {
+		t.Errorf("bm25 = %+v, want one boosted and one fuzzy query", bm25)
+	}
+
+	recency := report[1]
+	if !reflect.DeepEqual(recency.ClauseTypes(), []string{"bool", "function_score", "multi_match", "term"}) {
+		t.Errorf("recency clause types = %v; aggregations must not count", recency.ClauseTypes())
+	}
+	if recency.AvgClauses != 4 || recency.MaxDepth != 3 {
+		t.Errorf("recency clauses = %.1f, depth = %d, want 4 and 3", recency.AvgClauses, recency.MaxDepth)
+	}
+	if recency.Filtered != 1 || recency.FunctionScore != 1 || recency.Boosted != 1 || !recency.Script {
+		t.Errorf("recency = %+v, want a filtered, boosted function_score query with a script", recency)
+	}
+	if !reflect.DeepEqual(recency.MultiMatchTypes, map[string]int{"cross_fields": 1}) {
+		t.Errorf("recency multi_match types = %v", recency.MultiMatchTypes)
+	}
+	if !reflect.DeepEqual(recency.FieldBoosts, []string{"title^3"}) {
+		t.Errorf("recency field boosts = %v", recency.FieldBoosts)
+	}
+}
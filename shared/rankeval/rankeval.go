@@ -0,0 +1,231 @@
+package rankeval
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// Supported metric names
+const (
+	MetricDCG       = "dcg"
+	MetricERR       = "err"
+	MetricPrecision = "precision"
+	MetricRecall    = "recall"
+	MetricMRR       = "mrr"
+)
+
+// Result holds the _rank_eval output for one algorithm
+type Result struct {
+	Algorithm string       `json:"algorithm"`
+	Metric    string       `json:"metric"`
+	K         int          `json:"k"`
+	Score     float64      `json:"score"`
+	Queries   []QueryScore `json:"queries"`
+	Skipped   []string     `json:"skipped,omitempty"` // Queries without judgments
+	Failures  []string     `json:"failures,omitempty"`
+}
+
+// QueryScore holds the _rank_eval metric for a single query
+type QueryScore struct {
+	QueryID     string  `json:"query_id"`
+	Query       string  `json:"query"`
+	Score       float64 `json:"score"`
+	UnratedDocs int     `json:"unrated_docs"`
+}
+
+// Evaluator submits the query suite and judgments to Elasticsearch's
+// rank evaluation API
+type Evaluator struct {
+	client *elasticsearch.Client
+	index  string
+	metric string
+	k      int
+}
+
+// NewEvaluator creates an evaluator for the given metric and cut-off
+func NewEvaluator(client *elasticsearch.Client, index, metric string, k int) (*Evaluator, error) {
+	metric = strings.ToLower(strings.TrimSpace(metric))
+	switch metric {
+	case MetricDCG, MetricERR, MetricPrecision, MetricRecall, MetricMRR:
+	default:
+		return nil, fmt.Errorf("unsupported rank eval metric: %s", metric)
+	}
+	if k <= 0 {
+		k = 10
+	}
+
+	return &Evaluator{
+		client: client,
+		index:  index,
+		metric: metric,
+		k:      k,
+	}, nil
+}
+
+// CheckAlgorithms reports an error for algorithms _rank_eval can't score.
+// A federated algorithm merges hits from several indices, which _rank_eval
+// has no way to do.
+func CheckAlgorithms(algorithms []models.AlgorithmConfig) error {
+	for _, alg := range algorithms {
+		if alg.Federation != nil {
+			return fmt.Errorf("algorithm %s is federated across indices, which rank eval can't score", alg.Name)
+		}
+	}
+	return nil
+}
+
+// Evaluate runs one _rank_eval request per algorithm. Judgments are given
+// by URI, so uriToID maps them onto the document IDs Elasticsearch rates.
+func (e *Evaluator) Evaluate(ctx context.Context, algorithms []models.AlgorithmConfig,
+	judgments models.Judgments, uriToID map[string]string) ([]Result, error) {
+	if err := CheckAlgorithms(algorithms); err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(algorithms))
+
+	for _, alg := range algorithms {
+		body, result := e.buildRequest(alg, judgments, uriToID)
+		if len(result.Queries) == 0 {
+			results = append(results, result)
+			continue
+		}
+
+		response, err := e.client.RankEval(ctx, e.index, body)
+		if err != nil {
+			return nil, fmt.Errorf("rank eval for %s: %w", alg.Name, err)
+		}
+
+		result.Score = response.MetricScore
+		for i := range result.Queries {
+			detail := response.Details[result.Queries[i].QueryID]
+			result.Queries[i].Score = detail.MetricScore
+			result.Queries[i].UnratedDocs = len(detail.UnratedDocs)
+		}
+		for id := range response.Failures {
+			result.Failures = append(result.Failures, id)
+		}
+		sort.Strings(result.Failures)
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (e *Evaluator) buildRequest(alg models.AlgorithmConfig, judgments models.Judgments,
+	uriToID map[string]string) (map[string]interface{}, Result) {
+	result := Result{
+		Algorithm: alg.Name,
+		Metric:    e.metric,
+		K:         e.k,
+	}
+
+	requests := make([]interface{}, 0, len(alg.Queries))
+	for _, qc := range alg.Queries {
+		grades := judgments.ForQuery(qc.StableID(), qc.Query)
+
+		ratings := make([]interface{}, 0, len(grades))
+		for _, uri := range sortedURIs(grades) {
+			id, ok := uriToID[uri]
+			if !ok {
+				continue
+			}
+			ratings = append(ratings, map[string]interface{}{
+				"_index": e.index,
+				"_id":    id,
+				"rating": grades[uri],
+			})
+		}
+
+		if len(ratings) == 0 {
+			result.Skipped = append(result.Skipped, qc.Query)
+			continue
+		}
+
+		requests = append(requests, map[string]interface{}{
+			"id":      qc.StableID(),
+			"request": searchRequest(qc.ESQuery),
+			"ratings": ratings,
+		})
+		result.Queries = append(result.Queries, QueryScore{
+			QueryID: qc.StableID(),
+			Query:   qc.Query,
+		})
+	}
+
+	body := map[string]interface{}{
+		"requests": requests,
+		"metric":   e.metricDefinition(judgments.MaxGrade()),
+	}
+
+	return body, result
+}
+
+func (e *Evaluator) metricDefinition(maxGrade int) map[string]interface{} {
+	switch e.metric {
+	case MetricERR:
+		if maxGrade < 1 {
+			maxGrade = 1
+		}
+		return map[string]interface{}{
+			"expected_reciprocal_rank": map[string]interface{}{
+				"maximum_relevance": maxGrade,
+				"k":                 e.k,
+			},
+		}
+	case MetricPrecision:
+		return map[string]interface{}{
+			"precision": map[string]interface{}{
+				"k":                         e.k,
+				"relevant_rating_threshold": 1,
+			},
+		}
+	case MetricRecall:
+		return map[string]interface{}{
+			"recall": map[string]interface{}{
+				"k":                         e.k,
+				"relevant_rating_threshold": 1,
+			},
+		}
+	case MetricMRR:
+		return map[string]interface{}{
+			"mean_reciprocal_rank": map[string]interface{}{
+				"k":                         e.k,
+				"relevant_rating_threshold": 1,
+			},
+		}
+	default:
+		return map[string]interface{}{
+			"dcg": map[string]interface{}{
+				"k":         e.k,
+				"normalize": true,
+			},
+		}
+	}
+}
+
+// searchRequest strips the paging options _rank_eval controls through k
+func searchRequest(esQuery map[string]interface{}) map[string]interface{} {
+	req := make(map[string]interface{}, len(esQuery))
+	for key, v := range esQuery {
+		if key == "size" || key == "from" {
+			continue
+		}
+		req[key] = v
+	}
+	return req
+}
+
+func sortedURIs(grades map[string]int) []string {
+	uris := make([]string, 0, len(grades))
+	for uri := range grades {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	return uris
+}
@@ -0,0 +1,102 @@
+package rankeval
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestEvaluator_Evaluate(t *testing.T) {
+	var received struct {
+		Requests []struct {
+			ID      string                 `json:"id"`
+			Request map[string]interface{} `json:"request"`
+			Ratings []struct {
+				ID     string `json:"_id"`
+				Rating int    `json:"rating"`
+			} `json:"ratings"`
+		} `json:"requests"`
+		Metric map[string]interface{} `json:"metric"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"metric_score":0.75,"details":{"inflation":{"metric_score":0.75,"unrated_docs":[{"_index":"idx","_id":"9"}]}},"failures":{}}`))
+	}))
+	defer server.Close()
+
+	client, err := elasticsearch.NewClient(elasticsearch.Config{URL: server.URL})
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+
+	evaluator, err := NewEvaluator(client, "idx", "DCG", 5)
+	if err != nil {
+		t.Fatalf("create evaluator: %v", err)
+	}
+
+	algorithms := []models.AlgorithmConfig{
+		{
+			Name: "bm25",
+			Queries: []models.QueryConfig{
+				{Query: "inflation", ESQuery: map[string]interface{}{"query": "q", "size": 20}},
+				{Query: "gdp", ESQuery: map[string]interface{}{"query": "q"}},
+			},
+		},
+	}
+	judgments := models.Judgments{"inflation": {"/cpi": 3, "/unknown": 1}}
+	uriToID := map[string]string{"/cpi": "1"}
+
+	results, err := evaluator.Evaluate(context.Background(), algorithms, judgments, uriToID)
+	if err != nil {
+		t.Fatalf("evaluate: %v", err)
+	}
+
+	if len(received.Requests) != 1 || received.Requests[0].ID != "inflation" {
+		t.Fatalf("expected one request for inflation, got %+v", received.Requests)
+	}
+	if _, hasSize := received.Requests[0].Request["size"]; hasSize {
+		t.Error("expected size to be stripped from request")
+	}
+	if len(received.Requests[0].Ratings) != 1 || received.Requests[0].Ratings[0].ID != "1" {
+		t.Errorf("expected a single rating for document 1, got %+v", received.Requests[0].Ratings)
+	}
+	if _, ok := received.Metric["dcg"]; !ok {
+		t.Errorf("expected dcg metric, got %v", received.Metric)
+	}
+
+	r := results[0]
+	if r.Score != 0.75 || r.Queries[0].Score != 0.75 || r.Queries[0].UnratedDocs != 1 {
+		t.Errorf("unexpected result: %+v", r)
+	}
+	if len(r.Skipped) != 1 || r.Skipped[0] != "gdp" {
+		t.Errorf("expected gdp to be skipped, got %v", r.Skipped)
+	}
+}
+
+func TestCheckAlgorithms(t *testing.T) {
+	federated := &models.FederationConfig{Indices: []models.FederatedIndex{{Index: "{index}"}, {Index: "topics"}}}
+	tests := []struct {
+		name       string
+		algorithms []models.AlgorithmConfig
+		wantErr    bool
+	}{
+		{"single index", []models.AlgorithmConfig{{Name: "bm25"}}, false},
+		{"federated", []models.AlgorithmConfig{{Name: "bm25"}, {Name: "all", Federation: federated}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckAlgorithms(tt.algorithms); (err != nil) != tt.wantErr {
+				t.Errorf("CheckAlgorithms() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
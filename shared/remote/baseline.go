@@ -0,0 +1,293 @@
+package remote
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dis-search-test-bed/shared/lock"
+)
+
+// PointerName is the object naming the published baseline. It is written
+// after the run's archive, so it never names an archive that isn't there.
+const PointerName = "baseline.json"
+
+// Pointer describes the published baseline run
+type Pointer struct {
+	RunID    string    `json:"run_id"`
+	Archive  string    `json:"archive"` // Object holding the run folder as a .tar.gz
+	SHA256   string    `json:"sha256"`  // Hash of the archive, "sha256:<hex>"
+	Size     int64     `json:"size"`
+	PushedAt time.Time `json:"pushed_at"`
+	PushedBy string    `json:"pushed_by,omitempty"`
+}
+
+// Current returns the published baseline, or an error wrapping ErrNotFound
+// if none has been pushed
+func Current(ctx context.Context, store Store) (Pointer, error) {
+	r, err := store.Get(ctx, PointerName)
+	if err != nil {
+		return Pointer{}, err
+	}
+	defer r.Close()
+
+	var p Pointer
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return Pointer{}, fmt.Errorf("decode %s: %w", PointerName, err)
+	}
+	if p.RunID == "" || p.Archive == "" {
+		return Pointer{}, fmt.Errorf("%s at %s names no run", PointerName, store)
+	}
+	return p, nil
+}
+
+// Push publishes a run folder as the baseline: the folder is uploaded as an
+// archive named after the run and its content hash, then the pointer is
+// replaced. Re-pushing a changed run uploads a new archive rather than
+// overwriting the one the pointer still names, so a concurrent Pull never
+// reads an archive that doesn't match its hash. An unchanged run that is
+// already published is not uploaded again, and false is returned.
+func Push(ctx context.Context, store Store, runFolder, pushedBy string, now time.Time) (Pointer, bool, error) {
+	tmp, err := os.CreateTemp("", "baseline-*.tar.gz")
+	if err != nil {
+		return Pointer{}, false, fmt.Errorf("create archive: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	h := sha256.New()
+	if err := writeArchive(io.MultiWriter(tmp, h), runFolder); err != nil {
+		return Pointer{}, false, err
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return Pointer{}, false, fmt.Errorf("archive size: %w", err)
+	}
+
+	runID := filepath.Base(runFolder)
+	sum := hashString(h)
+	p := Pointer{
+		RunID:    runID,
+		Archive:  archiveName(runID, sum),
+		SHA256:   sum,
+		Size:     size,
+		PushedAt: now.UTC(),
+		PushedBy: pushedBy,
+	}
+
+	current, err := Current(ctx, store)
+	switch {
+	case err == nil && current.RunID == p.RunID && current.SHA256 == p.SHA256:
+		return current, false, nil
+	case err != nil && !errors.Is(err, ErrNotFound):
+		return Pointer{}, false, err
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return Pointer{}, false, fmt.Errorf("rewind archive: %w", err)
+	}
+	if err := store.Put(ctx, p.Archive, tmp); err != nil {
+		return Pointer{}, false, err
+	}
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return Pointer{}, false, fmt.Errorf("encode %s: %w", PointerName, err)
+	}
+	if err := store.Put(ctx, PointerName, bytes.NewReader(append(data, '\n'))); err != nil {
+		return Pointer{}, false, err
+	}
+	return p, true, nil
+}
+
+// Pull fetches the published baseline into baseDir and returns its pointer
+// and run folder. A run already in baseDir is kept as it is and false is
+// returned; otherwise the archive is checked against its hash and unpacked
+// in full before the run folder appears.
+func Pull(ctx context.Context, store Store, baseDir string) (Pointer, string, bool, error) {
+	p, err := Current(ctx, store)
+	if err != nil {
+		return Pointer{}, "", false, err
+	}
+	if !validRunID(p.RunID) {
+		return Pointer{}, "", false, fmt.Errorf("%s names an invalid run %q", PointerName, p.RunID)
+	}
+
+	runFolder := filepath.Join(baseDir, p.RunID)
+	if info, err := os.Stat(runFolder); err == nil && info.IsDir() {
+		return p, runFolder, false, nil
+	}
+
+	r, err := store.Get(ctx, p.Archive)
+	if err != nil {
+		return Pointer{}, "", false, err
+	}
+	defer r.Close()
+
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		return Pointer{}, "", false, fmt.Errorf("create %s: %w", baseDir, err)
+	}
+	tmp, err := os.CreateTemp(baseDir, ".baseline-*.tar.gz")
+	if err != nil {
+		return Pointer{}, "", false, fmt.Errorf("create archive: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, h), r); err != nil {
+		return Pointer{}, "", false, fmt.Errorf("download %s: %w", p.Archive, err)
+	}
+	if got := hashString(h); got != p.SHA256 {
+		return Pointer{}, "", false, fmt.Errorf("%s is corrupt: hash %s, want %s", p.Archive, got, p.SHA256)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return Pointer{}, "", false, fmt.Errorf("rewind archive: %w", err)
+	}
+
+	staging, err := os.MkdirTemp(baseDir, "."+p.RunID+".*")
+	if err != nil {
+		return Pointer{}, "", false, fmt.Errorf("create run folder: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	if err := extractArchive(tmp, staging); err != nil {
+		return Pointer{}, "", false, fmt.Errorf("unpack %s: %w", p.Archive, err)
+	}
+	if err := os.Chmod(staging, 0755); err != nil {
+		return Pointer{}, "", false, fmt.Errorf("create run folder: %w", err)
+	}
+	if err := os.Rename(staging, runFolder); err != nil {
+		return Pointer{}, "", false, fmt.Errorf("create run folder: %w", err)
+	}
+	return p, runFolder, true, nil
+}
+
+// writeArchive writes the regular files under runFolder as a gzipped tar,
+// leaving out its lock file. Entries are in walk order with their
+// modification times, so an unchanged folder gives the same bytes.
+func writeArchive(w io.Writer, runFolder string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.WalkDir(runFolder, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() || d.Name() == lock.FileName {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(runFolder, p)
+		if err != nil {
+			return err
+		}
+
+		hdr := &tar.Header{
+			Name:    filepath.ToSlash(rel),
+			Mode:    0644,
+			Size:    info.Size(),
+			ModTime: info.ModTime().UTC().Truncate(time.Second),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("archive %s: %w", runFolder, err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("archive %s: %w", runFolder, err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("archive %s: %w", runFolder, err)
+	}
+	return nil
+}
+
+// extractArchive unpacks a gzipped tar of regular files into dir, refusing
+// entries that would land outside it
+func extractArchive(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(hdr.Name)
+		if !fs.ValidPath(name) {
+			return fmt.Errorf("unsafe entry %q", hdr.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, tr); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		if err := os.Chtimes(target, hdr.ModTime, hdr.ModTime); err != nil {
+			return err
+		}
+	}
+}
+
+// archiveName names a run's archive after the run and the first 12 hex
+// digits of its hash, e.g. run_2024-01-15_10-30-00.412-3f2a9c81d04e.tar.gz
+func archiveName(runID, sum string) string {
+	return runID + "-" + strings.TrimPrefix(sum, "sha256:")[:12] + ".tar.gz"
+}
+
+// validRunID reports whether a published run ID is a plain folder name
+func validRunID(id string) bool {
+	return id != "" && id != "." && id != ".." && !strings.ContainsAny(id, `/\`)
+}
+
+func hashString(h hash.Hash) string {
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
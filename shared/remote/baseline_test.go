@@ -0,0 +1,148 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dis-search-test-bed/shared/lock"
+)
+
+func TestPushPull(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)
+	store, err := Open(t.TempDir(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, _, err := Pull(ctx, store, t.TempDir()); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Pull() before any push error = %v, want ErrNotFound", err)
+	}
+
+	runFolder := filepath.Join(t.TempDir(), "run_2024-01-02_09-00-00")
+	files := map[string]string{
+		"results.json":               `[{"query":"inflation"}]`,
+		"manifest.json":              `{"run_id":"run_2024-01-02_09-00-00"}`,
+		"comparison/report.md":       "# Report",
+		lock.FileName:                "",
+		"corpora/small.json":         "{}",
+		"comparison/nested/deep.txt": "deep",
+	}
+	for name, content := range files {
+		path := filepath.Join(runFolder, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pushed, ok, err := Push(ctx, store, runFolder, "alice", at)
+	if err != nil || !ok {
+		t.Fatalf("Push() = %v, %v, want a push", ok, err)
+	}
+	if pushed.RunID != "run_2024-01-02_09-00-00" || !strings.HasPrefix(pushed.SHA256, "sha256:") || pushed.PushedBy != "alice" {
+		t.Errorf("Push() pointer = %+v", pushed)
+	}
+	if _, ok, err := Push(ctx, store, runFolder, "bob", at.Add(time.Hour)); err != nil || ok {
+		t.Errorf("Push() of an unchanged run = %v, %v, want it skipped", ok, err)
+	}
+
+	baseDir := t.TempDir()
+	pulled, folder, downloaded, err := Pull(ctx, store, baseDir)
+	if err != nil || !downloaded {
+		t.Fatalf("Pull() = %v, %v, want a download", downloaded, err)
+	}
+	if pulled != pushed || folder != filepath.Join(baseDir, pushed.RunID) {
+		t.Errorf("Pull() = %+v in %s, want %+v", pulled, folder, pushed)
+	}
+	for name, content := range files {
+		got, err := os.ReadFile(filepath.Join(folder, name))
+		if name == lock.FileName {
+			if err == nil {
+				t.Errorf("the lock file was published")
+			}
+			continue
+		}
+		if err != nil || string(got) != content {
+			t.Errorf("%s = %q, %v, want %q", name, got, err, content)
+		}
+	}
+
+	if _, _, downloaded, err := Pull(ctx, store, baseDir); err != nil || downloaded {
+		t.Errorf("Pull() of a run already present = %v, %v, want it kept", downloaded, err)
+	}
+}
+
+func TestPull_Corrupt(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, _ := Open(dir, Options{})
+
+	runFolder := filepath.Join(t.TempDir(), "run_2024-01-02_09-00-00")
+	if err := os.MkdirAll(runFolder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(runFolder, "results.json"), []byte("[]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, _, err := Push(ctx, store, runFolder, "", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, p.Archive), []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	baseDir := t.TempDir()
+	if _, _, _, err := Pull(ctx, store, baseDir); err == nil || !strings.Contains(err.Error(), "corrupt") {
+		t.Fatalf("Pull() of a tampered archive error = %v, want corrupt", err)
+	}
+	if entries, _ := os.ReadDir(baseDir); len(entries) != 0 {
+		t.Errorf("Pull() left %d entries behind", len(entries))
+	}
+}
+
+func TestPush_ChangedRunKeepsArchive(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, _ := Open(dir, Options{})
+
+	runFolder := filepath.Join(t.TempDir(), "run_2024-01-02_09-00-00")
+	if err := os.MkdirAll(runFolder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	results := filepath.Join(runFolder, "results.json")
+	if err := os.WriteFile(results, []byte("[]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	first, _, err := Push(ctx, store, runFolder, "", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "run_2024-01-02_09-00-00-" + first.SHA256[len("sha256:"):][:12] + ".tar.gz"; first.Archive != want {
+		t.Errorf("Archive = %q, want %q", first.Archive, want)
+	}
+
+	if err := os.WriteFile(results, []byte(`[{"query":"inflation"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	second, ok, err := Push(ctx, store, runFolder, "", time.Now())
+	if err != nil || !ok {
+		t.Fatalf("Push() of a changed run = %v, %v, want a push", ok, err)
+	}
+	if second.Archive == first.Archive {
+		t.Errorf("changed run reused archive %s", first.Archive)
+	}
+	for _, p := range []Pointer{first, second} {
+		if _, err := os.Stat(filepath.Join(dir, p.Archive)); err != nil {
+			t.Errorf("archive %s: %v", p.Archive, err)
+		}
+	}
+}
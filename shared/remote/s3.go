@@ -0,0 +1,74 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// s3Store keeps objects in an S3 bucket through the aws CLI, so the usual
+// AWS profiles, SSO sessions and CI role credentials all apply
+type s3Store struct {
+	bucket  string
+	prefix  string
+	command string
+}
+
+func (s *s3Store) Put(ctx context.Context, name string, data io.Reader) error {
+	cmd := exec.CommandContext(ctx, s.command, "s3", "cp", "--only-show-errors", "-", s.object(name))
+	cmd.Stdin = data
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("upload %s: %s", name, commandError(err, &stderr))
+	}
+	return nil
+}
+
+func (s *s3Store) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	cmd := exec.CommandContext(ctx, s.command, "s3", "cp", "--only-show-errors", s.object(name), "-")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if isS3NotFound(stderr.String()) {
+			return nil, fmt.Errorf("%s %w at %s", name, ErrNotFound, s)
+		}
+		return nil, fmt.Errorf("download %s: %s", name, commandError(err, &stderr))
+	}
+	return io.NopCloser(&stdout), nil
+}
+
+func (s *s3Store) object(name string) string {
+	if s.prefix == "" {
+		return "s3://" + s.bucket + "/" + name
+	}
+	return "s3://" + s.bucket + "/" + s.prefix + "/" + name
+}
+
+func (s *s3Store) String() string {
+	if s.prefix == "" {
+		return "s3://" + s.bucket
+	}
+	return "s3://" + s.bucket + "/" + s.prefix
+}
+
+// isS3NotFound reports whether the aws CLI failed because the object
+// doesn't exist
+func isS3NotFound(stderr string) bool {
+	return strings.Contains(stderr, "(404)") ||
+		strings.Contains(stderr, "NoSuchKey") ||
+		strings.Contains(stderr, "does not exist")
+}
+
+// commandError describes a failed command by what it printed, or by how it
+// failed when it printed nothing
+func commandError(err error, stderr *bytes.Buffer) string {
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return msg
+	}
+	return err.Error()
+}
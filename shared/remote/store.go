@@ -0,0 +1,200 @@
+// Package remote publishes files to a location shared by the team and CI,
+// such as a network directory, an Artifactory repository or an S3 bucket
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned when a store holds no object of the given name
+var ErrNotFound = errors.New("not found")
+
+// Store reads and writes named objects at a shared location
+type Store interface {
+	// Put stores an object, replacing any earlier one of the same name
+	Put(ctx context.Context, name string, data io.Reader) error
+	// Get returns an object's contents, or ErrNotFound if there is none
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	// String describes the location, without credentials, for messages
+	String() string
+}
+
+// Options holds the credentials and tools a store may need
+type Options struct {
+	Token      string       // Bearer token sent to http(s) locations
+	HTTPClient *http.Client // Defaults to http.DefaultClient
+	AWSCommand string       // aws CLI used for s3:// locations; defaults to "aws"
+}
+
+// Open returns the store for a location: a directory path or file:// URL,
+// an http(s):// URL taking PUT and GET (e.g. an Artifactory generic
+// repository) or an s3:// URL
+func Open(location string, opts Options) (Store, error) {
+	if location == "" {
+		return nil, errors.New("no remote location given")
+	}
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme == "" || len(u.Scheme) == 1 {
+		// A plain path, including Windows drive letters
+		return &dirStore{dir: location}, nil
+	}
+
+	switch u.Scheme {
+	case "file":
+		return &dirStore{dir: filepath.FromSlash(u.Path)}, nil
+	case "http", "https":
+		client := opts.HTTPClient
+		if client == nil {
+			client = http.DefaultClient
+		}
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		return &httpStore{base: u, token: opts.Token, client: client}, nil
+	case "s3":
+		if u.Host == "" {
+			return nil, fmt.Errorf("s3 location %s has no bucket", location)
+		}
+		command := opts.AWSCommand
+		if command == "" {
+			command = "aws"
+		}
+		return &s3Store{
+			bucket:  u.Host,
+			prefix:  strings.Trim(u.Path, "/"),
+			command: command,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported remote scheme %q (use a path, file://, http(s):// or s3://)", u.Scheme)
+	}
+}
+
+// dirStore keeps objects as files in a directory, e.g. a network share
+type dirStore struct {
+	dir string
+}
+
+func (s *dirStore) Put(ctx context.Context, name string, data io.Reader) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("create %s: %w", s.dir, err)
+	}
+
+	// Write beside the target and rename, so readers never see half a file
+	tmp, err := os.CreateTemp(s.dir, "."+name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create %s: %w", name, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, name)); err != nil {
+		return fmt.Errorf("replace %s: %w", name, err)
+	}
+	return nil
+}
+
+func (s *dirStore) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	f, err := os.Open(filepath.Join(s.dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s %w in %s", name, ErrNotFound, s.dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", name, err)
+	}
+	return f, nil
+}
+
+func (s *dirStore) String() string {
+	return s.dir
+}
+
+// httpStore keeps objects under a base URL, uploaded with PUT and fetched
+// with GET
+type httpStore struct {
+	base   *url.URL
+	token  string
+	client *http.Client
+}
+
+func (s *httpStore) Put(ctx context.Context, name string, data io.Reader) error {
+	req, err := s.request(ctx, http.MethodPut, name, data)
+	if err != nil {
+		return err
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("upload %s: %w", name, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("upload %s: %s", name, responseError(res))
+	}
+	return nil
+}
+
+func (s *httpStore) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	req, err := s.request(ctx, http.MethodGet, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", name, err)
+	}
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		res.Body.Close()
+		return nil, fmt.Errorf("%s %w at %s", name, ErrNotFound, s)
+	case res.StatusCode < 200 || res.StatusCode > 299:
+		defer res.Body.Close()
+		return nil, fmt.Errorf("download %s: %s", name, responseError(res))
+	}
+	return res.Body, nil
+}
+
+func (s *httpStore) request(ctx context.Context, method, name string, body io.Reader) (*http.Request, error) {
+	u := *s.base
+	u.Path += "/" + name
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("create request for %s: %w", name, err)
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	return req, nil
+}
+
+func (s *httpStore) String() string {
+	u := *s.base
+	u.User = nil
+	return u.String()
+}
+
+// responseError describes a failed response by its status and the start of
+// its body
+func responseError(res *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+	if msg := strings.TrimSpace(string(body)); msg != "" {
+		return fmt.Sprintf("%s: %s", res.Status, msg)
+	}
+	return res.Status
+}
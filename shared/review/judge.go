@@ -0,0 +1,178 @@
+package review
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// MaxGrade is the highest relevance grade an analyst can give: 0 is not
+// relevant, 3 is a perfect match
+const MaxGrade = 3
+
+// DefaultJudgeTopN is the number of results per algorithm pooled for judging
+const DefaultJudgeTopN = 10
+
+// JudgeOptions control a judging session
+type JudgeOptions struct {
+	TopN    int                                    // Results per algorithm to pool for each query
+	Snippet func(uri, query string) string         // Optional document text shown under each result
+	Save    func(judgments models.Judgments) error // Called after each query, so quitting loses nothing
+}
+
+// poolEntry is one result to judge, with the title it was first seen with
+type poolEntry struct {
+	uri, title string
+}
+
+// pool is the results to judge for one query, across every algorithm
+type pool struct {
+	key, query string
+	entries    []poolEntry
+}
+
+// Judge walks an analyst through the results of a run, query by query,
+// asking for a grade from 0 to MaxGrade for each result. The top results of
+// every algorithm are pooled, so a URI returned by several is judged once.
+// Results already in judgments are skipped, so a session can be resumed.
+// Grades are read one per line from in; an empty line skips a result and
+// q ends the session. It returns the number of grades given.
+func Judge(in io.Reader, out io.Writer, results []models.QueryResults, judgments models.Judgments, opts JudgeOptions) (int, error) {
+	if opts.TopN <= 0 {
+		opts.TopN = DefaultJudgeTopN
+	}
+
+	pools := pooled(results, judgments, opts.TopN)
+	if len(pools) == 0 {
+		fmt.Fprintln(out, "Nothing left to judge")
+		return 0, nil
+	}
+
+	scanner := bufio.NewScanner(in)
+	judged := 0
+	for i, p := range pools {
+		fmt.Fprintf(out, "\n[Query %d/%d] %s (%d to judge)\n", i+1, len(pools), p.query, len(p.entries))
+
+		given := 0
+		quit := false
+		for j, entry := range p.entries {
+			fmt.Fprintf(out, "\n  %d/%d %s\n      %s\n", j+1, len(p.entries), models.SingleLine(entry.title), entry.uri)
+			if opts.Snippet != nil {
+				if snippet := opts.Snippet(entry.uri, p.query); snippet != "" {
+					fmt.Fprintf(out, "      %s\n", snippet)
+				}
+			}
+
+			grade, ok := readGrade(scanner, out)
+			if !ok {
+				quit = true
+				break
+			}
+			if grade < 0 {
+				continue
+			}
+			if judgments[p.key] == nil {
+				judgments[p.key] = make(map[string]int)
+			}
+			judgments[p.key][entry.uri] = grade
+			given++
+		}
+
+		judged += given
+		if given > 0 && opts.Save != nil {
+			if err := opts.Save(judgments); err != nil {
+				return judged, fmt.Errorf("save judgments: %w", err)
+			}
+		}
+		if quit {
+			break
+		}
+	}
+
+	return judged, scanner.Err()
+}
+
+// readGrade prompts until it reads a grade, or -1 for a skipped result. ok
+// is false when the analyst quits or the input ends.
+func readGrade(scanner *bufio.Scanner, out io.Writer) (grade int, ok bool) {
+	for {
+		fmt.Fprintf(out, "  Grade 0-%d, Enter to skip, q to quit: ", MaxGrade)
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return 0, false
+		}
+
+		answer := strings.TrimSpace(scanner.Text())
+		switch strings.ToLower(answer) {
+		case "":
+			return -1, true
+		case "q", "quit":
+			return 0, false
+		}
+		if g, err := strconv.Atoi(answer); err == nil && g >= 0 && g <= MaxGrade {
+			return g, true
+		}
+		fmt.Fprintf(out, "  %q is not a grade\n", answer)
+	}
+}
+
+// pooled groups the top n results of every algorithm by query, in suite
+// order, leaving out results that are already judged
+func pooled(results []models.QueryResults, judgments models.Judgments, n int) []pool {
+	var pools []*pool
+	byKey := make(map[string]*pool)
+	seen := make(map[string]map[string]bool)
+
+	for _, qr := range results {
+		key := judgmentKey(judgments, qr)
+		p, ok := byKey[key]
+		if !ok {
+			p = &pool{key: key, query: qr.Query}
+			byKey[key] = p
+			pools = append(pools, p)
+			seen[key] = make(map[string]bool)
+		}
+
+		existing := judgments[key]
+		for _, r := range top(qr.Results, n) {
+			if seen[key][r.URI] {
+				continue
+			}
+			seen[key][r.URI] = true
+			if _, done := existing[r.URI]; done {
+				continue
+			}
+			p.entries = append(p.entries, poolEntry{uri: r.URI, title: r.Title})
+		}
+	}
+
+	var todo []pool
+	for _, p := range pools {
+		if len(p.entries) > 0 {
+			todo = append(todo, *p)
+		}
+	}
+	return todo
+}
+
+// judgmentKey returns the key a query's judgments are stored under: the key
+// existing judgments already use, or else the query's ID, or its text if it
+// has no ID
+func judgmentKey(judgments models.Judgments, qr models.QueryResults) string {
+	if _, ok := judgments[qr.QueryID]; ok && qr.QueryID != "" {
+		return qr.QueryID
+	}
+	for key := range judgments {
+		if strings.EqualFold(key, qr.Query) {
+			return key
+		}
+	}
+	if qr.QueryID != "" {
+		return qr.QueryID
+	}
+	return qr.Query
+}
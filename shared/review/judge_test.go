@@ -0,0 +1,42 @@
+package review
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func TestJudge(t *testing.T) {
+	results := []models.QueryResults{
+		{Query: "cpi", QueryID: "cpi-q", Algorithm: "bm25", Results: results("/a", "/b", "/c")},
+		{Query: "cpi", QueryID: "cpi-q", Algorithm: "boosted", Results: results("/b", "/d")},
+		{Query: "gdp", Algorithm: "bm25", Results: results("/g")},
+	}
+	judgments := models.Judgments{"CPI": {"/a": 3}}
+
+	saves := 0
+	var out bytes.Buffer
+	// /b graded 2, /c skipped, a typo then /d graded 0, quit at /g
+	in := strings.NewReader("2\n\n7\n0\nq\n")
+	judged, err := Judge(in, &out, results, judgments, JudgeOptions{
+		TopN: 3,
+		Save: func(models.Judgments) error { saves++; return nil },
+	})
+	if err != nil {
+		t.Fatalf("Judge() error = %v", err)
+	}
+
+	want := models.Judgments{"CPI": {"/a": 3, "/b": 2, "/d": 0}}
+	if judged != 2 || !reflect.DeepEqual(judgments, want) {
+		t.Errorf("Judge() = %d, %v, want 2, %v", judged, judgments, want)
+	}
+	if saves != 1 {
+		t.Errorf("judgments saved %d times, want once after the first query", saves)
+	}
+	if !strings.Contains(out.String(), `"7" is not a grade`) {
+		t.Errorf("expected the invalid grade to be rejected, got:\n%s", out.String())
+	}
+}
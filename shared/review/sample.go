@@ -0,0 +1,206 @@
+// Package review builds lists of query results for people to spot-check.
+// Samples are stratified so that every category of query, and every amount
+// of change since the last run, gets a share of the reviewers' attention.
+package review
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// How much a query's top results changed since the previous run
+const (
+	ChangeNew       = "new"       // No previous results to compare with
+	ChangeUnchanged = "unchanged" // Same results in the same order
+	ChangeReordered = "reordered" // Same results in a different order
+	ChangeChanged   = "changed"   // Results entered or left the top
+)
+
+// Default sample settings
+const (
+	DefaultSize = 20
+	DefaultTopN = 3
+)
+
+// Options control how a sample is drawn
+type Options struct {
+	Size int   // Queries to sample
+	TopN int   // Results per query
+	Seed int64 // Same seed and runs give the same sample
+}
+
+// Item is one sampled query and its top results
+type Item struct {
+	Category string                `json:"category"` // The algorithm the query ran under
+	Query    string                `json:"query"`
+	QueryID  string                `json:"query_id,omitempty"`
+	Change   string                `json:"change"`
+	Results  []models.SearchResult `json:"results"`
+}
+
+// Sample draws up to opts.Size queries from the current run, stratified by
+// category and by change in the top results since the previous run. Strata
+// take turns, so rare strata (a category with one changed query, say) are
+// always represented rather than drowned out by large unchanged ones.
+// Queries without results are skipped.
+func Sample(current, previous []models.QueryResults, opts Options) []Item {
+	if opts.Size <= 0 {
+		opts.Size = DefaultSize
+	}
+	if opts.TopN <= 0 {
+		opts.TopN = DefaultTopN
+	}
+
+	previousByKey := make(map[string]models.QueryResults, len(previous))
+	for _, qr := range previous {
+		previousByKey[qr.Key()] = qr
+	}
+
+	strata := make(map[string][]Item)
+	for _, curr := range current {
+		if len(curr.Results) == 0 {
+			continue
+		}
+		change := ChangeNew
+		if prev, ok := curr.FindPrevious(previousByKey); ok {
+			change = classify(curr.Results, prev.Results, opts.TopN)
+		}
+		item := Item{
+			Category: curr.AlgorithmLabel(),
+			Query:    curr.Query,
+			QueryID:  curr.QueryID,
+			Change:   change,
+			Results:  top(curr.Results, opts.TopN),
+		}
+		key := item.Category + "\x00" + item.Change
+		strata[key] = append(strata[key], item)
+	}
+
+	keys := make([]string, 0, len(strata))
+	for key := range strata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	for _, key := range keys {
+		items := strata[key]
+		rng.Shuffle(len(items), func(i, j int) { items[i], items[j] = items[j], items[i] })
+	}
+
+	var sample []Item
+	for round := 0; len(sample) < opts.Size; round++ {
+		drawn := false
+		for _, key := range keys {
+			if round < len(strata[key]) && len(sample) < opts.Size {
+				sample = append(sample, strata[key][round])
+				drawn = true
+			}
+		}
+		if !drawn {
+			break
+		}
+	}
+
+	sort.SliceStable(sample, func(i, j int) bool {
+		if sample[i].Category != sample[j].Category {
+			return sample[i].Category < sample[j].Category
+		}
+		return sample[i].Query < sample[j].Query
+	})
+	return sample
+}
+
+// classify compares the top n URIs of two rankings
+func classify(current, previous []models.SearchResult, n int) string {
+	curr, prev := top(current, n), top(previous, n)
+	if len(curr) != len(prev) {
+		return ChangeChanged
+	}
+
+	inPrevious := make(map[string]bool, len(prev))
+	for _, r := range prev {
+		inPrevious[r.URI] = true
+	}
+	reordered := false
+	for i, r := range curr {
+		if !inPrevious[r.URI] {
+			return ChangeChanged
+		}
+		if r.URI != prev[i].URI {
+			reordered = true
+		}
+	}
+	if reordered {
+		return ChangeReordered
+	}
+	return ChangeUnchanged
+}
+
+func top(results []models.SearchResult, n int) []models.SearchResult {
+	if len(results) > n {
+		return results[:n]
+	}
+	return results
+}
+
+// sheetHeader names the review sheet's columns
+var sheetHeader = []string{
+	"category",
+	"query",
+	"query_id",
+	"change",
+	"rank",
+	"title",
+	"uri",
+	"content_type",
+	"relevant",
+	"notes",
+}
+
+// sheetRows lays a sample out as review sheet rows, one per result, in the
+// order of sheetHeader
+func sheetRows(sample []Item) [][]string {
+	var rows [][]string
+	for _, item := range sample {
+		for _, r := range item.Results {
+			rows = append(rows, []string{
+				item.Category,
+				item.Query,
+				item.QueryID,
+				item.Change,
+				strconv.Itoa(r.Rank),
+				models.SingleLine(r.Title),
+				r.URI,
+				r.ContentType,
+				"",
+				"",
+			})
+		}
+	}
+	return rows
+}
+
+// WriteCSV writes a sample as a review sheet with one row per result. The
+// relevant and notes columns are left empty for reviewers to fill in.
+func WriteCSV(w io.Writer, sample []Item) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(sheetHeader); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+	for _, row := range sheetRows(sample) {
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("write row: %w", err)
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,94 @@
+package review
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func results(uris ...string) []models.SearchResult {
+	out := make([]models.SearchResult, len(uris))
+	for i, uri := range uris {
+		out[i] = models.SearchResult{Rank: i + 1, URI: uri, Title: "Title " + uri}
+	}
+	return out
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  []models.SearchResult
+		previous []models.SearchResult
+		want     string
+	}{
+		{name: "unchanged", current: results("/a", "/b", "/c", "/x"), previous: results("/a", "/b", "/c", "/y"), want: ChangeUnchanged},
+		{name: "reordered", current: results("/b", "/a", "/c"), previous: results("/a", "/b", "/c"), want: ChangeReordered},
+		{name: "new result", current: results("/a", "/b", "/d"), previous: results("/a", "/b", "/c"), want: ChangeChanged},
+		{name: "fewer results", current: results("/a"), previous: results("/a", "/b"), want: ChangeChanged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classify(tt.current, tt.previous, 3); got != tt.want {
+				t.Errorf("classify() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSample_Stratified(t *testing.T) {
+	var current, previous []models.QueryResults
+	// Ten unchanged bm25 queries and one changed one
+	for _, q := range []string{"q0", "q1", "q2", "q3", "q4", "q5", "q6", "q7", "q8", "q9"} {
+		current = append(current, models.QueryResults{Query: q, Algorithm: "bm25", Results: results("/a", "/b")})
+		previous = append(previous, models.QueryResults{Query: q, Algorithm: "bm25", Results: results("/a", "/b")})
+	}
+	current = append(current,
+		models.QueryResults{Query: "moved", Algorithm: "bm25", Results: results("/c", "/a")},
+		models.QueryResults{Query: "fresh", Algorithm: "boosted", Results: results("/a")},
+		models.QueryResults{Query: "empty", Algorithm: "boosted"},
+	)
+	previous = append(previous, models.QueryResults{Query: "moved", Algorithm: "bm25", Results: results("/a", "/b")})
+
+	sample := Sample(current, previous, Options{Size: 3, Seed: 7})
+	if len(sample) != 3 {
+		t.Fatalf("Sample() returned %d items, want 3", len(sample))
+	}
+
+	changes := make(map[string]int)
+	for _, item := range sample {
+		changes[item.Category+"/"+item.Change]++
+		if item.Query == "empty" {
+			t.Errorf("query without results was sampled")
+		}
+	}
+	want := map[string]int{"bm25/changed": 1, "bm25/unchanged": 1, "boosted/new": 1}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("strata sampled = %v, want %v", changes, want)
+	}
+
+	again := Sample(current, previous, Options{Size: 3, Seed: 7})
+	if !reflect.DeepEqual(sample, again) {
+		t.Errorf("same seed gave a different sample")
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	sample := []Item{{Category: "bm25", Query: "cpi", Change: ChangeNew, Results: results("/a", "/b")}}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, sample); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("WriteCSV() wrote %d lines, want header and 2 rows", len(lines))
+	}
+	if want := "bm25,cpi,,new,2,Title /b,/b,,,"; lines[2] != want {
+		t.Errorf("row = %q, want %q", lines[2], want)
+	}
+}
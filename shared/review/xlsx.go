@@ -0,0 +1,109 @@
+package review
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// rankColumn is the review sheet column written as a number rather than text
+const rankColumn = 4
+
+// The fixed parts of a single sheet workbook
+const (
+	xlsxContentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+	xlsxRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbook = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Review" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`
+	xlsxWorkbookRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+)
+
+// WriteXLSX writes a sample as an Excel workbook with the same columns as
+// WriteCSV. The header row is frozen so it stays in view while reviewing.
+func WriteXLSX(w io.Writer, sample []Item) error {
+	zw := zip.NewWriter(w)
+
+	parts := []struct {
+		name string
+		data []byte
+	}{
+		{"[Content_Types].xml", []byte(xlsxContentTypes)},
+		{"_rels/.rels", []byte(xlsxRels)},
+		{"xl/workbook.xml", []byte(xlsxWorkbook)},
+		{"xl/_rels/workbook.xml.rels", []byte(xlsxWorkbookRels)},
+		{"xl/worksheets/sheet1.xml", worksheet(sheetRows(sample))},
+	}
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return fmt.Errorf("write %s: %w", part.name, err)
+		}
+		if _, err := f.Write(part.data); err != nil {
+			return fmt.Errorf("write %s: %w", part.name, err)
+		}
+	}
+	return zw.Close()
+}
+
+// worksheet builds the sheet XML for the header and rows, using inline
+// strings so the workbook needs no shared string table
+func worksheet(rows [][]string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	buf.WriteString(`<sheetViews><sheetView workbookViewId="0">` +
+		`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>` +
+		`</sheetView></sheetViews>`)
+	buf.WriteString(`<sheetData>`)
+
+	writeRow := func(n int, row []string, header bool) {
+		fmt.Fprintf(&buf, `<row r="%d">`, n)
+		for i, value := range row {
+			if value == "" {
+				continue
+			}
+			ref := cellRef(i, n)
+			if i == rankColumn && !header {
+				if _, err := strconv.Atoi(value); err == nil {
+					fmt.Fprintf(&buf, `<c r="%s"><v>%s</v></c>`, ref, value)
+					continue
+				}
+			}
+			fmt.Fprintf(&buf, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			_ = xml.EscapeText(&buf, []byte(value))
+			buf.WriteString(`</t></is></c>`)
+		}
+		buf.WriteString(`</row>`)
+	}
+
+	writeRow(1, sheetHeader, true)
+	for i, row := range rows {
+		writeRow(i+2, row, false)
+	}
+
+	buf.WriteString(`</sheetData></worksheet>`)
+	return buf.Bytes()
+}
+
+// cellRef names a cell from a zero-based column and one-based row, e.g. A1
+func cellRef(col, row int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name + strconv.Itoa(row)
+}
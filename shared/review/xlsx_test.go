@@ -0,0 +1,65 @@
+package review
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteXLSX(t *testing.T) {
+	sample := []Item{{Category: "bm25", Query: "cpi & <rpi>", Change: ChangeNew, Results: results("/a", "/b")}}
+
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, sample); err != nil {
+		t.Fatalf("WriteXLSX() error = %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("workbook is not a zip: %v", err)
+	}
+	parts := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(data)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("workbook has no %s", name)
+		}
+	}
+
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<c r="A1" t="inlineStr"><is><t xml:space="preserve">category</t></is></c>`,
+		`<c r="B2" t="inlineStr"><is><t xml:space="preserve">cpi &amp; &lt;rpi&gt;</t></is></c>`,
+		`<c r="E3"><v>2</v></c>`,
+		`<c r="G3" t="inlineStr"><is><t xml:space="preserve">/b</t></is></c>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet is missing %s", want)
+		}
+	}
+}
+
+func TestCellRef(t *testing.T) {
+	tests := map[string]struct{ col, row int }{
+		"A1":   {0, 1},
+		"J12":  {9, 12},
+		"Z3":   {25, 3},
+		"AA3":  {26, 3},
+		"AZ10": {51, 10},
+	}
+	for want, tt := range tests {
+		if got := cellRef(tt.col, tt.row); got != want {
+			t.Errorf("cellRef(%d, %d) = %q, want %q", tt.col, tt.row, got, want)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+// Package shardcheck explains score and rank differences caused by shards.
+// Elasticsearch scores each shard with that shard's own term statistics by
+// default, so the same documents can score and rank differently on indices
+// with a different shard count or document spread. Rerunning queries with
+// dfs_query_then_fetch (index-wide statistics) and against a single shard
+// shows whether shard-local IDF is behind a difference.
+package shardcheck
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/metrics"
+)
+
+// DefaultSample is the number of queries checked when none is given
+const DefaultSample = 10
+
+// scoreTolerance is the relative score difference treated as equal
+const scoreTolerance = 1e-6
+
+// Effects of shard-local statistics on a query
+const (
+	EffectNone    = "none"    // Scores and ranking are the same with index-wide statistics
+	EffectScores  = "scores"  // Scores change but the ranking doesn't
+	EffectRanking = "ranking" // The ranking changes with index-wide statistics
+)
+
+// Searcher runs searches with explicit shard options
+type Searcher interface {
+	SearchWith(ctx context.Context, index string, query map[string]interface{},
+		opts elasticsearch.SearchOptions) (*elasticsearch.SearchResponse, error)
+}
+
+// QueryCheck compares one query's default results with index-wide scoring
+type QueryCheck struct {
+	Query     string `json:"query"`
+	Algorithm string `json:"algorithm"`
+	Effect    string `json:"effect"`
+
+	// Agreement of the default top K with the dfs_query_then_fetch top K
+	Overlap float64 `json:"overlap"`
+	RBO     float64 `json:"rbo"`
+	Moved   int     `json:"moved"` // Documents in both top Ks at different ranks
+
+	// MaxScoreChange is the largest relative score difference between the
+	// default and dfs_query_then_fetch scores of the same document
+	MaxScoreChange float64 `json:"max_score_change"`
+
+	// ShardDistortion is the mean relative difference between the scores
+	// documents get on shard 0 alone and with index-wide statistics
+	ShardDistortion float64 `json:"shard_distortion"`
+}
+
+// Report is the outcome of checking a sample of queries
+type Report struct {
+	Index     string                    `json:"index"`
+	K         int                       `json:"k"`
+	Shards    []elasticsearch.ShardCopy `json:"shards,omitempty"`
+	DocSkew   float64                   `json:"doc_skew"`
+	Queries   []QueryCheck              `json:"queries"`
+	Reordered int                       `json:"reordered"`
+	Rescored  int                       `json:"rescored"`
+}
+
+// Checker reruns queries under different shard settings
+type Checker struct {
+	client Searcher
+	index  string
+	k      int
+}
+
+// NewChecker creates a checker comparing the top k results of each query
+func NewChecker(client Searcher, index string, k int) *Checker {
+	if k <= 0 {
+		k = metrics.DefaultK
+	}
+	return &Checker{client: client, index: index, k: k}
+}
+
+// Run checks an evenly spread sample of up to n queries across the
+// algorithms. Federated algorithms are rejected, since their hits come from
+// several indices and the check only searches one.
+func (c *Checker) Run(ctx context.Context, algorithms []models.AlgorithmConfig, n int) (Report, error) {
+	report := Report{Index: c.index, K: c.k}
+	for _, alg := range algorithms {
+		if alg.Federation != nil {
+			return report, fmt.Errorf("algorithm %s is federated across indices, which the shard check can't run", alg.Name)
+		}
+	}
+
+	for _, s := range Sample(algorithms, n) {
+		check, err := c.Check(ctx, s.Algorithm, s.Query)
+		if err != nil {
+			return report, fmt.Errorf("check %q (%s): %w", s.Query.Query, s.Algorithm, err)
+		}
+
+		report.Queries = append(report.Queries, check)
+		switch check.Effect {
+		case EffectRanking:
+			report.Reordered++
+		case EffectScores:
+			report.Rescored++
+		}
+	}
+
+	return report, nil
+}
+
+// Check runs a query with the default search type, with
+// dfs_query_then_fetch and against a single shard, and compares them
+func (c *Checker) Check(ctx context.Context, algorithm string, qc models.QueryConfig) (QueryCheck, error) {
+	check := QueryCheck{Query: qc.Query, Algorithm: algorithm}
+	query := prepareQuery(qc)
+
+	local, err := c.client.SearchWith(ctx, c.index, query, elasticsearch.SearchOptions{
+		SearchType: elasticsearch.SearchTypeQueryThenFetch,
+	})
+	if err != nil {
+		return check, fmt.Errorf("default search: %w", err)
+	}
+	global, err := c.client.SearchWith(ctx, c.index, query, elasticsearch.SearchOptions{
+		SearchType: elasticsearch.SearchTypeDFSQueryThenFetch,
+	})
+	if err != nil {
+		return check, fmt.Errorf("dfs search: %w", err)
+	}
+	single, err := c.client.SearchWith(ctx, c.index, query, elasticsearch.SearchOptions{
+		SearchType: elasticsearch.SearchTypeQueryThenFetch,
+		Preference: elasticsearch.ShardPreference(0),
+	})
+	if err != nil {
+		return check, fmt.Errorf("single shard search: %w", err)
+	}
+
+	localIDs, globalIDs := hitIDs(local), hitIDs(global)
+	check.Overlap = metrics.Overlap(localIDs, globalIDs, c.k)
+	check.RBO = metrics.RankBiasedOverlap(localIDs, globalIDs, c.k, metrics.DefaultRBOPersistence)
+	check.Moved = moved(localIDs, globalIDs, c.k)
+
+	globalScores := hitScores(global)
+	check.MaxScoreChange, _ = scoreChange(local, globalScores)
+	_, check.ShardDistortion = scoreChange(single, globalScores)
+
+	switch {
+	case check.Moved > 0 || check.Overlap < 1:
+		check.Effect = EffectRanking
+	case check.MaxScoreChange > scoreTolerance:
+		check.Effect = EffectScores
+	default:
+		check.Effect = EffectNone
+	}
+
+	return check, nil
+}
+
+// DocSkew is the spread of primary shard document counts relative to their
+// mean: 0 when documents are spread evenly, larger the more uneven they are
+func DocSkew(shards []elasticsearch.ShardCopy) float64 {
+	var counts []int
+	for _, s := range shards {
+		if s.Primary {
+			counts = append(counts, s.Docs)
+		}
+	}
+	if len(counts) < 2 {
+		return 0
+	}
+
+	lowest, highest, total := counts[0], counts[0], 0
+	for _, n := range counts {
+		lowest = min(lowest, n)
+		highest = max(highest, n)
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+
+	mean := float64(total) / float64(len(counts))
+	return float64(highest-lowest) / mean
+}
+
+// SampledQuery is a query picked for checking, with its algorithm
+type SampledQuery struct {
+	Algorithm string
+	Query     models.QueryConfig
+}
+
+// Sample picks up to n queries spread evenly through the suite, in suite
+// order. A non-positive n selects every query.
+func Sample(algorithms []models.AlgorithmConfig, n int) []SampledQuery {
+	var all []SampledQuery
+	for _, alg := range algorithms {
+		for _, q := range alg.Queries {
+			all = append(all, SampledQuery{Algorithm: alg.Name, Query: q})
+		}
+	}
+	if n <= 0 || n >= len(all) {
+		return all
+	}
+
+	sample := make([]SampledQuery, n)
+	for i := range sample {
+		sample[i] = all[i*len(all)/n]
+	}
+	return sample
+}
+
+// prepareQuery copies the query body, defaulting its size as the executor does
+func prepareQuery(qc models.QueryConfig) map[string]interface{} {
+	query := make(map[string]interface{}, len(qc.ESQuery)+1)
+	for k, v := range qc.ESQuery {
+		query[k] = v
+	}
+	if query["size"] == nil {
+		query["size"] = 20
+	}
+	return query
+}
+
+func hitIDs(res *elasticsearch.SearchResponse) []string {
+	ids := make([]string, len(res.Hits.Hits))
+	for i, hit := range res.Hits.Hits {
+		ids[i] = hit.ID
+	}
+	return ids
+}
+
+func hitScores(res *elasticsearch.SearchResponse) map[string]float64 {
+	scores := make(map[string]float64, len(res.Hits.Hits))
+	for _, hit := range res.Hits.Hits {
+		scores[hit.ID] = hit.Score
+	}
+	return scores
+}
+
+// moved counts documents in both top ks that sit at different ranks
+func moved(a, b []string, k int) int {
+	rank := make(map[string]int, k)
+	for i, id := range b {
+		if i >= k {
+			break
+		}
+		rank[id] = i
+	}
+
+	n := 0
+	for i, id := range a {
+		if i >= k {
+			break
+		}
+		if j, ok := rank[id]; ok && j != i {
+			n++
+		}
+	}
+	return n
+}
+
+// scoreChange returns the largest and mean relative difference between the
+// scores of hits and the reference scores of the same documents
+func scoreChange(res *elasticsearch.SearchResponse, reference map[string]float64) (maxChange, meanChange float64) {
+	compared := 0
+	for _, hit := range res.Hits.Hits {
+		ref, ok := reference[hit.ID]
+		if !ok || ref == 0 {
+			continue
+		}
+		change := math.Abs(hit.Score-ref) / math.Abs(ref)
+		maxChange = math.Max(maxChange, change)
+		meanChange += change
+		compared++
+	}
+	if compared > 0 {
+		meanChange /= float64(compared)
+	}
+	return maxChange, meanChange
+}
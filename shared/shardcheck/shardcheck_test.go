@@ -0,0 +1,157 @@
+package shardcheck
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/elasticsearch"
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// fakeSearcher returns canned hits for each shard setting
+type fakeSearcher struct {
+	local, global, single []elasticsearch.Hit
+}
+
+func (f fakeSearcher) SearchWith(_ context.Context, _ string, _ map[string]interface{},
+	opts elasticsearch.SearchOptions) (*elasticsearch.SearchResponse, error) {
+	var res elasticsearch.SearchResponse
+	switch {
+	case opts.Preference != "":
+		res.Hits.Hits = f.single
+	case opts.SearchType == elasticsearch.SearchTypeDFSQueryThenFetch:
+		res.Hits.Hits = f.global
+	default:
+		res.Hits.Hits = f.local
+	}
+	return &res, nil
+}
+
+func hits(pairs ...interface{}) []elasticsearch.Hit {
+	var out []elasticsearch.Hit
+	for i := 0; i < len(pairs); i += 2 {
+		out = append(out, elasticsearch.Hit{ID: pairs[i].(string), Score: pairs[i+1].(float64)})
+	}
+	return out
+}
+
+func TestChecker_Check(t *testing.T) {
+	tests := []struct {
+		name           string
+		searcher       fakeSearcher
+		wantEffect     string
+		wantMoved      int
+		wantMaxChange  float64
+		wantDistortion float64
+	}{
+		{
+			name: "no effect",
+			searcher: fakeSearcher{
+				local:  hits("a", 2.0, "b", 1.0),
+				global: hits("a", 2.0, "b", 1.0),
+				single: hits("a", 2.0),
+			},
+			wantEffect: EffectNone,
+		},
+		{
+			name: "scores only",
+			searcher: fakeSearcher{
+				local:  hits("a", 2.2, "b", 1.0),
+				global: hits("a", 2.0, "b", 1.0),
+				single: hits("b", 1.5),
+			},
+			wantEffect:     EffectScores,
+			wantMaxChange:  0.1,
+			wantDistortion: 0.5,
+		},
+		{
+			name: "ranking flips",
+			searcher: fakeSearcher{
+				local:  hits("b", 2.0, "a", 1.9),
+				global: hits("a", 2.0, "b", 1.8),
+				single: hits("a", 1.9),
+			},
+			wantEffect:     EffectRanking,
+			wantMoved:      2,
+			wantMaxChange:  0.2 / 1.8,
+			wantDistortion: 0.05,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, err := NewChecker(tt.searcher, "test", 10).Check(context.Background(), "bm25",
+				models.QueryConfig{Query: "inflation", ESQuery: map[string]interface{}{}})
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if check.Effect != tt.wantEffect {
+				t.Errorf("Effect = %s, want %s", check.Effect, tt.wantEffect)
+			}
+			if check.Moved != tt.wantMoved {
+				t.Errorf("Moved = %d, want %d", check.Moved, tt.wantMoved)
+			}
+			if math.Abs(check.MaxScoreChange-tt.wantMaxChange) > 1e-9 {
+				t.Errorf("MaxScoreChange = %f, want %f", check.MaxScoreChange, tt.wantMaxChange)
+			}
+			if math.Abs(check.ShardDistortion-tt.wantDistortion) > 1e-9 {
+				t.Errorf("ShardDistortion = %f, want %f", check.ShardDistortion, tt.wantDistortion)
+			}
+		})
+	}
+}
+
+func TestSample(t *testing.T) {
+	algorithms := []models.AlgorithmConfig{
+		{Name: "bm25", Queries: []models.QueryConfig{{Query: "q1"}, {Query: "q2"}, {Query: "q3"}}},
+		{Name: "boosted", Queries: []models.QueryConfig{{Query: "q4"}, {Query: "q5"}, {Query: "q6"}}},
+	}
+
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{n: 0, want: []string{"q1", "q2", "q3", "q4", "q5", "q6"}},
+		{n: 10, want: []string{"q1", "q2", "q3", "q4", "q5", "q6"}},
+		{n: 3, want: []string{"q1", "q3", "q5"}},
+		{n: 1, want: []string{"q1"}},
+	}
+
+	for _, tt := range tests {
+		got := Sample(algorithms, tt.n)
+		if len(got) != len(tt.want) {
+			t.Fatalf("Sample(%d) returned %d queries, want %d", tt.n, len(got), len(tt.want))
+		}
+		for i, s := range got {
+			if s.Query.Query != tt.want[i] {
+				t.Errorf("Sample(%d)[%d] = %s, want %s", tt.n, i, s.Query.Query, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestDocSkew(t *testing.T) {
+	shards := []elasticsearch.ShardCopy{
+		{Shard: 0, Primary: true, Docs: 150},
+		{Shard: 0, Primary: false, Docs: 10},
+		{Shard: 1, Primary: true, Docs: 50},
+	}
+	if got := DocSkew(shards); math.Abs(got-1.0) > 1e-9 {
+		t.Errorf("DocSkew() = %f, want 1.0", got)
+	}
+	if got := DocSkew(shards[:1]); got != 0 {
+		t.Errorf("DocSkew() of one shard = %f, want 0", got)
+	}
+}
+
+func TestChecker_RunFederated(t *testing.T) {
+	algorithms := []models.AlgorithmConfig{{
+		Name:       "all",
+		Federation: &models.FederationConfig{Indices: []models.FederatedIndex{{Index: "{index}"}, {Index: "topics"}}},
+		Queries:    []models.QueryConfig{{Query: "inflation"}},
+	}}
+	if _, err := NewChecker(fakeSearcher{}, "idx", 10).Run(context.Background(), algorithms, 0); err == nil {
+		t.Error("Run() with a federated algorithm should fail")
+	}
+}
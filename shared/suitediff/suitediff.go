@@ -0,0 +1,167 @@
+// Package suitediff compares the results of two query suites run against
+// the same index, query by query, to check that rewriting the queries kept
+// their behaviour.
+package suitediff
+
+import (
+	"math"
+	"sort"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/comparison"
+)
+
+// Status says how a query's results differ between the suites
+type Status string
+
+// Query statuses, from most to least alike
+const (
+	StatusIdentical Status = "identical" // Same results in the same order
+	StatusReordered Status = "reordered" // Same results in a different order
+	StatusChanged   Status = "changed"   // Results added or removed
+	StatusOnlyA     Status = "only_a"    // Query missing from suite B
+	StatusOnlyB     Status = "only_b"    // Query missing from suite A
+)
+
+// QueryDiff compares one query's results in the two suites
+type QueryDiff struct {
+	Key             string   `json:"key"`
+	Query           string   `json:"query"`
+	Algorithm       string   `json:"algorithm"`
+	Status          Status   `json:"status"`
+	FirstDifference int      `json:"first_difference,omitempty"` // Rank where the lists first differ; 0 if they don't
+	Overlap         float64  `json:"overlap"`                    // Share of results in both lists
+	MaxScoreDelta   float64  `json:"max_score_delta"`            // Largest score change of a result in both lists
+	Added           []string `json:"added,omitempty"`            // Results only suite B returned
+	Removed         []string `json:"removed,omitempty"`          // Results only suite A returned
+}
+
+// Report is the outcome of comparing two suites
+type Report struct {
+	Queries   []QueryDiff    `json:"queries"`
+	Counts    map[Status]int `json:"counts"`
+	Preserved bool           `json:"preserved"` // Every query returned the same results in the same order
+}
+
+// Compare pairs the queries of suite A and suite B by algorithm and query
+// ID, accepting suite B's aliases for renamed queries, and compares each
+// pair's results. Queries are listed least alike first.
+func Compare(a, b []models.QueryResults, matcher comparison.Matcher) Report {
+	if matcher == nil {
+		matcher = comparison.URIMatcher{}
+	}
+	matcher = comparison.GroupAware(matcher)
+
+	byKey := make(map[string]int, len(a))
+	for i, qr := range a {
+		byKey[qr.Key()] = i
+	}
+
+	report := Report{Counts: make(map[Status]int)}
+	paired := make(map[int]bool, len(a))
+	for _, qb := range b {
+		i, ok := -1, false
+		for _, key := range qb.CandidateKeys() {
+			if j, found := byKey[key]; found && !paired[j] {
+				i, ok = j, true
+				break
+			}
+		}
+		if !ok {
+			report.Queries = append(report.Queries, QueryDiff{
+				Key:       qb.Key(),
+				Query:     qb.Query,
+				Algorithm: qb.AlgorithmLabel(),
+				Status:    StatusOnlyB,
+			})
+			continue
+		}
+		paired[i] = true
+		report.Queries = append(report.Queries, compareQuery(a[i], qb, matcher))
+	}
+	for i, qa := range a {
+		if !paired[i] {
+			report.Queries = append(report.Queries, QueryDiff{
+				Key:       qa.Key(),
+				Query:     qa.Query,
+				Algorithm: qa.AlgorithmLabel(),
+				Status:    StatusOnlyA,
+			})
+		}
+	}
+
+	rank := map[Status]int{StatusOnlyA: 0, StatusOnlyB: 1, StatusChanged: 2, StatusReordered: 3, StatusIdentical: 4}
+	sort.SliceStable(report.Queries, func(i, j int) bool {
+		qi, qj := report.Queries[i], report.Queries[j]
+		if rank[qi.Status] != rank[qj.Status] {
+			return rank[qi.Status] < rank[qj.Status]
+		}
+		if qi.Overlap != qj.Overlap {
+			return qi.Overlap < qj.Overlap
+		}
+		return qi.Key < qj.Key
+	})
+
+	report.Preserved = true
+	for _, q := range report.Queries {
+		report.Counts[q.Status]++
+		if q.Status != StatusIdentical {
+			report.Preserved = false
+		}
+	}
+	return report
+}
+
+// compareQuery compares the results of one query in suite A and suite B
+func compareQuery(a, b models.QueryResults, matcher comparison.Matcher) QueryDiff {
+	diff := QueryDiff{
+		Key:       a.Key(),
+		Query:     a.Query,
+		Algorithm: a.AlgorithmLabel(),
+	}
+
+	scoresA := make(map[string]float64, len(a.Results))
+	for _, r := range a.Results {
+		scoresA[matcher.Key(r)] = r.Score
+	}
+	inB := make(map[string]bool, len(b.Results))
+	common := 0
+	for _, r := range b.Results {
+		key := matcher.Key(r)
+		inB[key] = true
+		if score, ok := scoresA[key]; ok {
+			common++
+			diff.MaxScoreDelta = math.Max(diff.MaxScoreDelta, math.Abs(r.Score-score))
+		} else {
+			diff.Added = append(diff.Added, key)
+		}
+	}
+	for _, r := range a.Results {
+		if key := matcher.Key(r); !inB[key] {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+
+	if longest := max(len(a.Results), len(b.Results)); longest > 0 {
+		diff.Overlap = float64(common) / float64(longest)
+	} else {
+		diff.Overlap = 1
+	}
+
+	for i := 0; i < max(len(a.Results), len(b.Results)); i++ {
+		if i >= len(a.Results) || i >= len(b.Results) || matcher.Key(a.Results[i]) != matcher.Key(b.Results[i]) {
+			diff.FirstDifference = i + 1
+			break
+		}
+	}
+
+	switch {
+	case len(diff.Added) > 0 || len(diff.Removed) > 0:
+		diff.Status = StatusChanged
+	case diff.FirstDifference > 0:
+		diff.Status = StatusReordered
+	default:
+		diff.Status = StatusIdentical
+	}
+	return diff
+}
@@ -0,0 +1,75 @@
+package suitediff
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func results(uris ...string) []models.SearchResult {
+	out := make([]models.SearchResult, len(uris))
+	for i, uri := range uris {
+		out[i] = models.SearchResult{Rank: i + 1, URI: uri, Score: float64(len(uris) - i)}
+	}
+	return out
+}
+
+func TestCompare(t *testing.T) {
+	a := []models.QueryResults{
+		{QueryID: "cpi", Query: "inflation", Algorithm: "bm25", Results: results("/a", "/b", "/c")},
+		{QueryID: "gdp", Query: "gdp", Algorithm: "bm25", Results: results("/g1", "/g2")},
+		{QueryID: "jobs", Query: "jobs", Algorithm: "bm25", Results: results("/j1", "/j2")},
+		{QueryID: "old", Query: "housing", Algorithm: "bm25", Results: results("/h1")},
+		{QueryID: "gone", Query: "trade", Algorithm: "bm25"},
+	}
+	b := []models.QueryResults{
+		{QueryID: "cpi", Query: "inflation", Algorithm: "bm25", Results: results("/a", "/b", "/c")},
+		{QueryID: "gdp", Query: "gdp", Algorithm: "bm25", Results: results("/g2", "/g1")},
+		{QueryID: "jobs", Query: "jobs", Algorithm: "bm25", Results: results("/j1", "/j3")},
+		{QueryID: "houses", Query: "housing", Aliases: []string{"old"}, Algorithm: "bm25", Results: results("/h1")},
+		{QueryID: "wages", Query: "wages", Algorithm: "bm25"},
+	}
+
+	report := Compare(a, b, nil)
+	if report.Preserved {
+		t.Error("Compare() reported differing suites as preserved")
+	}
+
+	byKey := make(map[string]QueryDiff)
+	var order []Status
+	for _, q := range report.Queries {
+		byKey[q.Key] = q
+		order = append(order, q.Status)
+	}
+
+	wantOrder := []Status{StatusOnlyA, StatusOnlyB, StatusChanged, StatusReordered, StatusIdentical, StatusIdentical}
+	if !reflect.DeepEqual(order, wantOrder) {
+		t.Errorf("statuses = %v, want %v", order, wantOrder)
+	}
+
+	if q := byKey["bm25/old"]; q.Status != StatusIdentical {
+		t.Errorf("renamed query with alias status = %s, want identical", q.Status)
+	}
+	if q := byKey["bm25/gdp"]; q.FirstDifference != 1 || q.Overlap != 1 || q.MaxScoreDelta != 1 {
+		t.Errorf("reordered query = %+v, want first difference 1, full overlap and score delta 1", q)
+	}
+	q := byKey["bm25/jobs"]
+	if q.FirstDifference != 2 || q.Overlap != 0.5 ||
+		!reflect.DeepEqual(q.Added, []string{"/j3"}) || !reflect.DeepEqual(q.Removed, []string{"/j2"}) {
+		t.Errorf("changed query = %+v", q)
+	}
+	if report.Counts[StatusIdentical] != 2 || report.Counts[StatusOnlyB] != 1 {
+		t.Errorf("counts = %v", report.Counts)
+	}
+}
+
+func TestCompare_Preserved(t *testing.T) {
+	suite := []models.QueryResults{
+		{QueryID: "cpi", Query: "inflation", Algorithm: "bm25", Results: results("/a", "/b")},
+		{QueryID: "none", Query: "zzz", Algorithm: "bm25"},
+	}
+	if report := Compare(suite, suite, nil); !report.Preserved {
+		t.Errorf("Compare() of a suite with itself = %+v, want preserved", report)
+	}
+}
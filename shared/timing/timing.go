@@ -0,0 +1,108 @@
+// Package timing breaks the time a command takes down into named phases,
+// such as connecting, bulk loading and running queries, so the slow ones
+// are known rather than guessed.
+package timing
+
+import (
+	"time"
+
+	"github.com/ONSdigital/dis-search-test-bed/shared/clock"
+)
+
+// Other names the time spent outside any phase
+const Other = "other"
+
+// Phase is the total time spent in one named phase
+type Phase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Timer records phases from when it was created
+type Timer struct {
+	clock   clock.Clock
+	started time.Time
+	phases  []Phase
+	index   map[string]int
+}
+
+// NewTimer starts a timer on the given clock (the system clock if nil)
+func NewTimer(c clock.Clock) *Timer {
+	c = clock.OrReal(c)
+	return &Timer{clock: c, started: c.Now(), index: make(map[string]int)}
+}
+
+// Start begins timing a phase and returns the function that ends it. A
+// phase timed more than once adds up, keeping its first position.
+func (t *Timer) Start(name string) func() {
+	begin := t.clock.Now()
+	return func() {
+		t.add(name, t.clock.Now().Sub(begin))
+	}
+}
+
+func (t *Timer) add(name string, d time.Duration) {
+	i, ok := t.index[name]
+	if !ok {
+		i = len(t.phases)
+		t.index[name] = i
+		t.phases = append(t.phases, Phase{Name: name})
+	}
+	t.phases[i].Duration += d
+}
+
+// Phases returns the timed phases in the order they first started, followed
+// by the time spent outside them when there is any
+func (t *Timer) Phases() []Phase {
+	return t.phasesWithin(t.Total())
+}
+
+func (t *Timer) phasesWithin(total time.Duration) []Phase {
+	phases := append([]Phase(nil), t.phases...)
+	var timed time.Duration
+	for _, p := range t.phases {
+		timed += p.Duration
+	}
+	if other := total - timed; other > 0 && len(phases) > 0 {
+		phases = append(phases, Phase{Name: Other, Duration: other})
+	}
+	return phases
+}
+
+// Total returns the time since the timer was created
+func (t *Timer) Total() time.Duration {
+	return t.clock.Now().Sub(t.started)
+}
+
+// Report is a command's timings as saved in a run folder
+type Report struct {
+	Command   string        `json:"command"`
+	StartedAt time.Time     `json:"started_at"`
+	TotalMs   float64       `json:"total_ms"`
+	Phases    []PhaseReport `json:"phases"`
+}
+
+// PhaseReport is one phase's time and share of the command's total
+type PhaseReport struct {
+	Name  string  `json:"name"`
+	Ms    float64 `json:"ms"`
+	Share float64 `json:"share"`
+}
+
+// Report summarises the timer for the named command
+func (t *Timer) Report(command string) Report {
+	total := t.Total()
+	report := Report{Command: command, StartedAt: t.started, TotalMs: millis(total)}
+	for _, p := range t.phasesWithin(total) {
+		pr := PhaseReport{Name: p.Name, Ms: millis(p.Duration)}
+		if total > 0 {
+			pr.Share = float64(p.Duration) / float64(total)
+		}
+		report.Phases = append(report.Phases, pr)
+	}
+	return report
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
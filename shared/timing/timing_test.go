@@ -0,0 +1,54 @@
+package timing
+
+import (
+	"testing"
+	"time"
+)
+
+// stepClock moves forward by a second each time it is read
+type stepClock struct{ now time.Time }
+
+func (c *stepClock) Now() time.Time {
+	c.now = c.now.Add(time.Second)
+	return c.now
+}
+
+func TestTimer(t *testing.T) {
+	timer := NewTimer(&stepClock{}) // created at 1s
+
+	stop := timer.Start("connect") // 2s
+	stop()                         // 3s: connect 1s
+	stop = timer.Start("queries")  // 4s
+	stop()                         // 5s: queries 1s
+	stop = timer.Start("connect")  // 6s
+	stop()                         // 7s: connect 2s
+
+	phases := timer.Phases() // read at 8s: total 7s, so 4s other
+	want := []Phase{
+		{Name: "connect", Duration: 2 * time.Second},
+		{Name: "queries", Duration: time.Second},
+		{Name: Other, Duration: 4 * time.Second},
+	}
+	if len(phases) != len(want) {
+		t.Fatalf("Phases() = %+v, want %+v", phases, want)
+	}
+	for i := range want {
+		if phases[i] != want[i] {
+			t.Errorf("phase %d = %+v, want %+v", i, phases[i], want[i])
+		}
+	}
+
+	report := timer.Report("query") // read at 9s: total 8s, so 5s other
+	if report.Command != "query" || report.TotalMs != 8000 {
+		t.Errorf("report = %+v, want query taking 8000ms", report)
+	}
+	if report.Phases[0].Share != 0.25 || report.Phases[2].Ms != 5000 {
+		t.Errorf("phases = %+v, want connect at 0.25 and 5000ms other", report.Phases)
+	}
+}
+
+func TestTimer_NoPhases(t *testing.T) {
+	if phases := NewTimer(&stepClock{}).Phases(); len(phases) != 0 {
+		t.Errorf("Phases() = %+v, want none", phases)
+	}
+}
@@ -0,0 +1,233 @@
+// Package trends follows how search results evolve over a series of runs.
+// Each run is measured on its own (result counts, latency, NDCG against
+// judgments) and against the run before it (overlap and rank-biased
+// overlap), overall and for every query.
+package trends
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+	"github.com/ONSdigital/dis-search-test-bed/shared/metrics"
+)
+
+// Run is one run's results
+type Run struct {
+	ID      string
+	At      time.Time
+	Results []models.QueryResults
+}
+
+// Options control how runs are measured
+type Options struct {
+	K              int              // Rank cut-off for overlap, RBO and NDCG
+	RBOPersistence float64          // Rank-biased overlap weighting; the metrics default when unset
+	Judgments      models.Judgments // Graded judgments for NDCG; no NDCG when empty
+}
+
+// Point is one algorithm's metrics in one run. Overlap and RBO compare the
+// top K with the previous run, over the queries in both, and are unset for
+// the first run; NDCG is unset without judged queries.
+type Point struct {
+	RunID       string    `json:"run_id"`
+	At          time.Time `json:"at"`
+	Algorithm   string    `json:"algorithm"`
+	Queries     int       `json:"queries"`
+	ZeroResults int       `json:"zero_results"`
+	AvgResults  float64   `json:"avg_results"`
+	MeanTookMs  float64   `json:"mean_took_ms"`
+	Overlap     *float64  `json:"overlap,omitempty"`
+	RBO         *float64  `json:"rbo,omitempty"`
+	NDCG        *float64  `json:"ndcg,omitempty"`
+}
+
+// QueryPoint is one query's metrics in one run
+type QueryPoint struct {
+	RunID   string   `json:"run_id"`
+	Results int      `json:"results"`
+	TopURI  string   `json:"top_uri,omitempty"`
+	Overlap *float64 `json:"overlap,omitempty"`
+	RBO     *float64 `json:"rbo,omitempty"`
+	NDCG    *float64 `json:"ndcg,omitempty"`
+}
+
+// QueryTrend is one query's metrics in every run it appears in
+type QueryTrend struct {
+	Query       string       `json:"query"`
+	QueryID     string       `json:"query_id,omitempty"`
+	Algorithm   string       `json:"algorithm"`
+	Points      []QueryPoint `json:"points"`
+	MeanOverlap *float64     `json:"mean_overlap,omitempty"` // Over the runs with a previous run to compare
+	TopChanges  int          `json:"top_changes"`            // Runs whose top result differed from the run before
+}
+
+// Report is the trend over a series of runs, oldest first
+type Report struct {
+	K       int          `json:"k"`
+	Runs    []string     `json:"runs"`
+	Overall []Point      `json:"overall"`
+	Queries []QueryTrend `json:"queries"` // Least stable first
+}
+
+// Analyse measures each run, oldest first, against the run before it
+func Analyse(runs []Run, opts Options) Report {
+	if opts.K <= 0 {
+		opts.K = metrics.DefaultK
+	}
+	if opts.RBOPersistence <= 0 {
+		opts.RBOPersistence = metrics.DefaultRBOPersistence
+	}
+
+	report := Report{K: opts.K}
+	byKey := make(map[string]*QueryTrend)
+	var keys []string
+
+	var previousByKey map[string]models.QueryResults
+	for _, run := range runs {
+		report.Runs = append(report.Runs, run.ID)
+		points := make(map[string]*point)
+		var algorithms []string
+
+		for _, curr := range run.Results {
+			algorithm := curr.AlgorithmLabel()
+			p, ok := points[algorithm]
+			if !ok {
+				p = &point{Point: Point{RunID: run.ID, At: run.At, Algorithm: algorithm}}
+				points[algorithm] = p
+				algorithms = append(algorithms, algorithm)
+			}
+
+			qp := p.measure(curr, previousByKey, opts)
+
+			key := curr.Key()
+			trend, ok := byKey[key]
+			if !ok {
+				trend = &QueryTrend{Query: curr.Query, QueryID: curr.QueryID, Algorithm: algorithm}
+				byKey[key] = trend
+				keys = append(keys, key)
+			}
+			if n := len(trend.Points); n > 0 && trend.Points[n-1].TopURI != qp.TopURI {
+				trend.TopChanges++
+			}
+			trend.Points = append(trend.Points, qp)
+		}
+
+		for _, algorithm := range algorithms {
+			report.Overall = append(report.Overall, points[algorithm].finish())
+		}
+		previousByKey = make(map[string]models.QueryResults, len(run.Results))
+		for _, qr := range run.Results {
+			previousByKey[qr.Key()] = qr
+		}
+	}
+
+	report.Queries = queryTrends(byKey, keys)
+	return report
+}
+
+// queryTrends returns the query trends in key order with their mean overlap
+// set, least stable first
+func queryTrends(byKey map[string]*QueryTrend, keys []string) []QueryTrend {
+	var queries []QueryTrend
+	for _, key := range keys {
+		trend := byKey[key]
+		var overlap mean
+		for _, p := range trend.Points {
+			if p.Overlap != nil {
+				overlap.add(*p.Overlap)
+			}
+		}
+		trend.MeanOverlap = overlap.value()
+		queries = append(queries, *trend)
+	}
+	sort.SliceStable(queries, func(i, j int) bool {
+		a, b := queries[i].MeanOverlap, queries[j].MeanOverlap
+		switch {
+		case a == nil || b == nil:
+			return a != nil && b == nil
+		case *a != *b:
+			return *a < *b
+		default:
+			return queries[i].TopChanges > queries[j].TopChanges
+		}
+	})
+	return queries
+}
+
+// point accumulates one algorithm's metrics over a run's queries
+type point struct {
+	Point
+	results, took      int
+	overlap, rbo, ndcg mean
+}
+
+// measure scores one query's results against the previous run and any
+// judgments, adding them to the algorithm's totals for the run
+func (p *point) measure(curr models.QueryResults, previousByKey map[string]models.QueryResults, opts Options) QueryPoint {
+	qp := QueryPoint{RunID: p.RunID, Results: len(curr.Results)}
+	if len(curr.Results) > 0 {
+		qp.TopURI = curr.Results[0].URI
+	}
+	p.Queries++
+	p.results += len(curr.Results)
+	p.took += curr.TookMs
+	if len(curr.Results) == 0 {
+		p.ZeroResults++
+	}
+
+	ranking := uris(curr.Results)
+	if prev, ok := curr.FindPrevious(previousByKey); ok {
+		prevRanking := uris(prev.Results)
+		overlap := metrics.Overlap(ranking, prevRanking, opts.K)
+		rbo := metrics.RankBiasedOverlap(ranking, prevRanking, opts.K, opts.RBOPersistence)
+		qp.Overlap, qp.RBO = &overlap, &rbo
+		p.overlap.add(overlap)
+		p.rbo.add(rbo)
+	}
+	if grades := opts.Judgments.ForQuery(curr.QueryID, curr.Query); grades != nil {
+		if ndcg, ok := metrics.NDCG(ranking, grades, opts.K); ok {
+			qp.NDCG = &ndcg
+			p.ndcg.add(ndcg)
+		}
+	}
+	return qp
+}
+
+func (p *point) finish() Point {
+	if p.Queries > 0 {
+		p.AvgResults = float64(p.results) / float64(p.Queries)
+		p.MeanTookMs = float64(p.took) / float64(p.Queries)
+	}
+	p.Overlap = p.overlap.value()
+	p.RBO = p.rbo.value()
+	p.NDCG = p.ndcg.value()
+	return p.Point
+}
+
+// mean is a running mean that is unset until a value is added
+type mean struct {
+	sum float64
+	n   int
+}
+
+func (m *mean) add(v float64) {
+	m.sum += v
+	m.n++
+}
+
+func (m mean) value() *float64 {
+	if m.n == 0 {
+		return nil
+	}
+	v := m.sum / float64(m.n)
+	return &v
+}
+
+func uris(results []models.SearchResult) []string {
+	out := make([]string, len(results))
+	for i, r := range results {
+		out[i] = r.URI
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package trends
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+func results(query string, took int, uris ...string) models.QueryResults {
+	qr := models.QueryResults{Query: query, Algorithm: "bm25", TookMs: took}
+	for i, uri := range uris {
+		qr.Results = append(qr.Results, models.SearchResult{Rank: i + 1, URI: uri})
+	}
+	return qr
+}
+
+func TestAnalyse(t *testing.T) {
+	runs := []Run{
+		{ID: "run_1", Results: []models.QueryResults{
+			results("cpi", 10, "/a", "/b"),
+			results("gdp", 20, "/g"),
+		}},
+		{ID: "run_2", Results: []models.QueryResults{
+			results("cpi", 30, "/b", "/c"),
+			results("gdp", 10, "/g"),
+			results("jobs", 20),
+		}},
+	}
+	judgments := models.Judgments{"cpi": {"/a": 1}}
+
+	report := Analyse(runs, Options{K: 2, Judgments: judgments})
+
+	if len(report.Overall) != 2 {
+		t.Fatalf("Analyse() gave %d points, want one per run", len(report.Overall))
+	}
+	first, second := report.Overall[0], report.Overall[1]
+	if first.Overlap != nil || first.RBO != nil {
+		t.Errorf("first run = %+v, want no overlap without a previous run", first)
+	}
+	if first.NDCG == nil || *first.NDCG != 1 {
+		t.Errorf("first run NDCG = %v, want 1", first.NDCG)
+	}
+	if second.Queries != 3 || second.ZeroResults != 1 || second.MeanTookMs != 20 || second.AvgResults != 1 {
+		t.Errorf("second run = %+v, want 3 queries, 1 with no results, mean 20ms and 1 result", second)
+	}
+	// cpi kept one of two results and gdp all of its one
+	if second.Overlap == nil || math.Abs(*second.Overlap-0.75) > 1e-9 {
+		t.Errorf("second run overlap = %v, want 0.75", second.Overlap)
+	}
+	if second.NDCG == nil || *second.NDCG != 0 {
+		t.Errorf("second run NDCG = %v, want 0", second.NDCG)
+	}
+
+	if len(report.Queries) != 3 {
+		t.Fatalf("Analyse() followed %d queries, want 3", len(report.Queries))
+	}
+	cpi := report.Queries[0]
+	if cpi.Query != "cpi" || cpi.TopChanges != 1 || len(cpi.Points) != 2 {
+		t.Errorf("least stable query = %+v, want cpi with 1 top change over 2 runs", cpi)
+	}
+	if last := report.Queries[2]; last.Query != "jobs" || last.MeanOverlap != nil {
+		t.Errorf("last query = %+v, want jobs, with nothing to compare", last)
+	}
+}
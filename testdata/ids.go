@@ -0,0 +1,62 @@
+package testdata
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// Strategies for the IDs seeded documents are indexed under
+const (
+	IDSequential = "sequential" // Keep the generated sequence numbers or the file's own IDs
+	IDURIHash    = "uri_hash"   // Derive each ID from the document's URI
+)
+
+// uriHashLength is the number of hex characters kept from a URI's hash
+const uriHashLength = 16
+
+// ValidateIDStrategy checks an ID strategy is known. Empty means
+// sequential.
+func ValidateIDStrategy(strategy string) error {
+	switch strategy {
+	case "", IDSequential, IDURIHash:
+		return nil
+	default:
+		return fmt.Errorf("unknown id strategy %q (use %s or %s)", strategy, IDSequential, IDURIHash)
+	}
+}
+
+// AssignIDs sets document IDs by the strategy. With uri_hash a document
+// keeps the same ID however many documents are seeded alongside it, so
+// results stay comparable by ID across re-seeds; every document then needs
+// a URI of its own.
+func AssignIDs(docs []models.Document, strategy string) error {
+	if err := ValidateIDStrategy(strategy); err != nil {
+		return err
+	}
+	if strategy != IDURIHash {
+		return nil
+	}
+
+	seen := make(map[string]string, len(docs))
+	for i := range docs {
+		uri := docs[i].URI
+		if uri == "" {
+			return fmt.Errorf("document %s has no URI to derive its ID from", docs[i].ID)
+		}
+		if other, ok := seen[uri]; ok {
+			return fmt.Errorf("documents %s and %s share the URI %s", other, docs[i].ID, uri)
+		}
+		seen[uri] = docs[i].ID
+		docs[i].ID = URIHashID(uri)
+	}
+	return nil
+}
+
+// URIHashID returns the ID the uri_hash strategy gives a URI
+func URIHashID(uri string) string {
+	sum := sha256.Sum256([]byte(uri))
+	return hex.EncodeToString(sum[:])[:uriHashLength]
+}
@@ -22,23 +22,25 @@ func GetSampleDocuments() []models.Document {
 	return GetSampleDocumentsWithSeed(42, 50)
 }
 
-// GetSampleDocumentsWithSeed returns sample documents with custom seed and count
+// GetSampleDocumentsWithSeed returns sample documents with custom seed and count.
+// The same seed always gives the same documents, and a smaller count gives a
+// prefix of a larger one.
 func GetSampleDocumentsWithSeed(seed int64, docCount int) []models.Document {
-	rand.Seed(seed)
+	r := rand.New(rand.NewSource(seed))
 
 	var docs []models.Document
 
 	for i := 1; i <= docCount; i++ {
-		tech := technologies[rand.Intn(len(technologies))]
-		topic := topics[rand.Intn(len(topics))]
-		contentType := contentTypes[rand.Intn(len(contentTypes))]
-		baseURI := baseURIs[rand.Intn(len(baseURIs))]
+		tech := technologies[r.Intn(len(technologies))]
+		topic := topics[r.Intn(len(topics))]
+		contentType := contentTypes[r.Intn(len(contentTypes))]
+		baseURI := baseURIs[r.Intn(len(baseURIs))]
 
 		doc := models.Document{
 			ID:          fmt.Sprintf("%d", i),
-			Title:       fmt.Sprintf("%s %s %s", tech, topic, randomAdjective()),
+			Title:       fmt.Sprintf("%s %s %s", tech, topic, randomAdjective(r)),
 			URI:         fmt.Sprintf("%s%s-%d", baseURI, topic, i),
-			Body:        generateBody(tech, topic),
+			Body:        generateBody(r, tech, topic),
 			ContentType: contentType,
 			Date:        fmt.Sprintf("2024-01-0%d", (i%9)+1) + "T10:00:00Z",
 		}
@@ -76,17 +78,17 @@ func GetConfiguredDocuments(sourceDocumentsPath string, seed int64, docCount int
 	return GetSampleDocumentsWithSeed(seed, docCount), nil
 }
 
-func generateBody(tech, topic string) string {
+func generateBody(r *rand.Rand, tech, topic string) string {
 	templates := []string{
 		fmt.Sprintf("Learn about %s %s including best practices, patterns, and real-world examples.", tech, topic),
 		fmt.Sprintf("Comprehensive guide to %s %s with detailed explanations and code samples.", tech, topic),
 		fmt.Sprintf("Master %s %s through this hands-on tutorial with step-by-step instructions.", tech, topic),
 		fmt.Sprintf("Advanced techniques for %s %s optimization, performance tuning, and scaling.", tech, topic),
 	}
-	return templates[rand.Intn(len(templates))]
+	return templates[r.Intn(len(templates))]
 }
 
-func randomAdjective() string {
+func randomAdjective(r *rand.Rand) string {
 	adjectives := []string{"Guide", "Handbook", "Reference", "Tips", "Tricks", "Essentials", "Masterclass"}
-	return adjectives[rand.Intn(len(adjectives))]
+	return adjectives[r.Intn(len(adjectives))]
 }
@@ -2,9 +2,19 @@ package ui
 
 import (
 	"fmt"
+	"io"
 	"os"
 )
 
+// out receives status output from printers and spinners
+var out io.Writer = os.Stdout
+
+// SetOutput sends status output from printers and spinners to w, e.g.
+// os.Stderr so a command can write its own output to stdout for piping
+func SetOutput(w io.Writer) {
+	out = w
+}
+
 // Printer handles formatted output to the console
 type Printer struct {
 	verbose bool
@@ -17,17 +27,17 @@ func NewPrinter(verbose bool) *Printer {
 
 // Info prints an informational message
 func (p *Printer) Info(format string, args ...interface{}) {
-	fmt.Printf("ℹ️  "+format+"\n", args...)
+	fmt.Fprintf(out, "ℹ️  "+format+"\n", args...)
 }
 
 // Success prints a success message
 func (p *Printer) Success(format string, args ...interface{}) {
-	fmt.Printf("✅ "+format+"\n", args...)
+	fmt.Fprintf(out, "✅ "+format+"\n", args...)
 }
 
 // Warning prints a warning message
 func (p *Printer) Warning(format string, args ...interface{}) {
-	fmt.Printf("⚠️  "+format+"\n", args...)
+	fmt.Fprintf(out, "⚠️  "+format+"\n", args...)
 }
 
 // Error prints an error message
@@ -38,26 +48,26 @@ func (p *Printer) Error(format string, args ...interface{}) {
 // Debug prints a debug message (only if verbose)
 func (p *Printer) Debug(format string, args ...interface{}) {
 	if p.verbose {
-		fmt.Printf("🔍 "+format+"\n", args...)
+		fmt.Fprintf(out, "🔍 "+format+"\n", args...)
 	}
 }
 
 // Section prints a section header
 func (p *Printer) Section(title string) {
-	fmt.Println()
-	fmt.Println(repeatChar("=", 60))
-	fmt.Printf("  %s\n", title)
-	fmt.Println(repeatChar("=", 60))
-	fmt.Println()
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, repeatChar("=", 60))
+	fmt.Fprintf(out, "  %s\n", title)
+	fmt.Fprintln(out, repeatChar("=", 60))
+	fmt.Fprintln(out)
 }
 
 // Celebrate prints a celebration message
 func (p *Printer) Celebrate(format string, args ...interface{}) {
-	fmt.Println()
-	fmt.Println(repeatChar("=", 60))
-	fmt.Printf("🎉 "+format+"\n", args...)
-	fmt.Println(repeatChar("=", 60))
-	fmt.Println()
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, repeatChar("=", 60))
+	fmt.Fprintf(out, "🎉 "+format+"\n", args...)
+	fmt.Fprintln(out, repeatChar("=", 60))
+	fmt.Fprintln(out)
 }
 
 func repeatChar(char string, count int) string {
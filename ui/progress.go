@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Progress formats
+const (
+	ProgressText = "text" // Spinners and status lines only
+	ProgressJSON = "json" // Also JSON Lines progress events, without spinners
+)
+
+var (
+	progressMu  sync.Mutex
+	progressOut io.Writer // Receives progress events; nil in text mode
+)
+
+// ValidateProgressFormat checks a progress format is known
+func ValidateProgressFormat(format string) error {
+	switch format {
+	case "", ProgressText, ProgressJSON:
+		return nil
+	default:
+		return fmt.Errorf("unknown progress format %q (use %s or %s)", format, ProgressText, ProgressJSON)
+	}
+}
+
+// SetProgress chooses how progress is reported. With json, every Progress
+// writes an event to w as it advances and spinners stay silent, so wrappers
+// can render progress without parsing spinner frames.
+func SetProgress(format string, w io.Writer) {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	if format == ProgressJSON {
+		progressOut = w
+	} else {
+		progressOut = nil
+	}
+}
+
+// progressJSON reports whether progress events are being written
+func progressJSON() bool {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	return progressOut != nil
+}
+
+// ProgressEvent is one line of JSON progress output
+type ProgressEvent struct {
+	Time    time.Time `json:"time"`
+	Phase   string    `json:"phase"` // e.g. "queries" or "index"
+	Current int       `json:"current"`
+	Total   int       `json:"total"`
+}
+
+// Progress counts the steps of one phase of a command
+type Progress struct {
+	mu      sync.Mutex
+	phase   string
+	current int
+	total   int
+}
+
+// NewProgress starts a phase of total steps, reporting it at 0
+func NewProgress(phase string, total int) *Progress {
+	p := &Progress{phase: phase, total: total}
+	p.emit()
+	return p
+}
+
+// Add records n more steps as finished
+func (p *Progress) Add(n int) {
+	p.mu.Lock()
+	p.current += n
+	if p.current > p.total {
+		p.current = p.total
+	}
+	p.mu.Unlock()
+	p.emit()
+}
+
+// Set records current steps as finished, for phases that report a running
+// total rather than increments
+func (p *Progress) Set(current int) {
+	p.mu.Lock()
+	p.current = min(max(current, 0), p.total)
+	p.mu.Unlock()
+	p.emit()
+}
+
+// Done marks every step as finished, such as when failed steps were
+// skipped without being counted
+func (p *Progress) Done() {
+	p.mu.Lock()
+	finished := p.current == p.total
+	p.current = p.total
+	p.mu.Unlock()
+	if !finished {
+		p.emit()
+	}
+}
+
+func (p *Progress) emit() {
+	p.mu.Lock()
+	event := ProgressEvent{Time: time.Now().UTC(), Phase: p.phase, Current: p.current, Total: p.total}
+	p.mu.Unlock()
+
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	if progressOut == nil {
+		return
+	}
+	// Progress is best effort; a closed stderr mustn't stop the command
+	_ = json.NewEncoder(progressOut).Encode(event)
+}
@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestProgress_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	SetProgress(ProgressJSON, &buf)
+	defer SetProgress(ProgressText, nil)
+
+	p := NewProgress("queries", 3)
+	p.Add(1)
+	p.Add(1)
+	p.Done()
+	p.Done()
+
+	var got [][2]int
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var event ProgressEvent
+		if err := dec.Decode(&event); err != nil {
+			t.Fatalf("decode event: %v", err)
+		}
+		if event.Phase != "queries" || event.Time.IsZero() {
+			t.Errorf("event = %+v, want phase queries with a time", event)
+		}
+		got = append(got, [2]int{event.Current, event.Total})
+	}
+
+	want := [][2]int{{0, 3}, {1, 3}, {2, 3}, {3, 3}}
+	if len(got) != len(want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestProgress_Text(t *testing.T) {
+	SetProgress(ProgressText, nil)
+
+	p := NewProgress("bulk", 2)
+	p.Add(2)
+	p.Done()
+
+	if err := ValidateProgressFormat("xml"); err == nil {
+		t.Error("ValidateProgressFormat(xml) expected an error")
+	}
+}
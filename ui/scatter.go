@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// Scatter draws marked points on a character grid, for quick trade-off
+// plots in the terminal
+type Scatter struct {
+	xLabel, yLabel string
+	width, height  int
+	points         []scatterPoint
+}
+
+type scatterPoint struct {
+	x, y float64
+	mark rune
+}
+
+// NewScatter creates a plot width by height characters, not counting the
+// axes and their labels
+func NewScatter(xLabel, yLabel string, width, height int) *Scatter {
+	return &Scatter{xLabel: xLabel, yLabel: yLabel, width: max(width, 2), height: max(height, 2)}
+}
+
+// Add places a mark at (x, y). Marks landing on the same cell show as *.
+func (s *Scatter) Add(x, y float64, mark rune) {
+	s.points = append(s.points, scatterPoint{x: x, y: y, mark: mark})
+}
+
+// Print writes the plot to stdout
+func (s *Scatter) Print() error {
+	return s.Write(os.Stdout)
+}
+
+// Write writes the plot to w, scaling both axes to the range of the points
+func (s *Scatter) Write(w io.Writer) error {
+	if len(s.points) == 0 {
+		return nil
+	}
+
+	xMin, xMax := math.Inf(1), math.Inf(-1)
+	yMin, yMax := math.Inf(1), math.Inf(-1)
+	for _, p := range s.points {
+		xMin, xMax = math.Min(xMin, p.x), math.Max(xMax, p.x)
+		yMin, yMax = math.Min(yMin, p.y), math.Max(yMax, p.y)
+	}
+
+	grid := make([][]rune, s.height)
+	for i := range grid {
+		grid[i] = []rune(strings.Repeat(" ", s.width))
+	}
+	for _, p := range s.points {
+		col := scale(p.x, xMin, xMax, s.width)
+		row := s.height - 1 - scale(p.y, yMin, yMax, s.height)
+		if grid[row][col] == ' ' {
+			grid[row][col] = p.mark
+		} else {
+			grid[row][col] = '*'
+		}
+	}
+
+	top, bottom := fmt.Sprintf("%.3g", yMax), fmt.Sprintf("%.3g", yMin)
+	margin := max(len(top), len(bottom))
+	var b strings.Builder
+	fmt.Fprintf(&b, "%*s\n", margin+len(s.yLabel)+1, s.yLabel)
+	for i, row := range grid {
+		label := ""
+		switch i {
+		case 0:
+			label = top
+		case s.height - 1:
+			label = bottom
+		}
+		fmt.Fprintf(&b, "%*s |%s\n", margin, label, string(row))
+	}
+	fmt.Fprintf(&b, "%*s +%s\n", margin, "", strings.Repeat("-", s.width))
+	left, right := fmt.Sprintf("%.3g", xMin), fmt.Sprintf("%.3g", xMax)
+	gap := max(s.width-len(left)-len(right), 1)
+	fmt.Fprintf(&b, "%*s  %s%s%s\n", margin, "", left, strings.Repeat(" ", gap), right)
+	fmt.Fprintf(&b, "%*s  %s\n", margin, "", s.xLabel)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// scale maps v from [lo, hi] onto a cell index in [0, cells)
+func scale(v, lo, hi float64, cells int) int {
+	if hi == lo {
+		return cells / 2
+	}
+	return int(math.Round((v - lo) / (hi - lo) * float64(cells-1)))
+}
@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScatter_Write(t *testing.T) {
+	s := NewScatter("latency", "relevance", 5, 3)
+	s.Add(0, 0, 'A')
+	s.Add(10, 1, 'B')
+	s.Add(10, 1, 'C')
+	s.Add(5, 0.5, 'D')
+
+	var b strings.Builder
+	if err := s.Write(&b); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	want := strings.Join([]string{
+		"  relevance",
+		"1 |    *",
+		"  |  D  ",
+		"0 |A    ",
+		"  +-----",
+		"   0  10",
+		"   latency",
+		"",
+	}, "\n")
+	if got := b.String(); got != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", got, want)
+	}
+}
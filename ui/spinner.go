@@ -20,8 +20,12 @@ func NewSpinner(message string) *Spinner {
 	}
 }
 
-// Start begins the spinner animation
+// Start begins the spinner animation. Spinners stay silent while JSON
+// progress events are written.
 func (s *Spinner) Start() {
+	if progressJSON() {
+		return
+	}
 	s.active = true
 
 	go func() {
@@ -34,7 +38,7 @@ func (s *Spinner) Start() {
 				return
 			default:
 				if s.active {
-					fmt.Printf("\r%s %s", frames[i], s.message)
+					fmt.Fprintf(out, "\r%s %s", frames[i], s.message)
 					i = (i + 1) % len(frames)
 					time.Sleep(80 * time.Millisecond)
 				}
@@ -45,9 +49,12 @@ func (s *Spinner) Start() {
 
 // Stop stops the spinner and clears the line
 func (s *Spinner) Stop() {
+	if !s.active {
+		return
+	}
 	s.active = false
 	s.done <- true
-	fmt.Print("\r\033[K") // Clear line
+	fmt.Fprint(out, "\r\033[K") // Clear line
 }
 
 // UpdateMessage updates the spinner message
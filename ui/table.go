@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+
+	"github.com/ONSdigital/dis-search-test-bed/models"
+)
+
+// Table prints rows as aligned columns in the terminal
+type Table struct {
+	headers []string
+	rows    [][]string
+}
+
+// NewTable creates a table with the given column headers
+func NewTable(headers ...string) *Table {
+	return &Table{headers: headers}
+}
+
+// AddRow appends a row; missing cells are left blank
+func (t *Table) AddRow(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+// Print writes the table to the status output (stdout unless SetOutput
+// changed it)
+func (t *Table) Print() error {
+	return t.Write(out)
+}
+
+// Write writes the table to w with a rule under the headers
+func (t *Table) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	rule := make([]string, len(t.headers))
+	for i, h := range t.headers {
+		rule[i] = strings.Repeat("-", len([]rune(h)))
+	}
+
+	lines := append([][]string{t.headers, rule}, t.rows...)
+	for _, cells := range lines {
+		if _, err := fmt.Fprintln(tw, strings.Join(cells, "\t")); err != nil {
+			return fmt.Errorf("write table row: %w", err)
+		}
+	}
+
+	return tw.Flush()
+}
+
+// Truncate flattens s onto one line and shortens it to at most max
+// characters, marking the cut with an ellipsis. It counts runes rather than
+// bytes and never cuts between a letter and its combining marks.
+func Truncate(s string, max int) string {
+	s = models.SingleLine(s)
+	runes := []rune(s)
+	if max <= 0 || len(runes) <= max {
+		return s
+	}
+	if max == 1 {
+		return "…"
+	}
+
+	cut := max - 1
+	for cut > 0 && unicode.Is(unicode.Mn, runes[cut]) {
+		cut--
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), unicode.IsSpace) + "…"
+}
@@ -0,0 +1,29 @@
+package ui
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		max  int
+		want string
+	}{
+		{"short", "Births", 10, "Births"},
+		{"ascii", "Consumer price inflation", 10, "Consumer…"},
+		{"multibyte", "Ystadegau’r Gymraeg", 12, "Ystadegau’r…"},
+		{"right to left", "مؤشر أسعار المستهلك", 5, "مؤشر…"},
+		{"combining mark kept with letter", "Cafe\u0301 prices", 5, "Caf…"},
+		{"line breaks", "Labour\nmarket", 20, "Labour market"},
+		{"one", "Births", 1, "…"},
+		{"disabled", "Births", 0, "Births"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Truncate(tt.s, tt.max); got != tt.want {
+				t.Errorf("Truncate(%q, %d) = %q, want %q", tt.s, tt.max, got, tt.want)
+			}
+		})
+	}
+}